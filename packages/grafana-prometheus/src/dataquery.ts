@@ -18,6 +18,10 @@ export interface Prometheus extends common.DataQuery {
    * Execute an additional query to identify interesting raw samples relevant for the given expr
    */
   exemplar?: boolean;
+  /**
+   * Maximum number of exemplars returned for the query after sampling and deduplication, defaults to 1000
+   */
+  exemplarLimit?: number;
   /**
    * The actual expression/query that will be evaluated by Prometheus
    */
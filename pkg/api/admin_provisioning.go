@@ -85,3 +85,26 @@ func (hs *HTTPServer) AdminProvisioningReloadAlerting(c *contextmodel.ReqContext
 	}
 	return response.Success("Alerting config reloaded")
 }
+
+// swagger:route POST /admin/provisioning/access-control/reload admin_provisioning adminProvisioningReloadAccessControl
+//
+// Reload access control provisioning configurations.
+//
+// Reloads the provisioning config files for custom roles and their assignments to teams. It won’t return until the new provisioned entities are already stored in the database.
+// You need to have a permission with action `provisioning:reload` and scope `provisioners:accesscontrol`.
+//
+// Security:
+// - basic:
+//
+// Responses:
+// 200: okResponse
+// 401: unauthorisedError
+// 403: forbiddenError
+// 500: internalServerError
+func (hs *HTTPServer) AdminProvisioningReloadAccessControl(c *contextmodel.ReqContext) response.Response {
+	err := hs.ProvisioningService.ProvisionAccessControl(c.Req.Context())
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to reload access control config", err)
+	}
+	return response.Success("Access control config reloaded")
+}
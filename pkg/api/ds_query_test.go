@@ -20,6 +20,7 @@ import (
 	"github.com/grafana/grafana/pkg/plugins/backendplugin"
 	pluginClient "github.com/grafana/grafana/pkg/plugins/manager/client"
 	"github.com/grafana/grafana/pkg/plugins/manager/registry"
+	"github.com/grafana/grafana/pkg/services/anonymous/anontest"
 	"github.com/grafana/grafana/pkg/services/datasources"
 	fakeDatasources "github.com/grafana/grafana/pkg/services/datasources/fakes"
 	"github.com/grafana/grafana/pkg/services/pluginsintegration/pluginconfig"
@@ -86,6 +87,22 @@ func TestAPIEndpoint_Metrics_QueryMetricsV2(t *testing.T) {
 	})
 }
 
+func TestAPIEndpoint_Metrics_AnonymousQueryLimit(t *testing.T) {
+	server := SetupAPITestServer(t, func(hs *HTTPServer) {
+		hs.anonService = &anontest.FakeService{ExpectedError: errutil.TooManyRequests("anonymous.query-rate-limit-reached").Errorf("limit reached")}
+		hs.QuotaService = quotatest.New(false, nil)
+	})
+
+	t.Run("Status code is 429 when the anonymous device reached its query rate limit", func(t *testing.T) {
+		req := server.NewPostRequest("/api/ds/query", strings.NewReader(reqValid))
+		webtest.RequestWithSignedInUser(req, &user.SignedInUser{IsAnonymous: true, OrgID: 1, Permissions: map[int64]map[string][]string{1: {datasources.ActionQuery: []string{datasources.ScopeAll}}}})
+		resp, err := server.SendJSON(req)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+		require.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	})
+}
+
 var reqValid = `{
 	"from": "",
 	"to": "",
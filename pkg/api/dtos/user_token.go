@@ -15,3 +15,41 @@ type UserToken struct {
 	CreatedAt              time.Time `json:"createdAt"`
 	SeenAt                 time.Time `json:"seenAt"`
 }
+
+// UserSession is a session of a user, its metadata is derived from the user agent and never from the client location
+type UserSession struct {
+	Id                     int64     `json:"id"`
+	UserId                 int64     `json:"userId"`
+	IsActive               bool      `json:"isActive"`
+	ClientIp               string    `json:"clientIp"`
+	ClientType             string    `json:"clientType"`
+	Device                 string    `json:"device"`
+	OperatingSystem        string    `json:"os"`
+	OperatingSystemVersion string    `json:"osVersion"`
+	Browser                string    `json:"browser"`
+	BrowserVersion         string    `json:"browserVersion"`
+	AuthModule             string    `json:"authModule"`
+	CreatedAt              time.Time `json:"createdAt"`
+	SeenAt                 time.Time `json:"seenAt"`
+	RotatedAt              time.Time `json:"rotatedAt"`
+	IdleSeconds            int64     `json:"idleSeconds"`
+}
+
+type SearchUserSessionsResult struct {
+	TotalCount int64          `json:"totalCount"`
+	Sessions   []*UserSession `json:"sessions"`
+	Page       int            `json:"page"`
+	PerPage    int            `json:"perPage"`
+}
+
+// RevokeUserSessionsCmd revokes the sessions matching all the given criteria, at least one criterion is required
+type RevokeUserSessionsCmd struct {
+	SessionIds []int64 `json:"sessionIds"`
+	OrgId      int64   `json:"orgId"`
+	UserId     int64   `json:"userId"`
+	// IdleFor is a duration, e.g. 1h or 7d
+	IdleFor    string `json:"idleFor"`
+	AuthModule string `json:"authModule"`
+	// ClientType is browser, mobile or other
+	ClientType string `json:"clientType"`
+}
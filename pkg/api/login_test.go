@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -24,6 +25,7 @@ import (
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/login/social"
 	"github.com/grafana/grafana/pkg/models/usertoken"
+	"github.com/grafana/grafana/pkg/services/auth"
 	"github.com/grafana/grafana/pkg/services/auth/authtest"
 	"github.com/grafana/grafana/pkg/services/authn"
 	"github.com/grafana/grafana/pkg/services/authn/authntest"
@@ -40,6 +42,7 @@ import (
 	secretsManager "github.com/grafana/grafana/pkg/services/secrets/manager"
 	"github.com/grafana/grafana/pkg/services/user"
 	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/web"
 )
 
 const loginCookieName = "grafana_session"
@@ -652,6 +655,48 @@ func setupAuthProxyLoginTest(t *testing.T, enableLoginToken bool) *scenarioConte
 	return sc
 }
 
+func TestLoginUserWithUser_ClientIP(t *testing.T) {
+	_, proxies, err := net.ParseCIDR("10.0.0.0/8")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name           string
+		trustedProxies []*net.IPNet
+		expectedIP     string
+	}{
+		{
+			name:       "should ignore the forwarded IP of an untrusted client",
+			expectedIP: "10.0.0.1",
+		},
+		{
+			name:           "should use the forwarded IP of a trusted proxy",
+			trustedProxies: []*net.IPNet{proxies},
+			expectedIP:     "192.168.1.1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := setting.NewCfg()
+			cfg.TrustedProxies = tt.trustedProxies
+			var clientIP net.IP
+			tokenService := authtest.NewFakeUserAuthTokenService()
+			tokenService.CreateTokenProvider = func(ctx context.Context, cmd *auth.CreateTokenCommand) (*auth.UserToken, error) {
+				clientIP = cmd.ClientIP
+				return &auth.UserToken{}, nil
+			}
+			hs := &HTTPServer{Cfg: cfg, AuthTokenService: tokenService, log: log.New("test")}
+
+			req := httptest.NewRequest(http.MethodGet, "/invite/code", nil)
+			req.RemoteAddr = "10.0.0.1:1234"
+			req.Header.Set("X-Forwarded-For", "192.168.1.1")
+			c := &contextmodel.ReqContext{Context: &web.Context{Req: req, Resp: web.NewResponseWriter(req.Method, httptest.NewRecorder())}}
+
+			require.NoError(t, hs.loginUserWithUser(&user.User{ID: 1}, c))
+			assert.Equal(t, tt.expectedIP, clientIP.String())
+		})
+	}
+}
+
 func TestLogoutSaml(t *testing.T) {
 	fakeSetIndexViewData(t)
 	fakeViewIndex(t)
@@ -0,0 +1,427 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
+	"github.com/ua-parser/uap-go/uaparser"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/services/auth"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/util"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+const (
+	sessionClientTypeBrowser = "browser"
+	sessionClientTypeMobile  = "mobile"
+	sessionClientTypeOther   = "other"
+
+	// userSessionBatchSize is the number of tokens read at once when filtering them by client type
+	userSessionBatchSize = 1000
+)
+
+// userSessionFilter filters the active sessions, the client type is derived from the user agent
+// so it is filtered after querying the tokens
+type userSessionFilter struct {
+	query      auth.SearchUserTokensQuery
+	clientType string
+}
+
+func newUserSessionFilter(orgID, userID int64, idleFor, authModule, clientType string) (*userSessionFilter, error) {
+	filter := &userSessionFilter{
+		query:      auth.SearchUserTokensQuery{OrgID: orgID, UserID: userID, AuthModule: authModule},
+		clientType: clientType,
+	}
+
+	if idleFor != "" {
+		d, err := gtime.ParseDuration(idleFor)
+		if err != nil || d < 0 {
+			return nil, errors.New("idleFor is invalid")
+		}
+		filter.query.IdleSince = time.Now().Add(-d)
+	}
+
+	switch clientType {
+	case "", sessionClientTypeBrowser, sessionClientTypeMobile, sessionClientTypeOther:
+	default:
+		return nil, errors.New("clientType must be one of browser, mobile or other")
+	}
+
+	return filter, nil
+}
+
+func (f *userSessionFilter) isEmpty() bool {
+	q := f.query
+	return q.OrgID == 0 && q.UserID == 0 && q.IdleSince.IsZero() && q.AuthModule == "" && len(q.TokenIDs) == 0 && f.clientType == ""
+}
+
+// swagger:route GET /admin/users/{user_id}/sessions admin_users adminGetUserSessions
+//
+// Return the active sessions of the user, filtered by idle time, auth module and client type.
+// If you are running Grafana Enterprise and have Fine-grained access control enabled, you need to have a permission with action `users.authtoken:list` and scope `global.users:*`.
+//
+// Security:
+// - basic:
+//
+// Responses:
+// 200: adminSearchUserSessionsResponse
+// 400: badRequestError
+// 401: unauthorisedError
+// 403: forbiddenError
+// 404: notFoundError
+// 500: internalServerError
+func (hs *HTTPServer) AdminGetUserSessions(c *contextmodel.ReqContext) response.Response {
+	userID, err := strconv.ParseInt(web.Params(c.Req)[":id"], 10, 64)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "id is invalid", err)
+	}
+
+	if _, err := hs.userService.GetByID(c.Req.Context(), &user.GetUserByIDQuery{ID: userID}); err != nil {
+		if errors.Is(err, user.ErrUserNotFound) {
+			return response.Error(http.StatusNotFound, "User not found", err)
+		}
+		return response.Error(http.StatusInternalServerError, "Failed to get user", err)
+	}
+
+	filter, err := newUserSessionFilter(0, userID, c.Query("idleFor"), c.Query("authModule"), c.Query("clientType"))
+	if err != nil {
+		return response.Error(http.StatusBadRequest, err.Error(), err)
+	}
+
+	return hs.searchUserSessions(c, filter)
+}
+
+// swagger:route GET /admin/sessions admin_users adminSearchUserSessions
+//
+// Search the active sessions of all users, filtered by organization, user, idle time, auth module and client type.
+// If you are running Grafana Enterprise and have Fine-grained access control enabled, you need to have a permission with action `users.authtoken:list` and scope `global.users:*`.
+//
+// Security:
+// - basic:
+//
+// Responses:
+// 200: adminSearchUserSessionsResponse
+// 400: badRequestError
+// 401: unauthorisedError
+// 403: forbiddenError
+// 500: internalServerError
+func (hs *HTTPServer) AdminSearchUserSessions(c *contextmodel.ReqContext) response.Response {
+	filter, err := newUserSessionFilter(c.QueryInt64("orgId"), c.QueryInt64("userId"), c.Query("idleFor"), c.Query("authModule"), c.Query("clientType"))
+	if err != nil {
+		return response.Error(http.StatusBadRequest, err.Error(), err)
+	}
+
+	return hs.searchUserSessions(c, filter)
+}
+
+// swagger:route GET /org/sessions org getOrgUserSessions
+//
+// Search the active sessions of the users of the current organization, filtered by user, idle time, auth module and client type.
+// If you are running Grafana Enterprise and have Fine-grained access control enabled, you need to have a permission with action `org.users:read` and scope `users:*`.
+//
+// Security:
+// - basic:
+//
+// Responses:
+// 200: adminSearchUserSessionsResponse
+// 400: badRequestError
+// 401: unauthorisedError
+// 403: forbiddenError
+// 500: internalServerError
+func (hs *HTTPServer) GetOrgUserSessions(c *contextmodel.ReqContext) response.Response {
+	filter, err := newUserSessionFilter(c.SignedInUser.GetOrgID(), c.QueryInt64("userId"), c.Query("idleFor"), c.Query("authModule"), c.Query("clientType"))
+	if err != nil {
+		return response.Error(http.StatusBadRequest, err.Error(), err)
+	}
+
+	return hs.searchUserSessions(c, filter)
+}
+
+// swagger:route POST /admin/sessions/revoke admin_users adminRevokeUserSessions
+//
+// Revoke the sessions matching all the given criteria.
+//
+// At least one criterion is required. The session of the signed in user is never revoked.
+// If you are running Grafana Enterprise and have Fine-grained access control enabled, you need to have a permission with action `users.authtoken:update` and scope `global.users:*`.
+//
+// Security:
+// - basic:
+//
+// Responses:
+// 200: okResponse
+// 400: badRequestError
+// 401: unauthorisedError
+// 403: forbiddenError
+// 500: internalServerError
+func (hs *HTTPServer) AdminRevokeUserSessions(c *contextmodel.ReqContext) response.Response {
+	cmd := dtos.RevokeUserSessionsCmd{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+
+	filter, err := newUserSessionFilter(cmd.OrgId, cmd.UserId, cmd.IdleFor, cmd.AuthModule, cmd.ClientType)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, err.Error(), err)
+	}
+	filter.query.TokenIDs = cmd.SessionIds
+	if filter.isEmpty() {
+		return response.Error(http.StatusBadRequest, "at least one of sessionIds, orgId, userId, idleFor, authModule or clientType is required", nil)
+	}
+
+	_, tokens, err := hs.findUserSessions(c, filter, 1, 0)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to search user sessions", err)
+	}
+
+	revoked := 0
+	for _, token := range tokens {
+		if c.UserToken != nil && c.UserToken.Id == token.Id {
+			continue
+		}
+		if err := hs.AuthTokenService.RevokeToken(c.Req.Context(), token, false); err != nil {
+			if errors.Is(err, auth.ErrUserTokenNotFound) {
+				continue
+			}
+			return response.Error(http.StatusInternalServerError, "Failed to revoke user session", err)
+		}
+		revoked++
+	}
+
+	return response.JSON(http.StatusOK, util.DynMap{
+		"message":      "User sessions revoked",
+		"revokedCount": revoked,
+	})
+}
+
+func (hs *HTTPServer) searchUserSessions(c *contextmodel.ReqContext, filter *userSessionFilter) response.Response {
+	perPage := c.QueryInt("perpage")
+	if perPage <= 0 {
+		perPage = 1000
+	}
+	page := c.QueryInt("page")
+	if page < 1 {
+		page = 1
+	}
+
+	totalCount, tokens, err := hs.findUserSessions(c, filter, page, perPage)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to search user sessions", err)
+	}
+
+	// the auth modules of the page are read at once
+	externalSessionIDs := []int64{}
+	for _, token := range tokens {
+		if token.ExternalSessionId != 0 {
+			externalSessionIDs = append(externalSessionIDs, token.ExternalSessionId)
+		}
+	}
+	authModules := make(map[int64]string, len(externalSessionIDs))
+	if len(externalSessionIDs) > 0 {
+		externalSessions, err := hs.AuthTokenService.FindExternalSessions(c.Req.Context(), &auth.ListExternalSessionQuery{IDs: externalSessionIDs})
+		if err != nil {
+			return response.Error(http.StatusInternalServerError, "Failed to get the external sessions", err)
+		}
+		for _, externalSession := range externalSessions {
+			authModules[externalSession.ID] = externalSession.AuthModule
+		}
+	}
+
+	result := dtos.SearchUserSessionsResult{
+		TotalCount: totalCount,
+		Sessions:   make([]*dtos.UserSession, 0, len(tokens)),
+		Page:       page,
+		PerPage:    perPage,
+	}
+
+	parser := uaparser.NewFromSaved()
+	now := time.Now()
+	for _, token := range tokens {
+		client := parseUserAgent(parser, token.UserAgent)
+
+		createdAt := time.Unix(token.CreatedAt, 0)
+		seenAt := time.Unix(token.SeenAt, 0)
+		if token.SeenAt == 0 {
+			seenAt = createdAt
+		}
+		rotatedAt := time.Unix(token.RotatedAt, 0)
+
+		result.Sessions = append(result.Sessions, &dtos.UserSession{
+			Id:                     token.Id,
+			UserId:                 token.UserId,
+			IsActive:               c.UserToken != nil && c.UserToken.Id == token.Id,
+			ClientIp:               token.ClientIp,
+			ClientType:             client.clientType,
+			Device:                 client.device,
+			OperatingSystem:        client.os,
+			OperatingSystemVersion: client.osVersion,
+			Browser:                client.browser,
+			BrowserVersion:         client.browserVersion,
+			AuthModule:             authModules[token.ExternalSessionId],
+			CreatedAt:              createdAt,
+			SeenAt:                 seenAt,
+			RotatedAt:              rotatedAt,
+			IdleSeconds:            int64(now.Sub(rotatedAt).Seconds()),
+		})
+	}
+
+	return response.JSON(http.StatusOK, result)
+}
+
+// findUserSessions returns the total count and the page of the sessions matching the filter, all of them when
+// perPage is zero. The tokens are filtered and paged by the database, except for the client type which is derived
+// from the user agent: the tokens are then read in batches and only the requested page is kept.
+func (hs *HTTPServer) findUserSessions(c *contextmodel.ReqContext, filter *userSessionFilter, page, perPage int) (int64, []*auth.UserToken, error) {
+	query := filter.query
+	if filter.clientType == "" {
+		query.Page, query.Limit = page, perPage
+		result, err := hs.AuthTokenService.SearchUserTokens(c.Req.Context(), &query)
+		if err != nil {
+			return 0, nil, err
+		}
+		return result.TotalCount, result.Tokens, nil
+	}
+
+	parser := uaparser.NewFromSaved()
+	start := (page - 1) * perPage
+	var count int64
+	tokens := []*auth.UserToken{}
+	query.Limit = userSessionBatchSize
+	for query.Page = 1; ; query.Page++ {
+		result, err := hs.AuthTokenService.SearchUserTokens(c.Req.Context(), &query)
+		if err != nil {
+			return 0, nil, err
+		}
+		for _, token := range result.Tokens {
+			if parseUserAgent(parser, token.UserAgent).clientType != filter.clientType {
+				continue
+			}
+			if count >= int64(start) && (perPage == 0 || len(tokens) < perPage) {
+				tokens = append(tokens, token)
+			}
+			count++
+		}
+		if len(result.Tokens) < userSessionBatchSize {
+			return count, tokens, nil
+		}
+	}
+}
+
+type userAgentInfo struct {
+	clientType     string
+	device         string
+	os             string
+	osVersion      string
+	browser        string
+	browserVersion string
+}
+
+func parseUserAgent(parser *uaparser.Parser, userAgent string) userAgentInfo {
+	client := parser.Parse(userAgent)
+
+	info := userAgentInfo{
+		clientType: sessionClientTypeBrowser,
+		device:     client.Device.ToString(),
+		os:         client.Os.Family,
+		browser:    client.UserAgent.Family,
+	}
+
+	if client.Os.Major != "" {
+		info.osVersion = client.Os.Major
+
+		if client.Os.Minor != "" {
+			info.osVersion = info.osVersion + "." + client.Os.Minor
+		}
+	}
+
+	if client.UserAgent.Major != "" {
+		info.browserVersion = client.UserAgent.Major
+
+		if client.UserAgent.Minor != "" {
+			info.browserVersion = info.browserVersion + "." + client.UserAgent.Minor
+		}
+	}
+
+	switch {
+	case client.Os.Family == "iOS" || client.Os.Family == "Android":
+		info.clientType = sessionClientTypeMobile
+	// API clients, scripts and crawlers
+	case client.Os.Family == "Other" || client.UserAgent.Family == "Other" || client.Device.Family == "Spider":
+		info.clientType = sessionClientTypeOther
+	}
+
+	return info
+}
+
+// swagger:parameters adminGetUserSessions
+type AdminGetUserSessionsParams struct {
+	// in:path
+	// required:true
+	UserID int64 `json:"user_id"`
+	// in:query
+	IdleFor string `json:"idleFor"`
+	// in:query
+	AuthModule string `json:"authModule"`
+	// in:query
+	// enum: browser,mobile,other
+	ClientType string `json:"clientType"`
+	// in:query
+	Page int `json:"page"`
+	// in:query
+	PerPage int `json:"perpage"`
+}
+
+// swagger:parameters adminSearchUserSessions
+type AdminSearchUserSessionsParams struct {
+	// in:query
+	OrgID int64 `json:"orgId"`
+	// in:query
+	UserID int64 `json:"userId"`
+	// in:query
+	IdleFor string `json:"idleFor"`
+	// in:query
+	AuthModule string `json:"authModule"`
+	// in:query
+	// enum: browser,mobile,other
+	ClientType string `json:"clientType"`
+	// in:query
+	Page int `json:"page"`
+	// in:query
+	PerPage int `json:"perpage"`
+}
+
+// swagger:parameters getOrgUserSessions
+type GetOrgUserSessionsParams struct {
+	// in:query
+	UserID int64 `json:"userId"`
+	// in:query
+	IdleFor string `json:"idleFor"`
+	// in:query
+	AuthModule string `json:"authModule"`
+	// in:query
+	// enum: browser,mobile,other
+	ClientType string `json:"clientType"`
+	// in:query
+	Page int `json:"page"`
+	// in:query
+	PerPage int `json:"perpage"`
+}
+
+// swagger:parameters adminRevokeUserSessions
+type AdminRevokeUserSessionsParams struct {
+	// in:body
+	// required:true
+	Body dtos.RevokeUserSessionsCmd `json:"body"`
+}
+
+// swagger:response adminSearchUserSessionsResponse
+type AdminSearchUserSessionsResponse struct {
+	// in:body
+	Body dtos.SearchUserSessionsResult `json:"body"`
+}
@@ -0,0 +1,266 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ua-parser/uap-go/uaparser"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/auth"
+	"github.com/grafana/grafana/pkg/services/auth/authtest"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/web/webtest"
+)
+
+const (
+	testDesktopUserAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"
+	testMobileUserAgent  = "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.0 Mobile/15E148 Safari/604.1"
+)
+
+// searchUserTokens pages the tokens like the database and records the queries
+func searchUserTokens(tokens []*auth.UserToken, queries *[]auth.SearchUserTokensQuery) func(ctx context.Context, q *auth.SearchUserTokensQuery) (*auth.SearchUserTokensResult, error) {
+	return func(ctx context.Context, q *auth.SearchUserTokensQuery) (*auth.SearchUserTokensResult, error) {
+		*queries = append(*queries, *q)
+		matching := []*auth.UserToken{}
+		for _, token := range tokens {
+			if len(q.TokenIDs) == 0 || slices.Contains(q.TokenIDs, token.Id) {
+				matching = append(matching, token)
+			}
+		}
+		result := &auth.SearchUserTokensResult{TotalCount: int64(len(matching)), Tokens: matching}
+		if q.Limit > 0 {
+			start := min(q.Limit*(q.Page-1), len(matching))
+			result.Tokens = matching[start:min(start+q.Limit, len(matching))]
+		}
+		return result, nil
+	}
+}
+
+func TestHTTPServer_AdminSearchUserSessions(t *testing.T) {
+	tokens := []*auth.UserToken{
+		{Id: 1, UserId: 1, UserAgent: testDesktopUserAgent},
+		{Id: 2, UserId: 1, UserAgent: testMobileUserAgent, ExternalSessionId: 10},
+		{Id: 3, UserId: 2, UserAgent: "curl/8.4.0", ExternalSessionId: 11},
+	}
+
+	type testCase struct {
+		desc          string
+		url           string
+		permissions   []accesscontrol.Permission
+		expectedCode  int
+		expectedQuery *auth.SearchUserTokensQuery
+		expectedIDs   []int64
+		expectedTotal int64
+	}
+
+	orgPermission := []accesscontrol.Permission{{Action: accesscontrol.ActionOrgUsersRead, Scope: accesscontrol.ScopeUsersAll}}
+	listPermission := []accesscontrol.Permission{{Action: accesscontrol.ActionUsersAuthTokenList, Scope: accesscontrol.ScopeGlobalUsersAll}}
+
+	tests := []testCase{
+		{
+			desc:         "should fail without permission",
+			url:          "/api/admin/sessions",
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			desc:          "should list all the sessions",
+			url:           "/api/admin/sessions",
+			permissions:   listPermission,
+			expectedCode:  http.StatusOK,
+			expectedQuery: &auth.SearchUserTokensQuery{Limit: 1000, Page: 1},
+			expectedIDs:   []int64{1, 2, 3},
+			expectedTotal: 3,
+		},
+		{
+			desc:          "should pass the filters and filter by client type in batches",
+			url:           "/api/admin/sessions?orgId=2&userId=1&authModule=oauth_github&clientType=mobile",
+			permissions:   listPermission,
+			expectedCode:  http.StatusOK,
+			expectedQuery: &auth.SearchUserTokensQuery{OrgID: 2, UserID: 1, AuthModule: "oauth_github", Limit: userSessionBatchSize, Page: 1},
+			expectedIDs:   []int64{2},
+			expectedTotal: 1,
+		},
+		{
+			desc:          "should paginate the sessions in the query",
+			url:           "/api/admin/sessions?perpage=2&page=2",
+			permissions:   listPermission,
+			expectedCode:  http.StatusOK,
+			expectedQuery: &auth.SearchUserTokensQuery{Limit: 2, Page: 2},
+			expectedIDs:   []int64{3},
+			expectedTotal: 3,
+		},
+		{
+			desc:         "should fail to list the sessions of the organization without permission",
+			url:          "/api/org/sessions",
+			permissions:  listPermission,
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			desc:          "should list the sessions of the current organization",
+			url:           "/api/org/sessions?orgId=2&userId=1",
+			permissions:   orgPermission,
+			expectedCode:  http.StatusOK,
+			expectedQuery: &auth.SearchUserTokensQuery{OrgID: 1, UserID: 1, Limit: 1000, Page: 1},
+			expectedIDs:   []int64{1, 2, 3},
+			expectedTotal: 3,
+		},
+		{
+			desc:         "should fail on an invalid client type",
+			url:          "/api/admin/sessions?clientType=tablet",
+			permissions:  listPermission,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			desc:         "should fail on an invalid idle time",
+			url:          "/api/admin/sessions?idleFor=soon",
+			permissions:  listPermission,
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			var queries []auth.SearchUserTokensQuery
+			var externalSessionQueries []*auth.ListExternalSessionQuery
+			server := SetupAPITestServer(t, func(hs *HTTPServer) {
+				hs.Cfg = setting.NewCfg()
+				hs.AuthTokenService = &authtest.FakeUserAuthTokenService{
+					SearchUserTokensProvider: searchUserTokens(tokens, &queries),
+					FindExternalSessionsProvider: func(ctx context.Context, q *auth.ListExternalSessionQuery) ([]*auth.ExternalSession, error) {
+						externalSessionQueries = append(externalSessionQueries, q)
+						return []*auth.ExternalSession{{ID: 10, AuthModule: "oauth_github"}, {ID: 11, AuthModule: "ldap"}}, nil
+					},
+				}
+			})
+
+			orgID := int64(accesscontrol.GlobalOrgID)
+			if strings.HasPrefix(tt.url, "/api/org/") {
+				orgID = 1
+			}
+			res, err := server.Send(webtest.RequestWithSignedInUser(server.NewGetRequest(tt.url), authedUserWithPermissions(1, orgID, tt.permissions)))
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedCode, res.StatusCode)
+
+			if tt.expectedCode == http.StatusOK {
+				require.Len(t, queries, 1)
+				assert.Equal(t, *tt.expectedQuery, queries[0])
+
+				var result dtos.SearchUserSessionsResult
+				require.NoError(t, json.NewDecoder(res.Body).Decode(&result))
+				ids := make([]int64, 0, len(result.Sessions))
+				authModules := map[int64]string{}
+				for _, session := range result.Sessions {
+					ids = append(ids, session.Id)
+					authModules[session.Id] = session.AuthModule
+				}
+				assert.Equal(t, tt.expectedIDs, ids)
+				assert.Equal(t, tt.expectedTotal, result.TotalCount)
+
+				// the auth modules of the page are read with a single query
+				require.Len(t, externalSessionQueries, 1)
+				if slices.Contains(ids, 2) {
+					assert.Equal(t, "oauth_github", authModules[2])
+				}
+			}
+
+			require.NoError(t, res.Body.Close())
+		})
+	}
+}
+
+func TestHTTPServer_AdminRevokeUserSessions(t *testing.T) {
+	tokens := []*auth.UserToken{
+		{Id: 1, UserId: 1, UserAgent: testDesktopUserAgent},
+		{Id: 2, UserId: 1, UserAgent: testMobileUserAgent},
+		{Id: 3, UserId: 2, UserAgent: testMobileUserAgent},
+	}
+
+	type testCase struct {
+		desc            string
+		body            string
+		permissions     []accesscontrol.Permission
+		expectedCode    int
+		expectedRevoked []int64
+	}
+
+	updatePermission := []accesscontrol.Permission{{Action: accesscontrol.ActionUsersAuthTokenUpdate, Scope: accesscontrol.ScopeGlobalUsersAll}}
+
+	tests := []testCase{
+		{
+			desc:         "should fail without permission",
+			body:         `{"userId": 1}`,
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			desc:         "should fail without criteria",
+			body:         `{}`,
+			permissions:  updatePermission,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			desc:            "should revoke the given sessions",
+			body:            `{"sessionIds": [1, 3]}`,
+			permissions:     updatePermission,
+			expectedCode:    http.StatusOK,
+			expectedRevoked: []int64{1, 3},
+		},
+		{
+			desc:            "should revoke the sessions of a client type",
+			body:            `{"clientType": "mobile"}`,
+			permissions:     updatePermission,
+			expectedCode:    http.StatusOK,
+			expectedRevoked: []int64{2, 3},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			revoked := []int64{}
+			var queries []auth.SearchUserTokensQuery
+			server := SetupAPITestServer(t, func(hs *HTTPServer) {
+				hs.Cfg = setting.NewCfg()
+				hs.AuthTokenService = &authtest.FakeUserAuthTokenService{
+					SearchUserTokensProvider: searchUserTokens(tokens, &queries),
+					RevokeTokenProvider: func(ctx context.Context, token *auth.UserToken, soft bool) error {
+						revoked = append(revoked, token.Id)
+						return nil
+					},
+				}
+			})
+
+			req := server.NewRequest(http.MethodPost, "/api/admin/sessions/revoke", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			res, err := server.Send(webtest.RequestWithSignedInUser(req, authedUserWithPermissions(1, accesscontrol.GlobalOrgID, tt.permissions)))
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedCode, res.StatusCode)
+			if tt.expectedCode == http.StatusOK {
+				assert.Equal(t, tt.expectedRevoked, revoked)
+			}
+
+			require.NoError(t, res.Body.Close())
+		})
+	}
+}
+
+func TestParseUserAgentClientType(t *testing.T) {
+	tests := map[string]string{
+		testDesktopUserAgent: sessionClientTypeBrowser,
+		testMobileUserAgent:  sessionClientTypeMobile,
+		"Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Mobile Safari/537.36": sessionClientTypeMobile,
+		"curl/8.4.0": sessionClientTypeOther,
+		"":           sessionClientTypeOther,
+	}
+
+	parser := uaparser.NewFromSaved()
+	for userAgent, expected := range tests {
+		assert.Equal(t, expected, parseUserAgent(parser, userAgent).clientType, userAgent)
+	}
+}
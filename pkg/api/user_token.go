@@ -3,6 +3,7 @@ package api
 import (
 	"context"
 	"errors"
+	"net"
 	"net/http"
 	"time"
 
@@ -11,7 +12,6 @@ import (
 	claims "github.com/grafana/authlib/types"
 	"github.com/grafana/grafana/pkg/api/dtos"
 	"github.com/grafana/grafana/pkg/api/response"
-	"github.com/grafana/grafana/pkg/infra/network"
 	"github.com/grafana/grafana/pkg/services/auth"
 	"github.com/grafana/grafana/pkg/services/authn"
 	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
@@ -128,10 +128,7 @@ func (hs *HTTPServer) RotateUserAuthToken(c *contextmodel.ReqContext) response.R
 
 func (hs *HTTPServer) rotateToken(c *contextmodel.ReqContext) error {
 	token := c.GetCookie(hs.Cfg.LoginCookieName)
-	ip, err := network.GetIPFromAddress(c.RemoteAddr())
-	if err != nil {
-		hs.log.Debug("Failed to get IP from client address", "addr", c.RemoteAddr())
-	}
+	ip := net.ParseIP(web.ClientIP(c.Req, hs.Cfg.TrustedProxies))
 
 	res, err := hs.AuthTokenService.RotateToken(c.Req.Context(), auth.RotateCommand{
 		UnHashedToken: token,
@@ -191,32 +188,14 @@ func (hs *HTTPServer) getUserAuthTokensInternal(c *contextmodel.ReqContext, user
 	}
 
 	result := []*dtos.UserToken{}
+	parser := uaparser.NewFromSaved()
 	for _, token := range tokens {
 		isActive := false
 		if c.UserToken != nil && c.UserToken.Id == token.Id {
 			isActive = true
 		}
 
-		parser := uaparser.NewFromSaved()
-		client := parser.Parse(token.UserAgent)
-
-		osVersion := ""
-		if client.Os.Major != "" {
-			osVersion = client.Os.Major
-
-			if client.Os.Minor != "" {
-				osVersion = osVersion + "." + client.Os.Minor
-			}
-		}
-
-		browserVersion := ""
-		if client.UserAgent.Major != "" {
-			browserVersion = client.UserAgent.Major
-
-			if client.UserAgent.Minor != "" {
-				browserVersion = browserVersion + "." + client.UserAgent.Minor
-			}
-		}
+		client := parseUserAgent(parser, token.UserAgent)
 
 		createdAt := time.Unix(token.CreatedAt, 0)
 		seenAt := time.Unix(token.SeenAt, 0)
@@ -235,11 +214,11 @@ func (hs *HTTPServer) getUserAuthTokensInternal(c *contextmodel.ReqContext, user
 			Id:                     token.Id,
 			IsActive:               isActive,
 			ClientIp:               token.ClientIp,
-			Device:                 client.Device.ToString(),
-			OperatingSystem:        client.Os.Family,
-			OperatingSystemVersion: osVersion,
-			Browser:                client.UserAgent.Family,
-			BrowserVersion:         browserVersion,
+			Device:                 client.device,
+			OperatingSystem:        client.os,
+			OperatingSystemVersion: client.osVersion,
+			Browser:                client.browser,
+			BrowserVersion:         client.browserVersion,
 			AuthModule:             authModule,
 			CreatedAt:              createdAt,
 			SeenAt:                 seenAt,
@@ -39,6 +39,11 @@ func (s *stubProvisioning) GetDashboardProvisionerResolvedPath(name string) stri
 	return s.path[name]
 }
 
+// ProvisionAccessControl implements provisioning.ProvisioningService.
+func (s *stubProvisioning) ProvisionAccessControl(ctx context.Context) error {
+	panic("unimplemented")
+}
+
 // ProvisionAlerting implements provisioning.ProvisioningService.
 func (s *stubProvisioning) ProvisionAlerting(ctx context.Context) error {
 	panic("unimplemented")
@@ -39,6 +39,18 @@ type SignUpCompleted struct {
 	Email     string    `json:"email"`
 }
 
+type UserDeleted struct {
+	Timestamp time.Time `json:"timestamp"`
+	Id        int64     `json:"id"`
+	Login     string    `json:"login"`
+}
+
+// TOTPReset is published when an admin removes the second factor of a user
+type TOTPReset struct {
+	Timestamp time.Time `json:"timestamp"`
+	UserID    int64     `json:"userId"`
+}
+
 type UserUpdated struct {
 	Timestamp time.Time `json:"timestamp"`
 	Id        int64     `json:"id"`
@@ -71,6 +83,67 @@ type DataSourceCreated struct {
 	OrgID     int64     `json:"org_id"`
 }
 
+type DataSourceUpdated struct {
+	Timestamp time.Time `json:"timestamp"`
+	Name      string    `json:"name"`
+	ID        int64     `json:"id"`
+	UID       string    `json:"uid"`
+	OrgID     int64     `json:"org_id"`
+}
+
+type DashboardSaved struct {
+	Timestamp time.Time `json:"timestamp"`
+	Title     string    `json:"title"`
+	ID        int64     `json:"id"`
+	UID       string    `json:"uid"`
+	OrgID     int64     `json:"org_id"`
+	Version   int       `json:"version"`
+	IsFolder  bool      `json:"is_folder"`
+}
+
+type DashboardDeleted struct {
+	Timestamp time.Time `json:"timestamp"`
+	Title     string    `json:"title"`
+	ID        int64     `json:"id"`
+	UID       string    `json:"uid"`
+	OrgID     int64     `json:"org_id"`
+	IsFolder  bool      `json:"is_folder"`
+}
+
+// APIKeyCreated is emitted when an API key or a service account token is created
+type APIKeyCreated struct {
+	Timestamp        time.Time `json:"timestamp"`
+	Name             string    `json:"name"`
+	ID               int64     `json:"id"`
+	OrgID            int64     `json:"org_id"`
+	ServiceAccountID *int64    `json:"service_account_id,omitempty"`
+}
+
+// ResourcePermissionChanged is emitted when the permission of a user, a team or a basic role
+// on a resource is set. An empty permission means the permission was removed.
+type ResourcePermissionChanged struct {
+	Timestamp   time.Time `json:"timestamp"`
+	OrgID       int64     `json:"org_id"`
+	Resource    string    `json:"resource"`
+	ResourceID  string    `json:"resource_id"`
+	Permission  string    `json:"permission"`
+	UserID      int64     `json:"user_id,omitempty"`
+	TeamID      int64     `json:"team_id,omitempty"`
+	BuiltInRole string    `json:"builtin_role,omitempty"`
+}
+
+// TeamMembershipSynced is emitted when the team sync adds a user to a team of the team mapping of their
+// identity provider, or removes them from it
+type TeamMembershipSynced struct {
+	Timestamp  time.Time `json:"timestamp"`
+	OrgID      int64     `json:"org_id"`
+	TeamID     int64     `json:"team_id"`
+	TeamName   string    `json:"team_name"`
+	UserID     int64     `json:"user_id"`
+	AuthModule string    `json:"auth_module"`
+	Removed    bool      `json:"removed"`
+}
+
 // FolderFullPathUpdated is emitted when the full path of the folder(s) is updated.
 // For example, when the folder is renamed or moved to another folder.
 // It does not contain the full path of the folders because calculating
@@ -71,6 +71,23 @@ func NewSyslog(sec *ini.Section, format Formatedlogger) *SysLogHandler {
 	return handler
 }
 
+// NewSyslogHandler creates a syslog handler from its settings, returning an error when the syslog daemon can't be reached.
+func NewSyslogHandler(network, address, facility, tag string, format Formatedlogger) (*SysLogHandler, error) {
+	handler := &SysLogHandler{
+		Format:   format,
+		Network:  network,
+		Address:  address,
+		Facility: facility,
+		Tag:      tag,
+	}
+
+	if err := handler.Init(); err != nil {
+		return nil, err
+	}
+	handler.logger = gokitsyslog.NewSyslogLogger(handler.syslog, format, gokitsyslog.PrioritySelectorOption(selector))
+	return handler, nil
+}
+
 func (sw *SysLogHandler) Init() error {
 	// the facility is the origin of the syslog message
 	prio := parseFacility(sw.Facility)
@@ -16,6 +16,10 @@ func NewSyslog(sec *ini.Section, format Formatedlogger) *SysLogHandler {
 	return &SysLogHandler{}
 }
 
+func NewSyslogHandler(network, address, facility, tag string, format Formatedlogger) (*SysLogHandler, error) {
+	return &SysLogHandler{}, nil
+}
+
 func (sw *SysLogHandler) Log(keyvals ...any) error {
 	return nil
 }
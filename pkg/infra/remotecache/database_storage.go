@@ -122,6 +122,51 @@ func (dc *databaseCache) Delete(ctx context.Context, key string) error {
 	})
 }
 
+// CompareAndSwap updates the row only if it still holds the old value, or inserts it if the key is missing.
+// The expired rows are deleted first, as they are missing for Get.
+func (dc *databaseCache) CompareAndSwap(ctx context.Context, key string, old, data []byte, expire time.Duration) (bool, error) {
+	var swapped bool
+	err := dc.SQLStore.WithDbSession(ctx, func(session *db.Session) error {
+		var expiresInSeconds int64
+		if expire != 0 {
+			expiresInSeconds = int64(expire) / int64(time.Second)
+		}
+		now := getTime().Unix()
+
+		if _, err := session.Exec(`DELETE FROM cache_data WHERE cache_key=? AND (? - created_at) >= expires AND expires <> 0`, key, now); err != nil {
+			return err
+		}
+
+		if old == nil {
+			_, err := session.Exec(`INSERT INTO cache_data (cache_key,data,created_at,expires) VALUES(?,?,?,?)`, key, data, now, expiresInSeconds)
+			if err != nil {
+				// somebody else inserted the key
+				if dc.SQLStore.GetDialect().IsUniqueConstraintViolation(err) {
+					return nil
+				}
+				return err
+			}
+			swapped = true
+			return nil
+		}
+
+		res, err := session.Exec(`UPDATE cache_data SET data=?, created_at=?, expires=? WHERE cache_key=? AND data=?`, data, now, expiresInSeconds, key, old)
+		if err != nil {
+			if dc.SQLStore.GetDialect().IsDeadlock(err) {
+				return nil
+			}
+			return err
+		}
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		swapped = affected == 1
+		return nil
+	})
+	return swapped, err
+}
+
 // CacheData is the struct representing the table in the database
 type CacheData struct {
 	CacheKey  string
@@ -1,6 +1,7 @@
 package remotecache
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"time"
@@ -57,6 +58,41 @@ func (s *memcachedStorage) Get(ctx context.Context, key string) ([]byte, error)
 	return memcachedItem.Value, nil
 }
 
+// CompareAndSwap sets the value of the key only if its current value is old, or if the key is missing when old is nil
+func (s *memcachedStorage) CompareAndSwap(ctx context.Context, key string, old, data []byte, expires time.Duration) (bool, error) {
+	var expiresInSeconds int64
+	if expires != 0 {
+		expiresInSeconds = int64(expires) / int64(time.Second)
+	}
+
+	if old == nil {
+		err := s.c.Add(newItem(key, data, int32(expiresInSeconds)))
+		if errors.Is(err, memcache.ErrNotStored) {
+			return false, nil
+		}
+		return err == nil, err
+	}
+
+	// the item keeps the CAS ID of the read value
+	item, err := s.c.Get(key)
+	if errors.Is(err, memcache.ErrCacheMiss) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if !bytes.Equal(item.Value, old) {
+		return false, nil
+	}
+	item.Value = data
+	item.Expiration = int32(expiresInSeconds)
+	err = s.c.CompareAndSwap(item)
+	if errors.Is(err, memcache.ErrCASConflict) || errors.Is(err, memcache.ErrCacheMiss) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
 // Delete delete a key from the cache
 func (s *memcachedStorage) Delete(ctx context.Context, key string) error {
 	return s.c.Delete(key)
@@ -107,6 +107,27 @@ func (s *redisStorage) Get(ctx context.Context, key string) ([]byte, error) {
 	return item, nil
 }
 
+// compareAndSwapScript sets the key to ARGV[2] with an expiry of ARGV[3] milliseconds if its value is ARGV[1]
+var compareAndSwapScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	redis.call("SET", KEYS[1], ARGV[2], "PX", ARGV[3])
+	return 1
+end
+return 0
+`)
+
+// CompareAndSwap sets the value of the key only if its current value is old, or if the key is missing when old is nil
+func (s *redisStorage) CompareAndSwap(ctx context.Context, key string, old, data []byte, expires time.Duration) (bool, error) {
+	if old == nil {
+		return s.c.SetNX(ctx, key, data, expires).Result()
+	}
+	swapped, err := compareAndSwapScript.Run(ctx, s.c, []string{key}, old, data, expires.Milliseconds()).Int()
+	if err != nil {
+		return false, err
+	}
+	return swapped == 1, nil
+}
+
 // Delete delete a key from session.
 func (s *redisStorage) Delete(ctx context.Context, key string) error {
 	cmd := s.c.Del(ctx, key)
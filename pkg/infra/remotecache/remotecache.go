@@ -1,6 +1,7 @@
 package remotecache
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"time"
@@ -71,9 +72,19 @@ type CacheStorage interface {
 	Delete(ctx context.Context, key string) error
 }
 
+// AtomicCacheStorage is a cache storage which can update a value atomically, so that the Grafana instances
+// sharing the cache don't lose the concurrent updates of each other
+type AtomicCacheStorage interface {
+	CacheStorage
+
+	// CompareAndSwap sets the value of the key only if its current value is old, or if the key is missing when
+	// old is nil, and reports if the value was set
+	CompareAndSwap(ctx context.Context, key string, old, value []byte, expire time.Duration) (bool, error)
+}
+
 // RemoteCache allows Grafana to cache data outside its own process
 type RemoteCache struct {
-	client   CacheStorage
+	client   AtomicCacheStorage
 	SQLStore db.DB
 	Cfg      *setting.Cfg
 }
@@ -97,6 +108,15 @@ func (ds *RemoteCache) Delete(ctx context.Context, key string) error {
 	return ds.client.Delete(ctx, key)
 }
 
+// CompareAndSwap sets the value of the key only if its current value is old, or if the key is missing when old is nil
+func (ds *RemoteCache) CompareAndSwap(ctx context.Context, key string, old, value []byte, expire time.Duration) (bool, error) {
+	if expire == 0 {
+		expire = defaultMaxCacheExpiration
+	}
+
+	return ds.client.CompareAndSwap(ctx, key, old, value, expire)
+}
+
 // Run starts the backend processes for cache clients.
 func (ds *RemoteCache) Run(ctx context.Context) error {
 	// create new interface if more clients need GC jobs
@@ -109,7 +129,7 @@ func (ds *RemoteCache) Run(ctx context.Context) error {
 	return ctx.Err()
 }
 
-func createClient(opts *setting.RemoteCacheSettings, sqlstore db.DB, secretsService secrets.Service) (cache CacheStorage, err error) {
+func createClient(opts *setting.RemoteCacheSettings, sqlstore db.DB, secretsService secrets.Service) (cache AtomicCacheStorage, err error) {
 	switch opts.Name {
 	case redisCacheType:
 		cache, err = newRedisStorage(opts)
@@ -134,7 +154,7 @@ func createClient(opts *setting.RemoteCacheSettings, sqlstore db.DB, secretsServ
 }
 
 type encryptedCacheStorage struct {
-	cache          CacheStorage
+	cache          AtomicCacheStorage
 	secretsService encryptionService
 }
 
@@ -163,8 +183,37 @@ func (pcs *encryptedCacheStorage) Delete(ctx context.Context, key string) error
 	return pcs.cache.Delete(ctx, key)
 }
 
+// CompareAndSwap compares the decrypted value, since the same value is encrypted differently each time
+func (pcs *encryptedCacheStorage) CompareAndSwap(ctx context.Context, key string, old, value []byte, expire time.Duration) (bool, error) {
+	var current []byte
+	if old != nil {
+		encrypted, err := pcs.cache.Get(ctx, key)
+		if err != nil {
+			if errors.Is(err, ErrCacheItemNotFound) {
+				return false, nil
+			}
+			return false, err
+		}
+		decrypted, err := pcs.secretsService.Decrypt(ctx, encrypted)
+		if err != nil {
+			return false, err
+		}
+		if !bytes.Equal(decrypted, old) {
+			return false, nil
+		}
+		current = encrypted
+	}
+
+	encrypted, err := pcs.secretsService.Encrypt(ctx, value, secrets.WithoutScope())
+	if err != nil {
+		return false, err
+	}
+
+	return pcs.cache.CompareAndSwap(ctx, key, current, encrypted, expire)
+}
+
 type prefixCacheStorage struct {
-	cache  CacheStorage
+	cache  AtomicCacheStorage
 	prefix string
 }
 
@@ -177,3 +226,6 @@ func (pcs *prefixCacheStorage) Set(ctx context.Context, key string, value []byte
 func (pcs *prefixCacheStorage) Delete(ctx context.Context, key string) error {
 	return pcs.cache.Delete(ctx, pcs.prefix+key)
 }
+func (pcs *prefixCacheStorage) CompareAndSwap(ctx context.Context, key string, old, value []byte, expire time.Duration) (bool, error) {
+	return pcs.cache.CompareAndSwap(ctx, pcs.prefix+key, old, value, expire)
+}
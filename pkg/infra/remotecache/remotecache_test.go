@@ -21,7 +21,7 @@ func TestMain(m *testing.M) {
 	testsuite.Run(m)
 }
 
-func createTestClient(t *testing.T, opts *setting.RemoteCacheSettings, sqlstore db.DB) CacheStorage {
+func createTestClient(t *testing.T, opts *setting.RemoteCacheSettings, sqlstore db.DB) AtomicCacheStorage {
 	t.Helper()
 
 	cfg := &setting.Cfg{
@@ -49,9 +49,10 @@ func TestInvalidCacheTypeReturnsError(t *testing.T) {
 	assert.Equal(t, err, ErrInvalidCacheType)
 }
 
-func runTestsForClient(t *testing.T, client CacheStorage) {
+func runTestsForClient(t *testing.T, client AtomicCacheStorage) {
 	canPutGetAndDeleteCachedObjects(t, client)
 	canNotFetchExpiredItems(t, client)
+	canCompareAndSwapCachedObjects(t, client)
 }
 
 func canPutGetAndDeleteCachedObjects(t *testing.T, client CacheStorage) {
@@ -88,6 +89,31 @@ func canNotFetchExpiredItems(t *testing.T, client CacheStorage) {
 	assert.Error(t, err)
 }
 
+func canCompareAndSwapCachedObjects(t *testing.T, client AtomicCacheStorage) {
+	ctx := context.Background()
+	t.Cleanup(func() { _ = client.Delete(ctx, "key1") })
+
+	swapped, err := client.CompareAndSwap(ctx, "key1", nil, []byte("first"), 0)
+	require.NoError(t, err)
+	assert.True(t, swapped)
+
+	swapped, err = client.CompareAndSwap(ctx, "key1", nil, []byte("second"), 0)
+	require.NoError(t, err)
+	assert.False(t, swapped, "the key is not missing")
+
+	swapped, err = client.CompareAndSwap(ctx, "key1", []byte("other"), []byte("second"), 0)
+	require.NoError(t, err)
+	assert.False(t, swapped, "the value is not the old value")
+
+	swapped, err = client.CompareAndSwap(ctx, "key1", []byte("first"), []byte("second"), 0)
+	require.NoError(t, err)
+	assert.True(t, swapped)
+
+	data, err := client.Get(ctx, "key1")
+	require.NoError(t, err)
+	assert.Equal(t, "second", string(data))
+}
+
 func TestCollectUsageStats(t *testing.T) {
 	wantMap := map[string]any{
 		"stats.remote_cache.redis.count":           1,
@@ -143,6 +169,14 @@ func TestEncryptedCache(t *testing.T) {
 	v, err = encryptedCache.Get(context.Background(), "foo")
 	require.NoError(t, err)
 	require.Equal(t, "bar", string(v))
+
+	// the decrypted value is compared
+	swapped, err := encryptedCache.CompareAndSwap(context.Background(), "foo", []byte("bar"), []byte("baz"), time.Hour)
+	require.NoError(t, err)
+	require.True(t, swapped)
+	v, err = encryptedCache.Get(context.Background(), "foo")
+	require.NoError(t, err)
+	require.Equal(t, "baz", string(v))
 }
 
 type fakeSecretsService struct{}
@@ -1,20 +1,34 @@
 package remotecache
 
 import (
+	"bytes"
 	"context"
+	"sync"
 	"time"
 )
 
 type FakeCacheStorage struct {
 	Storage map[string][]byte
+	mu      *sync.Mutex
+}
+
+// lock serializes the updates of the fake storages created with NewFakeCacheStorage
+func (fcs FakeCacheStorage) lock() func() {
+	if fcs.mu == nil {
+		return func() {}
+	}
+	fcs.mu.Lock()
+	return fcs.mu.Unlock
 }
 
 func (fcs FakeCacheStorage) Set(_ context.Context, key string, value []byte, exp time.Duration) error {
+	defer fcs.lock()()
 	fcs.Storage[key] = value
 	return nil
 }
 
 func (fcs FakeCacheStorage) Get(_ context.Context, key string) ([]byte, error) {
+	defer fcs.lock()()
 	value, exist := fcs.Storage[key]
 	if !exist {
 		return nil, ErrCacheItemNotFound
@@ -24,12 +38,24 @@ func (fcs FakeCacheStorage) Get(_ context.Context, key string) ([]byte, error) {
 }
 
 func (fcs FakeCacheStorage) Delete(_ context.Context, key string) error {
+	defer fcs.lock()()
 	delete(fcs.Storage, key)
 	return nil
 }
 
+func (fcs FakeCacheStorage) CompareAndSwap(_ context.Context, key string, old, value []byte, exp time.Duration) (bool, error) {
+	defer fcs.lock()()
+	current, exist := fcs.Storage[key]
+	if exist != (old != nil) || !bytes.Equal(current, old) {
+		return false, nil
+	}
+	fcs.Storage[key] = value
+	return true, nil
+}
+
 func NewFakeCacheStorage() FakeCacheStorage {
 	return FakeCacheStorage{
 		Storage: map[string][]byte{},
+		mu:      &sync.Mutex{},
 	}
 }
@@ -88,6 +88,7 @@ type OAuthInfo struct {
 	Scopes                      []string          `mapstructure:"scopes" toml:"scopes"`
 	SignoutRedirectUrl          string            `mapstructure:"signout_redirect_url" toml:"signout_redirect_url"`
 	SkipOrgRoleSync             bool              `mapstructure:"skip_org_role_sync" toml:"skip_org_role_sync"`
+	TeamMapping                 []string          `mapstructure:"team_mapping" toml:"team_mapping"`
 	TeamIdsAttributePath        string            `mapstructure:"team_ids_attribute_path" toml:"team_ids_attribute_path"`
 	TeamsUrl                    string            `mapstructure:"teams_url" toml:"teams_url"`
 	TlsClientCa                 string            `mapstructure:"tls_client_ca" toml:"tls_client_ca"`
@@ -105,6 +106,7 @@ func NewOAuthInfo() *OAuthInfo {
 		Scopes:         []string{},
 		AllowedDomains: []string{},
 		AllowedGroups:  []string{},
+		TeamMapping:    []string{},
 		Extra:          map[string]string{},
 	}
 }
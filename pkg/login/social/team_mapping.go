@@ -0,0 +1,47 @@
+package social
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TeamMapping maps the members of an external group to a Grafana team
+type TeamMapping struct {
+	Group string
+	// Org is the ID or the name of the organization of the team
+	Org  string
+	Team string
+}
+
+// ParseTeamMapping parses the team_mapping setting of a provider.
+// Each entry has the format <group>:<org id or name>:<team name>, a colon that is part
+// of a value is escaped with a backslash.
+func ParseTeamMapping(mappings []string) ([]TeamMapping, error) {
+	result := make([]TeamMapping, 0, len(mappings))
+	for _, mapping := range mappings {
+		parts := splitTeamMapping(mapping)
+		if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+			return nil, fmt.Errorf("invalid team mapping %q, expected <group>:<org id or name>:<team name>", mapping)
+		}
+		result = append(result, TeamMapping{Group: parts[0], Org: parts[1], Team: parts[2]})
+	}
+	return result, nil
+}
+
+func splitTeamMapping(mapping string) []string {
+	parts := []string{}
+	var current strings.Builder
+	for i := 0; i < len(mapping); i++ {
+		switch {
+		case mapping[i] == '\\' && i+1 < len(mapping) && mapping[i+1] == ':':
+			current.WriteByte(':')
+			i++
+		case mapping[i] == ':':
+			parts = append(parts, current.String())
+			current.Reset()
+		default:
+			current.WriteByte(mapping[i])
+		}
+	}
+	return append(parts, current.String())
+}
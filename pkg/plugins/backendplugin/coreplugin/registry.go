@@ -16,11 +16,14 @@ import (
 	"github.com/grafana/grafana/pkg/plugins/backendplugin"
 	"github.com/grafana/grafana/pkg/plugins/log"
 	"github.com/grafana/grafana/pkg/services/featuremgmt"
+	"github.com/grafana/grafana/pkg/services/queryrestriction/queryrestrictionimpl"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/tsdb/azuremonitor"
 	cloudmonitoring "github.com/grafana/grafana/pkg/tsdb/cloud-monitoring"
 	"github.com/grafana/grafana/pkg/tsdb/cloudwatch"
 	"github.com/grafana/grafana/pkg/tsdb/elasticsearch"
+	files "github.com/grafana/grafana/pkg/tsdb/grafana-files-datasource"
+	jsonapi "github.com/grafana/grafana/pkg/tsdb/grafana-jsonapi-datasource"
 	postgres "github.com/grafana/grafana/pkg/tsdb/grafana-postgresql-datasource"
 	pyroscope "github.com/grafana/grafana/pkg/tsdb/grafana-pyroscope-datasource"
 	testdatasource "github.com/grafana/grafana/pkg/tsdb/grafana-testdata-datasource"
@@ -59,6 +62,8 @@ const (
 	Parca           = "parca"
 	Zipkin          = "zipkin"
 	Jaeger          = "jaeger"
+	JSONAPI         = "grafana-jsonapi-datasource"
+	Files           = "grafana-files-datasource"
 )
 
 func init() {
@@ -97,7 +102,8 @@ func NewRegistry(store map[string]backendplugin.PluginFactoryFunc) *Registry {
 func ProvideCoreRegistry(tracer tracing.Tracer, am *azuremonitor.Service, cw *cloudwatch.CloudWatchService, cm *cloudmonitoring.Service,
 	es *elasticsearch.Service, grap *graphite.Service, idb *influxdb.Service, lk *loki.Service, otsdb *opentsdb.Service,
 	pr *prometheus.Service, t *tempo.Service, td *testdatasource.Service, pg *postgres.Service, my *mysql.Service,
-	ms *mssql.Service, graf *grafanads.Service, pyroscope *pyroscope.Service, parca *parca.Service, zipkin *zipkin.Service, jaeger *jaeger.Service) *Registry {
+	ms *mssql.Service, graf *grafanads.Service, pyroscope *pyroscope.Service, parca *parca.Service, zipkin *zipkin.Service, jaeger *jaeger.Service,
+	jsonAPI *jsonapi.Service, files *files.Service) *Registry {
 	// Non-optimal global solution to replace plugin SDK default tracer for core plugins.
 	sdktracing.InitDefaultTracer(tracer)
 
@@ -121,6 +127,8 @@ func ProvideCoreRegistry(tracer tracing.Tracer, am *azuremonitor.Service, cw *cl
 		Parca:           asBackendPlugin(parca),
 		Zipkin:          asBackendPlugin(zipkin),
 		Jaeger:          asBackendPlugin(jaeger),
+		JSONAPI:         asBackendPlugin(jsonAPI),
+		Files:           asBackendPlugin(files),
 	})
 }
 
@@ -210,6 +218,8 @@ func NewPlugin(pluginID string, cfg *setting.Cfg, httpClientProvider *httpclient
 		AliasIDs: []string{},
 	}
 	var svc any
+	// the queries are restricted by the middlewares of the plugin client, which the plugin is created without
+	queryRestrictionService := queryrestrictionimpl.NewService(false, nil, nil)
 
 	switch pluginID {
 	case TestData, TestDataAlias:
@@ -229,11 +239,11 @@ func NewPlugin(pluginID string, cfg *setting.Cfg, httpClientProvider *httpclient
 	case InfluxDB:
 		svc = influxdb.ProvideService(httpClientProvider, features)
 	case Loki:
-		svc = loki.ProvideService(httpClientProvider, tracer)
+		svc = loki.ProvideService(httpClientProvider, tracer, queryRestrictionService)
 	case OpenTSDB:
 		svc = opentsdb.ProvideService(httpClientProvider)
 	case Prometheus:
-		svc = prometheus.ProvideService(httpClientProvider)
+		svc = prometheus.ProvideService(httpClientProvider, queryRestrictionService)
 	case Tempo:
 		svc = tempo.ProvideService(httpClientProvider)
 	case PostgreSQL:
@@ -250,6 +260,11 @@ func NewPlugin(pluginID string, cfg *setting.Cfg, httpClientProvider *httpclient
 		svc = zipkin.ProvideService(httpClientProvider)
 	case Jaeger:
 		svc = jaeger.ProvideService(httpClientProvider)
+	case JSONAPI:
+		svc = jsonapi.ProvideService(httpClientProvider)
+	case Files:
+		// without the grafana database, the plugin only reads the files of the allowed local directories
+		svc = files.ProvideService(cfg, nil)
 	default:
 		return nil, ErrCorePluginNotFound
 	}
@@ -38,6 +38,8 @@ func TestNewPlugin(t *testing.T) {
 		{ID: TestDataAlias, ExpectedID: TestData, ExpectedAlias: TestDataAlias},
 		{ID: Zipkin},
 		{ID: Jaeger},
+		{ID: JSONAPI},
+		{ID: Files},
 	}
 
 	for _, tc := range tcs {
@@ -0,0 +1,201 @@
+package converter
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// Range of the exponential native histogram schemas. The upper bound of the
+// bucket with index i is base^i, where base = 2^(2^-schema), so each schema
+// increment doubles the resolution. Other schemas (like the custom buckets
+// schema) don't have exponential boundaries and are returned as they are.
+const (
+	minExponentialSchema = -4
+	maxExponentialSchema = 8
+)
+
+type histogramBucket struct {
+	boundaryRule int8
+	lower        float64
+	upper        float64
+	count        float64
+}
+
+type histogramSample struct {
+	time    time.Time
+	buckets []histogramBucket
+}
+
+type histogramInfo struct {
+	samples []histogramSample
+}
+
+func newHistogramInfo() *histogramInfo {
+	return &histogramInfo{}
+}
+
+// frame converts the native histogram samples of a series into a heatmap-cells frame.
+// When the schema changed over the samples of the series (for example when Prometheus
+// reduced the resolution of a histogram with too many buckets) the samples are merged
+// into the buckets of the lowest schema, so that all cells of the heatmap line up.
+func (h *histogramInfo) frame(labels data.Labels, resultType string) *data.Frame {
+	// XMax (time)	YMin	Ymax	Count	YLayout
+	timeField := data.NewFieldFromFieldType(data.FieldTypeTime, 0)
+	timeField.Name = "xMax"
+	yMin := data.NewFieldFromFieldType(data.FieldTypeFloat64, 0)
+	yMin.Name = "yMin"
+	yMax := data.NewFieldFromFieldType(data.FieldTypeFloat64, 0)
+	yMax.Name = "yMax"
+	count := data.NewFieldFromFieldType(data.FieldTypeFloat64, 0)
+	count.Name = "count"
+	yLayout := data.NewFieldFromFieldType(data.FieldTypeInt8, 0)
+	yLayout.Name = "yLayout"
+
+	schemas := make([]int, len(h.samples))
+	schema, exponential := math.MaxInt, true
+	for i, sample := range h.samples {
+		s, found, ok := sample.schema()
+		if !ok {
+			exponential = false
+			break
+		}
+		schemas[i] = s
+		if found {
+			schema = min(schema, s)
+		}
+	}
+	// none of the samples has buckets
+	if schema == math.MaxInt {
+		exponential = false
+	}
+
+	for i, sample := range h.samples {
+		buckets := sample.buckets
+		if exponential && schemas[i] > schema {
+			buckets = reduceResolution(buckets, schemas[i], schema)
+		}
+		for _, b := range buckets {
+			timeField.Append(sample.time)
+			yLayout.Append(b.boundaryRule)
+			yMin.Append(b.lower)
+			yMax.Append(b.upper)
+			count.Append(b.count)
+		}
+	}
+
+	custom := resultTypeToCustomMeta(resultType)
+	if exponential {
+		custom["schema"] = strconv.Itoa(schema)
+		custom["schemaFactor"] = strconv.FormatFloat(schemaBase(schema), 'g', -1, 64)
+	}
+
+	fields := []*data.Field{timeField, yMin, yMax, count, yLayout}
+	for _, f := range fields {
+		f.Labels = labels
+	}
+	frame := data.NewFrame("", fields...)
+	frame.Meta = &data.FrameMeta{
+		Type:   "heatmap-cells",
+		Custom: custom,
+	}
+	return frame
+}
+
+// schema returns the exponential schema of the sample, which is computed from
+// the ratio between the boundaries of its buckets. found is false when the sample
+// has no bucket besides the zero bucket, and ok is false when the boundaries are
+// not exponential.
+func (s histogramSample) schema() (schema int, found bool, ok bool) {
+	zeroThreshold := 0.0
+	for _, b := range s.buckets {
+		if isZeroBucket(b) {
+			zeroThreshold = b.upper
+		}
+	}
+
+	for _, b := range s.buckets {
+		if isZeroBucket(b) {
+			continue
+		}
+		lower, upper := magnitudes(b)
+		// the lowest bucket may be cut by the zero bucket
+		if lower <= zeroThreshold {
+			continue
+		}
+
+		exact := -math.Log2(math.Log2(upper / lower))
+		if math.IsNaN(exact) || exact < minExponentialSchema-0.5 || exact > maxExponentialSchema+0.5 {
+			return 0, false, false
+		}
+		bucketSchema := int(math.Round(exact))
+		if math.Abs(exact-float64(bucketSchema)) > 1e-6 || (found && bucketSchema != schema) {
+			return 0, false, false
+		}
+		schema, found = bucketSchema, true
+	}
+	return schema, found, true
+}
+
+// reduceResolution merges the buckets of a schema into the buckets of a lower schema,
+// the same way Prometheus does when it reduces the resolution of a histogram.
+func reduceResolution(buckets []histogramBucket, from, to int) []histogramBucket {
+	type key struct {
+		negative bool
+		index    int
+	}
+
+	merged := make(map[key]int, len(buckets))
+	result := make([]histogramBucket, 0, len(buckets))
+	for _, b := range buckets {
+		if isZeroBucket(b) {
+			result = append(result, b)
+			continue
+		}
+
+		_, upper := magnitudes(b)
+		index := int(math.Round(math.Log2(upper) * math.Exp2(float64(from))))
+		k := key{negative: b.upper <= 0, index: ((index - 1) >> (from - to)) + 1}
+		if i, ok := merged[k]; ok {
+			result[i].count += b.count
+			continue
+		}
+
+		lower, upper := bucketBound(k.index-1, to), bucketBound(k.index, to)
+		if k.negative {
+			lower, upper = -upper, -lower
+		}
+		merged[k] = len(result)
+		result = append(result, histogramBucket{boundaryRule: b.boundaryRule, lower: lower, upper: upper, count: b.count})
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].lower < result[j].lower
+	})
+	return result
+}
+
+// schemaBase returns the growth factor between the boundaries of consecutive buckets.
+func schemaBase(schema int) float64 {
+	return math.Exp2(math.Exp2(float64(-schema)))
+}
+
+// bucketBound returns the upper boundary of the bucket with the given index.
+func bucketBound(index, schema int) float64 {
+	return math.Exp2(float64(index) / math.Exp2(float64(schema)))
+}
+
+func isZeroBucket(b histogramBucket) bool {
+	return b.lower <= 0 && b.upper >= 0
+}
+
+// magnitudes returns the absolute boundaries of a bucket, lowest first.
+func magnitudes(b histogramBucket) (float64, float64) {
+	if b.upper <= 0 {
+		return -b.upper, -b.lower
+	}
+	return b.lower, b.upper
+}
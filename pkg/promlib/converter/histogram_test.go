@@ -0,0 +1,111 @@
+package converter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	sdkjsoniter "github.com/grafana/grafana-plugin-sdk-go/data/utils/jsoniter"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readTestResult(t *testing.T, body string) data.Frames {
+	t.Helper()
+
+	iter := jsoniter.ParseString(sdkjsoniter.ConfigDefault, body)
+	rsp := ReadPrometheusStyleResult(iter, Options{})
+	require.NoError(t, rsp.Error)
+	return rsp.Frames
+}
+
+func TestNativeHistogramFrame(t *testing.T) {
+	t.Run("should merge the buckets of samples with different schemas", func(t *testing.T) {
+		frames := readTestResult(t, `{"status":"success","data":{"resultType":"matrix","result":[{"metric":{"job":"api"},"histograms":[
+			[1700000000,{"count":"10","sum":"15","buckets":[
+				[3,"-0.001","0.001","1"],
+				[0,"1","1.4142135623730951","2"],
+				[0,"1.4142135623730951","2","3"],
+				[0,"2","2.8284271247461903","4"]
+			]}],
+			[1700000060,{"count":"5","sum":"9","buckets":[
+				[1,"-4","-2","1"],
+				[0,"1","2","2"],
+				[0,"2","4","2"]
+			]}]
+		]}]}}`)
+		require.Len(t, frames, 1)
+
+		frame := frames[0]
+		assert.Equal(t, data.FrameType("heatmap-cells"), frame.Meta.Type)
+		assert.Equal(t, map[string]string{"resultType": "matrix", "schema": "0", "schemaFactor": "2"}, frame.Meta.Custom)
+		assert.Equal(t, data.Labels{"job": "api"}, frame.Fields[3].Labels)
+
+		type cell struct {
+			time           time.Time
+			yMin, yMax, ct float64
+			layout         int8
+		}
+		cells := make([]cell, 0, frame.Rows())
+		for i := 0; i < frame.Rows(); i++ {
+			cells = append(cells, cell{
+				time:   frame.Fields[0].At(i).(time.Time),
+				yMin:   frame.Fields[1].At(i).(float64),
+				yMax:   frame.Fields[2].At(i).(float64),
+				ct:     frame.Fields[3].At(i).(float64),
+				layout: frame.Fields[4].At(i).(int8),
+			})
+		}
+		first, second := time.Unix(1700000000, 0).UTC(), time.Unix(1700000060, 0).UTC()
+		assert.Equal(t, []cell{
+			{time: first, yMin: -0.001, yMax: 0.001, ct: 1, layout: 3},
+			{time: first, yMin: 1, yMax: 2, ct: 5, layout: 0},
+			{time: first, yMin: 2, yMax: 4, ct: 4, layout: 0},
+			{time: second, yMin: -4, yMax: -2, ct: 1, layout: 1},
+			{time: second, yMin: 1, yMax: 2, ct: 2, layout: 0},
+			{time: second, yMin: 2, yMax: 4, ct: 2, layout: 0},
+		}, cells)
+	})
+
+	t.Run("should keep custom buckets as they are", func(t *testing.T) {
+		frames := readTestResult(t, `{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"histogram":
+			[1700000000,{"count":"3","sum":"1","buckets":[[0,"0.1","0.25","1"],[0,"0.25","1","2"]]}]
+		}]}}`)
+		require.Len(t, frames, 1)
+		assert.Equal(t, map[string]string{"resultType": "vector"}, frames[0].Meta.Custom)
+		assert.Equal(t, 0.25, frames[0].Fields[1].At(1))
+		assert.Equal(t, 1.0, frames[0].Fields[2].At(1))
+	})
+
+	t.Run("should return the float samples of series with both samples types", func(t *testing.T) {
+		frames := readTestResult(t, `{"status":"success","data":{"resultType":"matrix","result":[{"metric":{"job":"api"},
+			"values":[[1700000000,"1"]],
+			"histograms":[[1700000060,{"count":"1","sum":"1","buckets":[[0,"1","2","1"]]}]]
+		}]}}`)
+		require.Len(t, frames, 2)
+		assert.Equal(t, data.FrameType("heatmap-cells"), frames[0].Meta.Type)
+		assert.Equal(t, data.FrameTypeTimeSeriesMulti, frames[1].Meta.Type)
+		assert.Equal(t, 1, frames[1].Rows())
+	})
+}
+
+func TestHistogramSampleSchema(t *testing.T) {
+	for schema := minExponentialSchema; schema <= maxExponentialSchema; schema++ {
+		sample := histogramSample{buckets: []histogramBucket{
+			{lower: bucketBound(4, schema), upper: bucketBound(5, schema)},
+			{lower: -bucketBound(-2, schema), upper: -bucketBound(-3, schema)},
+		}}
+		actual, found, ok := sample.schema()
+		require.True(t, ok)
+		require.True(t, found)
+		assert.Equal(t, schema, actual)
+	}
+
+	_, found, ok := histogramSample{buckets: []histogramBucket{{lower: 0, upper: 0, count: 3}}}.schema()
+	assert.True(t, ok)
+	assert.False(t, found)
+
+	_, _, ok = histogramSample{buckets: []histogramBucket{{lower: 1, upper: 3}}}.schema()
+	assert.False(t, ok)
+}
@@ -669,17 +669,10 @@ func readMatrixOrVectorMulti(iter *sdkjsoniter.Iterator, resultType string, opt
 		}
 
 		if histogram != nil {
-			histogram.yMin.Labels = labels
-			histogram.yMax.Labels = labels
-			histogram.count.Labels = labels
-			histogram.yLayout.Labels = labels
-			histogram.time.Labels = labels
-			frame := data.NewFrame("", histogram.time, histogram.yMin, histogram.yMax, histogram.count, histogram.yLayout)
-			frame.Meta = &data.FrameMeta{
-				Type: "heatmap-cells",
-			}
-			rsp.Frames = append(rsp.Frames, frame)
-		} else {
+			rsp.Frames = append(rsp.Frames, histogram.frame(labels, resultType))
+		}
+		// series may have both float and histogram samples when the metric type changed over the time range
+		if histogram == nil || len(tempTimes) > 0 {
 			frame := data.NewFrame("", data.NewField(data.TimeSeriesTimeFieldName, nil, tempTimes), data.NewField(data.TimeSeriesValueFieldName, labels, tempValues))
 			frame.Meta = &data.FrameMeta{
 				Type:        data.FrameTypeTimeSeriesMulti,
@@ -725,31 +718,6 @@ func readTimeValuePair(iter *sdkjsoniter.Iterator) (time.Time, float64, error) {
 	return tt, fv, err
 }
 
-type histogramInfo struct {
-	// XMax (time)	YMin	Ymax	Count	YLayout
-	time    *data.Field
-	yMin    *data.Field // will have labels?
-	yMax    *data.Field
-	count   *data.Field
-	yLayout *data.Field
-}
-
-func newHistogramInfo() *histogramInfo {
-	hist := &histogramInfo{
-		time:    data.NewFieldFromFieldType(data.FieldTypeTime, 0),
-		yMin:    data.NewFieldFromFieldType(data.FieldTypeFloat64, 0),
-		yMax:    data.NewFieldFromFieldType(data.FieldTypeFloat64, 0),
-		count:   data.NewFieldFromFieldType(data.FieldTypeFloat64, 0),
-		yLayout: data.NewFieldFromFieldType(data.FieldTypeInt8, 0),
-	}
-	hist.time.Name = "xMax"
-	hist.yMin.Name = "yMin"
-	hist.yMax.Name = "yMax"
-	hist.count.Name = "count"
-	hist.yLayout.Name = "yLayout"
-	return hist
-}
-
 // This will read a single sparse histogram
 // [ time, { count, sum, buckets: [...] }]
 func readHistogram(iter *sdkjsoniter.Iterator, hist *histogramInfo) error {
@@ -762,7 +730,7 @@ func readHistogram(iter *sdkjsoniter.Iterator, hist *histogramInfo) error {
 	if err != nil {
 		return err
 	}
-	t := timeFromFloat(f)
+	sample := histogramSample{time: timeFromFloat(f)}
 
 	// next object element
 	if _, err := iter.ReadArray(); err != nil {
@@ -788,23 +756,21 @@ func readHistogram(iter *sdkjsoniter.Iterator, hist *histogramInfo) error {
 				if err != nil {
 					return err
 				}
-				hist.time.Append(t)
 
 				if _, err := iter.ReadArray(); err != nil {
 					return err
 				}
 
-				v, err := iter.ReadInt8()
-				if err != nil {
+				var b histogramBucket
+				if b.boundaryRule, err = iter.ReadInt8(); err != nil {
 					return err
 				}
-				hist.yLayout.Append(v)
 
 				if _, err := iter.ReadArray(); err != nil {
 					return err
 				}
 
-				if err = appendValueFromString(iter, hist.yMin); err != nil {
+				if b.lower, err = readFloatFromString(iter); err != nil {
 					return err
 				}
 
@@ -812,8 +778,7 @@ func readHistogram(iter *sdkjsoniter.Iterator, hist *histogramInfo) error {
 					return err
 				}
 
-				err = appendValueFromString(iter, hist.yMax)
-				if err != nil {
+				if b.upper, err = readFloatFromString(iter); err != nil {
 					return err
 				}
 
@@ -821,10 +786,10 @@ func readHistogram(iter *sdkjsoniter.Iterator, hist *histogramInfo) error {
 					return err
 				}
 
-				err = appendValueFromString(iter, hist.count)
-				if err != nil {
+				if b.count, err = readFloatFromString(iter); err != nil {
 					return err
 				}
+				sample.buckets = append(sample.buckets, b)
 
 				for more, err := iter.ReadArray(); more; more, err = iter.ReadArray() {
 					if err != nil {
@@ -849,26 +814,21 @@ func readHistogram(iter *sdkjsoniter.Iterator, hist *histogramInfo) error {
 		return fmt.Errorf("expected to be done")
 	}
 
+	hist.samples = append(hist.samples, sample)
 	return nil
 }
 
-func appendValueFromString(iter *sdkjsoniter.Iterator, field *data.Field) error {
+func readFloatFromString(iter *sdkjsoniter.Iterator) (float64, error) {
 	// Read the string directly into our buffer
 	buf, err := iter.ReadStringAsSlice()
 	if err != nil {
-		return err
+		return 0, err
 	}
 
 	// #nosec G103
 	// Convert string to float64 without allocation
 	// https://github.com/search?q=org%3Agrafana+yoloString&type=code
-	v, err := strconv.ParseFloat(*(*string)(unsafe.Pointer(&buf)), 64)
-	if err != nil {
-		return err
-	}
-
-	field.Append(v)
-	return nil
+	return strconv.ParseFloat(*(*string)(unsafe.Pointer(&buf)), 64)
 }
 
 func readStream(iter *sdkjsoniter.Iterator) backend.DataResponse {
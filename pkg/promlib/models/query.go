@@ -54,6 +54,9 @@ type PrometheusQueryProperties struct {
 	// Execute an additional query to identify interesting raw samples relevant for the given expr
 	Exemplar bool `json:"exemplar,omitempty"`
 
+	// Maximum number of exemplars returned for the query after sampling and deduplication, defaults to 1000
+	ExemplarLimit int64 `json:"exemplarLimit,omitempty"`
+
 	// what we should show in the editor
 	EditorMode QueryEditorMode `json:"editorMode,omitempty"`
 
@@ -143,6 +146,9 @@ const (
 
 var safeResolution = 11000
 
+// DefaultExemplarLimit is the maximum number of exemplars returned for the queries that do not set exemplarLimit
+const DefaultExemplarLimit = 1000
+
 // QueryModel includes both the common and specific values
 // NOTE: this struct may have issues when decoding JSON that requires the special handling
 // registered in https://github.com/grafana/grafana-plugin-sdk-go/blob/v0.228.0/experimental/apis/data/v0alpha1/query.go#L298
@@ -173,6 +179,7 @@ type Query struct {
 	InstantQuery  bool
 	RangeQuery    bool
 	ExemplarQuery bool
+	ExemplarLimit int
 	UtcOffsetSec  int64
 
 	Scopes []ScopeSpec
@@ -260,6 +267,11 @@ func Parse(span trace.Span, query backend.DataQuery, dsScrapeInterval string, in
 		model.Exemplar = false
 	}
 
+	exemplarLimit := int(model.ExemplarLimit)
+	if exemplarLimit <= 0 {
+		exemplarLimit = DefaultExemplarLimit
+	}
+
 	span.SetAttributes(
 		attribute.String("expr", expr),
 		attribute.Int64("start_unixnano", query.TimeRange.From.UnixNano()),
@@ -276,6 +288,7 @@ func Parse(span trace.Span, query backend.DataQuery, dsScrapeInterval string, in
 		InstantQuery:  model.Instant,
 		RangeQuery:    model.Range,
 		ExemplarQuery: model.Exemplar,
+		ExemplarLimit: exemplarLimit,
 		UtcOffsetSec:  model.UtcOffsetSec,
 	}, nil
 }
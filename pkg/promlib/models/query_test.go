@@ -49,6 +49,41 @@ func TestParse(t *testing.T) {
 		require.Equal(t, false, res.ExemplarQuery)
 	})
 
+	t.Run("parsing query model without exemplar limit", func(t *testing.T) {
+		timeRange := backend.TimeRange{
+			From: now,
+			To:   now.Add(12 * time.Hour),
+		}
+
+		q := queryContext(`{
+			"expr": "go_goroutines",
+			"refId": "A",
+			"exemplar": true
+		}`, timeRange, time.Duration(1)*time.Minute)
+
+		res, err := models.Parse(span, q, "15s", intervalCalculator, false, false)
+		require.NoError(t, err)
+		require.Equal(t, models.DefaultExemplarLimit, res.ExemplarLimit)
+	})
+
+	t.Run("parsing query model with exemplar limit", func(t *testing.T) {
+		timeRange := backend.TimeRange{
+			From: now,
+			To:   now.Add(12 * time.Hour),
+		}
+
+		q := queryContext(`{
+			"expr": "go_goroutines",
+			"refId": "A",
+			"exemplar": true,
+			"exemplarLimit": 50
+		}`, timeRange, time.Duration(1)*time.Minute)
+
+		res, err := models.Parse(span, q, "15s", intervalCalculator, false, false)
+		require.NoError(t, err)
+		require.Equal(t, 50, res.ExemplarLimit)
+	})
+
 	t.Run("parsing query model with step", func(t *testing.T) {
 		timeRange := backend.TimeRange{
 			From: now,
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql/parser"
+)
+
+// CheckMetricPrefixes returns an error when a selector of the raw expression does not select a metric starting with
+// one of the prefixes. The selectors must select their metric by name, a selector without metric name could select
+// any metric. The variables of the expression are replaced by placeholder values before parsing it.
+func CheckMetricPrefixes(rawExpr string, prefixes []string) error {
+	expr, err := parser.ParseExpr(InterpolateVariables(rawExpr, time.Minute, time.Minute, "", "", time.Hour))
+	if err != nil {
+		return err
+	}
+
+	var restrictionErr error
+	parser.Inspect(expr, func(node parser.Node, _ []parser.Node) error {
+		v, ok := node.(*parser.VectorSelector)
+		if !ok || restrictionErr != nil {
+			return nil
+		}
+		name := ""
+		for _, m := range v.LabelMatchers {
+			if m != nil && m.Name == labels.MetricName && m.Type == labels.MatchEqual {
+				name = m.Value
+			}
+		}
+		if name == "" {
+			restrictionErr = fmt.Errorf("the selector %s must select a metric by name", v.String())
+			return nil
+		}
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(name, prefix) {
+				return nil
+			}
+		}
+		restrictionErr = fmt.Errorf("the metric %s does not start with %s", name, strings.Join(prefixes, " or "))
+		return nil
+	})
+	return restrictionErr
+}
@@ -0,0 +1,67 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckMetricPrefixes(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		prefixes  []string
+		expectErr bool
+	}{
+		{
+			name:     "metric with an allowed prefix",
+			query:    `sum(rate(team_a_requests_total{job="api"}[$__rate_interval])) by (code)`,
+			prefixes: []string{"team_a_"},
+		},
+		{
+			name:     "metrics with one of the allowed prefixes",
+			query:    `team_a_errors_total / on(job) group_left team_b_requests_total offset 5m`,
+			prefixes: []string{"team_a_", "team_b_"},
+		},
+		{
+			name:     "metric selected by name matcher",
+			query:    `{__name__="team_a_up"}[$__range]`,
+			prefixes: []string{"team_a_"},
+		},
+		{
+			name:      "metric outside of the allowed prefixes",
+			query:     `team_a_up or team_b_up`,
+			prefixes:  []string{"team_a_"},
+			expectErr: true,
+		},
+		{
+			name:      "selector without metric name",
+			query:     `count({job="api"})`,
+			prefixes:  []string{"team_a_"},
+			expectErr: true,
+		},
+		{
+			name:      "metric name regex",
+			query:     `{__name__=~"team_a_.*|node_.*"}`,
+			prefixes:  []string{"team_a_"},
+			expectErr: true,
+		},
+		{
+			name:      "invalid query",
+			query:     `sum(`,
+			prefixes:  []string{"team_a_"},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckMetricPrefixes(tt.query, tt.prefixes)
+			if tt.expectErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package exemplar
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/promlib/models"
+)
+
+var _ Sampler = (*DeduplicatingSampler)(nil)
+
+// DeduplicatingSampler wraps a sampler to drop the exemplars that were already added
+// for another series, and to limit the number of sampled exemplars.
+// Prometheus returns the same exemplar for every series it is attached to, which
+// happens for example with all the buckets of a classic histogram.
+type DeduplicatingSampler struct {
+	sampler Sampler
+	limit   int
+	seen    map[string]struct{}
+}
+
+// NewDeduplicatingSampler returns a sampler that returns at most limit exemplars,
+// evenly spread over the exemplars sampled by sampler. A limit of 0 disables the limit.
+func NewDeduplicatingSampler(sampler Sampler, limit int) Sampler {
+	return &DeduplicatingSampler{
+		sampler: sampler,
+		limit:   limit,
+		seen:    map[string]struct{}{},
+	}
+}
+
+func (e *DeduplicatingSampler) SetStep(step time.Duration) {
+	e.sampler.SetStep(step)
+}
+
+func (e *DeduplicatingSampler) Add(ex models.Exemplar) {
+	key := exemplarKey(ex)
+	if _, exists := e.seen[key]; exists {
+		return
+	}
+	e.seen[key] = struct{}{}
+	e.sampler.Add(ex)
+}
+
+func (e *DeduplicatingSampler) Sample() []models.Exemplar {
+	exemplars := e.sampler.Sample()
+	if e.limit <= 0 || len(exemplars) <= e.limit {
+		return exemplars
+	}
+
+	// the exemplars are sorted by time, so picking them at a regular
+	// interval keeps exemplars over the whole time range
+	sampled := make([]models.Exemplar, 0, e.limit)
+	for i := 0; i < e.limit; i++ {
+		sampled = append(sampled, exemplars[i*len(exemplars)/e.limit])
+	}
+	return sampled
+}
+
+func (e *DeduplicatingSampler) Reset() {
+	e.sampler.Reset()
+	e.seen = map[string]struct{}{}
+}
+
+// exemplarKey identifies an exemplar by its timestamp, value and own labels, ignoring the series labels
+func exemplarKey(ex models.Exemplar) string {
+	labels := make([]string, 0, len(ex.Fields))
+	for _, f := range ex.Fields {
+		v, _ := f.CopyAt(ex.RowIdx).(string)
+		labels = append(labels, f.Name+"="+v)
+	}
+	sort.Strings(labels)
+
+	return strconv.FormatInt(ex.Timestamp.UnixNano(), 10) + "\x00" +
+		strconv.FormatFloat(ex.Value, 'g', -1, 64) + "\x00" +
+		strings.Join(labels, "\x00")
+}
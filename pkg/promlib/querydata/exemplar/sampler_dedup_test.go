@@ -0,0 +1,57 @@
+package exemplar_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/promlib/models"
+	"github.com/grafana/grafana/pkg/promlib/querydata/exemplar"
+)
+
+func TestDeduplicatingSampler(t *testing.T) {
+	traceIDs := data.NewField("traceID", nil, []string{"a", "b"})
+	exemplarsFor := func(series string) []models.Exemplar {
+		ts := time.Unix(60, 0)
+		return []models.Exemplar{
+			{Timestamp: ts, Value: 1, Fields: []*data.Field{traceIDs}, RowIdx: 0, SeriesLabels: map[string]string{"le": series}},
+			{Timestamp: ts, Value: 1, Fields: []*data.Field{traceIDs}, RowIdx: 1, SeriesLabels: map[string]string{"le": series}},
+		}
+	}
+
+	t.Run("should drop exemplars added for several series", func(t *testing.T) {
+		sampler := exemplar.NewDeduplicatingSampler(exemplar.NewNoOpSampler(), 0)
+		for _, series := range []string{"0.5", "1", "+Inf"} {
+			for _, ex := range exemplarsFor(series) {
+				sampler.Add(ex)
+			}
+		}
+
+		sampled := sampler.Sample()
+		require.Len(t, sampled, 2)
+		assert.Equal(t, "0.5", sampled[0].SeriesLabels["le"])
+		assert.Equal(t, "0.5", sampled[1].SeriesLabels["le"])
+
+		sampler.Reset()
+		for _, ex := range exemplarsFor("1") {
+			sampler.Add(ex)
+		}
+		assert.Len(t, sampler.Sample(), 2)
+	})
+
+	t.Run("should spread the limited exemplars over the time range", func(t *testing.T) {
+		sampler := exemplar.NewDeduplicatingSampler(exemplar.NewNoOpSampler(), 4)
+		for _, ex := range generateTestExemplars(models.TimeRange{Start: time.Unix(0, 0), End: time.Unix(100, 0)}) {
+			sampler.Add(ex)
+		}
+
+		values := []float64{}
+		for _, ex := range sampler.Sample() {
+			values = append(values, ex.Value)
+		}
+		assert.Equal(t, []float64{0, 25, 50, 75}, values)
+	})
+}
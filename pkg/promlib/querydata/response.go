@@ -87,7 +87,7 @@ func (s *QueryData) parseResponse(ctx context.Context, q *models.Query, res *htt
 func (s *QueryData) processExemplars(ctx context.Context, q *models.Query, dr backend.DataResponse) backend.DataResponse {
 	_, endSpan := utils.StartTrace(ctx, s.tracer, "datasource.prometheus.processExemplars")
 	defer endSpan()
-	sampler := s.exemplarSampler()
+	sampler := exemplar.NewDeduplicatingSampler(s.exemplarSampler(), q.ExemplarLimit)
 	labelTracker := exemplar.NewLabelTracker()
 
 	// we are moving from a multi-frame response returned
@@ -145,17 +145,21 @@ func addMetadataToMultiFrame(q *models.Query, frame *data.Frame) {
 	}
 	frame.Fields[0].Config = &data.FieldConfig{Interval: float64(q.Step.Milliseconds())}
 
-	customName := getName(q, frame.Fields[1])
-	if customName != "" {
-		frame.Fields[1].Config = &data.FieldConfig{DisplayNameFromDS: customName}
-	}
-
 	// For heatmap-cells type we don't want to set field name
 	// prometheus native histograms have their own field name structure
+	// and the series name belongs to the count field
 	if frame.Meta.Type == "heatmap-cells" {
+		if customName := getName(q, frame.Fields[3]); customName != "" {
+			frame.Fields[3].Config = &data.FieldConfig{DisplayNameFromDS: customName}
+		}
 		return
 	}
 
+	customName := getName(q, frame.Fields[1])
+	if customName != "" {
+		frame.Fields[1].Config = &data.FieldConfig{DisplayNameFromDS: customName}
+	}
+
 	valueField := frame.Fields[1]
 	if n, ok := valueField.Labels["__name__"]; ok {
 		valueField.Name = n
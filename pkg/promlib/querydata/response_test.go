@@ -68,6 +68,8 @@ func TestAddMetadataToMultiFrame(t *testing.T) {
 		assert.Nil(t, result.Error)
 		assert.Len(t, result.Frames, 1)
 		assert.Equal(t, "yMin", result.Frames[0].Fields[1].Name)
+		assert.Nil(t, result.Frames[0].Fields[1].Config)
+		assert.Equal(t, `rpc_durations_native_histogram_seconds{instance="nativehisto:8080", job="prometheus"}`, result.Frames[0].Fields[3].Config.DisplayNameFromDS)
 	})
 }
 
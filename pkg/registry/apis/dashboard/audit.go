@@ -0,0 +1,123 @@
+package dashboard
+
+import (
+	"context"
+	"strconv"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metainternalversion "k8s.io/apimachinery/pkg/apis/meta/internalversion"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/apiserver/pkg/registry/rest"
+
+	"github.com/grafana/grafana/pkg/apimachinery/utils"
+	grafanarest "github.com/grafana/grafana/pkg/apiserver/rest"
+	"github.com/grafana/grafana/pkg/services/apiserver/endpoints/request"
+	"github.com/grafana/grafana/pkg/services/auditlog"
+)
+
+// auditStorage records the dashboard writes in the audit log, whatever the dual writer mode.
+// The writes also reaching the legacy store are marked as recorded, so that the events it
+// publishes are not recorded twice.
+type auditStorage struct {
+	grafanarest.Storage
+	auditLog auditlog.Service
+}
+
+// auditWatchStorage is an auditStorage of a storage supporting watch
+type auditWatchStorage struct {
+	auditStorage
+	watcher rest.Watcher
+}
+
+func newAuditStorage(store grafanarest.Storage, auditLog auditlog.Service) grafanarest.Storage {
+	s := auditStorage{Storage: store, auditLog: auditLog}
+	if w, ok := store.(rest.Watcher); ok {
+		return &auditWatchStorage{auditStorage: s, watcher: w}
+	}
+	return &s
+}
+
+func (s *auditWatchStorage) Watch(ctx context.Context, options *metainternalversion.ListOptions) (watch.Interface, error) {
+	return s.watcher.Watch(ctx, options)
+}
+
+func (s *auditStorage) Create(ctx context.Context, obj runtime.Object, createValidation rest.ValidateObjectFunc, options *metav1.CreateOptions) (runtime.Object, error) {
+	out, err := s.Storage.Create(auditlog.WithRecorded(ctx), obj, createValidation, options)
+	if err == nil {
+		s.record(ctx, auditlog.ActionDashboardSave, out)
+	}
+	return out, err
+}
+
+func (s *auditStorage) Update(ctx context.Context, name string, objInfo rest.UpdatedObjectInfo, createValidation rest.ValidateObjectFunc, updateValidation rest.ValidateObjectUpdateFunc, forceAllowCreate bool, options *metav1.UpdateOptions) (runtime.Object, bool, error) {
+	out, created, err := s.Storage.Update(auditlog.WithRecorded(ctx), name, objInfo, createValidation, updateValidation, forceAllowCreate, options)
+	if err == nil {
+		s.record(ctx, auditlog.ActionDashboardSave, out)
+	}
+	return out, created, err
+}
+
+func (s *auditStorage) Delete(ctx context.Context, name string, deleteValidation rest.ValidateObjectFunc, options *metav1.DeleteOptions) (runtime.Object, bool, error) {
+	out, async, err := s.Storage.Delete(auditlog.WithRecorded(ctx), name, deleteValidation, options)
+	if err == nil {
+		s.recordDelete(ctx, name, out)
+	}
+	return out, async, err
+}
+
+func (s *auditStorage) DeleteCollection(ctx context.Context, deleteValidation rest.ValidateObjectFunc, options *metav1.DeleteOptions, listOptions *metainternalversion.ListOptions) (runtime.Object, error) {
+	out, err := s.Storage.DeleteCollection(auditlog.WithRecorded(ctx), deleteValidation, options, listOptions)
+	if err == nil {
+		_ = meta.EachListItem(out, func(item runtime.Object) error {
+			s.recordDelete(ctx, "", item)
+			return nil
+		})
+	}
+	return out, err
+}
+
+func (s *auditStorage) record(ctx context.Context, action string, obj runtime.Object) {
+	accessor, err := utils.MetaAccessor(obj)
+	if err != nil {
+		return
+	}
+	s.auditLog.Log(ctx, &auditlog.Event{
+		OrgID:        orgIDFrom(ctx),
+		Action:       action,
+		ResourceKind: auditlog.ResourceKindDashboard,
+		ResourceUID:  accessor.GetName(),
+		Details: map[string]string{
+			"title":   accessor.FindTitle(accessor.GetName()),
+			"version": strconv.FormatInt(accessor.GetGeneration(), 10),
+		},
+	})
+}
+
+func (s *auditStorage) recordDelete(ctx context.Context, name string, obj runtime.Object) {
+	details := map[string]string{}
+	if accessor, err := utils.MetaAccessor(obj); err == nil && accessor.GetName() != "" {
+		name = accessor.GetName()
+		details["title"] = accessor.FindTitle(name)
+	}
+	if name == "" {
+		return
+	}
+	s.auditLog.Log(ctx, &auditlog.Event{
+		OrgID:        orgIDFrom(ctx),
+		Action:       auditlog.ActionDashboardDelete,
+		ResourceKind: auditlog.ResourceKindDashboard,
+		ResourceUID:  name,
+		Details:      details,
+	})
+}
+
+// orgIDFrom returns the org of the request namespace, or zero to use the org of the requester
+func orgIDFrom(ctx context.Context) int64 {
+	info, err := request.NamespaceInfoFrom(ctx, true)
+	if err != nil {
+		return 0
+	}
+	return info.OrgID
+}
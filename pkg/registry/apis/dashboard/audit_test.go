@@ -0,0 +1,94 @@
+package dashboard
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8srequest "k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/apiserver/pkg/registry/rest"
+
+	dashv1 "github.com/grafana/grafana/apps/dashboard/pkg/apis/dashboard/v1alpha1"
+	common "github.com/grafana/grafana/pkg/apimachinery/apis/common/v0alpha1"
+	grafanarest "github.com/grafana/grafana/pkg/apiserver/rest"
+	"github.com/grafana/grafana/pkg/services/auditlog"
+	"github.com/grafana/grafana/pkg/services/auditlog/auditlogtest"
+)
+
+// fakeDashboardStorage returns the dashboard for every write, and keeps whether the writes were marked as recorded
+type fakeDashboardStorage struct {
+	grafanarest.Storage
+	dashboard *dashv1.Dashboard
+	recorded  []bool
+	err       error
+}
+
+func (f *fakeDashboardStorage) Create(ctx context.Context, obj runtime.Object, createValidation rest.ValidateObjectFunc, options *metav1.CreateOptions) (runtime.Object, error) {
+	f.recorded = append(f.recorded, auditlog.IsRecorded(ctx))
+	return f.dashboard, f.err
+}
+
+func (f *fakeDashboardStorage) Update(ctx context.Context, name string, objInfo rest.UpdatedObjectInfo, createValidation rest.ValidateObjectFunc, updateValidation rest.ValidateObjectUpdateFunc, forceAllowCreate bool, options *metav1.UpdateOptions) (runtime.Object, bool, error) {
+	f.recorded = append(f.recorded, auditlog.IsRecorded(ctx))
+	return f.dashboard, false, f.err
+}
+
+func (f *fakeDashboardStorage) Delete(ctx context.Context, name string, deleteValidation rest.ValidateObjectFunc, options *metav1.DeleteOptions) (runtime.Object, bool, error) {
+	f.recorded = append(f.recorded, auditlog.IsRecorded(ctx))
+	return f.dashboard, false, f.err
+}
+
+func TestAuditStorage(t *testing.T) {
+	dashboard := &dashv1.Dashboard{
+		ObjectMeta: metav1.ObjectMeta{Name: "dash", Namespace: "org-2", Generation: 3},
+		Spec: common.Unstructured{
+			Object: map[string]interface{}{"title": "Home"},
+		},
+	}
+	ctx := k8srequest.WithNamespace(context.Background(), "org-2")
+
+	t.Run("record the writes", func(t *testing.T) {
+		inner := &fakeDashboardStorage{dashboard: dashboard}
+		auditLog := &auditlogtest.FakeService{}
+		store := newAuditStorage(inner, auditLog)
+
+		_, err := store.Create(ctx, dashboard, nil, &metav1.CreateOptions{})
+		require.NoError(t, err)
+		_, _, err = store.Update(ctx, "dash", nil, nil, nil, false, &metav1.UpdateOptions{})
+		require.NoError(t, err)
+		_, _, err = store.Delete(ctx, "dash", nil, &metav1.DeleteOptions{})
+		require.NoError(t, err)
+
+		require.Equal(t, []bool{true, true, true}, inner.recorded)
+		require.Len(t, auditLog.Events, 3)
+		require.Equal(t, &auditlog.Event{
+			OrgID:        2,
+			Action:       auditlog.ActionDashboardSave,
+			ResourceKind: auditlog.ResourceKindDashboard,
+			ResourceUID:  "dash",
+			Details:      map[string]string{"title": "Home", "version": "3"},
+		}, auditLog.Events[0])
+		require.Equal(t, auditlog.ActionDashboardSave, auditLog.Events[1].Action)
+		require.Equal(t, auditlog.ActionDashboardDelete, auditLog.Events[2].Action)
+		require.Equal(t, map[string]string{"title": "Home"}, auditLog.Events[2].Details)
+	})
+
+	t.Run("do not record the failed writes", func(t *testing.T) {
+		inner := &fakeDashboardStorage{err: context.Canceled}
+		auditLog := &auditlogtest.FakeService{}
+		store := newAuditStorage(inner, auditLog)
+
+		_, err := store.Create(ctx, dashboard, nil, &metav1.CreateOptions{})
+		require.Error(t, err)
+		_, _, err = store.Delete(ctx, "dash", nil, &metav1.DeleteOptions{})
+		require.Error(t, err)
+		require.Empty(t, auditLog.Events)
+	})
+
+	t.Run("only support watch when the wrapped storage does", func(t *testing.T) {
+		_, ok := newAuditStorage(&fakeDashboardStorage{}, &auditlogtest.FakeService{}).(rest.Watcher)
+		require.False(t, ok)
+	})
+}
@@ -35,6 +35,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/accesscontrol"
 	"github.com/grafana/grafana/pkg/services/apiserver/builder"
 	"github.com/grafana/grafana/pkg/services/apiserver/endpoints/request"
+	"github.com/grafana/grafana/pkg/services/auditlog"
 	"github.com/grafana/grafana/pkg/services/dashboards"
 	"github.com/grafana/grafana/pkg/services/featuremgmt"
 	"github.com/grafana/grafana/pkg/services/folder"
@@ -84,6 +85,7 @@ type DashboardsAPIBuilder struct {
 	cfg                          *setting.Cfg
 	dualWriter                   dualwrite.Service
 	folderClient                 client.K8sHandler
+	auditLog                     auditlog.Service
 
 	log log.Logger
 	reg prometheus.Registerer
@@ -109,6 +111,7 @@ func RegisterAPIService(
 	folderStore folder.FolderStore,
 	restConfigProvider apiserver.RestConfigProvider,
 	userService user.Service,
+	auditLog auditlog.Service,
 ) *DashboardsAPIBuilder {
 	dbp := legacysql.NewDatabaseProvider(sql)
 	namespacer := request.GetNamespaceMapper(cfg)
@@ -131,6 +134,7 @@ func RegisterAPIService(
 		cfg:                          cfg,
 		dualWriter:                   dual,
 		folderClient:                 folderClient,
+		auditLog:                     auditLog,
 
 		legacy: &DashboardStorage{
 			Access:           legacy.NewDashboardAccess(dbp, namespacer, dashStore, provisioning, sorter),
@@ -483,10 +487,11 @@ func (b *DashboardsAPIBuilder) storageForVersion(
 	}
 
 	gr := dashboards.GroupResource()
-	storage[dashboards.StoragePath()], err = opts.DualWriteBuilder(gr, legacyStore, store)
+	dualStore, err := opts.DualWriteBuilder(gr, legacyStore, store)
 	if err != nil {
 		return err
 	}
+	storage[dashboards.StoragePath()] = newAuditStorage(dualStore, b.auditLog)
 
 	// Register the DTO endpoint that will consolidate all dashboard bits
 	storage[dashboards.StoragePath("dto")], err = NewDTOConnector(
@@ -94,6 +94,33 @@ func (s *SearchHandler) GetAPIRoutes(defs map[string]common.OpenAPIDefinition) *
 										Schema:      spec.StringProperty(),
 									},
 								},
+								{
+									ParameterProps: spec3.ParameterProps{
+										Name:        "datasource",
+										In:          "query",
+										Description: "dashboards with a panel using the datasource (uid)",
+										Required:    false,
+										Schema:      spec.StringProperty(),
+									},
+								},
+								{
+									ParameterProps: spec3.ParameterProps{
+										Name:        "metric",
+										In:          "query",
+										Description: "dashboards with a query selecting the metric",
+										Required:    false,
+										Schema:      spec.StringProperty(),
+									},
+								},
+								{
+									ParameterProps: spec3.ParameterProps{
+										Name:        "label",
+										In:          "query",
+										Description: "dashboards with a query matching or grouping by the label",
+										Required:    false,
+										Schema:      spec.StringProperty(),
+									},
+								},
 								{
 									ParameterProps: spec3.ParameterProps{
 										Name:        "sort",
@@ -363,6 +390,21 @@ func (s *SearchHandler) DoSearch(w http.ResponseWriter, r *http.Request) {
 		searchRequest.Options.Fields = append(searchRequest.Options.Fields, namesFilter...)
 	}
 
+	// The query filters, a dashboard matches when one of its queries matches each value
+	for _, filter := range []struct{ param, field string }{
+		{"datasource", search.DASHBOARD_DS_UIDS},
+		{"metric", search.DASHBOARD_QUERY_METRICS},
+		{"label", search.DASHBOARD_QUERY_LABELS},
+	} {
+		if values, ok := queryParams[filter.param]; ok {
+			searchRequest.Options.Fields = append(searchRequest.Options.Fields, &resource.Requirement{
+				Key:      resource.SEARCH_FIELD_PREFIX + filter.field,
+				Operator: "=",
+				Values:   values,
+			})
+		}
+	}
+
 	result, err := s.client.Search(ctx, searchRequest)
 	if err != nil {
 		errhttp.Write(ctx, err, w)
@@ -276,6 +276,31 @@ func TestSearchHandler(t *testing.T) {
 		}
 	})
 
+	t.Run("Query filters are added to the search request", func(t *testing.T) {
+		mockClient := &MockClient{}
+		searchHandler := SearchHandler{
+			log:      log.New("test", "test"),
+			client:   mockClient,
+			tracer:   tracing.NewNoopTracerService(),
+			features: featuremgmt.WithFeatures(),
+		}
+
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/search?folder=f1&datasource=prom&metric=http_requests_total&label=cluster&label=job", nil)
+		req.Header.Add("content-type", "application/json")
+		req = req.WithContext(identity.WithRequester(req.Context(), &user.SignedInUser{Namespace: "test"}))
+
+		searchHandler.DoSearch(rr, req)
+
+		require.NotNil(t, mockClient.LastSearchRequest)
+		require.Equal(t, []*resource.Requirement{
+			{Key: "folder", Operator: "=", Values: []string{"f1"}},
+			{Key: "fields.ds_uids", Operator: "=", Values: []string{"prom"}},
+			{Key: "fields.query_metrics", Operator: "=", Values: []string{"http_requests_total"}},
+			{Key: "fields.query_labels", Operator: "=", Values: []string{"cluster", "job"}},
+		}, mockClient.LastSearchRequest.Options.Fields)
+	})
+
 	t.Run("Sort - default sort by resource", func(t *testing.T) {
 		rows := make([]*resource.ResourceTableRow, len(mockResults))
 		for i, r := range mockResults {
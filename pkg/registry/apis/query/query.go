@@ -21,6 +21,8 @@ import (
 	"k8s.io/apiserver/pkg/endpoints/request"
 	"k8s.io/apiserver/pkg/registry/rest"
 
+	claims "github.com/grafana/authlib/types"
+	"github.com/grafana/grafana/pkg/apimachinery/identity"
 	query "github.com/grafana/grafana/pkg/apis/query/v0alpha1"
 	"github.com/grafana/grafana/pkg/expr/mathexp"
 	"github.com/grafana/grafana/pkg/infra/log"
@@ -106,6 +108,11 @@ func (r *queryREST) Connect(connectCtx context.Context, name string, _ runtime.O
 				span.RecordError(err)
 			})
 
+		if err := b.limitAnonymousQuery(ctx, httpreq); err != nil {
+			responder.Error(err)
+			return
+		}
+
 		raw := &query.QueryDataRequest{}
 		err := web.Bind(httpreq, raw)
 		if err != nil {
@@ -487,3 +494,19 @@ func alertQueryWithoutExpression(req parsedRequestInfo) bool {
 	}
 	return false
 }
+
+// limitAnonymousQuery returns an error when the anonymous device sending the query reached its query rate limit
+func (b *QueryAPIBuilder) limitAnonymousQuery(ctx context.Context, httpreq *http.Request) error {
+	if b.anonService == nil {
+		return nil
+	}
+	user, err := identity.GetRequester(ctx)
+	if err != nil || !user.IsIdentityType(claims.TypeAnonymous) {
+		return nil
+	}
+	if err := b.anonService.LimitQuery(ctx, httpreq); err != nil {
+		b.log.Debug("Anonymous query rate limit reached", "error", err)
+		return errorsK8s.NewTooManyRequests("anonymous query rate limit reached", 60)
+	}
+	return nil
+}
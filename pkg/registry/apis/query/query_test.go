@@ -3,6 +3,7 @@ package query
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -10,13 +11,17 @@ import (
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	data "github.com/grafana/grafana-plugin-sdk-go/experimental/apis/data/v0alpha1"
+	"github.com/grafana/grafana/pkg/apimachinery/identity"
 	"github.com/grafana/grafana/pkg/expr"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/infra/tracing"
 	"github.com/grafana/grafana/pkg/registry/apis/query/clientapi"
+	"github.com/grafana/grafana/pkg/services/anonymous/anontest"
 	"github.com/grafana/grafana/pkg/services/featuremgmt"
 	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/user"
 	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -32,7 +37,7 @@ func TestQueryRestConnectHandler(t *testing.T) {
 	}
 	qr := newQueryREST(b)
 	ctx := context.Background()
-	mr := mockResponder{}
+	mr := &mockResponder{}
 
 	handler, err := qr.Connect(ctx, "name", nil, mr)
 	require.NoError(t, err)
@@ -81,15 +86,44 @@ func TestQueryRestConnectHandler(t *testing.T) {
 	}, *b.client.(mockClient).lastCalledWithHeaders)
 }
 
+func TestQueryRestConnectHandlerAnonymousQueryLimit(t *testing.T) {
+	b := &QueryAPIBuilder{
+		client: mockClient{
+			lastCalledWithHeaders: &map[string]string{},
+		},
+		tracer: tracing.InitializeTracerForTest(),
+		parser: newQueryParser(expr.NewExpressionQueryReader(featuremgmt.WithFeatures()),
+			&legacyDataSourceRetriever{}, tracing.InitializeTracerForTest(), nil),
+		log:         log.New("test"),
+		anonService: &anontest.FakeService{ExpectedError: errors.New("limit reached")},
+	}
+	qr := newQueryREST(b)
+	mr := &mockResponder{}
+
+	handler, err := qr.Connect(context.Background(), "name", nil, mr)
+	require.NoError(t, err)
+
+	body := []byte(`{"queries": [{"datasource": {"type": "prometheus", "uid": "demo-prometheus"}, "expr": "up"}], "from": "now-1h", "to": "now"}`)
+	req := httptest.NewRequest(http.MethodPost, "/some-path", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req = req.WithContext(identity.WithRequester(req.Context(), &user.SignedInUser{IsAnonymous: true, OrgID: 1}))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	require.True(t, apierrors.IsTooManyRequests(mr.err))
+	require.Empty(t, *b.client.(mockClient).lastCalledWithHeaders)
+}
+
 type mockResponder struct {
+	err error
 }
 
 // Object writes the provided object to the response. Invoking this method multiple times is undefined.
-func (m mockResponder) Object(statusCode int, obj runtime.Object) {
+func (m *mockResponder) Object(statusCode int, obj runtime.Object) {
 }
 
 // Error writes the provided error to the response. This method may only be invoked once.
-func (m mockResponder) Error(err error) {
+func (m *mockResponder) Error(err error) {
+	m.err = err
 }
 
 type mockClient struct {
@@ -25,6 +25,7 @@ import (
 	"github.com/grafana/grafana/pkg/registry/apis/query/clientapi"
 	"github.com/grafana/grafana/pkg/registry/apis/query/queryschema"
 	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/anonymous"
 	"github.com/grafana/grafana/pkg/services/apiserver/builder"
 	"github.com/grafana/grafana/pkg/services/datasources"
 	"github.com/grafana/grafana/pkg/services/datasources/service"
@@ -50,6 +51,9 @@ type QueryAPIBuilder struct {
 	registry   query.DataSourceApiServerRegistry
 	converter  *expr.ResultConverter
 	queryTypes *query.QueryTypeDefinitionList
+
+	// anonService limits the queries of the anonymous devices
+	anonService anonymous.Service
 }
 
 func NewQueryAPIBuilder(features featuremgmt.FeatureToggles,
@@ -105,6 +109,7 @@ func RegisterAPIService(features featuremgmt.FeatureToggles,
 	registerer prometheus.Registerer,
 	tracer tracing.Tracer,
 	legacy service.LegacyDataSourceLookup,
+	anonService anonymous.Service,
 ) (*QueryAPIBuilder, error) {
 	if !featuremgmt.AnyEnabled(features,
 		featuremgmt.FlagQueryService,
@@ -134,8 +139,12 @@ func RegisterAPIService(features featuremgmt.FeatureToggles,
 		client.NewDataSourceRegistryFromStore(pluginStore, dataSourcesService),
 		legacy, registerer, tracer,
 	)
+	if err != nil {
+		return nil, err
+	}
+	builder.anonService = anonService
 	apiregistration.RegisterAPI(builder)
-	return builder, err
+	return builder, nil
 }
 
 func (b *QueryAPIBuilder) GetGroupVersion() schema.GroupVersion {
@@ -0,0 +1,175 @@
+package customroles
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/middleware/requestmeta"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+type api struct {
+	service *Service
+}
+
+func registerAPI(routeRegister routing.RouteRegister, ac accesscontrol.AccessControl, s *Service) {
+	a := &api{service: s}
+	authorize := accesscontrol.Middleware(ac)
+	roleScope := accesscontrol.Scope("roles", "uid", accesscontrol.Parameter(":roleUID"))
+	userScope := accesscontrol.Scope("users", "id", accesscontrol.Parameter(":userId"))
+	teamScope := accesscontrol.Scope("teams", "id", accesscontrol.Parameter(":teamId"))
+
+	routeRegister.Group("/api/access-control", func(r routing.RouteRegister) {
+		r.Get("/roles", authorize(accesscontrol.EvalPermission(accesscontrol.ActionRolesRead)), routing.Wrap(a.listRoles))
+		r.Get("/roles/:roleUID", authorize(accesscontrol.EvalPermission(accesscontrol.ActionRolesRead, roleScope)), routing.Wrap(a.getRole))
+		r.Post("/roles", authorize(accesscontrol.EvalPermission(accesscontrol.ActionRolesWrite, accesscontrol.ScopePermissionsDelegate)), routing.Wrap(a.createRole))
+		r.Put("/roles/:roleUID", authorize(accesscontrol.EvalPermission(accesscontrol.ActionRolesWrite, accesscontrol.ScopePermissionsDelegate)), routing.Wrap(a.updateRole))
+		r.Delete("/roles/:roleUID", authorize(accesscontrol.EvalPermission(accesscontrol.ActionRolesDelete, accesscontrol.ScopePermissionsDelegate)), routing.Wrap(a.deleteRole))
+
+		r.Get("/users/:userId/roles", authorize(accesscontrol.EvalPermission(accesscontrol.ActionUsersRolesRead, userScope)), routing.Wrap(a.getUserRoles))
+		r.Post("/users/:userId/roles", authorize(accesscontrol.EvalPermission(accesscontrol.ActionUsersRolesAdd, accesscontrol.ScopePermissionsDelegate)), routing.Wrap(a.addUserRole))
+		r.Delete("/users/:userId/roles/:roleUID", authorize(accesscontrol.EvalPermission(accesscontrol.ActionUsersRolesRemove, accesscontrol.ScopePermissionsDelegate)), routing.Wrap(a.removeUserRole))
+
+		r.Get("/teams/:teamId/roles", authorize(accesscontrol.EvalPermission(accesscontrol.ActionTeamsRolesRead, teamScope)), routing.Wrap(a.getTeamRoles))
+		r.Post("/teams/:teamId/roles", authorize(accesscontrol.EvalPermission(accesscontrol.ActionTeamsRolesAdd, accesscontrol.ScopePermissionsDelegate)), routing.Wrap(a.addTeamRole))
+		r.Delete("/teams/:teamId/roles/:roleUID", authorize(accesscontrol.EvalPermission(accesscontrol.ActionTeamsRolesRemove, accesscontrol.ScopePermissionsDelegate)), routing.Wrap(a.removeTeamRole))
+	}, requestmeta.SetOwner(requestmeta.TeamAuth))
+}
+
+// GET /api/access-control/roles
+func (a *api) listRoles(c *contextmodel.ReqContext) response.Response {
+	roles, err := a.service.ListRoles(c.Req.Context(), c.GetOrgID())
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to list the roles", err)
+	}
+	return response.JSON(http.StatusOK, roles)
+}
+
+// GET /api/access-control/roles/:roleUID
+func (a *api) getRole(c *contextmodel.ReqContext) response.Response {
+	role, err := a.service.GetRole(c.Req.Context(), c.GetOrgID(), web.Params(c.Req)[":roleUID"])
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to get the role", err)
+	}
+	return response.JSON(http.StatusOK, role)
+}
+
+// POST /api/access-control/roles
+func (a *api) createRole(c *contextmodel.ReqContext) response.Response {
+	cmd := RoleCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	role, err := a.service.CreateRole(c.Req.Context(), c.SignedInUser, cmd)
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to create the role", err)
+	}
+	return response.JSON(http.StatusCreated, role)
+}
+
+// PUT /api/access-control/roles/:roleUID
+func (a *api) updateRole(c *contextmodel.ReqContext) response.Response {
+	cmd := RoleCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	role, err := a.service.UpdateRole(c.Req.Context(), c.SignedInUser, web.Params(c.Req)[":roleUID"], cmd)
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to update the role", err)
+	}
+	return response.JSON(http.StatusOK, role)
+}
+
+// DELETE /api/access-control/roles/:roleUID?force=true
+func (a *api) deleteRole(c *contextmodel.ReqContext) response.Response {
+	if err := a.service.DeleteRole(c.Req.Context(), c.SignedInUser, web.Params(c.Req)[":roleUID"], c.QueryBool("force")); err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to delete the role", err)
+	}
+	return response.Success("Role deleted")
+}
+
+// GET /api/access-control/users/:userId/roles
+func (a *api) getUserRoles(c *contextmodel.ReqContext) response.Response {
+	userID, err := strconv.ParseInt(web.Params(c.Req)[":userId"], 10, 64)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "userId is invalid", err)
+	}
+	roles, err := a.service.GetUserRoles(c.Req.Context(), c.GetOrgID(), userID)
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to get the roles of the user", err)
+	}
+	return response.JSON(http.StatusOK, roles)
+}
+
+// POST /api/access-control/users/:userId/roles
+func (a *api) addUserRole(c *contextmodel.ReqContext) response.Response {
+	userID, err := strconv.ParseInt(web.Params(c.Req)[":userId"], 10, 64)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "userId is invalid", err)
+	}
+	cmd := AssignmentCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	if err := a.service.AddUserRole(c.Req.Context(), c.SignedInUser, userID, cmd.RoleUID); err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to assign the role", err)
+	}
+	return response.Success("Role assigned")
+}
+
+// DELETE /api/access-control/users/:userId/roles/:roleUID
+func (a *api) removeUserRole(c *contextmodel.ReqContext) response.Response {
+	userID, err := strconv.ParseInt(web.Params(c.Req)[":userId"], 10, 64)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "userId is invalid", err)
+	}
+	if err := a.service.RemoveUserRole(c.Req.Context(), c.SignedInUser, userID, web.Params(c.Req)[":roleUID"]); err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to revoke the role", err)
+	}
+	return response.Success("Role revoked")
+}
+
+// GET /api/access-control/teams/:teamId/roles
+func (a *api) getTeamRoles(c *contextmodel.ReqContext) response.Response {
+	teamID, err := strconv.ParseInt(web.Params(c.Req)[":teamId"], 10, 64)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "teamId is invalid", err)
+	}
+	roles, err := a.service.GetTeamRoles(c.Req.Context(), c.GetOrgID(), teamID)
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to get the roles of the team", err)
+	}
+	return response.JSON(http.StatusOK, roles)
+}
+
+// POST /api/access-control/teams/:teamId/roles
+func (a *api) addTeamRole(c *contextmodel.ReqContext) response.Response {
+	teamID, err := strconv.ParseInt(web.Params(c.Req)[":teamId"], 10, 64)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "teamId is invalid", err)
+	}
+	cmd := AssignmentCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	if err := a.service.AddTeamRole(c.Req.Context(), c.SignedInUser, teamID, cmd.RoleUID); err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to assign the role", err)
+	}
+	return response.Success("Role assigned")
+}
+
+// DELETE /api/access-control/teams/:teamId/roles/:roleUID
+func (a *api) removeTeamRole(c *contextmodel.ReqContext) response.Response {
+	teamID, err := strconv.ParseInt(web.Params(c.Req)[":teamId"], 10, 64)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "teamId is invalid", err)
+	}
+	if err := a.service.RemoveTeamRole(c.Req.Context(), c.SignedInUser, teamID, web.Params(c.Req)[":roleUID"]); err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to revoke the role", err)
+	}
+	return response.Success("Role revoked")
+}
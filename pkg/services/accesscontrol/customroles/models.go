@@ -0,0 +1,52 @@
+package customroles
+
+import (
+	"github.com/grafana/grafana/pkg/apimachinery/errutil"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+)
+
+const invalidRoleMessage = `Role is invalid: {{ .Public.reason }}`
+
+var (
+	ErrRoleNotFound = errutil.NotFound("customroles.notFound", errutil.WithPublicMessage("Role not found"))
+	ErrInvalidRole  = errutil.BadRequest("customroles.invalid").
+			MustTemplate(invalidRoleMessage, errutil.WithPublic(invalidRoleMessage))
+	ErrRoleExists   = errutil.Conflict("customroles.exists", errutil.WithPublicMessage("A role with the same uid or name already exists"))
+	ErrVersion      = errutil.Conflict("customroles.version", errutil.WithPublicMessage("The version of the role must be greater than the current version"))
+	ErrRoleAssigned = errutil.BadRequest("customroles.assigned", errutil.WithPublicMessage("The role is assigned, remove the assignments or force the deletion"))
+	ErrEscalation   = errutil.Forbidden("customroles.escalation", errutil.WithPublicMessage("The role grants permissions you don't have"))
+	ErrGlobalRole   = errutil.Forbidden("customroles.global", errutil.WithPublicMessage("Only Grafana server admins can manage global roles"))
+)
+
+func errInvalidRole(reason string) error {
+	return ErrInvalidRole.Build(errutil.TemplateData{Public: map[string]any{"reason": reason}})
+}
+
+// RoleCommand creates or updates a custom role
+type RoleCommand struct {
+	// UID of the role, generated when empty
+	UID string `json:"uid"`
+	// Name of the role, prefixed with custom:
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+	Description string `json:"description"`
+	Group       string `json:"group"`
+	// Version of the role, an update must increase it. Incremented when zero.
+	Version int64 `json:"version"`
+	Hidden  bool  `json:"hidden"`
+	// Global roles are shared by all the organizations, and managed by the Grafana server admins
+	Global      bool                       `json:"global"`
+	Permissions []accesscontrol.Permission `json:"permissions"`
+}
+
+// RoleRef references a role by uid or by name
+type RoleRef struct {
+	UID    string `json:"uid" yaml:"uid"`
+	Name   string `json:"name" yaml:"name"`
+	Global bool   `json:"global" yaml:"global"`
+}
+
+// AssignmentCommand assigns a role to a user or a team
+type AssignmentCommand struct {
+	RoleUID string `json:"roleUid" binding:"Required"`
+}
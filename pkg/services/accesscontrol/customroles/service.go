@@ -0,0 +1,446 @@
+package customroles
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/apimachinery/identity"
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/accesscontrol/database"
+	"github.com/grafana/grafana/pkg/services/accesscontrol/permreg"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+const maxNameLength = 190
+
+type store interface {
+	ListRoles(ctx context.Context, orgID int64, namePrefix string) ([]*accesscontrol.RoleDTO, error)
+	GetRole(ctx context.Context, orgID int64, uid string) (*accesscontrol.RoleDTO, error)
+	SaveRole(ctx context.Context, role accesscontrol.RoleDTO) (*accesscontrol.RoleDTO, error)
+	DeleteRole(ctx context.Context, roleID int64) error
+	CountRoleAssignments(ctx context.Context, roleID int64) (int64, error)
+	GetUserRoles(ctx context.Context, orgID, userID int64, namePrefix string) ([]*accesscontrol.RoleDTO, error)
+	AddUserRole(ctx context.Context, orgID, userID, roleID int64) error
+	RemoveUserRole(ctx context.Context, orgID, userID, roleID int64) error
+	GetTeamRoles(ctx context.Context, orgID, teamID int64, namePrefix string) ([]*accesscontrol.RoleDTO, error)
+	AddTeamRole(ctx context.Context, orgID, teamID, roleID int64) error
+	RemoveTeamRole(ctx context.Context, orgID, teamID, roleID int64) error
+	GetTeamIDByName(ctx context.Context, orgID int64, name string) (int64, error)
+	IsOrgUser(ctx context.Context, orgID, userID int64) (bool, error)
+	IsOrgTeam(ctx context.Context, orgID, teamID int64) (bool, error)
+}
+
+// Service manages the custom roles, and their assignments to users, service accounts and teams.
+// The users can only manage the roles granting permissions they have.
+type Service struct {
+	store        store
+	ac           accesscontrol.AccessControl
+	acService    accesscontrol.Service
+	permRegistry permreg.PermissionRegistry
+	log          log.Logger
+}
+
+func ProvideService(sqlStore db.DB, ac accesscontrol.AccessControl, acService accesscontrol.Service, permRegistry permreg.PermissionRegistry, routeRegister routing.RouteRegister) *Service {
+	s := NewService(database.ProvideService(sqlStore), ac, acService, permRegistry)
+	registerAPI(routeRegister, ac, s)
+	return s
+}
+
+func NewService(store store, ac accesscontrol.AccessControl, acService accesscontrol.Service, permRegistry permreg.PermissionRegistry) *Service {
+	return &Service{
+		store:        store,
+		ac:           ac,
+		acService:    acService,
+		permRegistry: permRegistry,
+		log:          log.New("accesscontrol.customroles"),
+	}
+}
+
+// ListRoles returns the custom roles of an organization, and the global custom roles
+func (s *Service) ListRoles(ctx context.Context, orgID int64) ([]*accesscontrol.RoleDTO, error) {
+	return s.store.ListRoles(ctx, orgID, accesscontrol.CustomRolePrefix)
+}
+
+// GetRole returns a custom role of an organization, or a global custom role
+func (s *Service) GetRole(ctx context.Context, orgID int64, uid string) (*accesscontrol.RoleDTO, error) {
+	role, err := s.store.GetRole(ctx, orgID, uid)
+	if err != nil {
+		if errors.Is(err, accesscontrol.ErrRoleNotFound) {
+			return nil, ErrRoleNotFound.Errorf("role %s not found", uid)
+		}
+		return nil, err
+	}
+	if !role.IsCustom() {
+		return nil, ErrRoleNotFound.Errorf("role %s is not a custom role", uid)
+	}
+	return role, nil
+}
+
+// CreateRole creates a custom role in the organization of the user, or a global role
+func (s *Service) CreateRole(ctx context.Context, user identity.Requester, cmd RoleCommand) (*accesscontrol.RoleDTO, error) {
+	if err := s.canManage(ctx, user, cmd.Global, cmd.Permissions); err != nil {
+		return nil, err
+	}
+	orgID := roleOrgID(user.GetOrgID(), cmd.Global)
+	if cmd.UID != "" {
+		if _, err := s.store.GetRole(ctx, orgID, cmd.UID); err == nil {
+			return nil, ErrRoleExists.Errorf("role %s already exists", cmd.UID)
+		} else if !errors.Is(err, accesscontrol.ErrRoleNotFound) {
+			return nil, err
+		}
+	}
+	if err := s.checkNameAvailable(ctx, orgID, cmd.Name, ""); err != nil {
+		return nil, err
+	}
+	return s.save(ctx, orgID, cmd, nil)
+}
+
+// UpdateRole updates a custom role, the version of the role must be increased
+func (s *Service) UpdateRole(ctx context.Context, user identity.Requester, uid string, cmd RoleCommand) (*accesscontrol.RoleDTO, error) {
+	existing, err := s.GetRole(ctx, user.GetOrgID(), uid)
+	if err != nil {
+		return nil, err
+	}
+	// The users need the permissions they remove from the role as well as the ones they add
+	if err := s.canManage(ctx, user, existing.Global(), slices.Concat(existing.Permissions, cmd.Permissions)); err != nil {
+		return nil, err
+	}
+	cmd.UID, cmd.Global = existing.UID, existing.Global()
+	if cmd.Version != 0 && cmd.Version <= existing.Version {
+		return nil, ErrVersion.Errorf("version %d of role %s is not greater than %d", cmd.Version, uid, existing.Version)
+	}
+	if err := s.checkNameAvailable(ctx, existing.OrgID, cmd.Name, existing.UID); err != nil {
+		return nil, err
+	}
+	return s.save(ctx, existing.OrgID, cmd, existing)
+}
+
+// DeleteRole deletes a custom role. An assigned role is only deleted when forced, which revokes its assignments.
+func (s *Service) DeleteRole(ctx context.Context, user identity.Requester, uid string, force bool) error {
+	existing, err := s.GetRole(ctx, user.GetOrgID(), uid)
+	if err != nil {
+		return err
+	}
+	if err := s.canManage(ctx, user, existing.Global(), existing.Permissions); err != nil {
+		return err
+	}
+	return s.delete(ctx, existing, force)
+}
+
+// GetUserRoles returns the custom roles assigned to a user or a service account in an organization
+func (s *Service) GetUserRoles(ctx context.Context, orgID, userID int64) ([]*accesscontrol.RoleDTO, error) {
+	return s.store.GetUserRoles(ctx, orgID, userID, accesscontrol.CustomRolePrefix)
+}
+
+// AddUserRole assigns a custom role to a user or a service account of the organization of the user
+func (s *Service) AddUserRole(ctx context.Context, user identity.Requester, userID int64, roleUID string) error {
+	role, err := s.assignable(ctx, user, roleUID)
+	if err != nil {
+		return err
+	}
+	member, err := s.store.IsOrgUser(ctx, user.GetOrgID(), userID)
+	if err != nil {
+		return err
+	}
+	if !member {
+		return accesscontrol.ErrAssignmentEntityNotFound.Build(accesscontrol.ErrAssignmentEntityNotFoundData("user"))
+	}
+	if err := s.store.AddUserRole(ctx, user.GetOrgID(), userID, role.ID); err != nil {
+		return err
+	}
+	s.clearUserPermissionCache(user.GetOrgID(), userID)
+	return nil
+}
+
+// RemoveUserRole revokes a custom role assigned to a user or a service account of the organization of the user
+func (s *Service) RemoveUserRole(ctx context.Context, user identity.Requester, userID int64, roleUID string) error {
+	role, err := s.assignable(ctx, user, roleUID)
+	if err != nil {
+		return err
+	}
+	if err := s.store.RemoveUserRole(ctx, user.GetOrgID(), userID, role.ID); err != nil {
+		return err
+	}
+	s.clearUserPermissionCache(user.GetOrgID(), userID)
+	return nil
+}
+
+// GetTeamRoles returns the custom roles assigned to a team
+func (s *Service) GetTeamRoles(ctx context.Context, orgID, teamID int64) ([]*accesscontrol.RoleDTO, error) {
+	return s.store.GetTeamRoles(ctx, orgID, teamID, accesscontrol.CustomRolePrefix)
+}
+
+// AddTeamRole assigns a custom role to a team of the organization of the user
+func (s *Service) AddTeamRole(ctx context.Context, user identity.Requester, teamID int64, roleUID string) error {
+	role, err := s.assignable(ctx, user, roleUID)
+	if err != nil {
+		return err
+	}
+	member, err := s.store.IsOrgTeam(ctx, user.GetOrgID(), teamID)
+	if err != nil {
+		return err
+	}
+	if !member {
+		return accesscontrol.ErrAssignmentEntityNotFound.Build(accesscontrol.ErrAssignmentEntityNotFoundData("team"))
+	}
+	return s.store.AddTeamRole(ctx, user.GetOrgID(), teamID, role.ID)
+}
+
+// RemoveTeamRole revokes a custom role assigned to a team of the organization of the user
+func (s *Service) RemoveTeamRole(ctx context.Context, user identity.Requester, teamID int64, roleUID string) error {
+	role, err := s.assignable(ctx, user, roleUID)
+	if err != nil {
+		return err
+	}
+	return s.store.RemoveTeamRole(ctx, user.GetOrgID(), teamID, role.ID)
+}
+
+// ProvisionRole creates a custom role, or updates it when the version is greater than the stored one
+func (s *Service) ProvisionRole(ctx context.Context, orgID int64, cmd RoleCommand) error {
+	orgID = roleOrgID(orgID, cmd.Global)
+	var existing *accesscontrol.RoleDTO
+	if cmd.UID != "" {
+		role, err := s.GetRole(ctx, orgID, cmd.UID)
+		if err != nil && !errors.Is(err, ErrRoleNotFound) {
+			return err
+		}
+		existing = role
+	} else {
+		role, err := s.findByName(ctx, orgID, cmd.Name)
+		if err != nil && !errors.Is(err, ErrRoleNotFound) {
+			return err
+		}
+		existing = role
+	}
+
+	if existing != nil {
+		if existing.OrgID != orgID {
+			return ErrRoleExists.Errorf("role %s exists in another organization", existing.UID)
+		}
+		if cmd.Version <= existing.Version {
+			s.log.Debug("Provisioned role is up to date", "role", existing.LogID(), "version", existing.Version)
+			return nil
+		}
+		cmd.UID = existing.UID
+	}
+	if err := s.checkNameAvailable(ctx, orgID, cmd.Name, cmd.UID); err != nil {
+		return err
+	}
+	role, err := s.save(ctx, orgID, cmd, existing)
+	if err != nil {
+		return err
+	}
+	s.log.Info("Provisioned role", "role", role.LogID(), "version", role.Version)
+	return nil
+}
+
+// DeleteProvisionedRole deletes a custom role when it exists
+func (s *Service) DeleteProvisionedRole(ctx context.Context, orgID int64, ref RoleRef, force bool) error {
+	role, err := s.resolve(ctx, orgID, ref)
+	if err != nil {
+		if errors.Is(err, ErrRoleNotFound) {
+			return nil
+		}
+		return err
+	}
+	return s.delete(ctx, role, force)
+}
+
+// ProvisionTeamRole assigns a custom role to a team identified by its name, or revokes it
+func (s *Service) ProvisionTeamRole(ctx context.Context, orgID int64, teamName string, ref RoleRef, present bool) error {
+	teamID, err := s.store.GetTeamIDByName(ctx, orgID, teamName)
+	if err != nil {
+		return err
+	}
+	role, err := s.resolve(ctx, orgID, ref)
+	if err != nil {
+		if !present && errors.Is(err, ErrRoleNotFound) {
+			return nil
+		}
+		return err
+	}
+	if present {
+		return s.store.AddTeamRole(ctx, orgID, teamID, role.ID)
+	}
+	return s.store.RemoveTeamRole(ctx, orgID, teamID, role.ID)
+}
+
+func (s *Service) save(ctx context.Context, orgID int64, cmd RoleCommand, existing *accesscontrol.RoleDTO) (*accesscontrol.RoleDTO, error) {
+	permissions, err := s.validate(&cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	role := accesscontrol.RoleDTO{
+		OrgID:       orgID,
+		UID:         cmd.UID,
+		Name:        cmd.Name,
+		DisplayName: cmd.DisplayName,
+		Description: cmd.Description,
+		Group:       cmd.Group,
+		Version:     cmd.Version,
+		Hidden:      cmd.Hidden,
+		Permissions: permissions,
+	}
+	if role.UID == "" {
+		role.UID = util.GenerateShortUID()
+	}
+	if role.Version == 0 {
+		role.Version = 1
+		if existing != nil {
+			role.Version = existing.Version + 1
+		}
+	}
+	return s.store.SaveRole(ctx, role)
+}
+
+func (s *Service) delete(ctx context.Context, role *accesscontrol.RoleDTO, force bool) error {
+	if !force {
+		count, err := s.store.CountRoleAssignments(ctx, role.ID)
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrRoleAssigned.Errorf("role %s has %d assignments", role.UID, count)
+		}
+	}
+	if err := s.store.DeleteRole(ctx, role.ID); err != nil {
+		return err
+	}
+	s.log.Info("Deleted role", "role", role.LogID())
+	return nil
+}
+
+// clearUserPermissionCache makes the assignment changes of a user or a service account effective immediately
+func (s *Service) clearUserPermissionCache(orgID, userID int64) {
+	// the cache keys of the users and the service accounts differ
+	for _, isServiceAccount := range []bool{false, true} {
+		s.acService.ClearUserPermissionCache(&user.SignedInUser{UserID: userID, OrgID: orgID, IsServiceAccount: isServiceAccount})
+	}
+}
+
+// validate checks the role, and returns its permissions without duplicates
+func (s *Service) validate(cmd *RoleCommand) ([]accesscontrol.Permission, error) {
+	if !strings.HasPrefix(cmd.Name, accesscontrol.CustomRolePrefix) || len(cmd.Name) == len(accesscontrol.CustomRolePrefix) {
+		return nil, errInvalidRole(fmt.Sprintf("the name must start with %q", accesscontrol.CustomRolePrefix))
+	}
+	if len(cmd.Name) > maxNameLength || len(cmd.DisplayName) > maxNameLength || len(cmd.Group) > maxNameLength {
+		return nil, errInvalidRole(fmt.Sprintf("the name, display name and group are limited to %d characters", maxNameLength))
+	}
+	if cmd.UID != "" && (!util.IsValidShortUID(cmd.UID) || util.IsShortUIDTooLong(cmd.UID)) {
+		return nil, errInvalidRole("the uid is invalid")
+	}
+
+	permissions := make([]accesscontrol.Permission, 0, len(cmd.Permissions))
+	seen := make(map[accesscontrol.Permission]bool, len(cmd.Permissions))
+	for _, p := range cmd.Permissions {
+		p = accesscontrol.Permission{Action: p.Action, Scope: p.Scope}
+		if seen[p] {
+			continue
+		}
+		seen[p] = true
+		if err := s.permRegistry.IsPermissionValid(p.Action, p.Scope); err != nil {
+			return nil, err
+		}
+		permissions = append(permissions, p)
+	}
+	return permissions, nil
+}
+
+// canManage checks that a user can manage a role granting the permissions.
+// Only the Grafana server admins manage the global roles.
+func (s *Service) canManage(ctx context.Context, user identity.Requester, global bool, permissions []accesscontrol.Permission) error {
+	if global && !user.GetIsGrafanaAdmin() {
+		return ErrGlobalRole.Errorf("user is not a Grafana server admin")
+	}
+	for _, p := range permissions {
+		ok, err := s.ac.Evaluate(ctx, user, s.evaluator(p))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrEscalation.Errorf("user does not have the permission %s on %s", p.Action, p.Scope)
+		}
+	}
+	return nil
+}
+
+// evaluator returns the evaluator of a permission. A scoped action without scope
+// is granted on all the resources, so the user must hold it on the wildcard of every kind.
+func (s *Service) evaluator(p accesscontrol.Permission) accesscontrol.Evaluator {
+	if p.Scope != "" {
+		return accesscontrol.EvalPermission(p.Action, p.Scope)
+	}
+	prefixes, _ := s.permRegistry.GetScopePrefixes(p.Action)
+	if len(prefixes) == 0 {
+		return accesscontrol.EvalPermission(p.Action)
+	}
+	evaluators := make([]accesscontrol.Evaluator, 0, len(prefixes))
+	for prefix := range prefixes {
+		kind, _, _ := strings.Cut(prefix, ":")
+		evaluators = append(evaluators, accesscontrol.EvalPermission(p.Action, kind+":*"))
+	}
+	return accesscontrol.EvalAll(evaluators...)
+}
+
+// assignable returns a role the user can assign
+func (s *Service) assignable(ctx context.Context, user identity.Requester, roleUID string) (*accesscontrol.RoleDTO, error) {
+	role, err := s.GetRole(ctx, user.GetOrgID(), roleUID)
+	if err != nil {
+		return nil, err
+	}
+	// Assigning a global role in an organization does not need to be a server admin
+	if err := s.canManage(ctx, user, false, role.Permissions); err != nil {
+		return nil, err
+	}
+	return role, nil
+}
+
+func (s *Service) checkNameAvailable(ctx context.Context, orgID int64, name, uid string) error {
+	role, err := s.findByName(ctx, orgID, name)
+	if err != nil {
+		if errors.Is(err, ErrRoleNotFound) {
+			return nil
+		}
+		return err
+	}
+	if role.UID != uid && role.OrgID == orgID {
+		return ErrRoleExists.Errorf("role %s already exists", name)
+	}
+	return nil
+}
+
+func (s *Service) findByName(ctx context.Context, orgID int64, name string) (*accesscontrol.RoleDTO, error) {
+	roles, err := s.store.ListRoles(ctx, orgID, name)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range roles {
+		if r.Name == name && r.OrgID == orgID && r.IsCustom() {
+			return r, nil
+		}
+	}
+	return nil, ErrRoleNotFound.Errorf("role %s not found", name)
+}
+
+func (s *Service) resolve(ctx context.Context, orgID int64, ref RoleRef) (*accesscontrol.RoleDTO, error) {
+	orgID = roleOrgID(orgID, ref.Global)
+	if ref.UID != "" {
+		return s.GetRole(ctx, orgID, ref.UID)
+	}
+	return s.findByName(ctx, orgID, ref.Name)
+}
+
+func roleOrgID(orgID int64, global bool) int64 {
+	if global {
+		return accesscontrol.GlobalOrgID
+	}
+	return orgID
+}
@@ -0,0 +1,226 @@
+package customroles
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/apimachinery/identity"
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/accesscontrol/acimpl"
+	"github.com/grafana/grafana/pkg/services/accesscontrol/actest"
+	"github.com/grafana/grafana/pkg/services/accesscontrol/database"
+	"github.com/grafana/grafana/pkg/services/accesscontrol/permreg"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/team"
+)
+
+func TestIntegrationService(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.Background()
+	sqlStore := db.InitTestDB(t)
+	registry := permreg.ProvidePermissionRegistry()
+	require.NoError(t, registry.RegisterPermission("dashboards:read", "dashboards:uid:*"))
+	require.NoError(t, registry.RegisterPermission("dashboards:write", "dashboards:uid:*"))
+	acService := &fakeCacheService{}
+	svc := NewService(database.ProvideService(sqlStore), acimpl.ProvideAccessControlTest(), acService, registry)
+
+	readDashboards := accesscontrol.Permission{Action: "dashboards:read", Scope: "dashboards:uid:*"}
+	writeDashboards := accesscontrol.Permission{Action: "dashboards:write", Scope: "dashboards:uid:*"}
+	admin := &identity.StaticRequester{OrgID: 1, Permissions: map[int64]map[string][]string{
+		1: {"dashboards:read": {"dashboards:uid:*"}, "dashboards:write": {"dashboards:uid:*"}},
+	}}
+	reader := &identity.StaticRequester{OrgID: 1, Permissions: map[int64]map[string][]string{
+		1: {"dashboards:read": {"dashboards:uid:*"}},
+	}}
+
+	var teamID, otherTeamID int64
+	require.NoError(t, sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+		if _, err := sess.Insert(&org.OrgUser{OrgID: 1, UserID: 2, Role: org.RoleViewer, Created: time.Now(), Updated: time.Now()}); err != nil {
+			return err
+		}
+		if _, err := sess.Insert(&org.OrgUser{OrgID: 2, UserID: 3, Role: org.RoleViewer, Created: time.Now(), Updated: time.Now()}); err != nil {
+			return err
+		}
+		tm := &team.Team{UID: "writers", OrgID: 1, Name: "Writers", Created: time.Now(), Updated: time.Now()}
+		if _, err := sess.Insert(tm); err != nil {
+			return err
+		}
+		teamID = tm.ID
+		other := &team.Team{UID: "others", OrgID: 2, Name: "Others", Created: time.Now(), Updated: time.Now()}
+		if _, err := sess.Insert(other); err != nil {
+			return err
+		}
+		otherTeamID = other.ID
+		return nil
+	}))
+
+	t.Run("should create, get and list custom roles", func(t *testing.T) {
+		role, err := svc.CreateRole(ctx, admin, RoleCommand{
+			UID:         "dashboardsreader",
+			Name:        "custom:dashboards:reader",
+			Permissions: []accesscontrol.Permission{readDashboards, readDashboards},
+		})
+		require.NoError(t, err)
+		require.Equal(t, int64(1), role.OrgID)
+		require.Equal(t, int64(1), role.Version)
+		require.Len(t, role.Permissions, 1)
+
+		got, err := svc.GetRole(ctx, 1, "dashboardsreader")
+		require.NoError(t, err)
+		require.Equal(t, role.ID, got.ID)
+
+		roles, err := svc.ListRoles(ctx, 1)
+		require.NoError(t, err)
+		require.Len(t, roles, 1)
+
+		_, err = svc.CreateRole(ctx, admin, RoleCommand{UID: "dashboardsreader", Name: "custom:other"})
+		require.ErrorIs(t, err, ErrRoleExists)
+		_, err = svc.CreateRole(ctx, admin, RoleCommand{Name: "custom:dashboards:reader"})
+		require.ErrorIs(t, err, ErrRoleExists)
+	})
+
+	t.Run("should validate custom roles", func(t *testing.T) {
+		_, err := svc.CreateRole(ctx, admin, RoleCommand{Name: "dashboards:reader"})
+		require.ErrorIs(t, err, ErrInvalidRole)
+		_, err = svc.CreateRole(ctx, admin, RoleCommand{Name: "custom:"})
+		require.ErrorIs(t, err, ErrInvalidRole)
+		_, err = svc.CreateRole(ctx, admin, RoleCommand{Name: "custom:folders", Permissions: []accesscontrol.Permission{
+			{Action: "dashboards:read", Scope: "folders:uid:*"},
+		}})
+		require.Error(t, err)
+	})
+
+	t.Run("should prevent privilege escalation", func(t *testing.T) {
+		_, err := svc.CreateRole(ctx, reader, RoleCommand{Name: "custom:dashboards:writer", Permissions: []accesscontrol.Permission{writeDashboards}})
+		require.ErrorIs(t, err, ErrEscalation)
+		_, err = svc.CreateRole(ctx, admin, RoleCommand{Name: "custom:global", Global: true})
+		require.ErrorIs(t, err, ErrGlobalRole)
+	})
+
+	t.Run("should require the wildcard scope to manage a scoped action without scope", func(t *testing.T) {
+		folderWriter := &identity.StaticRequester{OrgID: 1, Permissions: map[int64]map[string][]string{
+			1: {"dashboards:write": {"dashboards:uid:abc"}},
+		}}
+		unscoped := []accesscontrol.Permission{{Action: "dashboards:write"}}
+		_, err := svc.CreateRole(ctx, folderWriter, RoleCommand{Name: "custom:dashboards:unscoped", Permissions: unscoped})
+		require.ErrorIs(t, err, ErrEscalation)
+
+		wildcardWriter := &identity.StaticRequester{OrgID: 1, Permissions: map[int64]map[string][]string{
+			1: {"dashboards:write": {"dashboards:*"}},
+		}}
+		_, err = svc.CreateRole(ctx, wildcardWriter, RoleCommand{Name: "custom:dashboards:unscoped", Permissions: unscoped})
+		require.NotErrorIs(t, err, ErrEscalation)
+	})
+
+	t.Run("should require a greater version on update", func(t *testing.T) {
+		_, err := svc.UpdateRole(ctx, admin, "dashboardsreader", RoleCommand{Name: "custom:dashboards:reader", Version: 1})
+		require.ErrorIs(t, err, ErrVersion)
+
+		role, err := svc.UpdateRole(ctx, admin, "dashboardsreader", RoleCommand{
+			Name:        "custom:dashboards:reader",
+			Permissions: []accesscontrol.Permission{readDashboards},
+			Description: "Read the dashboards",
+		})
+		require.NoError(t, err)
+		require.Equal(t, int64(2), role.Version)
+		require.Equal(t, "Read the dashboards", role.Description)
+
+		_, err = svc.UpdateRole(ctx, reader, "dashboardsreader", RoleCommand{
+			Name:        "custom:dashboards:reader",
+			Permissions: []accesscontrol.Permission{writeDashboards},
+		})
+		require.ErrorIs(t, err, ErrEscalation)
+	})
+
+	t.Run("should assign roles to users and teams", func(t *testing.T) {
+		require.NoError(t, svc.AddUserRole(ctx, admin, 2, "dashboardsreader"))
+		require.NoError(t, svc.AddUserRole(ctx, admin, 2, "dashboardsreader"))
+		roles, err := svc.GetUserRoles(ctx, 1, 2)
+		require.NoError(t, err)
+		require.Len(t, roles, 1)
+		require.Contains(t, acService.cleared, "1-user-2")
+
+		acService.cleared = nil
+		err = svc.AddUserRole(ctx, admin, 42, "dashboardsreader")
+		require.ErrorIs(t, err, accesscontrol.ErrAssignmentEntityNotFound)
+		require.Empty(t, acService.cleared)
+
+		require.NoError(t, svc.AddTeamRole(ctx, admin, teamID, "dashboardsreader"))
+		roles, err = svc.GetTeamRoles(ctx, 1, teamID)
+		require.NoError(t, err)
+		require.Len(t, roles, 1)
+
+		require.NoError(t, svc.RemoveUserRole(ctx, admin, 2, "dashboardsreader"))
+		roles, err = svc.GetUserRoles(ctx, 1, 2)
+		require.NoError(t, err)
+		require.Len(t, roles, 0)
+		require.Contains(t, acService.cleared, "1-user-2")
+	})
+
+	t.Run("should not assign roles to users and teams of another organization", func(t *testing.T) {
+		err := svc.AddUserRole(ctx, admin, 3, "dashboardsreader")
+		require.ErrorIs(t, err, accesscontrol.ErrAssignmentEntityNotFound)
+		err = svc.AddTeamRole(ctx, admin, otherTeamID, "dashboardsreader")
+		require.ErrorIs(t, err, accesscontrol.ErrAssignmentEntityNotFound)
+
+		roles, err := svc.GetUserRoles(ctx, 2, 3)
+		require.NoError(t, err)
+		require.Len(t, roles, 0)
+		roles, err = svc.GetTeamRoles(ctx, 2, otherTeamID)
+		require.NoError(t, err)
+		require.Len(t, roles, 0)
+	})
+
+	t.Run("should only delete an assigned role when forced", func(t *testing.T) {
+		err := svc.DeleteRole(ctx, admin, "dashboardsreader", false)
+		require.ErrorIs(t, err, ErrRoleAssigned)
+
+		require.NoError(t, svc.DeleteRole(ctx, admin, "dashboardsreader", true))
+		_, err = svc.GetRole(ctx, 1, "dashboardsreader")
+		require.ErrorIs(t, err, ErrRoleNotFound)
+		roles, err := svc.GetTeamRoles(ctx, 1, teamID)
+		require.NoError(t, err)
+		require.Len(t, roles, 0)
+	})
+
+	t.Run("should provision roles when the version increases", func(t *testing.T) {
+		cmd := RoleCommand{UID: "provisioned", Name: "custom:provisioned", Version: 2, Permissions: []accesscontrol.Permission{readDashboards}}
+		require.NoError(t, svc.ProvisionRole(ctx, 1, cmd))
+
+		cmd.Permissions = []accesscontrol.Permission{readDashboards, writeDashboards}
+		require.NoError(t, svc.ProvisionRole(ctx, 1, cmd))
+		role, err := svc.GetRole(ctx, 1, "provisioned")
+		require.NoError(t, err)
+		require.Len(t, role.Permissions, 1)
+
+		cmd.Version = 3
+		require.NoError(t, svc.ProvisionRole(ctx, 1, cmd))
+		role, err = svc.GetRole(ctx, 1, "provisioned")
+		require.NoError(t, err)
+		require.Len(t, role.Permissions, 2)
+
+		require.NoError(t, svc.ProvisionTeamRole(ctx, 1, "Writers", RoleRef{Name: "custom:provisioned"}, true))
+		err = svc.ProvisionTeamRole(ctx, 1, "Readers", RoleRef{UID: "provisioned"}, true)
+		require.ErrorIs(t, err, accesscontrol.ErrAssignmentEntityNotFound)
+
+		require.NoError(t, svc.DeleteProvisionedRole(ctx, 1, RoleRef{UID: "provisioned"}, true))
+		require.NoError(t, svc.DeleteProvisionedRole(ctx, 1, RoleRef{UID: "provisioned"}, true))
+	})
+}
+
+// fakeCacheService records the users whose permission cache is cleared
+type fakeCacheService struct {
+	actest.FakeService
+	cleared []string
+}
+
+func (f *fakeCacheService) ClearUserPermissionCache(user identity.Requester) {
+	f.cleared = append(f.cleared, user.GetCacheKey())
+}
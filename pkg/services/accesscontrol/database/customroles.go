@@ -0,0 +1,315 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+)
+
+// ListRoles returns the roles of an organization and the global roles with a name starting with the prefix, with their permissions
+func (s *AccessControlStore) ListRoles(ctx context.Context, orgID int64, namePrefix string) ([]*accesscontrol.RoleDTO, error) {
+	ctx, span := tracer.Start(ctx, "accesscontrol.database.ListRoles")
+	defer span.End()
+
+	var result []*accesscontrol.RoleDTO
+	err := s.sql.WithDbSession(ctx, func(sess *db.Session) error {
+		var roles []accesscontrol.Role
+		if err := sess.Where("(org_id = ? OR org_id = ?) AND name LIKE ?", orgID, accesscontrol.GlobalOrgID, namePrefix+"%").
+			OrderBy("name").
+			Find(&roles); err != nil {
+			return err
+		}
+		var err error
+		result, err = withPermissions(sess, roles)
+		return err
+	})
+	return result, err
+}
+
+// GetRole returns a role of an organization, or a global role, with its permissions
+func (s *AccessControlStore) GetRole(ctx context.Context, orgID int64, uid string) (*accesscontrol.RoleDTO, error) {
+	ctx, span := tracer.Start(ctx, "accesscontrol.database.GetRole")
+	defer span.End()
+
+	var result []*accesscontrol.RoleDTO
+	err := s.sql.WithDbSession(ctx, func(sess *db.Session) error {
+		var role accesscontrol.Role
+		has, err := sess.Where("uid = ? AND (org_id = ? OR org_id = ?)", uid, orgID, accesscontrol.GlobalOrgID).Get(&role)
+		if err != nil {
+			return err
+		}
+		if !has {
+			return accesscontrol.ErrRoleNotFound
+		}
+		result, err = withPermissions(sess, []accesscontrol.Role{role})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result[0], nil
+}
+
+// SaveRole creates or updates a role identified by its uid, and replaces its permissions
+func (s *AccessControlStore) SaveRole(ctx context.Context, role accesscontrol.RoleDTO) (*accesscontrol.RoleDTO, error) {
+	ctx, span := tracer.Start(ctx, "accesscontrol.database.SaveRole")
+	defer span.End()
+
+	var saved *accesscontrol.RoleDTO
+	err := s.sql.InTransaction(ctx, func(ctx context.Context) error {
+		return s.sql.WithDbSession(ctx, func(sess *db.Session) error {
+			r := role.Role()
+			r.Updated = time.Now()
+			r.Created = r.Updated
+			// The uid identifies the role in all the organizations
+			if existing, err := getRoleByUID(ctx, sess, r.UID); err == nil && existing.OrgID != r.OrgID {
+				return accesscontrol.ErrRoleUIDConflict.Errorf("role %s exists in organization %d", r.UID, existing.OrgID)
+			}
+			stored, err := s.saveRole(ctx, sess, &r)
+			if err != nil {
+				return err
+			}
+			permissions := make([]accesscontrol.Permission, 0, len(role.Permissions))
+			for _, p := range role.Permissions {
+				p := accesscontrol.Permission{Action: p.Action, Scope: p.Scope}
+				p.Kind, p.Attribute, p.Identifier = p.SplitScope()
+				permissions = append(permissions, p)
+			}
+			if err := s.savePermissions(ctx, sess, stored.ID, permissions); err != nil {
+				return err
+			}
+			result, err := withPermissions(sess, []accesscontrol.Role{*stored})
+			if err != nil {
+				return err
+			}
+			saved = result[0]
+			return nil
+		})
+	})
+	return saved, err
+}
+
+// DeleteRole deletes a role, its permissions and its assignments
+func (s *AccessControlStore) DeleteRole(ctx context.Context, roleID int64) error {
+	ctx, span := tracer.Start(ctx, "accesscontrol.database.DeleteRole")
+	defer span.End()
+
+	return s.sql.InTransaction(ctx, func(ctx context.Context) error {
+		return s.sql.WithDbSession(ctx, func(sess *db.Session) error {
+			for _, q := range []string{
+				"DELETE FROM user_role WHERE role_id = ?",
+				"DELETE FROM team_role WHERE role_id = ?",
+				"DELETE FROM builtin_role WHERE role_id = ?",
+				"DELETE FROM permission WHERE role_id = ?",
+				"DELETE FROM role WHERE id = ?",
+			} {
+				if _, err := sess.Exec(q, roleID); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	})
+}
+
+// CountRoleAssignments returns the number of users, teams and basic roles a role is assigned to
+func (s *AccessControlStore) CountRoleAssignments(ctx context.Context, roleID int64) (int64, error) {
+	ctx, span := tracer.Start(ctx, "accesscontrol.database.CountRoleAssignments")
+	defer span.End()
+
+	var total int64
+	err := s.sql.WithDbSession(ctx, func(sess *db.Session) error {
+		for _, table := range []string{"user_role", "team_role", "builtin_role"} {
+			count, err := sess.Table(table).Where("role_id = ?", roleID).Count()
+			if err != nil {
+				return err
+			}
+			total += count
+		}
+		return nil
+	})
+	return total, err
+}
+
+// GetUserRoles returns the roles with a name starting with the prefix assigned to a user in an organization
+func (s *AccessControlStore) GetUserRoles(ctx context.Context, orgID, userID int64, namePrefix string) ([]*accesscontrol.RoleDTO, error) {
+	ctx, span := tracer.Start(ctx, "accesscontrol.database.GetUserRoles")
+	defer span.End()
+
+	var result []*accesscontrol.RoleDTO
+	err := s.sql.WithDbSession(ctx, func(sess *db.Session) error {
+		var roles []accesscontrol.Role
+		if err := sess.SQL(`SELECT role.* FROM role
+			INNER JOIN user_role AS ur ON ur.role_id = role.id
+			WHERE ur.user_id = ? AND (ur.org_id = ? OR ur.org_id = ?) AND role.name LIKE ?
+			ORDER BY role.name`, userID, orgID, accesscontrol.GlobalOrgID, namePrefix+"%").Find(&roles); err != nil {
+			return err
+		}
+		var err error
+		result, err = withPermissions(sess, roles)
+		return err
+	})
+	return result, err
+}
+
+// AddUserRole assigns a role to a member of an organization, assigning it again does nothing
+func (s *AccessControlStore) AddUserRole(ctx context.Context, orgID, userID, roleID int64) error {
+	ctx, span := tracer.Start(ctx, "accesscontrol.database.AddUserRole")
+	defer span.End()
+
+	return s.sql.WithDbSession(ctx, func(sess *db.Session) error {
+		exists, err := sess.Exist(&accesscontrol.UserRole{OrgID: orgID, UserID: userID, RoleID: roleID})
+		if err != nil || exists {
+			return err
+		}
+		_, err = sess.Insert(&accesscontrol.UserRole{OrgID: orgID, UserID: userID, RoleID: roleID, Created: time.Now()})
+		return err
+	})
+}
+
+// RemoveUserRole revokes a role assigned to a user in an organization
+func (s *AccessControlStore) RemoveUserRole(ctx context.Context, orgID, userID, roleID int64) error {
+	ctx, span := tracer.Start(ctx, "accesscontrol.database.RemoveUserRole")
+	defer span.End()
+
+	return s.sql.WithDbSession(ctx, func(sess *db.Session) error {
+		_, err := sess.Exec("DELETE FROM user_role WHERE org_id = ? AND user_id = ? AND role_id = ?", orgID, userID, roleID)
+		return err
+	})
+}
+
+// GetTeamRoles returns the roles with a name starting with the prefix assigned to a team
+func (s *AccessControlStore) GetTeamRoles(ctx context.Context, orgID, teamID int64, namePrefix string) ([]*accesscontrol.RoleDTO, error) {
+	ctx, span := tracer.Start(ctx, "accesscontrol.database.GetTeamRoles")
+	defer span.End()
+
+	var result []*accesscontrol.RoleDTO
+	err := s.sql.WithDbSession(ctx, func(sess *db.Session) error {
+		var roles []accesscontrol.Role
+		if err := sess.SQL(`SELECT role.* FROM role
+			INNER JOIN team_role AS tr ON tr.role_id = role.id
+			WHERE tr.team_id = ? AND tr.org_id = ? AND role.name LIKE ?
+			ORDER BY role.name`, teamID, orgID, namePrefix+"%").Find(&roles); err != nil {
+			return err
+		}
+		var err error
+		result, err = withPermissions(sess, roles)
+		return err
+	})
+	return result, err
+}
+
+// AddTeamRole assigns a role to a team of an organization, assigning it again does nothing
+func (s *AccessControlStore) AddTeamRole(ctx context.Context, orgID, teamID, roleID int64) error {
+	ctx, span := tracer.Start(ctx, "accesscontrol.database.AddTeamRole")
+	defer span.End()
+
+	return s.sql.WithDbSession(ctx, func(sess *db.Session) error {
+		exists, err := sess.Exist(&accesscontrol.TeamRole{OrgID: orgID, TeamID: teamID, RoleID: roleID})
+		if err != nil || exists {
+			return err
+		}
+		_, err = sess.Insert(&accesscontrol.TeamRole{OrgID: orgID, TeamID: teamID, RoleID: roleID, Created: time.Now()})
+		return err
+	})
+}
+
+// RemoveTeamRole revokes a role assigned to a team
+func (s *AccessControlStore) RemoveTeamRole(ctx context.Context, orgID, teamID, roleID int64) error {
+	ctx, span := tracer.Start(ctx, "accesscontrol.database.RemoveTeamRole")
+	defer span.End()
+
+	return s.sql.WithDbSession(ctx, func(sess *db.Session) error {
+		_, err := sess.Exec("DELETE FROM team_role WHERE org_id = ? AND team_id = ? AND role_id = ?", orgID, teamID, roleID)
+		return err
+	})
+}
+
+// IsOrgUser returns whether a user or a service account belongs to an organization
+func (s *AccessControlStore) IsOrgUser(ctx context.Context, orgID, userID int64) (bool, error) {
+	ctx, span := tracer.Start(ctx, "accesscontrol.database.IsOrgUser")
+	defer span.End()
+
+	var member bool
+	err := s.sql.WithDbSession(ctx, func(sess *db.Session) error {
+		var err error
+		member, err = sess.Table("org_user").Where("org_id = ? AND user_id = ?", orgID, userID).Exist()
+		return err
+	})
+	return member, err
+}
+
+// IsOrgTeam returns whether a team belongs to an organization
+func (s *AccessControlStore) IsOrgTeam(ctx context.Context, orgID, teamID int64) (bool, error) {
+	ctx, span := tracer.Start(ctx, "accesscontrol.database.IsOrgTeam")
+	defer span.End()
+
+	var member bool
+	err := s.sql.WithDbSession(ctx, func(sess *db.Session) error {
+		var err error
+		member, err = sess.Table("team").Where("org_id = ? AND id = ?", orgID, teamID).Exist()
+		return err
+	})
+	return member, err
+}
+
+// GetTeamIDByName returns the id of a team of an organization
+func (s *AccessControlStore) GetTeamIDByName(ctx context.Context, orgID int64, name string) (int64, error) {
+	ctx, span := tracer.Start(ctx, "accesscontrol.database.GetTeamIDByName")
+	defer span.End()
+
+	var id int64
+	err := s.sql.WithDbSession(ctx, func(sess *db.Session) error {
+		has, err := sess.Table("team").Cols("id").Where("org_id = ? AND name = ?", orgID, name).Get(&id)
+		if err != nil {
+			return err
+		}
+		if !has {
+			return accesscontrol.ErrAssignmentEntityNotFound.Build(accesscontrol.ErrAssignmentEntityNotFoundData("team " + name))
+		}
+		return nil
+	})
+	return id, err
+}
+
+// withPermissions returns the roles with their permissions
+func withPermissions(sess *db.Session, roles []accesscontrol.Role) ([]*accesscontrol.RoleDTO, error) {
+	result := make([]*accesscontrol.RoleDTO, 0, len(roles))
+	if len(roles) == 0 {
+		return result, nil
+	}
+	byID := make(map[int64]*accesscontrol.RoleDTO, len(roles))
+	ids := make([]int64, 0, len(roles))
+	for _, r := range roles {
+		dto := &accesscontrol.RoleDTO{
+			ID:          r.ID,
+			OrgID:       r.OrgID,
+			Version:     r.Version,
+			UID:         r.UID,
+			Name:        r.Name,
+			DisplayName: r.DisplayName,
+			Description: r.Description,
+			Group:       r.Group,
+			Hidden:      r.Hidden,
+			Permissions: []accesscontrol.Permission{},
+			Updated:     r.Updated,
+			Created:     r.Created,
+		}
+		result = append(result, dto)
+		byID[r.ID] = dto
+		ids = append(ids, r.ID)
+	}
+
+	var permissions []accesscontrol.Permission
+	if err := sess.In("role_id", ids).OrderBy("action, scope").Find(&permissions); err != nil {
+		return nil, err
+	}
+	for _, p := range permissions {
+		if r, ok := byID[p.RoleID]; ok {
+			r.Permissions = append(r.Permissions, p)
+		}
+	}
+	return result, nil
+}
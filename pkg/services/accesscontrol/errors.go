@@ -18,6 +18,7 @@ var (
 	ErrNoneRoleAssignment       = errutil.BadRequest("accesscontrol.noneRoleAssignment", errutil.WithPublicMessage("none role cannot receive permissions"))
 	ErrAssignmentEntityNotFound = errutil.BadRequest("accesscontrol.assignmentEntityNotFound").
 					MustTemplate(assignmentEntityNotFoundMessage, errutil.WithPublic(assignmentEntityNotFoundMessage))
+	ErrRoleUIDConflict = errutil.Conflict("accesscontrol.roleUIDConflict", errutil.WithPublicMessage("a role with the same uid exists in another organization"))
 
 	// Note: these are intended to be replaced by equivalent errutil implementations.
 	// Avoid creating new errors with errors.New and prefer errutil
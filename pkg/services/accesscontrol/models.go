@@ -142,6 +142,10 @@ func (r *RoleDTO) IsBasic() bool {
 	return strings.HasPrefix(r.Name, BasicRolePrefix) || strings.HasPrefix(r.UID, BasicRoleUIDPrefix)
 }
 
+func (r *RoleDTO) IsCustom() bool {
+	return strings.HasPrefix(r.Name, CustomRolePrefix)
+}
+
 func (r *RoleDTO) IsExternalService() bool {
 	return strings.HasPrefix(r.Name, ExternalServiceRolePrefix) || strings.HasPrefix(r.UID, ExternalServiceRoleUIDPrefix)
 }
@@ -413,6 +417,22 @@ const (
 	// Team related scopes
 	ScopeTeamsAll = "teams:*"
 
+	// Custom roles actions
+	ActionRolesRead        = "roles:read"
+	ActionRolesWrite       = "roles:write"
+	ActionRolesDelete      = "roles:delete"
+	ActionUsersRolesRead   = "users.roles:read"
+	ActionUsersRolesAdd    = "users.roles:add"
+	ActionUsersRolesRemove = "users.roles:remove"
+	ActionTeamsRolesRead   = "teams.roles:read"
+	ActionTeamsRolesAdd    = "teams.roles:add"
+	ActionTeamsRolesRemove = "teams.roles:remove"
+
+	// Custom roles scopes
+	ScopeRolesAll = "roles:*"
+	// ScopePermissionsDelegate limits the management of the roles to the roles granting permissions the user has
+	ScopePermissionsDelegate = "permissions:type:delegate"
+
 	// Annotations related actions
 	ActionAnnotationsCreate = "annotations:create"
 	ActionAnnotationsDelete = "annotations:delete"
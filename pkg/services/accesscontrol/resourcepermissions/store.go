@@ -6,6 +6,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/grafana/grafana/pkg/events"
 	"github.com/grafana/grafana/pkg/infra/db"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/services/accesscontrol"
@@ -125,6 +126,15 @@ func (s *store) setUserResourcePermission(
 		}
 	}
 
+	sess.PublishAfterCommit(&events.ResourcePermissionChanged{
+		Timestamp:  time.Now(),
+		OrgID:      orgID,
+		Resource:   cmd.Resource,
+		ResourceID: cmd.ResourceID,
+		Permission: cmd.Permission,
+		UserID:     user.ID,
+	})
+
 	return permission, nil
 }
 
@@ -167,6 +177,15 @@ func (s *store) setTeamResourcePermission(
 		}
 	}
 
+	sess.PublishAfterCommit(&events.ResourcePermissionChanged{
+		Timestamp:  time.Now(),
+		OrgID:      orgID,
+		Resource:   cmd.Resource,
+		ResourceID: cmd.ResourceID,
+		Permission: cmd.Permission,
+		TeamID:     teamID,
+	})
+
 	return permission, nil
 }
 
@@ -213,6 +232,15 @@ func (s *store) setBuiltInResourcePermission(
 		}
 	}
 
+	sess.PublishAfterCommit(&events.ResourcePermissionChanged{
+		Timestamp:   time.Now(),
+		OrgID:       orgID,
+		Resource:    cmd.Resource,
+		ResourceID:  cmd.ResourceID,
+		Permission:  cmd.Permission,
+		BuiltInRole: builtInRole,
+	})
+
 	return permission, nil
 }
 
@@ -24,6 +24,8 @@ const (
 
 	ManagedRolePrefix = "managed:"
 
+	CustomRolePrefix = "custom:"
+
 	PluginRolePrefix = "plugins:"
 
 	BasicRoleNoneUID  = "basic_none"
@@ -134,6 +136,60 @@ var (
 		},
 	}
 
+	rolesReaderRole = RoleDTO{
+		Name:        "fixed:roles:reader",
+		DisplayName: "Reader",
+		Description: "List custom roles and the roles assigned to users and teams.",
+		Group:       "Roles",
+		Permissions: []Permission{
+			{
+				Action: ActionRolesRead,
+				Scope:  ScopeRolesAll,
+			},
+			{
+				Action: ActionUsersRolesRead,
+				Scope:  ScopeUsersAll,
+			},
+			{
+				Action: ActionTeamsRolesRead,
+				Scope:  ScopeTeamsAll,
+			},
+		},
+	}
+
+	rolesWriterRole = RoleDTO{
+		Name:        "fixed:roles:writer",
+		DisplayName: "Writer",
+		Description: "Create, update and delete custom roles, and assign them to users and teams. Only roles granting permissions the user has can be managed.",
+		Group:       "Roles",
+		Permissions: ConcatPermissions(rolesReaderRole.Permissions, []Permission{
+			{
+				Action: ActionRolesWrite,
+				Scope:  ScopePermissionsDelegate,
+			},
+			{
+				Action: ActionRolesDelete,
+				Scope:  ScopePermissionsDelegate,
+			},
+			{
+				Action: ActionUsersRolesAdd,
+				Scope:  ScopePermissionsDelegate,
+			},
+			{
+				Action: ActionUsersRolesRemove,
+				Scope:  ScopePermissionsDelegate,
+			},
+			{
+				Action: ActionTeamsRolesAdd,
+				Scope:  ScopePermissionsDelegate,
+			},
+			{
+				Action: ActionTeamsRolesRemove,
+				Scope:  ScopePermissionsDelegate,
+			},
+		}),
+	}
+
 	usersReaderRole = RoleDTO{
 		Name:        "fixed:users:reader",
 		DisplayName: "Reader (global)",
@@ -353,10 +409,20 @@ func DeclareFixedRoles(service Service, cfg *setting.Cfg) error {
 		Grants: []string{RoleGrafanaAdmin},
 	}
 
+	rolesReader := RoleRegistration{
+		Role:   rolesReaderRole,
+		Grants: []string{RoleGrafanaAdmin, string(org.RoleAdmin)},
+	}
+	rolesWriter := RoleRegistration{
+		Role:   rolesWriterRole,
+		Grants: []string{RoleGrafanaAdmin, string(org.RoleAdmin)},
+	}
+
 	return service.DeclareFixedRoles(
 		ldapReader, ldapWriter, orgUsersReader, orgUsersWriter,
 		settingsReader, statsReader, usersReader, usersWriter,
 		authenticationConfigWriter, generalAuthConfigWriter, usageStatsReader,
+		rolesReader, rolesWriter,
 	)
 }
 
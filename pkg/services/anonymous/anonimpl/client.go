@@ -20,6 +20,8 @@ var (
 	errInvalidOrg  = errutil.Unauthorized("anonymous.invalid-org")
 	errInvalidID   = errutil.Unauthorized("anonymous.invalid-id")
 	errDeviceLimit = errutil.Unauthorized("anonymous.device-limit-reached", errutil.WithPublicMessage("Anonymous device limit reached. Contact Administrator"))
+	// errQueryRateLimit is returned by AnonDeviceService.LimitQuery
+	errQueryRateLimit = errutil.TooManyRequests("anonymous.query-rate-limit-reached", errutil.WithPublicMessage("Anonymous query rate limit reached. Try again later"))
 )
 
 var (
@@ -102,6 +104,10 @@ func (a *Anonymous) UsageStatFn(ctx context.Context) (map[string]any, error) {
 	if !strings.EqualFold(a.cfg.Anonymous.OrgRole, "Viewer") {
 		m["stats.anonymous.customized_role.count"] = 1
 	}
+	m["stats.anonymous.scoped.count"] = 0
+	if a.cfg.Anonymous.Scoped() {
+		m["stats.anonymous.scoped.count"] = 1
+	}
 
 	return m, nil
 }
@@ -111,6 +117,21 @@ func (a *Anonymous) Priority() uint {
 }
 
 func (a *Anonymous) newAnonymousIdentity(o *org.Org) *authn.Identity {
+	if a.cfg.Anonymous.Scoped() {
+		// the scoped anonymous users have no org role, only the permissions of the scoped role
+		return &authn.Identity{
+			ID:       "0",
+			Type:     claims.TypeAnonymous,
+			OrgID:    o.ID,
+			OrgName:  o.Name,
+			OrgRoles: map[int64]org.RoleType{o.ID: org.RoleNone},
+			ClientParams: authn.ClientParams{
+				SyncPermissions:        true,
+				FetchPermissionsParams: authn.FetchPermissionsParams{Roles: []string{scopedRoleName}},
+			},
+		}
+	}
+
 	return &authn.Identity{
 		ID:           "0",
 		Type:         claims.TypeAnonymous,
@@ -10,8 +10,11 @@ import (
 
 	claims "github.com/grafana/authlib/types"
 	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
 	"github.com/grafana/grafana/pkg/services/anonymous/anontest"
 	"github.com/grafana/grafana/pkg/services/authn"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/datasources"
 	"github.com/grafana/grafana/pkg/services/org"
 	"github.com/grafana/grafana/pkg/services/org/orgtest"
 	"github.com/grafana/grafana/pkg/setting"
@@ -73,6 +76,49 @@ func TestAnonymous_Authenticate(t *testing.T) {
 	}
 }
 
+func TestAnonymous_AuthenticateScoped(t *testing.T) {
+	cfg := &setting.Cfg{
+		Anonymous: setting.AnonymousSettings{
+			OrgRole:        "Viewer",
+			OrgName:        "some org",
+			FolderUIDs:     []string{"status"},
+			DashboardUIDs:  []string{"home"},
+			DataSourceUIDs: []string{"prometheus"},
+			Permissions:    []string{"annotations:read annotations:type:dashboard", "datasources:explore"},
+		},
+	}
+	c := Anonymous{
+		cfg:               cfg,
+		log:               log.NewNopLogger(),
+		orgService:        &orgtest.FakeOrgService{ExpectedOrg: &org.Org{ID: 1, Name: "some org"}},
+		anonDeviceService: anontest.NewFakeService(),
+	}
+
+	user, err := c.Authenticate(context.Background(), &authn.Request{})
+	require.NoError(t, err)
+	assert.Equal(t, org.RoleNone, user.GetOrgRole())
+	assert.True(t, user.ClientParams.SyncPermissions)
+	assert.Equal(t, []string{scopedRoleName}, user.ClientParams.FetchPermissionsParams.Roles)
+
+	role, err := scopedRole(cfg.Anonymous)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []accesscontrol.Permission{
+		{Action: accesscontrol.ActionAnnotationsRead, Scope: accesscontrol.ScopeAnnotationsTypeDashboard},
+		{Action: accesscontrol.ActionDatasourcesExplore},
+		{Action: dashboards.ActionFoldersRead, Scope: "folders:uid:status"},
+		{Action: dashboards.ActionDashboardsRead, Scope: "folders:uid:status"},
+		{Action: dashboards.ActionDashboardsRead, Scope: "dashboards:uid:home"},
+		{Action: datasources.ActionQuery, Scope: "datasources:uid:prometheus"},
+	}, role.Permissions)
+}
+
+func TestAnonymous_ScopedRoleInvalidPermissions(t *testing.T) {
+	for _, permission := range []string{"dashboards:read dashboards:uid:home extra", "dashboards:read dashboards:uid:*home"} {
+		_, err := scopedRole(setting.AnonymousSettings{FolderUIDs: []string{"status"}, Permissions: []string{permission}})
+		assert.Error(t, err, permission)
+	}
+}
+
 func TestAnonymous_ResolveIdentity(t *testing.T) {
 	type TestCase struct {
 		desc        string
@@ -35,13 +35,14 @@ type AnonDeviceService struct {
 	serverLock     *serverlock.ServerLockService
 	cfg            *setting.Cfg
 	limitValidator validator.AnonUserLimitValidator
+	queryLimiter   *queryLimiter
 }
 
 func ProvideAnonymousDeviceService(usageStats usagestats.Service, authBroker authn.Service,
 	sqlStore db.DB, cfg *setting.Cfg, orgService org.Service,
-	serverLockService *serverlock.ServerLockService, accesscontrol accesscontrol.AccessControl, routeRegister routing.RouteRegister,
-	validator validator.AnonUserLimitValidator,
-) *AnonDeviceService {
+	serverLockService *serverlock.ServerLockService, ac accesscontrol.AccessControl, routeRegister routing.RouteRegister,
+	validator validator.AnonUserLimitValidator, acService accesscontrol.Service,
+) (*AnonDeviceService, error) {
 	a := &AnonDeviceService{
 		log:            log.New("anonymous-session-service"),
 		localCache:     localcache.New(29*time.Minute, 15*time.Minute),
@@ -51,6 +52,20 @@ func ProvideAnonymousDeviceService(usageStats usagestats.Service, authBroker aut
 		limitValidator: validator,
 	}
 
+	if cfg.Anonymous.QueryRateLimit > 0 {
+		a.queryLimiter = newQueryLimiter(cfg.Anonymous.QueryRateLimit, cfg.Anonymous.GlobalQueryRateLimit)
+	}
+
+	if cfg.Anonymous.Enabled && cfg.Anonymous.Scoped() {
+		role, err := scopedRole(cfg.Anonymous)
+		if err != nil {
+			return nil, err
+		}
+		if err := acService.DeclareFixedRoles(accesscontrol.RoleRegistration{Role: role}); err != nil {
+			return nil, err
+		}
+	}
+
 	usageStats.RegisterMetricsFunc(a.usageStatFn)
 
 	anonClient := &Anonymous{
@@ -65,10 +80,10 @@ func ProvideAnonymousDeviceService(usageStats usagestats.Service, authBroker aut
 		authBroker.RegisterPostLoginHook(a.untagDevice, 100)
 	}
 
-	anonAPI := api.NewAnonDeviceServiceAPI(cfg, a.anonStore, accesscontrol, routeRegister)
+	anonAPI := api.NewAnonDeviceServiceAPI(cfg, a.anonStore, ac, routeRegister)
 	anonAPI.RegisterAPIEndpoints()
 
-	return a
+	return a, nil
 }
 
 func (a *AnonDeviceService) usageStatFn(ctx context.Context) (map[string]any, error) {
@@ -140,7 +155,7 @@ func (a *AnonDeviceService) TagDevice(ctx context.Context, httpReq *http.Request
 		return nil
 	}
 
-	addr := web.RemoteAddr(httpReq)
+	addr := web.ClientIP(httpReq, a.cfg.TrustedProxies)
 	ip, err := network.GetIPFromAddress(addr)
 	if err != nil {
 		a.log.Debug("Failed to parse ip from address", "addr", addr)
@@ -169,6 +184,20 @@ func (a *AnonDeviceService) TagDevice(ctx context.Context, httpReq *http.Request
 	return nil
 }
 
+// LimitQuery returns an error when the client sending the request, or all the anonymous clients together,
+// reached their query rate limit. The clients are identified by their ip, the device id is set by the client
+// and can't be trusted.
+func (a *AnonDeviceService) LimitQuery(ctx context.Context, httpReq *http.Request) error {
+	if a.queryLimiter == nil {
+		return nil
+	}
+
+	if !a.queryLimiter.allow(queryLimitKey(web.ClientIP(httpReq, a.cfg.TrustedProxies))) {
+		return errQueryRateLimit.Errorf("anonymous client reached the query rate limit")
+	}
+	return nil
+}
+
 // ListDevices returns all devices that have been updated between the given times.
 func (a *AnonDeviceService) ListDevices(ctx context.Context, from *time.Time, to *time.Time) ([]*anonstore.Device, error) {
 	if !a.cfg.Anonymous.Enabled {
@@ -2,6 +2,8 @@ package anonimpl
 
 import (
 	"context"
+	"fmt"
+	"net"
 	"net/http"
 	"testing"
 	"time"
@@ -63,9 +65,9 @@ func TestIntegrationDeviceService_tag(t *testing.T) {
 			req: []tagReq{{httpReq: &http.Request{
 				Header: http.Header{
 					"User-Agent":                            []string{"test"},
-					"X-Forwarded-For":                       []string{"10.30.30.1"},
 					http.CanonicalHeaderKey(deviceIDHeader): []string{"32mdo31deeqwes"},
 				},
+				RemoteAddr: "10.30.30.1:1234",
 			},
 				kind: anonymous.AnonDeviceUI,
 			},
@@ -83,16 +85,16 @@ func TestIntegrationDeviceService_tag(t *testing.T) {
 				Header: http.Header{
 					"User-Agent":                            []string{"test"},
 					http.CanonicalHeaderKey(deviceIDHeader): []string{"32mdo31deeqwes"},
-					"X-Forwarded-For":                       []string{"10.30.30.1"},
 				},
+				RemoteAddr: "10.30.30.1:1234",
 			},
 				kind: anonymous.AnonDeviceUI,
 			}, {httpReq: &http.Request{
 				Header: http.Header{
 					"User-Agent":                            []string{"test"},
 					http.CanonicalHeaderKey(deviceIDHeader): []string{"32mdo31deeqwes"},
-					"X-Forwarded-For":                       []string{"10.30.30.1"},
 				},
+				RemoteAddr: "10.30.30.1:1234",
 			},
 				kind: anonymous.AnonDeviceUI,
 			},
@@ -102,18 +104,18 @@ func TestIntegrationDeviceService_tag(t *testing.T) {
 			name: "tag 2 different requests",
 			req: []tagReq{{httpReq: &http.Request{
 				Header: http.Header{
-					http.CanonicalHeaderKey("User-Agent"):      []string{"test"},
-					http.CanonicalHeaderKey("X-Forwarded-For"): []string{"10.30.30.1"},
-					http.CanonicalHeaderKey(deviceIDHeader):    []string{"a"},
+					http.CanonicalHeaderKey("User-Agent"):   []string{"test"},
+					http.CanonicalHeaderKey(deviceIDHeader): []string{"a"},
 				},
+				RemoteAddr: "10.30.30.1:1234",
 			},
 				kind: anonymous.AnonDeviceUI,
 			}, {httpReq: &http.Request{
 				Header: http.Header{
 					"User-Agent":                            []string{"test"},
-					"X-Forwarded-For":                       []string{"10.30.30.2"},
 					http.CanonicalHeaderKey(deviceIDHeader): []string{"b"},
 				},
+				RemoteAddr: "10.30.30.2:1234",
 			},
 				kind: anonymous.AnonDeviceUI,
 			},
@@ -127,9 +129,9 @@ func TestIntegrationDeviceService_tag(t *testing.T) {
 					httpReq: &http.Request{
 						Header: http.Header{
 							"User-Agent":                            []string{"testdisabled"},
-							"X-Forwarded-For":                       []string{"10.33.33.3"},
 							http.CanonicalHeaderKey(deviceIDHeader): []string{"t35td154b13d1d"},
 						},
+						RemoteAddr: "10.33.33.3:1234",
 					},
 					kind: anonymous.AnonDeviceUI,
 				},
@@ -148,10 +150,11 @@ func TestIntegrationDeviceService_tag(t *testing.T) {
 			cfg := setting.NewCfg()
 			cfg.Anonymous.Enabled = !tc.disableService
 
-			anonService := ProvideAnonymousDeviceService(
+			anonService, err := ProvideAnonymousDeviceService(
 				&usagestats.UsageStatsMock{}, &authntest.FakeService{}, store, cfg, orgtest.NewOrgServiceFake(),
-				nil, actest.FakeAccessControl{}, &routing.RouteRegisterImpl{}, validator.FakeAnonUserLimitValidator{},
+				nil, actest.FakeAccessControl{}, &routing.RouteRegisterImpl{}, validator.FakeAnonUserLimitValidator{}, &actest.FakeService{},
 			)
+			require.NoError(t, err)
 
 			for _, req := range tc.req {
 				err := anonService.TagDevice(ctx, req.httpReq, req.kind)
@@ -212,15 +215,16 @@ func TestIntegrationAnonDeviceService_localCacheSafety(t *testing.T) {
 		t.Skip("skipping test in short mode")
 	}
 	store := db.InitTestDB(t)
-	anonService := ProvideAnonymousDeviceService(&usagestats.UsageStatsMock{},
-		&authntest.FakeService{}, store, setting.NewCfg(), orgtest.NewOrgServiceFake(), nil, actest.FakeAccessControl{}, &routing.RouteRegisterImpl{}, validator.FakeAnonUserLimitValidator{})
+	anonService, err := ProvideAnonymousDeviceService(&usagestats.UsageStatsMock{},
+		&authntest.FakeService{}, store, setting.NewCfg(), orgtest.NewOrgServiceFake(), nil, actest.FakeAccessControl{}, &routing.RouteRegisterImpl{}, validator.FakeAnonUserLimitValidator{}, &actest.FakeService{})
+	require.NoError(t, err)
 
 	req := &http.Request{
 		Header: http.Header{
 			"User-Agent":                            []string{"test"},
-			"X-Forwarded-For":                       []string{"10.30.30.2"},
 			http.CanonicalHeaderKey(deviceIDHeader): []string{"32mdo31deeqwes"},
 		},
+		RemoteAddr: "10.30.30.2:1234",
 	}
 
 	anonDevice := &anonstore.Device{
@@ -233,7 +237,7 @@ func TestIntegrationAnonDeviceService_localCacheSafety(t *testing.T) {
 	key := anonDevice.CacheKey()
 	anonService.localCache.SetDefault(key, true)
 
-	err := anonService.TagDevice(context.Background(), req, anonymous.AnonDeviceUI)
+	err = anonService.TagDevice(context.Background(), req, anonymous.AnonDeviceUI)
 	require.NoError(t, err)
 
 	stats, err := anonService.usageStatFn(context.Background())
@@ -311,7 +315,8 @@ func TestIntegrationDeviceService_SearchDevice(t *testing.T) {
 	store := db.InitTestDB(t)
 	cfg := setting.NewCfg()
 	cfg.Anonymous.Enabled = true
-	anonService := ProvideAnonymousDeviceService(&usagestats.UsageStatsMock{}, &authntest.FakeService{}, store, cfg, orgtest.NewOrgServiceFake(), nil, actest.FakeAccessControl{}, &routing.RouteRegisterImpl{}, validator.FakeAnonUserLimitValidator{})
+	anonService, err := ProvideAnonymousDeviceService(&usagestats.UsageStatsMock{}, &authntest.FakeService{}, store, cfg, orgtest.NewOrgServiceFake(), nil, actest.FakeAccessControl{}, &routing.RouteRegisterImpl{}, validator.FakeAnonUserLimitValidator{}, &actest.FakeService{})
+	require.NoError(t, err)
 
 	for _, tc := range testCases {
 		err := store.Reset()
@@ -343,7 +348,7 @@ func TestIntegrationAnonDeviceService_DeviceLimitWithCache(t *testing.T) {
 	store := db.InitTestDB(t)
 	cfg := setting.NewCfg()
 	cfg.Anonymous.DeviceLimit = 1 // Set device limit to 1 for testing
-	anonService := ProvideAnonymousDeviceService(
+	anonService, err := ProvideAnonymousDeviceService(
 		&usagestats.UsageStatsMock{},
 		&authntest.FakeService{},
 		store,
@@ -353,7 +358,9 @@ func TestIntegrationAnonDeviceService_DeviceLimitWithCache(t *testing.T) {
 		actest.FakeAccessControl{},
 		&routing.RouteRegisterImpl{},
 		validator.FakeAnonUserLimitValidator{},
+		&actest.FakeService{},
 	)
+	require.NoError(t, err)
 
 	// Define test cases
 	testCases := []struct {
@@ -366,9 +373,9 @@ func TestIntegrationAnonDeviceService_DeviceLimitWithCache(t *testing.T) {
 			httpReq: &http.Request{
 				Header: http.Header{
 					"User-Agent":                            []string{"test"},
-					"X-Forwarded-For":                       []string{"10.30.30.1"},
 					http.CanonicalHeaderKey(deviceIDHeader): []string{"device1"},
 				},
+				RemoteAddr: "10.30.30.1:1234",
 			},
 			expectedErr: nil,
 		},
@@ -377,9 +384,9 @@ func TestIntegrationAnonDeviceService_DeviceLimitWithCache(t *testing.T) {
 			httpReq: &http.Request{
 				Header: http.Header{
 					"User-Agent":                            []string{"test"},
-					"X-Forwarded-For":                       []string{"10.30.30.2"},
 					http.CanonicalHeaderKey(deviceIDHeader): []string{"device2"},
 				},
+				RemoteAddr: "10.30.30.2:1234",
 			},
 			expectedErr: anonstore.ErrDeviceLimitReached,
 		},
@@ -388,9 +395,9 @@ func TestIntegrationAnonDeviceService_DeviceLimitWithCache(t *testing.T) {
 			httpReq: &http.Request{
 				Header: http.Header{
 					"User-Agent":                            []string{"test"},
-					"X-Forwarded-For":                       []string{"10.30.30.1"},
 					http.CanonicalHeaderKey(deviceIDHeader): []string{"device1"},
 				},
+				RemoteAddr: "10.30.30.1:1234",
 			},
 			expectedErr: nil,
 		},
@@ -399,9 +406,9 @@ func TestIntegrationAnonDeviceService_DeviceLimitWithCache(t *testing.T) {
 			httpReq: &http.Request{
 				Header: http.Header{
 					"User-Agent":                            []string{"test"},
-					"X-Forwarded-For":                       []string{"10.30.30.2"},
 					http.CanonicalHeaderKey(deviceIDHeader): []string{"device2"},
 				},
+				RemoteAddr: "10.30.30.2:1234",
 			},
 			expectedErr: anonstore.ErrDeviceLimitReached,
 		},
@@ -420,3 +427,79 @@ func TestIntegrationAnonDeviceService_DeviceLimitWithCache(t *testing.T) {
 		})
 	}
 }
+
+func TestAnonDeviceService_LimitQuery(t *testing.T) {
+	newRequest := func(remoteAddr string, headers ...string) *http.Request {
+		req := &http.Request{Header: http.Header{}, RemoteAddr: remoteAddr}
+		for i := 0; i+1 < len(headers); i += 2 {
+			req.Header.Set(headers[i], headers[i+1])
+		}
+		return req
+	}
+	cfg := setting.NewCfg()
+
+	t.Run("should not limit the queries without rate limit", func(t *testing.T) {
+		anonService := &AnonDeviceService{cfg: cfg}
+		for i := 0; i < 10; i++ {
+			require.NoError(t, anonService.LimitQuery(context.Background(), newRequest("10.0.0.1:1234")))
+		}
+	})
+
+	t.Run("should limit the queries of every client ip", func(t *testing.T) {
+		anonService := &AnonDeviceService{cfg: cfg, queryLimiter: newQueryLimiter(1, 0)}
+		ctx := context.Background()
+
+		require.NoError(t, anonService.LimitQuery(ctx, newRequest("10.0.0.1:1234", deviceIDHeader, "device-a")))
+		require.ErrorIs(t, anonService.LimitQuery(ctx, newRequest("10.0.0.1:5678", deviceIDHeader, "device-b")), errQueryRateLimit)
+		require.ErrorIs(t, anonService.LimitQuery(ctx, newRequest("10.0.0.1:5678", "X-Forwarded-For", "10.0.0.3")), errQueryRateLimit)
+		require.NoError(t, anonService.LimitQuery(ctx, newRequest("10.0.0.2:1234", deviceIDHeader, "device-a")))
+	})
+
+	t.Run("should limit the ipv6 clients by network", func(t *testing.T) {
+		anonService := &AnonDeviceService{cfg: cfg, queryLimiter: newQueryLimiter(1, 0)}
+		ctx := context.Background()
+
+		require.NoError(t, anonService.LimitQuery(ctx, newRequest("[2001:db8:1:1::1]:1234")))
+		require.ErrorIs(t, anonService.LimitQuery(ctx, newRequest("[2001:db8:1:1::2]:1234")), errQueryRateLimit)
+		require.NoError(t, anonService.LimitQuery(ctx, newRequest("[2001:db8:1:2::1]:1234")))
+	})
+
+	t.Run("should use the client ip forwarded by the trusted proxies", func(t *testing.T) {
+		_, proxies, err := net.ParseCIDR("192.168.0.0/24")
+		require.NoError(t, err)
+		cfg := setting.NewCfg()
+		cfg.TrustedProxies = []*net.IPNet{proxies}
+		anonService := &AnonDeviceService{cfg: cfg, queryLimiter: newQueryLimiter(1, 0)}
+		ctx := context.Background()
+
+		require.NoError(t, anonService.LimitQuery(ctx, newRequest("192.168.0.1:1234", "X-Forwarded-For", "10.0.0.1")))
+		require.ErrorIs(t, anonService.LimitQuery(ctx, newRequest("192.168.0.2:1234", "X-Forwarded-For", "10.0.0.1")), errQueryRateLimit)
+		require.NoError(t, anonService.LimitQuery(ctx, newRequest("192.168.0.1:1234", "X-Forwarded-For", "10.0.0.2")))
+	})
+
+	t.Run("should limit the queries of all the clients together", func(t *testing.T) {
+		anonService := &AnonDeviceService{cfg: cfg, queryLimiter: newQueryLimiter(1, 2)}
+		ctx := context.Background()
+
+		require.NoError(t, anonService.LimitQuery(ctx, newRequest("10.0.0.1:1234")))
+		require.NoError(t, anonService.LimitQuery(ctx, newRequest("10.0.0.2:1234")))
+		require.ErrorIs(t, anonService.LimitQuery(ctx, newRequest("10.0.0.3:1234")), errQueryRateLimit)
+
+		// the client rejected by the global limit keeps its token
+		anonService.queryLimiter.global = nil
+		require.NoError(t, anonService.LimitQuery(ctx, newRequest("10.0.0.3:1234")))
+	})
+
+	t.Run("should share a limiter between the clients over the cap", func(t *testing.T) {
+		limiter := newQueryLimiter(1, 0)
+		for i := 0; i < maxTrackedClients; i++ {
+			require.True(t, limiter.allow(fmt.Sprintf("client-%d", i)))
+		}
+
+		require.True(t, limiter.allow("client-a"))
+		require.False(t, limiter.allow("client-b"))
+		require.Equal(t, maxTrackedClients, limiter.limiters.ItemCount())
+		// the tracked clients keep their own limiter
+		require.False(t, limiter.allow("client-0"))
+	})
+}
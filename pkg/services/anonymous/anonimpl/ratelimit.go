@@ -0,0 +1,84 @@
+package anonimpl
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/grafana/grafana/pkg/infra/localcache"
+)
+
+// maxTrackedClients caps the number of clients with their own limiter, the clients over the cap
+// share a single limiter until the idle limiters are forgotten
+const maxTrackedClients = 10000
+
+// queryLimiter limits the number of queries per minute of every anonymous client,
+// and of all the anonymous clients together
+type queryLimiter struct {
+	limit    int64
+	mu       sync.Mutex
+	limiters *localcache.CacheService
+	overflow *rate.Limiter
+	global   *rate.Limiter
+}
+
+func newQueryLimiter(limit, globalLimit int64) *queryLimiter {
+	l := &queryLimiter{
+		limit: limit,
+		// an idle limiter is refilled after a minute, it can be forgotten
+		limiters: localcache.New(5*time.Minute, 10*time.Minute),
+		overflow: newMinuteLimiter(limit),
+	}
+	if globalLimit > 0 {
+		l.global = newMinuteLimiter(globalLimit)
+	}
+	return l
+}
+
+func newMinuteLimiter(limit int64) *rate.Limiter {
+	return rate.NewLimiter(rate.Every(time.Minute/time.Duration(limit)), int(limit))
+}
+
+// allow returns whether the client identified by the key can send a query
+func (l *queryLimiter) allow(key string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	limiter := l.overflow
+	if cached, ok := l.limiters.Get(key); ok {
+		limiter = cached.(*rate.Limiter)
+		l.limiters.SetDefault(key, limiter)
+	} else if l.limiters.ItemCount() < maxTrackedClients {
+		limiter = newMinuteLimiter(l.limit)
+		l.limiters.SetDefault(key, limiter)
+	}
+
+	// the token of the client is given back when the global limit rejects the query, the reservation
+	// is cancelled at the time it was made as the tokens of a past reservation are not restored
+	now := time.Now()
+	reservation := limiter.ReserveN(now, 1)
+	if !reservation.OK() || reservation.DelayFrom(now) > 0 {
+		reservation.CancelAt(now)
+		return false
+	}
+	if l.global != nil && !l.global.AllowN(now, 1) {
+		reservation.CancelAt(now)
+		return false
+	}
+	return true
+}
+
+// queryLimitKey returns the key of the client ip. The IPv6 addresses are grouped by /64 network,
+// as a single client usually gets a whole network.
+func queryLimitKey(clientIP string) string {
+	ip := net.ParseIP(clientIP)
+	if ip == nil {
+		return clientIP
+	}
+	if ip.To4() == nil {
+		return ip.Mask(net.CIDRMask(64, 128)).String()
+	}
+	return ip.String()
+}
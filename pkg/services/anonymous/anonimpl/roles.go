@@ -0,0 +1,65 @@
+package anonimpl
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// scopedRoleName is the role of the anonymous users when their access is scoped to folders and dashboards
+const scopedRoleName = "fixed:anonymous:scoped"
+
+// scopedRole grants the read permissions on the folders and dashboards of the anonymous access, the query
+// permission on its data sources and the permissions of the `permissions` setting
+func scopedRole(settings setting.AnonymousSettings) (accesscontrol.RoleDTO, error) {
+	permissions := make([]accesscontrol.Permission, 0, len(settings.Permissions))
+	for _, permission := range settings.Permissions {
+		p, err := parsePermission(permission)
+		if err != nil {
+			return accesscontrol.RoleDTO{}, err
+		}
+		permissions = append(permissions, p)
+	}
+	for _, uid := range settings.FolderUIDs {
+		scope := dashboards.ScopeFoldersProvider.GetResourceScopeUID(uid)
+		permissions = append(permissions,
+			accesscontrol.Permission{Action: dashboards.ActionFoldersRead, Scope: scope},
+			accesscontrol.Permission{Action: dashboards.ActionDashboardsRead, Scope: scope},
+		)
+	}
+	for _, uid := range settings.DashboardUIDs {
+		permissions = append(permissions, accesscontrol.Permission{Action: dashboards.ActionDashboardsRead, Scope: dashboards.ScopeDashboardsProvider.GetResourceScopeUID(uid)})
+	}
+	for _, uid := range settings.DataSourceUIDs {
+		permissions = append(permissions, accesscontrol.Permission{Action: datasources.ActionQuery, Scope: datasources.ScopeProvider.GetResourceScopeUID(uid)})
+	}
+
+	return accesscontrol.RoleDTO{
+		Name:        scopedRoleName,
+		DisplayName: "Scoped anonymous access",
+		Description: "Read the folders and dashboards of the anonymous access and query its data sources",
+		Group:       "Anonymous access",
+		Hidden:      true,
+		Permissions: permissions,
+	}, nil
+}
+
+// parsePermission parses a permission of the `permissions` setting, an action optionally followed by a scope
+func parsePermission(permission string) (accesscontrol.Permission, error) {
+	fields := strings.Fields(permission)
+	if len(fields) == 0 || len(fields) > 2 {
+		return accesscontrol.Permission{}, fmt.Errorf("invalid anonymous permission %q, expected an action and an optional scope", permission)
+	}
+	p := accesscontrol.Permission{Action: fields[0]}
+	if len(fields) == 2 {
+		p.Scope = fields[1]
+		if !accesscontrol.ValidateScope(p.Scope) {
+			return accesscontrol.Permission{}, fmt.Errorf("invalid scope %q of anonymous permission %q", p.Scope, permission)
+		}
+	}
+	return p, nil
+}
@@ -30,3 +30,7 @@ func (f *FakeService) CountDevices(ctx context.Context, from time.Time, to time.
 func (f *FakeService) ListDevices(ctx context.Context, from *time.Time, to *time.Time) ([]*anonstore.Device, error) {
 	return f.ExpectedListDevices, f.ExpectedError
 }
+
+func (f *FakeService) LimitQuery(ctx context.Context, httpReq *http.Request) error {
+	return f.ExpectedError
+}
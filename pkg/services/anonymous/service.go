@@ -18,4 +18,6 @@ type Service interface {
 	TagDevice(context.Context, *http.Request, DeviceKind) error
 	CountDevices(ctx context.Context, from time.Time, to time.Time) (int64, error)
 	ListDevices(ctx context.Context, from *time.Time, to *time.Time) ([]*anonstore.Device, error)
+	// LimitQuery returns an error when the anonymous client sending the request reached its query rate limit
+	LimitQuery(ctx context.Context, httpReq *http.Request) error
 }
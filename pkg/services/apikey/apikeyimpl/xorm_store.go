@@ -7,6 +7,7 @@ import (
 
 	"xorm.io/xorm"
 
+	"github.com/grafana/grafana/pkg/events"
 	"github.com/grafana/grafana/pkg/infra/db"
 	"github.com/grafana/grafana/pkg/services/accesscontrol"
 	"github.com/grafana/grafana/pkg/services/apikey"
@@ -130,6 +131,13 @@ func (ss *sqlStore) AddAPIKey(ctx context.Context, cmd *apikey.AddCommand) (res
 		if _, err := sess.Insert(&t); err != nil {
 			return fmt.Errorf("%s: %w", "failed to insert token", err)
 		}
+		sess.PublishAfterCommit(&events.APIKeyCreated{
+			Timestamp:        updated,
+			Name:             t.Name,
+			ID:               t.ID,
+			OrgID:            t.OrgID,
+			ServiceAccountID: t.ServiceAccountId,
+		})
 		res = &t
 		return nil
 	})
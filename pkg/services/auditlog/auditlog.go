@@ -0,0 +1,99 @@
+package auditlog
+
+import (
+	"context"
+	"time"
+
+	"github.com/grafana/grafana/pkg/apimachinery/errutil"
+)
+
+var ErrDatabaseSinkDisabled = errutil.BadRequest("auditlog.database-sink-disabled", errutil.WithPublicMessage("The audit log events are not stored in the database"))
+
+// Actions recorded in the audit log
+const (
+	ActionLogin             = "login"
+	ActionPermissionChange  = "permission-change"
+	ActionDashboardSave     = "dashboard-save"
+	ActionDashboardDelete   = "dashboard-delete"
+	ActionDataSourceCreate  = "datasource-create"
+	ActionDataSourceUpdate  = "datasource-update"
+	ActionDataSourceDelete  = "datasource-delete"
+	ActionAlertRuleCreate   = "alert-rule-create"
+	ActionAlertRuleUpdate   = "alert-rule-update"
+	ActionAlertRuleDelete   = "alert-rule-delete"
+	ActionTokenCreate       = "token-create"
+	ActionUserDelete        = "user-delete"
+	ActionNamespaceRestore  = "namespace-restore"
+	ActionTeamMemberAdd     = "team-member-add"
+	ActionTeamMemberRemove  = "team-member-remove"
+	ActionTOTPReset         = "totp-reset"
+	ResultSuccess           = "success"
+	ResultFailure           = "failure"
+	ResourceKindDashboard   = "dashboard"
+	ResourceKindDataSource  = "datasource"
+	ResourceKindAlertRule   = "alert-rule"
+	ResourceKindToken       = "token"
+	ResourceKindUser        = "user"
+	ResourceKindPermissions = "permissions"
+	ResourceKindNamespace   = "namespace"
+	ResourceKindTeam        = "team"
+)
+
+type recordedKey struct{}
+
+// WithRecorded returns a context telling that the action done with it is already recorded by the caller,
+// so that the listeners of the events published for the same action do not record it twice
+func WithRecorded(ctx context.Context) context.Context {
+	return context.WithValue(ctx, recordedKey{}, true)
+}
+
+// IsRecorded returns whether the action done with the context is already recorded by the caller
+func IsRecorded(ctx context.Context) bool {
+	recorded, _ := ctx.Value(recordedKey{}).(bool)
+	return recorded
+}
+
+type Service interface {
+	// Log records an event in the configured sinks, failures are logged and never returned to the caller
+	Log(ctx context.Context, event *Event)
+	// Search returns the events stored in the database, the most recent first
+	Search(ctx context.Context, query *SearchQuery) (*SearchResult, error)
+	// DeleteExpired deletes the events older than the retention from the database
+	DeleteExpired(ctx context.Context) (int64, error)
+}
+
+// Event is an action done in Grafana
+type Event struct {
+	ID      int64     `json:"id"`
+	Created time.Time `json:"created"`
+	OrgID   int64     `json:"orgId"`
+	Action  string    `json:"action"`
+	Result  string    `json:"result"`
+	// ActorUID and ActorLogin identify who did the action, empty for the actions done by Grafana itself
+	ActorUID     string `json:"actorUid"`
+	ActorLogin   string `json:"actorLogin"`
+	IPAddress    string `json:"ipAddress"`
+	ResourceKind string `json:"resourceKind"`
+	ResourceUID  string `json:"resourceUid"`
+	// Details of the action, depending on the action
+	Details map[string]string `json:"details,omitempty"`
+}
+
+type SearchQuery struct {
+	OrgID        int64
+	Action       string
+	ActorLogin   string
+	ResourceKind string
+	ResourceUID  string
+	From         time.Time
+	To           time.Time
+	Page         int
+	Limit        int
+}
+
+type SearchResult struct {
+	TotalCount int64    `json:"totalCount"`
+	Events     []*Event `json:"events"`
+	Page       int      `json:"page"`
+	PerPage    int      `json:"perPage"`
+}
@@ -0,0 +1,47 @@
+package auditlogimpl
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/middleware"
+	"github.com/grafana/grafana/pkg/services/auditlog"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+)
+
+type api struct {
+	service *Service
+}
+
+func registerAPI(routeRegister routing.RouteRegister, s *Service) {
+	a := &api{service: s}
+	routeRegister.Get("/api/admin/audit-logs", middleware.ReqGrafanaAdmin, routing.Wrap(a.search))
+}
+
+// GET /api/admin/audit-logs
+// from and to are epoch milliseconds, like for the annotations
+func (a *api) search(c *contextmodel.ReqContext) response.Response {
+	query := &auditlog.SearchQuery{
+		OrgID:        c.QueryInt64("orgId"),
+		Action:       c.Query("action"),
+		ActorLogin:   c.Query("actor"),
+		ResourceKind: c.Query("resourceKind"),
+		ResourceUID:  c.Query("resourceUid"),
+		Page:         c.QueryInt("page"),
+		Limit:        c.QueryInt("perpage"),
+	}
+	if from := c.QueryInt64("from"); from > 0 {
+		query.From = time.UnixMilli(from)
+	}
+	if to := c.QueryInt64("to"); to > 0 {
+		query.To = time.UnixMilli(to)
+	}
+
+	result, err := a.service.Search(c.Req.Context(), query)
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to search the audit log", err)
+	}
+	return response.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,230 @@
+package auditlogimpl
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/events"
+	"github.com/grafana/grafana/pkg/services/auditlog"
+	"github.com/grafana/grafana/pkg/services/authn"
+	ngstore "github.com/grafana/grafana/pkg/services/ngalert/store"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+const resourceKindFolder = "folder"
+
+// registerListeners records the events published by the services owning the audited resources.
+// The listeners never return an error, it would stop the other listeners of the event.
+func registerListeners(b bus.Bus, s *Service) {
+	b.AddEventListener(s.onDataSourceCreated)
+	b.AddEventListener(s.onDataSourceUpdated)
+	b.AddEventListener(s.onDataSourceDeleted)
+	b.AddEventListener(s.onDashboardSaved)
+	b.AddEventListener(s.onDashboardDeleted)
+	b.AddEventListener(s.onResourcePermissionChanged)
+	b.AddEventListener(s.onAPIKeyCreated)
+	b.AddEventListener(s.onUserDeleted)
+	b.AddEventListener(s.onRuleChange)
+	b.AddEventListener(s.onTeamMembershipSynced)
+	b.AddEventListener(s.onTOTPReset)
+}
+
+func (s *Service) onDataSourceCreated(ctx context.Context, e *events.DataSourceCreated) error {
+	s.Log(ctx, &auditlog.Event{
+		OrgID:        e.OrgID,
+		Action:       auditlog.ActionDataSourceCreate,
+		ResourceKind: auditlog.ResourceKindDataSource,
+		ResourceUID:  e.UID,
+		Details:      map[string]string{"name": e.Name},
+	})
+	return nil
+}
+
+func (s *Service) onDataSourceUpdated(ctx context.Context, e *events.DataSourceUpdated) error {
+	s.Log(ctx, &auditlog.Event{
+		OrgID:        e.OrgID,
+		Action:       auditlog.ActionDataSourceUpdate,
+		ResourceKind: auditlog.ResourceKindDataSource,
+		ResourceUID:  e.UID,
+		Details:      map[string]string{"name": e.Name},
+	})
+	return nil
+}
+
+func (s *Service) onDataSourceDeleted(ctx context.Context, e *events.DataSourceDeleted) error {
+	s.Log(ctx, &auditlog.Event{
+		OrgID:        e.OrgID,
+		Action:       auditlog.ActionDataSourceDelete,
+		ResourceKind: auditlog.ResourceKindDataSource,
+		ResourceUID:  e.UID,
+		Details:      map[string]string{"name": e.Name},
+	})
+	return nil
+}
+
+func dashboardKind(isFolder bool) string {
+	if isFolder {
+		return resourceKindFolder
+	}
+	return auditlog.ResourceKindDashboard
+}
+
+// The dashboards written through the apiserver are recorded by its storage, including when it writes
+// them to the legacy store that publishes these events.
+func (s *Service) onDashboardSaved(ctx context.Context, e *events.DashboardSaved) error {
+	if auditlog.IsRecorded(ctx) {
+		return nil
+	}
+	s.Log(ctx, &auditlog.Event{
+		OrgID:        e.OrgID,
+		Action:       auditlog.ActionDashboardSave,
+		ResourceKind: dashboardKind(e.IsFolder),
+		ResourceUID:  e.UID,
+		Details:      map[string]string{"title": e.Title, "version": strconv.Itoa(e.Version)},
+	})
+	return nil
+}
+
+func (s *Service) onDashboardDeleted(ctx context.Context, e *events.DashboardDeleted) error {
+	if auditlog.IsRecorded(ctx) {
+		return nil
+	}
+	s.Log(ctx, &auditlog.Event{
+		OrgID:        e.OrgID,
+		Action:       auditlog.ActionDashboardDelete,
+		ResourceKind: dashboardKind(e.IsFolder),
+		ResourceUID:  e.UID,
+		Details:      map[string]string{"title": e.Title},
+	})
+	return nil
+}
+
+func (s *Service) onResourcePermissionChanged(ctx context.Context, e *events.ResourcePermissionChanged) error {
+	details := map[string]string{"permission": e.Permission}
+	switch {
+	case e.UserID != 0:
+		details["userId"] = strconv.FormatInt(e.UserID, 10)
+	case e.TeamID != 0:
+		details["teamId"] = strconv.FormatInt(e.TeamID, 10)
+	case e.BuiltInRole != "":
+		details["role"] = e.BuiltInRole
+	}
+	s.Log(ctx, &auditlog.Event{
+		OrgID:        e.OrgID,
+		Action:       auditlog.ActionPermissionChange,
+		ResourceKind: auditlog.ResourceKindPermissions,
+		ResourceUID:  fmt.Sprintf("%s:%s", e.Resource, e.ResourceID),
+		Details:      details,
+	})
+	return nil
+}
+
+func (s *Service) onTeamMembershipSynced(ctx context.Context, e *events.TeamMembershipSynced) error {
+	action := auditlog.ActionTeamMemberAdd
+	if e.Removed {
+		action = auditlog.ActionTeamMemberRemove
+	}
+	s.Log(ctx, &auditlog.Event{
+		OrgID:        e.OrgID,
+		Action:       action,
+		ResourceKind: auditlog.ResourceKindTeam,
+		ResourceUID:  strconv.FormatInt(e.TeamID, 10),
+		Details: map[string]string{
+			"team":       e.TeamName,
+			"userId":     strconv.FormatInt(e.UserID, 10),
+			"authModule": e.AuthModule,
+		},
+	})
+	return nil
+}
+
+func (s *Service) onAPIKeyCreated(ctx context.Context, e *events.APIKeyCreated) error {
+	details := map[string]string{"name": e.Name}
+	if e.ServiceAccountID != nil {
+		details["serviceAccountId"] = strconv.FormatInt(*e.ServiceAccountID, 10)
+	}
+	s.Log(ctx, &auditlog.Event{
+		OrgID:        e.OrgID,
+		Action:       auditlog.ActionTokenCreate,
+		ResourceKind: auditlog.ResourceKindToken,
+		ResourceUID:  strconv.FormatInt(e.ID, 10),
+		Details:      details,
+	})
+	return nil
+}
+
+func (s *Service) onUserDeleted(ctx context.Context, e *events.UserDeleted) error {
+	s.Log(ctx, &auditlog.Event{
+		Action:       auditlog.ActionUserDelete,
+		ResourceKind: auditlog.ResourceKindUser,
+		ResourceUID:  strconv.FormatInt(e.Id, 10),
+		Details:      map[string]string{"login": e.Login},
+	})
+	return nil
+}
+
+func (s *Service) onTOTPReset(ctx context.Context, e *events.TOTPReset) error {
+	s.Log(ctx, &auditlog.Event{
+		Action:       auditlog.ActionTOTPReset,
+		ResourceKind: auditlog.ResourceKindUser,
+		ResourceUID:  strconv.FormatInt(e.UserID, 10),
+	})
+	return nil
+}
+
+func (s *Service) onRuleChange(ctx context.Context, e *ngstore.RuleChangeEvent) error {
+	var action string
+	switch e.Type {
+	case ngstore.RuleChangeCreate:
+		action = auditlog.ActionAlertRuleCreate
+	case ngstore.RuleChangeUpdate:
+		action = auditlog.ActionAlertRuleUpdate
+	case ngstore.RuleChangeDelete:
+		action = auditlog.ActionAlertRuleDelete
+	default:
+		return nil
+	}
+	for _, key := range e.RuleKeys {
+		s.Log(ctx, &auditlog.Event{
+			OrgID:        key.OrgID,
+			Action:       action,
+			ResourceKind: auditlog.ResourceKindAlertRule,
+			ResourceUID:  key.UID,
+		})
+	}
+	return nil
+}
+
+// loginHook records the successful and the failed logins
+func (s *Service) loginHook(ctx context.Context, id *authn.Identity, r *authn.Request, err error) {
+	event := &auditlog.Event{
+		Action:       auditlog.ActionLogin,
+		Result:       auditlog.ResultSuccess,
+		ResourceKind: auditlog.ResourceKindUser,
+		Details:      map[string]string{},
+	}
+	if r != nil && r.HTTPRequest != nil {
+		event.IPAddress = web.ClientIP(r.HTTPRequest, s.trustedProxies)
+	}
+
+	if err != nil || id == nil {
+		event.Result = auditlog.ResultFailure
+		if r != nil {
+			event.ActorLogin = r.GetMeta(authn.MetaKeyUsername)
+			event.Details["authModule"] = r.GetMeta(authn.MetaKeyAuthModule)
+		}
+		if err != nil {
+			event.Details["error"] = err.Error()
+		}
+	} else {
+		event.OrgID = id.GetOrgID()
+		event.ActorUID = id.GetIdentifier()
+		event.ActorLogin = id.GetLogin()
+		event.ResourceUID = id.GetIdentifier()
+		event.Details["authModule"] = id.AuthenticatedBy
+	}
+
+	s.Log(ctx, event)
+}
@@ -0,0 +1,242 @@
+package auditlogimpl
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	gokitlog "github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/apimachinery/identity"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/auditlog"
+	"github.com/grafana/grafana/pkg/services/authn"
+	"github.com/grafana/grafana/pkg/services/contexthandler"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+const (
+	// Number of events waiting to be written before Log drops the events
+	queueSize = 1000
+	// Priority of the post login hook, after the login has been completed by the other hooks
+	loginHookPriority = 180
+)
+
+var _ auditlog.Service = new(Service)
+
+// writer is a sink writing the events as log lines
+type writer interface {
+	Log(keyvals ...any) error
+}
+
+// Service records the audit events. The events are queued by Log and written by Run so that
+// recording an event never fails or slows down the action being recorded. When the writes fall
+// behind and the queue is full, Log drops the event and counts it in a metric.
+type Service struct {
+	settings setting.AuditLogSettings
+	// store is nil when the database sink is disabled
+	store         *store
+	writers       []writer
+	queue         chan *auditlog.Event
+	droppedEvents prometheus.Counter
+	// the X-Forwarded-For header is only trusted from these proxies
+	trustedProxies []*net.IPNet
+	now            func() time.Time
+	log            log.Logger
+}
+
+func ProvideService(cfg *setting.Cfg, sqlStore db.DB, bus bus.Bus, authnService authn.Service, routeRegister routing.RouteRegister, reg prometheus.Registerer) *Service {
+	s := NewService(cfg.AuditLog, sqlStore)
+	s.trustedProxies = cfg.TrustedProxies
+	if s.settings.Enabled {
+		reg.MustRegister(s.droppedEvents)
+		authnService.RegisterPostLoginHook(s.loginHook, loginHookPriority)
+		registerListeners(bus, s)
+		registerAPI(routeRegister, s)
+	}
+	return s
+}
+
+func NewService(settings setting.AuditLogSettings, sqlStore db.DB) *Service {
+	s := &Service{
+		settings: settings,
+		queue:    make(chan *auditlog.Event, queueSize),
+		droppedEvents: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "grafana",
+			Subsystem: "auditlog",
+			Name:      "dropped_events_total",
+			Help:      "Number of audit log events dropped because the queue of events waiting to be written was full",
+		}),
+		now: time.Now,
+		log: log.New("auditlog"),
+	}
+	if !settings.Enabled {
+		return s
+	}
+
+	if settings.HasSink(setting.AuditLogSinkDatabase) {
+		s.store = &store{sqlStore: sqlStore}
+	}
+	if settings.HasSink(setting.AuditLogSinkFile) {
+		w, err := newFileWriter(settings)
+		if err != nil {
+			s.log.Error("Failed to open the audit log file", "path", settings.FilePath, "error", err)
+		} else {
+			s.writers = append(s.writers, w)
+		}
+	}
+	if settings.HasSink(setting.AuditLogSinkSyslog) {
+		w, err := log.NewSyslogHandler(settings.SyslogNetwork, settings.SyslogAddress, settings.SyslogFacility, settings.SyslogTag, jsonFormat)
+		if err != nil {
+			s.log.Error("Failed to connect to syslog", "network", settings.SyslogNetwork, "address", settings.SyslogAddress, "error", err)
+		} else {
+			s.writers = append(s.writers, w)
+		}
+	}
+	return s
+}
+
+func jsonFormat(w io.Writer) gokitlog.Logger {
+	return gokitlog.NewJSONLogger(w)
+}
+
+func newFileWriter(settings setting.AuditLogSettings) (*log.FileLogWriter, error) {
+	if err := os.MkdirAll(filepath.Dir(settings.FilePath), 0o750); err != nil {
+		return nil, err
+	}
+	w := log.NewFileWriter()
+	w.Filename = settings.FilePath
+	w.Format = jsonFormat
+	w.Maxsize = 1 << uint(settings.FileMaxSizeShift)
+	w.Daily = settings.FileDailyRotate
+	w.Maxdays = settings.FileMaxDays
+	if err := w.Init(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (s *Service) IsDisabled() bool {
+	return !s.settings.Enabled
+}
+
+func (s *Service) Run(ctx context.Context) error {
+	for {
+		select {
+		case event := <-s.queue:
+			s.write(ctx, event)
+		case <-ctx.Done():
+			// write the events still queued, the database may not be reachable anymore once Grafana stopped
+			for {
+				select {
+				case event := <-s.queue:
+					s.write(context.Background(), event)
+				default:
+					return ctx.Err()
+				}
+			}
+		}
+	}
+}
+
+// Log completes the event with the identity and the address of the current request, and queues it.
+// When the queue is full, the event is dropped.
+func (s *Service) Log(ctx context.Context, event *auditlog.Event) {
+	if !s.settings.Enabled {
+		return
+	}
+
+	if event.Created.IsZero() {
+		event.Created = s.now()
+	}
+	if event.Result == "" {
+		event.Result = auditlog.ResultSuccess
+	}
+	if event.ActorUID == "" && event.ActorLogin == "" {
+		if requester, err := identity.GetRequester(ctx); err == nil {
+			event.ActorUID = requester.GetIdentifier()
+			event.ActorLogin = requester.GetLogin()
+			if event.OrgID == 0 {
+				event.OrgID = requester.GetOrgID()
+			}
+		}
+	}
+	if event.IPAddress == "" {
+		if reqCtx := contexthandler.FromContext(ctx); reqCtx != nil && reqCtx.Context != nil && reqCtx.Req != nil {
+			event.IPAddress = web.ClientIP(reqCtx.Req, s.trustedProxies)
+		}
+	}
+
+	select {
+	case s.queue <- event:
+	default:
+		s.droppedEvents.Inc()
+		s.log.Error("Dropped audit log event, too many events are waiting to be written", "action", event.Action, "resourceKind", event.ResourceKind, "resourceUid", event.ResourceUID)
+	}
+}
+
+func (s *Service) write(ctx context.Context, event *auditlog.Event) {
+	if s.store != nil {
+		if err := s.store.insert(ctx, event); err != nil {
+			s.log.Error("Failed to store audit log event", "action", event.Action, "error", err)
+		}
+	}
+
+	if len(s.writers) == 0 {
+		return
+	}
+	keyvals := []any{
+		"time", event.Created.UTC().Format(time.RFC3339Nano),
+		"orgId", event.OrgID,
+		"action", event.Action,
+		"result", event.Result,
+		"actorUid", event.ActorUID,
+		"actorLogin", event.ActorLogin,
+		"ipAddress", event.IPAddress,
+		"resourceKind", event.ResourceKind,
+		"resourceUid", event.ResourceUID,
+	}
+	keys := make([]string, 0, len(event.Details))
+	for k := range event.Details {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		keyvals = append(keyvals, fmt.Sprintf("details.%s", k), event.Details[k])
+	}
+	for _, w := range s.writers {
+		if err := w.Log(keyvals...); err != nil {
+			s.log.Error("Failed to write audit log event", "action", event.Action, "error", err)
+		}
+	}
+}
+
+func (s *Service) Search(ctx context.Context, query *auditlog.SearchQuery) (*auditlog.SearchResult, error) {
+	if s.store == nil {
+		return nil, auditlog.ErrDatabaseSinkDisabled.Errorf("the audit log events are not stored in the database")
+	}
+	if query.Limit <= 0 {
+		query.Limit = 100
+	}
+	if query.Page <= 0 {
+		query.Page = 1
+	}
+	return s.store.search(ctx, query)
+}
+
+func (s *Service) DeleteExpired(ctx context.Context) (int64, error) {
+	if s.store == nil || s.settings.Retention <= 0 {
+		return 0, nil
+	}
+	return s.store.deleteBefore(ctx, s.now().Add(-s.settings.Retention))
+}
@@ -0,0 +1,209 @@
+package auditlogimpl
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	claims "github.com/grafana/authlib/types"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/apimachinery/identity"
+	"github.com/grafana/grafana/pkg/events"
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/services/auditlog"
+	"github.com/grafana/grafana/pkg/services/authn"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	ngstore "github.com/grafana/grafana/pkg/services/ngalert/store"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// flush writes the queued events like Run does
+func flush(ctx context.Context, s *Service) {
+	for len(s.queue) > 0 {
+		s.write(ctx, <-s.queue)
+	}
+}
+
+func TestIntegrationService(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	s := NewService(setting.AuditLogSettings{
+		Enabled:   true,
+		Sinks:     []string{setting.AuditLogSinkDatabase},
+		Retention: 24 * time.Hour,
+	}, db.InitTestDB(t))
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+
+	ctx := identity.WithRequester(context.Background(), &user.SignedInUser{UserID: 1, UserUID: "admin-uid", Login: "admin", OrgID: 1})
+
+	t.Run("record the events published by the services", func(t *testing.T) {
+		require.NoError(t, s.onDataSourceCreated(ctx, &events.DataSourceCreated{OrgID: 1, UID: "ds", Name: "Prometheus"}))
+		require.NoError(t, s.onDashboardSaved(ctx, &events.DashboardSaved{OrgID: 1, UID: "dash", Title: "Home", Version: 2}))
+		require.NoError(t, s.onDashboardDeleted(ctx, &events.DashboardDeleted{OrgID: 1, UID: "folder", Title: "Team", IsFolder: true}))
+		require.NoError(t, s.onResourcePermissionChanged(ctx, &events.ResourcePermissionChanged{OrgID: 1, Resource: "dashboards", ResourceID: "dash", Permission: "Edit", TeamID: 3}))
+		require.NoError(t, s.onRuleChange(ctx, &ngstore.RuleChangeEvent{Type: ngstore.RuleChangeDelete, RuleKeys: []models.AlertRuleKey{{OrgID: 2, UID: "rule-1"}, {OrgID: 2, UID: "rule-2"}}}))
+		flush(ctx, s)
+
+		result, err := s.Search(ctx, &auditlog.SearchQuery{OrgID: 1})
+		require.NoError(t, err)
+		require.EqualValues(t, 4, result.TotalCount)
+		for _, e := range result.Events {
+			require.Equal(t, "admin-uid", e.ActorUID)
+			require.Equal(t, "admin", e.ActorLogin)
+			require.Equal(t, auditlog.ResultSuccess, e.Result)
+		}
+
+		result, err = s.Search(ctx, &auditlog.SearchQuery{Action: auditlog.ActionPermissionChange})
+		require.NoError(t, err)
+		require.Len(t, result.Events, 1)
+		require.Equal(t, "dashboards:dash", result.Events[0].ResourceUID)
+		require.Equal(t, map[string]string{"permission": "Edit", "teamId": "3"}, result.Events[0].Details)
+
+		result, err = s.Search(ctx, &auditlog.SearchQuery{Action: auditlog.ActionDashboardDelete})
+		require.NoError(t, err)
+		require.Len(t, result.Events, 1)
+		require.Equal(t, resourceKindFolder, result.Events[0].ResourceKind)
+
+		result, err = s.Search(ctx, &auditlog.SearchQuery{ResourceKind: auditlog.ResourceKindAlertRule, Limit: 1})
+		require.NoError(t, err)
+		require.EqualValues(t, 2, result.TotalCount)
+		require.Len(t, result.Events, 1)
+		require.Equal(t, auditlog.ActionAlertRuleDelete, result.Events[0].Action)
+		require.EqualValues(t, 2, result.Events[0].OrgID)
+	})
+
+	t.Run("record the team memberships changed by the team sync", func(t *testing.T) {
+		require.NoError(t, s.onTeamMembershipSynced(ctx, &events.TeamMembershipSynced{OrgID: 3, TeamID: 10, TeamName: "Admins", UserID: 2, AuthModule: "oauth_generic_oauth"}))
+		require.NoError(t, s.onTeamMembershipSynced(ctx, &events.TeamMembershipSynced{OrgID: 3, TeamID: 11, TeamName: "Viewers", UserID: 2, AuthModule: "oauth_generic_oauth", Removed: true}))
+		flush(ctx, s)
+
+		result, err := s.Search(ctx, &auditlog.SearchQuery{OrgID: 3, ResourceKind: auditlog.ResourceKindTeam})
+		require.NoError(t, err)
+		require.Len(t, result.Events, 2)
+		actions := map[string]*auditlog.Event{}
+		for _, e := range result.Events {
+			actions[e.Action] = e
+		}
+		require.Equal(t, "10", actions[auditlog.ActionTeamMemberAdd].ResourceUID)
+		require.Equal(t, map[string]string{"team": "Admins", "userId": "2", "authModule": "oauth_generic_oauth"}, actions[auditlog.ActionTeamMemberAdd].Details)
+		require.Equal(t, "11", actions[auditlog.ActionTeamMemberRemove].ResourceUID)
+	})
+
+	t.Run("record the successful and the failed logins", func(t *testing.T) {
+		// the client can't spoof its address from an untrusted connection
+		r := &authn.Request{HTTPRequest: &http.Request{RemoteAddr: "10.0.0.1:5000", Header: http.Header{"X-Forwarded-For": []string{"192.168.0.1"}}}}
+		r.SetMeta(authn.MetaKeyUsername, "alice")
+		r.SetMeta(authn.MetaKeyAuthModule, "password")
+		s.loginHook(context.Background(), nil, r, errors.New("invalid username or password"))
+		s.loginHook(context.Background(), &authn.Identity{ID: "2", UID: "alice-uid", Type: claims.TypeUser, Login: "alice", OrgID: 1, AuthenticatedBy: "password"}, r, nil)
+		flush(ctx, s)
+
+		result, err := s.Search(ctx, &auditlog.SearchQuery{Action: auditlog.ActionLogin, ActorLogin: "alice"})
+		require.NoError(t, err)
+		require.Len(t, result.Events, 2)
+		results := map[string]*auditlog.Event{}
+		for _, e := range result.Events {
+			require.Equal(t, "10.0.0.1", e.IPAddress)
+			results[e.Result] = e
+		}
+		require.Equal(t, "alice-uid", results[auditlog.ResultSuccess].ActorUID)
+		require.Equal(t, "invalid username or password", results[auditlog.ResultFailure].Details["error"])
+	})
+
+	t.Run("filter the events by date", func(t *testing.T) {
+		result, err := s.Search(ctx, &auditlog.SearchQuery{From: now.Add(time.Minute)})
+		require.NoError(t, err)
+		require.Empty(t, result.Events)
+
+		result, err = s.Search(ctx, &auditlog.SearchQuery{From: now.Add(-time.Minute), To: now.Add(time.Minute)})
+		require.NoError(t, err)
+		require.EqualValues(t, 10, result.TotalCount)
+	})
+
+	t.Run("delete the events older than the retention", func(t *testing.T) {
+		now = now.Add(24*time.Hour + time.Minute)
+		s.Log(ctx, &auditlog.Event{Action: auditlog.ActionUserDelete, ResourceKind: auditlog.ResourceKindUser, ResourceUID: "3"})
+		flush(ctx, s)
+
+		deleted, err := s.DeleteExpired(ctx)
+		require.NoError(t, err)
+		require.EqualValues(t, 10, deleted)
+
+		result, err := s.Search(ctx, &auditlog.SearchQuery{})
+		require.NoError(t, err)
+		require.Len(t, result.Events, 1)
+		require.Equal(t, auditlog.ActionUserDelete, result.Events[0].Action)
+	})
+}
+
+func TestService_FileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit", "audit.log")
+	s := NewService(setting.AuditLogSettings{
+		Enabled:          true,
+		Sinks:            []string{setting.AuditLogSinkFile},
+		FilePath:         path,
+		FileMaxDays:      7,
+		FileMaxSizeShift: 28,
+	}, nil)
+	require.Nil(t, s.store)
+	require.Len(t, s.writers, 1)
+
+	ctx := context.Background()
+	s.Log(ctx, &auditlog.Event{OrgID: 1, Action: auditlog.ActionTokenCreate, ResourceKind: auditlog.ResourceKindToken, ResourceUID: "4", Details: map[string]string{"name": "ci"}})
+	flush(ctx, s)
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	line := strings.TrimSpace(string(content))
+	require.Contains(t, line, `"action":"token-create"`)
+	require.Contains(t, line, `"details.name":"ci"`)
+
+	_, err = s.Search(ctx, &auditlog.SearchQuery{})
+	require.ErrorIs(t, err, auditlog.ErrDatabaseSinkDisabled)
+	deleted, err := s.DeleteExpired(ctx)
+	require.NoError(t, err)
+	require.Zero(t, deleted)
+}
+
+func TestService_Disabled(t *testing.T) {
+	s := NewService(setting.AuditLogSettings{Sinks: []string{setting.AuditLogSinkDatabase}}, nil)
+	require.True(t, s.IsDisabled())
+	s.Log(context.Background(), &auditlog.Event{Action: auditlog.ActionLogin})
+	require.Empty(t, s.queue)
+}
+
+func TestService_QueueFull(t *testing.T) {
+	s := NewService(setting.AuditLogSettings{Enabled: true}, nil)
+	ctx := context.Background()
+	for range queueSize {
+		s.Log(ctx, &auditlog.Event{Action: auditlog.ActionLogin})
+	}
+	require.Zero(t, testutil.ToFloat64(s.droppedEvents))
+
+	s.Log(ctx, &auditlog.Event{Action: auditlog.ActionUserDelete})
+	require.Len(t, s.queue, queueSize)
+	require.Equal(t, 1.0, testutil.ToFloat64(s.droppedEvents))
+}
+
+func TestService_RecordedDashboardEvents(t *testing.T) {
+	s := NewService(setting.AuditLogSettings{Enabled: true}, nil)
+	ctx := auditlog.WithRecorded(context.Background())
+
+	require.NoError(t, s.onDashboardSaved(ctx, &events.DashboardSaved{OrgID: 1, UID: "dash", Title: "Home", Version: 2}))
+	require.NoError(t, s.onDashboardDeleted(ctx, &events.DashboardDeleted{OrgID: 1, UID: "dash", Title: "Home"}))
+	require.Empty(t, s.queue)
+
+	require.NoError(t, s.onDataSourceCreated(ctx, &events.DataSourceCreated{OrgID: 1, UID: "ds", Name: "Prometheus"}))
+	require.Len(t, s.queue, 1)
+}
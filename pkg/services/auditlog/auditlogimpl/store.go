@@ -0,0 +1,143 @@
+package auditlogimpl
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/services/auditlog"
+)
+
+// auditLogEvent is an event stored in the database
+type auditLogEvent struct {
+	ID           int64     `xorm:"pk autoincr 'id'"`
+	Created      time.Time `xorm:"'created'"`
+	OrgID        int64     `xorm:"org_id"`
+	Action       string    `xorm:"action"`
+	Result       string    `xorm:"result"`
+	ActorUID     string    `xorm:"actor_uid"`
+	ActorLogin   string    `xorm:"actor_login"`
+	IPAddress    string    `xorm:"ip_address"`
+	ResourceKind string    `xorm:"resource_kind"`
+	ResourceUID  string    `xorm:"resource_uid"`
+	// JSON encoded details
+	Details string `xorm:"details"`
+}
+
+func (auditLogEvent) TableName() string {
+	return "audit_log"
+}
+
+func (e *auditLogEvent) toEvent() *auditlog.Event {
+	event := &auditlog.Event{
+		ID:           e.ID,
+		Created:      e.Created,
+		OrgID:        e.OrgID,
+		Action:       e.Action,
+		Result:       e.Result,
+		ActorUID:     e.ActorUID,
+		ActorLogin:   e.ActorLogin,
+		IPAddress:    e.IPAddress,
+		ResourceKind: e.ResourceKind,
+		ResourceUID:  e.ResourceUID,
+	}
+	if e.Details != "" {
+		// the details are written by the store, an invalid value only loses the details
+		_ = json.Unmarshal([]byte(e.Details), &event.Details)
+	}
+	return event
+}
+
+type store struct {
+	sqlStore db.DB
+}
+
+func (s *store) insert(ctx context.Context, event *auditlog.Event) error {
+	row := &auditLogEvent{
+		Created:      event.Created,
+		OrgID:        event.OrgID,
+		Action:       event.Action,
+		Result:       event.Result,
+		ActorUID:     event.ActorUID,
+		ActorLogin:   event.ActorLogin,
+		IPAddress:    event.IPAddress,
+		ResourceKind: event.ResourceKind,
+		ResourceUID:  event.ResourceUID,
+	}
+	if len(event.Details) > 0 {
+		details, err := json.Marshal(event.Details)
+		if err != nil {
+			return err
+		}
+		row.Details = string(details)
+	}
+
+	return s.sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+		if _, err := sess.Insert(row); err != nil {
+			return err
+		}
+		event.ID = row.ID
+		return nil
+	})
+}
+
+func (s *store) search(ctx context.Context, query *auditlog.SearchQuery) (*auditlog.SearchResult, error) {
+	result := &auditlog.SearchResult{Events: []*auditlog.Event{}, Page: query.Page, PerPage: query.Limit}
+	err := s.sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+		filter := func() *db.Session {
+			sess.Table("audit_log")
+			if query.OrgID != 0 {
+				sess.Where("org_id = ?", query.OrgID)
+			}
+			if query.Action != "" {
+				sess.Where("action = ?", query.Action)
+			}
+			if query.ActorLogin != "" {
+				sess.Where("actor_login = ?", query.ActorLogin)
+			}
+			if query.ResourceKind != "" {
+				sess.Where("resource_kind = ?", query.ResourceKind)
+			}
+			if query.ResourceUID != "" {
+				sess.Where("resource_uid = ?", query.ResourceUID)
+			}
+			if !query.From.IsZero() {
+				sess.Where("created >= ?", query.From)
+			}
+			if !query.To.IsZero() {
+				sess.Where("created <= ?", query.To)
+			}
+			return sess
+		}
+
+		count, err := filter().Count()
+		if err != nil {
+			return err
+		}
+		result.TotalCount = count
+
+		rows := make([]*auditLogEvent, 0)
+		if err := filter().Desc("created", "id").Limit(query.Limit, (query.Page-1)*query.Limit).Find(&rows); err != nil {
+			return err
+		}
+		for _, row := range rows {
+			result.Events = append(result.Events, row.toEvent())
+		}
+		return nil
+	})
+	return result, err
+}
+
+func (s *store) deleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	var affected int64
+	err := s.sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+		res, err := sess.Exec("DELETE FROM audit_log WHERE created < ?", before)
+		if err != nil {
+			return err
+		}
+		affected, err = res.RowsAffected()
+		return err
+	})
+	return affected, err
+}
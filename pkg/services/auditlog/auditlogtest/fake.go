@@ -0,0 +1,27 @@
+package auditlogtest
+
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/services/auditlog"
+)
+
+var _ auditlog.Service = new(FakeService)
+
+type FakeService struct {
+	Events         []*auditlog.Event
+	ExpectedResult *auditlog.SearchResult
+	ExpectedErr    error
+}
+
+func (f *FakeService) Log(ctx context.Context, event *auditlog.Event) {
+	f.Events = append(f.Events, event)
+}
+
+func (f *FakeService) Search(ctx context.Context, query *auditlog.SearchQuery) (*auditlog.SearchResult, error) {
+	return f.ExpectedResult, f.ExpectedErr
+}
+
+func (f *FakeService) DeleteExpired(ctx context.Context) (int64, error) {
+	return 0, f.ExpectedErr
+}
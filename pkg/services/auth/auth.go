@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"time"
 
 	"github.com/grafana/grafana/pkg/models/usertoken"
 	"github.com/grafana/grafana/pkg/registry"
@@ -66,6 +67,27 @@ type RotateCommand struct {
 	UserAgent     string
 }
 
+// SearchUserTokensQuery filters the active user tokens, the zero value of a field disables its filter
+type SearchUserTokensQuery struct {
+	// OrgID returns the tokens of the members of the organization
+	OrgID  int64
+	UserID int64
+	// IdleSince returns the tokens not rotated since the given time
+	IdleSince time.Time
+	// AuthModule returns the tokens of the sessions created by an external auth module, e.g. oauth_generic_oauth
+	AuthModule string
+	// TokenIDs returns the tokens with the given ids
+	TokenIDs []int64
+	// Limit is the page size, all the tokens are returned when it is zero
+	Limit int
+	Page  int
+}
+
+type SearchUserTokensResult struct {
+	TotalCount int64
+	Tokens     []*UserToken
+}
+
 type CreateTokenCommand struct {
 	User            *user.User
 	ClientIP        net.IP
@@ -90,6 +112,7 @@ type UserTokenService interface {
 	RevokeAllUserTokens(ctx context.Context, userID int64) error
 	GetUserToken(ctx context.Context, userID, userTokenID int64) (*UserToken, error)
 	GetUserTokens(ctx context.Context, userID int64) ([]*UserToken, error)
+	SearchUserTokens(ctx context.Context, query *SearchUserTokensQuery) (*SearchUserTokensResult, error)
 	ActiveTokenCount(ctx context.Context, userID *int64) (int64, error)
 	GetUserRevokedTokens(ctx context.Context, userID int64) ([]*UserToken, error)
 }
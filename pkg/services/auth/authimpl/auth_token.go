@@ -165,6 +165,10 @@ func (s *UserAuthTokenService) LookupToken(ctx context.Context, unhashedToken st
 		}
 	}
 
+	if err := s.revokeIdleToken(ctx, &model); err != nil {
+		return nil, err
+	}
+
 	// Current incoming token is the previous auth token in the DB and the auth_token_seen is true
 	if model.AuthToken != hashedToken && model.PrevAuthToken == hashedToken && model.AuthTokenSeen {
 		model.AuthTokenSeen = false
@@ -272,6 +276,12 @@ func (s *UserAuthTokenService) RotateToken(ctx context.Context, cmd auth.RotateC
 		}
 		s.log.FromContext(ctx).Debug("Rotating token", "tokenID", token.Id, "userID", token.UserId, "createdAt", token.CreatedAt, "rotatedAt", token.RotatedAt)
 
+		// a session bound to the client network cannot be moved to another network by rotating its token
+		policy := s.cfg.SessionPolicy
+		if policy.BindClientIP && !auth.SameClientNetwork(net.ParseIP(token.ClientIp), cmd.IP, policy.BindIPv4Prefix, policy.BindIPv6Prefix) {
+			return nil, &auth.SessionPolicyError{UserID: token.UserId, TokenID: token.Id, Reason: auth.SessionPolicyClientIP}
+		}
+
 		newToken, err := s.rotateToken(ctx, token, cmd.IP, cmd.UserAgent)
 
 		if errors.Is(err, errTokenNotRotated) {
@@ -292,6 +302,41 @@ func (s *UserAuthTokenService) RotateToken(ctx context.Context, cmd auth.RotateC
 	return res.(*auth.UserToken), nil
 }
 
+// revokeIdleToken revokes the token idle, not rotated, for longer than the idle timeout of the roles of the user.
+// It is checked when the token is looked up, so that neither authenticating with the token nor rotating it makes
+// an idle session active again.
+func (s *UserAuthTokenService) revokeIdleToken(ctx context.Context, model *userAuthToken) error {
+	var token auth.UserToken
+	if err := model.toUserToken(&token); err != nil {
+		return err
+	}
+
+	expired, err := auth.IdleSessionExpired(ctx, s.cfg.SessionPolicy.IdleTimeoutByRole, &token, getTime(), s.userRoles)
+	if err != nil || !expired {
+		return err
+	}
+
+	s.log.FromContext(ctx).Info("Revoking idle session", "userID", token.UserId, "tokenID", token.Id)
+	if err := s.RevokeToken(ctx, &token, true); err != nil {
+		return err
+	}
+	return &auth.SessionPolicyError{UserID: token.UserId, TokenID: token.Id, Reason: auth.SessionPolicyIdleTimeout}
+}
+
+// userRoles returns whether the user is a Grafana server admin, and their roles in all their organizations.
+// They are read from the database, so that the token service does not depend on the user and org services.
+func (s *UserAuthTokenService) userRoles(ctx context.Context, userID int64) (bool, []string, error) {
+	var isGrafanaAdmin bool
+	var roles []string
+	err := s.sqlStore.WithDbSession(ctx, func(dbSession *db.Session) error {
+		if _, err := dbSession.Table("user").Cols("is_admin").Where("id = ?", userID).Get(&isGrafanaAdmin); err != nil {
+			return err
+		}
+		return dbSession.Table("org_user").Cols("role").Where("user_id = ?", userID).Find(&roles)
+	})
+	return isGrafanaAdmin, roles, err
+}
+
 func (s *UserAuthTokenService) rotateToken(ctx context.Context, token *auth.UserToken, clientIP net.IP, userAgent string) (*auth.UserToken, error) {
 	var clientIPStr string
 	if clientIP != nil {
@@ -512,6 +557,66 @@ func (s *UserAuthTokenService) GetUserTokens(ctx context.Context, userId int64)
 	return result, err
 }
 
+// SearchUserTokens returns the page of the active tokens matching the query, ordered by user and token id.
+func (s *UserAuthTokenService) SearchUserTokens(ctx context.Context, query *auth.SearchUserTokensQuery) (*auth.SearchUserTokensResult, error) {
+	where := []string{"created_at > ?", "rotated_at > ?", "revoked_at = 0"}
+	args := []any{s.createdAfterParam(), s.rotatedAfterParam()}
+	if query.UserID != 0 {
+		where = append(where, "user_id = ?")
+		args = append(args, query.UserID)
+	}
+	if query.OrgID != 0 {
+		where = append(where, "user_id IN (SELECT user_id FROM org_user WHERE org_id = ?)")
+		args = append(args, query.OrgID)
+	}
+	if !query.IdleSince.IsZero() {
+		where = append(where, "rotated_at <= ?")
+		args = append(args, query.IdleSince.Unix())
+	}
+	if query.AuthModule != "" {
+		where = append(where, "external_session_id IN (SELECT id FROM user_external_session WHERE auth_module = ?)")
+		args = append(args, query.AuthModule)
+	}
+	if len(query.TokenIDs) > 0 {
+		where = append(where, "id IN (?"+strings.Repeat(",?", len(query.TokenIDs)-1)+")")
+		for _, id := range query.TokenIDs {
+			args = append(args, id)
+		}
+	}
+	cond := strings.Join(where, " AND ")
+
+	result := &auth.SearchUserTokensResult{Tokens: []*auth.UserToken{}}
+	err := s.sqlStore.WithDbSession(ctx, func(dbSession *db.Session) error {
+		count, err := dbSession.Where(cond, args...).Count(&userAuthToken{})
+		if err != nil {
+			return err
+		}
+		result.TotalCount = count
+
+		sess := dbSession.Where(cond, args...).Asc("user_id", "id")
+		if query.Limit > 0 {
+			offset := query.Limit * (max(query.Page, 1) - 1)
+			sess.Limit(query.Limit, offset)
+		}
+		var tokens []*userAuthToken
+		if err := sess.Find(&tokens); err != nil {
+			return err
+		}
+
+		for _, token := range tokens {
+			var userToken auth.UserToken
+			if err := token.toUserToken(&userToken); err != nil {
+				return err
+			}
+			result.Tokens = append(result.Tokens, &userToken)
+		}
+
+		return nil
+	})
+
+	return result, err
+}
+
 // ActiveTokenCount returns the number of active tokens. If userID is nil, the count is for all users.
 func (s *UserAuthTokenService) ActiveTokenCount(ctx context.Context, userID *int64) (int64, error) {
 	if userID != nil && *userID < 1 {
@@ -787,6 +787,181 @@ func TestIntegrationTokenCount(t *testing.T) {
 	require.Equal(t, int64(0), count)
 }
 
+func TestIntegrationSearchUserTokens(t *testing.T) {
+	ctx := createTestContext(t)
+
+	now := time.Date(2018, 12, 13, 13, 45, 0, 0, time.UTC)
+	getTime = func() time.Time { return now }
+	defer func() { getTime = time.Now }()
+
+	createToken := func(userID int64, extSession *auth.ExternalSession) *auth.UserToken {
+		userToken, err := ctx.tokenService.CreateToken(context.Background(), &auth.CreateTokenCommand{
+			User:            &user.User{ID: userID},
+			ClientIP:        net.ParseIP("192.168.10.11"),
+			UserAgent:       "some user agent",
+			ExternalSession: extSession,
+		})
+		require.NoError(t, err)
+		return userToken
+	}
+
+	token1 := createToken(1, nil)
+	token2 := createToken(2, &auth.ExternalSession{UserID: 2, AuthModule: "oauth_generic_oauth", UserAuthID: 1})
+	token3 := createToken(3, nil)
+
+	err := ctx.sqlstore.WithDbSession(context.Background(), func(sess *db.Session) error {
+		_, err := sess.Exec("INSERT INTO org_user (org_id, user_id, role, created, updated) VALUES (?, ?, ?, ?, ?), (?, ?, ?, ?, ?)",
+			2, 1, "Viewer", now, now,
+			2, 2, "Editor", now, now)
+		return err
+	})
+	require.NoError(t, err)
+
+	_, err = ctx.updateRotatedAt(token3.Id, now.Add(-2*time.Hour).Unix())
+	require.NoError(t, err)
+
+	tokenIDs := func(tokens []*auth.UserToken) []int64 {
+		ids := make([]int64, 0, len(tokens))
+		for _, token := range tokens {
+			ids = append(ids, token.Id)
+		}
+		return ids
+	}
+
+	tests := []struct {
+		desc          string
+		query         auth.SearchUserTokensQuery
+		expected      []int64
+		expectedTotal int64
+	}{
+		{desc: "should return all the active tokens", query: auth.SearchUserTokensQuery{}, expected: []int64{token1.Id, token2.Id, token3.Id}},
+		{desc: "should filter by user", query: auth.SearchUserTokensQuery{UserID: 2}, expected: []int64{token2.Id}},
+		{desc: "should filter by organization", query: auth.SearchUserTokensQuery{OrgID: 2}, expected: []int64{token1.Id, token2.Id}},
+		{desc: "should filter by idle time", query: auth.SearchUserTokensQuery{IdleSince: now.Add(-time.Hour)}, expected: []int64{token3.Id}},
+		{desc: "should filter by auth module", query: auth.SearchUserTokensQuery{AuthModule: "oauth_generic_oauth"}, expected: []int64{token2.Id}},
+		{desc: "should combine the filters", query: auth.SearchUserTokensQuery{OrgID: 2, AuthModule: "ldap"}, expected: []int64{}},
+		{desc: "should filter by token ids", query: auth.SearchUserTokensQuery{TokenIDs: []int64{token1.Id, token3.Id}}, expected: []int64{token1.Id, token3.Id}},
+		{desc: "should page the tokens", query: auth.SearchUserTokensQuery{Limit: 2, Page: 2}, expected: []int64{token3.Id}, expectedTotal: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			result, err := ctx.tokenService.SearchUserTokens(context.Background(), &tt.query)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, tokenIDs(result.Tokens))
+			if tt.expectedTotal == 0 {
+				tt.expectedTotal = int64(len(tt.expected))
+			}
+			assert.Equal(t, tt.expectedTotal, result.TotalCount)
+		})
+	}
+}
+
+func TestIntegrationRotateTokenClientIPBinding(t *testing.T) {
+	ctx := createTestContext(t)
+	ctx.tokenService.cfg.SessionPolicy = setting.AuthSessionPolicySettings{
+		BindClientIP:   true,
+		BindIPv4Prefix: 24,
+		BindIPv6Prefix: 64,
+	}
+
+	token, err := ctx.tokenService.CreateToken(context.Background(), &auth.CreateTokenCommand{
+		User:      &user.User{ID: 10},
+		ClientIP:  net.ParseIP("192.168.10.11"),
+		UserAgent: "some user agent",
+	})
+	require.NoError(t, err)
+
+	t.Run("should rotate the token from the same network", func(t *testing.T) {
+		token, err = ctx.tokenService.RotateToken(context.Background(), auth.RotateCommand{
+			UnHashedToken: token.UnhashedToken,
+			IP:            net.ParseIP("192.168.10.200"),
+		})
+		require.NoError(t, err)
+	})
+
+	t.Run("should not rotate the token from another network", func(t *testing.T) {
+		_, err := ctx.tokenService.RotateToken(context.Background(), auth.RotateCommand{
+			UnHashedToken: token.UnhashedToken,
+			IP:            net.ParseIP("10.0.0.1"),
+		})
+		var policyErr *auth.SessionPolicyError
+		require.ErrorAs(t, err, &policyErr)
+		assert.Equal(t, auth.SessionPolicyClientIP, policyErr.Reason)
+		assert.ErrorIs(t, err, auth.ErrInvalidSessionToken)
+	})
+}
+
+func TestIntegrationIdleTimeout(t *testing.T) {
+	now := time.Now()
+	getTime = func() time.Time { return now }
+	defer func() { getTime = time.Now }()
+
+	ctx := createTestContext(t)
+	ctx.tokenService.cfg.SessionPolicy = setting.AuthSessionPolicySettings{
+		IdleTimeoutByRole: map[string]time.Duration{"Viewer": time.Hour, "Admin": 10 * time.Minute},
+	}
+	err := ctx.sqlstore.WithDbSession(context.Background(), func(sess *db.Session) error {
+		_, err := sess.Exec("INSERT INTO org_user (org_id, user_id, role, created, updated) VALUES (?, ?, ?, ?, ?)", 1, 10, "Viewer", now, now)
+		return err
+	})
+	require.NoError(t, err)
+
+	token, err := ctx.tokenService.CreateToken(context.Background(), &auth.CreateTokenCommand{
+		User:      &user.User{ID: 10},
+		ClientIP:  net.ParseIP("192.168.10.11"),
+		UserAgent: "some user agent",
+	})
+	require.NoError(t, err)
+
+	t.Run("should rotate the token idle for less than the timeout of the user roles", func(t *testing.T) {
+		now = now.Add(30 * time.Minute)
+		token, err = ctx.tokenService.RotateToken(context.Background(), auth.RotateCommand{
+			UnHashedToken: token.UnhashedToken,
+			IP:            net.ParseIP("192.168.10.11"),
+		})
+		require.NoError(t, err)
+	})
+
+	t.Run("should revoke the idle token instead of rotating it", func(t *testing.T) {
+		now = now.Add(2 * time.Hour)
+		_, err := ctx.tokenService.RotateToken(context.Background(), auth.RotateCommand{
+			UnHashedToken: token.UnhashedToken,
+			IP:            net.ParseIP("192.168.10.11"),
+		})
+		var policyErr *auth.SessionPolicyError
+		require.ErrorAs(t, err, &policyErr)
+		assert.Equal(t, auth.SessionPolicyIdleTimeout, policyErr.Reason)
+		assert.ErrorIs(t, err, auth.ErrInvalidSessionToken)
+
+		stored, err := ctx.getAuthTokenByID(token.Id)
+		require.NoError(t, err)
+		assert.NotZero(t, stored.RevokedAt)
+	})
+
+	t.Run("should revoke the idle token when looking it up", func(t *testing.T) {
+		token, err := ctx.tokenService.CreateToken(context.Background(), &auth.CreateTokenCommand{
+			User:      &user.User{ID: 10},
+			ClientIP:  net.ParseIP("192.168.10.11"),
+			UserAgent: "some user agent",
+		})
+		require.NoError(t, err)
+
+		_, err = ctx.tokenService.LookupToken(context.Background(), token.UnhashedToken)
+		require.NoError(t, err)
+
+		now = now.Add(2 * time.Hour)
+		_, err = ctx.tokenService.LookupToken(context.Background(), token.UnhashedToken)
+		var policyErr *auth.SessionPolicyError
+		require.ErrorAs(t, err, &policyErr)
+		assert.Equal(t, auth.SessionPolicyIdleTimeout, policyErr.Reason)
+
+		stored, err := ctx.getAuthTokenByID(token.Id)
+		require.NoError(t, err)
+		assert.NotZero(t, stored.RevokedAt)
+	})
+}
+
 func TestRevokeAllUserTokens(t *testing.T) {
 	t.Run("should not fail if the external sessions could not be removed", func(t *testing.T) {
 		ctx := createTestContext(t)
@@ -80,6 +80,9 @@ func (s *store) List(ctx context.Context, query *auth.ListExternalSessionQuery)
 
 	queryResult := make([]*auth.ExternalSession, 0)
 	err := s.sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+		if len(query.IDs) > 0 {
+			sess.In("id", query.IDs)
+		}
 		return sess.Find(&queryResult, externalSession)
 	})
 	if err != nil {
@@ -351,6 +351,36 @@ func (_m *MockUserAuthTokenService) RotateToken(ctx context.Context, cmd auth.Ro
 	return r0, r1
 }
 
+// SearchUserTokens provides a mock function with given fields: ctx, query
+func (_m *MockUserAuthTokenService) SearchUserTokens(ctx context.Context, query *auth.SearchUserTokensQuery) (*auth.SearchUserTokensResult, error) {
+	ret := _m.Called(ctx, query)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SearchUserTokens")
+	}
+
+	var r0 *auth.SearchUserTokensResult
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *auth.SearchUserTokensQuery) (*auth.SearchUserTokensResult, error)); ok {
+		return rf(ctx, query)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *auth.SearchUserTokensQuery) *auth.SearchUserTokensResult); ok {
+		r0 = rf(ctx, query)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*auth.SearchUserTokensResult)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *auth.SearchUserTokensQuery) error); ok {
+		r1 = rf(ctx, query)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // UpdateExternalSession provides a mock function with given fields: ctx, externalSessionID, cmd
 func (_m *MockUserAuthTokenService) UpdateExternalSession(ctx context.Context, externalSessionID int64, cmd *auth.UpdateExternalSessionCommand) error {
 	ret := _m.Called(ctx, externalSessionID, cmd)
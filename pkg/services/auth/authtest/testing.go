@@ -30,6 +30,7 @@ type FakeUserAuthTokenService struct {
 	ActiveTokenCountProvider            func(ctx context.Context, userID *int64) (int64, error)
 	GetUserTokenProvider                func(ctx context.Context, userID, userTokenID int64) (*auth.UserToken, error)
 	GetUserTokensProvider               func(ctx context.Context, userID int64) ([]*auth.UserToken, error)
+	SearchUserTokensProvider            func(ctx context.Context, query *auth.SearchUserTokensQuery) (*auth.SearchUserTokensResult, error)
 	GetUserRevokedTokensProvider        func(ctx context.Context, userID int64) ([]*auth.UserToken, error)
 	BatchRevokedTokenProvider           func(ctx context.Context, userIDs []int64) error
 }
@@ -69,6 +70,9 @@ func NewFakeUserAuthTokenService() *FakeUserAuthTokenService {
 		GetUserTokensProvider: func(ctx context.Context, userId int64) ([]*auth.UserToken, error) {
 			return nil, nil
 		},
+		SearchUserTokensProvider: func(ctx context.Context, query *auth.SearchUserTokensQuery) (*auth.SearchUserTokensResult, error) {
+			return &auth.SearchUserTokensResult{}, nil
+		},
 		GetExternalSessionProvider: func(ctx context.Context, externalSessionID int64) (*auth.ExternalSession, error) {
 			return nil, errors.New("settings Provider table not found")
 		},
@@ -129,6 +133,10 @@ func (s *FakeUserAuthTokenService) GetUserTokens(ctx context.Context, userId int
 	return s.GetUserTokensProvider(context.Background(), userId)
 }
 
+func (s *FakeUserAuthTokenService) SearchUserTokens(ctx context.Context, query *auth.SearchUserTokensQuery) (*auth.SearchUserTokensResult, error) {
+	return s.SearchUserTokensProvider(ctx, query)
+}
+
 func (s *FakeUserAuthTokenService) GetUserRevokedTokens(ctx context.Context, userId int64) ([]*auth.UserToken, error) {
 	return s.GetUserRevokedTokensProvider(context.Background(), userId)
 }
@@ -53,6 +53,8 @@ type ListExternalSessionQuery struct {
 	ID        int64
 	NameID    string
 	SessionID string
+	// IDs returns the external sessions with the given ids
+	IDs []int64
 }
 
 //go:generate mockery --name ExternalSessionStore --structname MockExternalSessionStore --outpkg authtest --filename external_session_store_mock.go --output ./authtest/
@@ -0,0 +1,92 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+const (
+	SessionPolicyIdleTimeout = "idle_timeout"
+	SessionPolicyClientIP    = "client_ip"
+)
+
+// SessionPolicyError is returned when a session violates the session policies
+// configured in [auth.session]
+type SessionPolicyError struct {
+	UserID  int64
+	TokenID int64
+	// Reason is the violated policy: SessionPolicyIdleTimeout or SessionPolicyClientIP
+	Reason string
+}
+
+func (e *SessionPolicyError) Unwrap() error { return ErrInvalidSessionToken }
+
+func (e *SessionPolicyError) Error() string {
+	return fmt.Sprintf("%s: session policy violated: %s", ErrInvalidSessionToken, e.Reason)
+}
+
+// SameClientNetwork reports whether the two client IPs belong to the same network,
+// the networks being sized by the IPv4 and IPv6 prefix lengths.
+// An unknown IP is considered part of any network.
+func SameClientNetwork(a, b net.IP, ipv4Prefix, ipv6Prefix int) bool {
+	if a == nil || b == nil || a.IsUnspecified() || b.IsUnspecified() {
+		return true
+	}
+
+	if a4, b4 := a.To4(), b.To4(); a4 != nil || b4 != nil {
+		if a4 == nil || b4 == nil {
+			return false
+		}
+		mask := net.CIDRMask(ipv4Prefix, 32)
+		return a4.Mask(mask).Equal(b4.Mask(mask))
+	}
+
+	mask := net.CIDRMask(ipv6Prefix, 128)
+	return a.To16().Mask(mask).Equal(b.To16().Mask(mask))
+}
+
+// UserRolesFunc returns whether a user is a Grafana server admin, and the roles of the user in their organizations
+type UserRolesFunc func(ctx context.Context, userID int64) (isGrafanaAdmin bool, orgRoles []string, err error)
+
+// IdleSessionExpired reports whether the session of the token was idle, not rotated, for longer than the strictest
+// idle timeout among the roles of the user. The roles are only fetched when the session is idle for longer than the
+// shortest timeout.
+func IdleSessionExpired(ctx context.Context, timeouts map[string]time.Duration, token *UserToken, now time.Time, roles UserRolesFunc) (bool, error) {
+	if len(timeouts) == 0 {
+		return false, nil
+	}
+
+	idle := now.Sub(time.Unix(token.RotatedAt, 0))
+	minTimeout := time.Duration(0)
+	for _, timeout := range timeouts {
+		if minTimeout == 0 || timeout < minTimeout {
+			minTimeout = timeout
+		}
+	}
+	if idle < minTimeout {
+		return false, nil
+	}
+
+	isGrafanaAdmin, orgRoles, err := roles(ctx, token.UserId)
+	if err != nil {
+		return false, err
+	}
+	var timeout time.Duration
+	apply := func(role string) {
+		if t, ok := timeouts[role]; ok && (timeout == 0 || t < timeout) {
+			timeout = t
+		}
+	}
+	if isGrafanaAdmin {
+		apply(setting.SessionPolicyRoleGrafanaAdmin)
+	}
+	for _, role := range orgRoles {
+		apply(role)
+	}
+
+	return timeout > 0 && idle >= timeout, nil
+}
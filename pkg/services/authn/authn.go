@@ -30,6 +30,8 @@ const (
 	ClientProxy        = "auth.client.proxy"
 	ClientSAML         = "auth.client.saml"
 	ClientPasswordless = "auth.client.passwordless"
+	ClientTOTP         = "auth.client.totp"
+	ClientPasskey      = "auth.client.passkey"
 	ClientLDAP         = "ldap"
 	ClientProvisioning = "auth.client.apiserver.provisioning"
 )
@@ -52,7 +54,7 @@ type ClientParams struct {
 	EnableUser bool
 	// FetchSyncedUser ensure that all required information is added to the identity
 	FetchSyncedUser bool
-	// SyncTeams will sync the groups from identity to teams in grafana, enterprise only feature
+	// SyncTeams will sync the groups from identity to teams in grafana using the team_mapping of the provider
 	SyncTeams bool
 	// SyncOrgRoles will sync the roles from the identity to orgs in grafana
 	SyncOrgRoles bool
@@ -3,6 +3,7 @@ package authnimpl
 import (
 	"context"
 
+	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/infra/remotecache"
 	"github.com/grafana/grafana/pkg/infra/tracing"
@@ -23,8 +24,10 @@ import (
 	"github.com/grafana/grafana/pkg/services/notifications"
 	"github.com/grafana/grafana/pkg/services/oauthtoken"
 	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/passkey"
 	"github.com/grafana/grafana/pkg/services/quota"
 	"github.com/grafana/grafana/pkg/services/rendering"
+	"github.com/grafana/grafana/pkg/services/team"
 	tempuser "github.com/grafana/grafana/pkg/services/temp_user"
 	"github.com/grafana/grafana/pkg/services/user"
 	"github.com/grafana/grafana/pkg/setting"
@@ -44,6 +47,8 @@ func ProvideRegistration(
 	socialService social.Service, cache *remotecache.RemoteCache,
 	ldapService service.LDAP, settingsProviderService setting.Provider,
 	tracer tracing.Tracer, tempUserService tempuser.Service, notificationService notifications.Service,
+	passkeyService passkey.Service, teamService team.Service, teamPermissionsService accesscontrol.TeamPermissionsService,
+	bus bus.Bus,
 ) Registration {
 	logger := log.New("authn.registration")
 
@@ -73,7 +78,8 @@ func ProvideRegistration(
 
 	// if we have password clients configure check if basic auth or form auth is enabled
 	if len(passwordClients) > 0 {
-		passwordClient := clients.ProvidePassword(loginAttempts, passwordClients...)
+		passwordClient := clients.ProvidePassword(cfg, loginAttempts, passwordClients...)
+		authnSvc.RegisterPostLoginHook(passwordClient.LoginSucceededHook, 180)
 		if cfg.BasicAuthEnabled {
 			authnSvc.RegisterClient(clients.ProvideBasic(passwordClient))
 		}
@@ -103,6 +109,10 @@ func ProvideRegistration(
 		}
 	}
 
+	if cfg.PasskeyAuth.Enabled {
+		authnSvc.RegisterClient(clients.ProvidePasskey(passkeyService))
+	}
+
 	if cfg.AuthProxy.Enabled && len(proxyClients) > 0 {
 		proxy, err := clients.ProvideProxy(cfg, cache, proxyClients...)
 		if err != nil {
@@ -137,6 +147,8 @@ func ProvideRegistration(
 	authnSvc.RegisterPostAuthHook(userSync.EnableUserHook, 20)
 	authnSvc.RegisterPostAuthHook(userSync.ValidateUserProvisioningHook, 30)
 	authnSvc.RegisterPostAuthHook(orgSync.SyncOrgRolesHook, 40)
+	teamSync := sync.ProvideTeamSync(socialService, orgService, teamService, teamPermissionsService, bus, cfg, tracer)
+	authnSvc.RegisterPostAuthHook(teamSync.SyncTeamsHook, 50)
 	authnSvc.RegisterPostAuthHook(userSync.SyncLastSeenHook, 130)
 	authnSvc.RegisterPostAuthHook(sync.ProvideOAuthTokenSync(oauthTokenService, sessionService, socialService, tracer, features).SyncOauthTokenHook, 60)
 	authnSvc.RegisterPostAuthHook(userSync.FetchSyncedUserHook, 100)
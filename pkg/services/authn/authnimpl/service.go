@@ -3,6 +3,7 @@ package authnimpl
 import (
 	"context"
 	"errors"
+	"net"
 	"net/http"
 	"strconv"
 	"strings"
@@ -17,7 +18,6 @@ import (
 	"github.com/grafana/grafana/pkg/apimachinery/errutil"
 	"github.com/grafana/grafana/pkg/apimachinery/identity"
 	"github.com/grafana/grafana/pkg/infra/log"
-	"github.com/grafana/grafana/pkg/infra/network"
 	"github.com/grafana/grafana/pkg/infra/tracing"
 	"github.com/grafana/grafana/pkg/infra/usagestats"
 	"github.com/grafana/grafana/pkg/services/auth"
@@ -249,11 +249,8 @@ func (s *Service) Login(ctx context.Context, client string, r *authn.Request) (i
 		return nil, err
 	}
 
-	addr := web.RemoteAddr(r.HTTPRequest)
-	ip, err := network.GetIPFromAddress(addr)
-	if err != nil {
-		s.log.FromContext(ctx).Debug("Failed to parse ip from address", "client", c.Name(), "id", id.ID, "addr", addr, "error", err)
-	}
+	// The client IP of the session is not taken from the headers of untrusted clients, the sessions can be bound to it
+	ip := net.ParseIP(web.ClientIP(r.HTTPRequest, s.cfg.TrustedProxies))
 
 	externalSession := s.resolveExternalSessionFromIdentity(ctx, id, userID)
 
@@ -0,0 +1,228 @@
+package sync
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	claims "github.com/grafana/authlib/types"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/events"
+	"github.com/grafana/grafana/pkg/infra/localcache"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/tracing"
+	"github.com/grafana/grafana/pkg/login/social"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/authn"
+	"github.com/grafana/grafana/pkg/services/login"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/team"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+const (
+	teamMemberPermission = "Member"
+	// teamSyncCacheTTL is how long a sync is skipped for the same user and groups. The JWT clients authenticate
+	// every request, the teams are not synced again until the groups change or the TTL expires.
+	teamSyncCacheTTL = 5 * time.Minute
+)
+
+func ProvideTeamSync(
+	socialService social.Service, orgService org.Service, teamService team.Service,
+	teamPermissions accesscontrol.TeamPermissionsService, bus bus.Bus, cfg *setting.Cfg, tracer tracing.Tracer,
+) *TeamSync {
+	return &TeamSync{
+		socialService:   socialService,
+		orgService:      orgService,
+		teamService:     teamService,
+		teamPermissions: teamPermissions,
+		bus:             bus,
+		cfg:             cfg,
+		cache:           localcache.New(teamSyncCacheTTL, 10*time.Minute),
+		log:             log.New("team.sync"),
+		tracer:          tracer,
+	}
+}
+
+// TeamSync keeps the team memberships of a user in line with the groups returned by the identity provider,
+// using the team_mapping setting of the provider the user authenticated with.
+type TeamSync struct {
+	socialService   social.Service
+	orgService      org.Service
+	teamService     team.Service
+	teamPermissions accesscontrol.TeamPermissionsService
+	bus             bus.Bus
+	cfg             *setting.Cfg
+	// cache keeps the users and groups synced recently
+	cache  *localcache.CacheService
+	log    log.Logger
+	tracer tracing.Tracer
+}
+
+func (s *TeamSync) SyncTeamsHook(ctx context.Context, id *authn.Identity, _ *authn.Request) error {
+	ctx, span := s.tracer.Start(ctx, "team.sync.SyncTeamsHook")
+	defer span.End()
+
+	if !id.ClientParams.SyncTeams {
+		return nil
+	}
+
+	mappings := s.teamMapping(id.AuthenticatedBy)
+	if len(mappings) == 0 {
+		return nil
+	}
+
+	ctxLogger := s.log.FromContext(ctx).New("id", id.ID, "login", id.Login)
+
+	if !id.IsIdentityType(claims.TypeUser) {
+		ctxLogger.Warn("Failed to sync teams, invalid namespace for identity", "type", id.GetIdentityType())
+		return nil
+	}
+
+	userID, err := id.GetInternalID()
+	if err != nil {
+		ctxLogger.Warn("Failed to sync teams, invalid ID for identity", "type", id.GetIdentityType(), "err", err)
+		return nil
+	}
+
+	cacheKey := teamSyncCacheKey(id.AuthenticatedBy, userID, id.Groups)
+	if _, ok := s.cache.Get(cacheKey); ok {
+		return nil
+	}
+
+	teamMapping, err := social.ParseTeamMapping(mappings)
+	if err != nil {
+		ctxLogger.Error("Failed to sync teams, invalid team mapping", "authModule", id.AuthenticatedBy, "error", err)
+		return nil
+	}
+
+	userOrgs, err := s.orgService.GetUserOrgList(ctx, &org.GetUserOrgListQuery{UserID: userID})
+	if err != nil {
+		ctxLogger.Error("Failed to get user's organizations", "error", err)
+		return nil
+	}
+
+	// teams of the mapping per org, and whether the user should be a member of them
+	desired := map[int64]map[string]bool{}
+	for _, m := range teamMapping {
+		orgID, ok := findOrg(userOrgs, m.Org)
+		if !ok {
+			// team sync never adds users to organizations, this is the job of the org sync
+			continue
+		}
+		if desired[orgID] == nil {
+			desired[orgID] = map[string]bool{}
+		}
+		desired[orgID][m.Team] = desired[orgID][m.Team] || slices.Contains(id.Groups, m.Group)
+	}
+
+	synced := true
+	for orgID, teams := range desired {
+		if err := s.syncOrgTeams(ctx, ctxLogger, id.AuthenticatedBy, userID, orgID, teams); err != nil {
+			ctxLogger.Error("Failed to sync teams", "orgId", orgID, "error", err)
+			synced = false
+		}
+	}
+	// a failed sync is tried again with the next request
+	if synced {
+		s.cache.SetDefault(cacheKey, true)
+	}
+
+	return nil
+}
+
+// teamSyncCacheKey identifies the groups of a user authenticated by an auth module
+func teamSyncCacheKey(authModule string, userID int64, groups []string) string {
+	sorted := slices.Clone(groups)
+	slices.Sort(sorted)
+	hash := sha256.Sum256([]byte(strings.Join(sorted, "\x00")))
+	return authModule + ":" + strconv.FormatInt(userID, 10) + ":" + hex.EncodeToString(hash[:])
+}
+
+func (s *TeamSync) syncOrgTeams(ctx context.Context, ctxLogger log.Logger, authModule string, userID, orgID int64, teams map[string]bool) error {
+	memberships, err := s.teamService.GetUserTeamMemberships(ctx, orgID, userID, false)
+	if err != nil {
+		return err
+	}
+
+	for name, shouldBeMember := range teams {
+		result, err := s.teamService.SearchTeams(ctx, &team.SearchTeamsQuery{
+			OrgID: orgID,
+			Name:  name,
+			SignedInUser: accesscontrol.BackgroundUser("team_sync", orgID, org.RoleAdmin, []accesscontrol.Permission{
+				{Action: accesscontrol.ActionTeamsRead, Scope: accesscontrol.ScopeTeamsAll},
+			}),
+		})
+		if err != nil {
+			return err
+		}
+		if len(result.Teams) == 0 {
+			ctxLogger.Warn("Team of the team mapping not found", "orgId", orgID, "team", name)
+			continue
+		}
+		teamID := result.Teams[0].ID
+
+		idx := slices.IndexFunc(memberships, func(m *team.TeamMemberDTO) bool { return m.TeamID == teamID })
+		switch {
+		case shouldBeMember && idx == -1:
+			if err := s.setMembership(ctx, userID, orgID, teamID, teamMemberPermission); err != nil {
+				return err
+			}
+			s.publish(ctx, ctxLogger, &events.TeamMembershipSynced{OrgID: orgID, TeamID: teamID, TeamName: name, UserID: userID, AuthModule: authModule})
+			ctxLogger.Info("Added user to team", "action", "team-sync-add", "userId", userID, "orgId", orgID, "teamId", teamID, "team", name, "authModule", authModule)
+		case !shouldBeMember && idx != -1 && memberships[idx].External:
+			// memberships managed manually in Grafana are never removed by the sync
+			if err := s.setMembership(ctx, userID, orgID, teamID, ""); err != nil {
+				return err
+			}
+			s.publish(ctx, ctxLogger, &events.TeamMembershipSynced{OrgID: orgID, TeamID: teamID, TeamName: name, UserID: userID, AuthModule: authModule, Removed: true})
+			ctxLogger.Info("Removed user from team", "action", "team-sync-remove", "userId", userID, "orgId", orgID, "teamId", teamID, "team", name, "authModule", authModule)
+		}
+	}
+
+	return nil
+}
+
+func (s *TeamSync) setMembership(ctx context.Context, userID, orgID, teamID int64, permission string) error {
+	_, err := s.teamPermissions.SetUserPermission(ctx, orgID, accesscontrol.User{ID: userID, IsExternal: true}, strconv.FormatInt(teamID, 10), permission)
+	return err
+}
+
+// publish sends the membership change to the audit log, the membership is already changed when it fails
+func (s *TeamSync) publish(ctx context.Context, ctxLogger log.Logger, e *events.TeamMembershipSynced) {
+	e.Timestamp = time.Now()
+	if err := s.bus.Publish(ctx, e); err != nil {
+		ctxLogger.Error("Failed to publish team membership change", "orgId", e.OrgID, "teamId", e.TeamID, "error", err)
+	}
+}
+
+func (s *TeamSync) teamMapping(authModule string) []string {
+	if authModule == login.JWTModule {
+		return s.cfg.JWTAuth.TeamMapping
+	}
+
+	if !strings.HasPrefix(authModule, "oauth_") {
+		return nil
+	}
+
+	info := s.socialService.GetOAuthInfoProvider(strings.TrimPrefix(authModule, "oauth_"))
+	if info == nil {
+		return nil
+	}
+	return info.TeamMapping
+}
+
+// findOrg resolves the org of a mapping, referenced by ID or by name, among the orgs of the user
+func findOrg(userOrgs []*org.UserOrgDTO, orgRef string) (int64, bool) {
+	orgID, err := strconv.ParseInt(orgRef, 10, 64)
+	for _, o := range userOrgs {
+		if (err == nil && o.OrgID == orgID) || o.Name == orgRef {
+			return o.OrgID, true
+		}
+	}
+	return 0, false
+}
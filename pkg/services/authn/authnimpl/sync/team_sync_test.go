@@ -0,0 +1,177 @@
+package sync
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	claims "github.com/grafana/authlib/types"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/events"
+	"github.com/grafana/grafana/pkg/infra/tracing"
+	"github.com/grafana/grafana/pkg/login/social"
+	"github.com/grafana/grafana/pkg/login/social/socialtest"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/accesscontrol/actest"
+	"github.com/grafana/grafana/pkg/services/authn"
+	"github.com/grafana/grafana/pkg/services/login"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/org/orgtest"
+	"github.com/grafana/grafana/pkg/services/team"
+	"github.com/grafana/grafana/pkg/services/team/teamtest"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+type fakeTeamSyncTeamService struct {
+	teamtest.FakeService
+	teams map[int64][]*team.TeamDTO
+}
+
+func (f *fakeTeamSyncTeamService) SearchTeams(ctx context.Context, query *team.SearchTeamsQuery) (team.SearchTeamQueryResult, error) {
+	result := team.SearchTeamQueryResult{Teams: []*team.TeamDTO{}}
+	for _, t := range f.teams[query.OrgID] {
+		if t.Name == query.Name {
+			result.Teams = append(result.Teams, t)
+		}
+	}
+	return result, nil
+}
+
+type membershipChange struct {
+	orgID      int64
+	teamID     string
+	external   bool
+	permission string
+}
+
+type recordingTeamPermissions struct {
+	actest.FakePermissionsService
+	changes []membershipChange
+}
+
+func (r *recordingTeamPermissions) SetUserPermission(ctx context.Context, orgID int64, user accesscontrol.User, resourceID, permission string) (*accesscontrol.ResourcePermission, error) {
+	r.changes = append(r.changes, membershipChange{orgID: orgID, teamID: resourceID, external: user.IsExternal, permission: permission})
+	return &accesscontrol.ResourcePermission{}, nil
+}
+
+func TestTeamSync_SyncTeamsHook(t *testing.T) {
+	orgService := &orgtest.FakeOrgService{ExpectedUserOrgDTO: []*org.UserOrgDTO{
+		{OrgID: 1, Name: "Main Org."},
+		{OrgID: 2, Name: "Engineering"},
+	}}
+
+	teamService := &fakeTeamSyncTeamService{teams: map[int64][]*team.TeamDTO{
+		1: {{ID: 10, OrgID: 1, Name: "Admins"}, {ID: 11, OrgID: 1, Name: "Viewers"}},
+		2: {{ID: 20, OrgID: 2, Name: "Platform"}, {ID: 21, OrgID: 2, Name: "Manual"}},
+	}}
+	teamService.ExpectedMembers = []*team.TeamMemberDTO{
+		{OrgID: 1, TeamID: 11, External: true},
+		{OrgID: 2, TeamID: 21, External: false},
+	}
+
+	mapping := []string{
+		"admins:1:Admins",
+		"viewers:Main Org.:Viewers",
+		"platform:Engineering:Platform",
+		"manual:2:Manual",
+		"other:Unknown Org:Team",
+		"admins:1:Missing",
+	}
+
+	newIdentity := func(authModule string, syncTeams bool, groups ...string) *authn.Identity {
+		return &authn.Identity{
+			ID:              "1",
+			Type:            claims.TypeUser,
+			AuthenticatedBy: authModule,
+			Groups:          groups,
+			ClientParams:    authn.ClientParams{SyncTeams: syncTeams},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		id       *authn.Identity
+		info     *social.OAuthInfo
+		jwt      []string
+		expected []membershipChange
+	}{
+		{
+			name:     "should add mapped teams and remove external memberships no longer mapped",
+			id:       newIdentity("oauth_generic_oauth", true, "admins", "platform"),
+			info:     &social.OAuthInfo{TeamMapping: mapping},
+			expected: []membershipChange{{1, "10", true, "Member"}, {1, "11", true, ""}, {2, "20", true, "Member"}},
+		},
+		{
+			name:     "should use the team mapping of the jwt settings",
+			id:       newIdentity(login.JWTModule, true, "viewers"),
+			jwt:      mapping,
+			expected: []membershipChange{},
+		},
+		{
+			name:     "should not sync teams when not requested by the client",
+			id:       newIdentity("oauth_generic_oauth", false, "admins"),
+			info:     &social.OAuthInfo{TeamMapping: mapping},
+			expected: []membershipChange{},
+		},
+		{
+			name:     "should not sync teams without team mapping",
+			id:       newIdentity("oauth_generic_oauth", true, "admins"),
+			info:     &social.OAuthInfo{},
+			expected: []membershipChange{},
+		},
+		{
+			name:     "should not sync teams with an invalid team mapping",
+			id:       newIdentity("oauth_generic_oauth", true, "admins"),
+			info:     &social.OAuthInfo{TeamMapping: []string{"admins:Admins"}},
+			expected: []membershipChange{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := setting.NewCfg()
+			cfg.JWTAuth.TeamMapping = tt.jwt
+			permissions := &recordingTeamPermissions{changes: []membershipChange{}}
+
+			s := ProvideTeamSync(&socialtest.FakeSocialService{ExpectedAuthInfoProvider: tt.info}, orgService, teamService, permissions, bus.ProvideBus(tracing.InitializeTracerForTest()), cfg, tracing.InitializeTracerForTest())
+			require.NoError(t, s.SyncTeamsHook(context.Background(), tt.id, nil))
+			assert.ElementsMatch(t, tt.expected, permissions.changes)
+		})
+	}
+
+	t.Run("should publish the membership changes", func(t *testing.T) {
+		eventBus := bus.ProvideBus(tracing.InitializeTracerForTest())
+		var published []events.TeamMembershipSynced
+		eventBus.AddEventListener(func(ctx context.Context, e *events.TeamMembershipSynced) error {
+			e.Timestamp = time.Time{}
+			published = append(published, *e)
+			return nil
+		})
+		permissions := &recordingTeamPermissions{changes: []membershipChange{}}
+		s := ProvideTeamSync(&socialtest.FakeSocialService{ExpectedAuthInfoProvider: &social.OAuthInfo{TeamMapping: mapping}}, orgService, teamService, permissions, eventBus, setting.NewCfg(), tracing.InitializeTracerForTest())
+
+		require.NoError(t, s.SyncTeamsHook(context.Background(), newIdentity("oauth_generic_oauth", true, "admins"), nil))
+		assert.ElementsMatch(t, []events.TeamMembershipSynced{
+			{OrgID: 1, TeamID: 10, TeamName: "Admins", UserID: 1, AuthModule: "oauth_generic_oauth"},
+			{OrgID: 1, TeamID: 11, TeamName: "Viewers", UserID: 1, AuthModule: "oauth_generic_oauth", Removed: true},
+		}, published)
+	})
+
+	t.Run("should only sync the teams again when the groups change", func(t *testing.T) {
+		permissions := &recordingTeamPermissions{changes: []membershipChange{}}
+		s := ProvideTeamSync(&socialtest.FakeSocialService{ExpectedAuthInfoProvider: &social.OAuthInfo{TeamMapping: mapping}}, orgService, teamService, permissions, bus.ProvideBus(tracing.InitializeTracerForTest()), setting.NewCfg(), tracing.InitializeTracerForTest())
+
+		require.NoError(t, s.SyncTeamsHook(context.Background(), newIdentity("oauth_generic_oauth", true, "platform", "admins"), nil))
+		require.Len(t, permissions.changes, 3)
+
+		require.NoError(t, s.SyncTeamsHook(context.Background(), newIdentity("oauth_generic_oauth", true, "admins", "platform"), nil))
+		require.Len(t, permissions.changes, 3, "the same groups are not synced again")
+
+		require.NoError(t, s.SyncTeamsHook(context.Background(), newIdentity("oauth_generic_oauth", true, "admins"), nil))
+		require.Len(t, permissions.changes, 5)
+	})
+}
@@ -0,0 +1,61 @@
+package clients
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"strconv"
+
+	claims "github.com/grafana/authlib/types"
+	"github.com/grafana/grafana/pkg/apimachinery/errutil"
+	"github.com/grafana/grafana/pkg/services/authn"
+	"github.com/grafana/grafana/pkg/services/login"
+	"github.com/grafana/grafana/pkg/services/passkey"
+)
+
+var errPasskeyInvalidForm = errutil.BadRequest("passkey.invalid-form", errutil.WithPublicMessage("bad login data"))
+
+// maxPasskeyCredentialSize caps the size of the assertions, which are a few kilobytes
+const maxPasskeyCredentialSize = 64 << 10
+
+var _ authn.Client = new(Passkey)
+
+func ProvidePasskey(service passkey.Service) *Passkey {
+	return &Passkey{service}
+}
+
+// Passkey signs in the users with the assertion of a passkey (WebAuthn), for a challenge returned by /api/login/passkey/begin
+type Passkey struct {
+	service passkey.Service
+}
+
+func (c *Passkey) Name() string {
+	return authn.ClientPasskey
+}
+
+func (c *Passkey) Authenticate(ctx context.Context, r *authn.Request) (*authn.Identity, error) {
+	credential, err := io.ReadAll(io.LimitReader(r.HTTPRequest.Body, maxPasskeyCredentialSize))
+	if err != nil {
+		return nil, errPasskeyInvalidForm.Errorf("failed to read request: %w", err)
+	}
+	if !json.Valid(credential) {
+		return nil, errPasskeyInvalidForm.Errorf("failed to parse request: invalid json")
+	}
+
+	userID, err := c.service.FinishLogin(ctx, credential)
+	if err != nil {
+		return nil, err
+	}
+
+	return &authn.Identity{
+		ID:              strconv.FormatInt(userID, 10),
+		Type:            claims.TypeUser,
+		OrgID:           r.OrgID,
+		ClientParams:    authn.ClientParams{FetchSyncedUser: true, SyncPermissions: true},
+		AuthenticatedBy: login.PasskeyAuthModule,
+	}, nil
+}
+
+func (c *Passkey) IsEnabled() bool {
+	return true
+}
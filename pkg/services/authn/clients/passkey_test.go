@@ -0,0 +1,66 @@
+package clients
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	claims "github.com/grafana/authlib/types"
+	"github.com/grafana/grafana/pkg/services/authn"
+	"github.com/grafana/grafana/pkg/services/login"
+	"github.com/grafana/grafana/pkg/services/passkey"
+	"github.com/grafana/grafana/pkg/services/passkey/passkeytest"
+)
+
+func TestPasskey_Authenticate(t *testing.T) {
+	type testCase struct {
+		desc             string
+		body             string
+		service          *passkeytest.FakeService
+		expectedErr      error
+		expectedIdentity *authn.Identity
+	}
+
+	tests := []testCase{
+		{
+			desc:    "should return the user of the passkey",
+			body:    `{"id": "abc", "rawId": "abc", "type": "public-key", "response": {"clientDataJSON": "e30"}}`,
+			service: &passkeytest.FakeService{ExpectedUserID: 2},
+			expectedIdentity: &authn.Identity{
+				ID:              "2",
+				Type:            claims.TypeUser,
+				OrgID:           1,
+				ClientParams:    authn.ClientParams{FetchSyncedUser: true, SyncPermissions: true},
+				AuthenticatedBy: login.PasskeyAuthModule,
+			},
+		},
+		{
+			desc:        "should return error for bad request",
+			body:        `not json`,
+			service:     &passkeytest.FakeService{},
+			expectedErr: errPasskeyInvalidForm,
+		},
+		{
+			desc:        "should return error for invalid passkey",
+			body:        `{"id": "abc", "rawId": "abc", "type": "public-key"}`,
+			service:     &passkeytest.FakeService{ExpectedErr: passkey.ErrInvalidCredential.Errorf("invalid")},
+			expectedErr: passkey.ErrInvalidCredential,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			c := ProvidePasskey(tt.service)
+			identity, err := c.Authenticate(context.Background(), &authn.Request{OrgID: 1, HTTPRequest: &http.Request{
+				Header: map[string][]string{"Content-Type": {"application/json"}},
+				Body:   io.NopCloser(strings.NewReader(tt.body)),
+			}})
+			assert.ErrorIs(t, err, tt.expectedErr)
+			assert.Equal(t, tt.expectedIdentity, identity)
+		})
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/services/authn"
 	"github.com/grafana/grafana/pkg/services/loginattempt"
+	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/web"
 )
 
@@ -18,11 +19,12 @@ var (
 
 var _ authn.PasswordClient = new(Password)
 
-func ProvidePassword(loginAttempts loginattempt.Service, clients ...authn.PasswordClient) *Password {
-	return &Password{loginAttempts, clients, log.New("authn.password")}
+func ProvidePassword(cfg *setting.Cfg, loginAttempts loginattempt.Service, clients ...authn.PasswordClient) *Password {
+	return &Password{cfg, loginAttempts, clients, log.New("authn.password")}
 }
 
 type Password struct {
+	cfg           *setting.Cfg
 	loginAttempts loginattempt.Service
 	clients       []authn.PasswordClient
 	log           log.Logger
@@ -31,7 +33,8 @@ type Password struct {
 func (c *Password) AuthenticatePassword(ctx context.Context, r *authn.Request, username, password string) (*authn.Identity, error) {
 	r.SetMeta(authn.MetaKeyUsername, username)
 
-	ok, err := c.loginAttempts.Validate(ctx, username)
+	addr := web.ClientIP(r.HTTPRequest, c.cfg.TrustedProxies)
+	ok, err := c.loginAttempts.Validate(ctx, username, addr)
 	if err != nil {
 		return nil, err
 	}
@@ -39,7 +42,7 @@ func (c *Password) AuthenticatePassword(ctx context.Context, r *authn.Request, u
 		return nil, errPasswordAuthFailed.Errorf("too many consecutive incorrect login attempts for user - login for user temporarily blocked")
 	}
 
-	ok, err = c.loginAttempts.ValidateIPAddress(ctx, web.RemoteAddr(r.HTTPRequest))
+	ok, err = c.loginAttempts.ValidateIPAddress(ctx, addr)
 	if err != nil {
 		return nil, err
 	}
@@ -64,10 +67,24 @@ func (c *Password) AuthenticatePassword(ctx context.Context, r *authn.Request, u
 		return identity, nil
 	}
 
-	err = c.loginAttempts.Add(ctx, username, web.RemoteAddr(r.HTTPRequest))
+	err = c.loginAttempts.Add(ctx, username, addr)
 	if err != nil {
 		return nil, err
 	}
 
 	return nil, errPasswordAuthFailed.Errorf("failed to authenticate identity: %w", clientErrs)
 }
+
+// LoginSucceededHook forgets the failed attempts of the username from the client IP address after a successful
+// login with a password. A login stopped by a second factor challenge is not successful, the failures are kept.
+func (c *Password) LoginSucceededHook(ctx context.Context, id *authn.Identity, r *authn.Request, err error) {
+	username := r.GetMeta(authn.MetaKeyUsername)
+	if err != nil || id == nil || username == "" {
+		return
+	}
+
+	addr := web.ClientIP(r.HTTPRequest, c.cfg.TrustedProxies)
+	if err := c.loginAttempts.AddSuccess(ctx, username, addr); err != nil {
+		c.log.FromContext(ctx).Warn("Failed to reset the failed login attempts", "error", err)
+	}
+}
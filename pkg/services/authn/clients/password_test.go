@@ -13,6 +13,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/authn"
 	"github.com/grafana/grafana/pkg/services/authn/authntest"
 	"github.com/grafana/grafana/pkg/services/loginattempt/loginattempttest"
+	"github.com/grafana/grafana/pkg/setting"
 )
 
 func TestPassword_AuthenticatePassword(t *testing.T) {
@@ -65,7 +66,7 @@ func TestPassword_AuthenticatePassword(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.desc, func(t *testing.T) {
-			c := ProvidePassword(loginattempttest.FakeLoginAttemptService{ExpectedValid: !tt.blockLogin}, tt.clients...)
+			c := ProvidePassword(setting.NewCfg(), loginattempttest.FakeLoginAttemptService{ExpectedValid: !tt.blockLogin}, tt.clients...)
 			r := &authn.Request{
 				OrgID: 12345,
 				HTTPRequest: &http.Request{
@@ -92,3 +93,34 @@ func TestPassword_AuthenticatePassword(t *testing.T) {
 		})
 	}
 }
+
+func TestPassword_LoginSucceededHook(t *testing.T) {
+	newRequest := func(username string) *authn.Request {
+		r := &authn.Request{HTTPRequest: &http.Request{Header: http.Header{}, RemoteAddr: "10.0.0.1:1234"}}
+		if username != "" {
+			r.SetMeta(authn.MetaKeyUsername, username)
+		}
+		return r
+	}
+
+	t.Run("should forget the failed attempts after a successful login with a password", func(t *testing.T) {
+		loginAttempts := &loginattempttest.MockLoginAttemptService{}
+		c := ProvidePassword(setting.NewCfg(), loginAttempts)
+		c.LoginSucceededHook(context.Background(), &authn.Identity{ID: "1", Type: claims.TypeUser}, newRequest("test"), nil)
+		assert.True(t, loginAttempts.AddSuccessCalled)
+	})
+
+	t.Run("should keep the failed attempts after a failed login", func(t *testing.T) {
+		loginAttempts := &loginattempttest.MockLoginAttemptService{}
+		c := ProvidePassword(setting.NewCfg(), loginAttempts)
+		c.LoginSucceededHook(context.Background(), nil, newRequest("test"), errPasswordAuthFailed)
+		assert.False(t, loginAttempts.AddSuccessCalled)
+	})
+
+	t.Run("should keep the failed attempts after a login without a password", func(t *testing.T) {
+		loginAttempts := &loginattempttest.MockLoginAttemptService{}
+		c := ProvidePassword(setting.NewCfg(), loginAttempts)
+		c.LoginSucceededHook(context.Background(), &authn.Identity{ID: "1", Type: claims.TypeUser}, newRequest(""), nil)
+		assert.False(t, loginAttempts.AddSuccessCalled)
+	})
+}
@@ -105,7 +105,7 @@ func (c *Passwordless) RedirectURL(ctx context.Context, r *authn.Request) (*auth
 		return nil, err
 	}
 
-	ok, err := c.loginAttempts.Validate(ctx, form.Email)
+	ok, err := c.loginAttempts.Validate(ctx, form.Email, web.ClientIP(r.HTTPRequest, c.cfg.TrustedProxies))
 	if err != nil {
 		return nil, err
 	}
@@ -114,7 +114,7 @@ func (c *Passwordless) RedirectURL(ctx context.Context, r *authn.Request) (*auth
 		return nil, errPasswordlessClientTooManyLoginAttempts.Errorf("too many consecutive incorrect login attempts for user - login for user temporarily blocked")
 	}
 
-	ok, err = c.loginAttempts.ValidateIPAddress(ctx, web.RemoteAddr(r.HTTPRequest))
+	ok, err = c.loginAttempts.ValidateIPAddress(ctx, web.ClientIP(r.HTTPRequest, c.cfg.TrustedProxies))
 	if err != nil {
 		return nil, err
 	}
@@ -123,7 +123,7 @@ func (c *Passwordless) RedirectURL(ctx context.Context, r *authn.Request) (*auth
 		return nil, errPasswordlessClientTooManyLoginAttempts.Errorf("too many consecutive incorrect login attempts for IP address - login for IP address temporarily blocked")
 	}
 
-	err = c.loginAttempts.Add(ctx, form.Email, web.RemoteAddr(r.HTTPRequest))
+	err = c.loginAttempts.Add(ctx, form.Email, web.ClientIP(r.HTTPRequest, c.cfg.TrustedProxies))
 	if err != nil {
 		return nil, err
 	}
@@ -272,7 +272,7 @@ func (c *Passwordless) authenticatePasswordless(ctx context.Context, r *authn.Re
 		return nil, errPasswordlessClientInvalidConfirmationCode
 	}
 
-	ok, err := c.loginAttempts.Validate(ctx, codeEntry.Email)
+	ok, err := c.loginAttempts.Validate(ctx, codeEntry.Email, web.ClientIP(r.HTTPRequest, c.cfg.TrustedProxies))
 	if err != nil {
 		return nil, err
 	}
@@ -2,6 +2,7 @@ package clients
 
 import (
 	"context"
+	"net/http"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -152,7 +153,7 @@ func TestPasswordless_AuthenticatePasswordless(t *testing.T) {
 				Name:             "user",
 				Username:         "username",
 			}
-			identity, err := c.authenticatePasswordless(context.Background(), &authn.Request{OrgID: 1}, *form)
+			identity, err := c.authenticatePasswordless(context.Background(), &authn.Request{OrgID: 1, HTTPRequest: &http.Request{Header: http.Header{}}}, *form)
 			assert.ErrorIs(t, err, tt.expectedErr)
 			assert.EqualValues(t, tt.expectedIdentity, identity)
 		})
@@ -3,6 +3,7 @@ package clients
 import (
 	"context"
 	"errors"
+	"net"
 	"net/url"
 	"strconv"
 	"time"
@@ -14,6 +15,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/login"
 	"github.com/grafana/grafana/pkg/services/user"
 	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/web"
 )
 
 var _ authn.ContextAwareClient = new(Session)
@@ -54,6 +56,11 @@ func (s *Session) Authenticate(ctx context.Context, r *authn.Request) (*authn.Id
 		return nil, err
 	}
 
+	// the idle sessions are revoked by the lookup
+	if err := s.checkClientNetwork(ctx, r, token); err != nil {
+		return nil, err
+	}
+
 	if token.NeedsRotation(time.Duration(s.cfg.TokenRotationIntervalMinutes) * time.Minute) {
 		return nil, authn.ErrTokenNeedsRotation.Errorf("token needs to be rotated")
 	}
@@ -81,6 +88,21 @@ func (s *Session) Authenticate(ctx context.Context, r *authn.Request) (*authn.Id
 	return ident, nil
 }
 
+// checkClientNetwork rejects the sessions used from another client network than the one of the token
+func (s *Session) checkClientNetwork(ctx context.Context, r *authn.Request, token *auth.UserToken) error {
+	policy := s.cfg.SessionPolicy
+	if !policy.BindClientIP {
+		return nil
+	}
+
+	clientIP := net.ParseIP(web.ClientIP(r.HTTPRequest, s.cfg.TrustedProxies))
+	if !auth.SameClientNetwork(net.ParseIP(token.ClientIp), clientIP, policy.BindIPv4Prefix, policy.BindIPv6Prefix) {
+		s.log.FromContext(ctx).Warn("Session used from another client network", "userID", token.UserId, "tokenID", token.Id)
+		return &auth.SessionPolicyError{UserID: token.UserId, TokenID: token.Id, Reason: auth.SessionPolicyClientIP}
+	}
+	return nil
+}
+
 func (s *Session) IsEnabled() bool {
 	return true
 }
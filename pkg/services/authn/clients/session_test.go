@@ -2,6 +2,7 @@ package clients
 
 import (
 	"context"
+	"net"
 	"net/http"
 	"testing"
 	"time"
@@ -206,3 +207,98 @@ func TestSession_Authenticate(t *testing.T) {
 		})
 	}
 }
+
+func TestSession_BindClientIP(t *testing.T) {
+	cookieName := "grafana_session"
+
+	newRequest := func(remoteAddr, forwardedFor string) *authn.Request {
+		req := &http.Request{Header: map[string][]string{}, RemoteAddr: remoteAddr}
+		if forwardedFor != "" {
+			req.Header.Set("X-Forwarded-For", forwardedFor)
+		}
+		req.AddCookie(&http.Cookie{Name: cookieName, Value: "bob-the-high-entropy-token"})
+		return &authn.Request{HTTPRequest: req}
+	}
+
+	type testCase struct {
+		desc         string
+		policy       setting.AuthSessionPolicySettings
+		remoteAddr   string
+		forwardedFor string
+		trustProxy   bool
+		idle         time.Duration
+		expectedErr  string
+	}
+
+	tests := []testCase{
+		{
+			desc:       "should accept the session without policies",
+			remoteAddr: "10.0.0.1:1234",
+			idle:       12 * time.Hour,
+		},
+		{
+			desc:       "should accept the session used from the same network",
+			policy:     setting.AuthSessionPolicySettings{BindClientIP: true, BindIPv4Prefix: 24, BindIPv6Prefix: 64},
+			remoteAddr: "192.168.10.200:1234",
+		},
+		{
+			desc:        "should reject the session used from another network",
+			policy:      setting.AuthSessionPolicySettings{BindClientIP: true, BindIPv4Prefix: 24, BindIPv6Prefix: 64},
+			remoteAddr:  "10.0.0.1:1234",
+			expectedErr: auth.SessionPolicyClientIP,
+		},
+		{
+			desc:         "should reject the session with a forwarded address from an untrusted client",
+			policy:       setting.AuthSessionPolicySettings{BindClientIP: true, BindIPv4Prefix: 24, BindIPv6Prefix: 64},
+			remoteAddr:   "10.0.0.1:1234",
+			forwardedFor: "192.168.10.200",
+			expectedErr:  auth.SessionPolicyClientIP,
+		},
+		{
+			desc:         "should accept the session with a forwarded address from a trusted proxy",
+			policy:       setting.AuthSessionPolicySettings{BindClientIP: true, BindIPv4Prefix: 24, BindIPv6Prefix: 64},
+			remoteAddr:   "10.0.0.1:1234",
+			forwardedFor: "192.168.10.200",
+			trustProxy:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			token := &usertoken.UserToken{
+				Id:            1,
+				UserId:        1,
+				ClientIp:      "192.168.10.11",
+				AuthTokenSeen: true,
+				RotatedAt:     time.Now().Add(-tt.idle).Unix(),
+				CreatedAt:     time.Now().Add(-tt.idle).Unix(),
+			}
+			sessionService := &authtest.FakeUserAuthTokenService{
+				LookupTokenProvider: func(ctx context.Context, unhashedToken string) (*auth.UserToken, error) {
+					return token, nil
+				},
+			}
+
+			cfg := setting.NewCfg()
+			cfg.LoginCookieName = cookieName
+			cfg.TokenRotationIntervalMinutes = 24 * 60
+			cfg.SessionPolicy = tt.policy
+			if tt.trustProxy {
+				_, proxies, err := net.ParseCIDR("10.0.0.0/8")
+				require.NoError(t, err)
+				cfg.TrustedProxies = []*net.IPNet{proxies}
+			}
+			s := ProvideSession(cfg, sessionService, &authinfotest.FakeService{ExpectedError: user.ErrUserNotFound})
+
+			_, err := s.Authenticate(context.Background(), newRequest(tt.remoteAddr, tt.forwardedFor))
+			if tt.expectedErr == "" {
+				require.NoError(t, err)
+				return
+			}
+
+			var policyErr *auth.SessionPolicyError
+			require.ErrorAs(t, err, &policyErr)
+			assert.Equal(t, tt.expectedErr, policyErr.Reason)
+		})
+	}
+}
@@ -17,6 +17,7 @@ import (
 	"github.com/grafana/grafana/pkg/infra/serverlock"
 	"github.com/grafana/grafana/pkg/infra/tracing"
 	"github.com/grafana/grafana/pkg/services/annotations"
+	"github.com/grafana/grafana/pkg/services/auditlog"
 	"github.com/grafana/grafana/pkg/services/dashboards"
 	"github.com/grafana/grafana/pkg/services/dashboardsnapshots"
 	dashver "github.com/grafana/grafana/pkg/services/dashboardversion"
@@ -46,12 +47,14 @@ type CleanUpService struct {
 	annotationCleaner         annotations.Cleaner
 	dashboardService          dashboards.DashboardService
 	alertRuleService          AlertRuleService
+	auditLogService           auditlog.Service
 }
 
 func ProvideService(cfg *setting.Cfg, serverLockService *serverlock.ServerLockService,
 	shortURLService shorturls.Service, sqlstore db.DB, queryHistoryService queryhistory.Service,
 	dashboardVersionService dashver.Service, dashSnapSvc dashboardsnapshots.Service, deleteExpiredImageService *image.DeleteExpiredService,
-	tempUserService tempuser.Service, tracer tracing.Tracer, annotationCleaner annotations.Cleaner, dashboardService dashboards.DashboardService, service AlertRuleService) *CleanUpService {
+	tempUserService tempuser.Service, tracer tracing.Tracer, annotationCleaner annotations.Cleaner, dashboardService dashboards.DashboardService, service AlertRuleService,
+	auditLogService auditlog.Service) *CleanUpService {
 	s := &CleanUpService{
 		Cfg:                       cfg,
 		ServerLockService:         serverLockService,
@@ -67,6 +70,7 @@ func ProvideService(cfg *setting.Cfg, serverLockService *serverlock.ServerLockSe
 		annotationCleaner:         annotationCleaner,
 		dashboardService:          dashboardService,
 		alertRuleService:          service,
+		auditLogService:           auditLogService,
 	}
 	return s
 }
@@ -121,6 +125,10 @@ func (srv *CleanUpService) clean(ctx context.Context) {
 		cleanupJobs = append(cleanupJobs, cleanUpJob{"cleanup trash alert rules", srv.cleanUpTrashAlertRules})
 	}
 
+	if srv.Cfg.AuditLog.Enabled {
+		cleanupJobs = append(cleanupJobs, cleanUpJob{"delete expired audit log events", srv.deleteExpiredAuditLogEvents})
+	}
+
 	logger := srv.log.FromContext(ctx)
 	logger.Debug("Starting cleanup jobs", "jobs", fmt.Sprintf("%v", cleanupJobs))
 
@@ -282,6 +290,16 @@ func (srv *CleanUpService) deleteStaleShortURLs(ctx context.Context) {
 	}
 }
 
+func (srv *CleanUpService) deleteExpiredAuditLogEvents(ctx context.Context) {
+	logger := srv.log.FromContext(ctx)
+	affected, err := srv.auditLogService.DeleteExpired(ctx)
+	if err != nil {
+		logger.Error("Problem deleting expired audit log events", "error", err.Error())
+	} else {
+		logger.Debug("Deleted expired audit log events", "rows affected", affected)
+	}
+}
+
 func (srv *CleanUpService) deleteStaleQueryHistory(ctx context.Context) {
 	logger := srv.log.FromContext(ctx)
 	// Delete query history from 14+ days ago with exception of starred queries
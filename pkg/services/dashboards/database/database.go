@@ -12,6 +12,7 @@ import (
 
 	claims "github.com/grafana/authlib/types"
 
+	"github.com/grafana/grafana/pkg/events"
 	"github.com/grafana/grafana/pkg/infra/db"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/infra/metrics"
@@ -289,6 +290,15 @@ func (d *dashboardStore) SaveDashboard(ctx context.Context, cmd dashboards.SaveD
 		if err != nil {
 			return err
 		}
+		sess.PublishAfterCommit(&events.DashboardSaved{
+			Timestamp: time.Now(),
+			Title:     result.Title,
+			ID:        result.ID,
+			UID:       result.UID,
+			OrgID:     result.OrgID,
+			Version:   result.Version,
+			IsFolder:  result.IsFolder,
+		})
 		return nil
 	})
 	if err != nil {
@@ -605,6 +615,15 @@ func (d *dashboardStore) deleteDashboard(cmd *dashboards.DeleteDashboardCommand,
 			return err
 		}
 	}
+
+	sess.PublishAfterCommit(&events.DashboardDeleted{
+		Timestamp: time.Now(),
+		Title:     dashboard.Title,
+		ID:        dashboard.ID,
+		UID:       dashboard.UID,
+		OrgID:     dashboard.OrgID,
+		IsFolder:  dashboard.IsFolder,
+	})
 	return nil
 }
 
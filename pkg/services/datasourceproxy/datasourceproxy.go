@@ -17,6 +17,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/featuremgmt"
 	"github.com/grafana/grafana/pkg/services/oauthtoken"
 	"github.com/grafana/grafana/pkg/services/pluginsintegration/pluginstore"
+	"github.com/grafana/grafana/pkg/services/queryrestriction"
 	"github.com/grafana/grafana/pkg/services/secrets"
 	"github.com/grafana/grafana/pkg/services/validations"
 	"github.com/grafana/grafana/pkg/setting"
@@ -27,7 +28,8 @@ import (
 func ProvideService(dataSourceCache datasources.CacheService, datasourceReqValidator validations.DataSourceRequestValidator,
 	pluginStore pluginstore.Store, cfg *setting.Cfg, httpClientProvider httpclient.Provider,
 	oauthTokenService *oauthtoken.Service, dsService datasources.DataSourceService,
-	tracer tracing.Tracer, secretsService secrets.Service, features featuremgmt.FeatureToggles) *DataSourceProxyService {
+	tracer tracing.Tracer, secretsService secrets.Service, features featuremgmt.FeatureToggles,
+	queryRestrictionService queryrestriction.Service) *DataSourceProxyService {
 	return &DataSourceProxyService{
 		DataSourceCache:            dataSourceCache,
 		DataSourceRequestValidator: datasourceReqValidator,
@@ -39,6 +41,7 @@ func ProvideService(dataSourceCache datasources.CacheService, datasourceReqValid
 		tracer:                     tracer,
 		secretsService:             secretsService,
 		features:                   features,
+		queryRestrictionService:    queryRestrictionService,
 	}
 }
 
@@ -53,6 +56,7 @@ type DataSourceProxyService struct {
 	tracer                     tracing.Tracer
 	secretsService             secrets.Service
 	features                   featuremgmt.FeatureToggles
+	queryRestrictionService    queryrestriction.Service
 }
 
 func (p *DataSourceProxyService) ProxyDataSourceRequest(c *contextmodel.ReqContext) {
@@ -114,6 +118,17 @@ func (p *DataSourceProxyService) proxyDatasourceRequest(c *contextmodel.ReqConte
 		return
 	}
 
+	// the proxied requests can't be restricted, they are denied to the users with query restrictions
+	restrictions, err := p.queryRestrictionService.GetUserRestrictions(c.Req.Context(), c.SignedInUser, ds.UID)
+	if err != nil {
+		c.JsonApiErr(http.StatusInternalServerError, "Failed to get the query restrictions", err)
+		return
+	}
+	if len(restrictions) > 0 {
+		c.JsonApiErr(http.StatusForbidden, "Access denied, the queries of your teams are restricted on this data source", nil)
+		return
+	}
+
 	// find plugin
 	plugin, exists := p.pluginStore.Plugin(c.Req.Context(), ds.Type)
 	if !exists {
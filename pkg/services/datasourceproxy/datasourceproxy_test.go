@@ -14,6 +14,8 @@ import (
 	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
 	"github.com/grafana/grafana/pkg/services/datasources"
 	"github.com/grafana/grafana/pkg/services/pluginsintegration/pluginstore"
+	"github.com/grafana/grafana/pkg/services/queryrestriction"
+	"github.com/grafana/grafana/pkg/services/queryrestriction/queryrestrictiontest"
 	"github.com/grafana/grafana/pkg/web"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -72,6 +74,28 @@ func TestDataProxy(t *testing.T) {
 
 // Tests request to datasource proxy service
 func TestDatasourceProxy_proxyDatasourceRequest(t *testing.T) {
+	t.Run("Users with query restrictions on the datasource will get a 403 HTTP status code", func(t *testing.T) {
+		p := DataSourceProxyService{
+			DataSourceRequestValidator: &fakeDataSourceRequestValidator{},
+			queryRestrictionService: &queryrestrictiontest.FakeService{ExpectedRestrictions: []queryrestriction.Restriction{
+				{TeamID: 1, Kind: queryrestriction.KindMetricPrefix, Pattern: "team_a_"},
+			}},
+		}
+
+		responseRecorder := httptest.NewRecorder()
+		c := &contextmodel.ReqContext{
+			Context: &web.Context{
+				Req:  &http.Request{URL: &url.URL{}},
+				Resp: web.NewResponseWriter("GET", responseRecorder),
+			},
+			Logger: log.NewNopLogger(),
+		}
+
+		p.proxyDatasourceRequest(c, &datasources.DataSource{UID: "prom", Type: datasources.DS_PROMETHEUS})
+
+		require.Equal(t, http.StatusForbidden, responseRecorder.Code)
+	})
+
 	tcs := []struct {
 		name  string
 		dsURL string
@@ -96,6 +120,7 @@ func TestDatasourceProxy_proxyDatasourceRequest(t *testing.T) {
 			p := DataSourceProxyService{
 				DataSourceRequestValidator: &fakeDataSourceRequestValidator{},
 				pluginStore:                pluginStore,
+				queryRestrictionService:    &queryrestrictiontest.FakeService{},
 			}
 
 			responseRecorder := httptest.NewRecorder()
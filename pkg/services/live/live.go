@@ -16,6 +16,7 @@ import (
 	"github.com/centrifugal/centrifuge"
 	"github.com/go-redis/redis/v8"
 	"github.com/gobwas/glob"
+	claims "github.com/grafana/authlib/types"
 	jsoniter "github.com/json-iterator/go"
 	"golang.org/x/sync/errgroup"
 
@@ -36,6 +37,7 @@ import (
 	"github.com/grafana/grafana/pkg/plugins"
 	"github.com/grafana/grafana/pkg/services/accesscontrol"
 	"github.com/grafana/grafana/pkg/services/annotations"
+	"github.com/grafana/grafana/pkg/services/anonymous"
 	"github.com/grafana/grafana/pkg/services/apiserver"
 	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
 	"github.com/grafana/grafana/pkg/services/dashboards"
@@ -80,7 +82,7 @@ func ProvideService(plugCtxProvider *plugincontext.Provider, cfg *setting.Cfg, r
 	dataSourceCache datasources.CacheService, sqlStore db.DB, secretsService secrets.Service,
 	usageStatsService usagestats.Service, queryDataService query.Service, toggles featuremgmt.FeatureToggles,
 	accessControl accesscontrol.AccessControl, dashboardService dashboards.DashboardService, annotationsRepo annotations.Repository,
-	orgService org.Service, configProvider apiserver.RestConfigProvider) (*GrafanaLive, error) {
+	orgService org.Service, configProvider apiserver.RestConfigProvider, anonService anonymous.Service) (*GrafanaLive, error) {
 	g := &GrafanaLive{
 		Cfg:                   cfg,
 		Features:              toggles,
@@ -99,6 +101,7 @@ func ProvideService(plugCtxProvider *plugincontext.Provider, cfg *setting.Cfg, r
 		},
 		usageStatsService: usageStatsService,
 		orgService:        orgService,
+		anonService:       anonService,
 		keyPrefix:         "gf_live",
 	}
 
@@ -309,6 +312,7 @@ func ProvideService(plugCtxProvider *plugincontext.Provider, cfg *setting.Cfg, r
 		}
 		newCtx := centrifuge.SetCredentials(ctx.Req.Context(), cred)
 		newCtx = livecontext.SetContextSignedUser(newCtx, user)
+		newCtx = livecontext.SetContextRequest(newCtx, ctx.Req)
 		r := ctx.Req.WithContext(newCtx)
 		wsHandler.ServeHTTP(ctx.Resp, r)
 	}
@@ -401,6 +405,7 @@ type GrafanaLive struct {
 	pluginClient          plugins.Client
 	queryDataService      query.Service
 	orgService            org.Service
+	anonService           anonymous.Service
 
 	keyPrefix string
 
@@ -595,6 +600,9 @@ func (g *GrafanaLive) handleOnRPC(client *centrifuge.Client, e centrifuge.RPCEve
 		logger.Error("No user found in context", "user", client.UserID(), "client", client.ID(), "method", e.Method)
 		return centrifuge.RPCReply{}, centrifuge.ErrorInternal
 	}
+	if limitErr := g.limitAnonymousQuery(client.Context(), user); limitErr != nil {
+		return centrifuge.RPCReply{}, limitErr
+	}
 	var req dtos.MetricRequest
 	err := json.Unmarshal(e.Data, &req)
 	if err != nil {
@@ -622,6 +630,22 @@ func (g *GrafanaLive) handleOnRPC(client *centrifuge.Client, e centrifuge.RPCEve
 	}, nil
 }
 
+// limitAnonymousQuery returns an error when the anonymous device of the Live connection reached its query rate limit
+func (g *GrafanaLive) limitAnonymousQuery(ctx context.Context, user identity.Requester) *centrifuge.Error {
+	if g.anonService == nil || !user.IsIdentityType(claims.TypeAnonymous) {
+		return nil
+	}
+	req, ok := livecontext.GetContextRequest(ctx)
+	if !ok {
+		return nil
+	}
+	if err := g.anonService.LimitQuery(ctx, req); err != nil {
+		logger.Debug("Anonymous query rate limit reached", "error", err)
+		return &centrifuge.Error{Code: uint32(http.StatusTooManyRequests), Message: http.StatusText(http.StatusTooManyRequests)}
+	}
+	return nil
+}
+
 func (g *GrafanaLive) handleOnSubscribe(ctx context.Context, client *centrifuge.Client, e centrifuge.SubscribeEvent) (centrifuge.SubscribeReply, error) {
 	logger.Debug("Client wants to subscribe", "user", client.UserID(), "client", client.ID(), "channel", e.Channel)
 
@@ -695,6 +719,12 @@ func (g *GrafanaLive) handleOnSubscribe(ctx context.Context, client *centrifuge.
 			logger.Error("Error getting channel handler", "user", client.UserID(), "client", client.ID(), "channel", e.Channel, "error", err)
 			return centrifuge.SubscribeReply{}, centrifuge.ErrorInternal
 		}
+		// the streams of the data sources and plugins run queries
+		if addr.Scope == live.ScopeDatasource || addr.Scope == live.ScopePlugin {
+			if limitErr := g.limitAnonymousQuery(client.Context(), user); limitErr != nil {
+				return centrifuge.SubscribeReply{}, limitErr
+			}
+		}
 		reply, status, err = handler.OnSubscribe(client.Context(), user, model.SubscribeEvent{
 			Channel: channel,
 			Path:    addr.Path,
@@ -2,6 +2,8 @@ package live
 
 import (
 	"context"
+	"errors"
+	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"testing"
@@ -14,8 +16,11 @@ import (
 	"github.com/grafana/grafana/pkg/infra/usagestats"
 	"github.com/grafana/grafana/pkg/services/accesscontrol/acimpl"
 	"github.com/grafana/grafana/pkg/services/annotations/annotationstest"
+	"github.com/grafana/grafana/pkg/services/anonymous/anontest"
 	"github.com/grafana/grafana/pkg/services/dashboards"
 	"github.com/grafana/grafana/pkg/services/featuremgmt"
+	"github.com/grafana/grafana/pkg/services/live/livecontext"
+	"github.com/grafana/grafana/pkg/services/user"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/tests/testsuite"
 )
@@ -40,7 +45,7 @@ func Test_provideLiveService_RedisUnavailable(t *testing.T) {
 		acimpl.ProvideAccessControl(featuremgmt.WithFeatures()),
 		&dashboards.FakeDashboardService{},
 		annotationstest.NewFakeAnnotationsRepo(),
-		nil, nil)
+		nil, nil, nil)
 
 	// Proceeds without live HA if redis is unavaialble
 	require.NoError(t, err)
@@ -233,3 +238,14 @@ func Test_getHistogramMetric(t *testing.T) {
 		})
 	}
 }
+
+func TestLimitAnonymousQuery(t *testing.T) {
+	g := &GrafanaLive{anonService: &anontest.FakeService{ExpectedError: errors.New("limit reached")}}
+	ctx := livecontext.SetContextRequest(context.Background(), httptest.NewRequest(http.MethodGet, "/api/live/ws", nil))
+
+	require.Nil(t, g.limitAnonymousQuery(ctx, &user.SignedInUser{UserID: 1, OrgID: 1}))
+
+	err := g.limitAnonymousQuery(ctx, &user.SignedInUser{IsAnonymous: true, OrgID: 1})
+	require.NotNil(t, err)
+	require.Equal(t, uint32(http.StatusTooManyRequests), err.Code)
+}
@@ -2,6 +2,7 @@ package livecontext
 
 import (
 	"context"
+	"net/http"
 
 	"github.com/grafana/grafana/pkg/apimachinery/identity"
 )
@@ -52,3 +53,18 @@ func GetContextChannelID(ctx context.Context) (string, bool) {
 	}
 	return "", false
 }
+
+type requestContextKey struct{}
+
+// SetContextRequest keeps the HTTP request which opened the Live connection
+func SetContextRequest(ctx context.Context, req *http.Request) context.Context {
+	return context.WithValue(ctx, requestContextKey{}, req)
+}
+
+func GetContextRequest(ctx context.Context) (*http.Request, bool) {
+	if val := ctx.Value(requestContextKey{}); val != nil {
+		req, ok := val.(*http.Request)
+		return req, ok
+	}
+	return nil, false
+}
@@ -24,6 +24,7 @@ const (
 	// modules
 	PasswordAuthModule     = "password"
 	PasswordlessAuthModule = "passwordless"
+	PasskeyAuthModule      = "passkey"
 	APIKeyAuthModule       = "apikey"
 	SAMLAuthModule         = "auth.saml"
 	LDAPAuthModule         = "ldap"
@@ -9,12 +9,16 @@ type Service interface {
 	Add(ctx context.Context, username, ipAddress string) error
 	// Validate checks if username has to many login attempts inside a window.
 	// Will return true if provided username do not have too many attempts.
-	Validate(ctx context.Context, username string) (bool, error)
+	// With the backoff enabled, the attempts of the username from the IP address are checked instead.
+	Validate(ctx context.Context, username, ipAddress string) (bool, error)
 	// Validate checks if IP address has to many login attempts inside a window.
 	// Will return true if provided IP address do not have too many attempts.
 	ValidateIPAddress(ctx context.Context, ipAddress string) (bool, error)
 	// Reset resets all login attempts attached to username
 	Reset(ctx context.Context, username string) error
+	// AddSuccess records a successful login of the username from the IP address. With the backoff enabled,
+	// the failed attempts of the username from the IP address are forgotten.
+	AddSuccess(ctx context.Context, username, ipAddress string) error
 }
 
 type LoginAttempt struct {
@@ -0,0 +1,42 @@
+package loginattemptimpl
+
+import (
+	"net/http"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/middleware"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+type api struct {
+	service *Service
+}
+
+func registerAPI(routeRegister routing.RouteRegister, s *Service) {
+	a := &api{service: s}
+	routeRegister.Group("/api/admin/login-lockouts", func(r routing.RouteRegister) {
+		r.Get("/", routing.Wrap(a.list))
+		r.Delete("/:id", routing.Wrap(a.clear))
+	}, middleware.ReqGrafanaAdmin)
+}
+
+// GET /api/admin/login-lockouts
+func (a *api) list(c *contextmodel.ReqContext) response.Response {
+	lockouts, err := a.service.ListLockouts(c.Req.Context())
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to list the login lockouts", err)
+	}
+	return response.JSON(http.StatusOK, lockouts)
+}
+
+// DELETE /api/admin/login-lockouts/:id
+func (a *api) clear(c *contextmodel.ReqContext) response.Response {
+	id := web.Params(c.Req)[":id"]
+	if err := a.service.ClearLockout(c.Req.Context(), id); err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to clear the login lockout", err)
+	}
+	c.Logger.Info("Login lockout cleared", "id", id, "by", c.SignedInUser.GetID())
+	return response.Success("Login lockout cleared")
+}
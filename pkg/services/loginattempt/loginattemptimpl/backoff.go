@@ -0,0 +1,324 @@
+package loginattemptimpl
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/remotecache"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+const (
+	lockoutKeyPrefix       = "login-lockout-"
+	usernameResetKeyPrefix = "login-lockouts-reset-"
+	blockedLockoutsKey     = "login-lockouts-blocked-"
+
+	// blockedIndexShards is the number of indexes of the blocked lockouts, a lockout is listed in the index of the
+	// first hex digit of its ID
+	blockedIndexShards = 16
+	// maxUpdateAttempts is how many times an update is retried when other requests update the same key, one of
+	// the concurrent updates succeeds on each attempt
+	maxUpdateAttempts = 20
+)
+
+var errConcurrentUpdates = errors.New("too many concurrent updates of the login lockouts")
+
+// backoff tracks the failed login attempts in the remote cache, so that all the Grafana instances agree.
+// The lockouts are updated with a compare-and-swap, the concurrent failures of the instances are all counted.
+// The cached lockouts expire once their failures are forgotten.
+type backoff struct {
+	cache remotecache.AtomicCacheStorage
+	cfg   *setting.Cfg
+	now   func() time.Time
+}
+
+func lockoutID(username, ipAddress string) string {
+	hash := sha256.Sum256([]byte(username + "\x00" + ipAddress))
+	return hex.EncodeToString(hash[:16])
+}
+
+func usernameResetKey(username string) string {
+	hash := sha256.Sum256([]byte(username))
+	return usernameResetKeyPrefix + hex.EncodeToString(hash[:16])
+}
+
+func blockedIndexKey(id string) string {
+	return blockedLockoutsKey + id[:1]
+}
+
+// maxAttempts returns the failures before a lockout, the usernames from any IP address are allowed more
+func (b *backoff) maxAttempts(ipAddress string) int64 {
+	if ipAddress == "" {
+		return b.cfg.BruteForceLoginProtectionUsernameMaxAttempts
+	}
+	return b.cfg.BruteForceLoginProtectionMaxAttempts
+}
+
+// duration returns how long a lockout is blocked after its failures, which doubles with each failure past the max attempts
+func (b *backoff) duration(failures, maxAttempts int64) time.Duration {
+	if maxAttempts <= 0 || failures < maxAttempts {
+		return 0
+	}
+	d := b.cfg.BruteForceLoginProtectionBackoffInitial
+	for i := maxAttempts; i < failures && d < b.cfg.BruteForceLoginProtectionBackoffMax; i++ {
+		d *= 2
+	}
+	return min(d, b.cfg.BruteForceLoginProtectionBackoffMax)
+}
+
+// forgotten checks if the failures of a lockout are forgotten, once it is not blocked and did not fail during a window
+func (b *backoff) forgotten(lockout *Lockout, now time.Time) bool {
+	since := now.Add(-loginAttemptsWindow)
+	return lockout.LastAttempt.Before(since) && lockout.BlockedUntil.Before(since)
+}
+
+// lockoutExpiry is the longest time a lockout is kept after its last failure
+func (b *backoff) lockoutExpiry() time.Duration {
+	return b.cfg.BruteForceLoginProtectionBackoffMax + loginAttemptsWindow
+}
+
+// update changes the value of the key with a compare-and-swap, and retries when another instance changed it
+// first. The change gets the current value, nil when missing, and returns the new value with its expiry.
+func (b *backoff) update(ctx context.Context, key string, change func(old []byte) ([]byte, time.Duration, error)) error {
+	for i := 0; i < maxUpdateAttempts; i++ {
+		old, err := b.cache.Get(ctx, key)
+		if err != nil {
+			if !errors.Is(err, remotecache.ErrCacheItemNotFound) {
+				return err
+			}
+			old = nil
+		}
+		value, expiry, err := change(old)
+		if err != nil {
+			return err
+		}
+		if bytes.Equal(value, old) {
+			return nil
+		}
+		swapped, err := b.cache.CompareAndSwap(ctx, key, old, value, expiry)
+		if err != nil {
+			return err
+		}
+		if swapped {
+			return nil
+		}
+	}
+	return errConcurrentUpdates
+}
+
+// decode reads a cached lockout, which is missing once its failures are forgotten or the failures of its username reset
+func (b *backoff) decode(ctx context.Context, data []byte, now time.Time) (*Lockout, error) {
+	if data == nil {
+		return nil, nil
+	}
+	var lockout Lockout
+	if err := json.Unmarshal(data, &lockout); err != nil {
+		return nil, err
+	}
+	if b.forgotten(&lockout, now) {
+		return nil, nil
+	}
+	if lockout.Username != "" {
+		resetAt, err := b.cache.Get(ctx, usernameResetKey(lockout.Username))
+		if err != nil && !errors.Is(err, remotecache.ErrCacheItemNotFound) {
+			return nil, err
+		}
+		if err == nil {
+			var t time.Time
+			if err := t.UnmarshalText(resetAt); err != nil {
+				return nil, err
+			}
+			if !lockout.LastAttempt.After(t) {
+				return nil, nil
+			}
+		}
+	}
+	return &lockout, nil
+}
+
+func (b *backoff) get(ctx context.Context, id string) (*Lockout, error) {
+	data, err := b.cache.Get(ctx, lockoutKeyPrefix+id)
+	if err != nil {
+		if errors.Is(err, remotecache.ErrCacheItemNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return b.decode(ctx, data, b.now())
+}
+
+func (b *backoff) blocked(ctx context.Context, username, ipAddress string) (bool, error) {
+	lockout, err := b.get(ctx, lockoutID(username, ipAddress))
+	if err != nil || lockout == nil {
+		return false, err
+	}
+	return lockout.blocked(b.now()), nil
+}
+
+// fail counts a failed attempt, and blocks the lockout when it reaches the max attempts
+func (b *backoff) fail(ctx context.Context, username, ipAddress string) (*Lockout, error) {
+	id := lockoutID(username, ipAddress)
+	var lockout *Lockout
+	err := b.update(ctx, lockoutKeyPrefix+id, func(old []byte) ([]byte, time.Duration, error) {
+		now := b.now()
+		var err error
+		lockout, err = b.decode(ctx, old, now)
+		if err != nil {
+			return nil, 0, err
+		}
+		if lockout == nil {
+			lockout = &Lockout{ID: id, Username: username, IPAddress: ipAddress}
+		}
+		lockout.Failures++
+		lockout.LastAttempt = now
+		if block := b.duration(lockout.Failures, b.maxAttempts(ipAddress)); block > 0 {
+			lockout.BlockedUntil = now.Add(block)
+		}
+
+		data, err := json.Marshal(lockout)
+		if err != nil {
+			return nil, 0, err
+		}
+		// the lockout is kept until its failures are forgotten
+		return data, max(lockout.BlockedUntil.Sub(now), 0) + loginAttemptsWindow, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if lockout.blocked(lockout.LastAttempt) {
+		if err := b.updateIndex(ctx, blockedIndexKey(id), func(index map[string]time.Time) {
+			// a concurrent failure may have blocked the lockout for longer
+			if index[id].Before(lockout.BlockedUntil) {
+				index[id] = lockout.BlockedUntil
+			}
+		}); err != nil {
+			return nil, err
+		}
+	}
+	return lockout, nil
+}
+
+// getIndex reads an index of the blocked lockout IDs, with the time until which they are blocked
+func (b *backoff) getIndex(ctx context.Context, key string) (map[string]time.Time, error) {
+	data, err := b.cache.Get(ctx, key)
+	if err != nil {
+		if errors.Is(err, remotecache.ErrCacheItemNotFound) {
+			return map[string]time.Time{}, nil
+		}
+		return nil, err
+	}
+	return decodeIndex(data)
+}
+
+func decodeIndex(data []byte) (map[string]time.Time, error) {
+	index := map[string]time.Time{}
+	if data == nil {
+		return index, nil
+	}
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, err
+	}
+	return index, nil
+}
+
+// updateIndex changes an index of the blocked lockouts
+func (b *backoff) updateIndex(ctx context.Context, key string, change func(index map[string]time.Time)) error {
+	_, err := b.pruneIndex(ctx, key, change)
+	return err
+}
+
+// pruneIndex changes an index of the blocked lockouts and removes the lockouts which are no longer blocked, so that
+// the index only holds the blocked lockouts. It returns the number of removed lockouts.
+func (b *backoff) pruneIndex(ctx context.Context, key string, change func(index map[string]time.Time)) (int64, error) {
+	var removed int64
+	err := b.update(ctx, key, func(old []byte) ([]byte, time.Duration, error) {
+		index, err := decodeIndex(old)
+		if err != nil {
+			return nil, 0, err
+		}
+		change(index)
+		now := b.now()
+		removed = 0
+		for id, blockedUntil := range index {
+			if !now.Before(blockedUntil) {
+				delete(index, id)
+				removed++
+			}
+		}
+		if old == nil && len(index) == 0 {
+			return nil, 0, nil
+		}
+		data, err := json.Marshal(index)
+		if err != nil {
+			return nil, 0, err
+		}
+		return data, b.lockoutExpiry(), nil
+	})
+	return removed, err
+}
+
+// list returns the blocked lockouts
+func (b *backoff) list(ctx context.Context) ([]*Lockout, error) {
+	now := b.now()
+	lockouts := []*Lockout{}
+	for shard := 0; shard < blockedIndexShards; shard++ {
+		index, err := b.getIndex(ctx, blockedLockoutsKey+strconv.FormatInt(int64(shard), 16))
+		if err != nil {
+			return nil, err
+		}
+		for id, blockedUntil := range index {
+			if !now.Before(blockedUntil) {
+				continue
+			}
+			lockout, err := b.get(ctx, id)
+			if err != nil {
+				return nil, err
+			}
+			if lockout != nil && lockout.blocked(now) {
+				lockouts = append(lockouts, lockout)
+			}
+		}
+	}
+	return lockouts, nil
+}
+
+// clear forgets the failed attempts of a lockout
+func (b *backoff) clear(ctx context.Context, id string) error {
+	if err := b.cache.Delete(ctx, lockoutKeyPrefix+id); err != nil {
+		return err
+	}
+	return b.updateIndex(ctx, blockedIndexKey(id), func(index map[string]time.Time) {
+		delete(index, id)
+	})
+}
+
+// clearUsername forgets the failed attempts of a username, from any IP address. The lockouts of the username
+// are not listed: the lockouts which failed before the reset are forgotten when they are read.
+func (b *backoff) clearUsername(ctx context.Context, username string) error {
+	resetAt, err := b.now().MarshalText()
+	if err != nil {
+		return err
+	}
+	return b.cache.Set(ctx, usernameResetKey(username), resetAt, b.lockoutExpiry())
+}
+
+// cleanup removes the lockouts which are no longer blocked from the indexes of the blocked lockouts, the cached
+// lockouts expire by themselves
+func (b *backoff) cleanup(ctx context.Context) (int64, error) {
+	var removed int64
+	for shard := 0; shard < blockedIndexShards; shard++ {
+		n, err := b.pruneIndex(ctx, blockedLockoutsKey+strconv.FormatInt(int64(shard), 16), func(map[string]time.Time) {})
+		if err != nil {
+			return removed, err
+		}
+		removed += n
+	}
+	return removed, nil
+}
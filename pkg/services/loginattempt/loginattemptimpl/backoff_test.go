@@ -0,0 +1,291 @@
+package loginattemptimpl
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/remotecache"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func newBackoffService(t *testing.T, now *time.Time) *Service {
+	t.Helper()
+	cfg := setting.NewCfg()
+	cfg.BruteForceLoginProtectionMaxAttempts = 3
+	cfg.BruteForceLoginProtectionBackoff = true
+	cfg.BruteForceLoginProtectionBackoffInitial = time.Second
+	cfg.BruteForceLoginProtectionBackoffMax = 10 * time.Second
+	cfg.BruteForceLoginProtectionUsernameMaxAttempts = 0
+	cfg.DisableIPAddressLoginProtection = true
+	return &Service{
+		store:   fakeStore{},
+		cfg:     cfg,
+		backoff: &backoff{cache: remotecache.NewFakeCacheStorage(), cfg: cfg, now: func() time.Time { return *now }},
+		metrics: newMetrics(nil),
+		logger:  log.NewNopLogger(),
+	}
+}
+
+func TestBackoff_Duration(t *testing.T) {
+	cfg := setting.NewCfg()
+	cfg.BruteForceLoginProtectionMaxAttempts = 3
+	cfg.BruteForceLoginProtectionBackoffInitial = time.Second
+	cfg.BruteForceLoginProtectionBackoffMax = 10 * time.Second
+	b := &backoff{cfg: cfg}
+
+	assert.Equal(t, time.Duration(0), b.duration(2, 3))
+	assert.Equal(t, time.Second, b.duration(3, 3))
+	assert.Equal(t, 2*time.Second, b.duration(4, 3))
+	assert.Equal(t, 8*time.Second, b.duration(6, 3))
+	assert.Equal(t, 10*time.Second, b.duration(7, 3))
+	assert.Equal(t, 10*time.Second, b.duration(1000, 3))
+	assert.Equal(t, time.Duration(0), b.duration(1000, 0), "no lockout when the max attempts are disabled")
+}
+
+func TestService_Backoff(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should block the pair with an exponential backoff", func(t *testing.T) {
+		now := time.Now()
+		service := newBackoffService(t, &now)
+
+		for i := 0; i < 3; i++ {
+			ok, err := service.Validate(ctx, "admin", "10.0.0.1")
+			require.NoError(t, err)
+			require.True(t, ok)
+			require.NoError(t, service.Add(ctx, "Admin", "10.0.0.1"))
+		}
+
+		ok, err := service.Validate(ctx, "admin", "10.0.0.1")
+		require.NoError(t, err)
+		assert.False(t, ok)
+
+		// Another IP address is not locked out
+		ok, err = service.Validate(ctx, "admin", "10.0.0.2")
+		require.NoError(t, err)
+		assert.True(t, ok)
+
+		now = now.Add(time.Second)
+		ok, err = service.Validate(ctx, "admin", "10.0.0.1")
+		require.NoError(t, err)
+		assert.True(t, ok)
+
+		require.NoError(t, service.Add(ctx, "admin", "10.0.0.1"))
+		now = now.Add(time.Second)
+		ok, err = service.Validate(ctx, "admin", "10.0.0.1")
+		require.NoError(t, err)
+		assert.False(t, ok, "the second block should last two seconds")
+	})
+
+	t.Run("should block the IP address when the IP address protection is enabled", func(t *testing.T) {
+		now := time.Now()
+		service := newBackoffService(t, &now)
+		service.cfg.DisableIPAddressLoginProtection = false
+
+		require.NoError(t, service.Add(ctx, "user1", "10.0.0.1"))
+		require.NoError(t, service.Add(ctx, "user2", "10.0.0.1"))
+		ok, err := service.ValidateIPAddress(ctx, "10.0.0.1")
+		require.NoError(t, err)
+		assert.True(t, ok)
+
+		require.NoError(t, service.Add(ctx, "user3", "10.0.0.1"))
+		ok, err = service.ValidateIPAddress(ctx, "10.0.0.1")
+		require.NoError(t, err)
+		assert.False(t, ok)
+	})
+
+	t.Run("should not block the username from any IP address by default", func(t *testing.T) {
+		now := time.Now()
+		service := newBackoffService(t, &now)
+		cfg, err := setting.NewCfgFromBytes([]byte("[security]\nbrute_force_login_protection_backoff = true"))
+		require.NoError(t, err)
+		service.cfg.BruteForceLoginProtectionUsernameMaxAttempts = cfg.BruteForceLoginProtectionUsernameMaxAttempts
+
+		for i := 0; i < 100; i++ {
+			require.NoError(t, service.Add(ctx, "admin", fmt.Sprintf("10.0.%d.%d", i/10, i%10)))
+		}
+		ok, err := service.Validate(ctx, "admin", "10.0.100.1")
+		require.NoError(t, err)
+		assert.True(t, ok)
+	})
+
+	t.Run("should block the username from any IP address", func(t *testing.T) {
+		now := time.Now()
+		service := newBackoffService(t, &now)
+		service.cfg.BruteForceLoginProtectionUsernameMaxAttempts = 5
+
+		for i := 0; i < 5; i++ {
+			require.NoError(t, service.Add(ctx, "admin", fmt.Sprintf("10.0.0.%d", i)))
+		}
+		ok, err := service.Validate(ctx, "admin", "10.0.1.1")
+		require.NoError(t, err)
+		assert.False(t, ok)
+		ok, err = service.Validate(ctx, "editor", "10.0.1.1")
+		require.NoError(t, err)
+		assert.True(t, ok)
+
+		lockouts, err := service.ListLockouts(ctx)
+		require.NoError(t, err)
+		require.Len(t, lockouts, 1)
+		assert.Equal(t, "admin", lockouts[0].Username)
+		assert.Empty(t, lockouts[0].IPAddress)
+
+		require.NoError(t, service.Reset(ctx, "admin"))
+		ok, err = service.Validate(ctx, "admin", "10.0.1.1")
+		require.NoError(t, err)
+		assert.True(t, ok)
+	})
+
+	t.Run("should forget the failures of the pair after a successful login", func(t *testing.T) {
+		now := time.Now()
+		service := newBackoffService(t, &now)
+		service.cfg.BruteForceLoginProtectionUsernameMaxAttempts = 4
+
+		for i := 0; i < 2; i++ {
+			require.NoError(t, service.Add(ctx, "admin", "10.0.0.1"))
+		}
+		require.NoError(t, service.AddSuccess(ctx, "Admin", "10.0.0.1"))
+		lockout, err := service.backoff.get(ctx, lockoutID("admin", "10.0.0.1"))
+		require.NoError(t, err)
+		assert.Nil(t, lockout)
+
+		// the failures of the username from any IP address are still counted
+		for i := 0; i < 2; i++ {
+			require.NoError(t, service.Add(ctx, "admin", "10.0.0.1"))
+		}
+		ok, err := service.Validate(ctx, "admin", "10.0.0.1")
+		require.NoError(t, err)
+		assert.False(t, ok)
+	})
+
+	t.Run("should count the concurrent failures", func(t *testing.T) {
+		now := time.Now()
+		service := newBackoffService(t, &now)
+		service.cfg.BruteForceLoginProtectionMaxAttempts = 100
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				assert.NoError(t, service.Add(ctx, "admin", "10.0.0.1"))
+			}()
+		}
+		wg.Wait()
+
+		lockout, err := service.backoff.get(ctx, lockoutID("admin", "10.0.0.1"))
+		require.NoError(t, err)
+		assert.Equal(t, int64(10), lockout.Failures)
+	})
+
+	t.Run("should forget the failures after the window", func(t *testing.T) {
+		now := time.Now()
+		service := newBackoffService(t, &now)
+		for i := 0; i < 2; i++ {
+			require.NoError(t, service.Add(ctx, "admin", "10.0.0.1"))
+		}
+		now = now.Add(loginAttemptsWindow + time.Second)
+		require.NoError(t, service.Add(ctx, "admin", "10.0.0.1"))
+		ok, err := service.Validate(ctx, "admin", "10.0.0.1")
+		require.NoError(t, err)
+		assert.True(t, ok)
+
+		lockout, err := service.backoff.get(ctx, lockoutID("admin", "10.0.0.1"))
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), lockout.Failures)
+
+		now = now.Add(loginAttemptsWindow + time.Second)
+		lockout, err = service.backoff.get(ctx, lockoutID("admin", "10.0.0.1"))
+		require.NoError(t, err)
+		assert.Nil(t, lockout)
+	})
+
+	t.Run("should remove the expired lockouts from the index of the blocked lockouts", func(t *testing.T) {
+		now := time.Now()
+		service := newBackoffService(t, &now)
+		for i := 0; i < 3; i++ {
+			require.NoError(t, service.Add(ctx, "admin", "10.0.0.1"))
+		}
+		removed, err := service.backoff.cleanup(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), removed)
+
+		now = now.Add(2 * time.Second)
+		removed, err = service.backoff.cleanup(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), removed)
+	})
+
+	t.Run("should list and clear the lockouts", func(t *testing.T) {
+		now := time.Now()
+		service := newBackoffService(t, &now)
+		for i := 0; i < 3; i++ {
+			require.NoError(t, service.Add(ctx, "admin", "10.0.0.1"))
+			require.NoError(t, service.Add(ctx, "editor", "10.0.0.1"))
+		}
+		require.NoError(t, service.Add(ctx, "viewer", "10.0.0.1"))
+
+		lockouts, err := service.ListLockouts(ctx)
+		require.NoError(t, err)
+		require.Len(t, lockouts, 2)
+
+		require.NoError(t, service.ClearLockout(ctx, lockouts[0].ID))
+		require.ErrorIs(t, service.ClearLockout(ctx, lockouts[0].ID), ErrLockoutNotFound)
+		require.NoError(t, service.Reset(ctx, lockouts[1].Username))
+
+		lockouts, err = service.ListLockouts(ctx)
+		require.NoError(t, err)
+		require.Len(t, lockouts, 0)
+
+		ok, err := service.Validate(ctx, "admin", "10.0.0.1")
+		require.NoError(t, err)
+		assert.True(t, ok)
+	})
+
+	t.Run("should forget the expired lockouts", func(t *testing.T) {
+		now := time.Now()
+		service := newBackoffService(t, &now)
+		for i := 0; i < 3; i++ {
+			require.NoError(t, service.Add(ctx, "admin", "10.0.0.1"))
+		}
+		now = now.Add(2 * time.Second)
+		lockouts, err := service.ListLockouts(ctx)
+		require.NoError(t, err)
+		require.Len(t, lockouts, 0)
+	})
+
+	t.Run("should not throttle the allowed networks", func(t *testing.T) {
+		now := time.Now()
+		service := newBackoffService(t, &now)
+		_, network, err := net.ParseCIDR("10.0.0.0/8")
+		require.NoError(t, err)
+		service.cfg.BruteForceLoginProtectionAllowedNetworks = []*net.IPNet{network}
+
+		for i := 0; i < 5; i++ {
+			require.NoError(t, service.Add(ctx, "admin", "10.0.0.1"))
+			require.NoError(t, service.Add(ctx, "admin", "192.168.0.1"))
+		}
+		ok, err := service.Validate(ctx, "admin", "10.0.0.1")
+		require.NoError(t, err)
+		assert.True(t, ok)
+		ok, err = service.Validate(ctx, "admin", "192.168.0.1")
+		require.NoError(t, err)
+		assert.False(t, ok)
+	})
+
+	t.Run("should not list the lockouts when the backoff is disabled", func(t *testing.T) {
+		now := time.Now()
+		service := newBackoffService(t, &now)
+		service.cfg.BruteForceLoginProtectionBackoff = false
+		_, err := service.ListLockouts(ctx)
+		require.ErrorIs(t, err, ErrBackoffDisabled)
+	})
+}
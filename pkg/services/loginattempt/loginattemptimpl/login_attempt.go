@@ -2,31 +2,44 @@ package loginattemptimpl
 
 import (
 	"context"
+	"net"
+	"slices"
 	"strings"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/grafana/grafana/pkg/api/routing"
 	"github.com/grafana/grafana/pkg/infra/db"
 	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/remotecache"
 	"github.com/grafana/grafana/pkg/infra/serverlock"
 	"github.com/grafana/grafana/pkg/setting"
 )
 
 const loginAttemptsWindow = time.Minute * 5
 
-func ProvideService(db db.DB, cfg *setting.Cfg, lock *serverlock.ServerLockService) *Service {
-	return &Service{
-		&xormStore{db: db, now: time.Now},
-		cfg,
-		lock,
-		log.New("login_attempt"),
+func ProvideService(db db.DB, cfg *setting.Cfg, lock *serverlock.ServerLockService, cache remotecache.AtomicCacheStorage,
+	routeRegister routing.RouteRegister, reg prometheus.Registerer) *Service {
+	s := &Service{
+		store:   &xormStore{db: db, now: time.Now},
+		cfg:     cfg,
+		lock:    lock,
+		backoff: &backoff{cache: cache, cfg: cfg, now: time.Now},
+		metrics: newMetrics(reg),
+		logger:  log.New("login_attempt"),
 	}
+	registerAPI(routeRegister, s)
+	return s
 }
 
 type Service struct {
-	store  store
-	cfg    *setting.Cfg
-	lock   *serverlock.ServerLockService
-	logger log.Logger
+	store   store
+	cfg     *setting.Cfg
+	lock    *serverlock.ServerLockService
+	backoff *backoff
+	metrics *metrics
+	logger  log.Logger
 }
 
 func (s *Service) Run(ctx context.Context) error {
@@ -47,10 +60,14 @@ func (s *Service) Run(ctx context.Context) error {
 }
 
 func (s *Service) Add(ctx context.Context, username, IPAddress string) error {
-	if s.cfg.DisableBruteForceLoginProtection {
+	if s.cfg.DisableBruteForceLoginProtection || s.allowed(IPAddress) {
 		return nil
 	}
 
+	if s.cfg.BruteForceLoginProtectionBackoff {
+		return s.addBackoff(ctx, strings.ToLower(username), IPAddress)
+	}
+
 	_, err := s.store.CreateLoginAttempt(ctx, CreateLoginAttemptCommand{
 		Username:  strings.ToLower(username),
 		IPAddress: IPAddress,
@@ -58,15 +75,50 @@ func (s *Service) Add(ctx context.Context, username, IPAddress string) error {
 	return err
 }
 
+// AddSuccess forgets the failed attempts of the username from the IP address after a successful login, the
+// failures of the username from any IP address and of the IP address are still counted
+func (s *Service) AddSuccess(ctx context.Context, username, IPAddress string) error {
+	if s.cfg.DisableBruteForceLoginProtection || !s.cfg.BruteForceLoginProtectionBackoff {
+		return nil
+	}
+	return s.backoff.clear(ctx, lockoutID(strings.ToLower(username), IPAddress))
+}
+
 func (s *Service) Reset(ctx context.Context, username string) error {
+	if s.cfg.BruteForceLoginProtectionBackoff {
+		if err := s.backoff.clearUsername(ctx, strings.ToLower(username)); err != nil {
+			return err
+		}
+	}
 	return s.store.DeleteLoginAttempts(ctx, DeleteLoginAttemptsCommand{strings.ToLower(username)})
 }
 
-func (s *Service) Validate(ctx context.Context, username string) (bool, error) {
-	if s.cfg.DisableBruteForceLoginProtection {
+func (s *Service) Validate(ctx context.Context, username, IPAddress string) (bool, error) {
+	if s.cfg.DisableBruteForceLoginProtection || s.allowed(IPAddress) {
 		return true, nil
 	}
 
+	if s.cfg.BruteForceLoginProtectionBackoff {
+		// Only the pair is blocked, so that failed attempts from elsewhere don't lock the user out
+		blocked, err := s.backoff.blocked(ctx, strings.ToLower(username), IPAddress)
+		if err != nil {
+			return false, err
+		}
+		if blocked {
+			s.metrics.blockedAttemptsCounter.WithLabelValues("user").Inc()
+			return false, nil
+		}
+		// The username is also throttled from any IP address, with a higher threshold
+		blocked, err = s.backoff.blocked(ctx, strings.ToLower(username), "")
+		if err != nil {
+			return false, err
+		}
+		if blocked {
+			s.metrics.blockedAttemptsCounter.WithLabelValues("username").Inc()
+		}
+		return !blocked, nil
+	}
+
 	loginAttemptCountQuery := GetUserLoginAttemptCountQuery{
 		Username: strings.ToLower(username),
 		Since:    time.Now().Add(-loginAttemptsWindow),
@@ -78,6 +130,7 @@ func (s *Service) Validate(ctx context.Context, username string) (bool, error) {
 	}
 
 	if count >= s.cfg.BruteForceLoginProtectionMaxAttempts {
+		s.metrics.blockedAttemptsCounter.WithLabelValues("user").Inc()
 		return false, nil
 	}
 
@@ -85,10 +138,21 @@ func (s *Service) Validate(ctx context.Context, username string) (bool, error) {
 }
 
 func (s *Service) ValidateIPAddress(ctx context.Context, IPAddress string) (bool, error) {
-	if s.cfg.DisableIPAddressLoginProtection {
+	if s.cfg.DisableIPAddressLoginProtection || s.allowed(IPAddress) {
 		return true, nil
 	}
 
+	if s.cfg.BruteForceLoginProtectionBackoff {
+		blocked, err := s.backoff.blocked(ctx, "", IPAddress)
+		if err != nil {
+			return false, err
+		}
+		if blocked {
+			s.metrics.blockedAttemptsCounter.WithLabelValues("ip").Inc()
+		}
+		return !blocked, nil
+	}
+
 	loginAttemptCountQuery := GetIPLoginAttemptCountQuery{
 		IPAddress: IPAddress,
 		Since:     time.Now().Add(-loginAttemptsWindow),
@@ -100,12 +164,94 @@ func (s *Service) ValidateIPAddress(ctx context.Context, IPAddress string) (bool
 	}
 
 	if count >= s.cfg.BruteForceLoginProtectionMaxAttempts {
+		s.metrics.blockedAttemptsCounter.WithLabelValues("ip").Inc()
 		return false, nil
 	}
 
 	return true, nil
 }
 
+// ListLockouts returns the usernames and IP addresses blocked by the login backoff
+func (s *Service) ListLockouts(ctx context.Context) ([]*Lockout, error) {
+	if !s.cfg.BruteForceLoginProtectionBackoff {
+		return nil, ErrBackoffDisabled.Errorf("the login backoff is disabled")
+	}
+	lockouts, err := s.backoff.list(ctx)
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(lockouts, func(a, b *Lockout) int {
+		return a.BlockedUntil.Compare(b.BlockedUntil)
+	})
+	return lockouts, nil
+}
+
+// ClearLockout unblocks a username and IP address pair, an IP address or a username, and forgets its failed attempts
+func (s *Service) ClearLockout(ctx context.Context, id string) error {
+	if !s.cfg.BruteForceLoginProtectionBackoff {
+		return ErrBackoffDisabled.Errorf("the login backoff is disabled")
+	}
+	lockout, err := s.backoff.get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if lockout == nil {
+		return ErrLockoutNotFound.Errorf("lockout %s not found", id)
+	}
+	return s.backoff.clear(ctx, id)
+}
+
+func (s *Service) addBackoff(ctx context.Context, username, IPAddress string) error {
+	lockout, err := s.backoff.fail(ctx, username, IPAddress)
+	if err != nil {
+		return err
+	}
+	s.logBlocked(lockout)
+
+	if s.cfg.BruteForceLoginProtectionUsernameMaxAttempts > 0 {
+		lockout, err = s.backoff.fail(ctx, username, "")
+		if err != nil {
+			return err
+		}
+		s.logBlocked(lockout)
+	}
+
+	if s.cfg.DisableIPAddressLoginProtection {
+		return nil
+	}
+	lockout, err = s.backoff.fail(ctx, "", IPAddress)
+	if err != nil {
+		return err
+	}
+	s.logBlocked(lockout)
+	return nil
+}
+
+func (s *Service) logBlocked(lockout *Lockout) {
+	if lockout.LastAttempt.Before(lockout.BlockedUntil) {
+		s.metrics.lockoutsCounter.Inc()
+		s.logger.Warn("Blocking logins after failed attempts", "username", lockout.Username, "ipAddress", lockout.IPAddress,
+			"failures", lockout.Failures, "until", lockout.BlockedUntil)
+	}
+}
+
+// allowed checks if the IP address belongs to a network exempted from the brute force login protection
+func (s *Service) allowed(IPAddress string) bool {
+	if len(s.cfg.BruteForceLoginProtectionAllowedNetworks) == 0 {
+		return false
+	}
+	ip := net.ParseIP(strings.Trim(IPAddress, "[]"))
+	if ip == nil {
+		return false
+	}
+	for _, network := range s.cfg.BruteForceLoginProtectionAllowedNetworks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
 func (s *Service) cleanup(ctx context.Context) {
 	err := s.lock.LockAndExecute(ctx, "delete old login attempts", time.Minute*10, func(context.Context) {
 		cmd := DeleteOldLoginAttemptsCommand{
@@ -116,6 +262,15 @@ func (s *Service) cleanup(ctx context.Context) {
 		} else {
 			s.logger.Debug("Deleted expired login attempts", "rows affected", deletedLogs)
 		}
+
+		if !s.cfg.BruteForceLoginProtectionBackoff {
+			return
+		}
+		if removed, err := s.backoff.cleanup(ctx); err != nil {
+			s.logger.Error("Problem removing expired login lockouts", "error", err.Error())
+		} else {
+			s.logger.Debug("Removed expired login lockouts", "removed", removed)
+		}
 	})
 
 	if err != nil {
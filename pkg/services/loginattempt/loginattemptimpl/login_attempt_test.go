@@ -6,7 +6,9 @@ import (
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/grafana/grafana/pkg/api/routing"
 	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/remotecache"
 	"github.com/grafana/grafana/pkg/services/loginattempt"
 	"github.com/grafana/grafana/pkg/setting"
 )
@@ -73,10 +75,11 @@ func TestService_Validate(t *testing.T) {
 					ExpectedCount: tt.loginAttempts,
 					ExpectedErr:   tt.expectedErr,
 				},
-				cfg: cfg,
+				cfg:     cfg,
+				metrics: newMetrics(nil),
 			}
 
-			ok, err := service.Validate(context.Background(), "test")
+			ok, err := service.Validate(context.Background(), "test", "192.168.1.1")
 			assert.Equal(t, tt.expected, ok)
 			assert.Equal(t, tt.expectedErr, err)
 		})
@@ -89,7 +92,7 @@ func TestUserLoginAttempts(t *testing.T) {
 	cfg.DisableBruteForceLoginProtection = false
 	cfg.BruteForceLoginProtectionMaxAttempts = 5
 	db := db.InitTestDB(t)
-	service := ProvideService(db, cfg, nil)
+	service := ProvideService(db, cfg, nil, remotecache.NewFakeCacheStorage(), routing.NewRouteRegister(), nil)
 
 	// add multiple login attempts with different uppercases, they all should be counted as the same user
 	_ = service.Add(ctx, "admin", "[::1]")
@@ -104,7 +107,7 @@ func TestUserLoginAttempts(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, int64(6), count)
 
-	ok, err := service.Validate(ctx, "admin")
+	ok, err := service.Validate(ctx, "admin", "[::1]")
 	assert.False(t, ok)
 	assert.Nil(t, err)
 }
@@ -171,7 +174,8 @@ func TestService_ValidateIPAddress(t *testing.T) {
 					ExpectedCount: tt.loginAttempts,
 					ExpectedErr:   tt.expectedErr,
 				},
-				cfg: cfg,
+				cfg:     cfg,
+				metrics: newMetrics(nil),
 			}
 
 			ok, err := service.ValidateIPAddress(context.Background(), "192.168.1.1")
@@ -187,7 +191,7 @@ func TestIPLoginAttempts(t *testing.T) {
 	cfg.DisableIPAddressLoginProtection = false
 	cfg.BruteForceLoginProtectionMaxAttempts = 3
 	db := db.InitTestDB(t)
-	service := ProvideService(db, cfg, nil)
+	service := ProvideService(db, cfg, nil, remotecache.NewFakeCacheStorage(), routing.NewRouteRegister(), nil)
 
 	_ = service.Add(ctx, "user1", "192.168.1.1")
 	_ = service.Add(ctx, "user2", "10.0.0.123")
@@ -0,0 +1,39 @@
+package loginattemptimpl
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	metricsNamespace = "grafana"
+	metricsSubSystem = "login_attempt"
+)
+
+func newMetrics(reg prometheus.Registerer) *metrics {
+	m := &metrics{
+		blockedAttemptsCounter: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubSystem,
+			Name:      "blocked_total",
+			Help:      "Number of login attempts blocked by the brute force login protection",
+		}, []string{"reason"}),
+		lockoutsCounter: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubSystem,
+			Name:      "lockouts_total",
+			Help:      "Number of lockouts started or extended by the login backoff",
+		}),
+	}
+
+	if reg != nil {
+		reg.MustRegister(m.blockedAttemptsCounter)
+		reg.MustRegister(m.lockoutsCounter)
+	}
+
+	return m
+}
+
+type metrics struct {
+	blockedAttemptsCounter *prometheus.CounterVec
+	lockoutsCounter        prometheus.Counter
+}
@@ -2,6 +2,13 @@ package loginattemptimpl
 
 import (
 	"time"
+
+	"github.com/grafana/grafana/pkg/apimachinery/errutil"
+)
+
+var (
+	ErrBackoffDisabled = errutil.BadRequest("loginattempt.backoffDisabled", errutil.WithPublicMessage("The login backoff is disabled"))
+	ErrLockoutNotFound = errutil.NotFound("loginattempt.lockoutNotFound", errutil.WithPublicMessage("Lockout not found"))
 )
 
 type CreateLoginAttemptCommand struct {
@@ -26,3 +33,17 @@ type DeleteOldLoginAttemptsCommand struct {
 type DeleteLoginAttemptsCommand struct {
 	Username string
 }
+
+// Lockout is the state of the login backoff of a username and IP address pair, of an IP address or of a username
+type Lockout struct {
+	ID           string    `json:"id"`
+	Username     string    `json:"username,omitempty"`
+	IPAddress    string    `json:"ipAddress,omitempty"`
+	Failures     int64     `json:"failures"`
+	LastAttempt  time.Time `json:"lastAttempt"`
+	BlockedUntil time.Time `json:"blockedUntil"`
+}
+
+func (l *Lockout) blocked(now time.Time) bool {
+	return now.Before(l.BlockedUntil)
+}
@@ -17,11 +17,15 @@ func (f FakeLoginAttemptService) Add(ctx context.Context, username, IPAddress st
 	return f.ExpectedErr
 }
 
+func (f FakeLoginAttemptService) AddSuccess(ctx context.Context, username, IPAddress string) error {
+	return f.ExpectedErr
+}
+
 func (f FakeLoginAttemptService) Reset(ctx context.Context, username string) error {
 	return f.ExpectedErr
 }
 
-func (f FakeLoginAttemptService) Validate(ctx context.Context, username string) (bool, error) {
+func (f FakeLoginAttemptService) Validate(ctx context.Context, username, ipAddress string) (bool, error) {
 	return f.ExpectedValid, f.ExpectedErr
 }
 
@@ -9,9 +9,10 @@ import (
 var _ loginattempt.Service = new(MockLoginAttemptService)
 
 type MockLoginAttemptService struct {
-	AddCalled      bool
-	ResetCalled    bool
-	ValidateCalled bool
+	AddCalled        bool
+	AddSuccessCalled bool
+	ResetCalled      bool
+	ValidateCalled   bool
 
 	ExpectedValid bool
 	ExpectedErr   error
@@ -22,12 +23,17 @@ func (f *MockLoginAttemptService) Add(ctx context.Context, username, ipAddress s
 	return f.ExpectedErr
 }
 
+func (f *MockLoginAttemptService) AddSuccess(ctx context.Context, username, ipAddress string) error {
+	f.AddSuccessCalled = true
+	return f.ExpectedErr
+}
+
 func (f *MockLoginAttemptService) Reset(ctx context.Context, username string) error {
 	f.ResetCalled = true
 	return f.ExpectedErr
 }
 
-func (f *MockLoginAttemptService) Validate(ctx context.Context, username string) (bool, error) {
+func (f *MockLoginAttemptService) Validate(ctx context.Context, username, ipAddress string) (bool, error) {
 	f.ValidateCalled = true
 	return f.ExpectedValid, f.ExpectedErr
 }
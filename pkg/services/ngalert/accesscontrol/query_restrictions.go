@@ -0,0 +1,95 @@
+package accesscontrol
+
+import (
+	"bytes"
+	"context"
+	"errors"
+
+	"github.com/grafana/grafana/pkg/apimachinery/errutil"
+	"github.com/grafana/grafana/pkg/apimachinery/identity"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/store"
+	"github.com/grafana/grafana/pkg/services/queryrestriction"
+)
+
+// QueryRestrictionService checks the queries of the alert rules against the query restrictions of the teams of a user.
+// The rules are evaluated without the user, so their queries must already be restricted, the queries which would be
+// rewritten by the restrictions are rejected as well.
+type QueryRestrictionService struct {
+	dataSourceCache         datasources.CacheService
+	queryRestrictionService queryrestriction.Service
+}
+
+func NewQueryRestrictionService(dataSourceCache datasources.CacheService, queryRestrictionService queryrestriction.Service) *QueryRestrictionService {
+	return &QueryRestrictionService{
+		dataSourceCache:         dataSourceCache,
+		queryRestrictionService: queryRestrictionService,
+	}
+}
+
+// AuthorizeRuleChanges checks the queries of the new and updated rules of the group
+func (s *QueryRestrictionService) AuthorizeRuleChanges(ctx context.Context, user identity.Requester, change *store.GroupDelta) error {
+	rules := make([]*models.AlertRule, 0, len(change.New)+len(change.Update))
+	rules = append(rules, change.New...)
+	for _, upd := range change.Update {
+		rules = append(rules, upd.New)
+	}
+	return s.AuthorizeRuleQueries(ctx, user, rules...)
+}
+
+// AuthorizeRuleQueries returns ErrQueryRestricted when a query of the rules is not allowed by the restrictions of the user
+func (s *QueryRestrictionService) AuthorizeRuleQueries(ctx context.Context, user identity.Requester, rules ...*models.AlertRule) error {
+	for _, rule := range rules {
+		if rule.IsPaused {
+			continue
+		}
+		for _, q := range rule.Data {
+			if isExpression, err := q.IsExpression(); err != nil || isExpression {
+				continue
+			}
+			restrictions, err := s.queryRestrictionService.GetUserRestrictions(ctx, user, q.DatasourceUID)
+			if err != nil {
+				return err
+			}
+			if len(restrictions) == 0 {
+				continue
+			}
+			ds, err := s.dataSourceCache.GetDatasourceByUID(ctx, q.DatasourceUID, user, false)
+			if err != nil {
+				return err
+			}
+			if err := s.checkQueryRestricted(ds.Type, q, restrictions); err != nil {
+				return queryrestriction.ErrQueryRestricted.Build(errutil.TemplateData{
+					Public: map[string]any{"RefId": q.RefID, "Reason": err.Error()},
+					Error:  err,
+				})
+			}
+		}
+	}
+	return nil
+}
+
+// checkQueryRestricted returns an error when the query is rejected or rewritten by the restrictions
+func (s *QueryRestrictionService) checkQueryRestricted(pluginID string, q models.AlertQuery, restrictions []queryrestriction.Restriction) error {
+	model, err := simplejson.NewJson(q.Model)
+	if err != nil {
+		return err
+	}
+	original, err := model.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	if err := s.queryRestrictionService.RestrictQuery(pluginID, model, restrictions); err != nil {
+		return err
+	}
+	restricted, err := model.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(original, restricted) {
+		return errors.New("the query of an alert rule must only select the data allowed by the restrictions")
+	}
+	return nil
+}
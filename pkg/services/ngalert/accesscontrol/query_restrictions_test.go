@@ -0,0 +1,71 @@
+package accesscontrol
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/datasources"
+	dsfakes "github.com/grafana/grafana/pkg/services/datasources/fakes"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/store"
+	"github.com/grafana/grafana/pkg/services/queryrestriction"
+	"github.com/grafana/grafana/pkg/services/queryrestriction/queryrestrictiontest"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/util/cmputil"
+)
+
+func TestQueryRestrictionServiceAuthorizeRuleChanges(t *testing.T) {
+	gen := models.RuleGen
+	query := func(dsUID string, expr string) models.AlertQuery {
+		q := models.GenerateAlertQuery()
+		q.DatasourceUID = dsUID
+		q.Model = json.RawMessage(fmt.Sprintf(`{"refId": %q, "expr": %q}`, q.RefID, expr))
+		return q
+	}
+	restriction := queryrestriction.Restriction{TeamID: 1, Kind: queryrestriction.KindStreamSelector, Pattern: `{namespace="team-a"}`}
+	requester := &user.SignedInUser{UserID: 2, OrgID: 1}
+	setup := func(restrictor queryrestriction.QueryRestrictor, restrictions ...queryrestriction.Restriction) *QueryRestrictionService {
+		queryRestrictionService := &queryrestrictiontest.FakeService{ExpectedRestrictions: restrictions}
+		queryRestrictionService.RegisterQueryRestrictor(datasources.DS_LOKI, restrictor)
+		return NewQueryRestrictionService(&dsfakes.FakeCacheService{DataSources: []*datasources.DataSource{{UID: "loki", Type: datasources.DS_LOKI}}}, queryRestrictionService)
+	}
+
+	t.Run("should accept the rules of the users without restrictions", func(t *testing.T) {
+		srv := setup(queryrestrictiontest.FakeQueryRestrictor{ExpectedErr: errors.New("restricted")})
+		delta := store.GroupDelta{New: []*models.AlertRule{gen.With(gen.WithQuery(query("loki", `{app="api"}`))).GenerateRef()}}
+		require.NoError(t, srv.AuthorizeRuleChanges(context.Background(), requester, &delta))
+	})
+
+	t.Run("should accept the queries allowed by the restrictions", func(t *testing.T) {
+		srv := setup(queryrestrictiontest.FakeQueryRestrictor{}, restriction)
+		delta := store.GroupDelta{New: []*models.AlertRule{gen.With(gen.WithQuery(query("loki", `{app="api", namespace="team-a"}`))).GenerateRef()}}
+		require.NoError(t, srv.AuthorizeRuleChanges(context.Background(), requester, &delta))
+	})
+
+	t.Run("should reject the new rules with restricted queries", func(t *testing.T) {
+		srv := setup(queryrestrictiontest.FakeQueryRestrictor{ExpectedErr: errors.New("restricted")}, restriction)
+		delta := store.GroupDelta{New: []*models.AlertRule{gen.With(gen.WithQuery(query("loki", `{app="api"}`))).GenerateRef()}}
+		require.ErrorIs(t, srv.AuthorizeRuleChanges(context.Background(), requester, &delta), queryrestriction.ErrQueryRestricted)
+	})
+
+	t.Run("should reject the queries rewritten by the restrictions", func(t *testing.T) {
+		srv := setup(queryrestrictiontest.FakeQueryRestrictor{ExpectedExpr: `{app="api", namespace="team-a"}`}, restriction)
+		delta := store.GroupDelta{New: []*models.AlertRule{gen.With(gen.WithQuery(query("loki", `{app="api"}`))).GenerateRef()}}
+		require.ErrorIs(t, srv.AuthorizeRuleChanges(context.Background(), requester, &delta), queryrestriction.ErrQueryRestricted)
+	})
+
+	t.Run("should reject the updated rules with restricted queries", func(t *testing.T) {
+		srv := setup(queryrestrictiontest.FakeQueryRestrictor{ExpectedErr: errors.New("restricted")}, restriction)
+		delta := store.GroupDelta{Update: []store.RuleDelta{{
+			Existing: gen.With(gen.WithQuery(query("loki", `{namespace="team-a"}`))).GenerateRef(),
+			New:      gen.With(gen.WithQuery(query("loki", `{app="api"}`))).GenerateRef(),
+			Diff:     cmputil.DiffReport{cmputil.Diff{Path: "Data"}},
+		}}}
+		require.ErrorIs(t, srv.AuthorizeRuleChanges(context.Background(), requester, &delta), queryrestriction.ErrQueryRestricted)
+	})
+}
@@ -81,6 +81,8 @@ type API struct {
 	Tracer               tracing.Tracer
 	AppUrl               *url.URL
 	UserService          user.Service
+	// RuleQueryRestrictions checks the queries of the rules against the restrictions of their author
+	RuleQueryRestrictions *accesscontrol.QueryRestrictionService
 
 	// Hooks can be used to replace API handlers for specific paths.
 	Hooks *Hooks
@@ -139,6 +141,8 @@ func (api *API) RegisterAPIEndpoints(m *metrics.API) {
 			amRefresher:        api.MultiOrgAlertmanager,
 			featureManager:     api.FeatureManager,
 			userService:        api.UserService,
+
+			queryRestrictions: api.RuleQueryRestrictions,
 		},
 	), m)
 	api.RegisterTestingApiEndpoints(NewTestingApi(
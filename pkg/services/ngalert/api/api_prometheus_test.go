@@ -822,6 +822,65 @@ func TestRouteGetRuleStatuses(t *testing.T) {
 		})
 	})
 
+	t.Run("test datasource_uid, metric and label query params", func(t *testing.T) {
+		ruleStore := fakes.NewRuleStore(t)
+		fakeAIM := NewFakeAlertInstanceManager(t)
+
+		promQuery := func(dsUID, expr string) ngmodels.AlertQuery {
+			return ngmodels.AlertQuery{
+				RefID:         "A",
+				DatasourceUID: dsUID,
+				Model:         json.RawMessage(fmt.Sprintf(`{"datasource":{"type":"prometheus","uid":%q},"expr":%q}`, dsUID, expr)),
+			}
+		}
+		groupKey := ngmodels.AlertRuleGroupKey{RuleGroup: "rule-group", NamespaceUID: "folder-1", OrgID: orgID}
+		cpuRule := gen.With(gen.WithGroupKey(groupKey), gen.WithTitle("cpu"), gen.WithQuery(promQuery("prom-1", `sum by (instance) (rate(node_cpu_seconds_total{job="node"}[5m]))`))).GenerateRef()
+		memoryRule := gen.With(gen.WithGroupKey(groupKey), gen.WithTitle("memory"), gen.WithQuery(promQuery("prom-2", `node_memory_MemFree_bytes{job="node"}`))).GenerateRef()
+		ruleStore.PutRule(context.Background(), cpuRule, memoryRule)
+
+		api := NewPrometheusSrv(
+			log.NewNopLogger(),
+			fakeAIM,
+			newFakeSchedulerReader(t).setupStates(fakeAIM),
+			ruleStore,
+			accesscontrol.NewRuleService(acimpl.ProvideAccessControl(featuremgmt.WithFeatures())),
+		)
+		c := &contextmodel.ReqContext{
+			SignedInUser: &user.SignedInUser{
+				OrgID:       orgID,
+				Permissions: createPermissionsForRules([]*ngmodels.AlertRule{cpuRule, memoryRule}, orgID),
+			},
+		}
+
+		ruleNames := func(t *testing.T, query string) []string {
+			t.Helper()
+			r, err := http.NewRequest("GET", "/api/v1/rules?"+query, nil)
+			require.NoError(t, err)
+			c.Context = &web.Context{Req: r}
+
+			resp := api.RouteGetRuleStatuses(c)
+			require.Equal(t, http.StatusOK, resp.Status())
+			result := &apimodels.RuleResponse{}
+			require.NoError(t, json.Unmarshal(resp.Body(), result))
+
+			var names []string
+			for _, rg := range result.Data.RuleGroups {
+				for _, rule := range rg.Rules {
+					names = append(names, rule.Name)
+				}
+			}
+			slices.Sort(names)
+			return names
+		}
+
+		require.Equal(t, []string{"cpu", "memory"}, ruleNames(t, ""))
+		require.Equal(t, []string{"memory"}, ruleNames(t, "datasource_uid=prom-2"))
+		require.Equal(t, []string{"cpu"}, ruleNames(t, "metric=node_cpu_seconds_total"))
+		require.Equal(t, []string{"cpu", "memory"}, ruleNames(t, "label=job"))
+		require.Equal(t, []string{"cpu"}, ruleNames(t, "label=job&label=instance"))
+		require.Empty(t, ruleNames(t, "datasource_uid=prom-2&metric=node_cpu_seconds_total"))
+	})
+
 	t.Run("when requesting rules with pagination", func(t *testing.T) {
 		ruleStore := fakes.NewRuleStore(t)
 		fakeAIM := NewFakeAlertInstanceManager(t)
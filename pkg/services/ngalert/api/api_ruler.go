@@ -61,6 +61,8 @@ type RulerSrv struct {
 	authz              RuleAccessControlService
 	userService        user.Service
 
+	queryRestrictions provisioning.QueryRestrictionAuthorizer
+
 	amConfigStore  AMConfigStore
 	amRefresher    AMRefresher
 	featureManager featuremgmt.FeatureToggles
@@ -480,6 +482,10 @@ func (srv RulerSrv) updateAlertRulesInGroup(c *contextmodel.ReqContext, groupKey
 			return err
 		}
 
+		if err := srv.queryRestrictions.AuthorizeRuleChanges(c.Req.Context(), c.SignedInUser, groupChanges); err != nil {
+			return err
+		}
+
 		newOrUpdatedNotificationSettings := groupChanges.NewOrUpdatedNotificationSettings()
 		if len(newOrUpdatedNotificationSettings) > 0 {
 			dbConfig, err = srv.amConfigStore.GetLatestAlertmanagerConfiguration(c.Req.Context(), groupChanges.GroupKey.OrgID)
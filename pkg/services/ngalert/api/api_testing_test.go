@@ -1,18 +1,25 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/handlertest"
 	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
+	"github.com/grafana/grafana/pkg/apimachinery/identity"
+	"github.com/grafana/grafana/pkg/expr"
 	"github.com/grafana/grafana/pkg/infra/tracing"
+	"github.com/grafana/grafana/pkg/plugins"
 	ac "github.com/grafana/grafana/pkg/services/accesscontrol"
 	acMock "github.com/grafana/grafana/pkg/services/accesscontrol/mock"
 	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
@@ -28,7 +35,14 @@ import (
 	"github.com/grafana/grafana/pkg/services/ngalert/eval/eval_mocks"
 	"github.com/grafana/grafana/pkg/services/ngalert/models"
 	fakes2 "github.com/grafana/grafana/pkg/services/ngalert/tests/fakes"
+	"github.com/grafana/grafana/pkg/services/pluginsintegration/clientmiddleware"
+	"github.com/grafana/grafana/pkg/services/pluginsintegration/pluginconfig"
+	"github.com/grafana/grafana/pkg/services/pluginsintegration/plugincontext"
+	"github.com/grafana/grafana/pkg/services/pluginsintegration/pluginstore"
+	"github.com/grafana/grafana/pkg/services/queryrestriction"
+	"github.com/grafana/grafana/pkg/services/queryrestriction/queryrestrictiontest"
 	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/web"
 )
 
@@ -407,3 +421,70 @@ func createTestingApiSrv(t *testing.T, ds *fakes.FakeCacheService, ac *acMock.Mo
 		folderService:   ruleStore,
 	}
 }
+
+func TestRouteEvalQueries_QueryRestrictions(t *testing.T) {
+	ds := &datasources.DataSource{UID: "prometheus", Type: datasources.DS_PROMETHEUS}
+	dsCache := &fakes.FakeCacheService{DataSources: []*datasources.DataSource{ds}}
+	signedInUser := &user.SignedInUser{UserID: 2, OrgID: 1}
+	permissions := acMock.New().WithPermissions([]ac.Permission{
+		{Action: datasources.ActionQuery, Scope: datasources.ScopeProvider.GetResourceScopeUID(ds.UID)},
+	})
+
+	// the queries reach the data source through the query restriction middleware of the plugin client,
+	// the queries received by the data source are returned
+	setup := func(t *testing.T, restrictor queryrestriction.QueryRestrictor) (*TestingApiSrv, *[]backend.DataQuery) {
+		received := &[]backend.DataQuery{}
+		queryRestrictionService := &queryrestrictiontest.FakeService{ExpectedRestrictions: []queryrestriction.Restriction{
+			{TeamID: 1, Kind: queryrestriction.KindMetricPrefix, Pattern: "team_a_"},
+		}}
+		queryRestrictionService.RegisterQueryRestrictor(datasources.DS_PROMETHEUS, restrictor)
+		cdt := handlertest.NewHandlerMiddlewareTest(t, handlertest.WithMiddlewares(
+			clientmiddleware.NewQueryRestrictionMiddleware(queryRestrictionService),
+		))
+		cdt.TestHandler.QueryDataFunc = func(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+			resp := backend.NewQueryDataResponse()
+			for _, q := range req.Queries {
+				*received = append(*received, q)
+				frame := data.NewFrame("", data.NewField("value", nil, []float64{1}))
+				frame.RefID = q.RefID
+				resp.Responses[q.RefID] = backend.DataResponse{Frames: data.Frames{frame}}
+			}
+			return resp, nil
+		}
+		pCtxProvider := plugincontext.ProvideService(setting.NewCfg(), nil, &pluginstore.FakePluginStore{
+			PluginList: []pluginstore.Plugin{{JSONData: plugins.JSONData{ID: datasources.DS_PROMETHEUS, Type: plugins.TypeDataSource, Backend: true}}},
+		}, dsCache, &fakes.FakeDataSourceService{}, nil, pluginconfig.NewFakePluginRequestConfigProvider())
+		exprService := expr.ProvideService(&setting.Cfg{ExpressionsEnabled: true}, cdt.MiddlewareHandler, pCtxProvider, featuremgmt.WithFeatures(), nil, tracing.InitializeTracerForTest())
+		evaluator := eval.NewEvaluatorFactory(setting.UnifiedAlertingSettings{}, dsCache, exprService)
+		return createTestingApiSrv(t, dsCache, permissions, evaluator, featuremgmt.WithFeatures(), fakes2.NewRuleStore(t)), received
+	}
+	evalQuery := func(t *testing.T, srv *TestingApiSrv, expr string) (int, backend.QueryDataResponse) {
+		recorder := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/eval", nil).WithContext(identity.WithRequester(context.Background(), signedInUser))
+		rc := &contextmodel.ReqContext{Context: &web.Context{Req: req, Resp: web.NewResponseWriter(http.MethodPost, recorder)}, SignedInUser: signedInUser}
+		srv.RouteEvalQueries(rc, definitions.EvalQueriesPayload{
+			Data: ApiAlertQueriesFromAlertQueries([]models.AlertQuery{models.CreatePrometheusQuery("A", expr, 1000, 43200, true, ds.UID)}),
+			Now:  time.Now(),
+		}).WriteTo(rc)
+
+		var result backend.QueryDataResponse
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &result))
+		return recorder.Code, result
+	}
+
+	t.Run("should evaluate the queries allowed by the restrictions", func(t *testing.T) {
+		srv, received := setup(t, queryrestrictiontest.FakeQueryRestrictor{})
+		status, result := evalQuery(t, srv, "team_a_requests_total")
+		require.Equal(t, http.StatusOK, status)
+		require.NoError(t, result.Responses["A"].Error)
+		require.Len(t, *received, 1)
+	})
+
+	t.Run("should not send the queries denied by the restrictions to the data source", func(t *testing.T) {
+		srv, received := setup(t, queryrestrictiontest.FakeQueryRestrictor{ExpectedErr: errors.New("the metric node_cpu_seconds_total does not start with team_a_")})
+		_, result := evalQuery(t, srv, "node_cpu_seconds_total")
+		require.Empty(t, *received)
+		require.Error(t, result.Responses["A"].Error)
+		require.Contains(t, result.Responses["A"].Error.Error(), "the metric node_cpu_seconds_total does not start with team_a_")
+	})
+}
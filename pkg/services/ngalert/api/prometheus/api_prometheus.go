@@ -214,6 +214,58 @@ func getStatesFromQuery(v url.Values) ([]eval.State, error) {
 	return states, nil
 }
 
+// queryFilter selects the rules with queries using the datasources, and selecting the metrics and labels.
+// Each value must be matched by one of the queries of a rule.
+type queryFilter struct {
+	datasources []string
+	metrics     []string
+	labels      []string
+}
+
+func getQueryFilterFromQuery(v url.Values) queryFilter {
+	return queryFilter{
+		datasources: v["datasource_uid"],
+		metrics:     v["metric"],
+		labels:      v["label"],
+	}
+}
+
+func (f queryFilter) empty() bool {
+	return len(f.datasources) == 0 && len(f.metrics) == 0 && len(f.labels) == 0
+}
+
+func (f queryFilter) matches(rule *ngmodels.AlertRule) bool {
+	datasources := map[string]bool{}
+	metrics := map[string]bool{}
+	names := map[string]bool{}
+	for i := range rule.Data {
+		datasources[rule.Data[i].DatasourceUID] = true
+		if len(f.metrics) == 0 && len(f.labels) == 0 {
+			continue
+		}
+		info, err := rule.Data[i].GetQueryInfo()
+		if err != nil {
+			continue
+		}
+		for _, m := range info.Metrics {
+			metrics[m] = true
+		}
+		for _, l := range info.Labels {
+			names[l] = true
+		}
+	}
+	return containsAll(datasources, f.datasources) && containsAll(metrics, f.metrics) && containsAll(names, f.labels)
+}
+
+func containsAll(set map[string]bool, values []string) bool {
+	for _, v := range values {
+		if !set[v] {
+			return false
+		}
+	}
+	return true
+}
+
 type RuleGroupStatusesOptions struct {
 	Ctx                context.Context
 	OrgID              int64
@@ -350,6 +402,12 @@ func PrepareRuleGroupStatuses(log log.Logger, manager state.AlertInstanceManager
 		return ruleResponse
 	}
 
+	if filter := getQueryFilterFromQuery(opts.Query); !filter.empty() {
+		ruleList = slices.DeleteFunc(ruleList, func(rule *ngmodels.AlertRule) bool {
+			return !filter.matches(rule)
+		})
+	}
+
 	ruleNames := opts.Query["rule_name"]
 	ruleNamesSet := make(map[string]struct{}, len(ruleNames))
 	for _, rn := range ruleNames {
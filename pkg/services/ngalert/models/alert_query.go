@@ -9,6 +9,7 @@ import (
 	"github.com/grafana/grafana-plugin-sdk-go/data"
 
 	"github.com/grafana/grafana/pkg/expr"
+	"github.com/grafana/grafana/pkg/services/store/kind/query"
 )
 
 const defaultMaxDataPoints float64 = 43200 // 12 hours at 1sec interval
@@ -244,6 +245,24 @@ func (aq *AlertQuery) GetQuery() (string, error) {
 	return q, nil
 }
 
+// GetQueryInfo returns the text of the query, with the metrics and labels it selects.
+// The datasource type is read from the `datasource` property of the model.
+func (aq *AlertQuery) GetQueryInfo() (query.Info, error) {
+	if aq.modelProps == nil {
+		err := aq.setModelProps()
+		if err != nil {
+			return query.Info{}, err
+		}
+	}
+	dsType := ""
+	if expr.NodeTypeFromDatasourceUID(aq.DatasourceUID) == expr.TypeCMDNode {
+		dsType = expr.DatasourceType
+	} else if ds, ok := aq.modelProps["datasource"].(map[string]any); ok {
+		dsType, _ = ds["type"].(string)
+	}
+	return query.Read(dsType, aq.modelProps), nil
+}
+
 func (aq *AlertQuery) GetModel() ([]byte, error) {
 	err := aq.setMaxDatapoints()
 	if err != nil {
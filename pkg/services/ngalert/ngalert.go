@@ -2,8 +2,10 @@ package ngalert
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/url"
+	"slices"
 	"time"
 
 	"github.com/benbjohnson/clock"
@@ -46,6 +48,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/ngalert/writer"
 	"github.com/grafana/grafana/pkg/services/notifications"
 	"github.com/grafana/grafana/pkg/services/pluginsintegration/pluginstore"
+	"github.com/grafana/grafana/pkg/services/queryrestriction"
 	"github.com/grafana/grafana/pkg/services/quota"
 	"github.com/grafana/grafana/pkg/services/rendering"
 	"github.com/grafana/grafana/pkg/services/secrets"
@@ -80,6 +83,7 @@ func ProvideService(
 	httpClientProvider httpclient.Provider,
 	resourcePermissions accesscontrol.ReceiverPermissionsService,
 	userService user.Service,
+	queryRestrictionService queryrestriction.Service,
 ) (*AlertNG, error) {
 	ng := &AlertNG{
 		Cfg:                  cfg,
@@ -109,6 +113,8 @@ func ProvideService(
 		httpClientProvider:   httpClientProvider,
 		ResourcePermissions:  resourcePermissions,
 		userService:          userService,
+
+		queryRestrictionService: queryRestrictionService,
 	}
 
 	if ng.IsDisabled() {
@@ -164,6 +170,8 @@ type AlertNG struct {
 	bus          bus.Bus
 	pluginsStore pluginstore.Store
 	tracer       tracing.Tracer
+
+	queryRestrictionService queryrestriction.Service
 }
 
 func (ng *AlertNG) init() error {
@@ -461,11 +469,13 @@ func (ng *AlertNG) init() error {
 	contactPointService := provisioning.NewContactPointService(configStore, ng.SecretsService, ng.store, ng.store, provisioningReceiverService, ng.Log, ng.store, ng.ResourcePermissions)
 	templateService := provisioning.NewTemplateService(configStore, ng.store, ng.store, ng.Log)
 	muteTimingService := provisioning.NewMuteTimingService(configStore, ng.store, ng.store, ng.Log, ng.store)
+	ruleQueryRestrictions := ac.NewQueryRestrictionService(ng.DataSourceCache, ng.queryRestrictionService)
+	subscribeToQueryRestrictionChanges(ng.Log, ng.bus, ng.store, ng.userService, ruleQueryRestrictions)
 	alertRuleService := provisioning.NewAlertRuleService(ng.store, ng.store, ng.folderService, ng.QuotaService, ng.store,
 		int64(ng.Cfg.UnifiedAlerting.DefaultRuleEvaluationInterval.Seconds()),
 		int64(ng.Cfg.UnifiedAlerting.BaseInterval.Seconds()),
 		ng.Cfg.UnifiedAlerting.RulesPerRuleGroupLimit, ng.Log, notifier.NewNotificationSettingsValidationService(ng.store),
-		ac.NewRuleService(ng.accesscontrol))
+		ac.NewRuleService(ng.accesscontrol), ruleQueryRestrictions)
 
 	ng.Api = &api.API{
 		Cfg:                  ng.Cfg,
@@ -498,6 +508,8 @@ func (ng *AlertNG) init() error {
 		Hooks:                api.NewHooks(ng.Log),
 		Tracer:               ng.tracer,
 		UserService:          ng.userService,
+
+		RuleQueryRestrictions: ruleQueryRestrictions,
 	}
 	ng.Api.RegisterAPIEndpoints(ng.Metrics.GetAPIMetrics())
 
@@ -587,6 +599,81 @@ func subscribeToFolderChanges(logger log.Logger, bus bus.Bus, dbStore api.RuleSt
 	})
 }
 
+// subscribeToQueryRestrictionChanges pauses the rules with queries which are no longer allowed by the query restrictions
+// of their author, when the restrictions of a data source are replaced or when the author joins or leaves a team.
+// The rules are evaluated without their author. They are checked in the background, since the events are published
+// by the requests changing the restrictions or the memberships.
+func subscribeToQueryRestrictionChanges(logger log.Logger, bus bus.Bus, dbStore api.RuleStore, userService user.Service, restrictions *ac.QueryRestrictionService) {
+	bus.AddEventListener(func(ctx context.Context, evt *queryrestriction.RestrictionsUpdated) error {
+		go pauseRestrictedRules(context.WithoutCancel(ctx), logger, dbStore, userService, restrictions, evt.OrgID, func(rule *models.AlertRule) bool {
+			return slices.ContainsFunc(rule.Data, func(q models.AlertQuery) bool {
+				return q.DatasourceUID == evt.DataSourceUID
+			})
+		})
+		return nil
+	})
+	// the team memberships are the permissions of the users on the teams
+	bus.AddEventListener(func(ctx context.Context, evt *events.ResourcePermissionChanged) error {
+		if evt.Resource != "teams" || evt.UserID == 0 {
+			return nil
+		}
+		go func(ctx context.Context) {
+			member, err := userService.GetByID(ctx, &user.GetUserByIDQuery{ID: evt.UserID})
+			if err != nil {
+				logger.Error("Failed to get the team member to check the query restrictions of their alert rules", "error", err, "userID", evt.UserID)
+				return
+			}
+			pauseRestrictedRules(ctx, logger, dbStore, userService, restrictions, evt.OrgID, func(rule *models.AlertRule) bool {
+				return string(*rule.UpdatedBy) == member.UID
+			})
+		}(context.WithoutCancel(ctx))
+		return nil
+	})
+}
+
+// pauseRestrictedRules pauses the rules of the org selected by the filter with queries which are not allowed by the
+// query restrictions of their author
+func pauseRestrictedRules(ctx context.Context, logger log.Logger, dbStore api.RuleStore, userService user.Service, restrictions *ac.QueryRestrictionService, orgID int64, filter func(rule *models.AlertRule) bool) {
+	rules, err := dbStore.ListAlertRules(ctx, &models.ListAlertRulesQuery{OrgID: orgID})
+	if err != nil {
+		logger.Error("Failed to list the alert rules to check their query restrictions", "error", err, "orgID", orgID)
+		return
+	}
+	for _, rule := range rules {
+		if rule.IsPaused || rule.UpdatedBy == nil || !filter(rule) {
+			continue
+		}
+		author, err := userService.GetByUID(ctx, &user.GetUserByUIDQuery{UID: string(*rule.UpdatedBy)})
+		if err != nil {
+			// the rules saved by the alerting or the provisioning have no author
+			if !errors.Is(err, user.ErrUserNotFound) {
+				logger.Error("Failed to get the author of the alert rule", "error", err, "rule", rule.GetKey())
+			}
+			continue
+		}
+		requester, err := userService.GetSignedInUser(ctx, &user.GetSignedInUserQuery{UserID: author.ID, OrgID: orgID})
+		if err != nil {
+			logger.Error("Failed to get the author of the alert rule", "error", err, "rule", rule.GetKey())
+			continue
+		}
+		err = restrictions.AuthorizeRuleQueries(ctx, requester, rule)
+		if err == nil {
+			continue
+		}
+		if !errors.Is(err, queryrestriction.ErrQueryRestricted) {
+			logger.Error("Failed to check the queries of the alert rule", "error", err, "rule", rule.GetKey())
+			continue
+		}
+		paused := rule.Copy()
+		paused.IsPaused = true
+		if err := dbStore.UpdateAlertRules(ctx, rule.UpdatedBy, []models.UpdateRule{{Existing: rule, New: *paused}}); err != nil {
+			logger.Error("Failed to pause the alert rule", "error", err, "rule", rule.GetKey())
+			continue
+		}
+		logger.Warn("Paused the alert rule, its queries are not allowed by the query restrictions of its author", "rule", rule.GetKey(), "reason", err)
+	}
+}
+
 // Run starts the scheduler and Alertmanager.
 func (ng *AlertNG) Run(ctx context.Context) error {
 	ng.Log.Debug("Starting", "execute_alerts", ng.Cfg.UnifiedAlerting.ExecuteAlerts)
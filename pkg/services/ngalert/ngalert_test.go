@@ -3,6 +3,8 @@ package ngalert
 import (
 	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"math/rand"
 	"testing"
 	"time"
@@ -17,14 +19,21 @@ import (
 	"github.com/grafana/grafana/pkg/infra/db"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/infra/tracing"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	dsfakes "github.com/grafana/grafana/pkg/services/datasources/fakes"
 	"github.com/grafana/grafana/pkg/services/featuremgmt"
 	"github.com/grafana/grafana/pkg/services/folder"
+	ac "github.com/grafana/grafana/pkg/services/ngalert/accesscontrol"
 	acfakes "github.com/grafana/grafana/pkg/services/ngalert/accesscontrol/fakes"
 	"github.com/grafana/grafana/pkg/services/ngalert/metrics"
 	"github.com/grafana/grafana/pkg/services/ngalert/models"
 	"github.com/grafana/grafana/pkg/services/ngalert/state"
 	"github.com/grafana/grafana/pkg/services/ngalert/store"
 	"github.com/grafana/grafana/pkg/services/ngalert/tests/fakes"
+	"github.com/grafana/grafana/pkg/services/queryrestriction"
+	"github.com/grafana/grafana/pkg/services/queryrestriction/queryrestrictiontest"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/services/user/usertest"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/util"
 )
@@ -79,6 +88,68 @@ func Test_subscribeToFolderChanges(t *testing.T) {
 	}, time.Second, 10*time.Millisecond, "expected to call db store method but nothing was called")
 }
 
+func Test_subscribeToQueryRestrictionChanges(t *testing.T) {
+	orgID := rand.Int63()
+	author := models.UserUID("author")
+	gen := models.RuleGen
+	query := models.GenerateAlertQuery()
+	query.DatasourceUID = "loki"
+	query.Model = json.RawMessage(`{"refId": "` + query.RefID + `", "expr": "{app=\"api\"}"}`)
+	restricted := gen.With(gen.WithOrgID(orgID), gen.WithQuery(query), gen.WithIsPaused(false), gen.WithUpdatedBy(&author)).GenerateRef()
+	other := gen.With(gen.WithOrgID(orgID), gen.WithIsPaused(false), gen.WithUpdatedBy(&author)).GenerateRef()
+
+	setup := func(t *testing.T) (bus.Bus, *fakes.RuleStore) {
+		bus := bus.ProvideBus(tracing.InitializeTracerForTest())
+		db := fakes.NewRuleStore(t)
+		db.PutRule(context.Background(), models.CopyRule(restricted), models.CopyRule(other))
+
+		queryRestrictionService := &queryrestrictiontest.FakeService{ExpectedRestrictions: []queryrestriction.Restriction{
+			{TeamID: 1, Kind: queryrestriction.KindStreamSelector, Pattern: `{namespace="team-a"}`},
+		}}
+		queryRestrictionService.RegisterQueryRestrictor(datasources.DS_LOKI, queryrestrictiontest.FakeQueryRestrictor{ExpectedErr: errors.New("restricted")})
+		userService := &usertest.FakeUserService{
+			ExpectedUser:         &user.User{ID: 2, UID: string(author)},
+			ExpectedSignedInUser: &user.SignedInUser{UserID: 2, OrgID: orgID},
+		}
+		restrictions := ac.NewQueryRestrictionService(
+			&dsfakes.FakeCacheService{DataSources: []*datasources.DataSource{{UID: "loki", Type: datasources.DS_LOKI}}},
+			queryRestrictionService,
+		)
+
+		subscribeToQueryRestrictionChanges(log.New("test"), bus, db, userService, restrictions)
+		return bus, db
+	}
+
+	requirePaused := func(t *testing.T, db *fakes.RuleStore) {
+		t.Helper()
+		require.EventuallyWithT(t, func(c *assert.CollectT) {
+			updates := db.GetRecordedCommands(func(cmd any) (any, bool) {
+				c, ok := cmd.([]models.UpdateRule)
+				return c, ok
+			})
+			require.Len(c, updates, 1)
+			update := updates[0].([]models.UpdateRule)
+			require.Len(c, update, 1)
+			require.Equal(c, restricted.UID, update[0].New.UID)
+			require.True(c, update[0].New.IsPaused)
+		}, time.Second, 10*time.Millisecond)
+	}
+
+	t.Run("pauses the rules when the restrictions of their data source are updated", func(t *testing.T) {
+		bus, db := setup(t)
+		err := bus.Publish(context.Background(), &queryrestriction.RestrictionsUpdated{OrgID: orgID, DataSourceUID: "loki"})
+		require.NoError(t, err)
+		requirePaused(t, db)
+	})
+
+	t.Run("pauses the rules when the team memberships of their author change", func(t *testing.T) {
+		bus, db := setup(t)
+		err := bus.Publish(context.Background(), &events.ResourcePermissionChanged{OrgID: orgID, Resource: "teams", ResourceID: "1", Permission: "Member", UserID: 2})
+		require.NoError(t, err)
+		requirePaused(t, db)
+	})
+}
+
 func TestConfigureHistorianBackend(t *testing.T) {
 	t.Run("fail initialization if invalid backend", func(t *testing.T) {
 		met := metrics.NewHistorianMetrics(prometheus.NewRegistry(), metrics.Subsystem)
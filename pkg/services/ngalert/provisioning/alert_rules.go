@@ -39,6 +39,12 @@ type NotificationSettingsValidatorProvider interface {
 	Validator(ctx context.Context, orgID int64) (notifier.NotificationSettingsValidator, error)
 }
 
+// QueryRestrictionAuthorizer checks the queries of the new and updated rules against the query restrictions of the user
+type QueryRestrictionAuthorizer interface {
+	AuthorizeRuleQueries(ctx context.Context, user identity.Requester, rules ...*models.AlertRule) error
+	AuthorizeRuleChanges(ctx context.Context, user identity.Requester, change *store.GroupDelta) error
+}
+
 type AlertRuleService struct {
 	defaultIntervalSeconds int64
 	baseIntervalSeconds    int64
@@ -51,6 +57,7 @@ type AlertRuleService struct {
 	log                    log.Logger
 	nsValidatorProvider    NotificationSettingsValidatorProvider
 	authz                  ruleAccessControlService
+	queryRestrictions      QueryRestrictionAuthorizer
 }
 
 func NewAlertRuleService(ruleStore RuleStore,
@@ -64,6 +71,7 @@ func NewAlertRuleService(ruleStore RuleStore,
 	log log.Logger,
 	ns NotificationSettingsValidatorProvider,
 	authz RuleAccessControlService,
+	queryRestrictions QueryRestrictionAuthorizer,
 ) *AlertRuleService {
 	return &AlertRuleService{
 		defaultIntervalSeconds: defaultIntervalSeconds,
@@ -77,6 +85,7 @@ func NewAlertRuleService(ruleStore RuleStore,
 		log:                    log,
 		nsValidatorProvider:    ns,
 		authz:                  newRuleAccessControlService(authz),
+		queryRestrictions:      queryRestrictions,
 	}
 }
 
@@ -237,6 +246,9 @@ func (service *AlertRuleService) CreateAlertRule(ctx context.Context, user ident
 			}
 		}
 	}
+	if err := service.queryRestrictions.AuthorizeRuleQueries(ctx, user, &rule); err != nil {
+		return models.AlertRule{}, err
+	}
 	err = service.xact.InTransaction(ctx, func(ctx context.Context) error {
 		ids, err := service.ruleStore.InsertAlertRules(ctx, userUidOrFallback(user), []models.AlertRule{
 			rule,
@@ -551,6 +563,9 @@ func (service *AlertRuleService) calcDelta(ctx context.Context, user identity.Re
 }
 
 func (service *AlertRuleService) persistDelta(ctx context.Context, user identity.Requester, delta *store.GroupDelta, provenance models.Provenance) error {
+	if err := service.queryRestrictions.AuthorizeRuleChanges(ctx, user, delta); err != nil {
+		return err
+	}
 	return service.xact.InTransaction(ctx, func(ctx context.Context) error {
 		// Delete first as this could prevent future unique constraint violations.
 		if len(delta.Delete) > 0 {
@@ -692,6 +707,9 @@ func (service *AlertRuleService) UpdateAlertRule(ctx context.Context, user ident
 			}
 		}
 	}
+	if err := service.queryRestrictions.AuthorizeRuleQueries(ctx, user, &rule); err != nil {
+		return models.AlertRule{}, err
+	}
 	rule.Updated = time.Now()
 	rule.ID = storedRule.ID
 	rule.IntervalSeconds = storedRule.IntervalSeconds
@@ -20,6 +20,8 @@ import (
 	"github.com/grafana/grafana/pkg/services/apiserver"
 	"github.com/grafana/grafana/pkg/services/ngalert/accesscontrol"
 	"github.com/grafana/grafana/pkg/services/ngalert/tests/fakes"
+	"github.com/grafana/grafana/pkg/services/queryrestriction"
+	"github.com/grafana/grafana/pkg/services/queryrestriction/queryrestrictiontest"
 	"github.com/grafana/grafana/pkg/services/search/sort"
 	"github.com/grafana/grafana/pkg/services/supportbundles/supportbundlestest"
 	"github.com/grafana/grafana/pkg/services/user"
@@ -31,6 +33,8 @@ import (
 	"github.com/grafana/grafana/pkg/infra/tracing"
 	acmock "github.com/grafana/grafana/pkg/services/accesscontrol/mock"
 	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	dsfakes "github.com/grafana/grafana/pkg/services/datasources/fakes"
 	"github.com/grafana/grafana/pkg/services/featuremgmt"
 	"github.com/grafana/grafana/pkg/services/folder"
 	"github.com/grafana/grafana/pkg/services/folder/folderimpl"
@@ -1672,6 +1676,70 @@ func TestReplaceGroup(t *testing.T) {
 	})
 }
 
+func TestQueryRestrictions(t *testing.T) {
+	orgID := rand.Int63()
+	u := &user.SignedInUser{OrgID: orgID, UserID: 2}
+	groupKey := models.GenerateGroupKey(orgID)
+	gen := models.RuleGen
+	query := models.GenerateAlertQuery()
+	query.DatasourceUID = "loki"
+	query.Model = json.RawMessage(`{"refId": "` + query.RefID + `", "expr": "{app=\"api\"}"}`)
+
+	initServiceWithRestrictions := func(t *testing.T) (*AlertRuleService, *fakes.RuleStore) {
+		service, ruleStore, _, ac := initService(t)
+		ac.CanWriteAllRulesFunc = func(ctx context.Context, user identity.Requester) (bool, error) {
+			return true, nil
+		}
+		queryRestrictionService := &queryrestrictiontest.FakeService{ExpectedRestrictions: []queryrestriction.Restriction{
+			{TeamID: 1, Kind: queryrestriction.KindStreamSelector, Pattern: `{namespace="team-a"}`},
+		}}
+		queryRestrictionService.RegisterQueryRestrictor(datasources.DS_LOKI, queryrestrictiontest.FakeQueryRestrictor{ExpectedErr: errors.New("restricted")})
+		service.queryRestrictions = accesscontrol.NewQueryRestrictionService(
+			&dsfakes.FakeCacheService{DataSources: []*datasources.DataSource{{UID: "loki", Type: datasources.DS_LOKI}}},
+			queryRestrictionService,
+		)
+		return service, ruleStore
+	}
+
+	t.Run("should not create a rule with restricted queries", func(t *testing.T) {
+		service, ruleStore := initServiceWithRestrictions(t)
+		rule := gen.With(gen.WithGroupKey(groupKey), gen.WithQuery(query), gen.WithIsPaused(false)).Generate()
+
+		_, err := service.CreateAlertRule(context.Background(), u, rule, models.ProvenanceAPI)
+		require.ErrorIs(t, err, queryrestriction.ErrQueryRestricted)
+		require.Empty(t, ruleStore.GetRecordedCommands(func(cmd any) (any, bool) {
+			a, ok := cmd.([]models.AlertRule)
+			return a, ok
+		}))
+	})
+
+	t.Run("should not replace a rule group with restricted queries", func(t *testing.T) {
+		service, ruleStore := initServiceWithRestrictions(t)
+		rule := gen.With(gen.WithGroupKey(groupKey), gen.WithQuery(query), gen.WithIsPaused(false)).Generate()
+		group := models.AlertRuleGroup{
+			Title:     groupKey.RuleGroup,
+			FolderUID: groupKey.NamespaceUID,
+			Interval:  60,
+			Rules:     []models.AlertRule{rule},
+		}
+
+		err := service.ReplaceRuleGroup(context.Background(), u, group, models.ProvenanceFile)
+		require.ErrorIs(t, err, queryrestriction.ErrQueryRestricted)
+		require.Empty(t, ruleStore.GetRecordedCommands(func(cmd any) (any, bool) {
+			a, ok := cmd.([]models.AlertRule)
+			return a, ok
+		}))
+	})
+
+	t.Run("should create a paused rule with restricted queries", func(t *testing.T) {
+		service, _ := initServiceWithRestrictions(t)
+		rule := gen.With(gen.WithGroupKey(groupKey), gen.WithQuery(query), gen.WithIsPaused(true)).Generate()
+
+		_, err := service.CreateAlertRule(context.Background(), u, rule, models.ProvenanceAPI)
+		require.NoError(t, err)
+	})
+}
+
 func TestDeleteRuleGroup(t *testing.T) {
 	orgID := rand.Int63()
 	u := &user.SignedInUser{OrgID: orgID}
@@ -2148,6 +2216,7 @@ func createAlertRuleService(t *testing.T, folderService folder.Service) AlertRul
 		folderService:          folderService,
 		authz:                  &fakeRuleAccessControlService{},
 		nsValidatorProvider:    &NotificationSettingsValidatorProviderFake{},
+		queryRestrictions:      accesscontrol.NewQueryRestrictionService(&dsfakes.FakeCacheService{}, &queryrestrictiontest.FakeService{}),
 	}
 }
 
@@ -2214,6 +2283,7 @@ func initService(t *testing.T) (*AlertRuleService, *fakes.RuleStore, *fakes.Fake
 		defaultIntervalSeconds: 60,
 		authz:                  ac,
 		nsValidatorProvider:    &NotificationSettingsValidatorProviderFake{},
+		queryRestrictions:      accesscontrol.NewQueryRestrictionService(&dsfakes.FakeCacheService{}, &queryrestrictiontest.FakeService{}),
 	}
 
 	return service, ruleStore, provenanceStore, ac
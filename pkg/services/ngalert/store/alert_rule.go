@@ -56,6 +56,7 @@ func (st DBstore) DeleteAlertRulesByUID(ctx context.Context, orgID int64, user *
 			}
 			_ = st.Bus.Publish(ctx, &RuleChangeEvent{
 				RuleKeys: keys,
+				Type:     RuleChangeDelete,
 			})
 		}
 
@@ -394,6 +395,7 @@ func (st DBstore) InsertAlertRules(ctx context.Context, user *ngmodels.UserUID,
 		if len(keys) > 0 {
 			_ = st.Bus.Publish(ctx, &RuleChangeEvent{
 				RuleKeys: keys,
+				Type:     RuleChangeCreate,
 			})
 		}
 		return nil
@@ -465,6 +467,7 @@ func (st DBstore) UpdateAlertRules(ctx context.Context, user *ngmodels.UserUID,
 		if len(keys) > 0 {
 			_ = st.Bus.Publish(ctx, &RuleChangeEvent{
 				RuleKeys: keys,
+				Type:     RuleChangeUpdate,
 			})
 		}
 		return nil
@@ -135,6 +135,7 @@ func TestIntegrationUpdateAlertRules(t *testing.T) {
 			require.NotNil(t, event)
 			require.Len(t, event.RuleKeys, 1)
 			require.Equal(t, rule.GetKey(), event.RuleKeys[0])
+			require.Equal(t, RuleChangeUpdate, event.Type)
 			called = true
 			return nil
 		}
@@ -492,6 +493,7 @@ func TestIntegration_DeleteAlertRulesByUID(t *testing.T) {
 			require.NotNil(t, event)
 			require.Len(t, event.RuleKeys, 1)
 			require.Equal(t, rule.GetKey(), event.RuleKeys[0])
+			require.Equal(t, RuleChangeDelete, event.Type)
 			called = true
 			return nil
 		}
@@ -854,6 +856,7 @@ func TestIntegrationInsertAlertRules(t *testing.T) {
 			require.NotNil(t, event)
 			require.Len(t, event.RuleKeys, 1)
 			require.Equal(t, rule.GetKey(), event.RuleKeys[0])
+			require.Equal(t, RuleChangeCreate, event.Type)
 			called = true
 			return nil
 		}
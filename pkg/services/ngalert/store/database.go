@@ -70,6 +70,16 @@ func ProvideDBStore(
 	return &store, nil
 }
 
+// RuleChangeType is the kind of change done to the rules of a RuleChangeEvent
+type RuleChangeType string
+
+const (
+	RuleChangeCreate RuleChangeType = "create"
+	RuleChangeUpdate RuleChangeType = "update"
+	RuleChangeDelete RuleChangeType = "delete"
+)
+
 type RuleChangeEvent struct {
 	RuleKeys []models.AlertRuleKey
+	Type     RuleChangeType
 }
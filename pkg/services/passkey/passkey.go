@@ -0,0 +1,36 @@
+package passkey
+
+import (
+	"context"
+	"time"
+
+	"github.com/grafana/grafana/pkg/apimachinery/errutil"
+)
+
+var (
+	ErrNotFound          = errutil.NotFound("passkey.not-found", errutil.WithPublicMessage("Passkey not found"))
+	ErrInvalidChallenge  = errutil.Unauthorized("passkey.invalid-challenge", errutil.WithPublicMessage("The passkey request has expired, try again"))
+	ErrInvalidCredential = errutil.Unauthorized("passkey.invalid-credential", errutil.WithPublicMessage("Invalid passkey"))
+	ErrAlreadyRegistered = errutil.Conflict("passkey.already-registered", errutil.WithPublicMessage("The passkey is already registered"))
+	ErrSignInRequired    = errutil.Forbidden("passkey.sign-in-required", errutil.WithPublicMessage("Sign in again to register a passkey"))
+	ErrPasswordDisabled  = errutil.Unauthorized("passkey.password-disabled", errutil.WithPublicMessage("Sign in with your passkey"))
+	ErrPasskeyRequired   = errutil.Unauthorized("passkey.required", errutil.WithPublicMessage("A passkey is required, ask a Grafana server admin for a new grace period to register one"))
+)
+
+type Service interface {
+	// FinishLogin verifies the assertion of a passkey for a challenge returned by the login options,
+	// and returns the id of the user of the passkey. The credential is the JSON of the PublicKeyCredential
+	// returned by navigator.credentials.get().
+	FinishLogin(ctx context.Context, credential []byte) (int64, error)
+}
+
+// Credential is a passkey registered by a user
+type Credential struct {
+	UID        string     `json:"uid"`
+	Name       string     `json:"name"`
+	AAGUID     string     `json:"aaguid"`
+	Transports []string   `json:"transports"`
+	BackedUp   bool       `json:"backedUp"`
+	Created    time.Time  `json:"created"`
+	LastUsed   *time.Time `json:"lastUsed"`
+}
@@ -0,0 +1,143 @@
+package passkeyimpl
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/middleware"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+type api struct {
+	service *Service
+}
+
+func registerAPI(routeRegister routing.RouteRegister, s *Service) {
+	a := &api{service: s}
+	routeRegister.Group("/api/user/passkeys", func(r routing.RouteRegister) {
+		r.Get("/", routing.Wrap(a.list))
+		r.Post("/register/begin", routing.Wrap(a.beginRegistration))
+		r.Post("/register/finish", routing.Wrap(a.finishRegistration))
+		r.Delete("/:uid", routing.Wrap(a.delete))
+	}, middleware.ReqSignedInNoAnonymous)
+	routeRegister.Post("/api/login/passkey/begin", routing.Wrap(a.beginLogin))
+	routeRegister.Group("/api/admin/users/:id/passkeys", func(r routing.RouteRegister) {
+		r.Get("/", routing.Wrap(a.adminList))
+		r.Delete("/:uid", routing.Wrap(a.adminDelete))
+		r.Post("/grace-period", routing.Wrap(a.adminRestartGracePeriod))
+	}, middleware.ReqGrafanaAdmin)
+}
+
+// RegistrationCommand is the body of the end of a registration, with the credential created by the browser
+type RegistrationCommand struct {
+	Name string `json:"name"`
+	// The JSON of the PublicKeyCredential returned by navigator.credentials.create()
+	Credential json.RawMessage `json:"credential" binding:"Required"`
+}
+
+func (a *api) registrationUser(c *contextmodel.ReqContext) RegistrationUser {
+	user := RegistrationUser{
+		ID:          c.SignedInUser.UserID,
+		UID:         c.SignedInUser.UserUID,
+		Login:       c.SignedInUser.GetLogin(),
+		DisplayName: c.SignedInUser.GetName(),
+	}
+	// The rotations of the session keep its creation time, the time of the login.
+	// Without session, e.g. with basic auth, the registrations are rejected.
+	if c.UserToken != nil {
+		user.SignedInAt = time.Unix(c.UserToken.CreatedAt, 0)
+	}
+	return user
+}
+
+// GET /api/user/passkeys
+func (a *api) list(c *contextmodel.ReqContext) response.Response {
+	credentials, err := a.service.List(c.Req.Context(), c.SignedInUser.UserID)
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to list passkeys", err)
+	}
+	return response.JSON(http.StatusOK, credentials)
+}
+
+// POST /api/user/passkeys/register/begin
+func (a *api) beginRegistration(c *contextmodel.ReqContext) response.Response {
+	options, err := a.service.BeginRegistration(c.Req.Context(), a.registrationUser(c))
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to start the passkey registration", err)
+	}
+	return response.JSON(http.StatusOK, options)
+}
+
+// POST /api/user/passkeys/register/finish
+func (a *api) finishRegistration(c *contextmodel.ReqContext) response.Response {
+	cmd := RegistrationCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	credential, err := a.service.FinishRegistration(c.Req.Context(), a.registrationUser(c), cmd.Name, cmd.Credential)
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to register the passkey", err)
+	}
+	return response.JSON(http.StatusOK, credential)
+}
+
+// DELETE /api/user/passkeys/:uid
+func (a *api) delete(c *contextmodel.ReqContext) response.Response {
+	if err := a.service.Delete(c.Req.Context(), c.SignedInUser.UserID, web.Params(c.Req)[":uid"]); err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to delete the passkey", err)
+	}
+	return response.Success("Passkey deleted")
+}
+
+// POST /api/login/passkey/begin
+func (a *api) beginLogin(c *contextmodel.ReqContext) response.Response {
+	options, err := a.service.BeginLogin(c.Req.Context())
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to start the passkey login", err)
+	}
+	return response.JSON(http.StatusOK, options)
+}
+
+// GET /api/admin/users/:id/passkeys
+func (a *api) adminList(c *contextmodel.ReqContext) response.Response {
+	userID, err := strconv.ParseInt(web.Params(c.Req)[":id"], 10, 64)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "id is invalid", err)
+	}
+	credentials, err := a.service.List(c.Req.Context(), userID)
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to list passkeys", err)
+	}
+	return response.JSON(http.StatusOK, credentials)
+}
+
+// DELETE /api/admin/users/:id/passkeys/:uid
+func (a *api) adminDelete(c *contextmodel.ReqContext) response.Response {
+	userID, err := strconv.ParseInt(web.Params(c.Req)[":id"], 10, 64)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "id is invalid", err)
+	}
+	if err := a.service.Delete(c.Req.Context(), userID, web.Params(c.Req)[":uid"]); err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to delete the passkey", err)
+	}
+	c.Logger.Info("Passkey deleted", "userId", userID, "passkey", web.Params(c.Req)[":uid"], "by", c.SignedInUser.GetID())
+	return response.Success("Passkey deleted")
+}
+
+// POST /api/admin/users/:id/passkeys/grace-period
+func (a *api) adminRestartGracePeriod(c *contextmodel.ReqContext) response.Response {
+	userID, err := strconv.ParseInt(web.Params(c.Req)[":id"], 10, 64)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "id is invalid", err)
+	}
+	if err := a.service.RestartGracePeriod(c.Req.Context(), userID); err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to restart the grace period", err)
+	}
+	c.Logger.Info("Passkey grace period restarted", "userId", userID, "by", c.SignedInUser.GetID())
+	return response.Success("Grace period restarted")
+}
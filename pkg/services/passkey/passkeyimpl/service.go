@@ -0,0 +1,414 @@
+package passkeyimpl
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/kvstore"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/remotecache"
+	"github.com/grafana/grafana/pkg/services/authn"
+	"github.com/grafana/grafana/pkg/services/login"
+	"github.com/grafana/grafana/pkg/services/passkey"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+const (
+	registrationKeyPrefix = "passkey-registration-"
+	loginKeyPrefix        = "passkey-login-"
+	// Priority of the post auth hook rejecting the passwords, before the second factor is asked
+	hookPriority = 104
+)
+
+var _ passkey.Service = new(Service)
+
+type Service struct {
+	cfg setting.AuthPasskeySettings
+	// The relying party of the WebAuthn ceremonies, the configuration is validated by the first ceremony
+	webauthn *webauthn.WebAuthn
+	store    *store
+	cache    remotecache.CacheStorage
+	// The start of the grace period of the users without passkey, by user ID
+	grace *kvstore.NamespacedKVStore
+	now   func() time.Time
+	log   log.Logger
+}
+
+func ProvideService(cfg *setting.Cfg, sqlStore db.DB, cache *remotecache.RemoteCache, authnService authn.Service, routeRegister routing.RouteRegister) *Service {
+	s := NewService(cfg.PasskeyAuth, sqlStore, cache)
+	if cfg.PasskeyAuth.Enabled {
+		if cfg.PasskeyAuth.PasskeyOnly {
+			authnService.RegisterPostAuthHook(s.passwordHook, hookPriority)
+		}
+		registerAPI(routeRegister, s)
+	}
+	return s
+}
+
+func NewService(cfg setting.AuthPasskeySettings, sqlStore db.DB, cache remotecache.CacheStorage) *Service {
+	timeout := webauthn.TimeoutConfig{Enforce: true, Timeout: cfg.ChallengeExpiration, TimeoutUVD: cfg.ChallengeExpiration}
+	return &Service{
+		cfg: cfg,
+		webauthn: &webauthn.WebAuthn{Config: &webauthn.Config{
+			RPID:          cfg.RelyingPartyID,
+			RPDisplayName: cfg.RelyingPartyName,
+			RPOrigins:     cfg.Origins,
+			// The attestation statements are not verified, the authenticators are trusted
+			AttestationPreference: protocol.PreferNoAttestation,
+			// The passkeys must be discoverable, the login does not ask for the username
+			AuthenticatorSelection: protocol.AuthenticatorSelection{
+				ResidentKey:        protocol.ResidentKeyRequirementRequired,
+				RequireResidentKey: protocol.ResidentKeyRequired(),
+				UserVerification:   protocol.UserVerificationRequirement(cfg.UserVerification),
+			},
+			Timeouts: webauthn.TimeoutsConfig{Login: timeout, Registration: timeout},
+		}},
+		store: &store{sqlStore: sqlStore},
+		cache: cache,
+		grace: kvstore.WithNamespace(kvstore.ProvideService(sqlStore), 0, "passkey-grace-period"),
+		now:   time.Now,
+		log:   log.New("passkey"),
+	}
+}
+
+// RegistrationUser is the user registering a passkey
+type RegistrationUser struct {
+	ID          int64
+	UID         string
+	Login       string
+	DisplayName string
+	// When the user signed in, the registrations need a recent login
+	SignedInAt time.Time
+}
+
+// webauthnUser is the user of the WebAuthn ceremonies, identified by the UID of the Grafana user
+type webauthnUser struct {
+	handle      string
+	name        string
+	displayName string
+	credentials []webauthn.Credential
+}
+
+func (u *webauthnUser) WebAuthnID() []byte {
+	return []byte(u.handle)
+}
+
+func (u *webauthnUser) WebAuthnName() string {
+	return u.name
+}
+
+func (u *webauthnUser) WebAuthnDisplayName() string {
+	return u.displayName
+}
+
+func (u *webauthnUser) WebAuthnCredentials() []webauthn.Credential {
+	return u.credentials
+}
+
+// BeginRegistration returns the options to create a passkey for a user, with a challenge valid for a single registration
+func (s *Service) BeginRegistration(ctx context.Context, user RegistrationUser) (*protocol.CredentialCreation, error) {
+	if err := s.checkRecentLogin(user); err != nil {
+		return nil, err
+	}
+	rows, err := s.store.list(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	exclusions := make([]protocol.CredentialDescriptor, 0, len(rows))
+	for _, row := range rows {
+		credential, err := toWebAuthnCredential(row)
+		if err != nil {
+			return nil, err
+		}
+		exclusions = append(exclusions, credential.Descriptor())
+	}
+
+	options, session, err := s.webauthn.BeginRegistration(&webauthnUser{handle: user.UID, name: user.Login, displayName: user.DisplayName},
+		webauthn.WithExclusions(exclusions))
+	if err != nil {
+		return nil, err
+	}
+	if err := s.saveSession(ctx, fmt.Sprintf("%s%d", registrationKeyPrefix, user.ID), session); err != nil {
+		return nil, err
+	}
+	return options, nil
+}
+
+// FinishRegistration checks a passkey created with the options of BeginRegistration, and saves it.
+// The credential is the JSON of the PublicKeyCredential returned by navigator.credentials.create().
+func (s *Service) FinishRegistration(ctx context.Context, user RegistrationUser, name string, credential []byte) (*passkey.Credential, error) {
+	if err := s.checkRecentLogin(user); err != nil {
+		return nil, err
+	}
+	session, err := s.useSession(ctx, fmt.Sprintf("%s%d", registrationKeyPrefix, user.ID))
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := protocol.ParseCredentialCreationResponseBytes(credential)
+	if err != nil {
+		return nil, passkey.ErrInvalidCredential.Errorf("failed to parse the registration: %w", verificationError(err))
+	}
+	verified, err := s.webauthn.CreateCredential(&webauthnUser{handle: user.UID}, *session, parsed)
+	if err != nil {
+		return nil, passkey.ErrInvalidCredential.Errorf("failed to verify the registration: %w", verificationError(err))
+	}
+
+	if name = strings.TrimSpace(name); name == "" {
+		name = "Passkey"
+	}
+	transports := make([]string, 0, len(verified.Transport))
+	for _, transport := range verified.Transport {
+		transports = append(transports, string(transport))
+	}
+	row := &userPasskey{
+		UID:              util.GenerateShortUID(),
+		UserID:           user.ID,
+		UserHandle:       user.UID,
+		Name:             name,
+		CredentialID:     base64.RawURLEncoding.EncodeToString(verified.ID),
+		CredentialIDHash: credentialIDHash(verified.ID),
+		PublicKey:        base64.RawURLEncoding.EncodeToString(verified.PublicKey),
+		SignCount:        int64(verified.Authenticator.SignCount),
+		AAGUID:           formatAAGUID(verified.Authenticator.AAGUID),
+		Transports:       strings.Join(transports, ","),
+		BackupEligible:   verified.Flags.BackupEligible,
+		BackedUp:         verified.Flags.BackupState,
+		Created:          s.now(),
+	}
+	if err := s.store.create(ctx, row); err != nil {
+		return nil, err
+	}
+	s.log.FromContext(ctx).Info("Passkey registered", "userId", user.ID, "passkey", row.UID)
+	return toCredential(row), nil
+}
+
+// BeginLogin returns the options to sign in with a passkey, with a challenge valid for a single login
+func (s *Service) BeginLogin(ctx context.Context) (*protocol.CredentialAssertion, error) {
+	options, session, err := s.webauthn.BeginDiscoverableLogin()
+	if err != nil {
+		return nil, err
+	}
+	// The login does not know the user yet, the challenge is its own key
+	if err := s.saveSession(ctx, loginKeyPrefix+session.Challenge, session); err != nil {
+		return nil, err
+	}
+	return options, nil
+}
+
+func (s *Service) FinishLogin(ctx context.Context, credential []byte) (int64, error) {
+	parsed, err := protocol.ParseCredentialRequestResponseBytes(credential)
+	if err != nil {
+		return 0, passkey.ErrInvalidCredential.Errorf("failed to parse the assertion: %w", verificationError(err))
+	}
+	session, err := s.useSession(ctx, loginKeyPrefix+parsed.Response.CollectedClientData.Challenge)
+	if err != nil {
+		return 0, err
+	}
+
+	var row *userPasskey
+	var lookupErr error
+	_, verified, err := s.webauthn.ValidatePasskeyLogin(func(rawID, userHandle []byte) (webauthn.User, error) {
+		// The user handle is checked against the handle of the passkey by the validation
+		if row, lookupErr = s.store.getByCredentialID(ctx, rawID); lookupErr != nil {
+			return nil, lookupErr
+		}
+		credential, err := toWebAuthnCredential(row)
+		if err != nil {
+			return nil, err
+		}
+		return &webauthnUser{handle: row.UserHandle, credentials: []webauthn.Credential{*credential}}, nil
+	}, *session, parsed)
+	if lookupErr != nil {
+		if errors.Is(lookupErr, passkey.ErrNotFound) {
+			return 0, passkey.ErrInvalidCredential.Errorf("unknown credential")
+		}
+		return 0, lookupErr
+	}
+	if err == nil && verified.Authenticator.CloneWarning {
+		// The authenticators without counter always return zero, a counter going back reveals a cloned authenticator
+		err = fmt.Errorf("the signature counter went back from %d, the authenticator may be cloned", row.SignCount)
+	}
+	if err != nil {
+		err = verificationError(err)
+		if row != nil {
+			s.log.FromContext(ctx).Warn("Failed to verify passkey", "userId", row.UserID, "passkey", row.UID, "error", err)
+		}
+		return 0, passkey.ErrInvalidCredential.Errorf("failed to verify the assertion: %w", err)
+	}
+	if err := s.store.markUsed(ctx, row.ID, row.SignCount, int64(verified.Authenticator.SignCount), s.now()); err != nil {
+		if errors.Is(err, passkey.ErrInvalidCredential) {
+			s.log.FromContext(ctx).Warn("Passkey used by concurrent logins, the authenticator may be cloned", "userId", row.UserID, "passkey", row.UID)
+		}
+		return 0, err
+	}
+	return row.UserID, nil
+}
+
+// List returns the passkeys of a user
+func (s *Service) List(ctx context.Context, userID int64) ([]*passkey.Credential, error) {
+	rows, err := s.store.list(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*passkey.Credential, 0, len(rows))
+	for _, row := range rows {
+		result = append(result, toCredential(row))
+	}
+	return result, nil
+}
+
+// Delete removes a passkey of a user
+func (s *Service) Delete(ctx context.Context, userID int64, uid string) error {
+	return s.store.delete(ctx, userID, uid)
+}
+
+// passwordHook rejects the password logins of the Grafana users when the passkeys are the only allowed login.
+// The users without passkey can sign in with their password to register one during the grace period,
+// which starts with their first password login.
+func (s *Service) passwordHook(ctx context.Context, id *authn.Identity, r *authn.Request) error {
+	if r.GetMeta(authn.MetaKeyUsername) == "" || id.AuthenticatedBy != login.PasswordAuthModule {
+		return nil
+	}
+	userID, err := id.GetInternalID()
+	if err != nil {
+		return err
+	}
+	count, err := s.store.count(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return passkey.ErrPasswordDisabled.Errorf("user %d must sign in with a passkey", userID)
+	}
+
+	key := strconv.FormatInt(userID, 10)
+	value, ok, err := s.grace.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+	started := s.now()
+	if ok {
+		if started, err = time.Parse(time.RFC3339, value); err != nil {
+			return fmt.Errorf("invalid grace period start %q: %w", value, err)
+		}
+	} else if err := s.grace.Set(ctx, key, started.Format(time.RFC3339)); err != nil {
+		return err
+	}
+	deadline := started.Add(s.cfg.PasskeyOnlyGracePeriod)
+	if !s.now().Before(deadline) {
+		return passkey.ErrPasskeyRequired.Errorf("user %d did not register a passkey before %s", userID, deadline)
+	}
+	s.log.FromContext(ctx).Warn("User without passkey signed in with a password", "userId", userID, "deadline", deadline)
+	return nil
+}
+
+// RestartGracePeriod lets a user without passkey sign in with their password again, to register a passkey
+func (s *Service) RestartGracePeriod(ctx context.Context, userID int64) error {
+	return s.grace.Del(ctx, strconv.FormatInt(userID, 10))
+}
+
+// checkRecentLogin rejects the registrations of the users who signed in more than ReauthenticationMaxAge ago,
+// so that a stolen session can't be turned into a passkey
+func (s *Service) checkRecentLogin(user RegistrationUser) error {
+	if user.SignedInAt.IsZero() || s.now().Sub(user.SignedInAt) > s.cfg.ReauthenticationMaxAge {
+		return passkey.ErrSignInRequired.Errorf("user %d signed in at %s", user.ID, user.SignedInAt)
+	}
+	return nil
+}
+
+// saveSession saves the state of a ceremony until the challenge expires
+func (s *Service) saveSession(ctx context.Context, key string, session *webauthn.SessionData) error {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+	return s.cache.Set(ctx, key, data, s.cfg.ChallengeExpiration)
+}
+
+// useSession returns the state of a ceremony and removes it, a challenge is only valid for a single ceremony
+func (s *Service) useSession(ctx context.Context, key string) (*webauthn.SessionData, error) {
+	data, err := s.cache.Get(ctx, key)
+	if err != nil {
+		if errors.Is(err, remotecache.ErrCacheItemNotFound) {
+			return nil, passkey.ErrInvalidChallenge.Errorf("challenge not found")
+		}
+		return nil, err
+	}
+	if err := s.cache.Delete(ctx, key); err != nil {
+		return nil, err
+	}
+	session := &webauthn.SessionData{}
+	if err := json.Unmarshal(data, session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// verificationError adds the details of the errors of the WebAuthn library, which are only in their debug information
+func verificationError(err error) error {
+	var protocolErr *protocol.Error
+	if errors.As(err, &protocolErr) && protocolErr.DevInfo != "" {
+		return fmt.Errorf("%w: %s", err, protocolErr.DevInfo)
+	}
+	return err
+}
+
+// toWebAuthnCredential returns the saved passkey as the credential record of the WebAuthn ceremonies
+func toWebAuthnCredential(row *userPasskey) (*webauthn.Credential, error) {
+	id, err := base64.RawURLEncoding.DecodeString(row.CredentialID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid credential id of passkey %s: %w", row.UID, err)
+	}
+	publicKey, err := base64.RawURLEncoding.DecodeString(row.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key of passkey %s: %w", row.UID, err)
+	}
+	credential := &webauthn.Credential{
+		ID:            id,
+		PublicKey:     publicKey,
+		Flags:         webauthn.CredentialFlags{BackupEligible: row.BackupEligible, BackupState: row.BackedUp},
+		Authenticator: webauthn.Authenticator{SignCount: uint32(row.SignCount)},
+	}
+	if row.Transports != "" {
+		for _, transport := range strings.Split(row.Transports, ",") {
+			credential.Transport = append(credential.Transport, protocol.AuthenticatorTransport(transport))
+		}
+	}
+	return credential, nil
+}
+
+func toCredential(row *userPasskey) *passkey.Credential {
+	c := &passkey.Credential{
+		UID:        row.UID,
+		Name:       row.Name,
+		AAGUID:     row.AAGUID,
+		Transports: []string{},
+		BackedUp:   row.BackedUp,
+		Created:    row.Created,
+		LastUsed:   row.LastUsed,
+	}
+	if row.Transports != "" {
+		c.Transports = strings.Split(row.Transports, ",")
+	}
+	return c
+}
+
+// formatAAGUID formats the model of the authenticator as a UUID
+func formatAAGUID(b []byte) string {
+	if len(b) != 16 {
+		return ""
+	}
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
@@ -0,0 +1,202 @@
+package passkeyimpl
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	claims "github.com/grafana/authlib/types"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/remotecache"
+	"github.com/grafana/grafana/pkg/services/authn"
+	"github.com/grafana/grafana/pkg/services/login"
+	"github.com/grafana/grafana/pkg/services/passkey"
+	"github.com/grafana/grafana/pkg/services/passkey/passkeytest"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestIntegrationService(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.Background()
+	s := NewService(setting.AuthPasskeySettings{
+		Enabled:                true,
+		RelyingPartyID:         "grafana.example.com",
+		RelyingPartyName:       "Grafana",
+		Origins:                []string{"https://grafana.example.com"},
+		UserVerification:       string(protocol.VerificationPreferred),
+		ChallengeExpiration:    time.Minute,
+		ReauthenticationMaxAge: 10 * time.Minute,
+		PasskeyOnly:            true,
+		PasskeyOnlyGracePeriod: time.Hour,
+	}, db.InitTestDB(t), remotecache.NewFakeCacheStorage())
+	user := RegistrationUser{ID: 1, UID: "user-1", Login: "user1", DisplayName: "User 1", SignedInAt: time.Now()}
+	authenticator := passkeytest.NewAuthenticator("grafana.example.com", "https://grafana.example.com")
+
+	register := func(a *passkeytest.Authenticator) (*passkey.Credential, error) {
+		options, err := s.BeginRegistration(ctx, user)
+		require.NoError(t, err)
+		return s.FinishRegistration(ctx, user, "Laptop", a.Create(options))
+	}
+	passwordLogin := func(userID string) error {
+		r := &authn.Request{}
+		r.SetMeta(authn.MetaKeyUsername, "user1")
+		return s.passwordHook(ctx, &authn.Identity{ID: userID, Type: claims.TypeUser, AuthenticatedBy: login.PasswordAuthModule}, r)
+	}
+
+	var registered *passkey.Credential
+	t.Run("users without passkey sign in with their password", func(t *testing.T) {
+		require.NoError(t, passwordLogin("1"))
+	})
+
+	t.Run("register a passkey", func(t *testing.T) {
+		var err error
+		registered, err = register(authenticator)
+		require.NoError(t, err)
+		require.Equal(t, "Laptop", registered.Name)
+		require.Equal(t, []string{"internal"}, registered.Transports)
+
+		list, err := s.List(ctx, user.ID)
+		require.NoError(t, err)
+		require.Len(t, list, 1)
+		require.Equal(t, registered.UID, list[0].UID)
+	})
+
+	t.Run("a passkey is registered once", func(t *testing.T) {
+		_, err := register(authenticator)
+		require.ErrorIs(t, err, passkey.ErrAlreadyRegistered)
+	})
+
+	t.Run("a registration needs a challenge", func(t *testing.T) {
+		options, err := s.BeginRegistration(ctx, user)
+		require.NoError(t, err)
+		credential := passkeytest.NewAuthenticator("grafana.example.com", "https://grafana.example.com").Create(options)
+		_, err = s.FinishRegistration(ctx, user, "", credential)
+		require.NoError(t, err)
+		_, err = s.FinishRegistration(ctx, user, "", credential)
+		require.ErrorIs(t, err, passkey.ErrInvalidChallenge)
+	})
+
+	t.Run("a registration needs a recent login", func(t *testing.T) {
+		options, err := s.BeginRegistration(ctx, user)
+		require.NoError(t, err)
+		s.now = func() time.Time { return user.SignedInAt.Add(11 * time.Minute) }
+		defer func() { s.now = time.Now }()
+		_, err = s.FinishRegistration(ctx, user, "", authenticator.Create(options))
+		require.ErrorIs(t, err, passkey.ErrSignInRequired)
+		_, err = s.BeginRegistration(ctx, user)
+		require.ErrorIs(t, err, passkey.ErrSignInRequired)
+
+		withoutSession := user
+		withoutSession.SignedInAt = time.Time{}
+		s.now = time.Now
+		_, err = s.BeginRegistration(ctx, withoutSession)
+		require.ErrorIs(t, err, passkey.ErrSignInRequired)
+	})
+
+	t.Run("registrations are verified", func(t *testing.T) {
+		options, err := s.BeginRegistration(ctx, user)
+		require.NoError(t, err)
+		other := passkeytest.NewAuthenticator("grafana.example.com", "https://evil.example.com")
+		_, err = s.FinishRegistration(ctx, user, "", other.Create(options))
+		require.ErrorIs(t, err, passkey.ErrInvalidCredential)
+
+		options, err = s.BeginRegistration(ctx, user)
+		require.NoError(t, err)
+		other = passkeytest.NewAuthenticator("evil.example.com", "https://grafana.example.com")
+		_, err = s.FinishRegistration(ctx, user, "", other.Create(options))
+		require.ErrorIs(t, err, passkey.ErrInvalidCredential)
+	})
+
+	t.Run("sign in with a passkey", func(t *testing.T) {
+		options, err := s.BeginLogin(ctx)
+		require.NoError(t, err)
+		credential := authenticator.Get(options)
+		userID, err := s.FinishLogin(ctx, credential)
+		require.NoError(t, err)
+		require.Equal(t, user.ID, userID)
+
+		_, err = s.FinishLogin(ctx, credential)
+		require.ErrorIs(t, err, passkey.ErrInvalidChallenge)
+
+		list, err := s.List(ctx, user.ID)
+		require.NoError(t, err)
+		require.NotNil(t, list[0].LastUsed)
+	})
+
+	t.Run("counters going back are rejected", func(t *testing.T) {
+		options, err := s.BeginLogin(ctx)
+		require.NoError(t, err)
+		authenticator.SignCount = 0
+		_, err = s.FinishLogin(ctx, authenticator.Get(options))
+		require.ErrorIs(t, err, passkey.ErrInvalidCredential)
+		authenticator.SignCount = 1
+	})
+
+	t.Run("a counter is saved by a single login", func(t *testing.T) {
+		row, err := s.store.getByCredentialID(ctx, authenticator.ID)
+		require.NoError(t, err)
+		require.Equal(t, int64(1), row.SignCount)
+
+		require.NoError(t, s.store.markUsed(ctx, row.ID, row.SignCount, 2, time.Now()))
+		require.ErrorIs(t, s.store.markUsed(ctx, row.ID, row.SignCount, 2, time.Now()), passkey.ErrInvalidCredential)
+
+		options, err := s.BeginLogin(ctx)
+		require.NoError(t, err)
+		authenticator.SignCount = 2
+		_, err = s.FinishLogin(ctx, authenticator.Get(options))
+		require.NoError(t, err)
+	})
+
+	t.Run("unknown passkeys are rejected", func(t *testing.T) {
+		options, err := s.BeginLogin(ctx)
+		require.NoError(t, err)
+		_, err = s.FinishLogin(ctx, passkeytest.NewAuthenticator("grafana.example.com", "https://grafana.example.com").Get(options))
+		require.ErrorIs(t, err, passkey.ErrInvalidCredential)
+	})
+
+	t.Run("the user handle must match the passkey", func(t *testing.T) {
+		options, err := s.BeginLogin(ctx)
+		require.NoError(t, err)
+		handle := authenticator.UserHandle
+		authenticator.UserHandle = []byte("user-2")
+		_, err = s.FinishLogin(ctx, authenticator.Get(options))
+		authenticator.UserHandle = handle
+		require.ErrorIs(t, err, passkey.ErrInvalidCredential)
+	})
+
+	t.Run("users with a passkey can not sign in with their password", func(t *testing.T) {
+		require.ErrorIs(t, passwordLogin("1"), passkey.ErrPasswordDisabled)
+		require.NoError(t, passwordLogin("2"))
+	})
+
+	t.Run("users without passkey sign in with their password during the grace period", func(t *testing.T) {
+		now := time.Now()
+		s.now = func() time.Time { return now.Add(59 * time.Minute) }
+		require.NoError(t, passwordLogin("2"))
+
+		s.now = func() time.Time { return now.Add(time.Hour) }
+		require.ErrorIs(t, passwordLogin("2"), passkey.ErrPasskeyRequired)
+
+		require.NoError(t, s.RestartGracePeriod(ctx, 2))
+		require.NoError(t, passwordLogin("2"))
+		s.now = time.Now
+	})
+
+	t.Run("delete a passkey", func(t *testing.T) {
+		require.NoError(t, s.Delete(ctx, user.ID, registered.UID))
+		list, err := s.List(ctx, user.ID)
+		require.NoError(t, err)
+		require.Len(t, list, 1)
+
+		options, err := s.BeginLogin(ctx)
+		require.NoError(t, err)
+		_, err = s.FinishLogin(ctx, authenticator.Get(options))
+		require.ErrorIs(t, err, passkey.ErrInvalidCredential)
+	})
+}
@@ -0,0 +1,122 @@
+package passkeyimpl
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/services/passkey"
+)
+
+// userPasskey is a passkey registered by a user
+type userPasskey struct {
+	ID         int64  `xorm:"pk autoincr 'id'"`
+	UID        string `xorm:"uid"`
+	UserID     int64  `xorm:"user_id"`
+	UserHandle string `xorm:"user_handle"`
+	Name       string `xorm:"name"`
+	// Base64 URL encoded
+	CredentialID     string `xorm:"credential_id"`
+	CredentialIDHash string `xorm:"credential_id_hash"`
+	// Base64 URL encoded COSE key
+	PublicKey      string     `xorm:"public_key"`
+	SignCount      int64      `xorm:"sign_count"`
+	AAGUID         string     `xorm:"aaguid"`
+	Transports     string     `xorm:"transports"`
+	BackupEligible bool       `xorm:"backup_eligible"`
+	BackedUp       bool       `xorm:"backed_up"`
+	Created        time.Time  `xorm:"created"`
+	LastUsed       *time.Time `xorm:"last_used"`
+}
+
+func (userPasskey) TableName() string {
+	return "user_passkey"
+}
+
+func credentialIDHash(id []byte) string {
+	sum := sha256.Sum256(id)
+	return hex.EncodeToString(sum[:])
+}
+
+type store struct {
+	sqlStore db.DB
+}
+
+func (s *store) list(ctx context.Context, userID int64) ([]*userPasskey, error) {
+	result := make([]*userPasskey, 0)
+	err := s.sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+		return sess.Where("user_id = ?", userID).Asc("id").Find(&result)
+	})
+	return result, err
+}
+
+func (s *store) count(ctx context.Context, userID int64) (int64, error) {
+	var count int64
+	err := s.sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+		var err error
+		count, err = sess.Where("user_id = ?", userID).Count(&userPasskey{})
+		return err
+	})
+	return count, err
+}
+
+func (s *store) getByCredentialID(ctx context.Context, id []byte) (*userPasskey, error) {
+	row := &userPasskey{}
+	err := s.sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+		found, err := sess.Where("credential_id_hash = ?", credentialIDHash(id)).Get(row)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return passkey.ErrNotFound.Errorf("passkey not found")
+		}
+		return nil
+	})
+	return row, err
+}
+
+func (s *store) create(ctx context.Context, row *userPasskey) error {
+	return s.sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+		exists, err := sess.Where("credential_id_hash = ?", row.CredentialIDHash).Exist(&userPasskey{})
+		if err != nil {
+			return err
+		}
+		if exists {
+			return passkey.ErrAlreadyRegistered.Errorf("passkey already registered")
+		}
+		_, err = sess.Insert(row)
+		return err
+	})
+}
+
+// markUsed saves the new signature counter of a passkey, if the counter was not changed since it was read.
+// Two logins verified with the same counter at the same time reveal a cloned authenticator, only the first one
+// is accepted.
+func (s *store) markUsed(ctx context.Context, id int64, previousSignCount, signCount int64, now time.Time) error {
+	return s.sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+		affected, err := sess.Where("id = ? AND sign_count = ?", id, previousSignCount).Cols("sign_count", "last_used").
+			Update(&userPasskey{SignCount: signCount, LastUsed: &now})
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return passkey.ErrInvalidCredential.Errorf("the signature counter of passkey %d changed during the login", id)
+		}
+		return nil
+	})
+}
+
+func (s *store) delete(ctx context.Context, userID int64, uid string) error {
+	return s.sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+		affected, err := sess.Where("user_id = ? AND uid = ?", userID, uid).Delete(&userPasskey{})
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return passkey.ErrNotFound.Errorf("passkey %s not found", uid)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,110 @@
+package passkeytest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/protocol/webauthncose"
+)
+
+// Authenticator is a software authenticator with a single ES256 passkey, to test the ceremonies
+type Authenticator struct {
+	RPID       string
+	Origin     string
+	ID         []byte
+	UserHandle []byte
+	SignCount  uint32
+	// Flags of the authenticator data, user present and verified by default
+	Flags byte
+
+	key *ecdsa.PrivateKey
+}
+
+func NewAuthenticator(rpID, origin string) *Authenticator {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+	return &Authenticator{RPID: rpID, Origin: origin, ID: id, Flags: 0x01 | 0x04, key: key}
+}
+
+// Create returns the JSON of the credential created by navigator.credentials.create() for the options of a registration
+func (a *Authenticator) Create(options *protocol.CredentialCreation) []byte {
+	if id, ok := options.Response.User.ID.(protocol.URLEncodedBase64); ok {
+		a.UserHandle = id
+	}
+
+	x := a.key.X.FillBytes(make([]byte, 32))
+	y := a.key.Y.FillBytes(make([]byte, 32))
+	publicKey, err := cbor.Marshal(map[int]any{1: 2, 3: int(webauthncose.AlgES256), -1: 1, -2: x, -3: y})
+	if err != nil {
+		panic(err)
+	}
+	authData := a.authenticatorData(0x40)
+	authData = append(authData, make([]byte, 16)...)
+	authData = binary.BigEndian.AppendUint16(authData, uint16(len(a.ID)))
+	authData = append(authData, a.ID...)
+	authData = append(authData, publicKey...)
+
+	attestation, err := cbor.Marshal(map[string]any{"fmt": "none", "attStmt": map[string]any{}, "authData": authData})
+	if err != nil {
+		panic(err)
+	}
+	return a.credential(map[string]any{
+		"clientDataJSON":    encode(a.clientData(protocol.CreateCeremony, options.Response.Challenge)),
+		"attestationObject": encode(attestation),
+		"transports":        []string{"internal"},
+	})
+}
+
+// Get returns the JSON of the assertion returned by navigator.credentials.get() for the options of a login
+func (a *Authenticator) Get(options *protocol.CredentialAssertion) []byte {
+	a.SignCount++
+	authData := a.authenticatorData(0)
+	clientData := a.clientData(protocol.AssertCeremony, options.Response.Challenge)
+	clientDataHash := sha256.Sum256(clientData)
+	digest := sha256.Sum256(append(authData, clientDataHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	if err != nil {
+		panic(err)
+	}
+
+	return a.credential(map[string]any{
+		"clientDataJSON":    encode(clientData),
+		"authenticatorData": encode(authData),
+		"signature":         encode(signature),
+		"userHandle":        encode(a.UserHandle),
+	})
+}
+
+func (a *Authenticator) credential(response map[string]any) []byte {
+	credential, err := json.Marshal(map[string]any{"id": encode(a.ID), "rawId": encode(a.ID), "type": "public-key", "response": response})
+	if err != nil {
+		panic(err)
+	}
+	return credential
+}
+
+func (a *Authenticator) authenticatorData(flags byte) []byte {
+	rpIDHash := sha256.Sum256([]byte(a.RPID))
+	data := append(rpIDHash[:], a.Flags|flags)
+	return binary.BigEndian.AppendUint32(data, a.SignCount)
+}
+
+func (a *Authenticator) clientData(ceremony protocol.CeremonyType, challenge protocol.URLEncodedBase64) []byte {
+	data, _ := json.Marshal(protocol.CollectedClientData{Type: ceremony, Challenge: challenge.String(), Origin: a.Origin})
+	return data
+}
+
+func encode(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,18 @@
+package passkeytest
+
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/services/passkey"
+)
+
+var _ passkey.Service = new(FakeService)
+
+type FakeService struct {
+	ExpectedUserID int64
+	ExpectedErr    error
+}
+
+func (f *FakeService) FinishLogin(ctx context.Context, credential []byte) (int64, error) {
+	return f.ExpectedUserID, f.ExpectedErr
+}
@@ -0,0 +1,150 @@
+package clientmiddleware
+
+import (
+	"context"
+	"errors"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+
+	"github.com/grafana/grafana/pkg/apimachinery/errutil"
+	"github.com/grafana/grafana/pkg/apimachinery/identity"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/queryrestriction"
+)
+
+// NewQueryRestrictionMiddleware creates a new backend.HandlerMiddleware that applies the query restrictions
+// of the teams of the signed in user to every query sent to a data source, whichever API sent it. The resource
+// calls and the streams can't be restricted, they are denied to the users with restrictions on the data source.
+func NewQueryRestrictionMiddleware(queryRestrictionService queryrestriction.Service) backend.HandlerMiddleware {
+	return backend.HandlerMiddlewareFunc(func(next backend.Handler) backend.Handler {
+		return &QueryRestrictionMiddleware{
+			BaseHandler:             backend.NewBaseHandler(next),
+			queryRestrictionService: queryRestrictionService,
+		}
+	})
+}
+
+type QueryRestrictionMiddleware struct {
+	backend.BaseHandler
+	queryRestrictionService queryrestriction.Service
+}
+
+// restrictions returns the restrictions of the requester on the data source of the plugin context,
+// the requests without requester or data source are not restricted
+func (m *QueryRestrictionMiddleware) restrictions(ctx context.Context, pCtx backend.PluginContext) ([]queryrestriction.Restriction, error) {
+	if pCtx.DataSourceInstanceSettings == nil {
+		return nil, nil
+	}
+	requester, err := identity.GetRequester(ctx)
+	if err != nil {
+		return nil, nil
+	}
+	return m.queryRestrictionService.GetUserRestrictions(ctx, requester, pCtx.DataSourceInstanceSettings.UID)
+}
+
+// denied returns an error when the requester has restrictions on the data source of the plugin context
+func (m *QueryRestrictionMiddleware) denied(ctx context.Context, pCtx backend.PluginContext) error {
+	restrictions, err := m.restrictions(ctx, pCtx)
+	if err != nil {
+		return err
+	}
+	if len(restrictions) > 0 {
+		return queryrestriction.ErrRequestRestricted.Errorf("the requests to the data source %s are restricted", pCtx.DataSourceInstanceSettings.UID)
+	}
+	return nil
+}
+
+func (m *QueryRestrictionMiddleware) QueryData(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+	if req == nil {
+		return m.BaseHandler.QueryData(ctx, req)
+	}
+
+	restrictions, err := m.restrictions(ctx, req.PluginContext)
+	if err != nil {
+		return nil, err
+	}
+	if len(restrictions) == 0 {
+		return m.BaseHandler.QueryData(ctx, req)
+	}
+
+	// the queries are rewritten in a copy of the request, the caller may still use its queries
+	restricted := *req
+	restricted.Queries = make([]backend.DataQuery, 0, len(req.Queries))
+	for _, q := range req.Queries {
+		model, err := m.restrictQuery(req.PluginContext.PluginID, q, restrictions)
+		if err != nil {
+			return nil, err
+		}
+		q.JSON = model
+		restricted.Queries = append(restricted.Queries, q)
+	}
+	return m.BaseHandler.QueryData(ctx, &restricted)
+}
+
+func (m *QueryRestrictionMiddleware) restrictQuery(pluginID string, q backend.DataQuery, restrictions []queryrestriction.Restriction) ([]byte, error) {
+	model, err := simplejson.NewJson(q.JSON)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.queryRestrictionService.RestrictQuery(pluginID, model, restrictions); err != nil {
+		return nil, queryrestriction.ErrQueryRestricted.Build(errutil.TemplateData{
+			Public: map[string]any{"RefId": q.RefID, "Reason": err.Error()},
+			Error:  err,
+		})
+	}
+	return model.MarshalJSON()
+}
+
+func (m *QueryRestrictionMiddleware) CallResource(ctx context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
+	if req == nil {
+		return m.BaseHandler.CallResource(ctx, req, sender)
+	}
+
+	if err := m.denied(ctx, req.PluginContext); err != nil {
+		return err
+	}
+
+	return m.BaseHandler.CallResource(ctx, req, sender)
+}
+
+func (m *QueryRestrictionMiddleware) SubscribeStream(ctx context.Context, req *backend.SubscribeStreamRequest) (*backend.SubscribeStreamResponse, error) {
+	if req == nil {
+		return m.BaseHandler.SubscribeStream(ctx, req)
+	}
+
+	if err := m.denied(ctx, req.PluginContext); err != nil {
+		if errors.Is(err, queryrestriction.ErrRequestRestricted) {
+			return &backend.SubscribeStreamResponse{Status: backend.SubscribeStreamStatusPermissionDenied}, nil
+		}
+		return nil, err
+	}
+
+	return m.BaseHandler.SubscribeStream(ctx, req)
+}
+
+func (m *QueryRestrictionMiddleware) PublishStream(ctx context.Context, req *backend.PublishStreamRequest) (*backend.PublishStreamResponse, error) {
+	if req == nil {
+		return m.BaseHandler.PublishStream(ctx, req)
+	}
+
+	if err := m.denied(ctx, req.PluginContext); err != nil {
+		if errors.Is(err, queryrestriction.ErrRequestRestricted) {
+			return &backend.PublishStreamResponse{Status: backend.PublishStreamStatusPermissionDenied}, nil
+		}
+		return nil, err
+	}
+
+	return m.BaseHandler.PublishStream(ctx, req)
+}
+
+func (m *QueryRestrictionMiddleware) RunStream(ctx context.Context, req *backend.RunStreamRequest, sender *backend.StreamSender) error {
+	if req == nil {
+		return m.BaseHandler.RunStream(ctx, req, sender)
+	}
+
+	if err := m.denied(ctx, req.PluginContext); err != nil {
+		return err
+	}
+
+	return m.BaseHandler.RunStream(ctx, req, sender)
+}
@@ -0,0 +1,133 @@
+package clientmiddleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/handlertest"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/apimachinery/identity"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/queryrestriction"
+	"github.com/grafana/grafana/pkg/services/queryrestriction/queryrestrictiontest"
+	"github.com/grafana/grafana/pkg/services/user"
+)
+
+func TestQueryRestrictionMiddleware(t *testing.T) {
+	ctx := identity.WithRequester(context.Background(), &user.SignedInUser{UserID: 2, OrgID: 1})
+	pluginContext := func(pluginID string) backend.PluginContext {
+		return backend.PluginContext{
+			PluginID:                   pluginID,
+			DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{UID: "ds1"},
+		}
+	}
+	query := func(expr string) backend.DataQuery {
+		return backend.DataQuery{RefID: "A", JSON: []byte(fmt.Sprintf(`{"refId": "A", "expr": %q}`, expr))}
+	}
+	setupWithRestrictor := func(t *testing.T, restrictor queryrestriction.QueryRestrictor, restrictions ...queryrestriction.Restriction) *handlertest.HandlerMiddlewareTest {
+		queryRestrictionService := &queryrestrictiontest.FakeService{ExpectedRestrictions: restrictions}
+		queryRestrictionService.RegisterQueryRestrictor(datasources.DS_PROMETHEUS, restrictor)
+		queryRestrictionService.RegisterQueryRestrictor(datasources.DS_LOKI, restrictor)
+		return handlertest.NewHandlerMiddlewareTest(t, handlertest.WithMiddlewares(
+			NewQueryRestrictionMiddleware(queryRestrictionService),
+		))
+	}
+	setup := func(t *testing.T, restrictions ...queryrestriction.Restriction) *handlertest.HandlerMiddlewareTest {
+		return setupWithRestrictor(t, queryrestrictiontest.FakeQueryRestrictor{}, restrictions...)
+	}
+	metricPrefix := queryrestriction.Restriction{TeamID: 1, Kind: queryrestriction.KindMetricPrefix, Pattern: "team_a_"}
+	streamSelector := queryrestriction.Restriction{TeamID: 1, Kind: queryrestriction.KindStreamSelector, Pattern: `{namespace="team-a"}`}
+
+	t.Run("users without restrictions are not restricted", func(t *testing.T) {
+		cdt := setup(t)
+		_, err := cdt.MiddlewareHandler.QueryData(ctx, &backend.QueryDataRequest{
+			PluginContext: pluginContext(datasources.DS_PROMETHEUS),
+			Queries:       []backend.DataQuery{query(`node_cpu_seconds_total`)},
+		})
+		require.NoError(t, err)
+		require.NotNil(t, cdt.QueryDataReq)
+
+		err = cdt.MiddlewareHandler.CallResource(ctx, &backend.CallResourceRequest{PluginContext: pluginContext(datasources.DS_PROMETHEUS)}, nopCallResourceSender)
+		require.NoError(t, err)
+		require.NotNil(t, cdt.CallResourceReq)
+	})
+
+	t.Run("requests without requester are not restricted", func(t *testing.T) {
+		cdt := setup(t, metricPrefix)
+		_, err := cdt.MiddlewareHandler.QueryData(context.Background(), &backend.QueryDataRequest{
+			PluginContext: pluginContext(datasources.DS_PROMETHEUS),
+			Queries:       []backend.DataQuery{query(`node_cpu_seconds_total`)},
+		})
+		require.NoError(t, err)
+		require.NotNil(t, cdt.QueryDataReq)
+	})
+
+	t.Run("queries are checked by the restrictor of the data source", func(t *testing.T) {
+		cdt := setup(t, metricPrefix)
+		_, err := cdt.MiddlewareHandler.QueryData(ctx, &backend.QueryDataRequest{
+			PluginContext: pluginContext(datasources.DS_PROMETHEUS),
+			Queries:       []backend.DataQuery{query(`rate(team_a_requests_total[$__rate_interval])`)},
+		})
+		require.NoError(t, err)
+		require.NotNil(t, cdt.QueryDataReq)
+
+		cdt = setupWithRestrictor(t, queryrestrictiontest.FakeQueryRestrictor{ExpectedErr: errors.New("the metric node_cpu_seconds_total is restricted")}, metricPrefix)
+		_, err = cdt.MiddlewareHandler.QueryData(ctx, &backend.QueryDataRequest{
+			PluginContext: pluginContext(datasources.DS_PROMETHEUS),
+			Queries:       []backend.DataQuery{query(`node_cpu_seconds_total`)},
+		})
+		require.ErrorIs(t, err, queryrestriction.ErrQueryRestricted)
+		require.Nil(t, cdt.QueryDataReq)
+	})
+
+	t.Run("queries are rewritten by the restrictor of the data source", func(t *testing.T) {
+		cdt := setupWithRestrictor(t, queryrestrictiontest.FakeQueryRestrictor{ExpectedExpr: `{app="api", namespace="team-a"} |= "error"`}, streamSelector)
+		req := &backend.QueryDataRequest{
+			PluginContext: pluginContext(datasources.DS_LOKI),
+			Queries:       []backend.DataQuery{query(`{app="api"} |= "error"`)},
+		}
+		_, err := cdt.MiddlewareHandler.QueryData(ctx, req)
+		require.NoError(t, err)
+		require.Len(t, cdt.QueryDataReq.Queries, 1)
+		model, err := simplejson.NewJson(cdt.QueryDataReq.Queries[0].JSON)
+		require.NoError(t, err)
+		require.Equal(t, `{app="api", namespace="team-a"} |= "error"`, model.Get("expr").MustString())
+
+		// the queries of the caller are left untouched
+		model, err = simplejson.NewJson(req.Queries[0].JSON)
+		require.NoError(t, err)
+		require.Equal(t, `{app="api"} |= "error"`, model.Get("expr").MustString())
+	})
+
+	t.Run("queries are rejected when the data source does not support the restrictions", func(t *testing.T) {
+		cdt := setup(t, metricPrefix)
+		_, err := cdt.MiddlewareHandler.QueryData(ctx, &backend.QueryDataRequest{
+			PluginContext: pluginContext(datasources.DS_MYSQL),
+			Queries:       []backend.DataQuery{query(``)},
+		})
+		require.ErrorIs(t, err, queryrestriction.ErrQueryRestricted)
+		require.Nil(t, cdt.QueryDataReq)
+	})
+
+	t.Run("resource calls and streams are denied to the restricted users", func(t *testing.T) {
+		cdt := setup(t, metricPrefix)
+
+		err := cdt.MiddlewareHandler.CallResource(ctx, &backend.CallResourceRequest{PluginContext: pluginContext(datasources.DS_PROMETHEUS)}, nopCallResourceSender)
+		require.ErrorIs(t, err, queryrestriction.ErrRequestRestricted)
+		require.Nil(t, cdt.CallResourceReq)
+
+		subscribeResp, err := cdt.MiddlewareHandler.SubscribeStream(ctx, &backend.SubscribeStreamRequest{PluginContext: pluginContext(datasources.DS_LOKI)})
+		require.NoError(t, err)
+		require.Equal(t, backend.SubscribeStreamStatusPermissionDenied, subscribeResp.Status)
+		require.Nil(t, cdt.SubscribeStreamReq)
+
+		err = cdt.MiddlewareHandler.RunStream(ctx, &backend.RunStreamRequest{PluginContext: pluginContext(datasources.DS_LOKI)}, backend.NewStreamSender(nil))
+		require.ErrorIs(t, err, queryrestriction.ErrRequestRestricted)
+		require.Nil(t, cdt.RunStreamReq)
+	})
+}
@@ -0,0 +1,137 @@
+package accesscontrol
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+)
+
+const supportedAPIVersion = 2
+
+type configReader interface {
+	readConfig(path string) ([]*rolesAsConfig, error)
+}
+
+type configReaderImpl struct {
+	log log.Logger
+}
+
+func newConfigReader(logger log.Logger) configReader {
+	return &configReaderImpl{log: logger}
+}
+
+func (cr *configReaderImpl) readConfig(path string) ([]*rolesAsConfig, error) {
+	var configs []*rolesAsConfig
+	cr.log.Debug("Looking for access control provisioning files", "path", path)
+
+	files, err := os.ReadDir(path)
+	if err != nil {
+		cr.log.Error("Failed to read access control provisioning files from directory", "path", path, "error", err)
+		return configs, nil
+	}
+
+	for _, file := range files {
+		if strings.HasSuffix(file.Name(), ".yaml") || strings.HasSuffix(file.Name(), ".yml") {
+			cr.log.Debug("Parsing access control provisioning file", "path", path, "file.Name", file.Name())
+			cfg, err := cr.parseConfig(path, file)
+			if err != nil {
+				return nil, err
+			}
+
+			if cfg != nil {
+				configs = append(configs, cfg)
+			}
+		}
+	}
+
+	cr.log.Debug("Validating roles and assignments")
+	if err := validateRequiredFields(configs); err != nil {
+		return nil, err
+	}
+
+	checkOrgIDs(configs)
+
+	return configs, nil
+}
+
+func (cr *configReaderImpl) parseConfig(path string, file fs.DirEntry) (*rolesAsConfig, error) {
+	filename, err := filepath.Abs(filepath.Join(path, file.Name()))
+	if err != nil {
+		return nil, err
+	}
+
+	// nolint:gosec
+	// We can ignore the gosec G304 warning on this one because `filename` comes from ps.Cfg.ProvisioningPath
+	yamlFile, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg *rolesAsConfigV2
+	if err := yaml.Unmarshal(yamlFile, &cfg); err != nil {
+		return nil, err
+	}
+	if cfg == nil {
+		return nil, nil
+	}
+	if version := cfg.APIVersion.Value(); version != supportedAPIVersion {
+		return nil, fmt.Errorf("%s: unsupported apiVersion %d, expected %d", file.Name(), version, supportedAPIVersion)
+	}
+
+	return cfg.mapToRolesFromConfig(), nil
+}
+
+func validateRequiredFields(configs []*rolesAsConfig) error {
+	for i := range configs {
+		errs := []error{}
+		for index, role := range configs[i].Roles {
+			switch {
+			case role.UID == "" && role.Name == "":
+				errs = append(errs, fmt.Errorf("role item %d in configuration doesn't contain required field uid or name", index+1))
+			case !role.Absent && role.Name == "":
+				errs = append(errs, fmt.Errorf("role item %d in configuration doesn't contain required field name", index+1))
+			case len(role.From) > 0:
+				errs = append(errs, fmt.Errorf("role item %d in configuration copies permissions with from, which is not supported", index+1))
+			}
+		}
+
+		for index, team := range configs[i].Teams {
+			if team.Name == "" {
+				errs = append(errs, fmt.Errorf("team item %d in configuration doesn't contain required field name", index+1))
+			}
+			for _, ref := range team.Roles {
+				if ref.UID == "" && ref.Name == "" {
+					errs = append(errs, fmt.Errorf("team item %d in configuration has a role without uid or name", index+1))
+				}
+			}
+		}
+
+		if len(errs) != 0 {
+			return errors.Join(errs...)
+		}
+	}
+
+	return nil
+}
+
+func checkOrgIDs(configs []*rolesAsConfig) {
+	for i := range configs {
+		for _, role := range configs[i].Roles {
+			if role.OrgID < 1 {
+				role.OrgID = 1
+			}
+		}
+		for _, team := range configs[i].Teams {
+			if team.OrgID < 1 {
+				team.OrgID = 1
+			}
+		}
+	}
+}
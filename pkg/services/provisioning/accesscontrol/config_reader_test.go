@@ -0,0 +1,87 @@
+package accesscontrol
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+)
+
+const (
+	brokenYaml        = "./testdata/test-configs/broken-yaml"
+	emptyFolder       = "./testdata/test-configs/empty_folder"
+	incorrectSettings = "./testdata/test-configs/incorrect-settings"
+	unsupportedFrom   = "./testdata/test-configs/unsupported-from"
+	correctProperties = "./testdata/test-configs/correct-properties"
+)
+
+func TestConfigReader(t *testing.T) {
+	t.Run("Broken yaml should return error", func(t *testing.T) {
+		reader := newConfigReader(log.New("test logger"))
+		_, err := reader.readConfig(brokenYaml)
+		require.Error(t, err)
+	})
+
+	t.Run("Skip invalid directory", func(t *testing.T) {
+		reader := newConfigReader(log.New("test logger"))
+		cfg, err := reader.readConfig(emptyFolder)
+		require.NoError(t, err)
+		require.Len(t, cfg, 0)
+	})
+
+	t.Run("Read incorrect properties", func(t *testing.T) {
+		reader := newConfigReader(log.New("test logger"))
+		_, err := reader.readConfig(incorrectSettings)
+		require.Error(t, err)
+		require.Equal(t, "role item 2 in configuration doesn't contain required field name", err.Error())
+	})
+
+	t.Run("Copying permissions from other roles should return error", func(t *testing.T) {
+		reader := newConfigReader(log.New("test logger"))
+		_, err := reader.readConfig(unsupportedFrom)
+		require.Error(t, err)
+		require.Equal(t, "role item 1 in configuration copies permissions with from, which is not supported", err.Error())
+	})
+
+	t.Run("Can read correct properties", func(t *testing.T) {
+		reader := newConfigReader(log.New("test logger"))
+		cfg, err := reader.readConfig(correctProperties)
+		require.NoError(t, err)
+		require.Len(t, cfg, 1)
+
+		roles := cfg[0].Roles
+		require.Len(t, roles, 2)
+		require.Equal(t, &roleFromConfig{
+			OrgID:       1,
+			UID:         "customuserswriter1",
+			Name:        "custom:users:writer",
+			DisplayName: "Users writer",
+			Description: "Create, read, write users",
+			Version:     2,
+			Permissions: []accesscontrol.Permission{
+				{Action: "users:read", Scope: "global.users:*"},
+				{Action: "users:write", Scope: "global.users:*"},
+				{Action: "users:create"},
+			},
+			From: []roleRefFromConfig{},
+		}, roles[0])
+		require.Equal(t, "custom:global:users:reader", roles[1].Name)
+		require.True(t, roles[1].Global)
+		require.True(t, roles[1].Absent)
+		require.True(t, roles[1].Force)
+		require.Equal(t, int64(1), roles[1].OrgID)
+
+		teams := cfg[0].Teams
+		require.Len(t, teams, 1)
+		require.Equal(t, &teamFromConfig{
+			OrgID: 1,
+			Name:  "Users writers",
+			Roles: []roleRefFromConfig{
+				{UID: "customuserswriter1"},
+				{Name: "custom:global:users:reader", Global: true, Absent: true},
+			},
+		}, teams[0])
+	})
+}
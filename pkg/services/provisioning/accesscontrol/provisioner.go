@@ -0,0 +1,101 @@
+package accesscontrol
+
+import (
+	"context"
+	"errors"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/accesscontrol/customroles"
+)
+
+// RoleService provisions custom roles and their assignments to teams
+type RoleService interface {
+	ProvisionRole(ctx context.Context, orgID int64, cmd customroles.RoleCommand) error
+	DeleteProvisionedRole(ctx context.Context, orgID int64, ref customroles.RoleRef, force bool) error
+	ProvisionTeamRole(ctx context.Context, orgID int64, teamName string, ref customroles.RoleRef, present bool) error
+}
+
+// Provision scans a directory for provisioning config files
+// and provisions the roles and team assignments in those files.
+func Provision(ctx context.Context, configDirectory string, roleService RoleService) error {
+	logger := log.New("provisioning.accesscontrol")
+	p := RoleProvisioner{
+		log:         logger,
+		cfgProvider: newConfigReader(logger),
+		roleService: roleService,
+	}
+	return p.applyChanges(ctx, configDirectory)
+}
+
+// RoleProvisioner is responsible for provisioning custom roles and their
+// assignments to teams based on configuration read by the `configReader`
+type RoleProvisioner struct {
+	log         log.Logger
+	cfgProvider configReader
+	roleService RoleService
+}
+
+func (p *RoleProvisioner) apply(ctx context.Context, cfg *rolesAsConfig) error {
+	for _, role := range cfg.Roles {
+		if !role.Absent {
+			continue
+		}
+		p.log.Info("Deleting role from configuration", "uid", role.UID, "name", role.Name)
+		ref := customroles.RoleRef{UID: role.UID, Name: role.Name, Global: role.Global}
+		if err := p.roleService.DeleteProvisionedRole(ctx, role.OrgID, ref, role.Force); err != nil {
+			return err
+		}
+	}
+
+	for _, role := range cfg.Roles {
+		if role.Absent {
+			continue
+		}
+		if err := p.roleService.ProvisionRole(ctx, role.OrgID, customroles.RoleCommand{
+			UID:         role.UID,
+			Name:        role.Name,
+			DisplayName: role.DisplayName,
+			Description: role.Description,
+			Group:       role.Group,
+			Version:     role.Version,
+			Hidden:      role.Hidden,
+			Global:      role.Global,
+			Permissions: role.Permissions,
+		}); err != nil {
+			return err
+		}
+	}
+
+	for _, team := range cfg.Teams {
+		for _, ref := range team.Roles {
+			err := p.roleService.ProvisionTeamRole(ctx, team.OrgID, team.Name,
+				customroles.RoleRef{UID: ref.UID, Name: ref.Name, Global: ref.Global}, !ref.Absent)
+			if errors.Is(err, accesscontrol.ErrAssignmentEntityNotFound) {
+				// Teams are often synced after the startup, they get their roles on the next reload
+				p.log.Warn("Skipping role assignment, team not found", "team", team.Name, "orgId", team.OrgID)
+				break
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (p *RoleProvisioner) applyChanges(ctx context.Context, configPath string) error {
+	configs, err := p.cfgProvider.readConfig(configPath)
+	if err != nil {
+		return err
+	}
+
+	for _, cfg := range configs {
+		if err := p.apply(ctx, cfg); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,94 @@
+package accesscontrol
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/accesscontrol/customroles"
+)
+
+func TestRoleProvisioner(t *testing.T) {
+	t.Run("Should delete absent roles, then save roles and assign them", func(t *testing.T) {
+		svc := &fakeRoleService{}
+		p := newTestProvisioner(svc)
+		require.NoError(t, p.applyChanges(context.Background(), correctProperties))
+
+		require.Equal(t, []string{
+			"delete 1 custom:global:users:reader force",
+			"save 1 customuserswriter1",
+			"assign 1 Users writers customuserswriter1",
+			"revoke 1 Users writers custom:global:users:reader",
+		}, svc.calls)
+		require.Len(t, svc.saved, 1)
+		require.Equal(t, int64(2), svc.saved[0].Version)
+		require.Len(t, svc.saved[0].Permissions, 3)
+	})
+
+	t.Run("Should skip the assignments of a missing team", func(t *testing.T) {
+		svc := &fakeRoleService{teamErr: accesscontrol.ErrAssignmentEntityNotFound.Build(accesscontrol.ErrAssignmentEntityNotFoundData("team"))}
+		p := newTestProvisioner(svc)
+		require.NoError(t, p.applyChanges(context.Background(), correctProperties))
+		require.Equal(t, []string{
+			"delete 1 custom:global:users:reader force",
+			"save 1 customuserswriter1",
+		}, svc.calls)
+	})
+
+	t.Run("Should return role errors", func(t *testing.T) {
+		svc := &fakeRoleService{roleErr: customroles.ErrVersion.Errorf("boom")}
+		p := newTestProvisioner(svc)
+		err := p.applyChanges(context.Background(), correctProperties)
+		require.ErrorIs(t, err, customroles.ErrVersion)
+	})
+}
+
+func newTestProvisioner(svc RoleService) RoleProvisioner {
+	logger := log.New("test logger")
+	return RoleProvisioner{
+		log:         logger,
+		cfgProvider: newConfigReader(logger),
+		roleService: svc,
+	}
+}
+
+type fakeRoleService struct {
+	calls   []string
+	saved   []customroles.RoleCommand
+	roleErr error
+	teamErr error
+}
+
+func (f *fakeRoleService) ProvisionRole(_ context.Context, orgID int64, cmd customroles.RoleCommand) error {
+	if f.roleErr != nil {
+		return f.roleErr
+	}
+	f.calls = append(f.calls, fmt.Sprintf("save %d %s", orgID, cmd.UID))
+	f.saved = append(f.saved, cmd)
+	return nil
+}
+
+func (f *fakeRoleService) DeleteProvisionedRole(_ context.Context, orgID int64, ref customroles.RoleRef, force bool) error {
+	call := fmt.Sprintf("delete %d %s%s", orgID, ref.UID, ref.Name)
+	if force {
+		call += " force"
+	}
+	f.calls = append(f.calls, call)
+	return nil
+}
+
+func (f *fakeRoleService) ProvisionTeamRole(_ context.Context, orgID int64, teamName string, ref customroles.RoleRef, present bool) error {
+	if f.teamErr != nil {
+		return f.teamErr
+	}
+	op := "revoke"
+	if present {
+		op = "assign"
+	}
+	f.calls = append(f.calls, fmt.Sprintf("%s %d %s %s%s", op, orgID, teamName, ref.UID, ref.Name))
+	return nil
+}
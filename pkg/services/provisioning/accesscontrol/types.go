@@ -0,0 +1,147 @@
+package accesscontrol
+
+import (
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/provisioning/values"
+)
+
+const stateAbsent = "absent"
+
+// rolesAsConfig is a normalized data object for access control config data. Any config version should be mappable
+// to this type.
+type rolesAsConfig struct {
+	Roles []*roleFromConfig
+	Teams []*teamFromConfig
+}
+
+type roleFromConfig struct {
+	OrgID       int64
+	UID         string
+	Name        string
+	DisplayName string
+	Description string
+	Group       string
+	Version     int64
+	Hidden      bool
+	Global      bool
+	Permissions []accesscontrol.Permission
+	Absent      bool
+	Force       bool
+	// From lists the roles to copy the permissions from, which is not supported
+	From []roleRefFromConfig
+}
+
+type teamFromConfig struct {
+	OrgID int64
+	Name  string
+	Roles []roleRefFromConfig
+}
+
+type roleRefFromConfig struct {
+	UID    string
+	Name   string
+	Global bool
+	Absent bool
+}
+
+type permissionFromConfigV2 struct {
+	Action values.StringValue `json:"action" yaml:"action"`
+	Scope  values.StringValue `json:"scope" yaml:"scope"`
+	State  values.StringValue `json:"state" yaml:"state"`
+}
+
+type roleRefFromConfigV2 struct {
+	OrgID  values.Int64Value  `json:"orgId" yaml:"orgId"`
+	UID    values.StringValue `json:"uid" yaml:"uid"`
+	Name   values.StringValue `json:"name" yaml:"name"`
+	Global values.BoolValue   `json:"global" yaml:"global"`
+	State  values.StringValue `json:"state" yaml:"state"`
+}
+
+type roleFromConfigV2 struct {
+	OrgID       values.Int64Value        `json:"orgId" yaml:"orgId"`
+	UID         values.StringValue       `json:"uid" yaml:"uid"`
+	Name        values.StringValue       `json:"name" yaml:"name"`
+	DisplayName values.StringValue       `json:"displayName" yaml:"displayName"`
+	Description values.StringValue       `json:"description" yaml:"description"`
+	Group       values.StringValue       `json:"group" yaml:"group"`
+	Version     values.Int64Value        `json:"version" yaml:"version"`
+	Hidden      values.BoolValue         `json:"hidden" yaml:"hidden"`
+	Global      values.BoolValue         `json:"global" yaml:"global"`
+	State       values.StringValue       `json:"state" yaml:"state"`
+	Force       values.BoolValue         `json:"force" yaml:"force"`
+	Permissions []permissionFromConfigV2 `json:"permissions" yaml:"permissions"`
+	From        []roleRefFromConfigV2    `json:"from" yaml:"from"`
+}
+
+type teamFromConfigV2 struct {
+	OrgID values.Int64Value     `json:"orgId" yaml:"orgId"`
+	Name  values.StringValue    `json:"name" yaml:"name"`
+	Roles []roleRefFromConfigV2 `json:"roles" yaml:"roles"`
+}
+
+// rolesAsConfigV2 is a mapping for the version 2 configs, the version shared with Grafana Enterprise.
+// This is mapped to its normalised version.
+type rolesAsConfigV2 struct {
+	APIVersion values.Int64Value   `json:"apiVersion" yaml:"apiVersion"`
+	Roles      []*roleFromConfigV2 `json:"roles" yaml:"roles"`
+	Teams      []*teamFromConfigV2 `json:"teams" yaml:"teams"`
+}
+
+// mapToRolesFromConfig maps config syntax to a normalized rolesAsConfig object. Every version
+// of the config syntax should have this function.
+func (cfg *rolesAsConfigV2) mapToRolesFromConfig() *rolesAsConfig {
+	r := &rolesAsConfig{}
+	if cfg == nil {
+		return r
+	}
+
+	for _, role := range cfg.Roles {
+		permissions := make([]accesscontrol.Permission, 0, len(role.Permissions))
+		for _, p := range role.Permissions {
+			// Absent permissions only make sense when copying the permissions of other roles
+			if p.State.Value() == stateAbsent {
+				continue
+			}
+			permissions = append(permissions, accesscontrol.Permission{Action: p.Action.Value(), Scope: p.Scope.Value()})
+		}
+		r.Roles = append(r.Roles, &roleFromConfig{
+			OrgID:       role.OrgID.Value(),
+			UID:         role.UID.Value(),
+			Name:        role.Name.Value(),
+			DisplayName: role.DisplayName.Value(),
+			Description: role.Description.Value(),
+			Group:       role.Group.Value(),
+			Version:     role.Version.Value(),
+			Hidden:      role.Hidden.Value(),
+			Global:      role.Global.Value(),
+			Permissions: permissions,
+			Absent:      role.State.Value() == stateAbsent,
+			Force:       role.Force.Value(),
+			From:        mapRoleRefs(role.From),
+		})
+	}
+
+	for _, team := range cfg.Teams {
+		r.Teams = append(r.Teams, &teamFromConfig{
+			OrgID: team.OrgID.Value(),
+			Name:  team.Name.Value(),
+			Roles: mapRoleRefs(team.Roles),
+		})
+	}
+
+	return r
+}
+
+func mapRoleRefs(refs []roleRefFromConfigV2) []roleRefFromConfig {
+	result := make([]roleRefFromConfig, 0, len(refs))
+	for _, ref := range refs {
+		result = append(result, roleRefFromConfig{
+			UID:    ref.UID.Value(),
+			Name:   ref.Name.Value(),
+			Global: ref.Global.Value(),
+			Absent: ref.State.Value() == stateAbsent,
+		})
+	}
+	return result
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/grafana/grafana/pkg/infra/tracing"
 	"github.com/grafana/grafana/pkg/registry"
 	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/accesscontrol/customroles"
 	"github.com/grafana/grafana/pkg/services/correlations"
 	dashboardservice "github.com/grafana/grafana/pkg/services/dashboards"
 	datasourceservice "github.com/grafana/grafana/pkg/services/datasources"
@@ -27,10 +28,12 @@ import (
 	"github.com/grafana/grafana/pkg/services/org"
 	"github.com/grafana/grafana/pkg/services/pluginsintegration/pluginsettings"
 	"github.com/grafana/grafana/pkg/services/pluginsintegration/pluginstore"
+	prov_accesscontrol "github.com/grafana/grafana/pkg/services/provisioning/accesscontrol"
 	prov_alerting "github.com/grafana/grafana/pkg/services/provisioning/alerting"
 	"github.com/grafana/grafana/pkg/services/provisioning/dashboards"
 	"github.com/grafana/grafana/pkg/services/provisioning/datasources"
 	"github.com/grafana/grafana/pkg/services/provisioning/plugins"
+	"github.com/grafana/grafana/pkg/services/queryrestriction"
 	"github.com/grafana/grafana/pkg/services/quota"
 	"github.com/grafana/grafana/pkg/services/searchV2"
 	"github.com/grafana/grafana/pkg/services/secrets"
@@ -59,6 +62,9 @@ func ProvideService(
 	resourcePermissions accesscontrol.ReceiverPermissionsService,
 	tracer tracing.Tracer,
 	dual dualwrite.Service,
+	customRoles *customroles.Service,
+	dataSourceCache datasourceservice.CacheService,
+	queryRestrictionService queryrestriction.Service,
 ) (*ProvisioningServiceImpl, error) {
 	s := &ProvisioningServiceImpl{
 		Cfg:                          cfg,
@@ -72,6 +78,8 @@ func ProvideService(
 		provisionDatasources:         datasources.Provision,
 		provisionPlugins:             plugins.Provision,
 		provisionAlerting:            prov_alerting.Provision,
+		provisionAccessControl:       prov_accesscontrol.Provision,
+		roleService:                  customRoles,
 		dashboardProvisioningService: dashboardProvisioningService,
 		dashboardService:             dashboardService,
 		datasourceService:            datasourceService,
@@ -85,6 +93,8 @@ func ProvideService(
 		folderService:                folderService,
 		resourcePermissions:          resourcePermissions,
 		tracer:                       tracer,
+		dataSourceCache:              dataSourceCache,
+		queryRestrictionService:      queryRestrictionService,
 	}
 
 	if err := s.setDashboardProvisioner(); err != nil {
@@ -107,6 +117,7 @@ func (ps *ProvisioningServiceImpl) setDashboardProvisioner() error {
 type ProvisioningService interface {
 	registry.BackgroundService
 	RunInitProvisioners(ctx context.Context) error
+	ProvisionAccessControl(ctx context.Context) error
 	ProvisionDatasources(ctx context.Context) error
 	ProvisionPlugins(ctx context.Context) error
 	ProvisionDashboards(ctx context.Context) error
@@ -127,6 +138,7 @@ func newProvisioningServiceImpl(
 		newDashboardProvisioner: newDashboardProvisioner,
 		provisionDatasources:    provisionDatasources,
 		provisionPlugins:        provisionPlugins,
+		provisionAccessControl:  prov_accesscontrol.Provision,
 		Cfg:                     setting.NewCfg(),
 		searchService:           searchService,
 	}
@@ -154,6 +166,8 @@ type ProvisioningServiceImpl struct {
 	provisionDatasources         func(context.Context, string, datasources.BaseDataSourceService, datasources.CorrelationsStore, org.Service) error
 	provisionPlugins             func(context.Context, string, pluginstore.Store, pluginsettings.Service, org.Service) error
 	provisionAlerting            func(context.Context, prov_alerting.ProvisionerConfig) error
+	provisionAccessControl       func(context.Context, string, prov_accesscontrol.RoleService) error
+	roleService                  prov_accesscontrol.RoleService
 	mutex                        sync.Mutex
 	dashboardProvisioningService dashboardservice.DashboardProvisioningService
 	dashboardService             dashboardservice.DashboardService
@@ -168,10 +182,18 @@ type ProvisioningServiceImpl struct {
 	tracer                       tracing.Tracer
 	dual                         dualwrite.Service
 	onceInitProvisioners         sync.Once
+	dataSourceCache              datasourceservice.CacheService
+	queryRestrictionService      queryrestriction.Service
 }
 
 func (ps *ProvisioningServiceImpl) RunInitProvisioners(ctx context.Context) error {
-	err := ps.ProvisionDatasources(ctx)
+	// Roles are provisioned first so that the other resources can rely on them
+	err := ps.ProvisionAccessControl(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = ps.ProvisionDatasources(ctx)
 	if err != nil {
 		ps.log.Error("Failed to provision data sources", "error", err)
 		return err
@@ -240,6 +262,16 @@ func (ps *ProvisioningServiceImpl) Run(ctx context.Context) error {
 	}
 }
 
+func (ps *ProvisioningServiceImpl) ProvisionAccessControl(ctx context.Context) error {
+	accessControlPath := filepath.Join(ps.Cfg.ProvisioningPath, "access-control")
+	if err := ps.provisionAccessControl(ctx, accessControlPath, ps.roleService); err != nil {
+		err = fmt.Errorf("%v: %w", "access control provisioning error", err)
+		ps.log.Error("Failed to provision access control", "error", err)
+		return err
+	}
+	return nil
+}
+
 func (ps *ProvisioningServiceImpl) ProvisionDatasources(ctx context.Context) error {
 	datasourcePath := filepath.Join(ps.Cfg.ProvisioningPath, "datasources")
 	if err := ps.provisionDatasources(ctx, datasourcePath, ps.datasourceService, ps.correlationsService, ps.orgService); err != nil {
@@ -296,6 +328,7 @@ func (ps *ProvisioningServiceImpl) ProvisionAlerting(ctx context.Context) error
 		ps.log,
 		notifier.NewCachedNotificationSettingsValidationService(ps.alertingStore),
 		alertingauthz.NewRuleService(ps.ac),
+		alertingauthz.NewQueryRestrictionService(ps.dataSourceCache, ps.queryRestrictionService),
 	)
 	configStore := legacy_storage.NewAlertmanagerConfigStore(ps.alertingStore)
 	receiverSvc := notifier.NewReceiverService(
@@ -4,6 +4,7 @@ import "context"
 
 type Calls struct {
 	RunInitProvisioners                 []any
+	ProvisionAccessControl              []any
 	ProvisionDatasources                []any
 	ProvisionPlugins                    []any
 	ProvisionDashboards                 []any
@@ -16,6 +17,7 @@ type Calls struct {
 type ProvisioningServiceMock struct {
 	Calls                                   *Calls
 	RunInitProvisionersFunc                 func(ctx context.Context) error
+	ProvisionAccessControlFunc              func(ctx context.Context) error
 	ProvisionDatasourcesFunc                func(ctx context.Context) error
 	ProvisionPluginsFunc                    func() error
 	ProvisionDashboardsFunc                 func() error
@@ -46,6 +48,14 @@ func (mock *ProvisioningServiceMock) ProvisionDatasources(ctx context.Context) e
 	return nil
 }
 
+func (mock *ProvisioningServiceMock) ProvisionAccessControl(ctx context.Context) error {
+	mock.Calls.ProvisionAccessControl = append(mock.Calls.ProvisionAccessControl, nil)
+	if mock.ProvisionAccessControlFunc != nil {
+		return mock.ProvisionAccessControlFunc(ctx)
+	}
+	return nil
+}
+
 func (mock *ProvisioningServiceMock) ProvisionPlugins(ctx context.Context) error {
 	mock.Calls.ProvisionPlugins = append(mock.Calls.ProvisionPlugins, nil)
 	if mock.ProvisionPluginsFunc != nil {
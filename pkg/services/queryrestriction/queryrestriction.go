@@ -0,0 +1,126 @@
+package queryrestriction
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"github.com/prometheus/prometheus/promql/parser"
+
+	"github.com/grafana/grafana/pkg/apimachinery/errutil"
+	"github.com/grafana/grafana/pkg/apimachinery/identity"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+// Kinds of restrictions, each data source type supports some of them
+const (
+	// KindMetricPrefix restricts the queries to the metrics starting with the pattern
+	KindMetricPrefix = "metric_prefix"
+	// KindStreamSelector restricts the queries to the log streams matching the pattern, like {namespace="team-a"}
+	KindStreamSelector = "stream_selector"
+	// KindSQLSchema restricts the MySQL queries to the tables of the database of the pattern
+	KindSQLSchema = "sql_schema"
+)
+
+var (
+	ErrInvalidRestriction = errutil.BadRequest("queryrestriction.invalid", errutil.WithPublicMessage("Invalid query restriction"))
+	ErrTeamNotFound       = errutil.BadRequest("queryrestriction.team-not-found", errutil.WithPublicMessage("Team not found"))
+	ErrQueryRestricted    = errutil.Forbidden("queryrestriction.query-restricted").MustTemplate("query {{ .Public.RefId }} is restricted: {{ .Public.Reason }}", errutil.WithPublic("Query {{ .Public.RefId }} is not allowed by the query restrictions of your teams: {{ .Public.Reason }}"))
+	ErrRequestRestricted  = errutil.Forbidden("queryrestriction.request-restricted", errutil.WithPublicMessage("Access denied, the queries of your teams are restricted on this data source"))
+)
+
+var (
+	metricPrefixRegexp = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+	sqlSchemaRegexp    = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_$]*$`)
+)
+
+// Restriction limits what the members of a team can query from a data source
+type Restriction struct {
+	TeamID  int64  `json:"teamId"`
+	Kind    string `json:"kind"`
+	Pattern string `json:"pattern"`
+}
+
+// Validate checks the syntax of the pattern for the kind of the restriction
+func (r Restriction) Validate() error {
+	if r.TeamID <= 0 {
+		return ErrInvalidRestriction.Errorf("missing team")
+	}
+	switch r.Kind {
+	case KindMetricPrefix:
+		if !metricPrefixRegexp.MatchString(r.Pattern) {
+			return ErrInvalidRestriction.Errorf("invalid metric prefix %q", r.Pattern)
+		}
+	case KindStreamSelector:
+		matchers, err := parser.ParseMetricSelector(r.Pattern)
+		if err != nil {
+			return ErrInvalidRestriction.Errorf("invalid stream selector %q: %w", r.Pattern, err)
+		}
+		if len(matchers) == 0 {
+			return ErrInvalidRestriction.Errorf("empty stream selector %q", r.Pattern)
+		}
+	case KindSQLSchema:
+		if !sqlSchemaRegexp.MatchString(r.Pattern) {
+			return ErrInvalidRestriction.Errorf("invalid sql schema %q", r.Pattern)
+		}
+	default:
+		return ErrInvalidRestriction.Errorf("invalid kind %q, expected %s, %s or %s", r.Kind, KindMetricPrefix, KindStreamSelector, KindSQLSchema)
+	}
+	return nil
+}
+
+// Patterns returns the distinct patterns of the restrictions of the kind
+func Patterns(restrictions []Restriction, kind string) []string {
+	patterns := []string{}
+	seen := map[string]bool{}
+	for _, r := range restrictions {
+		if r.Kind == kind && !seen[r.Pattern] {
+			seen[r.Pattern] = true
+			patterns = append(patterns, r.Pattern)
+		}
+	}
+	return patterns
+}
+
+// UnsupportedKind returns an error for the first restriction which isn't of one of the kinds
+func UnsupportedKind(restrictions []Restriction, pluginID string, kinds ...string) error {
+	for _, r := range restrictions {
+		supported := false
+		for _, kind := range kinds {
+			if r.Kind == kind {
+				supported = true
+			}
+		}
+		if !supported {
+			return fmt.Errorf("the restriction %s is not supported by %s", r.Kind, pluginID)
+		}
+	}
+	return nil
+}
+
+// RestrictionsUpdated is published when the restrictions of a data source are replaced
+type RestrictionsUpdated struct {
+	OrgID         int64
+	DataSourceUID string
+}
+
+// QueryRestrictor is implemented for the data source types supporting query restrictions. It checks the query
+// model, or rewrites it, so that the query only returns the data allowed by the restrictions. An error rejects the query.
+type QueryRestrictor interface {
+	RestrictQuery(query *simplejson.Json, restrictions []Restriction) error
+}
+
+type Service interface {
+	// List returns the restrictions of the data source
+	List(ctx context.Context, orgID int64, dataSourceUID string) ([]Restriction, error)
+	// Set replaces the restrictions of the data source
+	Set(ctx context.Context, orgID int64, dataSourceUID string, restrictions []Restriction) error
+	// GetUserRestrictions returns the restrictions of the teams of the user on the data source,
+	// the user is not restricted when none is returned
+	GetUserRestrictions(ctx context.Context, user identity.Requester, dataSourceUID string) ([]Restriction, error)
+	// RegisterQueryRestrictor registers the restrictor of the queries of the data sources of the plugin
+	RegisterQueryRestrictor(pluginID string, restrictor QueryRestrictor)
+	// RestrictQuery applies the restrictions to the query model of a data source of the plugin,
+	// the queries of the plugins without restrictor are rejected
+	RestrictQuery(pluginID string, query *simplejson.Json, restrictions []Restriction) error
+}
@@ -0,0 +1,85 @@
+package queryrestrictionimpl
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/queryrestriction"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+// API serves the query restrictions of the data sources. It is provided apart from the service, as the
+// data source service depends on the plugin client which applies the restrictions of the service.
+type API struct {
+	service           *Service
+	dataSourceService datasources.DataSourceService
+}
+
+func ProvideAPI(cfg *setting.Cfg, routeRegister routing.RouteRegister, ac accesscontrol.AccessControl, dataSourceService datasources.DataSourceService, s *Service) *API {
+	a := &API{service: s, dataSourceService: dataSourceService}
+	if cfg.QueryRestrictionsEnabled {
+		a.registerRoutes(routeRegister, ac)
+	}
+	return a
+}
+
+func (a *API) registerRoutes(routeRegister routing.RouteRegister, ac accesscontrol.AccessControl) {
+	authorize := accesscontrol.Middleware(ac)
+	uidScope := datasources.ScopeProvider.GetResourceScopeUID(accesscontrol.Parameter(":uid"))
+
+	routeRegister.Group("/api/datasources/uid/:uid/query-restrictions", func(r routing.RouteRegister) {
+		r.Get("/", authorize(accesscontrol.EvalPermission(datasources.ActionPermissionsRead, uidScope)), routing.Wrap(a.list))
+		r.Put("/", authorize(accesscontrol.EvalPermission(datasources.ActionPermissionsWrite, uidScope)), routing.Wrap(a.set))
+	})
+}
+
+// SetRestrictionsCommand replaces the query restrictions of a data source
+type SetRestrictionsCommand struct {
+	Restrictions []queryrestriction.Restriction `json:"restrictions"`
+}
+
+func (a *API) getDataSource(c *contextmodel.ReqContext) (*datasources.DataSource, response.Response) {
+	ds, err := a.dataSourceService.GetDataSource(c.Req.Context(), &datasources.GetDataSourceQuery{UID: web.Params(c.Req)[":uid"], OrgID: c.GetOrgID()})
+	if err != nil {
+		if errors.Is(err, datasources.ErrDataSourceNotFound) {
+			return nil, response.Error(http.StatusNotFound, "Data source not found", nil)
+		}
+		return nil, response.Error(http.StatusInternalServerError, "Failed to get the data source", err)
+	}
+	return ds, nil
+}
+
+// GET /api/datasources/uid/:uid/query-restrictions
+func (a *API) list(c *contextmodel.ReqContext) response.Response {
+	ds, errResponse := a.getDataSource(c)
+	if errResponse != nil {
+		return errResponse
+	}
+	restrictions, err := a.service.List(c.Req.Context(), ds.OrgID, ds.UID)
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to list the query restrictions", err)
+	}
+	return response.JSON(http.StatusOK, SetRestrictionsCommand{Restrictions: restrictions})
+}
+
+// PUT /api/datasources/uid/:uid/query-restrictions
+func (a *API) set(c *contextmodel.ReqContext) response.Response {
+	cmd := SetRestrictionsCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	ds, errResponse := a.getDataSource(c)
+	if errResponse != nil {
+		return errResponse
+	}
+	if err := a.service.Set(c.Req.Context(), ds.OrgID, ds.UID, cmd.Restrictions); err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to set the query restrictions", err)
+	}
+	return response.Success("Query restrictions updated")
+}
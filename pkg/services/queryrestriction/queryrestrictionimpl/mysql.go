@@ -0,0 +1,18 @@
+package queryrestrictionimpl
+
+import (
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/queryrestriction"
+)
+
+// mysqlQueryRestrictor restricts the MySQL queries to the tables of the databases of the restrictions. It is
+// registered by the service, so that the MySQL data source doesn't depend on the query restrictions.
+type mysqlQueryRestrictor struct{}
+
+func (mysqlQueryRestrictor) RestrictQuery(query *simplejson.Json, restrictions []queryrestriction.Restriction) error {
+	if err := queryrestriction.UnsupportedKind(restrictions, datasources.DS_MYSQL, queryrestriction.KindSQLSchema); err != nil {
+		return err
+	}
+	return queryrestriction.CheckSQLSchemas(query.Get("rawSql").MustString(), queryrestriction.Patterns(restrictions, queryrestriction.KindSQLSchema))
+}
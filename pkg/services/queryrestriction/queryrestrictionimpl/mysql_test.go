@@ -0,0 +1,37 @@
+package queryrestrictionimpl
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/queryrestriction"
+)
+
+func TestRestrictMySQLQuery(t *testing.T) {
+	s := NewService(true, nil, nil)
+	schema := queryrestriction.Restriction{TeamID: 1, Kind: queryrestriction.KindSQLSchema, Pattern: "team_a"}
+
+	t.Run("allow the tables of the databases of the restrictions", func(t *testing.T) {
+		query := simplejson.NewFromAny(map[string]any{"rawSql": "SELECT * FROM team_a.metrics"})
+		require.NoError(t, s.RestrictQuery(datasources.DS_MYSQL, query, []queryrestriction.Restriction{schema}))
+	})
+
+	t.Run("reject the tables of other databases", func(t *testing.T) {
+		query := simplejson.NewFromAny(map[string]any{"rawSql": "SELECT * FROM team_b.metrics"})
+		require.Error(t, s.RestrictQuery(datasources.DS_MYSQL, query, []queryrestriction.Restriction{schema}))
+	})
+
+	t.Run("reject the unsupported restrictions", func(t *testing.T) {
+		query := simplejson.NewFromAny(map[string]any{"rawSql": "SELECT * FROM team_a.metrics"})
+		prefix := queryrestriction.Restriction{TeamID: 1, Kind: queryrestriction.KindMetricPrefix, Pattern: "team_a_"}
+		require.Error(t, s.RestrictQuery(datasources.DS_MYSQL, query, []queryrestriction.Restriction{prefix}))
+	})
+
+	t.Run("reject the PostgreSQL queries", func(t *testing.T) {
+		query := simplejson.NewFromAny(map[string]any{"rawSql": "SELECT * FROM team_a.metrics"})
+		require.Error(t, s.RestrictQuery(datasources.DS_POSTGRES, query, []queryrestriction.Restriction{schema}))
+	})
+}
@@ -0,0 +1,120 @@
+package queryrestrictionimpl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	claims "github.com/grafana/authlib/types"
+
+	"github.com/grafana/grafana/pkg/apimachinery/identity"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/events"
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/queryrestriction"
+	"github.com/grafana/grafana/pkg/services/team"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+var _ queryrestriction.Service = new(Service)
+
+type Service struct {
+	enabled     bool
+	store       *store
+	teamService team.Service
+	bus         bus.Bus
+	log         log.Logger
+
+	mu          sync.RWMutex
+	restrictors map[string]queryrestriction.QueryRestrictor
+}
+
+func ProvideService(cfg *setting.Cfg, sqlStore db.DB, bus bus.Bus, teamService team.Service) *Service {
+	s := NewService(cfg.QueryRestrictionsEnabled, sqlStore, teamService)
+	s.bus = bus
+	teamService.RegisterDelete("DELETE FROM data_source_query_restriction WHERE org_id = ? AND team_id = ?")
+	// the restrictions of a deleted data source are deleted even when the restrictions are disabled
+	bus.AddEventListener(s.onDataSourceDeleted)
+	return s
+}
+
+func NewService(enabled bool, sqlStore db.DB, teamService team.Service) *Service {
+	return &Service{
+		enabled:     enabled,
+		store:       &store{sqlStore: sqlStore},
+		teamService: teamService,
+		log:         log.New("queryrestriction"),
+		restrictors: map[string]queryrestriction.QueryRestrictor{
+			datasources.DS_MYSQL: mysqlQueryRestrictor{},
+		},
+	}
+}
+
+func (s *Service) List(ctx context.Context, orgID int64, dataSourceUID string) ([]queryrestriction.Restriction, error) {
+	return s.store.list(ctx, orgID, dataSourceUID)
+}
+
+func (s *Service) Set(ctx context.Context, orgID int64, dataSourceUID string, restrictions []queryrestriction.Restriction) error {
+	teams := map[int64]bool{}
+	for _, r := range restrictions {
+		if err := r.Validate(); err != nil {
+			return err
+		}
+		if teams[r.TeamID] {
+			continue
+		}
+		if _, err := s.teamService.GetTeamByID(ctx, &team.GetTeamByIDQuery{OrgID: orgID, ID: r.TeamID}); err != nil {
+			if errors.Is(err, team.ErrTeamNotFound) {
+				return queryrestriction.ErrTeamNotFound.Errorf("team %d not found", r.TeamID)
+			}
+			return err
+		}
+		teams[r.TeamID] = true
+	}
+	if err := s.store.replace(ctx, orgID, dataSourceUID, restrictions); err != nil {
+		return err
+	}
+	if s.bus == nil {
+		return nil
+	}
+	return s.bus.Publish(ctx, &queryrestriction.RestrictionsUpdated{OrgID: orgID, DataSourceUID: dataSourceUID})
+}
+
+func (s *Service) GetUserRestrictions(ctx context.Context, user identity.Requester, dataSourceUID string) ([]queryrestriction.Restriction, error) {
+	// only the users are members of teams
+	if !s.enabled || user == nil || !user.IsIdentityType(claims.TypeUser) {
+		return nil, nil
+	}
+	userID, err := user.GetInternalID()
+	if err != nil {
+		return nil, err
+	}
+	return s.store.listForUser(ctx, user.GetOrgID(), dataSourceUID, userID)
+}
+
+func (s *Service) RegisterQueryRestrictor(pluginID string, restrictor queryrestriction.QueryRestrictor) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.restrictors[pluginID] = restrictor
+}
+
+func (s *Service) RestrictQuery(pluginID string, query *simplejson.Json, restrictions []queryrestriction.Restriction) error {
+	s.mu.RLock()
+	restrictor, ok := s.restrictors[pluginID]
+	s.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("the %s data sources do not support query restrictions", pluginID)
+	}
+	return restrictor.RestrictQuery(query, restrictions)
+}
+
+func (s *Service) onDataSourceDeleted(ctx context.Context, e *events.DataSourceDeleted) error {
+	if err := s.store.deleteByDataSource(ctx, e.OrgID, e.UID); err != nil {
+		s.log.Error("Failed to delete the query restrictions of the data source", "uid", e.UID, "error", err)
+	}
+	return nil
+}
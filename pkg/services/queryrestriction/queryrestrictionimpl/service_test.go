@@ -0,0 +1,127 @@
+package queryrestrictionimpl
+
+import (
+	"context"
+	"testing"
+
+	claims "github.com/grafana/authlib/types"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/apimachinery/identity"
+	"github.com/grafana/grafana/pkg/events"
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/tracing"
+	"github.com/grafana/grafana/pkg/services/queryrestriction"
+	"github.com/grafana/grafana/pkg/services/team"
+	"github.com/grafana/grafana/pkg/services/team/teamimpl"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestIntegrationService(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.Background()
+	sqlStore := db.InitTestDB(t)
+	teamService, err := teamimpl.ProvideService(sqlStore, setting.NewCfg(), tracing.InitializeTracerForTest())
+	require.NoError(t, err)
+	s := NewService(true, sqlStore, teamService)
+	teamService.RegisterDelete("DELETE FROM data_source_query_restriction WHERE org_id = ? AND team_id = ?")
+
+	teamA, err := teamService.CreateTeam(ctx, &team.CreateTeamCommand{Name: "team-a", OrgID: 1})
+	require.NoError(t, err)
+	teamB, err := teamService.CreateTeam(ctx, &team.CreateTeamCommand{Name: "team-b", OrgID: 1})
+	require.NoError(t, err)
+	err = sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+		return teamimpl.AddOrUpdateTeamMemberHook(sess, 10, 1, teamA.ID, false, team.PermissionTypeMember)
+	})
+	require.NoError(t, err)
+
+	member := &user.SignedInUser{UserID: 10, OrgID: 1}
+	other := &user.SignedInUser{UserID: 11, OrgID: 1}
+
+	t.Run("set and list the restrictions of a data source", func(t *testing.T) {
+		restrictions := []queryrestriction.Restriction{
+			{TeamID: teamA.ID, Kind: queryrestriction.KindStreamSelector, Pattern: `{namespace="team-a"}`},
+			{TeamID: teamB.ID, Kind: queryrestriction.KindStreamSelector, Pattern: `{namespace="team-b"}`},
+		}
+		require.NoError(t, s.Set(ctx, 1, "loki", restrictions))
+
+		listed, err := s.List(ctx, 1, "loki")
+		require.NoError(t, err)
+		require.Equal(t, restrictions, listed)
+
+		require.NoError(t, s.Set(ctx, 1, "loki", restrictions[:1]))
+		listed, err = s.List(ctx, 1, "loki")
+		require.NoError(t, err)
+		require.Equal(t, restrictions[:1], listed)
+	})
+
+	t.Run("return the restrictions of the teams of the user", func(t *testing.T) {
+		require.NoError(t, s.Set(ctx, 1, "prom", []queryrestriction.Restriction{
+			{TeamID: teamA.ID, Kind: queryrestriction.KindMetricPrefix, Pattern: "team_a_"},
+			{TeamID: teamB.ID, Kind: queryrestriction.KindMetricPrefix, Pattern: "team_b_"},
+		}))
+
+		restrictions, err := s.GetUserRestrictions(ctx, member, "prom")
+		require.NoError(t, err)
+		require.Equal(t, []queryrestriction.Restriction{{TeamID: teamA.ID, Kind: queryrestriction.KindMetricPrefix, Pattern: "team_a_"}}, restrictions)
+
+		restrictions, err = s.GetUserRestrictions(ctx, other, "prom")
+		require.NoError(t, err)
+		require.Empty(t, restrictions)
+
+		restrictions, err = s.GetUserRestrictions(ctx, &identity.StaticRequester{Type: claims.TypeServiceAccount, UserID: 10, OrgID: 1}, "prom")
+		require.NoError(t, err)
+		require.Empty(t, restrictions)
+	})
+
+	t.Run("reject invalid restrictions", func(t *testing.T) {
+		err := s.Set(ctx, 1, "prom", []queryrestriction.Restriction{{TeamID: teamA.ID, Kind: queryrestriction.KindMetricPrefix, Pattern: "team-a"}})
+		require.ErrorIs(t, err, queryrestriction.ErrInvalidRestriction)
+
+		err = s.Set(ctx, 1, "prom", []queryrestriction.Restriction{{TeamID: teamA.ID, Kind: "label", Pattern: "team_a"}})
+		require.ErrorIs(t, err, queryrestriction.ErrInvalidRestriction)
+
+		err = s.Set(ctx, 1, "mysql", []queryrestriction.Restriction{{TeamID: teamA.ID, Kind: queryrestriction.KindSQLSchema, Pattern: "team-a"}})
+		require.ErrorIs(t, err, queryrestriction.ErrInvalidRestriction)
+
+		err = s.Set(ctx, 1, "prom", []queryrestriction.Restriction{{TeamID: 999, Kind: queryrestriction.KindMetricPrefix, Pattern: "team_a_"}})
+		require.ErrorIs(t, err, queryrestriction.ErrTeamNotFound)
+	})
+
+	t.Run("delete the restrictions of a deleted data source", func(t *testing.T) {
+		require.NoError(t, s.onDataSourceDeleted(ctx, &events.DataSourceDeleted{OrgID: 1, UID: "prom"}))
+		listed, err := s.List(ctx, 1, "prom")
+		require.NoError(t, err)
+		require.Empty(t, listed)
+	})
+
+	t.Run("delete the restrictions of a deleted team", func(t *testing.T) {
+		require.NoError(t, teamService.DeleteTeam(ctx, &team.DeleteTeamCommand{OrgID: 1, ID: teamA.ID}))
+		listed, err := s.List(ctx, 1, "loki")
+		require.NoError(t, err)
+		require.Empty(t, listed)
+	})
+
+	t.Run("the restrictions are ignored when disabled", func(t *testing.T) {
+		teamC, err := teamService.CreateTeam(ctx, &team.CreateTeamCommand{Name: "team-c", OrgID: 1})
+		require.NoError(t, err)
+		err = sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+			return teamimpl.AddOrUpdateTeamMemberHook(sess, 10, 1, teamC.ID, false, team.PermissionTypeMember)
+		})
+		require.NoError(t, err)
+		require.NoError(t, s.Set(ctx, 1, "prom", []queryrestriction.Restriction{{TeamID: teamC.ID, Kind: queryrestriction.KindMetricPrefix, Pattern: "team_c_"}}))
+
+		restrictions, err := s.GetUserRestrictions(ctx, member, "prom")
+		require.NoError(t, err)
+		require.Len(t, restrictions, 1)
+
+		disabled := NewService(false, sqlStore, teamService)
+		restrictions, err = disabled.GetUserRestrictions(ctx, &user.SignedInUser{UserID: 10, OrgID: 1}, "prom")
+		require.NoError(t, err)
+		require.Empty(t, restrictions)
+	})
+}
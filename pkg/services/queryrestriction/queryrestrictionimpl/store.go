@@ -0,0 +1,92 @@
+package queryrestrictionimpl
+
+import (
+	"context"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/services/queryrestriction"
+)
+
+// queryRestriction is a restriction stored in the database
+type queryRestriction struct {
+	ID            int64     `xorm:"pk autoincr 'id'"`
+	OrgID         int64     `xorm:"org_id"`
+	DataSourceUID string    `xorm:"datasource_uid"`
+	TeamID        int64     `xorm:"team_id"`
+	Kind          string    `xorm:"kind"`
+	Pattern       string    `xorm:"pattern"`
+	Created       time.Time `xorm:"'created'"`
+}
+
+func (queryRestriction) TableName() string {
+	return "data_source_query_restriction"
+}
+
+func toRestrictions(rows []*queryRestriction) []queryrestriction.Restriction {
+	restrictions := make([]queryrestriction.Restriction, 0, len(rows))
+	for _, row := range rows {
+		restrictions = append(restrictions, queryrestriction.Restriction{TeamID: row.TeamID, Kind: row.Kind, Pattern: row.Pattern})
+	}
+	return restrictions
+}
+
+type store struct {
+	sqlStore db.DB
+}
+
+func (s *store) list(ctx context.Context, orgID int64, dataSourceUID string) ([]queryrestriction.Restriction, error) {
+	rows := make([]*queryRestriction, 0)
+	err := s.sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+		return sess.Where("org_id = ? AND datasource_uid = ?", orgID, dataSourceUID).Asc("id").Find(&rows)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toRestrictions(rows), nil
+}
+
+// listForUser returns the restrictions of the teams the user is a member of
+func (s *store) listForUser(ctx context.Context, orgID int64, dataSourceUID string, userID int64) ([]queryrestriction.Restriction, error) {
+	rows := make([]*queryRestriction, 0)
+	err := s.sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+		return sess.SQL(`SELECT r.* FROM data_source_query_restriction AS r
+			INNER JOIN team_member AS tm ON tm.org_id = r.org_id AND tm.team_id = r.team_id
+			WHERE r.org_id = ? AND r.datasource_uid = ? AND tm.user_id = ?
+			ORDER BY r.id`, orgID, dataSourceUID, userID).Find(&rows)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toRestrictions(rows), nil
+}
+
+func (s *store) replace(ctx context.Context, orgID int64, dataSourceUID string, restrictions []queryrestriction.Restriction) error {
+	now := time.Now()
+	return s.sqlStore.WithTransactionalDbSession(ctx, func(sess *db.Session) error {
+		if _, err := sess.Exec("DELETE FROM data_source_query_restriction WHERE org_id = ? AND datasource_uid = ?", orgID, dataSourceUID); err != nil {
+			return err
+		}
+		for _, r := range restrictions {
+			row := &queryRestriction{
+				OrgID:         orgID,
+				DataSourceUID: dataSourceUID,
+				TeamID:        r.TeamID,
+				Kind:          r.Kind,
+				Pattern:       r.Pattern,
+				Created:       now,
+			}
+			if _, err := sess.Insert(row); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *store) deleteByDataSource(ctx context.Context, orgID int64, dataSourceUID string) error {
+	return s.sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+		_, err := sess.Exec("DELETE FROM data_source_query_restriction WHERE org_id = ? AND datasource_uid = ?", orgID, dataSourceUID)
+		return err
+	})
+}
@@ -0,0 +1,61 @@
+package queryrestrictiontest
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/grafana/grafana/pkg/apimachinery/identity"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/queryrestriction"
+)
+
+var _ queryrestriction.Service = new(FakeService)
+
+type FakeService struct {
+	ExpectedRestrictions []queryrestriction.Restriction
+	ExpectedErr          error
+	Restrictors          map[string]queryrestriction.QueryRestrictor
+}
+
+func (f *FakeService) List(ctx context.Context, orgID int64, dataSourceUID string) ([]queryrestriction.Restriction, error) {
+	return f.ExpectedRestrictions, f.ExpectedErr
+}
+
+func (f *FakeService) Set(ctx context.Context, orgID int64, dataSourceUID string, restrictions []queryrestriction.Restriction) error {
+	return f.ExpectedErr
+}
+
+func (f *FakeService) GetUserRestrictions(ctx context.Context, user identity.Requester, dataSourceUID string) ([]queryrestriction.Restriction, error) {
+	return f.ExpectedRestrictions, f.ExpectedErr
+}
+
+func (f *FakeService) RegisterQueryRestrictor(pluginID string, restrictor queryrestriction.QueryRestrictor) {
+	if f.Restrictors == nil {
+		f.Restrictors = map[string]queryrestriction.QueryRestrictor{}
+	}
+	f.Restrictors[pluginID] = restrictor
+}
+
+func (f *FakeService) RestrictQuery(pluginID string, query *simplejson.Json, restrictions []queryrestriction.Restriction) error {
+	restrictor, ok := f.Restrictors[pluginID]
+	if !ok {
+		return fmt.Errorf("the %s data sources do not support query restrictions", pluginID)
+	}
+	return restrictor.RestrictQuery(query, restrictions)
+}
+
+// FakeQueryRestrictor sets the expression of the queries, or rejects them with the expected error
+type FakeQueryRestrictor struct {
+	ExpectedExpr string
+	ExpectedErr  error
+}
+
+func (f FakeQueryRestrictor) RestrictQuery(query *simplejson.Json, restrictions []queryrestriction.Restriction) error {
+	if f.ExpectedErr != nil {
+		return f.ExpectedErr
+	}
+	if f.ExpectedExpr != "" {
+		query.Set("expr", f.ExpectedExpr)
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package queryrestriction
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/dolthub/vitess/go/vt/sqlparser"
+)
+
+// macroRegexp matches the macros of the SQL data sources, like $__timeFilter(time), which the parser doesn't accept
+var macroRegexp = regexp.MustCompile(`\$(__[a-zA-Z0-9_]+)`)
+
+// deniedSQLFunctionPrefixes are the prefixes of the built-in functions which read data without referencing
+// a table, by reading a file of the server
+var deniedSQLFunctionPrefixes = []string{"load_file"}
+
+// CheckSQLSchemas checks that a SQL query only reads the tables of the schemas. The query must be a single select
+// statement in which every table is qualified by one of the schemas, the tables can't be resolved with the default
+// database of the data source. The queries which can't be parsed with the MySQL grammar are rejected.
+func CheckSQLSchemas(rawSQL string, schemas []string) error {
+	if len(schemas) == 0 {
+		return nil
+	}
+	// the macros are checked as functions, their arguments are part of the expanded query
+	stmt, err := sqlparser.Parse(macroRegexp.ReplaceAllString(rawSQL, "$1"))
+	if err != nil {
+		return fmt.Errorf("the query can't be checked against the allowed schemas: %w", err)
+	}
+	sel, ok := stmt.(sqlparser.SelectStatement)
+	if !ok {
+		return fmt.Errorf("only select statements are allowed")
+	}
+	return checkSQLSelect(sel, schemas, nil)
+}
+
+// sqlScope holds the common table expressions of a select statement, which are referenced without schema
+// in the statement and in its subqueries
+type sqlScope struct {
+	parent *sqlScope
+	ctes   map[string]bool
+}
+
+func (s *sqlScope) hasCTE(name string) bool {
+	for ; s != nil; s = s.parent {
+		if s.ctes[name] {
+			return true
+		}
+	}
+	return false
+}
+
+func checkSQLSelect(stmt sqlparser.SelectStatement, schemas []string, scope *sqlScope) error {
+	switch v := stmt.(type) {
+	case *sqlparser.ParenSelect:
+		return checkSQLSelect(v.Select, schemas, scope)
+	case *sqlparser.Select:
+		if v.Into != nil {
+			return fmt.Errorf("select into is not allowed")
+		}
+		scope, err := checkSQLWith(v.With, schemas, scope)
+		if err != nil {
+			return err
+		}
+		return walkSQL(schemas, scope, v.SelectExprs, v.From, v.Where, v.GroupBy, v.Having, v.Window, v.OrderBy, v.Limit)
+	case *sqlparser.SetOp:
+		if v.Into != nil {
+			return fmt.Errorf("select into is not allowed")
+		}
+		scope, err := checkSQLWith(v.With, schemas, scope)
+		if err != nil {
+			return err
+		}
+		if err := checkSQLSelect(v.Left, schemas, scope); err != nil {
+			return err
+		}
+		if err := checkSQLSelect(v.Right, schemas, scope); err != nil {
+			return err
+		}
+		return walkSQL(schemas, scope, v.OrderBy, v.Limit)
+	default:
+		return fmt.Errorf("the statement %s is not allowed", sqlparser.String(stmt))
+	}
+}
+
+// checkSQLWith checks the common table expressions and returns the scope of the statement. A common table
+// expression can only reference the previous ones, or all of them when the with clause is recursive.
+func checkSQLWith(with *sqlparser.With, schemas []string, scope *sqlScope) (*sqlScope, error) {
+	if with == nil {
+		return scope, nil
+	}
+	scope = &sqlScope{parent: scope, ctes: map[string]bool{}}
+	if with.Recursive {
+		for _, cte := range with.Ctes {
+			scope.ctes[cte.As.String()] = true
+		}
+	}
+	for _, cte := range with.Ctes {
+		subquery, ok := cte.Expr.(*sqlparser.Subquery)
+		if !ok {
+			return nil, fmt.Errorf("the common table expression %s is not allowed", cte.As.String())
+		}
+		if err := checkSQLSelect(subquery.Select, schemas, scope); err != nil {
+			return nil, err
+		}
+		scope.ctes[cte.As.String()] = true
+	}
+	return scope, nil
+}
+
+// walkSQL checks the tables and functions of the nodes of a select statement, the nested select statements
+// are checked in the scope of the statement
+func walkSQL(schemas []string, scope *sqlScope, nodes ...sqlparser.SQLNode) error {
+	return sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		switch v := node.(type) {
+		case sqlparser.SelectStatement:
+			return false, checkSQLSelect(v, schemas, scope)
+		case *sqlparser.FuncExpr:
+			name := strings.ToLower(v.Name.String())
+			for _, prefix := range deniedSQLFunctionPrefixes {
+				if strings.HasPrefix(name, prefix) {
+					return false, fmt.Errorf("the function %s is not allowed", v.Name.String())
+				}
+			}
+		case *sqlparser.JoinTableExpr, *sqlparser.ParenTableExpr:
+		case *sqlparser.AliasedTableExpr:
+			switch expr := v.Expr.(type) {
+			case *sqlparser.Subquery:
+			case sqlparser.TableName:
+				if err := checkSQLTable(expr, schemas, scope); err != nil {
+					return false, err
+				}
+			default:
+				return false, fmt.Errorf("the table expression %s is not allowed", sqlparser.String(v))
+			}
+		case sqlparser.TableExpr:
+			return false, fmt.Errorf("the table expression %s is not allowed", sqlparser.String(v))
+		}
+		return true, nil
+	}, nodes...)
+}
+
+func checkSQLTable(table sqlparser.TableName, schemas []string, scope *sqlScope) error {
+	schema := table.DbQualifier.String()
+	if schema == "" && table.SchemaQualifier.IsEmpty() && scope.hasCTE(table.Name.String()) {
+		return nil
+	}
+	if !table.SchemaQualifier.IsEmpty() || !slices.Contains(schemas, schema) {
+		return fmt.Errorf("the table %s is not in one of the schemas %s", sqlparser.String(table), strings.Join(schemas, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package queryrestriction
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckSQLSchemas(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		schemas   []string
+		expectErr bool
+	}{
+		{
+			name:    "tables of an allowed schema",
+			query:   `SELECT $__timeGroupAlias(time, $__interval), avg(value) FROM team_a.metrics m JOIN team_a.hosts h ON m.host = h.id WHERE $__timeFilter(time) GROUP BY 1 ORDER BY 1`,
+			schemas: []string{"team_a"},
+		},
+		{
+			name:    "tables of one of the allowed schemas",
+			query:   `SELECT value FROM team_a.metrics UNION ALL SELECT value FROM team_b.metrics`,
+			schemas: []string{"team_a", "team_b"},
+		},
+		{
+			name:    "common table expression",
+			query:   `WITH recent AS (SELECT * FROM team_a.metrics WHERE time > now() - interval 1 hour) SELECT count(*) FROM recent`,
+			schemas: []string{"team_a"},
+		},
+		{
+			name:    "subquery",
+			query:   `SELECT * FROM (SELECT value FROM team_a.metrics) AS m WHERE m.value > (SELECT max(threshold) FROM team_a.thresholds)`,
+			schemas: []string{"team_a"},
+		},
+		{
+			name:    "recursive common table expression",
+			query:   `WITH RECURSIVE hosts AS (SELECT id, parent FROM team_a.hosts UNION ALL SELECT h.id, h.parent FROM team_a.hosts h JOIN hosts ON h.parent = hosts.id) SELECT * FROM hosts`,
+			schemas: []string{"team_a"},
+		},
+		{
+			name:      "common table expression referencing itself without recursive",
+			query:     `WITH metrics AS (SELECT * FROM metrics) SELECT * FROM metrics`,
+			schemas:   []string{"team_a"},
+			expectErr: true,
+		},
+		{
+			name:      "table named as the common table expression of another subquery",
+			query:     `SELECT * FROM (WITH metrics AS (SELECT * FROM team_a.metrics) SELECT * FROM metrics) a, (SELECT * FROM metrics) b`,
+			schemas:   []string{"team_a"},
+			expectErr: true,
+		},
+		{
+			name:      "table named as the common table expression of a union member",
+			query:     `(WITH metrics AS (SELECT * FROM team_a.metrics) SELECT * FROM metrics) UNION (SELECT * FROM metrics)`,
+			schemas:   []string{"team_a"},
+			expectErr: true,
+		},
+		{
+			name:      "select into outfile",
+			query:     `SELECT * FROM team_a.metrics INTO OUTFILE '/tmp/metrics.csv'`,
+			schemas:   []string{"team_a"},
+			expectErr: true,
+		},
+		{
+			name:      "select into dumpfile",
+			query:     `SELECT * FROM team_a.metrics LIMIT 1 INTO DUMPFILE '/tmp/metrics'`,
+			schemas:   []string{"team_a"},
+			expectErr: true,
+		},
+		{
+			name:      "table of another schema",
+			query:     `SELECT * FROM team_a.metrics, team_b.metrics`,
+			schemas:   []string{"team_a"},
+			expectErr: true,
+		},
+		{
+			name:      "table in a subquery of another schema",
+			query:     `SELECT * FROM team_a.metrics WHERE host IN (SELECT id FROM team_b.hosts)`,
+			schemas:   []string{"team_a"},
+			expectErr: true,
+		},
+		{
+			name:      "table in a macro argument of another schema",
+			query:     `SELECT * FROM team_a.metrics WHERE $__timeFilter((SELECT max(time) FROM team_b.metrics))`,
+			schemas:   []string{"team_a"},
+			expectErr: true,
+		},
+		{
+			name:      "table without schema",
+			query:     `SELECT * FROM metrics`,
+			schemas:   []string{"team_a"},
+			expectErr: true,
+		},
+		{
+			name:      "function reading a file",
+			query:     `SELECT load_file('/etc/passwd') FROM team_a.metrics`,
+			schemas:   []string{"team_a"},
+			expectErr: true,
+		},
+		{
+			name:      "statement other than select",
+			query:     `DELETE FROM team_a.metrics`,
+			schemas:   []string{"team_a"},
+			expectErr: true,
+		},
+		{
+			name:      "several statements",
+			query:     `SELECT * FROM team_a.metrics; SELECT * FROM team_b.metrics`,
+			schemas:   []string{"team_a"},
+			expectErr: true,
+		},
+		{
+			name:      "query which can't be parsed",
+			query:     `SELECT value::int FROM team_a.metrics`,
+			schemas:   []string{"team_a"},
+			expectErr: true,
+		},
+		{
+			name:  "no schema",
+			query: `SELECT * FROM metrics`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckSQLSchemas(tt.query, tt.schemas)
+			if tt.expectErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
@@ -147,23 +147,98 @@ func (api *ServiceAccountsAPI) CreateToken(c *contextmodel.ReqContext) response.
 	// Force affected service account to be the one referenced in the URL
 	cmd.OrgId = c.GetOrgID()
 
+	if resp := api.checkTokenExpiration(cmd.SecondsToLive); resp != nil {
+		return resp
+	}
+
+	newKeyInfo, err := satokengen.New(ServiceID)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Generating service account token failed", err)
+	}
+
+	cmd.Key = newKeyInfo.HashedKey
+
+	apiKey, err := api.service.AddServiceAccountToken(c.Req.Context(), saID, &cmd)
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "failed to add service account token", err)
+	}
+
+	result := &dtos.NewApiKeyResult{
+		ID:   apiKey.ID,
+		Name: apiKey.Name,
+		Key:  newKeyInfo.ClientSecret,
+	}
+
+	return response.JSON(http.StatusOK, result)
+}
+
+// checkTokenExpiration checks the token lifetime against the global limits of the configuration
+func (api *ServiceAccountsAPI) checkTokenExpiration(secondsToLive int64) response.Response {
 	if api.cfg.ApiKeyMaxSecondsToLive != -1 {
-		if cmd.SecondsToLive == 0 {
+		if secondsToLive == 0 {
 			return response.Error(http.StatusBadRequest, "Number of seconds before expiration should be set", nil)
 		}
-		if cmd.SecondsToLive > api.cfg.ApiKeyMaxSecondsToLive {
+		if secondsToLive > api.cfg.ApiKeyMaxSecondsToLive {
 			return response.Error(http.StatusBadRequest, "Number of seconds before expiration is greater than the global limit", nil)
 		}
 	}
 
 	if api.cfg.SATokenExpirationDayLimit > 0 {
 		dayExpireLimit := time.Now().Add(time.Duration(api.cfg.SATokenExpirationDayLimit) * time.Hour * 24).Truncate(24 * time.Hour)
-		expirationDate := time.Now().Add(time.Duration(cmd.SecondsToLive) * time.Second).Truncate(24 * time.Hour)
+		expirationDate := time.Now().Add(time.Duration(secondsToLive) * time.Second).Truncate(24 * time.Hour)
 		if expirationDate.After(dayExpireLimit) {
 			return response.Respond(http.StatusBadRequest, "The expiration date input exceeds the limit for service account access tokens expiration date")
 		}
 	}
 
+	return nil
+}
+
+// swagger:route POST /serviceaccounts/{serviceAccountId}/tokens/{tokenId}/rotate service_accounts rotateToken
+//
+// # RotateToken replaces a service account token with a new one
+//
+// The rotated token remains valid during the overlap period, so that the clients can switch to the new token, and is revoked afterwards.
+// Without overlap period the rotated token is revoked immediately.
+//
+// Required permissions (See note in the [introduction](https://grafana.com/docs/grafana/latest/developers/http_api/serviceaccount/#service-account-api) for an explanation):
+// action: `serviceaccounts:write` scope: `serviceaccounts:id:1` (single service account)
+//
+// Responses:
+// 200: createTokenResponse
+// 400: badRequestError
+// 401: unauthorisedError
+// 403: forbiddenError
+// 404: notFoundError
+// 409: conflictError
+// 500: internalServerError
+func (api *ServiceAccountsAPI) RotateToken(c *contextmodel.ReqContext) response.Response {
+	saID, err := strconv.ParseInt(web.Params(c.Req)[":serviceAccountId"], 10, 64)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "Service Account ID is invalid", err)
+	}
+
+	tokenID, err := strconv.ParseInt(web.Params(c.Req)[":tokenId"], 10, 64)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "Token ID is invalid", err)
+	}
+
+	cmd := serviceaccounts.RotateServiceAccountTokenCommand{}
+	if err = web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "Bad request data", err)
+	}
+
+	cmd.OrgID = c.GetOrgID()
+	cmd.ServiceAccountID = saID
+	cmd.TokenID = tokenID
+
+	// the lifetime of the rotated token is kept when not set
+	if cmd.SecondsToLive != 0 {
+		if resp := api.checkTokenExpiration(cmd.SecondsToLive); resp != nil {
+			return resp
+		}
+	}
+
 	newKeyInfo, err := satokengen.New(ServiceID)
 	if err != nil {
 		return response.Error(http.StatusInternalServerError, "Generating service account token failed", err)
@@ -171,9 +246,9 @@ func (api *ServiceAccountsAPI) CreateToken(c *contextmodel.ReqContext) response.
 
 	cmd.Key = newKeyInfo.HashedKey
 
-	apiKey, err := api.service.AddServiceAccountToken(c.Req.Context(), saID, &cmd)
+	apiKey, err := api.service.RotateServiceAccountToken(c.Req.Context(), &cmd)
 	if err != nil {
-		return response.ErrOrFallback(http.StatusInternalServerError, "failed to add service account token", err)
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to rotate service account token", err)
 	}
 
 	result := &dtos.NewApiKeyResult{
@@ -185,6 +260,57 @@ func (api *ServiceAccountsAPI) CreateToken(c *contextmodel.ReqContext) response.
 	return response.JSON(http.StatusOK, result)
 }
 
+// swagger:route GET /serviceaccounts/token-policy service_accounts getTokenPolicy
+//
+// # Get the token policy of the organization
+//
+// Required permissions (See note in the [introduction](https://grafana.com/docs/grafana/latest/developers/http_api/serviceaccount/#service-account-api) for an explanation):
+// action: `serviceaccounts:read` scope: N/A
+//
+// Responses:
+// 200: tokenPolicyResponse
+// 401: unauthorisedError
+// 403: forbiddenError
+// 500: internalServerError
+func (api *ServiceAccountsAPI) GetTokenPolicy(c *contextmodel.ReqContext) response.Response {
+	policy, err := api.service.GetTokenPolicy(c.Req.Context(), c.GetOrgID())
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to get the token policy", err)
+	}
+
+	return response.JSON(http.StatusOK, policy)
+}
+
+// swagger:route PUT /serviceaccounts/token-policy service_accounts updateTokenPolicy
+//
+// # Update the token policy of the organization
+//
+// The policy applies to the tokens created or rotated afterwards. The existing tokens expiring later than the maximum
+// lifetime counted from now are shortened to it, and the existing tokens without expiry that can't be shortened are reported.
+//
+// Required permissions (See note in the [introduction](https://grafana.com/docs/grafana/latest/developers/http_api/serviceaccount/#service-account-api) for an explanation):
+// action: `serviceaccounts:write` scope: `serviceaccounts:*`
+//
+// Responses:
+// 200: tokenPolicyReportResponse
+// 400: badRequestError
+// 401: unauthorisedError
+// 403: forbiddenError
+// 500: internalServerError
+func (api *ServiceAccountsAPI) UpdateTokenPolicy(c *contextmodel.ReqContext) response.Response {
+	policy := serviceaccounts.TokenPolicy{}
+	if err := web.Bind(c.Req, &policy); err != nil {
+		return response.Error(http.StatusBadRequest, "Bad request data", err)
+	}
+
+	report, err := api.service.SetTokenPolicy(c.Req.Context(), c.GetOrgID(), &policy)
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to update the token policy", err)
+	}
+
+	return response.JSON(http.StatusOK, report)
+}
+
 // swagger:route DELETE /serviceaccounts/{serviceAccountId}/tokens/{tokenId} service_accounts deleteToken
 //
 // # DeleteToken deletes service account tokens
@@ -249,6 +375,34 @@ type DeleteTokenParams struct {
 	ServiceAccountId int64 `json:"serviceAccountId"`
 }
 
+// swagger:parameters rotateToken
+type RotateTokenParams struct {
+	// in:path
+	TokenId int64 `json:"tokenId"`
+	// in:path
+	ServiceAccountId int64 `json:"serviceAccountId"`
+	// in:body
+	Body serviceaccounts.RotateServiceAccountTokenCommand
+}
+
+// swagger:parameters updateTokenPolicy
+type UpdateTokenPolicyParams struct {
+	// in:body
+	Body serviceaccounts.TokenPolicy
+}
+
+// swagger:response tokenPolicyResponse
+type TokenPolicyResponse struct {
+	// in:body
+	Body serviceaccounts.TokenPolicy
+}
+
+// swagger:response tokenPolicyReportResponse
+type TokenPolicyReportResponse struct {
+	// in:body
+	Body serviceaccounts.TokenPolicyReport
+}
+
 // swagger:response listTokensResponse
 type ListTokensResponse struct {
 	// in:body
@@ -178,3 +178,136 @@ func TestServiceAccountsAPI_DeleteToken(t *testing.T) {
 		})
 	}
 }
+
+func TestServiceAccountsAPI_RotateToken(t *testing.T) {
+	type TestCase struct {
+		desc           string
+		saID           int64
+		body           string
+		permissions    []accesscontrol.Permission
+		tokenTTL       int64
+		expectedErr    error
+		expectedAPIKey *apikey.APIKey
+		expectedCode   int
+	}
+
+	tests := []TestCase{
+		{
+			desc:           "should be able to rotate service account token with correct permission",
+			saID:           1,
+			body:           `{"overlapSeconds": 3600}`,
+			tokenTTL:       -1,
+			permissions:    []accesscontrol.Permission{{Action: serviceaccounts.ActionWrite, Scope: "serviceaccounts:id:1"}},
+			expectedAPIKey: &apikey.APIKey{ID: 2, Name: "test-rotated"},
+			expectedCode:   http.StatusOK,
+		},
+		{
+			desc:         "should not be able to rotate service account token with wrong permission",
+			saID:         2,
+			body:         `{}`,
+			tokenTTL:     -1,
+			permissions:  []accesscontrol.Permission{{Action: serviceaccounts.ActionWrite, Scope: "serviceaccounts:id:1"}},
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			desc:         "should not be able to rotate service account token over the global limit",
+			saID:         1,
+			body:         `{"secondsToLive": 7200}`,
+			tokenTTL:     3600,
+			permissions:  []accesscontrol.Permission{{Action: serviceaccounts.ActionWrite, Scope: "serviceaccounts:id:1"}},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			desc:         "should not be able to rotate a revoked service account token",
+			saID:         1,
+			body:         `{}`,
+			tokenTTL:     -1,
+			permissions:  []accesscontrol.Permission{{Action: serviceaccounts.ActionWrite, Scope: "serviceaccounts:id:1"}},
+			expectedErr:  serviceaccounts.ErrTokenAlreadyRevoked.Errorf(""),
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			server := setupTests(t, func(a *ServiceAccountsAPI) {
+				a.cfg.ApiKeyMaxSecondsToLive = tt.tokenTTL
+				a.service = &satests.FakeServiceAccountService{
+					ExpectedErr:    tt.expectedErr,
+					ExpectedAPIKey: tt.expectedAPIKey,
+				}
+			})
+
+			req := server.NewRequest(http.MethodPost, fmt.Sprintf("/api/serviceaccounts/%d/tokens/1/rotate", tt.saID), strings.NewReader(tt.body))
+			webtest.RequestWithSignedInUser(req, &user.SignedInUser{OrgID: 1, Permissions: map[int64]map[string][]string{1: accesscontrol.GroupScopesByActionContext(context.Background(), tt.permissions)}})
+			res, err := server.SendJSON(req)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.expectedCode, res.StatusCode)
+			require.NoError(t, res.Body.Close())
+		})
+	}
+}
+
+func TestServiceAccountsAPI_TokenPolicy(t *testing.T) {
+	type TestCase struct {
+		desc         string
+		method       string
+		body         string
+		permissions  []accesscontrol.Permission
+		expectedErr  error
+		expectedCode int
+	}
+
+	tests := []TestCase{
+		{
+			desc:         "should be able to get the token policy with correct permission",
+			method:       http.MethodGet,
+			permissions:  []accesscontrol.Permission{{Action: serviceaccounts.ActionRead}},
+			expectedCode: http.StatusOK,
+		},
+		{
+			desc:         "should not be able to get the token policy without permission",
+			method:       http.MethodGet,
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			desc:         "should be able to update the token policy with correct permission",
+			method:       http.MethodPut,
+			body:         `{"maxSecondsToLive": 3600, "expiryRequired": true}`,
+			permissions:  []accesscontrol.Permission{{Action: serviceaccounts.ActionWrite, Scope: serviceaccounts.ScopeAll}},
+			expectedCode: http.StatusOK,
+		},
+		{
+			desc:         "should not be able to update the token policy with the permission on a single service account",
+			method:       http.MethodPut,
+			body:         `{"maxSecondsToLive": 3600}`,
+			permissions:  []accesscontrol.Permission{{Action: serviceaccounts.ActionWrite, Scope: "serviceaccounts:id:1"}},
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			desc:         "should not be able to update the token policy with an invalid policy",
+			method:       http.MethodPut,
+			body:         `{"maxSecondsToLive": -1}`,
+			permissions:  []accesscontrol.Permission{{Action: serviceaccounts.ActionWrite, Scope: serviceaccounts.ScopeAll}},
+			expectedErr:  serviceaccounts.ErrInvalidTokenPolicy.Errorf(""),
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			server := setupTests(t, func(a *ServiceAccountsAPI) {
+				a.service = &satests.FakeServiceAccountService{ExpectedErr: tt.expectedErr}
+			})
+
+			req := server.NewRequest(tt.method, "/api/serviceaccounts/token-policy", strings.NewReader(tt.body))
+			webtest.RequestWithSignedInUser(req, &user.SignedInUser{OrgID: 1, Permissions: map[int64]map[string][]string{1: accesscontrol.GroupScopesByActionContext(context.Background(), tt.permissions)}})
+			res, err := server.SendJSON(req)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.expectedCode, res.StatusCode)
+			require.NoError(t, res.Body.Close())
+		})
+	}
+}
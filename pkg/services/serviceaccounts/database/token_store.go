@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/grafana/grafana/pkg/infra/db"
 	"github.com/grafana/grafana/pkg/services/apikey"
@@ -109,6 +110,51 @@ func (s *ServiceAccountsStoreImpl) RevokeServiceAccountToken(ctx context.Context
 	})
 }
 
+// SetServiceAccountTokenExpiry sets the expiry of a service account token, expires is a unix timestamp
+func (s *ServiceAccountsStoreImpl) SetServiceAccountTokenExpiry(ctx context.Context, orgId, serviceAccountId, tokenId int64, expires int64) error {
+	rawSQL := "UPDATE api_key SET expires = ? WHERE id=? and org_id=? and service_account_id=?"
+
+	return s.sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+		result, err := sess.Exec(rawSQL, expires, tokenId, orgId, serviceAccountId)
+		if err != nil {
+			return err
+		}
+		affected, err := result.RowsAffected()
+		if affected == 0 {
+			return serviceaccounts.ErrServiceAccountTokenNotFound.Errorf("service account token with id %d not found for service account with id %d", tokenId, serviceAccountId)
+		}
+
+		return err
+	})
+}
+
+// ListActiveTokens returns the service account tokens of the organization that are neither revoked nor expired
+func (s *ServiceAccountsStoreImpl) ListActiveTokens(ctx context.Context, orgId int64) ([]apikey.APIKey, error) {
+	result := make([]apikey.APIKey, 0)
+	err := s.sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+		return sess.Where("org_id=? AND service_account_id IS NOT NULL", orgId).
+			Where("(expires IS NULL OR expires > ?)", time.Now().Unix()).
+			Where("(is_revoked IS NULL OR is_revoked = ?)", s.sqlStore.GetDialect().BooleanValue(false)).
+			Asc("id").
+			Find(&result)
+	})
+	return result, err
+}
+
+// ListExpiringTokens returns the service account tokens of the organization that are neither revoked nor expired
+// and expire before the given unix timestamp
+func (s *ServiceAccountsStoreImpl) ListExpiringTokens(ctx context.Context, orgId int64, expiresBefore int64) ([]apikey.APIKey, error) {
+	result := make([]apikey.APIKey, 0)
+	err := s.sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+		return sess.Where("org_id=? AND service_account_id IS NOT NULL", orgId).
+			Where("expires IS NOT NULL AND expires > ? AND expires <= ?", time.Now().Unix(), expiresBefore).
+			Where("(is_revoked IS NULL OR is_revoked = ?)", s.sqlStore.GetDialect().BooleanValue(false)).
+			Asc("expires").
+			Find(&result)
+	})
+	return result, err
+}
+
 // assignApiKeyToServiceAccount sets the API key service account ID
 func (s *ServiceAccountsStoreImpl) assignApiKeyToServiceAccount(ctx context.Context, apiKeyId int64, serviceAccountId int64) error {
 	return s.sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
@@ -3,6 +3,7 @@ package database
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
@@ -176,3 +177,55 @@ func TestIntegration_Store_DeleteServiceAccountToken(t *testing.T) {
 		}
 	}
 }
+
+func TestIntegration_Store_ListExpiringTokens(t *testing.T) {
+	userToCreate := tests.TestUser{Login: "servicetestwithTeam@admin", IsServiceAccount: true}
+	db, store := setupTestDatabase(t)
+	sa := tests.SetupUserServiceAccount(t, db, store.cfg, userToCreate)
+
+	addToken := func(name string, secondsToLive int64) int64 {
+		key, err := apikeygen.New(sa.OrgID, name)
+		require.NoError(t, err)
+		token, err := store.AddServiceAccountToken(context.Background(), sa.ID, &serviceaccounts.AddServiceAccountTokenCommand{
+			Name:          name,
+			OrgId:         sa.OrgID,
+			Key:           key.HashedKey,
+			SecondsToLive: secondsToLive,
+		})
+		require.NoError(t, err)
+		return token.ID
+	}
+
+	soon := addToken("soon", 3600)
+	shortened := addToken("shortened", 30*24*3600)
+	revoked := addToken("revoked", 3600)
+	later := addToken("later", 30*24*3600)
+	never := addToken("never", 0)
+	expired := addToken("expired", 3600)
+
+	require.NoError(t, store.RevokeServiceAccountToken(context.Background(), sa.OrgID, sa.ID, revoked))
+	require.NoError(t, store.SetServiceAccountTokenExpiry(context.Background(), sa.OrgID, sa.ID, shortened, time.Now().Add(2*time.Hour).Unix()))
+	require.Error(t, store.SetServiceAccountTokenExpiry(context.Background(), sa.OrgID+2, sa.ID, shortened, time.Now().Unix()))
+	require.NoError(t, store.SetServiceAccountTokenExpiry(context.Background(), sa.OrgID, sa.ID, expired, time.Now().Add(-time.Minute).Unix()))
+
+	keys, err := store.ListExpiringTokens(context.Background(), sa.OrgID, time.Now().Add(24*time.Hour).Unix())
+	require.NoError(t, err)
+
+	ids := make([]int64, 0, len(keys))
+	for _, k := range keys {
+		ids = append(ids, k.ID)
+	}
+	require.Equal(t, []int64{soon, shortened}, ids)
+
+	keys, err = store.ListExpiringTokens(context.Background(), sa.OrgID+2, time.Now().Add(24*time.Hour).Unix())
+	require.NoError(t, err)
+	require.Empty(t, keys)
+
+	keys, err = store.ListActiveTokens(context.Background(), sa.OrgID)
+	require.NoError(t, err)
+	ids = ids[:0]
+	for _, k := range keys {
+		ids = append(ids, k.ID)
+	}
+	require.Equal(t, []int64{soon, shortened, later, never}, ids)
+}
@@ -17,6 +17,8 @@ import (
 	"github.com/grafana/grafana/pkg/infra/usagestats"
 	"github.com/grafana/grafana/pkg/services/accesscontrol"
 	"github.com/grafana/grafana/pkg/services/apikey"
+	"github.com/grafana/grafana/pkg/services/featuremgmt"
+	"github.com/grafana/grafana/pkg/services/notifications"
 	"github.com/grafana/grafana/pkg/services/org"
 	"github.com/grafana/grafana/pkg/services/serviceaccounts"
 	"github.com/grafana/grafana/pkg/services/serviceaccounts/database"
@@ -42,9 +44,12 @@ type ServiceAccountsService struct {
 	secretScanService secretscan.Checker
 	orgService        org.Service
 	serverLock        *serverlock.ServerLockService
+	kvStore           kvstore.KVStore
+	notifications     notifications.EmailSender
 
-	secretScanEnabled  bool
-	secretScanInterval time.Duration
+	secretScanEnabled     bool
+	secretScanInterval    time.Duration
+	extSvcAccountsEnabled bool
 }
 
 func ProvideServiceAccountsService(
@@ -58,6 +63,8 @@ func ProvideServiceAccountsService(
 	acService accesscontrol.Service,
 	permissions accesscontrol.ServiceAccountPermissionsService,
 	serverLockService *serverlock.ServerLockService,
+	notificationService notifications.EmailSender,
+	features featuremgmt.FeatureToggles,
 ) (*ServiceAccountsService, error) {
 	serviceAccountsStore := database.ProvideServiceAccountsStore(
 		cfg,
@@ -77,6 +84,10 @@ func ProvideServiceAccountsService(
 		backgroundLog: log.New("serviceaccounts.background"),
 		orgService:    orgService,
 		serverLock:    serverLockService,
+		kvStore:       kvStore,
+		notifications: notificationService,
+
+		extSvcAccountsEnabled: cfg.ManagedServiceAccountsEnabled && features.IsEnabledGlobally(featuremgmt.FlagExternalServiceAccounts),
 	}
 
 	if err := RegisterRoles(acService); err != nil {
@@ -143,6 +154,9 @@ func (sa *ServiceAccountsService) Run(ctx context.Context) error {
 		defer tokenCheckTicker.Stop()
 	}
 
+	tokenLifecycleTicker := time.NewTicker(tokenLifecycleInterval)
+	defer tokenLifecycleTicker.Stop()
+
 	for {
 		select {
 		case <-ctx.Done():
@@ -165,6 +179,12 @@ func (sa *ServiceAccountsService) Run(ctx context.Context) error {
 			if err := sa.secretScanService.CheckTokens(ctx); err != nil {
 				sa.backgroundLog.Warn("Failed to check for leaked tokens", "error", err.Error())
 			}
+		case <-tokenLifecycleTicker.C:
+			sa.backgroundLog.Debug("Processing service account token rotations and expiry warnings")
+
+			if err := sa.serverLock.LockAndExecute(ctx, "service account token lifecycle", tokenLifecycleInterval, sa.processTokenLifecycle); err != nil {
+				sa.backgroundLog.Warn("Failed to lock and execute the service account token lifecycle", "error", err)
+			}
 		}
 	}
 }
@@ -239,9 +259,20 @@ func (sa *ServiceAccountsService) DeleteServiceAccount(ctx context.Context, orgI
 	if err := validServiceAccountID(serviceAccountID); err != nil {
 		return err
 	}
+	tokens, err := sa.store.ListTokens(ctx, &serviceaccounts.GetSATokensQuery{OrgID: &orgID, ServiceAccountID: &serviceAccountID})
+	if err != nil {
+		return err
+	}
 	if err := sa.store.DeleteServiceAccount(ctx, orgID, serviceAccountID); err != nil {
 		return err
 	}
+	tokenIDs := make([]int64, 0, len(tokens))
+	for _, token := range tokens {
+		tokenIDs = append(tokenIDs, token.ID)
+	}
+	if err := sa.deleteTokenWarnings(ctx, orgID, tokenIDs...); err != nil {
+		return err
+	}
 	if err := sa.acService.DeleteUserPermissions(ctx, orgID, serviceAccountID); err != nil {
 		return err
 	}
@@ -285,6 +316,15 @@ func (sa *ServiceAccountsService) AddServiceAccountToken(ctx context.Context, se
 	if err := validServiceAccountID(serviceAccountID); err != nil {
 		return nil, err
 	}
+	isExternal, err := sa.isExternalServiceAccount(ctx, query.OrgId, serviceAccountID)
+	if err != nil {
+		return nil, err
+	}
+	if !isExternal {
+		if err := sa.checkTokenPolicy(ctx, query.OrgId, query.SecondsToLive); err != nil {
+			return nil, err
+		}
+	}
 	return sa.store.AddServiceAccountToken(ctx, serviceAccountID, query)
 }
 
@@ -298,7 +338,10 @@ func (sa *ServiceAccountsService) DeleteServiceAccountToken(ctx context.Context,
 	if err := validServiceAccountTokenID(tokenID); err != nil {
 		return err
 	}
-	return sa.store.DeleteServiceAccountToken(ctx, orgID, serviceAccountID, tokenID)
+	if err := sa.store.DeleteServiceAccountToken(ctx, orgID, serviceAccountID, tokenID); err != nil {
+		return err
+	}
+	return sa.deleteTokenWarnings(ctx, orgID, tokenID)
 }
 
 func (sa *ServiceAccountsService) MigrateApiKey(ctx context.Context, orgID, keyID int64) error {
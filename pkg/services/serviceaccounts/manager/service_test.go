@@ -26,6 +26,9 @@ type FakeServiceAccountStore struct {
 	ExpectedAPIKey                          *apikey.APIKey
 	ExpectedBoolean                         bool
 	ExpectedError                           error
+
+	RevokedTokenIDs []int64
+	TokenExpiries   map[int64]int64
 }
 
 var _ store = (*FakeServiceAccountStore)(nil)
@@ -90,8 +93,28 @@ func (f *FakeServiceAccountStore) ListTokens(ctx context.Context, query *service
 	return f.ExpectedAPIKeys, f.ExpectedError
 }
 
+// ListActiveTokens is a fake listing active tokens.
+func (f *FakeServiceAccountStore) ListActiveTokens(ctx context.Context, orgID int64) ([]apikey.APIKey, error) {
+	return f.ExpectedAPIKeys, f.ExpectedError
+}
+
+// ListExpiringTokens is a fake listing expiring tokens.
+func (f *FakeServiceAccountStore) ListExpiringTokens(ctx context.Context, orgID int64, expiresBefore int64) ([]apikey.APIKey, error) {
+	return f.ExpectedAPIKeys, f.ExpectedError
+}
+
+// SetServiceAccountTokenExpiry is a fake setting the expiry of a service account token.
+func (f *FakeServiceAccountStore) SetServiceAccountTokenExpiry(ctx context.Context, orgID, serviceAccountID, tokenID int64, expires int64) error {
+	if f.TokenExpiries == nil {
+		f.TokenExpiries = map[int64]int64{}
+	}
+	f.TokenExpiries[tokenID] = expires
+	return f.ExpectedError
+}
+
 // RevokeServiceAccountToken is a fake revoking a service account token.
 func (f *FakeServiceAccountStore) RevokeServiceAccountToken(ctx context.Context, orgId, serviceAccountId, tokenId int64) error {
+	f.RevokedTokenIDs = append(f.RevokedTokenIDs, tokenId)
 	return f.ExpectedError
 }
 
@@ -0,0 +1,479 @@
+package manager
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/kvstore"
+	"github.com/grafana/grafana/pkg/services/apikey"
+	"github.com/grafana/grafana/pkg/services/notifications"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/serviceaccounts"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+const (
+	tokenPolicyNamespace        = "serviceaccounts.token-policy"
+	tokenPolicyKey              = "policy"
+	tokenRotationNamespace      = "serviceaccounts.token-rotation"
+	tokenExpiryWarningNamespace = "serviceaccounts.token-expiry-warning"
+
+	tokenLifecycleInterval = time.Minute * 5
+	tokenExpiringTemplate  = "service_account_token_expiring"
+)
+
+// pendingTokenRevocation is a rotated token to revoke once the overlap period is over
+type pendingTokenRevocation struct {
+	ServiceAccountID int64 `json:"serviceAccountId"`
+	RevokeAt         int64 `json:"revokeAt"`
+}
+
+func (sa *ServiceAccountsService) GetTokenPolicy(ctx context.Context, orgID int64) (*serviceaccounts.TokenPolicy, error) {
+	if err := validOrgID(orgID); err != nil {
+		return nil, err
+	}
+
+	policy := &serviceaccounts.TokenPolicy{}
+	value, ok, err := kvstore.WithNamespace(sa.kvStore, orgID, tokenPolicyNamespace).Get(ctx, tokenPolicyKey)
+	if err != nil || !ok {
+		return policy, err
+	}
+	if err := json.Unmarshal([]byte(value), policy); err != nil {
+		return nil, fmt.Errorf("failed to decode the token policy: %w", err)
+	}
+	return policy, nil
+}
+
+func (sa *ServiceAccountsService) SetTokenPolicy(ctx context.Context, orgID int64, policy *serviceaccounts.TokenPolicy) (*serviceaccounts.TokenPolicyReport, error) {
+	if err := validOrgID(orgID); err != nil {
+		return nil, err
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+
+	value, err := json.Marshal(policy)
+	if err != nil {
+		return nil, err
+	}
+	if err := kvstore.WithNamespace(sa.kvStore, orgID, tokenPolicyNamespace).Set(ctx, tokenPolicyKey, string(value)); err != nil {
+		return nil, err
+	}
+	return sa.enforceTokenPolicy(ctx, orgID, policy)
+}
+
+func (sa *ServiceAccountsService) checkTokenPolicy(ctx context.Context, orgID int64, secondsToLive int64) error {
+	policy, err := sa.GetTokenPolicy(ctx, orgID)
+	if err != nil {
+		return err
+	}
+	return policy.CheckSecondsToLive(secondsToLive)
+}
+
+// checkTokenLimits checks a token lifetime against the global limits of the configuration, without expiry when 0
+func (sa *ServiceAccountsService) checkTokenLimits(secondsToLive int64, now time.Time) error {
+	if sa.cfg.ApiKeyMaxSecondsToLive != -1 {
+		if secondsToLive <= 0 {
+			return serviceaccounts.ErrInvalidTokenExpiration.Errorf("token without expiry, the global limit is %ds", sa.cfg.ApiKeyMaxSecondsToLive)
+		}
+		if secondsToLive > sa.cfg.ApiKeyMaxSecondsToLive {
+			return serviceaccounts.ErrInvalidTokenExpiration.Errorf("token lifetime %ds exceeds the global limit %ds", secondsToLive, sa.cfg.ApiKeyMaxSecondsToLive)
+		}
+	}
+
+	if sa.cfg.SATokenExpirationDayLimit > 0 {
+		if secondsToLive <= 0 {
+			return serviceaccounts.ErrInvalidTokenExpiration.Errorf("token without expiry, the expiration limit is %d days", sa.cfg.SATokenExpirationDayLimit)
+		}
+		dayExpireLimit := now.Add(time.Duration(sa.cfg.SATokenExpirationDayLimit) * time.Hour * 24).Truncate(24 * time.Hour)
+		expirationDate := now.Add(time.Duration(secondsToLive) * time.Second).Truncate(24 * time.Hour)
+		if expirationDate.After(dayExpireLimit) {
+			return serviceaccounts.ErrInvalidTokenExpiration.Errorf("token expiration date exceeds the limit of %d days", sa.cfg.SATokenExpirationDayLimit)
+		}
+	}
+	return nil
+}
+
+// isExternalServiceAccount checks if the service account is managed by Grafana for an external service.
+// Its tokens are created by Grafana without expiry, the token policy doesn't apply to them. The external service
+// account logins are only reserved when the managed service accounts are enabled, otherwise any service account
+// can be named like one.
+func (sa *ServiceAccountsService) isExternalServiceAccount(ctx context.Context, orgID, serviceAccountID int64) (bool, error) {
+	if !sa.extSvcAccountsEnabled {
+		return false, nil
+	}
+	serviceAccount, err := sa.store.RetrieveServiceAccount(ctx, &serviceaccounts.GetServiceAccountQuery{OrgID: orgID, ID: serviceAccountID})
+	if err != nil {
+		return false, err
+	}
+	return serviceaccounts.IsExternalServiceAccount(serviceAccount.Login), nil
+}
+
+// enforceTokenPolicy applies the token policy to the existing tokens. The tokens expiring later than the maximum
+// lifetime counted from now are shortened to it, and the tokens without expiry which can't be shortened are reported.
+func (sa *ServiceAccountsService) enforceTokenPolicy(ctx context.Context, orgID int64, policy *serviceaccounts.TokenPolicy) (*serviceaccounts.TokenPolicyReport, error) {
+	report := &serviceaccounts.TokenPolicyReport{
+		ShortenedTokens:    []serviceaccounts.TokenPolicyViolation{},
+		NonCompliantTokens: []serviceaccounts.TokenPolicyViolation{},
+	}
+	if !policy.ExpiryRequired && policy.MaxSecondsToLive <= 0 {
+		return report, nil
+	}
+
+	tokens, err := sa.store.ListActiveTokens(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	maxExpires := time.Now().Add(time.Duration(policy.MaxSecondsToLive) * time.Second).Unix()
+	external := map[int64]bool{}
+	for _, token := range tokens {
+		if token.ServiceAccountId == nil {
+			continue
+		}
+		if token.Expires != nil && (policy.MaxSecondsToLive <= 0 || *token.Expires <= maxExpires) {
+			continue
+		}
+
+		saID := *token.ServiceAccountId
+		isExternal, ok := external[saID]
+		if !ok {
+			if isExternal, err = sa.isExternalServiceAccount(ctx, orgID, saID); err != nil {
+				return nil, err
+			}
+			external[saID] = isExternal
+		}
+		if isExternal {
+			continue
+		}
+
+		violation := serviceaccounts.TokenPolicyViolation{ServiceAccountID: saID, TokenID: token.ID, TokenName: token.Name}
+		if policy.MaxSecondsToLive <= 0 {
+			report.NonCompliantTokens = append(report.NonCompliantTokens, violation)
+			continue
+		}
+
+		if err := sa.store.SetServiceAccountTokenExpiry(ctx, orgID, saID, token.ID, maxExpires); err != nil {
+			return nil, err
+		}
+		expires := time.Unix(maxExpires, 0)
+		violation.Expires = &expires
+		report.ShortenedTokens = append(report.ShortenedTokens, violation)
+	}
+	return report, nil
+}
+
+// enforceTokenPolicies applies the token policies to the tokens created without them, like the migrated API keys
+func (sa *ServiceAccountsService) enforceTokenPolicies(ctx context.Context) error {
+	policies, err := sa.kvStore.GetAll(ctx, kvstore.AllOrganizations, tokenPolicyNamespace)
+	if err != nil {
+		return err
+	}
+
+	for orgID, values := range policies {
+		var policy serviceaccounts.TokenPolicy
+		if err := json.Unmarshal([]byte(values[tokenPolicyKey]), &policy); err != nil {
+			continue
+		}
+
+		report, err := sa.enforceTokenPolicy(ctx, orgID, &policy)
+		if err != nil {
+			return err
+		}
+		for _, token := range report.ShortenedTokens {
+			sa.backgroundLog.Info("Shortened service account token to the token policy", "orgId", orgID,
+				"serviceAccountId", token.ServiceAccountID, "tokenId", token.TokenID, "expires", token.Expires)
+		}
+		if len(report.NonCompliantTokens) > 0 {
+			sa.backgroundLog.Warn("Service account tokens without expiry violate the token policy", "orgId", orgID,
+				"tokens", len(report.NonCompliantTokens))
+		}
+	}
+	return nil
+}
+
+func (sa *ServiceAccountsService) RotateServiceAccountToken(ctx context.Context, cmd *serviceaccounts.RotateServiceAccountTokenCommand) (*apikey.APIKey, error) {
+	if err := validOrgID(cmd.OrgID); err != nil {
+		return nil, err
+	}
+	if err := validServiceAccountID(cmd.ServiceAccountID); err != nil {
+		return nil, err
+	}
+	if err := validServiceAccountTokenID(cmd.TokenID); err != nil {
+		return nil, err
+	}
+	if cmd.OverlapSeconds < 0 {
+		return nil, serviceaccounts.ErrInvalidTokenExpiration.Errorf("invalid overlap period %d", cmd.OverlapSeconds)
+	}
+
+	tokens, err := sa.store.ListTokens(ctx, &serviceaccounts.GetSATokensQuery{OrgID: &cmd.OrgID, ServiceAccountID: &cmd.ServiceAccountID})
+	if err != nil {
+		return nil, err
+	}
+	var rotated *apikey.APIKey
+	for i := range tokens {
+		if tokens[i].ID == cmd.TokenID {
+			rotated = &tokens[i]
+			break
+		}
+	}
+	if rotated == nil {
+		return nil, serviceaccounts.ErrServiceAccountTokenNotFound.Errorf("service account token with id %d not found", cmd.TokenID)
+	}
+
+	now := time.Now()
+	if (rotated.IsRevoked != nil && *rotated.IsRevoked) || (rotated.Expires != nil && *rotated.Expires <= now.Unix()) {
+		return nil, serviceaccounts.ErrTokenAlreadyRevoked.Errorf("service account token with id %d is revoked or expired", cmd.TokenID)
+	}
+
+	name := cmd.Name
+	if name == "" {
+		name = fmt.Sprintf("%s-rotated-%d", rotated.Name, now.Unix())
+	}
+	secondsToLive := cmd.SecondsToLive
+	if secondsToLive == 0 && rotated.Expires != nil {
+		// keep the lifetime of the rotated token
+		secondsToLive = max(*rotated.Expires-rotated.Created.Unix(), 1)
+	}
+	// the kept lifetime is checked too, the rotated token may be older than the limits
+	if err := sa.checkTokenLimits(secondsToLive, now); err != nil {
+		return nil, err
+	}
+	if err := sa.checkTokenPolicy(ctx, cmd.OrgID, secondsToLive); err != nil {
+		return nil, err
+	}
+
+	var token *apikey.APIKey
+	err = sa.db.InTransaction(ctx, func(ctx context.Context) error {
+		token, err = sa.store.AddServiceAccountToken(ctx, cmd.ServiceAccountID, &serviceaccounts.AddServiceAccountTokenCommand{
+			Name:          name,
+			OrgId:         cmd.OrgID,
+			Key:           cmd.Key,
+			SecondsToLive: secondsToLive,
+		})
+		if err != nil {
+			return err
+		}
+
+		if cmd.OverlapSeconds == 0 {
+			return sa.store.RevokeServiceAccountToken(ctx, cmd.OrgID, cmd.ServiceAccountID, cmd.TokenID)
+		}
+
+		// the rotated token expires at the end of the overlap period and is revoked by the background job
+		revokeAt := now.Add(time.Duration(cmd.OverlapSeconds) * time.Second).Unix()
+		if rotated.Expires == nil || *rotated.Expires > revokeAt {
+			if err := sa.store.SetServiceAccountTokenExpiry(ctx, cmd.OrgID, cmd.ServiceAccountID, cmd.TokenID, revokeAt); err != nil {
+				return err
+			}
+		} else {
+			revokeAt = *rotated.Expires
+		}
+
+		value, err := json.Marshal(pendingTokenRevocation{ServiceAccountID: cmd.ServiceAccountID, RevokeAt: revokeAt})
+		if err != nil {
+			return err
+		}
+		return kvstore.WithNamespace(sa.kvStore, cmd.OrgID, tokenRotationNamespace).
+			Set(ctx, strconv.FormatInt(cmd.TokenID, 10), string(value))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sa.log.Info("Rotated service account token", "orgId", cmd.OrgID, "serviceAccountId", cmd.ServiceAccountID,
+		"tokenId", cmd.TokenID, "newTokenId", token.ID, "overlapSeconds", cmd.OverlapSeconds)
+	return token, nil
+}
+
+// processTokenLifecycle revokes the rotated tokens whose overlap period is over, applies the token policies
+// and warns the organization admins about the tokens expiring soon
+func (sa *ServiceAccountsService) processTokenLifecycle(ctx context.Context) {
+	if err := sa.revokeRotatedTokens(ctx); err != nil {
+		sa.backgroundLog.Warn("Failed to revoke rotated service account tokens", "error", err)
+	}
+	if err := sa.enforceTokenPolicies(ctx); err != nil {
+		sa.backgroundLog.Warn("Failed to apply the service account token policies", "error", err)
+	}
+	if err := sa.warnExpiringTokens(ctx); err != nil {
+		sa.backgroundLog.Warn("Failed to warn about expiring service account tokens", "error", err)
+	}
+	if err := sa.deleteExpiredTokenWarnings(ctx); err != nil {
+		sa.backgroundLog.Warn("Failed to delete the warnings of expired service account tokens", "error", err)
+	}
+}
+
+func (sa *ServiceAccountsService) revokeRotatedTokens(ctx context.Context) error {
+	pending, err := sa.kvStore.GetAll(ctx, kvstore.AllOrganizations, tokenRotationNamespace)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().Unix()
+	for orgID, revocations := range pending {
+		kv := kvstore.WithNamespace(sa.kvStore, orgID, tokenRotationNamespace)
+		for key, value := range revocations {
+			tokenID, err := strconv.ParseInt(key, 10, 64)
+			if err != nil {
+				sa.backgroundLog.Warn("Invalid pending token revocation", "orgId", orgID, "key", key)
+				_ = kv.Del(ctx, key)
+				continue
+			}
+			var revocation pendingTokenRevocation
+			if err := json.Unmarshal([]byte(value), &revocation); err != nil {
+				sa.backgroundLog.Warn("Invalid pending token revocation", "orgId", orgID, "tokenId", tokenID, "error", err)
+				_ = kv.Del(ctx, key)
+				continue
+			}
+			if revocation.RevokeAt > now {
+				continue
+			}
+
+			err = sa.store.RevokeServiceAccountToken(ctx, orgID, revocation.ServiceAccountID, tokenID)
+			if err != nil && !errors.Is(err, serviceaccounts.ErrServiceAccountTokenNotFound) {
+				sa.backgroundLog.Warn("Failed to revoke rotated service account token", "orgId", orgID, "tokenId", tokenID, "error", err)
+				continue
+			}
+			sa.backgroundLog.Info("Revoked rotated service account token", "orgId", orgID,
+				"serviceAccountId", revocation.ServiceAccountID, "tokenId", tokenID)
+			if err := kv.Del(ctx, key); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (sa *ServiceAccountsService) warnExpiringTokens(ctx context.Context) error {
+	policies, err := sa.kvStore.GetAll(ctx, kvstore.AllOrganizations, tokenPolicyNamespace)
+	if err != nil {
+		return err
+	}
+
+	for orgID, values := range policies {
+		var policy serviceaccounts.TokenPolicy
+		if err := json.Unmarshal([]byte(values[tokenPolicyKey]), &policy); err != nil || policy.WarnBeforeExpirySeconds <= 0 {
+			continue
+		}
+
+		expiresBefore := time.Now().Add(time.Duration(policy.WarnBeforeExpirySeconds) * time.Second).Unix()
+		tokens, err := sa.store.ListExpiringTokens(ctx, orgID, expiresBefore)
+		if err != nil {
+			return err
+		}
+		if len(tokens) == 0 {
+			continue
+		}
+
+		if err := sa.warnOrgAdmins(ctx, orgID, tokens); err != nil {
+			sa.backgroundLog.Warn("Failed to warn about expiring service account tokens", "orgId", orgID, "error", err)
+		}
+	}
+	return nil
+}
+
+func (sa *ServiceAccountsService) warnOrgAdmins(ctx context.Context, orgID int64, tokens []apikey.APIKey) error {
+	kv := kvstore.WithNamespace(sa.kvStore, orgID, tokenExpiryWarningNamespace)
+
+	var recipients []string
+	var orgName string
+	for _, token := range tokens {
+		key := strconv.FormatInt(token.ID, 10)
+		expires := strconv.FormatInt(*token.Expires, 10)
+		// the expiry changes when the token is shortened by a rotation, warn again in that case
+		if warned, ok, err := kv.Get(ctx, key); err != nil || (ok && warned == expires) {
+			continue
+		}
+
+		if recipients == nil {
+			o, err := sa.orgService.GetByID(ctx, &org.GetOrgByIDQuery{ID: orgID})
+			if err != nil {
+				return err
+			}
+			orgName = o.Name
+			if recipients, err = sa.orgAdminEmails(ctx, orgID); err != nil {
+				return err
+			}
+		}
+		if len(recipients) == 0 {
+			sa.backgroundLog.Debug("No organization admin to warn about expiring service account tokens", "orgId", orgID)
+			return nil
+		}
+
+		serviceAccount, err := sa.store.RetrieveServiceAccount(ctx, &serviceaccounts.GetServiceAccountQuery{OrgID: orgID, ID: *token.ServiceAccountId})
+		if err != nil {
+			sa.backgroundLog.Warn("Failed to retrieve the service account of an expiring token", "orgId", orgID, "tokenId", token.ID, "error", err)
+			continue
+		}
+
+		if err := sa.notifications.SendEmailCommandHandler(ctx, &notifications.SendEmailCommand{
+			To:       recipients,
+			Template: tokenExpiringTemplate,
+			Data: map[string]any{
+				"OrgName":            orgName,
+				"ServiceAccountName": serviceAccount.Name,
+				"TokenName":          token.Name,
+				"ExpiresAt":          time.Unix(*token.Expires, 0).UTC().Format(time.RFC1123),
+				"Link":               fmt.Sprintf("%sorg/serviceaccounts/%s?orgId=%d", sa.cfg.AppURL, serviceAccount.UID, orgID),
+			},
+		}); err != nil {
+			return err
+		}
+
+		if err := kv.Set(ctx, key, expires); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteExpiredTokenWarnings deletes the warnings recorded for the tokens which expired since
+func (sa *ServiceAccountsService) deleteExpiredTokenWarnings(ctx context.Context) error {
+	warnings, err := sa.kvStore.GetAll(ctx, kvstore.AllOrganizations, tokenExpiryWarningNamespace)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().Unix()
+	for orgID, values := range warnings {
+		kv := kvstore.WithNamespace(sa.kvStore, orgID, tokenExpiryWarningNamespace)
+		for key, value := range values {
+			if expires, err := strconv.ParseInt(value, 10, 64); err == nil && expires > now {
+				continue
+			}
+			if err := kv.Del(ctx, key); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// deleteTokenWarnings deletes the warnings recorded for deleted tokens
+func (sa *ServiceAccountsService) deleteTokenWarnings(ctx context.Context, orgID int64, tokenIDs ...int64) error {
+	kv := kvstore.WithNamespace(sa.kvStore, orgID, tokenExpiryWarningNamespace)
+	for _, tokenID := range tokenIDs {
+		if err := kv.Del(ctx, strconv.FormatInt(tokenID, 10)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (sa *ServiceAccountsService) orgAdminEmails(ctx context.Context, orgID int64) ([]string, error) {
+	users, err := sa.orgService.GetOrgUsers(ctx, &org.GetOrgUsersQuery{OrgID: orgID, DontEnforceAccessControl: true})
+	if err != nil {
+		return nil, err
+	}
+
+	emails := make([]string, 0)
+	for _, u := range users {
+		if u.Role == string(org.RoleAdmin) && !u.IsDisabled && util.IsEmail(u.Email) {
+			emails = append(emails, u.Email)
+		}
+	}
+	return emails, nil
+}
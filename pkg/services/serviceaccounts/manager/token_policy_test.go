@@ -0,0 +1,356 @@
+package manager
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/kvstore"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/apikey"
+	"github.com/grafana/grafana/pkg/services/notifications"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/org/orgtest"
+	"github.com/grafana/grafana/pkg/services/serviceaccounts"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func setupTokenPolicyService(t *testing.T) (*ServiceAccountsService, *FakeServiceAccountStore) {
+	t.Helper()
+	sqlStore := db.InitTestDB(t)
+	storeMock := newServiceAccountStoreFake()
+	storeMock.ExpectedServiceAccountProfileDTO = &serviceaccounts.ServiceAccountProfileDTO{Id: 10, Name: "deployer"}
+	cfg := setting.NewCfg()
+	cfg.ApiKeyMaxSecondsToLive = -1
+	return &ServiceAccountsService{
+		cfg:           cfg,
+		db:            sqlStore,
+		store:         storeMock,
+		kvStore:       kvstore.ProvideService(sqlStore),
+		log:           log.NewNopLogger(),
+		backgroundLog: log.NewNopLogger(),
+	}, storeMock
+}
+
+func TestIntegrationServiceAccountsService_TokenPolicy(t *testing.T) {
+	svc, _ := setupTokenPolicyService(t)
+	ctx := context.Background()
+
+	policy, err := svc.GetTokenPolicy(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, &serviceaccounts.TokenPolicy{}, policy)
+
+	_, err = svc.SetTokenPolicy(ctx, 1, &serviceaccounts.TokenPolicy{MaxSecondsToLive: -1})
+	require.ErrorIs(t, err, serviceaccounts.ErrInvalidTokenPolicy)
+
+	expected := &serviceaccounts.TokenPolicy{MaxSecondsToLive: 3600, ExpiryRequired: true, WarnBeforeExpirySeconds: 600}
+	_, err = svc.SetTokenPolicy(ctx, 1, expected)
+	require.NoError(t, err)
+
+	policy, err = svc.GetTokenPolicy(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, expected, policy)
+
+	policy, err = svc.GetTokenPolicy(ctx, 2)
+	require.NoError(t, err)
+	assert.Equal(t, &serviceaccounts.TokenPolicy{}, policy)
+}
+
+func TestTokenPolicy_CheckSecondsToLive(t *testing.T) {
+	assert.NoError(t, (&serviceaccounts.TokenPolicy{}).CheckSecondsToLive(0))
+	assert.ErrorIs(t, (&serviceaccounts.TokenPolicy{ExpiryRequired: true}).CheckSecondsToLive(0), serviceaccounts.ErrTokenExpiryRequired)
+	assert.ErrorIs(t, (&serviceaccounts.TokenPolicy{MaxSecondsToLive: 60}).CheckSecondsToLive(0), serviceaccounts.ErrTokenExpiryRequired)
+	assert.ErrorIs(t, (&serviceaccounts.TokenPolicy{MaxSecondsToLive: 60}).CheckSecondsToLive(61), serviceaccounts.ErrTokenLifetimeExceeded)
+	assert.NoError(t, (&serviceaccounts.TokenPolicy{MaxSecondsToLive: 60}).CheckSecondsToLive(60))
+}
+
+func TestIntegrationServiceAccountsService_AddServiceAccountToken(t *testing.T) {
+	ctx := context.Background()
+	svc, storeMock := setupTokenPolicyService(t)
+	storeMock.ExpectedAPIKey = &apikey.APIKey{ID: 1}
+	_, err := svc.SetTokenPolicy(ctx, 1, &serviceaccounts.TokenPolicy{MaxSecondsToLive: 3600})
+	require.NoError(t, err)
+
+	_, err = svc.AddServiceAccountToken(ctx, 10, &serviceaccounts.AddServiceAccountTokenCommand{Name: "ci", OrgId: 1})
+	require.ErrorIs(t, err, serviceaccounts.ErrTokenExpiryRequired)
+	_, err = svc.AddServiceAccountToken(ctx, 10, &serviceaccounts.AddServiceAccountTokenCommand{Name: "ci", OrgId: 1, SecondsToLive: 7200})
+	require.ErrorIs(t, err, serviceaccounts.ErrTokenLifetimeExceeded)
+	_, err = svc.AddServiceAccountToken(ctx, 10, &serviceaccounts.AddServiceAccountTokenCommand{Name: "ci", OrgId: 1, SecondsToLive: 3600})
+	require.NoError(t, err)
+
+	// the service accounts can be named like the external ones when the managed service accounts are disabled
+	storeMock.ExpectedServiceAccountProfileDTO = &serviceaccounts.ServiceAccountProfileDTO{
+		Id: 10, Name: serviceaccounts.ExtSvcPrefix + "grafana-app", Login: serviceaccounts.ExtSvcLoginPrefix(1) + "grafana-app",
+	}
+	_, err = svc.AddServiceAccountToken(ctx, 10, &serviceaccounts.AddServiceAccountTokenCommand{Name: "extsvc-token-grafana-app", OrgId: 1})
+	require.ErrorIs(t, err, serviceaccounts.ErrTokenExpiryRequired)
+
+	// the tokens of the external service accounts never expire
+	svc.extSvcAccountsEnabled = true
+	_, err = svc.AddServiceAccountToken(ctx, 10, &serviceaccounts.AddServiceAccountTokenCommand{Name: "extsvc-token-grafana-app", OrgId: 1})
+	require.NoError(t, err)
+
+	// only the reserved login identifies them
+	storeMock.ExpectedServiceAccountProfileDTO = &serviceaccounts.ServiceAccountProfileDTO{
+		Id: 10, Name: serviceaccounts.ExtSvcPrefix + "grafana-app", Login: "sa-1-grafana-app",
+	}
+	_, err = svc.AddServiceAccountToken(ctx, 10, &serviceaccounts.AddServiceAccountTokenCommand{Name: "ci", OrgId: 1})
+	require.ErrorIs(t, err, serviceaccounts.ErrTokenExpiryRequired)
+}
+
+func TestIntegrationServiceAccountsService_EnforceTokenPolicy(t *testing.T) {
+	ctx := context.Background()
+	saID := int64(10)
+	soon := time.Now().Add(time.Hour).Unix()
+	later := time.Now().Add(30 * 24 * time.Hour).Unix()
+	tokens := []apikey.APIKey{
+		{ID: 1, OrgID: 1, Name: "soon", Expires: &soon, ServiceAccountId: &saID},
+		{ID: 2, OrgID: 1, Name: "later", Expires: &later, ServiceAccountId: &saID},
+		{ID: 3, OrgID: 1, Name: "forever", ServiceAccountId: &saID},
+	}
+
+	t.Run("should shorten the tokens outliving the maximum lifetime", func(t *testing.T) {
+		svc, storeMock := setupTokenPolicyService(t)
+		storeMock.ExpectedAPIKeys = tokens
+
+		report, err := svc.SetTokenPolicy(ctx, 1, &serviceaccounts.TokenPolicy{MaxSecondsToLive: 7 * 24 * 3600})
+		require.NoError(t, err)
+		require.Len(t, report.ShortenedTokens, 2)
+		assert.Equal(t, int64(2), report.ShortenedTokens[0].TokenID)
+		assert.Equal(t, int64(3), report.ShortenedTokens[1].TokenID)
+		assert.Empty(t, report.NonCompliantTokens)
+
+		maxExpires := time.Now().Add(7 * 24 * time.Hour).Unix()
+		assert.Len(t, storeMock.TokenExpiries, 2)
+		assert.InDelta(t, maxExpires, storeMock.TokenExpiries[2], 5)
+		assert.InDelta(t, maxExpires, storeMock.TokenExpiries[3], 5)
+	})
+
+	t.Run("should report the tokens without expiry when the policy requires one", func(t *testing.T) {
+		svc, storeMock := setupTokenPolicyService(t)
+		storeMock.ExpectedAPIKeys = tokens
+
+		report, err := svc.SetTokenPolicy(ctx, 1, &serviceaccounts.TokenPolicy{ExpiryRequired: true})
+		require.NoError(t, err)
+		assert.Empty(t, report.ShortenedTokens)
+		require.Len(t, report.NonCompliantTokens, 1)
+		assert.Equal(t, "forever", report.NonCompliantTokens[0].TokenName)
+		assert.Empty(t, storeMock.TokenExpiries)
+	})
+
+	t.Run("should apply the policies in the background", func(t *testing.T) {
+		svc, storeMock := setupTokenPolicyService(t)
+		_, err := svc.SetTokenPolicy(ctx, 1, &serviceaccounts.TokenPolicy{MaxSecondsToLive: 7 * 24 * 3600})
+		require.NoError(t, err)
+
+		// like an API key migrated after the policy was set
+		storeMock.ExpectedAPIKeys = tokens
+		require.NoError(t, svc.enforceTokenPolicies(ctx))
+		assert.Len(t, storeMock.TokenExpiries, 2)
+	})
+
+	t.Run("should not apply the policy to the external service accounts", func(t *testing.T) {
+		svc, storeMock := setupTokenPolicyService(t)
+		svc.extSvcAccountsEnabled = true
+		storeMock.ExpectedAPIKeys = tokens
+		storeMock.ExpectedServiceAccountProfileDTO = &serviceaccounts.ServiceAccountProfileDTO{
+			Id: saID, Name: serviceaccounts.ExtSvcPrefix + "grafana-app", Login: serviceaccounts.ExtSvcLoginPrefix(1) + "grafana-app",
+		}
+
+		report, err := svc.SetTokenPolicy(ctx, 1, &serviceaccounts.TokenPolicy{MaxSecondsToLive: 7 * 24 * 3600, ExpiryRequired: true})
+		require.NoError(t, err)
+		assert.Empty(t, report.ShortenedTokens)
+		assert.Empty(t, report.NonCompliantTokens)
+		assert.Empty(t, storeMock.TokenExpiries)
+	})
+}
+
+func TestIntegrationServiceAccountsService_RotateServiceAccountToken(t *testing.T) {
+	ctx := context.Background()
+	created := time.Now().Add(-time.Hour)
+	expires := created.Add(30 * 24 * time.Hour).Unix()
+	expired := time.Now().Add(-time.Minute).Unix()
+	revoked := true
+
+	tokens := []apikey.APIKey{
+		{ID: 1, OrgID: 1, Name: "ci", Created: created, Expires: &expires},
+		{ID: 2, OrgID: 1, Name: "expired", Created: created, Expires: &expired},
+		{ID: 3, OrgID: 1, Name: "revoked", Created: created, IsRevoked: &revoked},
+		{ID: 4, OrgID: 1, Name: "forever", Created: created},
+	}
+
+	newCmd := func(tokenID, overlap int64) *serviceaccounts.RotateServiceAccountTokenCommand {
+		return &serviceaccounts.RotateServiceAccountTokenCommand{
+			OrgID:            1,
+			ServiceAccountID: 10,
+			TokenID:          tokenID,
+			OverlapSeconds:   overlap,
+			Key:              "hashed",
+		}
+	}
+
+	t.Run("should revoke the rotated token immediately without overlap", func(t *testing.T) {
+		svc, storeMock := setupTokenPolicyService(t)
+		storeMock.ExpectedAPIKeys = tokens
+		storeMock.ExpectedAPIKey = &apikey.APIKey{ID: 5}
+
+		token, err := svc.RotateServiceAccountToken(ctx, newCmd(1, 0))
+		require.NoError(t, err)
+		assert.Equal(t, int64(5), token.ID)
+		assert.Equal(t, []int64{1}, storeMock.RevokedTokenIDs)
+		assert.Empty(t, storeMock.TokenExpiries)
+	})
+
+	t.Run("should shorten the rotated token and revoke it after the overlap", func(t *testing.T) {
+		svc, storeMock := setupTokenPolicyService(t)
+		storeMock.ExpectedAPIKeys = tokens
+		storeMock.ExpectedAPIKey = &apikey.APIKey{ID: 5}
+
+		_, err := svc.RotateServiceAccountToken(ctx, newCmd(1, 3600))
+		require.NoError(t, err)
+		assert.Empty(t, storeMock.RevokedTokenIDs)
+		assert.InDelta(t, time.Now().Add(time.Hour).Unix(), storeMock.TokenExpiries[1], 5)
+
+		// the overlap is not over yet
+		require.NoError(t, svc.revokeRotatedTokens(ctx))
+		assert.Empty(t, storeMock.RevokedTokenIDs)
+
+		value, err := json.Marshal(pendingTokenRevocation{ServiceAccountID: 10, RevokeAt: time.Now().Add(-time.Second).Unix()})
+		require.NoError(t, err)
+		require.NoError(t, svc.kvStore.Set(ctx, 1, tokenRotationNamespace, "1", string(value)))
+
+		require.NoError(t, svc.revokeRotatedTokens(ctx))
+		assert.Equal(t, []int64{1}, storeMock.RevokedTokenIDs)
+
+		pending, err := svc.kvStore.GetAll(ctx, kvstore.AllOrganizations, tokenRotationNamespace)
+		require.NoError(t, err)
+		assert.Empty(t, pending)
+	})
+
+	t.Run("should keep the lifetime of the rotated token", func(t *testing.T) {
+		svc, storeMock := setupTokenPolicyService(t)
+		storeMock.ExpectedAPIKeys = tokens
+		storeMock.ExpectedAPIKey = &apikey.APIKey{ID: 5}
+		_, err := svc.SetTokenPolicy(ctx, 1, &serviceaccounts.TokenPolicy{MaxSecondsToLive: 7 * 24 * 3600})
+		require.NoError(t, err)
+
+		_, err = svc.RotateServiceAccountToken(ctx, newCmd(1, 0))
+		require.ErrorIs(t, err, serviceaccounts.ErrTokenLifetimeExceeded)
+
+		cmd := newCmd(1, 0)
+		cmd.SecondsToLive = 24 * 3600
+		_, err = svc.RotateServiceAccountToken(ctx, cmd)
+		require.NoError(t, err)
+
+		_, err = svc.RotateServiceAccountToken(ctx, newCmd(4, 0))
+		require.ErrorIs(t, err, serviceaccounts.ErrTokenExpiryRequired)
+	})
+
+	t.Run("should check the kept lifetime against the global limits", func(t *testing.T) {
+		svc, storeMock := setupTokenPolicyService(t)
+		storeMock.ExpectedAPIKeys = tokens
+		storeMock.ExpectedAPIKey = &apikey.APIKey{ID: 5}
+
+		svc.cfg.ApiKeyMaxSecondsToLive = 7 * 24 * 3600
+		_, err := svc.RotateServiceAccountToken(ctx, newCmd(1, 0))
+		require.ErrorIs(t, err, serviceaccounts.ErrInvalidTokenExpiration)
+		_, err = svc.RotateServiceAccountToken(ctx, newCmd(4, 0))
+		require.ErrorIs(t, err, serviceaccounts.ErrInvalidTokenExpiration)
+
+		svc.cfg.ApiKeyMaxSecondsToLive = -1
+		svc.cfg.SATokenExpirationDayLimit = 7
+		_, err = svc.RotateServiceAccountToken(ctx, newCmd(1, 0))
+		require.ErrorIs(t, err, serviceaccounts.ErrInvalidTokenExpiration)
+		_, err = svc.RotateServiceAccountToken(ctx, newCmd(4, 0))
+		require.ErrorIs(t, err, serviceaccounts.ErrInvalidTokenExpiration)
+
+		cmd := newCmd(4, 0)
+		cmd.SecondsToLive = 24 * 3600
+		_, err = svc.RotateServiceAccountToken(ctx, cmd)
+		require.NoError(t, err)
+		assert.Equal(t, []int64{4}, storeMock.RevokedTokenIDs)
+	})
+
+	t.Run("should fail on revoked, expired and unknown tokens", func(t *testing.T) {
+		svc, storeMock := setupTokenPolicyService(t)
+		storeMock.ExpectedAPIKeys = tokens
+
+		_, err := svc.RotateServiceAccountToken(ctx, newCmd(2, 0))
+		require.ErrorIs(t, err, serviceaccounts.ErrTokenAlreadyRevoked)
+		_, err = svc.RotateServiceAccountToken(ctx, newCmd(3, 0))
+		require.ErrorIs(t, err, serviceaccounts.ErrTokenAlreadyRevoked)
+		_, err = svc.RotateServiceAccountToken(ctx, newCmd(42, 0))
+		require.ErrorIs(t, err, serviceaccounts.ErrServiceAccountTokenNotFound)
+		assert.Empty(t, storeMock.RevokedTokenIDs)
+	})
+}
+
+func TestIntegrationServiceAccountsService_WarnExpiringTokens(t *testing.T) {
+	ctx := context.Background()
+	svc, storeMock := setupTokenPolicyService(t)
+
+	saID := int64(10)
+	expires := time.Now().Add(time.Hour).Unix()
+	storeMock.ExpectedAPIKeys = []apikey.APIKey{{ID: 1, OrgID: 1, Name: "ci", Expires: &expires, ServiceAccountId: &saID}}
+	storeMock.ExpectedServiceAccountProfileDTO = &serviceaccounts.ServiceAccountProfileDTO{Id: saID, UID: "sa-uid", Name: "deployer"}
+
+	svc.orgService = &orgtest.FakeOrgService{
+		ExpectedOrg: &org.Org{ID: 1, Name: "Main Org."},
+		ExpectedOrgUsers: []*org.OrgUserDTO{
+			{UserID: 1, Email: "admin@example.com", Role: string(org.RoleAdmin)},
+			{UserID: 2, Email: "disabled@example.com", Role: string(org.RoleAdmin), IsDisabled: true},
+			{UserID: 3, Email: "editor@example.com", Role: string(org.RoleEditor)},
+		},
+	}
+
+	sent := []*notifications.SendEmailCommand{}
+	svc.notifications = &notifications.NotificationServiceMock{
+		EmailHandler: func(ctx context.Context, cmd *notifications.SendEmailCommand) error {
+			sent = append(sent, cmd)
+			return nil
+		},
+	}
+
+	// no warning without policy
+	require.NoError(t, svc.warnExpiringTokens(ctx))
+	assert.Empty(t, sent)
+
+	_, err := svc.SetTokenPolicy(ctx, 1, &serviceaccounts.TokenPolicy{WarnBeforeExpirySeconds: 24 * 3600})
+	require.NoError(t, err)
+	require.NoError(t, svc.warnExpiringTokens(ctx))
+	require.Len(t, sent, 1)
+	assert.Equal(t, []string{"admin@example.com"}, sent[0].To)
+	assert.Equal(t, tokenExpiringTemplate, sent[0].Template)
+	assert.Equal(t, "ci", sent[0].Data["TokenName"])
+	assert.Equal(t, "deployer", sent[0].Data["ServiceAccountName"])
+
+	// the admins are warned once per expiry
+	require.NoError(t, svc.warnExpiringTokens(ctx))
+	assert.Len(t, sent, 1)
+
+	shortened := time.Now().Add(time.Minute).Unix()
+	storeMock.ExpectedAPIKeys[0].Expires = &shortened
+	require.NoError(t, svc.warnExpiringTokens(ctx))
+	assert.Len(t, sent, 2)
+
+	// the warnings are deleted with the tokens and once they expire
+	warnings := kvstore.WithNamespace(svc.kvStore, 1, tokenExpiryWarningNamespace)
+	require.NoError(t, svc.DeleteServiceAccountToken(ctx, 1, saID, 1))
+	_, ok, err := warnings.Get(ctx, "1")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	require.NoError(t, warnings.Set(ctx, "2", strconv.FormatInt(time.Now().Add(-time.Minute).Unix(), 10)))
+	require.NoError(t, warnings.Set(ctx, "3", strconv.FormatInt(time.Now().Add(time.Minute).Unix(), 10)))
+	require.NoError(t, svc.deleteExpiredTokenWarnings(ctx))
+	keys, err := warnings.Keys(ctx, "")
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	assert.Equal(t, "3", keys[0].Key)
+}
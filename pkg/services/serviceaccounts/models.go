@@ -42,6 +42,10 @@ var (
 	ErrServiceAccountTokenNotFound       = errutil.NotFound("serviceaccounts.ErrTokenNotFound", errutil.WithPublicMessage("service account token not found"))
 	ErrInvalidTokenExpiration            = errutil.ValidationFailed("serviceaccounts.ErrInvalidInput", errutil.WithPublicMessage("invalid SecondsToLive value"))
 	ErrDuplicateToken                    = errutil.BadRequest("serviceaccounts.ErrTokenAlreadyExists", errutil.WithPublicMessage("service account token with given name already exists in the organization"))
+	ErrTokenExpiryRequired               = errutil.BadRequest("serviceaccounts.ErrTokenExpiryRequired", errutil.WithPublicMessage("the token policy of the organization requires an expiry"))
+	ErrTokenLifetimeExceeded             = errutil.BadRequest("serviceaccounts.ErrTokenLifetimeExceeded", errutil.WithPublicMessage("the token lifetime exceeds the maximum of the token policy of the organization"))
+	ErrInvalidTokenPolicy                = errutil.ValidationFailed("serviceaccounts.ErrInvalidTokenPolicy", errutil.WithPublicMessage("invalid token policy"))
+	ErrTokenAlreadyRevoked               = errutil.BadRequest("serviceaccounts.ErrTokenAlreadyRevoked", errutil.WithPublicMessage("service account token is revoked or expired"))
 )
 
 type MigrationResult struct {
@@ -113,6 +117,77 @@ type AddServiceAccountTokenCommand struct {
 	SecondsToLive int64  `json:"secondsToLive"`
 }
 
+// swagger:model
+type RotateServiceAccountTokenCommand struct {
+	// Name of the new token, the name of the rotated token with a suffix by default
+	Name string `json:"name"`
+	// SecondsToLive of the new token, the lifetime of the rotated token by default
+	SecondsToLive int64 `json:"secondsToLive"`
+	// OverlapSeconds during which the rotated token remains valid, it is revoked immediately when zero
+	OverlapSeconds int64 `json:"overlapSeconds"`
+
+	OrgID            int64  `json:"-"`
+	ServiceAccountID int64  `json:"-"`
+	TokenID          int64  `json:"-"`
+	Key              string `json:"-"`
+}
+
+// TokenPolicy is the policy applied to the service account tokens of an organization
+// swagger:model
+type TokenPolicy struct {
+	// MaxSecondsToLive is the maximum lifetime of the tokens, no limit when zero
+	MaxSecondsToLive int64 `json:"maxSecondsToLive"`
+	// ExpiryRequired rejects the tokens without expiry
+	ExpiryRequired bool `json:"expiryRequired"`
+	// WarnBeforeExpirySeconds notifies the organization admins by email this many seconds before a token expires,
+	// no notification when zero
+	WarnBeforeExpirySeconds int64 `json:"warnBeforeExpirySeconds"`
+}
+
+func (p *TokenPolicy) Validate() error {
+	if p.MaxSecondsToLive < 0 {
+		return ErrInvalidTokenPolicy.Errorf("maxSecondsToLive must not be negative")
+	}
+	if p.WarnBeforeExpirySeconds < 0 {
+		return ErrInvalidTokenPolicy.Errorf("warnBeforeExpirySeconds must not be negative")
+	}
+	return nil
+}
+
+// CheckSecondsToLive returns an error when a token with the given lifetime violates the policy
+func (p *TokenPolicy) CheckSecondsToLive(secondsToLive int64) error {
+	if secondsToLive <= 0 {
+		// a maximum lifetime implies an expiry
+		if p.ExpiryRequired || p.MaxSecondsToLive > 0 {
+			return ErrTokenExpiryRequired.Errorf("token without expiry")
+		}
+		return nil
+	}
+	if p.MaxSecondsToLive > 0 && secondsToLive > p.MaxSecondsToLive {
+		return ErrTokenLifetimeExceeded.Errorf("token lifetime %ds exceeds the maximum %ds", secondsToLive, p.MaxSecondsToLive)
+	}
+	return nil
+}
+
+// TokenPolicyReport lists the existing tokens which violated the token policy when it was applied
+// swagger:model
+type TokenPolicyReport struct {
+	// ShortenedTokens expired later than the maximum lifetime of the policy counted from now,
+	// and now expire at the end of it
+	ShortenedTokens []TokenPolicyViolation `json:"shortenedTokens"`
+	// NonCompliantTokens have no expiry while the policy requires one, they must be rotated or deleted
+	NonCompliantTokens []TokenPolicyViolation `json:"nonCompliantTokens"`
+}
+
+// swagger:model
+type TokenPolicyViolation struct {
+	ServiceAccountID int64  `json:"serviceAccountId"`
+	TokenID          int64  `json:"tokenId"`
+	TokenName        string `json:"tokenName"`
+	// Expires is the expiry of the token after the policy was applied
+	Expires *time.Time `json:"expires,omitempty"`
+}
+
 type SearchOrgServiceAccountsQuery struct {
 	OrgID        int64
 	Query        string
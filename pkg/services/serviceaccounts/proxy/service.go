@@ -131,6 +131,30 @@ func (s *ServiceAccountsProxy) MigrateApiKeysToServiceAccounts(ctx context.Conte
 	return s.proxiedService.MigrateApiKeysToServiceAccounts(ctx, orgID)
 }
 
+func (s *ServiceAccountsProxy) RotateServiceAccountToken(ctx context.Context, cmd *serviceaccounts.RotateServiceAccountTokenCommand) (*apikey.APIKey, error) {
+	if s.isProxyEnabled {
+		sa, err := s.proxiedService.RetrieveServiceAccount(ctx, &serviceaccounts.GetServiceAccountQuery{ID: cmd.ServiceAccountID, OrgID: cmd.OrgID})
+		if err != nil {
+			return nil, err
+		}
+
+		if serviceaccounts.IsExternalServiceAccount(sa.Login) {
+			s.log.Error("unable to rotate tokens for external service accounts", "serviceAccountID", cmd.ServiceAccountID)
+			return nil, extsvcaccounts.ErrCannotCreateToken
+		}
+	}
+
+	return s.proxiedService.RotateServiceAccountToken(ctx, cmd)
+}
+
+func (s *ServiceAccountsProxy) GetTokenPolicy(ctx context.Context, orgID int64) (*serviceaccounts.TokenPolicy, error) {
+	return s.proxiedService.GetTokenPolicy(ctx, orgID)
+}
+
+func (s *ServiceAccountsProxy) SetTokenPolicy(ctx context.Context, orgID int64, policy *serviceaccounts.TokenPolicy) (*serviceaccounts.TokenPolicyReport, error) {
+	return s.proxiedService.SetTokenPolicy(ctx, orgID, policy)
+}
+
 func (s *ServiceAccountsProxy) RetrieveServiceAccount(ctx context.Context, query *serviceaccounts.GetServiceAccountQuery) (*serviceaccounts.ServiceAccountProfileDTO, error) {
 	sa, err := s.proxiedService.RetrieveServiceAccount(ctx, query)
 	if err != nil {
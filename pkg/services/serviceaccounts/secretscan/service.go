@@ -138,6 +138,12 @@ func (s *Service) CheckTokens(ctx context.Context) error {
 			"url", secretscanToken.URL, "reported_at", secretscanToken.ReportedAt,
 			"token_id", leakedToken.ID, "token", leakedToken.Name, "org", leakedToken.OrgID,
 			"serviceAccount", *leakedToken.ServiceAccountId, "revoked", s.revoke)
+
+		if !s.revoke {
+			s.logger.Warn("Leaked token was not revoked, revoke it or rotate it without overlap period",
+				"token_id", leakedToken.ID, "org", leakedToken.OrgID,
+				"rotate", fmt.Sprintf("POST /api/serviceaccounts/%d/tokens/%d/rotate", *leakedToken.ServiceAccountId, leakedToken.ID))
+		}
 	}
 
 	return nil
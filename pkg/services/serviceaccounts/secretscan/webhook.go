@@ -57,7 +57,8 @@ func newWebHookClient(url, version string, dev bool) (*webHookClient, error) {
 func (wClient *webHookClient) Notify(ctx context.Context,
 	token *Token, tokenName string, revoked bool,
 ) error {
-	revokedMsg := ""
+	// the overlap period of a rotation would keep the leaked token valid, it must be revoked immediately
+	revokedMsg := " Revoke this token now, or rotate it without overlap period"
 	if revoked {
 		revokedMsg = " Grafana has revoked this token"
 	}
@@ -40,6 +40,11 @@ type Service interface {
 		cmd *AddServiceAccountTokenCommand) (*apikey.APIKey, error)
 	DeleteServiceAccountToken(ctx context.Context, orgID, serviceAccountID, tokenID int64) error
 	ListTokens(ctx context.Context, query *GetSATokensQuery) ([]apikey.APIKey, error)
+	// RotateServiceAccountToken issues a new token and revokes the rotated one once the overlap period is over
+	RotateServiceAccountToken(ctx context.Context, cmd *RotateServiceAccountTokenCommand) (*apikey.APIKey, error)
+	GetTokenPolicy(ctx context.Context, orgID int64) (*TokenPolicy, error)
+	// SetTokenPolicy saves the token policy and applies it to the existing tokens
+	SetTokenPolicy(ctx context.Context, orgID int64, policy *TokenPolicy) (*TokenPolicyReport, error)
 
 	// API specific functions
 	MigrateApiKey(ctx context.Context, orgID int64, keyId int64) error
@@ -16,6 +16,7 @@ type FakeServiceAccountService struct {
 	ExpectedServiceAccountID               int64
 	ExpectedServiceAccountProfile          *serviceaccounts.ServiceAccountProfileDTO
 	ExpectedServiceAccountTokens           []apikey.APIKey
+	ExpectedTokenPolicy                    *serviceaccounts.TokenPolicy
 }
 
 var _ serviceaccounts.Service = new(FakeServiceAccountService)
@@ -52,6 +53,24 @@ func (f *FakeServiceAccountService) ListTokens(ctx context.Context, query *servi
 	return f.ExpectedServiceAccountTokens, f.ExpectedErr
 }
 
+func (f *FakeServiceAccountService) RotateServiceAccountToken(ctx context.Context, cmd *serviceaccounts.RotateServiceAccountTokenCommand) (*apikey.APIKey, error) {
+	return f.ExpectedAPIKey, f.ExpectedErr
+}
+
+func (f *FakeServiceAccountService) GetTokenPolicy(ctx context.Context, orgID int64) (*serviceaccounts.TokenPolicy, error) {
+	if f.ExpectedTokenPolicy == nil {
+		return &serviceaccounts.TokenPolicy{}, f.ExpectedErr
+	}
+	return f.ExpectedTokenPolicy, f.ExpectedErr
+}
+
+func (f *FakeServiceAccountService) SetTokenPolicy(ctx context.Context, orgID int64, policy *serviceaccounts.TokenPolicy) (*serviceaccounts.TokenPolicyReport, error) {
+	if f.ExpectedErr != nil {
+		return nil, f.ExpectedErr
+	}
+	return &serviceaccounts.TokenPolicyReport{}, nil
+}
+
 func (f *FakeServiceAccountService) MigrateApiKey(ctx context.Context, orgID, keyID int64) error {
 	return f.ExpectedErr
 }
@@ -111,6 +111,32 @@ func (_m *MockServiceAccountService) EnableServiceAccount(ctx context.Context, o
 	return r0
 }
 
+// GetTokenPolicy provides a mock function with given fields: ctx, orgID
+func (_m *MockServiceAccountService) GetTokenPolicy(ctx context.Context, orgID int64) (*serviceaccounts.TokenPolicy, error) {
+	ret := _m.Called(ctx, orgID)
+
+	var r0 *serviceaccounts.TokenPolicy
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (*serviceaccounts.TokenPolicy, error)); ok {
+		return rf(ctx, orgID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) *serviceaccounts.TokenPolicy); ok {
+		r0 = rf(ctx, orgID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*serviceaccounts.TokenPolicy)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, orgID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // ListTokens provides a mock function with given fields: ctx, query
 func (_m *MockServiceAccountService) ListTokens(ctx context.Context, query *serviceaccounts.GetSATokensQuery) ([]apikey.APIKey, error) {
 	ret := _m.Called(ctx, query)
@@ -227,6 +253,32 @@ func (_m *MockServiceAccountService) RetrieveServiceAccountIdByName(ctx context.
 	return r0, r1
 }
 
+// RotateServiceAccountToken provides a mock function with given fields: ctx, cmd
+func (_m *MockServiceAccountService) RotateServiceAccountToken(ctx context.Context, cmd *serviceaccounts.RotateServiceAccountTokenCommand) (*apikey.APIKey, error) {
+	ret := _m.Called(ctx, cmd)
+
+	var r0 *apikey.APIKey
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *serviceaccounts.RotateServiceAccountTokenCommand) (*apikey.APIKey, error)); ok {
+		return rf(ctx, cmd)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *serviceaccounts.RotateServiceAccountTokenCommand) *apikey.APIKey); ok {
+		r0 = rf(ctx, cmd)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*apikey.APIKey)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *serviceaccounts.RotateServiceAccountTokenCommand) error); ok {
+		r1 = rf(ctx, cmd)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // SearchOrgServiceAccounts provides a mock function with given fields: ctx, query
 func (_m *MockServiceAccountService) SearchOrgServiceAccounts(ctx context.Context, query *serviceaccounts.SearchOrgServiceAccountsQuery) (*serviceaccounts.SearchOrgServiceAccountsResult, error) {
 	ret := _m.Called(ctx, query)
@@ -253,6 +305,32 @@ func (_m *MockServiceAccountService) SearchOrgServiceAccounts(ctx context.Contex
 	return r0, r1
 }
 
+// SetTokenPolicy provides a mock function with given fields: ctx, orgID, policy
+func (_m *MockServiceAccountService) SetTokenPolicy(ctx context.Context, orgID int64, policy *serviceaccounts.TokenPolicy) (*serviceaccounts.TokenPolicyReport, error) {
+	ret := _m.Called(ctx, orgID, policy)
+
+	var r0 *serviceaccounts.TokenPolicyReport
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, *serviceaccounts.TokenPolicy) (*serviceaccounts.TokenPolicyReport, error)); ok {
+		return rf(ctx, orgID, policy)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, *serviceaccounts.TokenPolicy) *serviceaccounts.TokenPolicyReport); ok {
+		r0 = rf(ctx, orgID, policy)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*serviceaccounts.TokenPolicyReport)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, *serviceaccounts.TokenPolicy) error); ok {
+		r1 = rf(ctx, orgID, policy)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // UpdateServiceAccount provides a mock function with given fields: ctx, orgID, serviceAccountID, saForm
 func (_m *MockServiceAccountService) UpdateServiceAccount(ctx context.Context, orgID int64, serviceAccountID int64, saForm *serviceaccounts.UpdateServiceAccountForm) (*serviceaccounts.ServiceAccountProfileDTO, error) {
 	ret := _m.Called(ctx, orgID, serviceAccountID, saForm)
@@ -65,6 +65,32 @@ func NewDatabaseConfig(cfg *setting.Cfg, features featuremgmt.FeatureToggles) (*
 	return dbCfg, nil
 }
 
+// NewReplicaDatabaseConfig returns the configuration of a read replica of the database,
+// which only differs from the primary database by its host
+func NewReplicaDatabaseConfig(cfg *setting.Cfg, host string) (*DatabaseConfig, error) {
+	if cfg == nil {
+		return nil, errors.New("cfg cannot be nil")
+	}
+
+	dbCfg := &DatabaseConfig{}
+	if err := dbCfg.readConfig(cfg); err != nil {
+		return nil, err
+	}
+	if dbCfg.Type != migrator.MySQL && dbCfg.Type != migrator.Postgres {
+		return nil, fmt.Errorf("read replicas are not supported with %s databases", dbCfg.Type)
+	}
+	if dbCfg.ConnectionString != "" {
+		return nil, errors.New("read replicas can not be used with a connection string")
+	}
+	dbCfg.Host = host
+
+	if err := dbCfg.buildConnectionString(cfg, nil); err != nil {
+		return nil, err
+	}
+
+	return dbCfg, nil
+}
+
 func (dbCfg *DatabaseConfig) readConfig(cfg *setting.Cfg) error {
 	sec := cfg.Raw.Section("database")
 
@@ -0,0 +1,30 @@
+package auditlog
+
+import "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+
+func AddMigration(mg *migrator.Migrator) {
+	auditLogV1 := migrator.Table{
+		Name: "audit_log",
+		Columns: []*migrator.Column{
+			{Name: "id", Type: migrator.DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "created", Type: migrator.DB_DateTime, Nullable: false},
+			{Name: "org_id", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "action", Type: migrator.DB_NVarchar, Length: 64, Nullable: false},
+			{Name: "result", Type: migrator.DB_NVarchar, Length: 16, Nullable: false},
+			{Name: "actor_uid", Type: migrator.DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "actor_login", Type: migrator.DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "ip_address", Type: migrator.DB_NVarchar, Length: 64, Nullable: false},
+			{Name: "resource_kind", Type: migrator.DB_NVarchar, Length: 64, Nullable: false},
+			{Name: "resource_uid", Type: migrator.DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "details", Type: migrator.DB_Text, Nullable: true},
+		},
+		Indices: []*migrator.Index{
+			{Cols: []string{"created"}},
+			{Cols: []string{"org_id", "created"}},
+		},
+	}
+
+	mg.AddMigration("create audit_log table", migrator.NewAddTableMigration(auditLogV1))
+	mg.AddMigration("add index audit_log.created", migrator.NewAddIndexMigration(auditLogV1, auditLogV1.Indices[0]))
+	mg.AddMigration("add index audit_log.org_id_created", migrator.NewAddIndexMigration(auditLogV1, auditLogV1.Indices[1]))
+}
@@ -0,0 +1,48 @@
+package changefeed
+
+import "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+
+func AddMigration(mg *migrator.Migrator) {
+	subscriptionV1 := migrator.Table{
+		Name: "changefeed_subscription",
+		Columns: []*migrator.Column{
+			{Name: "id", Type: migrator.DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "uid", Type: migrator.DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "name", Type: migrator.DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "url", Type: migrator.DB_Text, Nullable: false},
+			{Name: "secret", Type: migrator.DB_Text, Nullable: true},
+			{Name: "resource_group", Type: migrator.DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "resource", Type: migrator.DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "namespace", Type: migrator.DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "folder", Type: migrator.DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "disabled", Type: migrator.DB_Bool, Nullable: false},
+			{Name: "created", Type: migrator.DB_DateTime, Nullable: false},
+			{Name: "updated", Type: migrator.DB_DateTime, Nullable: false},
+		},
+		Indices: []*migrator.Index{
+			{Cols: []string{"uid"}, Type: migrator.UniqueIndex},
+		},
+	}
+
+	mg.AddMigration("create changefeed_subscription table", migrator.NewAddTableMigration(subscriptionV1))
+	mg.AddMigration("add unique index changefeed_subscription.uid", migrator.NewAddIndexMigration(subscriptionV1, subscriptionV1.Indices[0]))
+
+	deadLetterV1 := migrator.Table{
+		Name: "changefeed_dead_letter",
+		Columns: []*migrator.Column{
+			{Name: "id", Type: migrator.DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "subscription_uid", Type: migrator.DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "event_id", Type: migrator.DB_NVarchar, Length: 255, Nullable: false},
+			{Name: "payload", Type: migrator.DB_MediumText, Nullable: false},
+			{Name: "attempts", Type: migrator.DB_Int, Nullable: false},
+			{Name: "last_error", Type: migrator.DB_Text, Nullable: false},
+			{Name: "created", Type: migrator.DB_DateTime, Nullable: false},
+		},
+		Indices: []*migrator.Index{
+			{Cols: []string{"subscription_uid"}},
+		},
+	}
+
+	mg.AddMigration("create changefeed_dead_letter table", migrator.NewAddTableMigration(deadLetterV1))
+	mg.AddMigration("add index changefeed_dead_letter.subscription_uid", migrator.NewAddIndexMigration(deadLetterV1, deadLetterV1.Indices[0]))
+}
@@ -5,9 +5,14 @@ import (
 	"github.com/grafana/grafana/pkg/services/featuremgmt"
 	"github.com/grafana/grafana/pkg/services/sqlstore/migrations/accesscontrol"
 	"github.com/grafana/grafana/pkg/services/sqlstore/migrations/anonservice"
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrations/auditlog"
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrations/changefeed"
 	"github.com/grafana/grafana/pkg/services/sqlstore/migrations/externalsession"
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrations/passkey"
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrations/queryrestriction"
 	"github.com/grafana/grafana/pkg/services/sqlstore/migrations/signingkeys"
 	"github.com/grafana/grafana/pkg/services/sqlstore/migrations/ssosettings"
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrations/totp"
 	"github.com/grafana/grafana/pkg/services/sqlstore/migrations/ualert"
 	. "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
 )
@@ -153,4 +158,14 @@ func (oss *OSSMigrations) AddMigration(mg *Migrator) {
 	accesscontrol.AddDatasourceDrilldownRemovalMigration(mg)
 
 	ualert.DropTitleUniqueIndexMigration(mg)
+
+	changefeed.AddMigration(mg)
+
+	totp.AddMigration(mg)
+
+	passkey.AddMigration(mg)
+
+	auditlog.AddMigration(mg)
+
+	queryrestriction.AddMigration(mg)
 }
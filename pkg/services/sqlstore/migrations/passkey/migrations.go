@@ -0,0 +1,36 @@
+package passkey
+
+import "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+
+func AddMigration(mg *migrator.Migrator) {
+	userPasskeyV1 := migrator.Table{
+		Name: "user_passkey",
+		Columns: []*migrator.Column{
+			{Name: "id", Type: migrator.DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "uid", Type: migrator.DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "user_id", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "user_handle", Type: migrator.DB_NVarchar, Length: 64, Nullable: false},
+			{Name: "name", Type: migrator.DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "credential_id", Type: migrator.DB_Text, Nullable: false},
+			{Name: "credential_id_hash", Type: migrator.DB_Char, Length: 64, Nullable: false},
+			{Name: "public_key", Type: migrator.DB_Text, Nullable: false},
+			{Name: "sign_count", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "aaguid", Type: migrator.DB_NVarchar, Length: 36, Nullable: false},
+			{Name: "transports", Type: migrator.DB_NVarchar, Length: 255, Nullable: false},
+			{Name: "backup_eligible", Type: migrator.DB_Bool, Nullable: false},
+			{Name: "backed_up", Type: migrator.DB_Bool, Nullable: false},
+			{Name: "created", Type: migrator.DB_DateTime, Nullable: false},
+			{Name: "last_used", Type: migrator.DB_DateTime, Nullable: true},
+		},
+		Indices: []*migrator.Index{
+			{Cols: []string{"uid"}, Type: migrator.UniqueIndex},
+			{Cols: []string{"credential_id_hash"}, Type: migrator.UniqueIndex},
+			{Cols: []string{"user_id"}},
+		},
+	}
+
+	mg.AddMigration("create user_passkey table", migrator.NewAddTableMigration(userPasskeyV1))
+	mg.AddMigration("add unique index user_passkey.uid", migrator.NewAddIndexMigration(userPasskeyV1, userPasskeyV1.Indices[0]))
+	mg.AddMigration("add unique index user_passkey.credential_id_hash", migrator.NewAddIndexMigration(userPasskeyV1, userPasskeyV1.Indices[1]))
+	mg.AddMigration("add index user_passkey.user_id", migrator.NewAddIndexMigration(userPasskeyV1, userPasskeyV1.Indices[2]))
+}
@@ -0,0 +1,26 @@
+package queryrestriction
+
+import "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+
+func AddMigration(mg *migrator.Migrator) {
+	queryRestrictionV1 := migrator.Table{
+		Name: "data_source_query_restriction",
+		Columns: []*migrator.Column{
+			{Name: "id", Type: migrator.DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "datasource_uid", Type: migrator.DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "team_id", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "kind", Type: migrator.DB_NVarchar, Length: 32, Nullable: false},
+			{Name: "pattern", Type: migrator.DB_Text, Nullable: false},
+			{Name: "created", Type: migrator.DB_DateTime, Nullable: false},
+		},
+		Indices: []*migrator.Index{
+			{Cols: []string{"org_id", "datasource_uid"}},
+			{Cols: []string{"org_id", "team_id"}},
+		},
+	}
+
+	mg.AddMigration("create data_source_query_restriction table", migrator.NewAddTableMigration(queryRestrictionV1))
+	mg.AddMigration("add index data_source_query_restriction.org_id_datasource_uid", migrator.NewAddIndexMigration(queryRestrictionV1, queryRestrictionV1.Indices[0]))
+	mg.AddMigration("add index data_source_query_restriction.org_id_team_id", migrator.NewAddIndexMigration(queryRestrictionV1, queryRestrictionV1.Indices[1]))
+}
@@ -57,6 +57,7 @@ var (
 	signout_redirect_url = test_signout_redirect_url
 	org_attribute_path = groups
 	org_mapping = Group1:*:Editor
+	team_mapping = Group1:1:Platform
 	`
 
 	expectedOAuthInfo = map[string]any{
@@ -102,6 +103,7 @@ var (
 		"team_ids":                      "first, second",
 		"org_attribute_path":            "groups",
 		"org_mapping":                   "Group1:*:Editor",
+		"team_mapping":                  "Group1:1:Platform",
 	}
 )
 
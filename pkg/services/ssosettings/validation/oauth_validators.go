@@ -31,6 +31,19 @@ func OrgMappingValidator(info *social.OAuthInfo, oldInfo *social.OAuthInfo, requ
 	}
 }
 
+func TeamMappingValidator(info *social.OAuthInfo, oldInfo *social.OAuthInfo, requester identity.Requester) ssosettings.ValidateFunc[social.OAuthInfo] {
+	return func(info *social.OAuthInfo, requester identity.Requester) error {
+		hasChanged := !slices.Equal(oldInfo.TeamMapping, info.TeamMapping)
+		if hasChanged && !requester.GetIsGrafanaAdmin() {
+			return ssosettings.ErrInvalidOAuthConfig("Team mapping can only be updated by Grafana Server Admins.")
+		}
+		if _, err := social.ParseTeamMapping(info.TeamMapping); err != nil {
+			return ssosettings.ErrInvalidOAuthConfig(fmt.Sprintf("Team mapping is invalid: %s.", err.Error()))
+		}
+		return nil
+	}
+}
+
 func OrgAttributePathValidator(info *social.OAuthInfo, oldInfo *social.OAuthInfo, requester identity.Requester) ssosettings.ValidateFunc[social.OAuthInfo] {
 	return func(info *social.OAuthInfo, requester identity.Requester) error {
 		hasChanged := info.OrgAttributePath != oldInfo.OrgAttributePath
@@ -229,6 +229,72 @@ func TestOrgMappingValidator(t *testing.T) {
 	}
 }
 
+func TestTeamMappingValidator(t *testing.T) {
+	tc := []testCase{
+		{
+			name: "passes when user is Grafana Admin and Team mapping was changed",
+			input: &social.OAuthInfo{
+				TeamMapping: []string{"group1:1:Platform"},
+			},
+			oldSettings: &social.OAuthInfo{
+				TeamMapping: []string{"group1:2:Platform"},
+			},
+			requester: &user.SignedInUser{
+				IsGrafanaAdmin: true,
+			},
+			wantErr: nil,
+		},
+		{
+			name: "passes when user is not Grafana Admin and Team mapping was not changed",
+			input: &social.OAuthInfo{
+				TeamMapping: []string{"group1:Main Org.:Platform"},
+			},
+			oldSettings: &social.OAuthInfo{
+				TeamMapping: []string{"group1:Main Org.:Platform"},
+			},
+			requester: &user.SignedInUser{
+				IsGrafanaAdmin: false,
+			},
+			wantErr: nil,
+		},
+		{
+			name: "fails when user is not Grafana Admin and Team mapping was changed",
+			input: &social.OAuthInfo{
+				TeamMapping: []string{"group1:1:Platform"},
+			},
+			oldSettings: &social.OAuthInfo{
+				TeamMapping: []string{},
+			},
+			requester: &user.SignedInUser{
+				IsGrafanaAdmin: false,
+			},
+			wantErr: ssosettings.ErrInvalidOAuthConfig("Team mapping can only be updated by Grafana Server Admins."),
+		},
+		{
+			name: "fails when Team mapping has an invalid format",
+			input: &social.OAuthInfo{
+				TeamMapping: []string{"group1:Platform"},
+			},
+			oldSettings: &social.OAuthInfo{},
+			requester: &user.SignedInUser{
+				IsGrafanaAdmin: true,
+			},
+			wantErr: ssosettings.ErrInvalidOAuthConfig("Team mapping is invalid."),
+		},
+	}
+
+	for _, tt := range tc {
+		t.Run(tt.name, func(t *testing.T) {
+			err := TeamMappingValidator(tt.input, tt.oldSettings, tt.requester)(tt.input, tt.requester)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
 func TestOrgAttributePathValidator(t *testing.T) {
 	tc := []testCase{
 		{
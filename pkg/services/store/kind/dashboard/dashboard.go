@@ -394,11 +394,12 @@ func readpanelInfo(iter *jsoniter.Iterator, lookup DatasourceLookup) PanelSummar
 	panel := PanelSummaryInfo{}
 
 	targets := newTargetInfo(lookup)
+	var datasource *DataSourceRef
 
 	for l1Field := iter.ReadObject(); l1Field != ""; l1Field = iter.ReadObject() {
 		if iter.WhatIsNext() == jsoniter.NilValue {
 			if l1Field == "datasource" {
-				targets.addDatasource(iter)
+				datasource = targets.addDatasource(iter)
 				continue
 			}
 
@@ -433,7 +434,7 @@ func readpanelInfo(iter *jsoniter.Iterator, lookup DatasourceLookup) PanelSummar
 			}
 
 		case "datasource":
-			targets.addDatasource(iter)
+			datasource = targets.addDatasource(iter)
 
 		case "targets":
 			switch iter.WhatIsNext() {
@@ -482,6 +483,7 @@ func readpanelInfo(iter *jsoniter.Iterator, lookup DatasourceLookup) PanelSummar
 	}
 
 	panel.Datasource = targets.GetDatasourceInfo()
+	panel.Queries = targets.getQueries(datasource)
 
 	return panel
 }
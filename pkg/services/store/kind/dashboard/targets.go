@@ -2,11 +2,21 @@ package dashboard
 
 import (
 	jsoniter "github.com/json-iterator/go"
+
+	"github.com/grafana/grafana/pkg/services/store/kind/query"
 )
 
 type targetInfo struct {
 	lookup DatasourceLookup
 	uids   map[string]*DataSourceRef
+
+	// The targets are read once the panel datasource is known
+	targets []targetModel
+}
+
+type targetModel struct {
+	datasource *DataSourceRef
+	model      map[string]any
 }
 
 func newTargetInfo(lookup DatasourceLookup) targetInfo {
@@ -27,7 +37,8 @@ func (s *targetInfo) GetDatasourceInfo() []DataSourceRef {
 }
 
 // the node will either be string (name|uid) OR ref
-func (s *targetInfo) addDatasource(iter *jsoniter.Iterator) {
+// The reference is returned as declared when the lookup does not find it
+func (s *targetInfo) addDatasource(iter *jsoniter.Iterator) *DataSourceRef {
 	switch iter.WhatIsNext() {
 	case jsoniter.StringValue:
 		key := iter.ReadString()
@@ -36,28 +47,41 @@ func (s *targetInfo) addDatasource(iter *jsoniter.Iterator) {
 		if !isVariableRef(dsRef.UID) && !isSpecialDatasource(dsRef.UID) {
 			ds := s.lookup.ByRef(dsRef)
 			s.addRef(ds)
-		} else {
-			s.addRef(dsRef)
+			return orDeclared(ds, dsRef)
 		}
+		s.addRef(dsRef)
+		return dsRef
 
 	case jsoniter.NilValue:
-		s.addRef(s.lookup.ByRef(nil))
+		ds := s.lookup.ByRef(nil)
+		s.addRef(ds)
 		iter.Skip()
+		return ds
 
 	case jsoniter.ObjectValue:
 		ref := &DataSourceRef{}
 		iter.ReadVal(ref)
 
 		if !isVariableRef(ref.UID) && !isSpecialDatasource(ref.UID) {
-			s.addRef(s.lookup.ByRef(ref))
-		} else {
-			s.addRef(ref)
+			ds := s.lookup.ByRef(ref)
+			s.addRef(ds)
+			return orDeclared(ds, ref)
 		}
+		s.addRef(ref)
+		return ref
 
 	default:
 		v := iter.Read()
 		logf("[Panel.datasource.unknown] %v\n", v)
 	}
+	return nil
+}
+
+func orDeclared(found *DataSourceRef, declared *DataSourceRef) *DataSourceRef {
+	if found != nil {
+		return found
+	}
+	return declared
 }
 
 func (s *targetInfo) addRef(ref *DataSourceRef) {
@@ -67,19 +91,44 @@ func (s *targetInfo) addRef(ref *DataSourceRef) {
 }
 
 func (s *targetInfo) addTarget(iter *jsoniter.Iterator) {
+	target := targetModel{model: map[string]any{}}
 	for l1Field := iter.ReadObject(); l1Field != ""; l1Field = iter.ReadObject() {
 		switch l1Field {
 		case "datasource":
-			s.addDatasource(iter)
-
-		case "refId":
-			iter.Skip()
+			target.datasource = s.addDatasource(iter)
 
 		default:
-			v := iter.Read()
-			logf("[Panel.TARGET] %s=%v\n", l1Field, v)
+			target.model[l1Field] = iter.Read()
+		}
+	}
+	s.targets = append(s.targets, target)
+}
+
+// getQueries reads the expressions of the targets. The panel datasource is used when
+// a target does not have its own
+func (s *targetInfo) getQueries(panelDatasource *DataSourceRef) []QuerySummaryInfo {
+	var queries []QuerySummaryInfo
+	for _, t := range s.targets {
+		ds := t.datasource
+		if ds == nil || (ds.UID == "" && ds.Type == "") {
+			ds = panelDatasource
+		}
+		if ds == nil {
+			ds = s.lookup.ByRef(nil)
+		}
+
+		q := QuerySummaryInfo{}
+		if ds != nil {
+			q.Datasource = *ds
+		}
+		q.Info = query.Read(q.Datasource.Type, t.model)
+		if q.Expression == "" {
+			continue
 		}
+		q.RefID, _ = t.model["refId"].(string)
+		queries = append(queries, q)
 	}
+	return queries
 }
 
 func (s *targetInfo) addPanel(panel PanelSummaryInfo) {
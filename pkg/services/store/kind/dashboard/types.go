@@ -1,18 +1,27 @@
 package dashboard
 
+import "github.com/grafana/grafana/pkg/services/store/kind/query"
+
 type PanelSummaryInfo struct {
-	ID            int64           `json:"id"`
-	Title         string          `json:"title"`
-	Description   string          `json:"description,omitempty"`
-	Type          string          `json:"type,omitempty"` // PluginID
-	PluginVersion string          `json:"pluginVersion,omitempty"`
-	LibraryPanel  string          `json:"libraryPanel,omitempty"` // UID of referenced library panel
-	Datasource    []DataSourceRef `json:"datasource,omitempty"`   // UIDs
-	Transformer   []string        `json:"transformer,omitempty"`  // ids of the transformation steps
+	ID            int64              `json:"id"`
+	Title         string             `json:"title"`
+	Description   string             `json:"description,omitempty"`
+	Type          string             `json:"type,omitempty"` // PluginID
+	PluginVersion string             `json:"pluginVersion,omitempty"`
+	LibraryPanel  string             `json:"libraryPanel,omitempty"` // UID of referenced library panel
+	Datasource    []DataSourceRef    `json:"datasource,omitempty"`   // UIDs
+	Transformer   []string           `json:"transformer,omitempty"`  // ids of the transformation steps
+	Queries       []QuerySummaryInfo `json:"queries,omitempty"`
 	// Rows define panels as sub objects
 	Collapsed []PanelSummaryInfo `json:"collapsed,omitempty"`
 }
 
+type QuerySummaryInfo struct {
+	RefID      string        `json:"refId,omitempty"`
+	Datasource DataSourceRef `json:"datasource"`
+	query.Info
+}
+
 type DashboardSummaryInfo struct {
 	UID           string             `json:"uid,omitempty"`
 	ID            int64              `json:"id,omitempty"` // internal ID
@@ -0,0 +1,163 @@
+// Package query reads what the queries of dashboards and alert rules select,
+// so they can be found by datasource, metric or label
+package query
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql/parser"
+)
+
+// Info describes a single query
+type Info struct {
+	// The query text, as written by the user
+	Expression string `json:"expression,omitempty"`
+
+	// The metrics selected by the query
+	Metrics []string `json:"metrics,omitempty"`
+
+	// The labels the query matches or groups by
+	Labels []string `json:"labels,omitempty"`
+}
+
+// The datasource type of server side expressions
+const expressionDatasourceType = "__expr__"
+
+// The fields holding the query text, by datasource type
+var expressionFields = map[string][]string{
+	"prometheus":                    {"expr"},
+	"loki":                          {"expr"},
+	"graphite":                      {"target"},
+	"influxdb":                      {"query"},
+	"elasticsearch":                 {"query"},
+	"mysql":                         {"rawSql"},
+	"postgres":                      {"rawSql"},
+	"grafana-postgresql-datasource": {"rawSql"},
+	"mssql":                         {"rawSql"},
+	"tempo":                         {"query"},
+	expressionDatasourceType:        {"expression"},
+}
+
+// The fields checked for the other datasource types
+var defaultExpressionFields = []string{"expr", "expression", "rawSql", "query", "target"}
+
+// Read returns the query text of a query model, with the metrics and labels it selects.
+// The metrics and labels are only read from Prometheus and Loki queries.
+func Read(dsType string, model map[string]any) Info {
+	fields, ok := expressionFields[dsType]
+	if !ok {
+		fields = defaultExpressionFields
+	}
+	info := Info{}
+	for _, f := range fields {
+		if v, ok := model[f].(string); ok && strings.TrimSpace(v) != "" {
+			info.Expression = v
+			break
+		}
+	}
+	if info.Expression == "" {
+		return info
+	}
+
+	switch dsType {
+	case "prometheus":
+		info.Metrics, info.Labels = readPromQL(info.Expression)
+	case "loki":
+		info.Labels = readLabels(info.Expression)
+	}
+	return info
+}
+
+var (
+	// Template variables used as a range, like [$__rate_interval]
+	rangeVariable = regexp.MustCompile(`\[\s*\$\{?[\w.]+(?::\w+)?\}?\s*\]`)
+	// Any other template variable, like $job, ${job:regex} or [[job]]
+	variable = regexp.MustCompile(`\$\{[^}]*\}|\$\w+|\[\[[^\]]*\]\]`)
+	// The label matchers in a selector, like {job="api", instance=~".+"}
+	selector = regexp.MustCompile(`\{([^{}]*)\}`)
+	matcher  = regexp.MustCompile(`([a-zA-Z_][\w.]*)\s*(?:=~|!~|!=|=)`)
+	// The labels of a grouping, like by (job, instance)
+	grouping = regexp.MustCompile(`\b(?:by|without|on|ignoring|group_left|group_right)\s*\(([^()]*)\)`)
+)
+
+// The value that replaces template variables, so the query can be parsed
+const variablePlaceholder = "__grafana_variable__"
+
+func readPromQL(expr string) ([]string, []string) {
+	expr = rangeVariable.ReplaceAllString(expr, "[5m]")
+	expr = variable.ReplaceAllString(expr, variablePlaceholder)
+
+	parsed, err := parser.ParseExpr(expr)
+	if err != nil {
+		// Keep what can be read without the parser
+		return nil, readLabels(expr)
+	}
+
+	metrics := map[string]bool{}
+	names := map[string]bool{}
+	parser.Inspect(parsed, func(node parser.Node, _ []parser.Node) error {
+		switch n := node.(type) {
+		case *parser.VectorSelector:
+			if n.Name != "" {
+				metrics[n.Name] = true
+			}
+			for _, m := range n.LabelMatchers {
+				if m.Name != labels.MetricName {
+					names[m.Name] = true
+				} else if m.Type == labels.MatchEqual {
+					metrics[m.Value] = true
+				}
+			}
+		case *parser.AggregateExpr:
+			for _, l := range n.Grouping {
+				names[l] = true
+			}
+		case *parser.BinaryExpr:
+			if n.VectorMatching != nil {
+				for _, l := range n.VectorMatching.MatchingLabels {
+					names[l] = true
+				}
+				for _, l := range n.VectorMatching.Include {
+					names[l] = true
+				}
+			}
+		}
+		return nil
+	})
+	return sortedKeys(metrics), sortedKeys(names)
+}
+
+// readLabels finds the labels in the selectors and groupings of a query
+func readLabels(expr string) []string {
+	names := map[string]bool{}
+	for _, s := range selector.FindAllStringSubmatch(expr, -1) {
+		for _, m := range matcher.FindAllStringSubmatch(s[1], -1) {
+			names[m[1]] = true
+		}
+	}
+	for _, g := range grouping.FindAllStringSubmatch(expr, -1) {
+		for _, l := range strings.Split(g[1], ",") {
+			if l = strings.TrimSpace(l); l != "" {
+				names[l] = true
+			}
+		}
+	}
+	return sortedKeys(names)
+}
+
+func sortedKeys(values map[string]bool) []string {
+	delete(values, variablePlaceholder)
+	delete(values, labels.MetricName)
+	if len(values) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,81 @@
+package query
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRead(t *testing.T) {
+	tests := []struct {
+		name   string
+		dsType string
+		model  map[string]any
+		expect Info
+	}{
+		{
+			name:   "prometheus",
+			dsType: "prometheus",
+			model: map[string]any{
+				"refId": "A",
+				"expr":  `sum by (cluster) (rate(http_requests_total{job="$job", code=~"5.."}[$__rate_interval])) / on(cluster) group_left(region) cluster_info`,
+			},
+			expect: Info{
+				Expression: `sum by (cluster) (rate(http_requests_total{job="$job", code=~"5.."}[$__rate_interval])) / on(cluster) group_left(region) cluster_info`,
+				Metrics:    []string{"cluster_info", "http_requests_total"},
+				Labels:     []string{"cluster", "code", "job", "region"},
+			},
+		},
+		{
+			name:   "prometheus with a variable metric",
+			dsType: "prometheus",
+			model:  map[string]any{"expr": `$metric{instance="a"} > ${threshold}`},
+			expect: Info{
+				Expression: `$metric{instance="a"} > ${threshold}`,
+				Labels:     []string{"instance"},
+			},
+		},
+		{
+			name:   "prometheus metric name matcher",
+			dsType: "prometheus",
+			model:  map[string]any{"expr": `{__name__="up", job="api"}`},
+			expect: Info{
+				Expression: `{__name__="up", job="api"}`,
+				Metrics:    []string{"up"},
+				Labels:     []string{"job"},
+			},
+		},
+		{
+			name:   "loki",
+			dsType: "loki",
+			model:  map[string]any{"expr": `sum by (level) (count_over_time({app="api", env=~"prod"} |= "error" [5m]))`},
+			expect: Info{
+				Expression: `sum by (level) (count_over_time({app="api", env=~"prod"} |= "error" [5m]))`,
+				Labels:     []string{"app", "env", "level"},
+			},
+		},
+		{
+			name:   "sql",
+			dsType: "mysql",
+			model:  map[string]any{"rawSql": "SELECT 1", "query": "ignored"},
+			expect: Info{Expression: "SELECT 1"},
+		},
+		{
+			name:   "unknown datasource",
+			dsType: "other",
+			model:  map[string]any{"target": "a.b.c"},
+			expect: Info{Expression: "a.b.c"},
+		},
+		{
+			name:   "no expression",
+			dsType: "prometheus",
+			model:  map[string]any{"expr": " "},
+			expect: Info{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expect, Read(tt.dsType, tt.model))
+		})
+	}
+}
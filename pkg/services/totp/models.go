@@ -0,0 +1,36 @@
+package totp
+
+import (
+	"github.com/grafana/grafana/pkg/apimachinery/errutil"
+)
+
+// RoleGrafanaAdmin is the role of the enforce_roles setting matching the server admins
+const RoleGrafanaAdmin = "GrafanaAdmin"
+
+var (
+	ErrNotEnrolled     = errutil.NotFound("totp.not-enrolled", errutil.WithPublicMessage("Two-factor authentication is not set up"))
+	ErrAlreadyEnabled  = errutil.Conflict("totp.already-enabled", errutil.WithPublicMessage("Two-factor authentication is already enabled"))
+	ErrInvalidCode     = errutil.Unauthorized("totp.invalid-code", errutil.WithPublicMessage("Invalid two-factor authentication code"))
+	ErrEnforced        = errutil.Forbidden("totp.enforced", errutil.WithPublicMessage("Two-factor authentication is required and cannot be disabled"))
+	ErrChallengeFailed = errutil.Unauthorized("totp.invalid-challenge", errutil.WithPublicMessage("The login has expired, sign in again"))
+	ErrCodeRequired    = errutil.Unauthorized("totp.required", errutil.WithPublicMessage("Two-factor authentication code required"))
+	ErrBasicAuth       = errutil.Unauthorized("totp.basic-auth", errutil.WithPublicMessage("Basic auth is not supported for users with two-factor authentication, use a service account token"))
+	ErrTooManyAttempts = errutil.TooManyRequests("totp.too-many-attempts", errutil.WithPublicMessage("Too many invalid codes, try again later"))
+)
+
+// Status describes the second factor of a user
+type Status struct {
+	// The user confirmed an enrollment, and is asked for a code on login
+	Enabled bool `json:"enabled"`
+	// The user must enable a second factor
+	Required bool `json:"required"`
+	// The number of the recovery codes that were not used yet
+	RecoveryCodesLeft int `json:"recoveryCodesLeft"`
+}
+
+// Enrollment is returned when a user sets up a second factor, it is the only time the secret and the recovery codes are shown
+type Enrollment struct {
+	Secret        string   `json:"secret"`
+	URL           string   `json:"url"`
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
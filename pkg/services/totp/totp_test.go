@@ -0,0 +1,64 @@
+package totp
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateCode(t *testing.T) {
+	// Test vectors of RFC 6238 for SHA1, truncated to 6 digits
+	secret := base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+	tests := map[int64]string{
+		59:          "287082",
+		1111111109:  "081804",
+		1111111111:  "050471",
+		1234567890:  "005924",
+		2000000000:  "279037",
+		20000000000: "353130",
+	}
+	for unix, expected := range tests {
+		code, err := GenerateCode(secret, time.Unix(unix, 0))
+		require.NoError(t, err)
+		require.Equal(t, expected, code, "time %d", unix)
+	}
+}
+
+func TestValidateCode(t *testing.T) {
+	secret, err := GenerateSecret()
+	require.NoError(t, err)
+	now := time.Unix(1700000000, 0)
+
+	code, err := GenerateCode(secret, now.Add(-Period))
+	require.NoError(t, err)
+
+	s, ok, err := ValidateCode(secret, code, now, 1)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, now.Unix()/30-1, s)
+
+	_, ok, err = ValidateCode(secret, code, now, 0)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	_, ok, err = ValidateCode(secret, "12345", now, 1)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	_, _, err = ValidateCode("not base32!", code, now, 1)
+	require.Error(t, err)
+
+	// Lower case secrets, as typed by the users, are accepted
+	_, ok, err = ValidateCode(strings.ToLower(secret), code, now, 1)
+	require.NoError(t, err)
+	require.True(t, ok)
+}
+
+func TestKeyURI(t *testing.T) {
+	require.Equal(t,
+		"otpauth://totp/Grafana:admin@example.com?algorithm=SHA1&digits=6&issuer=Grafana&period=30&secret=ABC",
+		KeyURI("Grafana", "admin@example.com", "ABC"))
+}
@@ -0,0 +1,135 @@
+package totpimpl
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/middleware"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/services/totp"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+type api struct {
+	service *Service
+}
+
+func registerAPI(routeRegister routing.RouteRegister, s *Service) {
+	a := &api{service: s}
+	routeRegister.Group("/api/user/totp", func(r routing.RouteRegister) {
+		r.Get("/", routing.Wrap(a.status))
+		r.Post("/enroll", routing.Wrap(a.enroll))
+		r.Post("/confirm", routing.Wrap(a.confirm))
+		r.Post("/recovery-codes", routing.Wrap(a.regenerateRecoveryCodes))
+		r.Post("/disable", routing.Wrap(a.disable))
+	}, middleware.ReqSignedInNoAnonymous)
+	routeRegister.Post("/api/login/totp/enroll", routing.Wrap(a.enrollChallenge))
+	routeRegister.Delete("/api/admin/users/:id/totp", middleware.ReqGrafanaAdmin, routing.Wrap(a.reset))
+}
+
+// CodeCommand is the body of the requests checking a code of the authenticator app, or a recovery code
+type CodeCommand struct {
+	Code string `json:"code" binding:"Required"`
+}
+
+// TokenCommand is the body of the enrollment of a user logging in, with the token returned with the password
+type TokenCommand struct {
+	Token string `json:"token" binding:"Required"`
+}
+
+// GET /api/user/totp
+func (a *api) status(c *contextmodel.ReqContext) response.Response {
+	status, err := a.service.Status(c.Req.Context(), c.SignedInUser.UserID, c.SignedInUser.GetIsGrafanaAdmin())
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to get the two-factor authentication status", err)
+	}
+	return response.JSON(http.StatusOK, status)
+}
+
+// POST /api/user/totp/enroll
+func (a *api) enroll(c *contextmodel.ReqContext) response.Response {
+	enrollment, err := a.service.Enroll(c.Req.Context(), c.SignedInUser.UserID, c.SignedInUser.GetLogin())
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to set up two-factor authentication", err)
+	}
+	return response.JSON(http.StatusOK, enrollment)
+}
+
+// POST /api/user/totp/confirm
+func (a *api) confirm(c *contextmodel.ReqContext) response.Response {
+	cmd := CodeCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	if err := a.service.Confirm(c.Req.Context(), c.SignedInUser.UserID, cmd.Code); err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to enable two-factor authentication", err)
+	}
+	return response.Success("Two-factor authentication enabled")
+}
+
+// throttle checks a code of the signed in user like the codes of the logins, so that a session can't be used
+// to guess the codes and disable the second factor
+func (a *api) throttle(c *contextmodel.ReqContext, check func() error) error {
+	addr := web.ClientIP(c.Req, a.service.trustedProxies)
+	return a.service.throttle(c.Req.Context(), c.SignedInUser.GetLogin(), addr, totp.ErrTooManyAttempts, check)
+}
+
+// POST /api/user/totp/recovery-codes
+func (a *api) regenerateRecoveryCodes(c *contextmodel.ReqContext) response.Response {
+	cmd := CodeCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	var codes []string
+	err := a.throttle(c, func() (err error) {
+		codes, err = a.service.RegenerateRecoveryCodes(c.Req.Context(), c.SignedInUser.UserID, cmd.Code)
+		return err
+	})
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to generate recovery codes", err)
+	}
+	return response.JSON(http.StatusOK, map[string]any{"recoveryCodes": codes})
+}
+
+// POST /api/user/totp/disable
+func (a *api) disable(c *contextmodel.ReqContext) response.Response {
+	cmd := CodeCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	err := a.throttle(c, func() error {
+		return a.service.Disable(c.Req.Context(), c.SignedInUser.UserID, c.SignedInUser.GetIsGrafanaAdmin(), cmd.Code)
+	})
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to disable two-factor authentication", err)
+	}
+	return response.Success("Two-factor authentication disabled")
+}
+
+// POST /api/login/totp/enroll
+func (a *api) enrollChallenge(c *contextmodel.ReqContext) response.Response {
+	cmd := TokenCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	enrollment, err := a.service.EnrollChallenge(c.Req.Context(), cmd.Token)
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to set up two-factor authentication", err)
+	}
+	return response.JSON(http.StatusOK, enrollment)
+}
+
+// DELETE /api/admin/users/:id/totp
+func (a *api) reset(c *contextmodel.ReqContext) response.Response {
+	userID, err := strconv.ParseInt(web.Params(c.Req)[":id"], 10, 64)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "id is invalid", err)
+	}
+	if err := a.service.Reset(c.Req.Context(), userID); err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to reset two-factor authentication", err)
+	}
+	c.Logger.Info("Two-factor authentication reset", "userId", userID, "by", c.SignedInUser.GetID())
+	return response.Success("Two-factor authentication reset")
+}
@@ -0,0 +1,92 @@
+package totpimpl
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	claims "github.com/grafana/authlib/types"
+
+	"github.com/grafana/grafana/pkg/apimachinery/errutil"
+	"github.com/grafana/grafana/pkg/services/authn"
+	"github.com/grafana/grafana/pkg/services/totp"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+var (
+	errBadForm         = errutil.BadRequest("totp.invalid-form", errutil.WithPublicMessage("bad login data"))
+	errTooManyAttempts = errutil.Unauthorized("totp.invalid.login-attempt", errutil.WithPublicMessage("Login temporarily blocked"))
+)
+
+var _ authn.Client = new(Client)
+
+type loginForm struct {
+	Token string `json:"token" binding:"Required"`
+	Code  string `json:"code" binding:"Required"`
+}
+
+// Client completes a login started with a password, with a code of the authenticator app or a recovery code.
+// When the second factor is enforced and was not set up, the code confirms the enrollment.
+type Client struct {
+	service *Service
+}
+
+func (c *Client) Name() string {
+	return authn.ClientTOTP
+}
+
+func (c *Client) IsEnabled() bool {
+	return true
+}
+
+func (c *Client) Authenticate(ctx context.Context, r *authn.Request) (*authn.Identity, error) {
+	form := loginForm{}
+	if err := web.Bind(r.HTTPRequest, &form); err != nil {
+		return nil, errBadForm.Errorf("failed to parse request: %w", err)
+	}
+
+	s := c.service
+	ch, err := s.getChallenge(ctx, form.Token)
+	if err != nil {
+		return nil, err
+	}
+
+	// The codes are throttled like the passwords, the failed attempts count for both
+	addr := web.ClientIP(r.HTTPRequest, s.trustedProxies)
+	err = s.throttle(ctx, ch.Login, addr, errTooManyAttempts, func() error {
+		if ch.Enroll {
+			return s.Confirm(ctx, ch.UserID, form.Code)
+		}
+		return s.Verify(ctx, ch.UserID, form.Code)
+	})
+	if err != nil {
+		if !errors.Is(err, totp.ErrInvalidCode) && !errors.Is(err, totp.ErrNotEnrolled) {
+			return nil, err
+		}
+		ch.Attempts++
+		if ch.Attempts >= maxChallengeAttempts {
+			err = s.cache.Delete(ctx, challengeKeyPrefix+form.Token)
+		} else {
+			err = s.setChallenge(ctx, form.Token, ch)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return nil, totp.ErrInvalidCode.Errorf("invalid code for user %d", ch.UserID)
+	}
+
+	if err := s.cache.Delete(ctx, challengeKeyPrefix+form.Token); err != nil {
+		return nil, err
+	}
+	// The password and the code are both correct, the failed attempts of the pair are forgotten
+	if err := s.loginAttempts.AddSuccess(ctx, ch.Login, addr); err != nil {
+		return nil, err
+	}
+	return &authn.Identity{
+		ID:              strconv.FormatInt(ch.UserID, 10),
+		Type:            claims.TypeUser,
+		OrgID:           r.OrgID,
+		ClientParams:    authn.ClientParams{FetchSyncedUser: true, SyncPermissions: true},
+		AuthenticatedBy: ch.AuthenticatedBy,
+	}, nil
+}
@@ -0,0 +1,416 @@
+package totpimpl
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net"
+	"slices"
+	"strings"
+	"time"
+
+	claims "github.com/grafana/authlib/types"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/apimachinery/errutil"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/events"
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/remotecache"
+	"github.com/grafana/grafana/pkg/services/authn"
+	"github.com/grafana/grafana/pkg/services/loginattempt"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/secrets"
+	"github.com/grafana/grafana/pkg/services/totp"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+const (
+	recoveryCodeCount = 10
+	// Steps of clock drift accepted between the server and the authenticator app
+	allowedSkew = 1
+	// Wrong codes accepted for a login before the password must be entered again
+	maxChallengeAttempts = 5
+	challengeKeyPrefix   = "totp-challenge-"
+	// Priority of the post auth hook, it runs once the user is fetched and before the permissions are loaded
+	hookPriority = 105
+)
+
+var recoveryCodeAlphabet = []byte("abcdefghjkmnpqrstuvwxyz23456789")
+
+type Service struct {
+	cfg           setting.AuthTOTPSettings
+	store         *store
+	cache         remotecache.CacheStorage
+	loginAttempts loginattempt.Service
+	orgService    org.Service
+	bus           bus.Bus
+	// the reverse proxies whose headers give the IP address of the clients
+	trustedProxies []*net.IPNet
+	now            func() time.Time
+	log            log.Logger
+}
+
+func ProvideService(cfg *setting.Cfg, sqlStore db.DB, secretsService secrets.Service, cache *remotecache.RemoteCache,
+	authnService authn.Service, loginAttempts loginattempt.Service, orgService org.Service, routeRegister routing.RouteRegister,
+	bus bus.Bus,
+) *Service {
+	s := NewService(cfg.TOTPAuth, sqlStore, secretsService, cache, loginAttempts, orgService, bus)
+	s.trustedProxies = cfg.TrustedProxies
+	if cfg.TOTPAuth.Enabled {
+		authnService.RegisterClient(&Client{service: s})
+		authnService.RegisterPostAuthHook(s.challengeHook, hookPriority)
+		registerAPI(routeRegister, s)
+	}
+	return s
+}
+
+func NewService(cfg setting.AuthTOTPSettings, sqlStore db.DB, secretsService secrets.Service, cache remotecache.CacheStorage,
+	loginAttempts loginattempt.Service, orgService org.Service, bus bus.Bus,
+) *Service {
+	return &Service{
+		cfg:           cfg,
+		store:         &store{sqlStore: sqlStore, secretsService: secretsService},
+		cache:         cache,
+		loginAttempts: loginAttempts,
+		orgService:    orgService,
+		bus:           bus,
+		now:           time.Now,
+		log:           log.New("totp"),
+	}
+}
+
+// Status returns the second factor status of a user
+func (s *Service) Status(ctx context.Context, userID int64, isGrafanaAdmin bool) (*totp.Status, error) {
+	status := &totp.Status{}
+	row, err := s.store.get(ctx, userID)
+	if err != nil && !errors.Is(err, totp.ErrNotEnrolled) {
+		return nil, err
+	}
+	if row != nil && row.Enabled {
+		status.Enabled = true
+		status.RecoveryCodesLeft = len(row.recoveryCodes())
+	}
+	if status.Required, err = s.required(ctx, userID, isGrafanaAdmin); err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+// required checks if the second factor is enforced for a user, by the organizations or the roles of the user
+func (s *Service) required(ctx context.Context, userID int64, isGrafanaAdmin bool) (bool, error) {
+	if len(s.cfg.EnforcedOrgIDs) == 0 && len(s.cfg.EnforcedRoles) == 0 {
+		return false, nil
+	}
+	if isGrafanaAdmin && slices.Contains(s.cfg.EnforcedRoles, totp.RoleGrafanaAdmin) {
+		return true, nil
+	}
+	orgs, err := s.orgService.GetUserOrgList(ctx, &org.GetUserOrgListQuery{UserID: userID})
+	if err != nil {
+		return false, err
+	}
+	for _, o := range orgs {
+		if len(s.cfg.EnforcedOrgIDs) > 0 && !slices.Contains(s.cfg.EnforcedOrgIDs, o.OrgID) {
+			continue
+		}
+		if len(s.cfg.EnforcedRoles) > 0 && !slices.Contains(s.cfg.EnforcedRoles, string(o.Role)) {
+			continue
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
+// Enroll starts the set up of a second factor, which is enabled by Confirm.
+// A previous set up that was not confirmed is replaced.
+func (s *Service) Enroll(ctx context.Context, userID int64, login string) (*totp.Enrollment, error) {
+	row, err := s.store.get(ctx, userID)
+	if err != nil && !errors.Is(err, totp.ErrNotEnrolled) {
+		return nil, err
+	}
+	if row != nil && row.Enabled {
+		return nil, totp.ErrAlreadyEnabled.Errorf("user %d already enabled a second factor", userID)
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	encoded, err := json.Marshal(hashes)
+	if err != nil {
+		return nil, err
+	}
+	now := s.now()
+	err = s.store.save(ctx, &userTOTP{
+		UserID:        userID,
+		Secret:        secret,
+		RecoveryCodes: string(encoded),
+		Created:       now,
+		Updated:       now,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &totp.Enrollment{
+		Secret:        secret,
+		URL:           totp.KeyURI(s.cfg.Issuer, login, secret),
+		RecoveryCodes: codes,
+	}, nil
+}
+
+// Confirm enables the second factor set up by Enroll, once the user entered a code of the authenticator app
+func (s *Service) Confirm(ctx context.Context, userID int64, code string) error {
+	row, err := s.store.get(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if row.Enabled {
+		return totp.ErrAlreadyEnabled.Errorf("user %d already enabled a second factor", userID)
+	}
+	step, ok, err := totp.ValidateCode(row.Secret, normalizeCode(code), s.now(), allowedSkew)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return totp.ErrInvalidCode.Errorf("invalid code")
+	}
+	return s.store.enable(ctx, userID, step, s.now())
+}
+
+// Verify checks a code of the authenticator app, or a recovery code which can then not be used again
+func (s *Service) Verify(ctx context.Context, userID int64, code string) error {
+	row, err := s.store.get(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !row.Enabled {
+		return totp.ErrNotEnrolled.Errorf("user %d did not confirm the second factor", userID)
+	}
+
+	code = normalizeCode(code)
+	if len(code) == totp.Digits {
+		step, ok, err := totp.ValidateCode(row.Secret, code, s.now(), allowedSkew)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return totp.ErrInvalidCode.Errorf("invalid code")
+		}
+		used, err := s.store.useStep(ctx, userID, step, s.now())
+		if err != nil {
+			return err
+		}
+		if !used {
+			return totp.ErrInvalidCode.Errorf("code already used")
+		}
+		return nil
+	}
+
+	hashes := row.recoveryCodes()
+	i := slices.Index(hashes, hashRecoveryCode(code))
+	if i < 0 {
+		return totp.ErrInvalidCode.Errorf("invalid recovery code")
+	}
+	updated, err := s.store.setRecoveryCodes(ctx, userID, row.RecoveryCodes, slices.Delete(hashes, i, i+1), s.now())
+	if err != nil {
+		return err
+	}
+	if !updated {
+		return totp.ErrInvalidCode.Errorf("recovery code already used")
+	}
+	s.log.FromContext(ctx).Info("Recovery code used", "userId", userID, "left", len(hashes)-1)
+	return nil
+}
+
+// throttle checks a code with the throttling of the logins, the codes are rejected with tooManyAttempts while the
+// logins of the user from the IP address are blocked, and the wrong codes count as failed login attempts
+func (s *Service) throttle(ctx context.Context, login, addr string, tooManyAttempts errutil.Base, check func() error) error {
+	ok, err := s.loginAttempts.Validate(ctx, login, addr)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return tooManyAttempts.Errorf("too many consecutive incorrect login attempts for user - login for user temporarily blocked")
+	}
+	ok, err = s.loginAttempts.ValidateIPAddress(ctx, addr)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return tooManyAttempts.Errorf("too many consecutive incorrect login attempts for IP address - login for IP address temporarily blocked")
+	}
+
+	err = check()
+	if errors.Is(err, totp.ErrInvalidCode) || errors.Is(err, totp.ErrNotEnrolled) {
+		if err := s.loginAttempts.Add(ctx, login, addr); err != nil {
+			return err
+		}
+	}
+	return err
+}
+
+// Disable removes the second factor of a user, after checking a code. It fails when the second factor is enforced.
+func (s *Service) Disable(ctx context.Context, userID int64, isGrafanaAdmin bool, code string) error {
+	required, err := s.required(ctx, userID, isGrafanaAdmin)
+	if err != nil {
+		return err
+	}
+	if required {
+		return totp.ErrEnforced.Errorf("the second factor is enforced for user %d", userID)
+	}
+	if err := s.Verify(ctx, userID, code); err != nil {
+		return err
+	}
+	return s.store.delete(ctx, userID)
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes of a user, after checking a code
+func (s *Service) RegenerateRecoveryCodes(ctx context.Context, userID int64, code string) ([]string, error) {
+	if err := s.Verify(ctx, userID, code); err != nil {
+		return nil, err
+	}
+	row, err := s.store.get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	updated, err := s.store.setRecoveryCodes(ctx, userID, row.RecoveryCodes, hashes, s.now())
+	if err != nil {
+		return nil, err
+	}
+	if !updated {
+		return nil, totp.ErrInvalidCode.Errorf("recovery codes changed concurrently")
+	}
+	return codes, nil
+}
+
+// Reset removes the second factor of a user, for the admins helping a user who lost the authenticator app and the recovery codes.
+// The user is asked to set up a new one on the next login when it is enforced.
+func (s *Service) Reset(ctx context.Context, userID int64) error {
+	if err := s.store.delete(ctx, userID); err != nil {
+		return err
+	}
+	// the reset is recorded by the audit log, the second factor is already removed when it fails
+	if err := s.bus.Publish(ctx, &events.TOTPReset{Timestamp: s.now(), UserID: userID}); err != nil {
+		s.log.FromContext(ctx).Error("Failed to publish the reset of the second factor", "userId", userID, "error", err)
+	}
+	return nil
+}
+
+// challenge is the state of a login waiting for a code, after the password was checked
+type challenge struct {
+	UserID          int64  `json:"userId"`
+	Login           string `json:"login"`
+	AuthenticatedBy string `json:"authenticatedBy"`
+	// The user must set up the second factor before completing the login
+	Enroll   bool `json:"enroll"`
+	Attempts int  `json:"attempts"`
+}
+
+// challengeHook stops the logins with a password of the users with a second factor, returning a challenge to complete with a code.
+// The basic auth requests of these users are rejected.
+func (s *Service) challengeHook(ctx context.Context, id *authn.Identity, r *authn.Request) error {
+	// Only the password clients set the username, the other clients rely on the second factor of the identity provider
+	login := r.GetMeta(authn.MetaKeyUsername)
+	if login == "" || !id.IsIdentityType(claims.TypeUser) {
+		return nil
+	}
+	userID, err := id.GetInternalID()
+	if err != nil {
+		return err
+	}
+	status, err := s.Status(ctx, userID, id.GetIsGrafanaAdmin())
+	if err != nil {
+		return err
+	}
+	if !status.Enabled && !status.Required {
+		return nil
+	}
+
+	if r.GetMeta(authn.MetaKeyIsLogin) == "" {
+		return totp.ErrBasicAuth.Errorf("user %d has a second factor", userID)
+	}
+
+	token, err := util.GetRandomString(32)
+	if err != nil {
+		return err
+	}
+	ch := &challenge{UserID: userID, Login: login, AuthenticatedBy: id.AuthenticatedBy, Enroll: !status.Enabled}
+	if err := s.setChallenge(ctx, token, ch); err != nil {
+		return err
+	}
+	e := totp.ErrCodeRequired.Errorf("second factor required for user %d", userID)
+	e.PublicPayload = map[string]any{"token": token, "enroll": ch.Enroll}
+	return e
+}
+
+func (s *Service) setChallenge(ctx context.Context, token string, ch *challenge) error {
+	value, err := json.Marshal(ch)
+	if err != nil {
+		return err
+	}
+	return s.cache.Set(ctx, challengeKeyPrefix+token, value, s.cfg.ChallengeExpiration)
+}
+
+func (s *Service) getChallenge(ctx context.Context, token string) (*challenge, error) {
+	value, err := s.cache.Get(ctx, challengeKeyPrefix+token)
+	if err != nil {
+		if errors.Is(err, remotecache.ErrCacheItemNotFound) {
+			return nil, totp.ErrChallengeFailed.Errorf("challenge not found")
+		}
+		return nil, err
+	}
+	ch := &challenge{}
+	if err := json.Unmarshal(value, ch); err != nil {
+		return nil, err
+	}
+	return ch, nil
+}
+
+// EnrollChallenge starts the set up of the second factor of a user logging in, when it is enforced and was not set up yet
+func (s *Service) EnrollChallenge(ctx context.Context, token string) (*totp.Enrollment, error) {
+	ch, err := s.getChallenge(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	if !ch.Enroll {
+		return nil, totp.ErrAlreadyEnabled.Errorf("user %d already enabled a second factor", ch.UserID)
+	}
+	return s.Enroll(ctx, ch.UserID, ch.Login)
+}
+
+func generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	for range recoveryCodeCount {
+		code, err := util.GetRandomString(10, recoveryCodeAlphabet...)
+		if err != nil {
+			return nil, nil, err
+		}
+		code = code[:5] + "-" + code[5:]
+		codes = append(codes, code)
+		hashes = append(hashes, hashRecoveryCode(code))
+	}
+	return codes, hashes, nil
+}
+
+// The recovery codes are random enough to not need a slow hash
+func hashRecoveryCode(code string) string {
+	sum := sha256.Sum256([]byte(strings.ReplaceAll(normalizeCode(code), "-", "")))
+	return hex.EncodeToString(sum[:])
+}
+
+func normalizeCode(code string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), " ", ""))
+}
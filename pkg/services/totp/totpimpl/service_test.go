@@ -0,0 +1,249 @@
+package totpimpl
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	claims "github.com/grafana/authlib/types"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/apimachinery/errutil"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/events"
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/remotecache"
+	"github.com/grafana/grafana/pkg/infra/tracing"
+	"github.com/grafana/grafana/pkg/services/authn"
+	"github.com/grafana/grafana/pkg/services/login"
+	"github.com/grafana/grafana/pkg/services/loginattempt/loginattempttest"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/org/orgtest"
+	"github.com/grafana/grafana/pkg/services/secrets/fakes"
+	"github.com/grafana/grafana/pkg/services/totp"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestIntegrationService(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := context.Background()
+	sqlStore := db.InitTestDB(t)
+	loginAttempts := &loginattempttest.MockLoginAttemptService{ExpectedValid: true}
+	orgService := &orgtest.FakeOrgService{ExpectedUserOrgDTO: []*org.UserOrgDTO{{OrgID: 1, Role: org.RoleViewer}}}
+	eventBus := bus.ProvideBus(tracing.InitializeTracerForTest())
+	var resets []int64
+	eventBus.AddEventListener(func(ctx context.Context, e *events.TOTPReset) error {
+		resets = append(resets, e.UserID)
+		return nil
+	})
+	s := NewService(setting.AuthTOTPSettings{
+		Enabled:             true,
+		Issuer:              "Grafana",
+		EnforcedRoles:       []string{string(org.RoleAdmin)},
+		ChallengeExpiration: time.Minute,
+	}, sqlStore, fakes.NewFakeSecretsService(), remotecache.NewFakeCacheStorage(), loginAttempts, orgService, eventBus)
+	now := time.Unix(1700000000, 0)
+	s.now = func() time.Time { return now }
+	client := &Client{service: s}
+
+	code := func(secret string) string {
+		c, err := totp.GenerateCode(secret, now)
+		require.NoError(t, err)
+		return c
+	}
+	passwordLogin := func(userID int64, isLogin bool) error {
+		r := &authn.Request{}
+		r.SetMeta(authn.MetaKeyUsername, fmt.Sprintf("user-%d", userID))
+		if isLogin {
+			r.SetMeta(authn.MetaKeyIsLogin, "true")
+		}
+		id := &authn.Identity{ID: fmt.Sprint(userID), Type: claims.TypeUser, AuthenticatedBy: login.PasswordAuthModule}
+		return s.challengeHook(ctx, id, r)
+	}
+	challengeToken := func(err error) (string, bool) {
+		t.Helper()
+		require.ErrorIs(t, err, totp.ErrCodeRequired)
+		public := err.(errutil.Error).Public()
+		return public.Extra["token"].(string), public.Extra["enroll"].(bool)
+	}
+	authenticate := func(token, code string) (*authn.Identity, error) {
+		return client.Authenticate(ctx, &authn.Request{OrgID: 1, HTTPRequest: &http.Request{
+			Header: map[string][]string{"Content-Type": {"application/json"}},
+			Body:   io.NopCloser(strings.NewReader(fmt.Sprintf(`{"token": %q, "code": %q}`, token, code))),
+		}})
+	}
+
+	t.Run("users without second factor log in with the password", func(t *testing.T) {
+		require.NoError(t, passwordLogin(1, true))
+		require.NoError(t, passwordLogin(1, false))
+	})
+
+	enrollment, err := s.Enroll(ctx, 1, "user-1")
+	require.NoError(t, err)
+	require.Len(t, enrollment.RecoveryCodes, recoveryCodeCount)
+	require.Contains(t, enrollment.URL, "otpauth://totp/Grafana:user-1?")
+
+	t.Run("the second factor is enabled once confirmed", func(t *testing.T) {
+		require.NoError(t, passwordLogin(1, true))
+		require.ErrorIs(t, s.Confirm(ctx, 1, "000000"), totp.ErrInvalidCode)
+		require.NoError(t, s.Confirm(ctx, 1, code(enrollment.Secret)))
+
+		status, err := s.Status(ctx, 1, false)
+		require.NoError(t, err)
+		require.Equal(t, &totp.Status{Enabled: true, RecoveryCodesLeft: recoveryCodeCount}, status)
+
+		_, err = s.Enroll(ctx, 1, "user-1")
+		require.ErrorIs(t, err, totp.ErrAlreadyEnabled)
+	})
+
+	t.Run("the login asks for a code", func(t *testing.T) {
+		require.ErrorIs(t, passwordLogin(1, false), totp.ErrBasicAuth)
+
+		token, enroll := challengeToken(passwordLogin(1, true))
+		require.False(t, enroll)
+
+		_, err := authenticate(token, "000000")
+		require.ErrorIs(t, err, totp.ErrInvalidCode)
+		require.True(t, loginAttempts.AddCalled)
+
+		// Moving to the next time step, the code confirming the enrollment can't be used again
+		now = now.Add(totp.Period)
+		id, err := authenticate(token, code(enrollment.Secret))
+		require.NoError(t, err)
+		require.Equal(t, "1", id.ID)
+		require.Equal(t, login.PasswordAuthModule, id.AuthenticatedBy)
+
+		_, err = authenticate(token, code(enrollment.Secret))
+		require.ErrorIs(t, err, totp.ErrChallengeFailed)
+
+		token, _ = challengeToken(passwordLogin(1, true))
+		_, err = authenticate(token, code(enrollment.Secret))
+		require.ErrorIs(t, err, totp.ErrInvalidCode)
+	})
+
+	t.Run("recovery codes can be used once", func(t *testing.T) {
+		token, _ := challengeToken(passwordLogin(1, true))
+		_, err := authenticate(token, strings.ToUpper(enrollment.RecoveryCodes[0]))
+		require.NoError(t, err)
+
+		token, _ = challengeToken(passwordLogin(1, true))
+		_, err = authenticate(token, enrollment.RecoveryCodes[0])
+		require.ErrorIs(t, err, totp.ErrInvalidCode)
+
+		status, err := s.Status(ctx, 1, false)
+		require.NoError(t, err)
+		require.Equal(t, recoveryCodeCount-1, status.RecoveryCodesLeft)
+	})
+
+	t.Run("the challenge expires after too many wrong codes", func(t *testing.T) {
+		token, _ := challengeToken(passwordLogin(1, true))
+		for range maxChallengeAttempts {
+			_, err := authenticate(token, "000000")
+			require.ErrorIs(t, err, totp.ErrInvalidCode)
+		}
+		now = now.Add(totp.Period)
+		_, err := authenticate(token, code(enrollment.Secret))
+		require.ErrorIs(t, err, totp.ErrChallengeFailed)
+	})
+
+	t.Run("blocked logins are not checked", func(t *testing.T) {
+		token, _ := challengeToken(passwordLogin(1, true))
+		loginAttempts.ExpectedValid = false
+		t.Cleanup(func() { loginAttempts.ExpectedValid = true })
+		now = now.Add(totp.Period)
+		_, err := authenticate(token, code(enrollment.Secret))
+		require.ErrorIs(t, err, errTooManyAttempts)
+	})
+
+	t.Run("enforced users set up the second factor when logging in", func(t *testing.T) {
+		orgService.ExpectedUserOrgDTO = []*org.UserOrgDTO{{OrgID: 1, Role: org.RoleAdmin}}
+		t.Cleanup(func() { orgService.ExpectedUserOrgDTO = []*org.UserOrgDTO{{OrgID: 1, Role: org.RoleViewer}} })
+
+		token, enroll := challengeToken(passwordLogin(2, true))
+		require.True(t, enroll)
+		enrollment, err := s.EnrollChallenge(ctx, token)
+		require.NoError(t, err)
+		id, err := authenticate(token, code(enrollment.Secret))
+		require.NoError(t, err)
+		require.Equal(t, "2", id.ID)
+
+		status, err := s.Status(ctx, 2, false)
+		require.NoError(t, err)
+		require.True(t, status.Enabled)
+		require.True(t, status.Required)
+
+		now = now.Add(totp.Period)
+		require.ErrorIs(t, s.Disable(ctx, 2, false, code(enrollment.Secret)), totp.ErrEnforced)
+
+		require.NoError(t, s.Reset(ctx, 2))
+		require.Equal(t, []int64{2}, resets)
+		_, enroll = challengeToken(passwordLogin(2, true))
+		require.True(t, enroll)
+	})
+
+	t.Run("the codes checked with a session are throttled like the logins", func(t *testing.T) {
+		disable := func() error { return s.Disable(ctx, 1, false, "000000") }
+
+		loginAttempts.AddCalled = false
+		err := s.throttle(ctx, "user-1", "10.0.0.1", totp.ErrTooManyAttempts, disable)
+		require.ErrorIs(t, err, totp.ErrInvalidCode)
+		require.True(t, loginAttempts.AddCalled)
+
+		loginAttempts.ExpectedValid = false
+		t.Cleanup(func() { loginAttempts.ExpectedValid = true })
+		err = s.throttle(ctx, "user-1", "10.0.0.1", totp.ErrTooManyAttempts, func() error {
+			require.Fail(t, "the code of a blocked user must not be checked")
+			return nil
+		})
+		require.ErrorIs(t, err, totp.ErrTooManyAttempts)
+	})
+
+	t.Run("the second factor can be disabled with a code", func(t *testing.T) {
+		now = now.Add(totp.Period)
+		codes, err := s.RegenerateRecoveryCodes(ctx, 1, code(enrollment.Secret))
+		require.NoError(t, err)
+		require.Len(t, codes, recoveryCodeCount)
+
+		require.ErrorIs(t, s.Disable(ctx, 1, false, enrollment.RecoveryCodes[1]), totp.ErrInvalidCode)
+		require.NoError(t, s.Disable(ctx, 1, false, codes[0]))
+		require.NoError(t, passwordLogin(1, true))
+	})
+}
+
+func TestRequired(t *testing.T) {
+	orgService := &orgtest.FakeOrgService{ExpectedUserOrgDTO: []*org.UserOrgDTO{
+		{OrgID: 1, Role: org.RoleViewer},
+		{OrgID: 2, Role: org.RoleAdmin},
+	}}
+	tests := []struct {
+		name           string
+		orgIDs         []int64
+		roles          []string
+		isGrafanaAdmin bool
+		expected       bool
+	}{
+		{name: "not enforced", expected: false},
+		{name: "enforced in an org of the user", orgIDs: []int64{1}, expected: true},
+		{name: "enforced in another org", orgIDs: []int64{3}, expected: false},
+		{name: "enforced for a role of the user", roles: []string{"Admin"}, expected: true},
+		{name: "enforced for a role of the user in another org", orgIDs: []int64{1}, roles: []string{"Admin"}, expected: false},
+		{name: "enforced for a role of the user in the org", orgIDs: []int64{2}, roles: []string{"Admin"}, expected: true},
+		{name: "enforced for the server admins", roles: []string{totp.RoleGrafanaAdmin}, isGrafanaAdmin: true, expected: true},
+		{name: "enforced for the server admins only", roles: []string{totp.RoleGrafanaAdmin}, expected: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Service{cfg: setting.AuthTOTPSettings{EnforcedOrgIDs: tt.orgIDs, EnforcedRoles: tt.roles}, orgService: orgService}
+			required, err := s.required(context.Background(), 1, tt.isGrafanaAdmin)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, required)
+		})
+	}
+}
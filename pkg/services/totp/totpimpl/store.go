@@ -0,0 +1,144 @@
+package totpimpl
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/services/secrets"
+	"github.com/grafana/grafana/pkg/services/totp"
+)
+
+// userTOTP is the second factor of a user
+type userTOTP struct {
+	ID     int64 `xorm:"pk autoincr 'id'"`
+	UserID int64 `xorm:"user_id"`
+	// Encrypted with the secrets service
+	Secret string `xorm:"secret"`
+	// JSON list of the hashes of the recovery codes that were not used yet
+	RecoveryCodes string `xorm:"recovery_codes"`
+	Enabled       bool   `xorm:"enabled"`
+	// The time step of the last accepted code, the codes can only be used once
+	LastUsedStep int64     `xorm:"last_used_step"`
+	Created      time.Time `xorm:"created"`
+	Updated      time.Time `xorm:"updated"`
+}
+
+func (userTOTP) TableName() string {
+	return "user_totp"
+}
+
+func (u *userTOTP) recoveryCodes() []string {
+	var hashes []string
+	_ = json.Unmarshal([]byte(u.RecoveryCodes), &hashes)
+	return hashes
+}
+
+type store struct {
+	sqlStore       db.DB
+	secretsService secrets.Service
+}
+
+func (s *store) get(ctx context.Context, userID int64) (*userTOTP, error) {
+	row := &userTOTP{}
+	err := s.sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+		found, err := sess.Where("user_id = ?", userID).Get(row)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return totp.ErrNotEnrolled.Errorf("user %d has no second factor", userID)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if row.Secret, err = s.decodeAndDecrypt(ctx, row.Secret); err != nil {
+		return nil, err
+	}
+	return row, nil
+}
+
+// save replaces the second factor of a user
+func (s *store) save(ctx context.Context, row *userTOTP) error {
+	clone := *row
+	var err error
+	if clone.Secret, err = s.encryptAndEncode(ctx, row.Secret); err != nil {
+		return err
+	}
+	return s.sqlStore.InTransaction(ctx, func(ctx context.Context) error {
+		return s.sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+			if _, err := sess.Where("user_id = ?", row.UserID).Delete(&userTOTP{}); err != nil {
+				return err
+			}
+			_, err := sess.Insert(&clone)
+			return err
+		})
+	})
+}
+
+func (s *store) enable(ctx context.Context, userID, step int64, now time.Time) error {
+	return s.sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+		_, err := sess.Where("user_id = ?", userID).Cols("enabled", "last_used_step", "updated").
+			Update(&userTOTP{Enabled: true, LastUsedStep: step, Updated: now})
+		return err
+	})
+}
+
+// useStep records the time step of an accepted code, it returns false when a code of the step, or of a later one, was already used
+func (s *store) useStep(ctx context.Context, userID, step int64, now time.Time) (bool, error) {
+	var used bool
+	err := s.sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+		affected, err := sess.Where("user_id = ? AND last_used_step < ?", userID, step).Cols("last_used_step", "updated").
+			Update(&userTOTP{LastUsedStep: step, Updated: now})
+		used = affected > 0
+		return err
+	})
+	return used, err
+}
+
+// setRecoveryCodes replaces the recovery codes, it returns false when they were changed concurrently
+func (s *store) setRecoveryCodes(ctx context.Context, userID int64, previous string, hashes []string, now time.Time) (bool, error) {
+	codes, err := json.Marshal(hashes)
+	if err != nil {
+		return false, err
+	}
+	var updated bool
+	err = s.sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+		affected, err := sess.Where("user_id = ? AND recovery_codes = ?", userID, previous).Cols("recovery_codes", "updated").
+			Update(&userTOTP{RecoveryCodes: string(codes), Updated: now})
+		updated = affected > 0
+		return err
+	})
+	return updated, err
+}
+
+func (s *store) delete(ctx context.Context, userID int64) error {
+	return s.sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+		_, err := sess.Where("user_id = ?", userID).Delete(&userTOTP{})
+		return err
+	})
+}
+
+func (s *store) encryptAndEncode(ctx context.Context, str string) (string, error) {
+	encrypted, err := s.secretsService.Encrypt(ctx, []byte(str), secrets.WithoutScope())
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(encrypted), nil
+}
+
+func (s *store) decodeAndDecrypt(ctx context.Context, str string) (string, error) {
+	decoded, err := base64.StdEncoding.DecodeString(str)
+	if err != nil {
+		return "", err
+	}
+	decrypted, err := s.secretsService.Decrypt(ctx, decoded)
+	if err != nil {
+		return "", err
+	}
+	return string(decrypted), nil
+}
@@ -81,10 +81,23 @@ func (ss *sqlStore) Insert(ctx context.Context, cmd *user.User) (int64, error) {
 }
 
 func (ss *sqlStore) Delete(ctx context.Context, userID int64) error {
-	err := ss.db.WithDbSession(ctx, func(sess *db.Session) error {
+	err := ss.db.WithTransactionalDbSession(ctx, func(sess *db.Session) error {
+		var usr user.User
+		if _, err := sess.ID(userID).Get(&usr); err != nil {
+			return err
+		}
+
 		var rawSQL = "DELETE FROM " + ss.dialect.Quote("user") + " WHERE id = ?"
-		_, err := sess.Exec(rawSQL, userID)
-		return err
+		if _, err := sess.Exec(rawSQL, userID); err != nil {
+			return err
+		}
+
+		sess.PublishAfterCommit(&events.UserDeleted{
+			Timestamp: time.Now(),
+			Id:        userID,
+			Login:     usr.Login,
+		})
+		return nil
 	})
 	if err != nil {
 		return err
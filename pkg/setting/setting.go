@@ -8,6 +8,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
@@ -168,6 +169,19 @@ type Cfg struct {
 	StrictTransportSecurityMaxAge        int
 	StrictTransportSecurityPreload       bool
 	StrictTransportSecuritySubDomains    bool
+	// BruteForceLoginProtectionBackoff throttles the logins with an exponential backoff per username and IP address pair,
+	// instead of blocking the username.
+	BruteForceLoginProtectionBackoff        bool
+	BruteForceLoginProtectionBackoffInitial time.Duration
+	BruteForceLoginProtectionBackoffMax     time.Duration
+	// BruteForceLoginProtectionAllowedNetworks are exempted from the login throttling.
+	BruteForceLoginProtectionAllowedNetworks []*net.IPNet
+	// BruteForceLoginProtectionUsernameMaxAttempts are the failed attempts of a username from any IP address
+	// before the username is throttled by the backoff, zero disables the throttling of the usernames.
+	BruteForceLoginProtectionUsernameMaxAttempts int64
+	// TrustedProxies are the networks of the reverse proxies whose X-Forwarded-For and X-Real-IP headers
+	// are used to find the IP address of the clients.
+	TrustedProxies []*net.IPNet
 	// CSPEnabled toggles Content Security Policy support.
 	CSPEnabled bool
 	// CSPTemplate contains the Content Security Policy template.
@@ -276,6 +290,15 @@ type Cfg struct {
 
 	PasswordlessMagicLinkAuth AuthPasswordlessMagicLinkSettings
 
+	TOTPAuth    AuthTOTPSettings
+	PasskeyAuth AuthPasskeySettings
+
+	// Session policies (idle timeout per role, client IP binding)
+	SessionPolicy AuthSessionPolicySettings
+
+	// Audit log of the security-relevant actions
+	AuditLog AuditLogSettings
+
 	// SSO Settings Auth
 	SSOSettingsReloadInterval        time.Duration
 	SSOSettingsConfigurableProviders map[string]bool
@@ -338,6 +361,8 @@ type Cfg struct {
 	// Default behavior for the "Manage alerts via Alerting UI" toggle when configuring a data source.
 	// It only works if the data source's `jsonData.manageAlerts` prop does not contain a previously configured value.
 	DefaultDatasourceManageAlertsUIToggle bool
+	// Restrict the queries of the team members to the query restrictions of their teams
+	QueryRestrictionsEnabled bool
 
 	// IP range access control
 	IPRangeACEnabled     bool
@@ -547,12 +572,44 @@ type Cfg struct {
 	IndexMaxBatchSize           int
 	IndexFileThreshold          int
 	IndexMinCount               int
+	IndexCompactionInterval     time.Duration
+	IndexCompactionThreshold    float64
+	ChangeFeed                  ChangeFeedSettings
+	UnifiedStorageQuotas        UnifiedStorageQuotaSettings
 	SprinklesApiServer          string
 	SprinklesApiServerPageLimit int
 	CACertPath                  string
 	HttpsSkipVerify             bool
 }
 
+// ChangeFeedSettings configures the delivery of the changes of unified storage resources to webhooks
+type ChangeFeedSettings struct {
+	Enabled bool
+	// The resources to watch, as resource.group
+	Resources []string
+	// How many times a webhook is called before the event is moved to the dead letters
+	MaxAttempts int
+	// The delay before the first retry, doubled on each attempt
+	RetryBackoff time.Duration
+	Timeout      time.Duration
+	// How often the subscriptions are read from the database
+	RefreshInterval time.Duration
+}
+
+// UnifiedStorageQuotaSettings caps the objects stored in each namespace, zero values are not limited.
+// The limits of a resource can be set in its [unified_storage.<resource>.<group>] section.
+type UnifiedStorageQuotaSettings struct {
+	// Limits of all the resources of a namespace together
+	NamespaceMaxObjects int64
+	NamespaceMaxBytes   int64
+	// Limits of each resource of a namespace
+	MaxObjects    int64
+	MaxBytes      int64
+	MaxObjectSize int64
+	// How long the usage of a namespace is trusted before being counted again
+	RefreshInterval time.Duration
+}
+
 type UnifiedStorageConfig struct {
 	DualWriterMode                       rest.DualWriterMode
 	DualWriterPeriodicDataSyncJobEnabled bool
@@ -560,6 +617,11 @@ type UnifiedStorageConfig struct {
 	DataSyncerInterval time.Duration
 	// DataSyncerRecordsLimit defines how many records will be processed at max during a sync invocation.
 	DataSyncerRecordsLimit int
+	// Quotas of the resource in each namespace, replacing the defaults of the [unified_storage] section when set.
+	// A negative value removes the default limit.
+	QuotaMaxObjects int64
+	QuotaMaxBytes   int64
+	MaxObjectSize   int64
 }
 
 type InstallPlugin struct {
@@ -1280,6 +1342,14 @@ func (cfg *Cfg) parseINIFile(iniFile *ini.File) error {
 	cfg.readAuthProxySettings()
 	cfg.readSessionConfig()
 	cfg.readPasswordlessMagicLinkSettings()
+	cfg.readAuthTOTPSettings()
+	cfg.readAuthPasskeySettings()
+	if err := cfg.readAuthSessionPolicySettings(); err != nil {
+		return err
+	}
+	if err := cfg.readAuditLogSettings(); err != nil {
+		return err
+	}
 	if err := cfg.readSmtpSettings(); err != nil {
 		return err
 	}
@@ -1545,6 +1615,35 @@ func readSecuritySettings(iniFile *ini.File, cfg *Cfg) error {
 		cfg.BruteForceLoginProtectionMaxAttempts = 1
 	}
 
+	cfg.BruteForceLoginProtectionBackoff = security.Key("brute_force_login_protection_backoff").MustBool(false)
+	cfg.BruteForceLoginProtectionBackoffInitial = security.Key("brute_force_login_protection_backoff_initial").MustDuration(time.Second)
+	cfg.BruteForceLoginProtectionBackoffMax = security.Key("brute_force_login_protection_backoff_max").MustDuration(15 * time.Minute)
+	if cfg.BruteForceLoginProtectionBackoffInitial <= 0 {
+		cfg.BruteForceLoginProtectionBackoffInitial = time.Second
+	}
+	if cfg.BruteForceLoginProtectionBackoffMax < cfg.BruteForceLoginProtectionBackoffInitial {
+		cfg.BruteForceLoginProtectionBackoffMax = cfg.BruteForceLoginProtectionBackoffInitial
+	}
+	cfg.BruteForceLoginProtectionUsernameMaxAttempts = security.Key("brute_force_login_protection_username_max_attempts").MustInt64(0)
+
+	cfg.BruteForceLoginProtectionAllowedNetworks = nil
+	for _, cidr := range util.SplitString(valueAsString(security, "brute_force_login_protection_allowed_cidrs", "")) {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return fmt.Errorf("invalid network %q in brute_force_login_protection_allowed_cidrs: %w", cidr, err)
+		}
+		cfg.BruteForceLoginProtectionAllowedNetworks = append(cfg.BruteForceLoginProtectionAllowedNetworks, network)
+	}
+
+	cfg.TrustedProxies = nil
+	for _, cidr := range util.SplitString(valueAsString(security, "trusted_proxies", "")) {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return fmt.Errorf("invalid network %q in trusted_proxies: %w", cidr, err)
+		}
+		cfg.TrustedProxies = append(cfg.TrustedProxies, network)
+	}
+
 	CookieSecure = security.Key("cookie_secure").MustBool(false)
 	cfg.CookieSecure = CookieSecure
 
@@ -1929,6 +2028,7 @@ func (cfg *Cfg) readDataSourcesSettings() {
 	cfg.DataSourceLimit = datasources.Key("datasource_limit").MustInt(5000)
 	cfg.ConcurrentQueryCount = datasources.Key("concurrent_query_count").MustInt(10)
 	cfg.DefaultDatasourceManageAlertsUIToggle = datasources.Key("default_manage_alerts_ui_toggle").MustBool(true)
+	cfg.QueryRestrictionsEnabled = datasources.Key("query_restrictions_enabled").MustBool(false)
 }
 
 func (cfg *Cfg) readDataSourceSecuritySettings() {
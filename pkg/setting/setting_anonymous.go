@@ -1,11 +1,35 @@
 package setting
 
+import (
+	"strings"
+
+	"github.com/grafana/grafana/pkg/util"
+)
+
 type AnonymousSettings struct {
 	Enabled     bool
 	OrgName     string
 	OrgRole     string
 	HideVersion bool
 	DeviceLimit int64
+	// FolderUIDs and DashboardUIDs restrict the anonymous access to these folders and dashboards
+	FolderUIDs    []string
+	DashboardUIDs []string
+	// DataSourceUIDs are the data sources the anonymous users can query when their access is scoped
+	DataSourceUIDs []string
+	// Permissions are the other RBAC permissions of the anonymous users when their access is scoped,
+	// as "<action> <scope>" pairs
+	Permissions []string
+	// QueryRateLimit is the number of queries per minute allowed for an anonymous client ip, 0 means unlimited
+	QueryRateLimit int64
+	// GlobalQueryRateLimit is the number of queries per minute allowed for all the anonymous clients together,
+	// 0 means unlimited
+	GlobalQueryRateLimit int64
+}
+
+// Scoped returns whether the anonymous access is restricted to folders and dashboards instead of the org role
+func (s AnonymousSettings) Scoped() bool {
+	return len(s.FolderUIDs) > 0 || len(s.DashboardUIDs) > 0
 }
 
 func (cfg *Cfg) readAnonymousSettings() {
@@ -22,5 +46,16 @@ func (cfg *Cfg) readAnonymousSettings() {
 	}
 	anonSettings.HideVersion = anonSection.Key("hide_version").MustBool(false)
 	anonSettings.DeviceLimit = anonSection.Key("device_limit").MustInt64(0)
+	anonSettings.FolderUIDs = util.SplitString(anonSection.Key("folder_uids").MustString(""))
+	anonSettings.DashboardUIDs = util.SplitString(anonSection.Key("dashboard_uids").MustString(""))
+	anonSettings.DataSourceUIDs = util.SplitString(anonSection.Key("datasource_uids").MustString(""))
+	anonSettings.Permissions = []string{}
+	for _, permission := range strings.Split(anonSection.Key("permissions").MustString("annotations:read annotations:type:dashboard"), ",") {
+		if permission = strings.TrimSpace(permission); permission != "" {
+			anonSettings.Permissions = append(anonSettings.Permissions, permission)
+		}
+	}
+	anonSettings.QueryRateLimit = anonSection.Key("query_rate_limit").MustInt64(0)
+	anonSettings.GlobalQueryRateLimit = anonSection.Key("global_query_rate_limit").MustInt64(10 * anonSettings.QueryRateLimit)
 	cfg.Anonymous = anonSettings
 }
@@ -0,0 +1,82 @@
+package setting
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
+
+	"github.com/grafana/grafana/pkg/util"
+)
+
+const (
+	AuditLogSinkDatabase = "database"
+	AuditLogSinkFile     = "file"
+	AuditLogSinkSyslog   = "syslog"
+)
+
+type AuditLogSettings struct {
+	// Record the security-relevant actions
+	Enabled bool
+	// Sinks receiving the events: database, file and/or syslog
+	Sinks []string
+	// How long the events are kept in the database
+	Retention time.Duration
+
+	// File sink, rotated like the log files
+	FilePath         string
+	FileMaxDays      int64
+	FileMaxSizeShift int
+	FileDailyRotate  bool
+
+	// Syslog sink
+	SyslogNetwork  string
+	SyslogAddress  string
+	SyslogFacility string
+	SyslogTag      string
+}
+
+// HasSink returns whether the events are sent to the given sink
+func (s AuditLogSettings) HasSink(sink string) bool {
+	for _, v := range s.Sinks {
+		if v == sink {
+			return true
+		}
+	}
+	return false
+}
+
+func (cfg *Cfg) readAuditLogSettings() error {
+	section := cfg.SectionWithEnvOverrides("audit_log")
+	settings := AuditLogSettings{}
+	settings.Enabled = section.Key("enabled").MustBool(false)
+	settings.Sinks = util.SplitString(section.Key("sinks").MustString(AuditLogSinkDatabase))
+	for _, sink := range settings.Sinks {
+		if sink != AuditLogSinkDatabase && sink != AuditLogSinkFile && sink != AuditLogSinkSyslog {
+			return fmt.Errorf("invalid audit log sink %q, expected %s, %s or %s", sink, AuditLogSinkDatabase, AuditLogSinkFile, AuditLogSinkSyslog)
+		}
+	}
+
+	retention, err := gtime.ParseDuration(section.Key("retention").MustString("90d"))
+	if err != nil {
+		return fmt.Errorf("invalid audit log retention: %w", err)
+	}
+	settings.Retention = retention
+
+	settings.FilePath = section.Key("file_path").MustString("")
+	if settings.FilePath == "" {
+		settings.FilePath = filepath.Join(cfg.LogsPath, "audit.log")
+	}
+	settings.FileMaxDays = section.Key("file_max_days").MustInt64(7)
+	settings.FileMaxSizeShift = section.Key("file_max_size_shift").MustInt(28)
+	settings.FileDailyRotate = section.Key("file_daily_rotate").MustBool(true)
+
+	settings.SyslogNetwork = section.Key("syslog_network").MustString("")
+	settings.SyslogAddress = section.Key("syslog_address").MustString("")
+	settings.SyslogFacility = section.Key("syslog_facility").MustString("local7")
+	settings.SyslogTag = section.Key("syslog_tag").MustString("grafana-audit")
+
+	cfg.AuditLog = settings
+	return nil
+}
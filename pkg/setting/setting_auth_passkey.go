@@ -0,0 +1,54 @@
+package setting
+
+import (
+	"net/url"
+	"time"
+
+	"github.com/grafana/grafana/pkg/util"
+)
+
+type AuthPasskeySettings struct {
+	// Sign in with passkeys (WebAuthn)
+	Enabled bool
+	// Domain the passkeys are bound to, the domain of the root URL by default
+	RelyingPartyID string
+	// Name shown by the browsers when creating a passkey
+	RelyingPartyName string
+	// Origins the browsers are allowed to use the passkeys from, the origin of the root URL by default
+	Origins []string
+	// User verification (PIN, biometrics) asked to the authenticators: required, preferred or discouraged
+	UserVerification string
+	// How long a registration or a login can take
+	ChallengeExpiration time.Duration
+	// How long after signing in the users can register a passkey, they must sign in again afterwards
+	ReauthenticationMaxAge time.Duration
+	// Reject the password logins of the Grafana users with a passkey, and of the users
+	// without passkey once their grace period is over
+	PasskeyOnly bool
+	// How long the users without passkey can sign in with their password to register one,
+	// from their first password login
+	PasskeyOnlyGracePeriod time.Duration
+}
+
+func (cfg *Cfg) readAuthPasskeySettings() {
+	section := cfg.SectionWithEnvOverrides("auth.passkey")
+	settings := AuthPasskeySettings{}
+	settings.Enabled = section.Key("enabled").MustBool(false)
+	settings.RelyingPartyName = section.Key("rp_name").MustString("Grafana")
+	settings.RelyingPartyID = section.Key("rp_id").MustString("")
+	settings.Origins = util.SplitString(section.Key("origins").MustString(""))
+	if appURL, err := url.Parse(cfg.AppURL); err == nil && appURL.Host != "" {
+		if settings.RelyingPartyID == "" {
+			settings.RelyingPartyID = appURL.Hostname()
+		}
+		if len(settings.Origins) == 0 {
+			settings.Origins = []string{appURL.Scheme + "://" + appURL.Host}
+		}
+	}
+	settings.UserVerification = section.Key("user_verification").In("preferred", []string{"required", "preferred", "discouraged"})
+	settings.ChallengeExpiration = section.Key("challenge_expiration").MustDuration(5 * time.Minute)
+	settings.ReauthenticationMaxAge = section.Key("reauthentication_max_age").MustDuration(10 * time.Minute)
+	settings.PasskeyOnly = section.Key("passkey_only").MustBool(false)
+	settings.PasskeyOnlyGracePeriod = section.Key("passkey_only_grace_period").MustDuration(7 * 24 * time.Hour)
+	cfg.PasskeyAuth = settings
+}
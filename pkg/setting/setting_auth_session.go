@@ -0,0 +1,68 @@
+package setting
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
+
+	"github.com/grafana/grafana/pkg/util"
+)
+
+// SessionPolicyRoleGrafanaAdmin is the key of the idle timeout applied to the Grafana server admins
+const SessionPolicyRoleGrafanaAdmin = "GrafanaAdmin"
+
+type AuthSessionPolicySettings struct {
+	// Idle timeout of the sessions per role (None, Viewer, Editor, Admin, GrafanaAdmin).
+	// The strictest timeout among the roles of the user in all their organizations applies.
+	IdleTimeoutByRole map[string]time.Duration
+	// Reject the sessions used from another network than the one they were created or last rotated in
+	BindClientIP bool
+	// Prefix length of the IPv4 networks the sessions are bound to
+	BindIPv4Prefix int
+	// Prefix length of the IPv6 networks the sessions are bound to
+	BindIPv6Prefix int
+}
+
+func (cfg *Cfg) readAuthSessionPolicySettings() error {
+	section := cfg.SectionWithEnvOverrides("auth.session")
+	settings := AuthSessionPolicySettings{
+		IdleTimeoutByRole: map[string]time.Duration{},
+	}
+
+	for _, entry := range util.SplitString(section.Key("idle_timeout_by_role").MustString("")) {
+		role, value, found := strings.Cut(entry, ":")
+		if !found {
+			return fmt.Errorf("invalid idle_timeout_by_role entry %q in [auth.session], expected <role>:<duration>", entry)
+		}
+		switch role {
+		case "None", "Viewer", "Editor", "Admin", SessionPolicyRoleGrafanaAdmin:
+		default:
+			return fmt.Errorf("invalid role %q in idle_timeout_by_role in [auth.session]", role)
+		}
+		timeout, err := gtime.ParseDuration(value)
+		if err != nil || timeout <= 0 {
+			return fmt.Errorf("invalid idle timeout %q for role %s in [auth.session]", value, role)
+		}
+		// The idle time is measured from the last rotation of the session, which active sessions only do once
+		// per rotation interval, a shorter timeout would revoke them
+		if rotation := time.Duration(cfg.TokenRotationIntervalMinutes) * time.Minute; timeout <= rotation {
+			return fmt.Errorf("idle timeout %s for role %s in [auth.session] must be longer than token_rotation_interval_minutes (%s)", value, role, rotation)
+		}
+		settings.IdleTimeoutByRole[role] = timeout
+	}
+
+	settings.BindClientIP = section.Key("bind_client_ip").MustBool(false)
+	settings.BindIPv4Prefix = section.Key("bind_ipv4_prefix").MustInt(32)
+	if settings.BindIPv4Prefix < 0 || settings.BindIPv4Prefix > 32 {
+		return fmt.Errorf("invalid bind_ipv4_prefix %d in [auth.session], expected a value between 0 and 32", settings.BindIPv4Prefix)
+	}
+	settings.BindIPv6Prefix = section.Key("bind_ipv6_prefix").MustInt(64)
+	if settings.BindIPv6Prefix < 0 || settings.BindIPv6Prefix > 128 {
+		return fmt.Errorf("invalid bind_ipv6_prefix %d in [auth.session], expected a value between 0 and 128", settings.BindIPv6Prefix)
+	}
+
+	cfg.SessionPolicy = settings
+	return nil
+}
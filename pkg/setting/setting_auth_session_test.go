@@ -0,0 +1,68 @@
+package setting
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/ini.v1"
+)
+
+func TestReadAuthSessionPolicySettings(t *testing.T) {
+	newCfg := func(t *testing.T, keys map[string]string) *Cfg {
+		t.Helper()
+		f := ini.Empty()
+		s, err := f.NewSection("auth.session")
+		require.NoError(t, err)
+		for k, v := range keys {
+			_, err := s.NewKey(k, v)
+			require.NoError(t, err)
+		}
+		cfg := NewCfg()
+		cfg.Raw = f
+		cfg.TokenRotationIntervalMinutes = 10
+		return cfg
+	}
+
+	t.Run("should use the defaults", func(t *testing.T) {
+		cfg := newCfg(t, nil)
+		require.NoError(t, cfg.readAuthSessionPolicySettings())
+
+		assert.Empty(t, cfg.SessionPolicy.IdleTimeoutByRole)
+		assert.False(t, cfg.SessionPolicy.BindClientIP)
+		assert.Equal(t, 32, cfg.SessionPolicy.BindIPv4Prefix)
+		assert.Equal(t, 64, cfg.SessionPolicy.BindIPv6Prefix)
+	})
+
+	t.Run("should read the idle timeouts per role", func(t *testing.T) {
+		cfg := newCfg(t, map[string]string{
+			"idle_timeout_by_role": "Admin:30m Editor:2h GrafanaAdmin:15m",
+			"bind_client_ip":       "true",
+			"bind_ipv4_prefix":     "24",
+		})
+		require.NoError(t, cfg.readAuthSessionPolicySettings())
+
+		assert.Equal(t, map[string]time.Duration{
+			"Admin":        30 * time.Minute,
+			"Editor":       2 * time.Hour,
+			"GrafanaAdmin": 15 * time.Minute,
+		}, cfg.SessionPolicy.IdleTimeoutByRole)
+		assert.True(t, cfg.SessionPolicy.BindClientIP)
+		assert.Equal(t, 24, cfg.SessionPolicy.BindIPv4Prefix)
+	})
+
+	t.Run("should fail on invalid settings", func(t *testing.T) {
+		for _, keys := range []map[string]string{
+			{"idle_timeout_by_role": "Admin"},
+			{"idle_timeout_by_role": "Owner:1h"},
+			{"idle_timeout_by_role": "Admin:soon"},
+			{"idle_timeout_by_role": "Admin:10m"},
+			{"bind_ipv4_prefix": "33"},
+			{"bind_ipv6_prefix": "-1"},
+		} {
+			cfg := newCfg(t, keys)
+			assert.Error(t, cfg.readAuthSessionPolicySettings(), keys)
+		}
+	})
+}
@@ -0,0 +1,39 @@
+package setting
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/grafana/grafana/pkg/util"
+)
+
+type AuthTOTPSettings struct {
+	// Two-factor authentication with time-based one-time passwords for the built-in login
+	Enabled bool
+	// Issuer shown by the authenticator apps
+	Issuer string
+	// The users of these organizations must use a second factor
+	EnforcedOrgIDs []int64
+	// The users with these roles in an organization, or GrafanaAdmin for the server admins, must use a second factor
+	EnforcedRoles []string
+	// How long a user has to enter the code after the password
+	ChallengeExpiration time.Duration
+}
+
+func (cfg *Cfg) readAuthTOTPSettings() {
+	section := cfg.SectionWithEnvOverrides("auth.totp")
+	settings := AuthTOTPSettings{}
+	settings.Enabled = section.Key("enabled").MustBool(false)
+	settings.Issuer = section.Key("issuer").MustString("Grafana")
+	for _, id := range util.SplitString(section.Key("enforce_org_ids").MustString("")) {
+		orgID, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			cfg.Logger.Warn("Ignoring invalid org id in [auth.totp] enforce_org_ids", "id", id)
+			continue
+		}
+		settings.EnforcedOrgIDs = append(settings.EnforcedOrgIDs, orgID)
+	}
+	settings.EnforcedRoles = util.SplitString(section.Key("enforce_roles").MustString(""))
+	settings.ChallengeExpiration = section.Key("challenge_expiration").MustDuration(5 * time.Minute)
+	cfg.TOTPAuth = settings
+}
@@ -31,6 +31,7 @@ type AuthJWTSettings struct {
 	AllowAssignGrafanaAdmin bool
 	SkipOrgRoleSync         bool
 	GroupsAttributePath     string
+	TeamMapping             []string
 	EmailAttributePath      string
 	UsernameAttributePath   string
 	TlsSkipVerify           bool
@@ -79,6 +80,7 @@ func (cfg *Cfg) readAuthJWTSettings() {
 	jwtSettings.TlsSkipVerify = authJWT.Key("tls_skip_verify_insecure").MustBool(false)
 	jwtSettings.OrgAttributePath = valueAsString(authJWT, "org_attribute_path", "")
 	jwtSettings.OrgMapping = util.SplitString(valueAsString(authJWT, "org_mapping", ""))
+	jwtSettings.TeamMapping = util.SplitString(valueAsString(authJWT, "team_mapping", ""))
 
 	cfg.JWTAuth = jwtSettings
 }
@@ -5,8 +5,14 @@ import (
 	"time"
 
 	"github.com/grafana/grafana/pkg/apiserver/rest"
+	"github.com/grafana/grafana/pkg/util"
 )
 
+// The resources watched by the change feed when change_feed_resources is not set
+const defaultChangeFeedResources = "folders.folder.grafana.app dashboards.dashboard.grafana.app librarypanels.dashboard.grafana.app " +
+	"playlists.playlist.grafana.app receivers.notifications.alerting.grafana.app routingtrees.notifications.alerting.grafana.app " +
+	"templategroups.notifications.alerting.grafana.app timeintervals.notifications.alerting.grafana.app"
+
 // read storage configs from ini file. They look like:
 // [unified_storage.<group>.<resource>]
 // <field> = <value>
@@ -41,6 +47,9 @@ func (cfg *Cfg) setUnifiedStorageConfig() {
 			DualWriterPeriodicDataSyncJobEnabled: dualWriterPeriodicDataSyncJobEnabled,
 			DataSyncerRecordsLimit:               dataSyncerRecordsLimit,
 			DataSyncerInterval:                   dataSyncerInterval,
+			QuotaMaxObjects:                      section.Key("quotaMaxObjects").MustInt64(0),
+			QuotaMaxBytes:                        section.Key("quotaMaxBytes").MustInt64(0),
+			MaxObjectSize:                        section.Key("maxObjectSize").MustInt64(0),
 		}
 	}
 	cfg.UnifiedStorage = storageConfig
@@ -52,6 +61,24 @@ func (cfg *Cfg) setUnifiedStorageConfig() {
 	cfg.IndexMaxBatchSize = section.Key("index_max_batch_size").MustInt(100)
 	cfg.IndexFileThreshold = section.Key("index_file_threshold").MustInt(10)
 	cfg.IndexMinCount = section.Key("index_min_count").MustInt(1)
+	cfg.IndexCompactionInterval = section.Key("index_compaction_interval").MustDuration(time.Hour)
+	cfg.IndexCompactionThreshold = section.Key("index_compaction_threshold").MustFloat64(0.3)
+	cfg.ChangeFeed = ChangeFeedSettings{
+		Enabled:         section.Key("change_feed_enabled").MustBool(false),
+		Resources:       util.SplitString(section.Key("change_feed_resources").MustString(defaultChangeFeedResources)),
+		MaxAttempts:     section.Key("change_feed_max_attempts").MustInt(5),
+		RetryBackoff:    section.Key("change_feed_retry_backoff").MustDuration(time.Second),
+		Timeout:         section.Key("change_feed_timeout").MustDuration(10 * time.Second),
+		RefreshInterval: section.Key("change_feed_refresh_interval").MustDuration(time.Minute),
+	}
+	cfg.UnifiedStorageQuotas = UnifiedStorageQuotaSettings{
+		NamespaceMaxObjects: section.Key("quota_namespace_max_objects").MustInt64(0),
+		NamespaceMaxBytes:   section.Key("quota_namespace_max_bytes").MustInt64(0),
+		MaxObjects:          section.Key("quota_max_objects").MustInt64(0),
+		MaxBytes:            section.Key("quota_max_bytes").MustInt64(0),
+		MaxObjectSize:       section.Key("max_object_size").MustInt64(0),
+		RefreshInterval:     section.Key("quota_refresh_interval").MustDuration(time.Minute),
+	}
 	cfg.SprinklesApiServer = section.Key("sprinkles_api_server").String()
 	cfg.SprinklesApiServerPageLimit = section.Key("sprinkles_api_server_page_limit").MustInt(100)
 	cfg.CACertPath = section.Key("ca_cert_path").String()
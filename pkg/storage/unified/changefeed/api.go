@@ -0,0 +1,279 @@
+package changefeed
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/middleware"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/util"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+type api struct {
+	service *Service
+}
+
+func registerAPI(routeRegister routing.RouteRegister, s *Service) {
+	a := &api{service: s}
+	routeRegister.Group("/api/admin/unified-storage/changefeed", func(r routing.RouteRegister) {
+		r.Get("/subscriptions", routing.Wrap(a.list))
+		r.Post("/subscriptions", routing.Wrap(a.create))
+		r.Get("/subscriptions/:uid", routing.Wrap(a.get))
+		r.Put("/subscriptions/:uid", routing.Wrap(a.update))
+		r.Delete("/subscriptions/:uid", routing.Wrap(a.delete))
+		r.Get("/subscriptions/:uid/dead-letters", routing.Wrap(a.listDeadLetters))
+		r.Post("/subscriptions/:uid/dead-letters/:id/redeliver", routing.Wrap(a.redeliver))
+		r.Delete("/subscriptions/:uid/dead-letters/:id", routing.Wrap(a.deleteDeadLetter))
+		r.Get("/stream", routing.Wrap(a.stream))
+	}, middleware.ReqGrafanaAdmin)
+}
+
+// SubscriptionCommand is the body of the create and update requests
+type SubscriptionCommand struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+	// Generated when creating a subscription without secret, kept when updating without secret
+	Secret string `json:"secret"`
+
+	Group     string `json:"group"`
+	Resource  string `json:"resource"`
+	Namespace string `json:"namespace"`
+	Folder    string `json:"folder"`
+
+	Disabled bool `json:"disabled"`
+}
+
+func (cmd *SubscriptionCommand) validate() error {
+	if cmd.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrBadRequest)
+	}
+	u, err := url.Parse(cmd.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: url must be an absolute http or https URL", ErrBadRequest)
+	}
+	return nil
+}
+
+// The secret is only returned when the subscription is created
+type createdSubscription struct {
+	*Subscription
+	Secret string `json:"secret"`
+}
+
+// GET /api/admin/unified-storage/changefeed/subscriptions
+func (a *api) list(c *contextmodel.ReqContext) response.Response {
+	subs, err := a.service.store.listSubscriptions(c.Req.Context())
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to list subscriptions", err)
+	}
+	return response.JSON(http.StatusOK, subs)
+}
+
+// POST /api/admin/unified-storage/changefeed/subscriptions
+func (a *api) create(c *contextmodel.ReqContext) response.Response {
+	cmd := SubscriptionCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	if err := cmd.validate(); err != nil {
+		return errorResponse(err)
+	}
+	if cmd.Secret == "" {
+		secret, err := generateSecret()
+		if err != nil {
+			return response.Error(http.StatusInternalServerError, "Failed to generate secret", err)
+		}
+		cmd.Secret = secret
+	}
+	now := time.Now()
+	sub := &Subscription{
+		UID:       util.GenerateShortUID(),
+		Name:      cmd.Name,
+		URL:       cmd.URL,
+		Secret:    cmd.Secret,
+		Group:     cmd.Group,
+		Resource:  cmd.Resource,
+		Namespace: cmd.Namespace,
+		Folder:    cmd.Folder,
+		Disabled:  cmd.Disabled,
+		Created:   now,
+		Updated:   now,
+	}
+	if err := a.service.store.createSubscription(c.Req.Context(), sub); err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to create subscription", err)
+	}
+	a.refresh(c.Req.Context())
+	return response.JSON(http.StatusOK, createdSubscription{Subscription: sub, Secret: sub.Secret})
+}
+
+// GET /api/admin/unified-storage/changefeed/subscriptions/:uid
+func (a *api) get(c *contextmodel.ReqContext) response.Response {
+	sub, err := a.service.store.getSubscription(c.Req.Context(), web.Params(c.Req)[":uid"])
+	if err != nil {
+		return errorResponse(err)
+	}
+	return response.JSON(http.StatusOK, sub)
+}
+
+// PUT /api/admin/unified-storage/changefeed/subscriptions/:uid
+func (a *api) update(c *contextmodel.ReqContext) response.Response {
+	cmd := SubscriptionCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	if err := cmd.validate(); err != nil {
+		return errorResponse(err)
+	}
+	sub, err := a.service.store.getSubscription(c.Req.Context(), web.Params(c.Req)[":uid"])
+	if err != nil {
+		return errorResponse(err)
+	}
+	sub.Name = cmd.Name
+	sub.URL = cmd.URL
+	if cmd.Secret != "" {
+		sub.Secret = cmd.Secret
+	}
+	sub.Group = cmd.Group
+	sub.Resource = cmd.Resource
+	sub.Namespace = cmd.Namespace
+	sub.Folder = cmd.Folder
+	sub.Disabled = cmd.Disabled
+	sub.Updated = time.Now()
+	if err := a.service.store.updateSubscription(c.Req.Context(), sub); err != nil {
+		return errorResponse(err)
+	}
+	a.refresh(c.Req.Context())
+	return response.JSON(http.StatusOK, sub)
+}
+
+// DELETE /api/admin/unified-storage/changefeed/subscriptions/:uid
+func (a *api) delete(c *contextmodel.ReqContext) response.Response {
+	if err := a.service.store.deleteSubscription(c.Req.Context(), web.Params(c.Req)[":uid"]); err != nil {
+		return errorResponse(err)
+	}
+	a.refresh(c.Req.Context())
+	return response.Success("Subscription deleted")
+}
+
+// GET /api/admin/unified-storage/changefeed/subscriptions/:uid/dead-letters?limit=
+func (a *api) listDeadLetters(c *contextmodel.ReqContext) response.Response {
+	letters, err := a.service.store.listDeadLetters(c.Req.Context(), web.Params(c.Req)[":uid"], c.QueryIntWithDefault("limit", 100))
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to list dead letters", err)
+	}
+	return response.JSON(http.StatusOK, letters)
+}
+
+// POST /api/admin/unified-storage/changefeed/subscriptions/:uid/dead-letters/:id/redeliver
+func (a *api) redeliver(c *contextmodel.ReqContext) response.Response {
+	id, err := strconv.ParseInt(web.Params(c.Req)[":id"], 10, 64)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "id is invalid", err)
+	}
+	err = a.service.redeliver(c.Req.Context(), web.Params(c.Req)[":uid"], id)
+	var derr *deliveryError
+	if errors.As(err, &derr) {
+		return response.Error(http.StatusBadGateway, "Failed to deliver event: "+derr.Error(), err)
+	}
+	if err != nil {
+		return errorResponse(err)
+	}
+	return response.Success("Event delivered")
+}
+
+// DELETE /api/admin/unified-storage/changefeed/subscriptions/:uid/dead-letters/:id
+func (a *api) deleteDeadLetter(c *contextmodel.ReqContext) response.Response {
+	id, err := strconv.ParseInt(web.Params(c.Req)[":id"], 10, 64)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "id is invalid", err)
+	}
+	if err := a.service.store.deleteDeadLetter(c.Req.Context(), web.Params(c.Req)[":uid"], id); err != nil {
+		return errorResponse(err)
+	}
+	return response.Success("Dead letter deleted")
+}
+
+// GET /api/admin/unified-storage/changefeed/stream?group=&resource=&namespace=&folder=
+func (a *api) stream(c *contextmodel.ReqContext) response.Response {
+	return &streamResponse{
+		service: a.service,
+		filter: Filter{
+			Group:     c.Query("group"),
+			Resource:  c.Query("resource"),
+			Namespace: c.Query("namespace"),
+			Folder:    c.Query("folder"),
+		},
+	}
+}
+
+// streamResponse writes the events as newline delimited JSON until the client goes away
+type streamResponse struct {
+	service *Service
+	filter  Filter
+}
+
+func (r *streamResponse) Status() int {
+	return http.StatusOK
+}
+
+func (r *streamResponse) Body() []byte {
+	return nil
+}
+
+func (r *streamResponse) WriteTo(c *contextmodel.ReqContext) {
+	st := r.service.subscribe(r.filter)
+	defer r.service.unsubscribe(st)
+
+	c.Resp.Header().Set("Content-Type", "application/x-ndjson")
+	c.Resp.Header().Set("Cache-Control", "no-cache")
+	c.Resp.WriteHeader(http.StatusOK)
+	c.Resp.Flush()
+	for {
+		select {
+		case <-c.Req.Context().Done():
+			return
+		case payload, ok := <-st.events:
+			if !ok {
+				return
+			}
+			if _, err := c.Resp.Write(append(payload, '\n')); err != nil {
+				return
+			}
+			c.Resp.Flush()
+		}
+	}
+}
+
+func (a *api) refresh(ctx context.Context) {
+	if err := a.service.refresh(ctx); err != nil {
+		a.service.log.Error("Failed to read the subscriptions", "error", err)
+	}
+}
+
+func errorResponse(err error) response.Response {
+	switch {
+	case errors.Is(err, ErrSubscriptionNotFound), errors.Is(err, ErrDeadLetterNotFound):
+		return response.Error(http.StatusNotFound, err.Error(), err)
+	case errors.Is(err, ErrBadRequest):
+		return response.Error(http.StatusBadRequest, err.Error(), err)
+	}
+	return response.Error(http.StatusInternalServerError, "Change feed request failed", err)
+}
+
+func generateSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,321 @@
+package changefeed
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/grafana/authlib/types"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/apimachinery/identity"
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/kvstore"
+	"github.com/grafana/grafana/pkg/infra/serverlock"
+	"github.com/grafana/grafana/pkg/infra/tracing"
+	"github.com/grafana/grafana/pkg/services/secrets/fakes"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/storage/unified/kv"
+	"github.com/grafana/grafana/pkg/storage/unified/resource"
+)
+
+func TestNewEvent(t *testing.T) {
+	previous := object(t, "a", "folder-1", "A")
+	value := object(t, "a", "folder-2", "B")
+
+	e, err := newEvent("dashboard.grafana.app", "dashboards", &resource.WatchEvent{
+		Type:     resource.WatchEvent_MODIFIED,
+		Resource: &resource.WatchEvent_Resource{Version: 2, Value: value},
+		Previous: &resource.WatchEvent_Resource{Version: 1, Value: previous},
+	})
+	require.NoError(t, err)
+	require.Equal(t, EventTypeUpdated, e.Type)
+	require.Equal(t, "dashboard.grafana.app/dashboards/default/a/2", e.ID)
+	require.Equal(t, "default", e.Namespace)
+	require.Equal(t, "a", e.Name)
+	require.Equal(t, "folder-2", e.Folder)
+	require.Equal(t, "folder-1", e.PreviousFolder)
+	require.Equal(t, int64(1), e.PreviousResourceVersion)
+
+	require.True(t, Filter{}.Matches(e))
+	require.True(t, Filter{Group: "dashboard.grafana.app", Namespace: "default", Folder: "folder-1"}.Matches(e))
+	require.True(t, Filter{Folder: "folder-2"}.Matches(e))
+	require.False(t, Filter{Folder: "folder-3"}.Matches(e))
+	require.False(t, Filter{Resource: "folders"}.Matches(e))
+	require.False(t, Filter{Namespace: "org-2"}.Matches(e))
+
+	e, err = newEvent("dashboard.grafana.app", "dashboards", &resource.WatchEvent{
+		Type:     resource.WatchEvent_DELETED,
+		Resource: &resource.WatchEvent_Resource{Version: 3},
+		Previous: &resource.WatchEvent_Resource{Version: 2, Value: value},
+	})
+	require.NoError(t, err)
+	require.Equal(t, EventTypeDeleted, e.Type)
+	require.Equal(t, "a", e.Name)
+	require.Equal(t, "folder-2", e.Folder)
+	require.Empty(t, e.Object)
+}
+
+func TestDeliver(t *testing.T) {
+	var calls atomic.Int32
+	statuses := []int{http.StatusInternalServerError, http.StatusTooManyRequests, http.StatusOK}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		timestamp, err := strconv.ParseInt(r.Header.Get(HeaderTimestamp), 10, 64)
+		require.NoError(t, err)
+		require.Equal(t, Sign("secret", timestamp, body), r.Header.Get(HeaderSignature))
+		require.Equal(t, "created", r.Header.Get(HeaderEvent))
+		require.Equal(t, "event-1", r.Header.Get(HeaderEventID))
+		w.WriteHeader(statuses[n-1])
+	}))
+	defer server.Close()
+
+	d := &deliverer{client: server.Client(), maxAttempts: 3, retryBackoff: time.Millisecond, now: time.Now}
+	sub := &Subscription{UID: "s", URL: server.URL, Secret: "secret"}
+
+	attempts, err := d.deliver(context.Background(), sub, EventTypeCreated, "event-1", []byte(`{}`))
+	require.NoError(t, err)
+	require.Equal(t, 3, attempts)
+
+	t.Run("client errors are not retried", func(t *testing.T) {
+		calls.Store(0)
+		statuses = []int{http.StatusBadRequest, http.StatusOK}
+		attempts, err := d.deliver(context.Background(), sub, EventTypeCreated, "event-1", []byte(`{}`))
+		require.Error(t, err)
+		require.Equal(t, 1, attempts)
+	})
+
+	t.Run("stops after the max attempts", func(t *testing.T) {
+		calls.Store(0)
+		statuses = []int{http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway}
+		attempts, err := d.deliver(context.Background(), sub, EventTypeCreated, "event-1", []byte(`{}`))
+		require.EqualError(t, err, "webhook responded with status 502")
+		require.Equal(t, 3, attempts)
+	})
+}
+
+func TestCheckpoint(t *testing.T) {
+	cp := &checkpoint{}
+	cp.resume(10)
+	first := cp.add(11, 2)
+	second := cp.add(12, 1)
+	require.Equal(t, int64(10), cp.get())
+
+	// The checkpoint does not move past an event still being sent
+	second()
+	require.Equal(t, int64(10), cp.get())
+	first()
+	require.Equal(t, int64(10), cp.get())
+	first()
+	require.Equal(t, int64(12), cp.get())
+
+	// The events without subscriptions are handled right away
+	cp.add(13, 0)
+	require.Equal(t, int64(13), cp.get())
+
+	// A saved checkpoint never moves it back
+	cp.resume(5)
+	require.Equal(t, int64(13), cp.get())
+}
+
+func TestIntegrationService(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	backend, err := kv.NewBackend(kv.BackendOptions{Path: filepath.Join(t.TempDir(), "resource.db")})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = backend.Stop(context.Background())
+	})
+	server, err := resource.NewResourceServer(resource.ResourceServerOptions{Backend: backend})
+	require.NoError(t, err)
+	client := resource.NewLocalResourceClient(server)
+
+	received := make(chan *http.Request, 10)
+	bodies := make(chan []byte, 10)
+	var fail atomic.Bool
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if fail.Load() {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		received <- r
+		bodies <- body
+	}))
+	defer webhook.Close()
+
+	sqlStore := db.InitTestDB(t)
+	newService := func() *Service {
+		s := NewService(setting.ChangeFeedSettings{
+			Enabled:         true,
+			Resources:       []string{"dashboards.dashboard.grafana.app"},
+			MaxAttempts:     2,
+			RetryBackoff:    time.Millisecond,
+			Timeout:         time.Second,
+			RefreshInterval: time.Hour,
+		}, sqlStore, fakes.NewFakeSecretsService(), serverlock.ProvideService(sqlStore, tracing.InitializeTracerForTest()), kvstore.ProvideService(sqlStore), client)
+		s.term = 2 * time.Second
+		return s
+	}
+	service := newService()
+	stop := run(t, service)
+
+	ctx := context.Background()
+
+	sub := &Subscription{UID: "sub", Name: "cmdb", URL: webhook.URL, Secret: "secret", Folder: "folder-1", Created: time.Now(), Updated: time.Now()}
+	require.NoError(t, service.store.createSubscription(ctx, sub))
+	require.Eventually(t, func() bool {
+		require.NoError(t, service.refresh(ctx))
+		service.mu.Lock()
+		defer service.mu.Unlock()
+		return len(service.workers) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	stream := service.subscribe(Filter{Resource: "dashboards"})
+	defer service.unsubscribe(stream)
+
+	userCtx := identity.WithRequester(ctx, &identity.StaticRequester{
+		Type:    types.TypeUser,
+		UserID:  1,
+		UserUID: "u1",
+		OrgRole: identity.RoleAdmin,
+	})
+	key := &resource.ResourceKey{Namespace: "default", Group: "dashboard.grafana.app", Resource: "dashboards"}
+
+	// Wait for the watches to start
+	var created *resource.CreateResponse
+	var event Event
+	require.Eventually(t, func() bool {
+		key.Name = "dash-" + strconv.FormatInt(time.Now().UnixNano(), 10)
+		created, err = client.Create(userCtx, &resource.CreateRequest{Key: key, Value: object(t, key.Name, "folder-1", "A")})
+		require.NoError(t, err)
+		require.Nil(t, created.Error)
+		select {
+		case r := <-received:
+			body := <-bodies
+			timestamp, err := strconv.ParseInt(r.Header.Get(HeaderTimestamp), 10, 64)
+			require.NoError(t, err)
+			require.Equal(t, Sign("secret", timestamp, body), r.Header.Get(HeaderSignature))
+			require.NoError(t, json.Unmarshal(body, &event))
+			return event.Name == key.Name
+		case <-time.After(100 * time.Millisecond):
+			return false
+		}
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Equal(t, EventTypeCreated, event.Type)
+	require.Equal(t, key.Name, event.Name)
+	require.Equal(t, "folder-1", event.Folder)
+	require.Equal(t, created.ResourceVersion, event.ResourceVersion)
+
+	t.Run("events that can not be delivered are moved to the dead letters", func(t *testing.T) {
+		fail.Store(true)
+		updated, err := client.Update(userCtx, &resource.UpdateRequest{Key: key, Value: object(t, key.Name, "folder-1", "B"), ResourceVersion: created.ResourceVersion})
+		require.NoError(t, err)
+		require.Nil(t, updated.Error)
+
+		var letters []*DeadLetter
+		require.Eventually(t, func() bool {
+			letters, err = service.store.listDeadLetters(ctx, "sub", 10)
+			require.NoError(t, err)
+			return len(letters) == 1
+		}, 5*time.Second, 10*time.Millisecond)
+		require.Equal(t, 1, letters[0].Attempts)
+		require.Contains(t, letters[0].EventID, strconv.FormatInt(updated.ResourceVersion, 10))
+
+		fail.Store(false)
+		require.NoError(t, service.redeliver(ctx, "sub", letters[0].ID))
+		require.NoError(t, json.Unmarshal(<-bodies, &event))
+		<-received
+		require.Equal(t, EventTypeUpdated, event.Type)
+		require.Equal(t, created.ResourceVersion, event.PreviousResourceVersion)
+
+		letters, err = service.store.listDeadLetters(ctx, "sub", 10)
+		require.NoError(t, err)
+		require.Empty(t, letters)
+	})
+
+	t.Run("events in other folders are not sent", func(t *testing.T) {
+		other := &resource.ResourceKey{Namespace: "default", Group: key.Group, Resource: key.Resource, Name: "other"}
+		rsp, err := client.Create(userCtx, &resource.CreateRequest{Key: other, Value: object(t, "other", "folder-2", "C")})
+		require.NoError(t, err)
+		require.Nil(t, rsp.Error)
+
+		// The stream gets the events of all the folders
+		for event.Name != "other" {
+			select {
+			case payload := <-stream.events:
+				require.NoError(t, json.Unmarshal(payload, &event))
+			case <-time.After(5 * time.Second):
+				t.Fatal("stream did not get the event")
+			}
+		}
+		select {
+		case <-received:
+			t.Fatal("webhook was called for another folder")
+		case <-time.After(100 * time.Millisecond):
+		}
+	})
+
+	t.Run("the changes made while stopped are sent once running again", func(t *testing.T) {
+		stop()
+		key.Name = "resumed"
+		rsp, err := client.Create(userCtx, &resource.CreateRequest{Key: key, Value: object(t, key.Name, "folder-1", "D")})
+		require.NoError(t, err)
+		require.Nil(t, rsp.Error)
+
+		run(t, newService())
+		select {
+		case <-received:
+			require.NoError(t, json.Unmarshal(<-bodies, &event))
+		case <-time.After(10 * time.Second):
+			t.Fatal("webhook was not called")
+		}
+		require.Equal(t, EventTypeCreated, event.Type)
+		require.Equal(t, "resumed", event.Name)
+		require.Equal(t, rsp.ResourceVersion, event.ResourceVersion)
+	})
+}
+
+// run runs the service until the returned function or the end of the test stops it
+func run(t *testing.T, service *Service) func() {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- service.Run(ctx)
+	}()
+	stop := sync.OnceFunc(func() {
+		cancel()
+		require.NoError(t, <-done)
+	})
+	t.Cleanup(stop)
+	return stop
+}
+
+func object(t *testing.T, name, folder, title string) []byte {
+	t.Helper()
+	value, err := json.Marshal(map[string]any{
+		"apiVersion": "dashboard.grafana.app/v1alpha1",
+		"kind":       "Dashboard",
+		"metadata": map[string]any{
+			"name":        name,
+			"namespace":   "default",
+			"uid":         name + "-uid",
+			"annotations": map[string]any{"grafana.app/folder": folder},
+		},
+		"spec": map[string]any{"title": title},
+	})
+	require.NoError(t, err)
+	return value
+}
@@ -0,0 +1,54 @@
+package changefeed
+
+import "sync"
+
+// checkpoint is the resource version the watch of a resource resumes from: the last event handled
+// by all its subscriptions, with all the events before it. The workers of the subscriptions send
+// the events in parallel, so an event can be handled before the events before it.
+type checkpoint struct {
+	mu      sync.Mutex
+	rv      int64
+	pending []*pendingEvent
+}
+
+// pendingEvent is an event waiting to be handled by some workers
+type pendingEvent struct {
+	rv        int64
+	remaining int
+}
+
+// add tracks an event queued for a number of workers, and returns the function each of them
+// calls once the event is delivered or moved to the dead letters
+func (c *checkpoint) add(rv int64, workers int) func() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e := &pendingEvent{rv: rv, remaining: workers}
+	c.pending = append(c.pending, e)
+	c.advance()
+	return func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		e.remaining--
+		c.advance()
+	}
+}
+
+// resume moves the checkpoint to a resource version saved by an earlier term
+func (c *checkpoint) resume(rv int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rv = max(c.rv, rv)
+}
+
+func (c *checkpoint) get() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.rv
+}
+
+func (c *checkpoint) advance() {
+	for len(c.pending) > 0 && c.pending[0].remaining <= 0 {
+		c.rv = max(c.rv, c.pending[0].rv)
+		c.pending = c.pending[1:]
+	}
+}
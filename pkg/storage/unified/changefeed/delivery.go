@@ -0,0 +1,108 @@
+package changefeed
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	HeaderEvent     = "X-Grafana-Event"
+	HeaderEventID   = "X-Grafana-Event-Id"
+	HeaderTimestamp = "X-Grafana-Timestamp"
+	HeaderSignature = "X-Grafana-Signature"
+)
+
+// Sign returns the signature of a payload sent at a unix time, as set in the X-Grafana-Signature header.
+// Receivers compute it with the secret of the subscription and compare it to the header.
+func Sign(secret string, timestamp int64, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// deliveryError is returned when a webhook did not accept an event
+type deliveryError struct {
+	status int
+	err    error
+}
+
+func (e *deliveryError) Error() string {
+	if e.err != nil {
+		return e.err.Error()
+	}
+	return fmt.Sprintf("webhook responded with status %d", e.status)
+}
+
+// permanent is true when sending the event again will not help
+func (e *deliveryError) permanent() bool {
+	return e.status >= 400 && e.status < 500 && e.status != http.StatusRequestTimeout && e.status != http.StatusTooManyRequests
+}
+
+type deliverer struct {
+	client       *http.Client
+	maxAttempts  int
+	retryBackoff time.Duration
+	now          func() time.Time
+}
+
+// send calls the webhook once
+func (d *deliverer) send(ctx context.Context, sub *Subscription, eventType EventType, eventID string, payload []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.URL, bytes.NewReader(payload))
+	if err != nil {
+		return &deliveryError{err: err}
+	}
+	timestamp := d.now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, string(eventType))
+	req.Header.Set(HeaderEventID, eventID)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	if sub.Secret != "" {
+		req.Header.Set(HeaderSignature, Sign(sub.Secret, timestamp, payload))
+	}
+
+	rsp, err := d.client.Do(req)
+	if err != nil {
+		return &deliveryError{err: err}
+	}
+	defer func() { _ = rsp.Body.Close() }()
+	_, _ = io.Copy(io.Discard, io.LimitReader(rsp.Body, 1<<16))
+	if rsp.StatusCode < 200 || rsp.StatusCode > 299 {
+		return &deliveryError{status: rsp.StatusCode}
+	}
+	return nil
+}
+
+// deliver calls the webhook until it accepts the event, or the attempts are exhausted.
+// It returns the number of attempts and the last error.
+func (d *deliverer) deliver(ctx context.Context, sub *Subscription, eventType EventType, eventID string, payload []byte) (int, error) {
+	backoff := d.retryBackoff
+	attempt := 0
+	for {
+		attempt++
+		err := d.send(ctx, sub, eventType, eventID, payload)
+		if err == nil {
+			return attempt, nil
+		}
+		var derr *deliveryError
+		if attempt >= d.maxAttempts || (errors.As(err, &derr) && derr.permanent()) {
+			return attempt, err
+		}
+		select {
+		case <-ctx.Done():
+			return attempt, ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
@@ -0,0 +1,187 @@
+package changefeed
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/grafana/grafana/pkg/apimachinery/utils"
+	"github.com/grafana/grafana/pkg/storage/unified/resource"
+)
+
+var (
+	ErrSubscriptionNotFound = errors.New("subscription not found")
+	ErrDeadLetterNotFound   = errors.New("dead letter not found")
+	ErrBadRequest           = errors.New("bad request")
+)
+
+// Subscription sends the changes matching its filter to a webhook.
+// Empty filter fields match everything.
+type Subscription struct {
+	ID   int64  `xorm:"pk autoincr 'id'" json:"-"`
+	UID  string `xorm:"uid" json:"uid"`
+	Name string `xorm:"name" json:"name"`
+	URL  string `xorm:"url" json:"url"`
+	// The key used to sign the events, encrypted in the database
+	Secret string `xorm:"secret" json:"-"`
+
+	Group     string `xorm:"resource_group" json:"group,omitempty"`
+	Resource  string `xorm:"resource" json:"resource,omitempty"`
+	Namespace string `xorm:"namespace" json:"namespace,omitempty"`
+	Folder    string `xorm:"folder" json:"folder,omitempty"`
+
+	Disabled bool      `xorm:"disabled" json:"disabled"`
+	Created  time.Time `xorm:"created" json:"created"`
+	Updated  time.Time `xorm:"updated" json:"updated"`
+}
+
+func (s *Subscription) TableName() string {
+	return "changefeed_subscription"
+}
+
+func (s *Subscription) Filter() Filter {
+	return Filter{Group: s.Group, Resource: s.Resource, Namespace: s.Namespace, Folder: s.Folder}
+}
+
+// DeadLetter is an event that could not be delivered to a subscription
+type DeadLetter struct {
+	ID              int64     `xorm:"pk autoincr 'id'" json:"id"`
+	SubscriptionUID string    `xorm:"subscription_uid" json:"subscriptionUid"`
+	EventID         string    `xorm:"event_id" json:"eventId"`
+	Payload         string    `xorm:"payload" json:"payload"`
+	Attempts        int       `xorm:"attempts" json:"attempts"`
+	LastError       string    `xorm:"last_error" json:"lastError"`
+	Created         time.Time `xorm:"created" json:"created"`
+}
+
+func (d *DeadLetter) TableName() string {
+	return "changefeed_dead_letter"
+}
+
+// Filter selects the events of a subscription or a stream
+type Filter struct {
+	Group     string `json:"group,omitempty"`
+	Resource  string `json:"resource,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+	Folder    string `json:"folder,omitempty"`
+}
+
+// Matches checks the event against the filter. The folder is matched against
+// the folder of the new version of the resource, and of the previous one.
+func (f Filter) Matches(e *Event) bool {
+	if f.Group != "" && f.Group != e.Group {
+		return false
+	}
+	if f.Resource != "" && f.Resource != e.Resource {
+		return false
+	}
+	if f.Namespace != "" && f.Namespace != e.Namespace {
+		return false
+	}
+	if f.Folder != "" && f.Folder != e.Folder && f.Folder != e.PreviousFolder {
+		return false
+	}
+	return true
+}
+
+type EventType string
+
+const (
+	EventTypeCreated EventType = "created"
+	EventTypeUpdated EventType = "updated"
+	EventTypeDeleted EventType = "deleted"
+)
+
+// Event is a change of a resource, as sent to the webhooks
+type Event struct {
+	// Unique for each change, so receivers can ignore the events they already got
+	ID   string    `json:"id"`
+	Type EventType `json:"type"`
+	Time time.Time `json:"time"`
+
+	Group     string `json:"group"`
+	Resource  string `json:"resource"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+
+	Folder         string `json:"folder,omitempty"`
+	PreviousFolder string `json:"previousFolder,omitempty"`
+
+	ResourceVersion         int64 `json:"resourceVersion"`
+	PreviousResourceVersion int64 `json:"previousResourceVersion,omitempty"`
+
+	// The new version of the resource, not set for deletes
+	Object json.RawMessage `json:"object,omitempty"`
+	// The previous version of the resource, not set for creates
+	Previous json.RawMessage `json:"previous,omitempty"`
+}
+
+// newEvent reads the event of a watch stream of a resource
+func newEvent(group, resourceName string, w *resource.WatchEvent) (*Event, error) {
+	e := &Event{
+		Group:    group,
+		Resource: resourceName,
+		Time:     time.Now().UTC(),
+	}
+	// Not all the backends set the time of the event
+	if w.Timestamp > 0 {
+		e.Time = time.UnixMilli(w.Timestamp).UTC()
+	}
+	switch w.Type {
+	case resource.WatchEvent_ADDED:
+		e.Type = EventTypeCreated
+	case resource.WatchEvent_MODIFIED:
+		e.Type = EventTypeUpdated
+	case resource.WatchEvent_DELETED:
+		e.Type = EventTypeDeleted
+	default:
+		return nil, fmt.Errorf("unexpected watch event type: %s", w.Type)
+	}
+	if w.Resource != nil {
+		e.ResourceVersion = w.Resource.Version
+		if len(w.Resource.Value) > 0 {
+			e.Object = w.Resource.Value
+		}
+	}
+	if w.Previous != nil {
+		e.PreviousResourceVersion = w.Previous.Version
+		if len(w.Previous.Value) > 0 {
+			e.Previous = w.Previous.Value
+		}
+	}
+
+	if len(e.Previous) > 0 {
+		meta, err := readMeta(e.Previous)
+		if err != nil {
+			return nil, err
+		}
+		e.Namespace, e.Name, e.PreviousFolder = meta.GetNamespace(), meta.GetName(), meta.GetFolder()
+	}
+	if len(e.Object) > 0 {
+		meta, err := readMeta(e.Object)
+		if err != nil {
+			return nil, err
+		}
+		e.Namespace, e.Name, e.Folder = meta.GetNamespace(), meta.GetName(), meta.GetFolder()
+	}
+	if e.Name == "" {
+		return nil, fmt.Errorf("missing object in %s event of %s.%s", e.Type, resourceName, group)
+	}
+	// Deleted resources are in the folder they were deleted from
+	if e.Type == EventTypeDeleted {
+		e.Folder = e.PreviousFolder
+	}
+	e.ID = fmt.Sprintf("%s/%s/%s/%s/%d", e.Group, e.Resource, e.Namespace, e.Name, e.ResourceVersion)
+	return e, nil
+}
+
+func readMeta(value []byte) (utils.GrafanaMetaAccessor, error) {
+	obj := &unstructured.Unstructured{}
+	if err := obj.UnmarshalJSON(value); err != nil {
+		return nil, err
+	}
+	return utils.MetaAccessor(obj)
+}
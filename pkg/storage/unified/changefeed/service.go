@@ -0,0 +1,525 @@
+package changefeed
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/apimachinery/identity"
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/kvstore"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/serverlock"
+	"github.com/grafana/grafana/pkg/services/secrets"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/storage/unified/resource"
+)
+
+const (
+	// The events waiting to be sent to a webhook. When full, new events go to the dead letters.
+	queueSize = 1000
+	// The events waiting to be written to a stream. When full, the stream is closed.
+	streamBufferSize = 100
+	// The delay before watching a resource again after the watch failed
+	rewatchDelay = 5 * time.Second
+	// The instance holding the lock sends the events to the webhooks for a term. It stops a bit
+	// before the end of the term, so another instance can take the lock once the term is over.
+	leaderLock = "unified-storage-changefeed"
+	leaderTerm = time.Minute
+)
+
+// Service watches the resources of unified storage, and sends their changes to the
+// subscribed webhooks and to the open streams
+type Service struct {
+	settings  setting.ChangeFeedSettings
+	resources []schema.GroupResource
+	log       log.Logger
+	client    resource.ResourceClient
+	store     *store
+	deliverer *deliverer
+
+	serverLock  *serverlock.ServerLockService
+	kv          *kvstore.NamespacedKVStore
+	term        time.Duration
+	checkpoints map[schema.GroupResource]*checkpoint
+
+	mu      sync.Mutex
+	workers map[string]*worker
+	streams map[*stream]struct{}
+	// set when the service runs
+	ctx context.Context
+}
+
+func ProvideService(cfg *setting.Cfg, routeRegister routing.RouteRegister, sqlStore db.DB, secretsService secrets.Service, serverLock *serverlock.ServerLockService, kv kvstore.KVStore, client resource.ResourceClient) *Service {
+	s := NewService(cfg.ChangeFeed, sqlStore, secretsService, serverLock, kv, client)
+	if s.settings.Enabled {
+		registerAPI(routeRegister, s)
+	}
+	return s
+}
+
+func NewService(settings setting.ChangeFeedSettings, sqlStore db.DB, secretsService secrets.Service, serverLock *serverlock.ServerLockService, kv kvstore.KVStore, client resource.ResourceClient) *Service {
+	s := &Service{
+		settings: settings,
+		log:      log.New("unified-storage.changefeed"),
+		client:   client,
+		store:    &store{sqlStore: sqlStore, secretsService: secretsService},
+		deliverer: &deliverer{
+			client:       &http.Client{Timeout: settings.Timeout},
+			maxAttempts:  max(settings.MaxAttempts, 1),
+			retryBackoff: settings.RetryBackoff,
+			now:          time.Now,
+		},
+		serverLock:  serverLock,
+		kv:          kvstore.WithNamespace(kv, 0, "changefeed"),
+		term:        leaderTerm,
+		checkpoints: map[schema.GroupResource]*checkpoint{},
+		workers:     map[string]*worker{},
+		streams:     map[*stream]struct{}{},
+	}
+	for _, r := range settings.Resources {
+		gr := schema.ParseGroupResource(r)
+		s.resources = append(s.resources, gr)
+		s.checkpoints[gr] = &checkpoint{}
+	}
+	return s
+}
+
+func (s *Service) IsDisabled() bool {
+	return !s.settings.Enabled
+}
+
+// Run sends the changes to the streams of this instance, and to the webhooks from the instance
+// holding the lock
+func (s *Service) Run(ctx context.Context) error {
+	s.mu.Lock()
+	s.ctx = ctx
+	s.mu.Unlock()
+
+	if err := s.refresh(ctx); err != nil {
+		s.log.Error("Failed to read the subscriptions", "error", err)
+	}
+
+	var wg sync.WaitGroup
+	for _, gr := range s.resources {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// The streams get the changes made after they were opened
+			s.watch(ctx, gr, 0, s.publishToStreams)
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		s.lead(ctx)
+	}()
+
+	ticker := time.NewTicker(max(s.settings.RefreshInterval, time.Second))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			s.stopWorkers()
+			return nil
+		case <-ticker.C:
+			if err := s.refresh(ctx); err != nil {
+				s.log.Error("Failed to read the subscriptions", "error", err)
+			}
+		}
+	}
+}
+
+// lead sends the changes to the webhooks while this instance holds the lock, and takes the lock
+// again once the term of the instance holding it is over
+func (s *Service) lead(ctx context.Context) {
+	for {
+		err := s.serverLock.LockAndExecute(ctx, leaderLock, s.term, s.sendToWebhooks)
+		if err != nil && ctx.Err() == nil {
+			s.log.Error("Failed to take the change feed lock", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(s.term / 12):
+		}
+	}
+}
+
+// sendToWebhooks sends the changes to the webhooks for a term, from the checkpoints saved by the
+// previous term. The events are sent at least once: the events delivered after the last saved
+// checkpoint are sent again by the next term.
+func (s *Service) sendToWebhooks(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, s.term-s.term/6)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for _, gr := range s.resources {
+		cp := s.checkpoints[gr]
+		if err := s.resume(ctx, gr, cp); err != nil {
+			s.log.Error("Failed to read the change feed checkpoint", "group", gr.Group, "resource", gr.Resource, "error", err)
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.watch(ctx, gr, cp.get(), func(e *Event) {
+				s.publishToWebhooks(e, cp)
+			})
+		}()
+	}
+
+	ticker := time.NewTicker(s.term / 6)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			s.saveCheckpoints(context.WithoutCancel(ctx))
+			return
+		case <-ticker.C:
+			s.saveCheckpoints(ctx)
+		}
+	}
+}
+
+// resume moves the checkpoint to the one saved by the previous term. The first term starts from
+// the current resource version, or from the start of its watch when the resource was never written.
+func (s *Service) resume(ctx context.Context, gr schema.GroupResource, cp *checkpoint) error {
+	value, ok, err := s.kv.Get(ctx, gr.String())
+	if err != nil {
+		return err
+	}
+	if ok {
+		rv, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		cp.resume(rv)
+	}
+	if cp.get() > 0 {
+		return nil
+	}
+	rsp, err := s.client.List(identity.WithServiceIdentityContext(ctx, 0), &resource.ListRequest{
+		Limit: 1,
+		Options: &resource.ListOptions{
+			Key: &resource.ResourceKey{Group: gr.Group, Resource: gr.Resource},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if rsp.Error == nil {
+		cp.resume(rsp.ResourceVersion)
+	}
+	return nil
+}
+
+func (s *Service) saveCheckpoints(ctx context.Context) {
+	for gr, cp := range s.checkpoints {
+		rv := cp.get()
+		if rv == 0 {
+			continue
+		}
+		if err := s.kv.Set(ctx, gr.String(), strconv.FormatInt(rv, 10)); err != nil {
+			s.log.Error("Failed to save the change feed checkpoint", "group", gr.Group, "resource", gr.Resource, "error", err)
+		}
+	}
+}
+
+// watch publishes the changes of a resource made after a resource version until the context is done.
+// The watch is opened again when it fails, from the last seen resource version.
+func (s *Service) watch(ctx context.Context, gr schema.GroupResource, since int64, publish func(*Event)) {
+	ctx = identity.WithServiceIdentityContext(ctx, 0)
+	logger := s.log.New("group", gr.Group, "resource", gr.Resource)
+	for {
+		err := s.watchOnce(ctx, gr, &since, publish)
+		if ctx.Err() != nil {
+			return
+		}
+		logger.Warn("Watch stopped, watching again", "error", err, "since", since)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(rewatchDelay):
+		}
+	}
+}
+
+func (s *Service) watchOnce(ctx context.Context, gr schema.GroupResource, since *int64, publish func(*Event)) error {
+	w, err := s.client.Watch(ctx, &resource.WatchRequest{
+		Since: *since,
+		Options: &resource.ListOptions{
+			Key: &resource.ResourceKey{Group: gr.Group, Resource: gr.Resource},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	for {
+		msg, err := w.Recv()
+		if err != nil {
+			return err
+		}
+		if msg.Type == resource.WatchEvent_BOOKMARK || msg.Type == resource.WatchEvent_ERROR {
+			continue
+		}
+		event, err := newEvent(gr.Group, gr.Resource, msg)
+		if err != nil {
+			s.log.Warn("Skipping unreadable event", "group", gr.Group, "resource", gr.Resource, "error", err)
+			continue
+		}
+		*since = event.ResourceVersion
+		publish(event)
+	}
+}
+
+// publishToWebhooks queues the event for the matching subscriptions. The checkpoint moves past
+// the event once they all handled it.
+func (s *Service) publishToWebhooks(e *Event, cp *checkpoint) {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		s.log.Error("Failed to encode event", "id", e.ID, "error", err)
+		cp.add(e.ResourceVersion, 0)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var matching []*worker
+	for _, w := range s.workers {
+		if w.subscription().Filter().Matches(e) {
+			matching = append(matching, w)
+		}
+	}
+	done := cp.add(e.ResourceVersion, len(matching))
+	for _, w := range matching {
+		w.enqueue(queuedEvent{event: e, payload: payload, done: done})
+	}
+}
+
+// publishToStreams writes the event to the matching streams
+func (s *Service) publishToStreams(e *Event) {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		s.log.Error("Failed to encode event", "id", e.ID, "error", err)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for st := range s.streams {
+		if !st.filter.Matches(e) {
+			continue
+		}
+		select {
+		case st.events <- payload:
+		default:
+			// The client is too slow, it has to open the stream again
+			delete(s.streams, st)
+			close(st.events)
+		}
+	}
+}
+
+// refresh starts a worker for each enabled subscription, and stops the other workers
+func (s *Service) refresh(ctx context.Context) error {
+	subs, err := s.store.listSubscriptions(ctx)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ctx == nil {
+		return nil
+	}
+	enabled := make(map[string]bool, len(subs))
+	for _, sub := range subs {
+		if sub.Disabled {
+			continue
+		}
+		enabled[sub.UID] = true
+		if w, ok := s.workers[sub.UID]; ok {
+			w.sub.Store(sub)
+			continue
+		}
+		s.workers[sub.UID] = s.startWorker(sub)
+	}
+	for uid, w := range s.workers {
+		if !enabled[uid] {
+			w.remove()
+			delete(s.workers, uid)
+		}
+	}
+	return nil
+}
+
+func (s *Service) stopWorkers() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for uid, w := range s.workers {
+		w.cancel()
+		delete(s.workers, uid)
+	}
+	for st := range s.streams {
+		delete(s.streams, st)
+		close(st.events)
+	}
+}
+
+// subscribe opens a stream of the events matching the filter
+func (s *Service) subscribe(filter Filter) *stream {
+	st := &stream{filter: filter, events: make(chan []byte, streamBufferSize)}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.streams[st] = struct{}{}
+	return st
+}
+
+func (s *Service) unsubscribe(st *stream) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.streams[st]; ok {
+		delete(s.streams, st)
+		close(st.events)
+	}
+}
+
+// redeliver sends a dead letter to its subscription again, and removes it once delivered
+func (s *Service) redeliver(ctx context.Context, subscriptionUID string, id int64) error {
+	sub, err := s.store.getSubscription(ctx, subscriptionUID)
+	if err != nil {
+		return err
+	}
+	letter, err := s.store.getDeadLetter(ctx, subscriptionUID, id)
+	if err != nil {
+		return err
+	}
+	event := &Event{}
+	if err := json.Unmarshal([]byte(letter.Payload), event); err != nil {
+		return err
+	}
+	if err := s.deliverer.send(ctx, sub, event.Type, letter.EventID, []byte(letter.Payload)); err != nil {
+		return err
+	}
+	return s.store.deleteDeadLetter(ctx, subscriptionUID, id)
+}
+
+type stream struct {
+	filter Filter
+	events chan []byte
+}
+
+type queuedEvent struct {
+	event   *Event
+	payload []byte
+	// called once the event is delivered or moved to the dead letters
+	done func()
+}
+
+// worker sends the events of a subscription in order
+type worker struct {
+	sub    atomic.Pointer[Subscription]
+	queue  chan queuedEvent
+	cancel context.CancelFunc
+	// called when the queue is full
+	overflow func(queuedEvent)
+	// set when the subscription is deleted or disabled, its events no longer hold the checkpoints
+	removed atomic.Bool
+}
+
+func (w *worker) subscription() *Subscription {
+	return w.sub.Load()
+}
+
+func (w *worker) enqueue(q queuedEvent) {
+	select {
+	case w.queue <- q:
+	default:
+		w.overflow(q)
+	}
+}
+
+func (w *worker) remove() {
+	w.removed.Store(true)
+	w.cancel()
+}
+
+// release marks the queued events as handled
+func (w *worker) release() {
+	for {
+		select {
+		case q := <-w.queue:
+			q.done()
+		default:
+			return
+		}
+	}
+}
+
+func (s *Service) startWorker(sub *Subscription) *worker {
+	ctx, cancel := context.WithCancel(s.ctx)
+	w := &worker{
+		queue:  make(chan queuedEvent, queueSize),
+		cancel: cancel,
+	}
+	w.sub.Store(sub)
+	w.overflow = func(q queuedEvent) {
+		// Do not block the publisher on the database
+		go func() {
+			s.deadLetter(context.WithoutCancel(ctx), w.subscription(), q, 0, errors.New("too many events waiting to be sent"))
+			q.done()
+		}()
+	}
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				if w.removed.Load() {
+					w.release()
+				}
+				return
+			case q := <-w.queue:
+				sub := w.subscription()
+				attempts, err := s.deliverer.deliver(ctx, sub, q.event.Type, q.event.ID, q.payload)
+				switch {
+				case err == nil:
+				case ctx.Err() != nil:
+					// Stopped by the shutdown, the event is sent again by the next term
+					if !w.removed.Load() {
+						continue
+					}
+				default:
+					s.deadLetter(ctx, sub, q, attempts, err)
+				}
+				q.done()
+			}
+		}
+	}()
+	return w
+}
+
+func (s *Service) deadLetter(ctx context.Context, sub *Subscription, q queuedEvent, attempts int, cause error) {
+	s.log.Warn("Failed to deliver event", "subscription", sub.UID, "event", q.event.ID, "attempts", attempts, "error", cause)
+	err := s.store.addDeadLetter(ctx, &DeadLetter{
+		SubscriptionUID: sub.UID,
+		EventID:         q.event.ID,
+		Payload:         string(q.payload),
+		Attempts:        attempts,
+		LastError:       cause.Error(),
+		Created:         time.Now(),
+	})
+	if err != nil {
+		s.log.Error("Failed to save dead letter", "subscription", sub.UID, "event", q.event.ID, "error", err)
+	}
+}
@@ -0,0 +1,174 @@
+package changefeed
+
+import (
+	"context"
+	"encoding/base64"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/services/secrets"
+)
+
+// store saves the subscriptions and the dead letters in the Grafana database
+type store struct {
+	sqlStore       db.DB
+	secretsService secrets.Service
+}
+
+func (s *store) listSubscriptions(ctx context.Context) ([]*Subscription, error) {
+	result := make([]*Subscription, 0)
+	err := s.sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+		return sess.Asc("id").Find(&result)
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, sub := range result {
+		if sub.Secret, err = s.decodeAndDecrypt(ctx, sub.Secret); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+func (s *store) getSubscription(ctx context.Context, uid string) (*Subscription, error) {
+	sub := &Subscription{}
+	err := s.sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+		found, err := sess.Where("uid = ?", uid).Get(sub)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return ErrSubscriptionNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if sub.Secret, err = s.decodeAndDecrypt(ctx, sub.Secret); err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+func (s *store) createSubscription(ctx context.Context, sub *Subscription) error {
+	clone := *sub
+	var err error
+	if clone.Secret, err = s.encryptAndEncode(ctx, sub.Secret); err != nil {
+		return err
+	}
+	err = s.sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+		_, err := sess.Insert(&clone)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	sub.ID = clone.ID
+	return nil
+}
+
+func (s *store) updateSubscription(ctx context.Context, sub *Subscription) error {
+	clone := *sub
+	var err error
+	if clone.Secret, err = s.encryptAndEncode(ctx, sub.Secret); err != nil {
+		return err
+	}
+	return s.sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+		affected, err := sess.Where("uid = ?", sub.UID).
+			Cols("name", "url", "secret", "resource_group", "resource", "namespace", "folder", "disabled", "updated").
+			Update(&clone)
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return ErrSubscriptionNotFound
+		}
+		return nil
+	})
+}
+
+func (s *store) deleteSubscription(ctx context.Context, uid string) error {
+	return s.sqlStore.InTransaction(ctx, func(ctx context.Context) error {
+		return s.sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+			affected, err := sess.Where("uid = ?", uid).Delete(&Subscription{})
+			if err != nil {
+				return err
+			}
+			if affected == 0 {
+				return ErrSubscriptionNotFound
+			}
+			_, err = sess.Where("subscription_uid = ?", uid).Delete(&DeadLetter{})
+			return err
+		})
+	})
+}
+
+func (s *store) addDeadLetter(ctx context.Context, letter *DeadLetter) error {
+	return s.sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+		_, err := sess.Insert(letter)
+		return err
+	})
+}
+
+func (s *store) listDeadLetters(ctx context.Context, subscriptionUID string, limit int) ([]*DeadLetter, error) {
+	result := make([]*DeadLetter, 0)
+	err := s.sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+		return sess.Where("subscription_uid = ?", subscriptionUID).Desc("id").Limit(limit).Find(&result)
+	})
+	return result, err
+}
+
+func (s *store) getDeadLetter(ctx context.Context, subscriptionUID string, id int64) (*DeadLetter, error) {
+	letter := &DeadLetter{}
+	err := s.sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+		found, err := sess.Where("id = ? AND subscription_uid = ?", id, subscriptionUID).Get(letter)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return ErrDeadLetterNotFound
+		}
+		return nil
+	})
+	return letter, err
+}
+
+func (s *store) deleteDeadLetter(ctx context.Context, subscriptionUID string, id int64) error {
+	return s.sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+		affected, err := sess.Where("id = ? AND subscription_uid = ?", id, subscriptionUID).Delete(&DeadLetter{})
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return ErrDeadLetterNotFound
+		}
+		return nil
+	})
+}
+
+func (s *store) encryptAndEncode(ctx context.Context, str string) (string, error) {
+	if str == "" {
+		return "", nil
+	}
+	encrypted, err := s.secretsService.Encrypt(ctx, []byte(str), secrets.WithoutScope())
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(encrypted), nil
+}
+
+func (s *store) decodeAndDecrypt(ctx context.Context, str string) (string, error) {
+	if str == "" {
+		return "", nil
+	}
+	decoded, err := base64.StdEncoding.DecodeString(str)
+	if err != nil {
+		return "", err
+	}
+	decrypted, err := s.secretsService.Decrypt(ctx, decoded)
+	if err != nil {
+		return "", err
+	}
+	return string(decrypted), nil
+}
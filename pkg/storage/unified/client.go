@@ -3,6 +3,7 @@ package unified
 import (
 	"context"
 	"fmt"
+	"os"
 	"path/filepath"
 	"time"
 
@@ -28,6 +29,7 @@ import (
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/storage/legacysql"
 	"github.com/grafana/grafana/pkg/storage/unified/federated"
+	"github.com/grafana/grafana/pkg/storage/unified/kv"
 	"github.com/grafana/grafana/pkg/storage/unified/resource"
 	"github.com/grafana/grafana/pkg/storage/unified/search"
 	"github.com/grafana/grafana/pkg/storage/unified/sql"
@@ -113,6 +115,23 @@ func newClient(opts options.StorageOptions,
 		}
 		return resource.NewLocalResourceClient(server), nil
 
+	case options.StorageTypeUnifiedKV:
+		if opts.DataPath == "" {
+			opts.DataPath = filepath.Join(cfg.DataPath, "grafana-apiserver")
+		}
+		if err := os.MkdirAll(opts.DataPath, 0750); err != nil {
+			return nil, err
+		}
+		searchOptions, err := search.NewSearchOptions(features, cfg, tracer, docs, indexMetrics)
+		if err != nil {
+			return nil, err
+		}
+		server, err := kv.NewResourceServer(filepath.Join(opts.DataPath, "resource.db"), cfg, tracer, reg, authzc, searchOptions, indexMetrics)
+		if err != nil {
+			return nil, err
+		}
+		return resource.NewLocalResourceClient(server), nil
+
 	case options.StorageTypeUnifiedGrpc:
 		if opts.Address == "" {
 			return nil, fmt.Errorf("expecting address for storage_type: %s", opts.StorageType)
@@ -43,3 +43,12 @@ func (s *federatedClient) GetStats(ctx context.Context, in *resource.ResourceSta
 
 	return rsp, err
 }
+
+// IndexStatus implements resource.IndexStatusProvider
+func (s *federatedClient) IndexStatus(ctx context.Context) ([]resource.IndexStatus, error) {
+	provider, ok := s.ResourceClient.(resource.IndexStatusProvider)
+	if !ok {
+		return nil, resource.ErrIndexStatusNotSupported
+	}
+	return provider.IndexStatus(ctx)
+}
@@ -0,0 +1,52 @@
+package indexstatus
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/middleware"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/storage/unified/resource"
+)
+
+// API exposes the status of the search indexes to the server admins
+type API struct {
+	client resource.ResourceClient
+}
+
+func ProvideAPI(routeRegister routing.RouteRegister, client resource.ResourceClient) *API {
+	api := &API{
+		client: client,
+	}
+	routeRegister.Get("/api/admin/unified-storage/search/indexes", middleware.ReqGrafanaAdmin, routing.Wrap(api.list))
+	return api
+}
+
+// GET /api/admin/unified-storage/search/indexes?namespace=
+func (a *API) list(c *contextmodel.ReqContext) response.Response {
+	provider, ok := a.client.(resource.IndexStatusProvider)
+	if !ok {
+		return response.Error(http.StatusNotImplemented, resource.ErrIndexStatusNotSupported.Error(), nil)
+	}
+	status, err := provider.IndexStatus(c.Req.Context())
+	if errors.Is(err, resource.ErrIndexStatusNotSupported) {
+		return response.Error(http.StatusNotImplemented, err.Error(), err)
+	}
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to read the index status", err)
+	}
+
+	namespace := c.Query("namespace")
+	if namespace == "" {
+		return response.JSON(http.StatusOK, status)
+	}
+	filtered := make([]resource.IndexStatus, 0, len(status))
+	for _, s := range status {
+		if s.Namespace == namespace {
+			filtered = append(filtered, s)
+		}
+	}
+	return response.JSON(http.StatusOK, filtered)
+}
@@ -0,0 +1,423 @@
+package kv
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/grafana/grafana-app-sdk/logging"
+	"github.com/grafana/grafana/pkg/storage/unified/resource"
+)
+
+const tracePrefix = "kv.resource."
+const defaultWatchBufferSize = 100 // number of events to buffer in the watch stream
+
+// Backend is a resource.StorageBackend that keeps everything in an embedded key-value
+// store (a single bbolt file), so unified storage can run without any SQL database.
+// It supports a single process: the watch events are only sent to the local subscribers.
+type Backend interface {
+	resource.StorageBackend
+	resource.DiagnosticsServer
+	resource.LifecycleHooks
+}
+
+type BackendOptions struct {
+	// Path of the database file, created when it does not exist
+	Path            string
+	Tracer          trace.Tracer
+	WatchBufferSize int
+}
+
+func NewBackend(opts BackendOptions) (Backend, error) {
+	if opts.Path == "" {
+		return nil, errors.New("missing database path")
+	}
+	if opts.Tracer == nil {
+		opts.Tracer = noop.NewTracerProvider().Tracer("kv-backend")
+	}
+	if opts.WatchBufferSize == 0 {
+		opts.WatchBufferSize = defaultWatchBufferSize
+	}
+
+	db, err := bolt.Open(opts.Path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("open database: %w", err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{bucketResources, bucketHistory, bucketBlobs, bucketMeta} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("create buckets: %w", err)
+	}
+
+	log := logging.DefaultLogger.With("logger", "kv-resource-server")
+	return &backend{
+		db:       db,
+		log:      log,
+		tracer:   opts.Tracer,
+		notifier: newNotifier(opts.WatchBufferSize, log),
+	}, nil
+}
+
+type backend struct {
+	db     *bolt.DB
+	log    logging.Logger
+	tracer trace.Tracer
+
+	// bbolt only allows one writer at a time, the lock also makes sure the
+	// events are sent to the watchers in the order of their resource versions
+	writeMu  sync.Mutex
+	notifier *notifier
+}
+
+func (b *backend) Init(_ context.Context) error {
+	return nil
+}
+
+func (b *backend) Stop(_ context.Context) error {
+	b.notifier.close()
+	return b.db.Close()
+}
+
+func (b *backend) IsHealthy(_ context.Context, _ *resource.HealthCheckRequest) (*resource.HealthCheckResponse, error) {
+	err := b.db.View(func(tx *bolt.Tx) error {
+		if tx.Bucket(bucketResources) == nil {
+			return errors.New("missing resources bucket")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &resource.HealthCheckResponse{Status: resource.HealthCheckResponse_SERVING}, nil
+}
+
+// nextResourceVersion returns a new resource version, which is the current time in
+// microseconds (like the SQL backend) but always greater than the previous one.
+func nextResourceVersion(tx *bolt.Tx) (int64, error) {
+	meta := tx.Bucket(bucketMeta)
+	rv := time.Now().UnixMicro()
+	if last := latestResourceVersion(tx); rv <= last {
+		rv = last + 1
+	}
+	return rv, meta.Put(metaResourceVersion, binary.BigEndian.AppendUint64(nil, uint64(rv)))
+}
+
+func latestResourceVersion(tx *bolt.Tx) int64 {
+	raw := tx.Bucket(bucketMeta).Get(metaResourceVersion)
+	if len(raw) != 8 {
+		return 0
+	}
+	return int64(binary.BigEndian.Uint64(raw))
+}
+
+func (b *backend) WriteEvent(ctx context.Context, event resource.WriteEvent) (int64, error) {
+	_, span := b.tracer.Start(ctx, tracePrefix+"WriteEvent")
+	defer span.End()
+
+	if event.Key == nil {
+		return 0, fmt.Errorf("missing key")
+	}
+	switch event.Type {
+	case resource.WatchEvent_ADDED, resource.WatchEvent_MODIFIED, resource.WatchEvent_DELETED:
+	default:
+		return 0, fmt.Errorf("unsupported event type")
+	}
+
+	folder := ""
+	if event.Object != nil {
+		folder = event.Object.GetFolder()
+	}
+
+	b.writeMu.Lock()
+	defer b.writeMu.Unlock()
+
+	var rv int64
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		resources := tx.Bucket(bucketResources)
+		key := prefixOf(event.Key)
+		exists := resources.Get(key) != nil
+		switch {
+		case event.Type == resource.WatchEvent_ADDED && exists:
+			return resource.ErrResourceAlreadyExists
+		case event.Type != resource.WatchEvent_ADDED && !exists:
+			return apierrors.NewNotFound(schema.GroupResource{Group: event.Key.Group, Resource: event.Key.Resource}, event.Key.Name)
+		}
+
+		var err error
+		rv, err = nextResourceVersion(tx)
+		if err != nil {
+			return err
+		}
+		rec := &record{action: event.Type, rv: rv, folder: folder, value: event.Value}
+		if event.Type == resource.WatchEvent_DELETED {
+			err = resources.Delete(key)
+		} else {
+			err = resources.Put(key, rec.encode())
+		}
+		if err != nil {
+			return err
+		}
+		return tx.Bucket(bucketHistory).Put(historyKey(event.Key, rv), rec.encode())
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	b.notifier.send(&resource.WrittenEvent{
+		Type:            event.Type,
+		Key:             event.Key,
+		PreviousRV:      event.PreviousRV,
+		Value:           event.Value,
+		ResourceVersion: rv,
+		Folder:          folder,
+	})
+	return rv, nil
+}
+
+func (b *backend) ReadResource(ctx context.Context, req *resource.ReadRequest) *resource.BackendReadResponse {
+	_, span := b.tracer.Start(ctx, tracePrefix+"Read")
+	defer span.End()
+
+	var rec *record
+	err := b.db.View(func(tx *bolt.Tx) error {
+		var err error
+		if req.ResourceVersion > 0 {
+			rec, err = readVersion(tx, req.Key, req.ResourceVersion)
+			return err
+		}
+		if raw := tx.Bucket(bucketResources).Get(prefixOf(req.Key)); raw != nil {
+			rec, err = decodeRecord(raw)
+		}
+		return err
+	})
+	if err != nil {
+		return &resource.BackendReadResponse{Error: resource.AsErrorResult(err)}
+	}
+	if rec == nil {
+		return &resource.BackendReadResponse{Error: resource.NewNotFoundError(req.Key)}
+	}
+	return &resource.BackendReadResponse{
+		Key:             req.Key,
+		Folder:          rec.folder,
+		ResourceVersion: rec.rv,
+		Value:           rec.value,
+	}
+}
+
+// readVersion returns the latest version of a resource that is not newer than rv, or nil when there is none.
+func readVersion(tx *bolt.Tx, key *resource.ResourceKey, rv int64) (*record, error) {
+	c := tx.Bucket(bucketHistory).Cursor()
+	prefix := prefixOf(key)
+	k, v := c.Seek(historyKey(key, rv+1))
+	if k == nil {
+		k, v = c.Last()
+	} else {
+		k, v = c.Prev()
+	}
+	if k == nil || !bytes.HasPrefix(k, prefix) {
+		return nil, nil
+	}
+	return decodeRecord(v)
+}
+
+func (b *backend) WatchWriteEvents(ctx context.Context) (<-chan *resource.WrittenEvent, error) {
+	return b.notifier.notify(ctx), nil
+}
+
+// GetResourceStats implements Backend.
+func (b *backend) GetResourceStats(ctx context.Context, namespace string, minCount int) ([]resource.ResourceStats, error) {
+	_, span := b.tracer.Start(ctx, tracePrefix+"GetResourceStats")
+	defer span.End()
+
+	type statsKey struct {
+		namespace, group, resource string
+	}
+	var keys []statsKey
+	stats := map[statsKey]*resource.ResourceStats{}
+	err := b.db.View(func(tx *bolt.Tx) error {
+		scan := prefixScanner(tx.Bucket(bucketResources), nil)
+		for k, v := scan(); k != nil; k, v = scan() {
+			item, err := decodeItem(k, v)
+			if err != nil {
+				return err
+			}
+			if namespace != "" && item.key.Namespace != namespace {
+				continue
+			}
+			sk := statsKey{namespace: item.key.Namespace, group: item.key.Group, resource: item.key.Resource}
+			s, ok := stats[sk]
+			if !ok {
+				s = &resource.ResourceStats{NamespacedResource: resource.NamespacedResource{
+					Namespace: item.key.Namespace,
+					Group:     item.key.Group,
+					Resource:  item.key.Resource,
+				}}
+				stats[sk] = s
+				keys = append(keys, sk)
+			}
+			s.Count++
+			s.ResourceVersion = max(s.ResourceVersion, item.rv)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]resource.ResourceStats, 0, len(keys))
+	for _, k := range keys {
+		if s := stats[k]; s.Count > int64(minCount) {
+			res = append(res, *s)
+		}
+	}
+	return res, nil
+}
+
+// GetResourceSizeStats implements resource.ResourceSizeStatsGetter.
+func (b *backend) GetResourceSizeStats(ctx context.Context, namespace string) ([]resource.ResourceStats, error) {
+	_, span := b.tracer.Start(ctx, tracePrefix+"GetResourceSizeStats")
+	defer span.End()
+
+	var res []resource.ResourceStats
+	index := map[resource.NamespacedResource]int{}
+	err := b.db.View(func(tx *bolt.Tx) error {
+		scan := prefixScanner(tx.Bucket(bucketResources), nil)
+		for k, v := scan(); k != nil; k, v = scan() {
+			item, err := decodeItem(k, v)
+			if err != nil {
+				return err
+			}
+			if item.key.Namespace != namespace {
+				continue
+			}
+			nr := resource.NamespacedResource{Namespace: namespace, Group: item.key.Group, Resource: item.key.Resource}
+			i, ok := index[nr]
+			if !ok {
+				i = len(res)
+				index[nr] = i
+				res = append(res, resource.ResourceStats{NamespacedResource: nr})
+			}
+			res[i].Count++
+			res[i].Size += int64(len(item.value))
+		}
+		return nil
+	})
+	return res, err
+}
+
+// ListModifiedSince implements resource.ModifiedSinceLister.
+func (b *backend) ListModifiedSince(ctx context.Context, key resource.NamespacedResource, sinceRV int64, fn func(*resource.ModifiedResource) error) (int64, error) {
+	_, span := b.tracer.Start(ctx, tracePrefix+"ListModifiedSince")
+	defer span.End()
+
+	// the versions of a resource are sorted by resource version, the last one of each name is sent
+	var latest *resource.ModifiedResource
+	var rv int64
+	err := b.db.View(func(tx *bolt.Tx) error {
+		scan := prefixScanner(tx.Bucket(bucketHistory), prefixOf(&resource.ResourceKey{
+			Namespace: key.Namespace,
+			Group:     key.Group,
+			Resource:  key.Resource,
+		}))
+		for k, v := scan(); k != nil; k, v = scan() {
+			hkey, version, err := parseHistoryKey(k)
+			if err != nil {
+				return err
+			}
+			if latest != nil && latest.Key.Name != hkey.Name {
+				if err := fn(latest); err != nil {
+					return err
+				}
+				latest = nil
+			}
+			if version <= sinceRV {
+				continue
+			}
+			rec, err := decodeRecord(v)
+			if err != nil {
+				return err
+			}
+			latest = &resource.ModifiedResource{Key: hkey, Action: rec.action, ResourceVersion: version, Value: rec.value}
+			rv = max(rv, version)
+		}
+		if latest != nil {
+			return fn(latest)
+		}
+		return nil
+	})
+	return rv, err
+}
+
+// ListWrittenSince implements resource.WrittenEventLister. The history is sorted by name, so the
+// events of the collection are read first and sent once sorted by resource version.
+func (b *backend) ListWrittenSince(ctx context.Context, group, res string, sinceRV int64, fn func(*resource.WrittenEvent) error) error {
+	_, span := b.tracer.Start(ctx, tracePrefix+"ListWrittenSince")
+	defer span.End()
+
+	var events []*resource.WrittenEvent
+	err := b.db.View(func(tx *bolt.Tx) error {
+		scan := prefixScanner(tx.Bucket(bucketHistory), prefixOf(&resource.ResourceKey{Group: group, Resource: res}))
+		var previous *resource.ResourceKey
+		var previousRV int64
+		for k, v := scan(); k != nil; k, v = scan() {
+			hkey, version, err := parseHistoryKey(k)
+			if err != nil {
+				return err
+			}
+			if previous == nil || previous.Namespace != hkey.Namespace || previous.Name != hkey.Name {
+				previousRV = 0
+			}
+			previous = hkey
+			if version > sinceRV {
+				rec, err := decodeRecord(v)
+				if err != nil {
+					return err
+				}
+				event := &resource.WrittenEvent{
+					Type:            rec.action,
+					Key:             hkey,
+					Value:           rec.value,
+					Folder:          rec.folder,
+					ResourceVersion: version,
+				}
+				if rec.action != resource.WatchEvent_ADDED {
+					event.PreviousRV = previousRV
+				}
+				events = append(events, event)
+			}
+			previousRV = version
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].ResourceVersion < events[j].ResourceVersion
+	})
+	for _, event := range events {
+		if err := fn(event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
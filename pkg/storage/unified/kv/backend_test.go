@@ -0,0 +1,112 @@
+package kv
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/storage/unified/resource"
+	unitest "github.com/grafana/grafana/pkg/storage/unified/testing"
+)
+
+func newTestBackend(t *testing.T) Backend {
+	t.Helper()
+
+	backend, err := NewBackend(BackendOptions{
+		Path: filepath.Join(t.TempDir(), "resource.db"),
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = backend.Stop(context.Background())
+	})
+	return backend
+}
+
+func TestIntegrationKVStorageBackend(t *testing.T) {
+	unitest.RunStorageBackendTest(t, func(ctx context.Context) resource.StorageBackend {
+		return newTestBackend(t)
+	}, nil)
+}
+
+func TestIntegrationKVStorageServer(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	unitest.RunStorageServerTest(t, func(ctx context.Context) resource.StorageBackend {
+		return newTestBackend(t)
+	})
+}
+
+func TestBackendReopen(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "resource.db")
+	key := &resource.ResourceKey{Namespace: "default", Group: "g", Resource: "r", Name: "a"}
+
+	backend, err := NewBackend(BackendOptions{Path: path})
+	require.NoError(t, err)
+	rv, err := backend.WriteEvent(ctx, resource.WriteEvent{Type: resource.WatchEvent_ADDED, Key: key, Value: []byte(`{"v":1}`)})
+	require.NoError(t, err)
+	require.NoError(t, backend.Stop(ctx))
+
+	backend, err = NewBackend(BackendOptions{Path: path})
+	require.NoError(t, err)
+	defer func() { _ = backend.Stop(ctx) }()
+
+	read := backend.ReadResource(ctx, &resource.ReadRequest{Key: key})
+	require.Nil(t, read.Error)
+	require.Equal(t, rv, read.ResourceVersion)
+	require.Equal(t, `{"v":1}`, string(read.Value))
+
+	// the resource versions keep increasing after a restart
+	next, err := backend.WriteEvent(ctx, resource.WriteEvent{Type: resource.WatchEvent_MODIFIED, Key: key, Value: []byte(`{"v":2}`)})
+	require.NoError(t, err)
+	require.Greater(t, next, rv)
+
+	// the previous version is still in the history
+	read = backend.ReadResource(ctx, &resource.ReadRequest{Key: key, ResourceVersion: rv})
+	require.Nil(t, read.Error)
+	require.Equal(t, `{"v":1}`, string(read.Value))
+}
+
+func TestPrefixOf(t *testing.T) {
+	ns := prefixOf(&resource.ResourceKey{Group: "g", Resource: "r", Namespace: "ns"})
+	ns2 := prefixOf(&resource.ResourceKey{Group: "g", Resource: "r", Namespace: "ns-2"})
+	require.False(t, len(ns2) > len(ns) && string(ns2[:len(ns)]) == string(ns), "a namespace must not match a longer namespace")
+
+	full := &resource.ResourceKey{Group: "g", Resource: "r", Namespace: "ns", Name: "a"}
+	key, rv, err := parseHistoryKey(historyKey(full, 256))
+	require.NoError(t, err)
+	require.Equal(t, full.String(), key.String())
+	require.Equal(t, int64(256), rv)
+
+	// cluster scoped resources have an empty namespace
+	cluster := &resource.ResourceKey{Group: "g", Resource: "r", Name: "a"}
+	require.Equal(t, "g\x00r\x00\x00a\x00", string(prefixOf(cluster)))
+}
+
+func TestListCollections(t *testing.T) {
+	ctx := context.Background()
+	backend := newTestBackend(t)
+
+	write := func(typ resource.WatchEvent_Type, ns, group, name string) {
+		_, err := backend.WriteEvent(ctx, resource.WriteEvent{
+			Type:  typ,
+			Key:   &resource.ResourceKey{Namespace: ns, Group: group, Resource: "r", Name: name},
+			Value: []byte(`{}`),
+		})
+		require.NoError(t, err)
+	}
+	write(resource.WatchEvent_ADDED, "ns", "a", "x")
+	write(resource.WatchEvent_ADDED, "ns", "b", "x")
+	write(resource.WatchEvent_DELETED, "ns", "b", "x")
+	write(resource.WatchEvent_ADDED, "ns-2", "c", "x")
+
+	collections, err := backend.(resource.CollectionLister).ListCollections(ctx, "ns")
+	require.NoError(t, err)
+	require.Equal(t, []resource.NamespacedResource{
+		{Namespace: "ns", Group: "a", Resource: "r"},
+		{Namespace: "ns", Group: "b", Resource: "r"},
+	}, collections)
+}
@@ -0,0 +1,138 @@
+package kv
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/grafana/grafana/pkg/apimachinery/utils"
+	"github.com/grafana/grafana/pkg/storage/unified/resource"
+)
+
+var (
+	_ resource.BlobSupport = (*backend)(nil)
+)
+
+func (b *backend) SupportsSignedURLs() bool {
+	return false
+}
+
+func (b *backend) PutResourceBlob(ctx context.Context, req *resource.PutBlobRequest) (*resource.PutBlobResponse, error) {
+	_, span := b.tracer.Start(ctx, tracePrefix+"PutResourceBlob")
+	defer span.End()
+
+	if req.Method == resource.PutBlobRequest_HTTP {
+		return &resource.PutBlobResponse{
+			Error: resource.NewBadRequestError("signed url upload not supported"),
+		}, nil
+	}
+
+	hasher := md5.New() // same as s3
+	_, err := hasher.Write(req.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &utils.BlobInfo{
+		UID:  uuid.New().String(),
+		Size: int64(len(req.Value)),
+		Hash: hex.EncodeToString(hasher.Sum(nil)),
+	}
+	info.SetContentType(req.ContentType)
+
+	if info.Size < 1 {
+		return &resource.PutBlobResponse{
+			Error: resource.NewBadRequestError("empty content"),
+		}, nil
+	}
+
+	// The blobs of a resource are sorted by creation time, so the latest one is found without an index
+	key := binary.BigEndian.AppendUint64(prefixOf(req.Resource), uint64(time.Now().UnixNano()))
+	key = append(key, info.UID...)
+
+	value := binary.AppendUvarint(nil, uint64(len(req.ContentType)))
+	value = append(value, req.ContentType...)
+	value = append(value, req.Value...)
+
+	err = b.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketBlobs).Put(key, value)
+	})
+	if err != nil {
+		return &resource.PutBlobResponse{
+			Error: resource.AsErrorResult(err),
+		}, nil
+	}
+	return &resource.PutBlobResponse{
+		Uid:      info.UID,
+		Size:     info.Size,
+		MimeType: info.MimeType,
+		Charset:  info.Charset,
+		Hash:     info.Hash,
+	}, nil
+}
+
+func (b *backend) GetResourceBlob(ctx context.Context, key *resource.ResourceKey, info *utils.BlobInfo, mustProxy bool) (*resource.GetBlobResponse, error) {
+	_, span := b.tracer.Start(ctx, tracePrefix+"GetResourceBlob")
+	defer span.End()
+
+	if info == nil {
+		return &resource.GetBlobResponse{
+			Error: resource.NewBadRequestError("missing blob info"),
+		}, nil
+	}
+
+	rsp := &resource.GetBlobResponse{}
+	err := b.db.View(func(tx *bolt.Tx) error {
+		// Without a UID, the most recent blob is returned. The key may not have a name,
+		// so the blobs of several resources can be mixed and all of them are compared.
+		var found []byte
+		var latest []byte
+		scan := prefixScanner(tx.Bucket(bucketBlobs), prefixOf(key))
+		for k, v := scan(); k != nil; k, v = scan() {
+			_, suffix, err := parseKey(k)
+			if err != nil {
+				return err
+			}
+			if len(suffix) < 8 {
+				return fmt.Errorf("invalid blob key: %q", k)
+			}
+			created, uid := suffix[:8], string(suffix[8:])
+			if info.UID != "" {
+				if uid == info.UID {
+					found = v
+					break
+				}
+				continue
+			}
+			if latest == nil || bytes.Compare(created, latest) > 0 {
+				found, latest = v, created
+			}
+		}
+		if found == nil {
+			rsp.Error = &resource.ErrorResult{
+				Code: http.StatusNotFound,
+			}
+			return nil
+		}
+
+		size, n := binary.Uvarint(found)
+		if n <= 0 || uint64(len(found)-n) < size {
+			return fmt.Errorf("invalid blob")
+		}
+		rsp.ContentType = string(found[n : n+int(size)])
+		rsp.Value = bytes.Clone(found[n+int(size):])
+		return nil
+	})
+	if err != nil {
+		rsp.Error = resource.AsErrorResult(err)
+	}
+	return rsp, nil
+}
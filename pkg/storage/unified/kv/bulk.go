@@ -0,0 +1,200 @@
+package kv
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/grafana/grafana/pkg/storage/unified/resource"
+)
+
+var (
+	_ resource.BulkProcessingBackend = (*backend)(nil)
+	_ resource.CollectionLister      = (*backend)(nil)
+	_ resource.ModifiedSinceLister   = (*backend)(nil)
+	_ resource.WrittenEventLister    = (*backend)(nil)
+)
+
+// errBulkRollback aborts the bulk transaction when the iterator requests a rollback
+var errBulkRollback = errors.New("bulk rollback")
+
+// ProcessBulk writes all the requests in a single transaction, so either all of them are saved or none.
+// Like the SQL backend, watch events are only sent for the values added to a collection that is not rebuilt.
+func (b *backend) ProcessBulk(ctx context.Context, setting resource.BulkSettings, iter resource.BulkRequestIterator) *resource.BulkResponse {
+	_, span := b.tracer.Start(ctx, tracePrefix+"ProcessBulk")
+	defer span.End()
+
+	b.writeMu.Lock()
+	defer b.writeMu.Unlock()
+
+	var rsp *resource.BulkResponse
+	var events []*resource.WrittenEvent
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		// reset for every attempt, the response of a rolled back transaction is discarded
+		rsp = &resource.BulkResponse{}
+		events = nil
+		summaries := make(map[string]*resource.BulkResponse_Summary, len(setting.Collection))
+		for _, key := range setting.Collection {
+			summary := &resource.BulkResponse_Summary{
+				Namespace: key.Namespace,
+				Group:     key.Group,
+				Resource:  key.Resource,
+			}
+			if setting.RebuildCollection {
+				var err error
+				if summary.PreviousCount, err = deletePrefix(tx.Bucket(bucketResources), prefixOf(key)); err != nil {
+					return err
+				}
+				if summary.PreviousHistory, err = deletePrefix(tx.Bucket(bucketHistory), prefixOf(key)); err != nil {
+					return err
+				}
+			}
+			summaries[key.NSGR()] = summary
+			rsp.Summary = append(rsp.Summary, summary)
+		}
+
+		for iter.Next() {
+			if iter.RollbackRequested() {
+				return errBulkRollback
+			}
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			req := iter.Request()
+			if req == nil {
+				return fmt.Errorf("missing request")
+			}
+			rsp.Processed++
+
+			summary := summaries[req.Key.NSGR()]
+			reject := func(msg string) {
+				rsp.Rejected = append(rsp.Rejected, &resource.BulkResponse_Rejected{
+					Key:    req.Key,
+					Action: req.Action,
+					Error:  msg,
+				})
+			}
+			switch {
+			case summary == nil:
+				reject("key not in the bulk collections")
+				continue
+			case req.Action == resource.BulkRequest_UNKNOWN:
+				reject("unknown action")
+				continue
+			}
+
+			key := prefixOf(req.Key)
+			value := req.Value
+			var previousRV int64
+			if !setting.RebuildCollection {
+				if raw := tx.Bucket(bucketResources).Get(key); raw != nil {
+					current, err := decodeRecord(raw)
+					if err != nil {
+						return err
+					}
+					previousRV = current.rv
+				}
+				var err error
+				if value, err = resource.CheckBulkAppend(req, previousRV); err != nil {
+					return err
+				}
+			}
+
+			rv, err := nextResourceVersion(tx)
+			if err != nil {
+				return err
+			}
+			rec := &record{action: resource.WatchEvent_Type(req.Action), rv: rv, folder: req.Folder, value: value}
+			if req.Action == resource.BulkRequest_DELETED {
+				err = tx.Bucket(bucketResources).Delete(key)
+			} else {
+				err = tx.Bucket(bucketResources).Put(key, rec.encode())
+			}
+			if err != nil {
+				return err
+			}
+			if err := tx.Bucket(bucketHistory).Put(historyKey(req.Key, rv), rec.encode()); err != nil {
+				return err
+			}
+			summary.History++
+			summary.ResourceVersion = rv
+			if !setting.RebuildCollection {
+				events = append(events, &resource.WrittenEvent{
+					Type:            rec.action,
+					Key:             req.Key,
+					PreviousRV:      previousRV,
+					Value:           value,
+					ResourceVersion: rv,
+					Folder:          req.Folder,
+				})
+			}
+		}
+		if iter.RollbackRequested() {
+			return errBulkRollback
+		}
+
+		for _, summary := range rsp.Summary {
+			prefix := prefixOf(&resource.ResourceKey{Namespace: summary.Namespace, Group: summary.Group, Resource: summary.Resource})
+			scan := prefixScanner(tx.Bucket(bucketResources), prefix)
+			for k, _ := scan(); k != nil; k, _ = scan() {
+				summary.Count++
+			}
+		}
+		return nil
+	})
+	switch {
+	case errors.Is(err, errBulkRollback):
+		rsp.Summary = nil
+	case err != nil:
+		rsp.Error = resource.AsErrorResult(err)
+	default:
+		for _, event := range events {
+			b.notifier.send(event)
+		}
+	}
+	return rsp
+}
+
+// deletePrefix deletes all the keys with a prefix, and returns how many were deleted
+func deletePrefix(bucket *bolt.Bucket, prefix []byte) (int64, error) {
+	var count int64
+	c := bucket.Cursor()
+	for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Seek(prefix) {
+		if err := c.Delete(); err != nil {
+			return 0, err
+		}
+		count++
+	}
+	return count, nil
+}
+
+// ListCollections implements resource.CollectionLister, by looking for the collections
+// with at least one saved version in the namespace.
+func (b *backend) ListCollections(ctx context.Context, namespace string) ([]resource.NamespacedResource, error) {
+	_, span := b.tracer.Start(ctx, tracePrefix+"ListCollections")
+	defer span.End()
+
+	var res []resource.NamespacedResource
+	err := b.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(bucketHistory).Cursor()
+		k, _ := c.First()
+		for k != nil {
+			key, _, err := parseKey(k)
+			if err != nil {
+				return err
+			}
+			// look for the namespace in the collection, then skip to the next collection
+			collection := prefixOf(&resource.ResourceKey{Group: key.Group, Resource: key.Resource})
+			prefix := prefixOf(&resource.ResourceKey{Group: key.Group, Resource: key.Resource, Namespace: namespace})
+			if found, _ := c.Seek(prefix); found != nil && bytes.HasPrefix(found, prefix) {
+				res = append(res, resource.NamespacedResource{Namespace: namespace, Group: key.Group, Resource: key.Resource})
+			}
+			k, _ = c.Seek(append(collection[:len(collection)-1], keySeparator+1))
+		}
+		return nil
+	})
+	return res, err
+}
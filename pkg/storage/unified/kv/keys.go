@@ -0,0 +1,114 @@
+package kv
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/grafana/grafana/pkg/storage/unified/resource"
+)
+
+// The keys are made of the group, resource, namespace and name separated by a zero byte,
+// so that the keys of a resource are sorted by namespace and name, and the keys of
+// "ns" and "ns-2" don't share the same prefix.
+const keySeparator = 0
+
+var (
+	bucketResources = []byte("resources") // key -> latest version
+	bucketHistory   = []byte("history")   // key + rv -> every version
+	bucketBlobs     = []byte("blobs")     // key + uid -> blob
+	bucketMeta      = []byte("meta")
+
+	metaResourceVersion = []byte("rv")
+)
+
+// prefixOf returns the prefix of the keys matching a (partial) resource key.
+// An empty namespace matches all namespaces, unless a name is set for a cluster scoped resource.
+func prefixOf(key *resource.ResourceKey) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(key.Group)
+	buf.WriteByte(keySeparator)
+	buf.WriteString(key.Resource)
+	buf.WriteByte(keySeparator)
+	if key.Namespace == "" && key.Name == "" {
+		return buf.Bytes()
+	}
+	buf.WriteString(key.Namespace)
+	buf.WriteByte(keySeparator)
+	if key.Name == "" {
+		return buf.Bytes()
+	}
+	buf.WriteString(key.Name)
+	buf.WriteByte(keySeparator)
+	return buf.Bytes()
+}
+
+// historyKey returns the key of a version of a resource, which sorts the versions by resource version.
+func historyKey(key *resource.ResourceKey, rv int64) []byte {
+	return binary.BigEndian.AppendUint64(prefixOf(key), uint64(rv))
+}
+
+// parseKey returns the resource key and the suffix (resource version or blob uid) of a key.
+func parseKey(k []byte) (*resource.ResourceKey, []byte, error) {
+	parts := bytes.SplitN(k, []byte{keySeparator}, 5)
+	if len(parts) != 5 {
+		return nil, nil, fmt.Errorf("invalid key: %q", k)
+	}
+	return &resource.ResourceKey{
+		Group:     string(parts[0]),
+		Resource:  string(parts[1]),
+		Namespace: string(parts[2]),
+		Name:      string(parts[3]),
+	}, parts[4], nil
+}
+
+// parseHistoryKey returns the resource key and resource version of a history key.
+func parseHistoryKey(k []byte) (*resource.ResourceKey, int64, error) {
+	key, suffix, err := parseKey(k)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(suffix) != 8 {
+		return nil, 0, fmt.Errorf("invalid history key: %q", k)
+	}
+	return key, int64(binary.BigEndian.Uint64(suffix)), nil
+}
+
+// record is the value stored for a version of a resource.
+type record struct {
+	action resource.WatchEvent_Type
+	rv     int64
+	folder string
+	value  []byte
+}
+
+// encode serializes the record as action (1 byte) | rv (8 bytes) | folder length (varint) | folder | value
+func (r *record) encode() []byte {
+	buf := make([]byte, 0, 1+8+binary.MaxVarintLen64+len(r.folder)+len(r.value))
+	buf = append(buf, byte(r.action))
+	buf = binary.BigEndian.AppendUint64(buf, uint64(r.rv))
+	buf = binary.AppendUvarint(buf, uint64(len(r.folder)))
+	buf = append(buf, r.folder...)
+	return append(buf, r.value...)
+}
+
+// decodeRecord deserializes a record. The value is copied, as the bytes returned
+// by bbolt are only valid for the life of the transaction.
+func decodeRecord(raw []byte) (*record, error) {
+	if len(raw) < 10 {
+		return nil, fmt.Errorf("invalid record")
+	}
+	r := &record{
+		action: resource.WatchEvent_Type(raw[0]),
+		rv:     int64(binary.BigEndian.Uint64(raw[1:9])),
+	}
+	size, n := binary.Uvarint(raw[9:])
+	start := 9 + n
+	if n <= 0 || uint64(len(raw)-start) < size {
+		return nil, fmt.Errorf("invalid record")
+	}
+	end := start + int(size)
+	r.folder = string(raw[start:end])
+	r.value = bytes.Clone(raw[end:])
+	return r, nil
+}
@@ -0,0 +1,329 @@
+package kv
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+
+	bolt "go.etcd.io/bbolt"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+
+	"github.com/grafana/grafana/pkg/storage/unified/resource"
+)
+
+func (b *backend) ListIterator(ctx context.Context, req *resource.ListRequest, cb func(resource.ListIterator) error) (int64, error) {
+	ctx, span := b.tracer.Start(ctx, tracePrefix+"List")
+	defer span.End()
+
+	if err := resource.MigrateListRequestVersionMatch(req, b.log); err != nil {
+		return 0, err
+	}
+
+	if req.Options == nil || req.Options.Key.Group == "" || req.Options.Key.Resource == "" {
+		return 0, fmt.Errorf("missing group or resource")
+	}
+
+	if req.Source != resource.ListRequest_STORE {
+		return b.getHistory(ctx, req, cb)
+	}
+	if req.ResourceVersion > 0 || req.NextPageToken != "" {
+		return b.listAtRevision(ctx, req, cb)
+	}
+	return b.listLatest(ctx, req, cb)
+}
+
+type listItem struct {
+	key *resource.ResourceKey
+	*record
+}
+
+type listIter struct {
+	ctx     context.Context
+	next    func() (*listItem, error)
+	offset  int64
+	listRV  int64
+	sortAsc bool
+
+	// any error
+	err error
+
+	// The current item
+	item *listItem
+}
+
+// ContinueToken implements resource.ListIterator.
+func (l *listIter) ContinueToken() string {
+	return resource.ContinueToken{ResourceVersion: l.listRV, StartOffset: l.offset, SortAscending: l.sortAsc}.String()
+}
+
+func (l *listIter) ContinueTokenWithCurrentRV() string {
+	return resource.ContinueToken{ResourceVersion: l.ResourceVersion(), StartOffset: l.offset, SortAscending: l.sortAsc}.String()
+}
+
+func (l *listIter) Error() error {
+	return l.err
+}
+
+func (l *listIter) Name() string {
+	if l.item == nil {
+		return ""
+	}
+	return l.item.key.Name
+}
+
+func (l *listIter) Namespace() string {
+	if l.item == nil {
+		return ""
+	}
+	return l.item.key.Namespace
+}
+
+func (l *listIter) Folder() string {
+	if l.item == nil {
+		return ""
+	}
+	return l.item.folder
+}
+
+// ResourceVersion implements resource.ListIterator.
+func (l *listIter) ResourceVersion() int64 {
+	if l.item == nil {
+		return 0
+	}
+	return l.item.rv
+}
+
+// Value implements resource.ListIterator.
+func (l *listIter) Value() []byte {
+	if l.item == nil {
+		return nil
+	}
+	return l.item.value
+}
+
+// Next implements resource.ListIterator.
+func (l *listIter) Next() bool {
+	if l.err != nil {
+		return false
+	}
+	if l.err = l.ctx.Err(); l.err != nil {
+		return false
+	}
+	l.item, l.err = l.next()
+	if l.err != nil || l.item == nil {
+		return false
+	}
+	l.offset++
+	return true
+}
+
+var _ resource.ListIterator = (*listIter)(nil)
+
+// listLatest iterates over the current version of the resources, sorted by namespace and name.
+func (b *backend) listLatest(ctx context.Context, req *resource.ListRequest, cb func(resource.ListIterator) error) (int64, error) {
+	ctx, span := b.tracer.Start(ctx, tracePrefix+"listLatest")
+	defer span.End()
+
+	iter := &listIter{ctx: ctx}
+	err := b.db.View(func(tx *bolt.Tx) error {
+		iter.listRV = latestResourceVersion(tx)
+		scan := prefixScanner(tx.Bucket(bucketResources), prefixOf(req.Options.Key))
+		iter.next = func() (*listItem, error) {
+			k, v := scan()
+			if k == nil {
+				return nil, nil
+			}
+			return decodeItem(k, v)
+		}
+		return cb(iter)
+	})
+	return iter.listRV, err
+}
+
+// listAtRevision iterates over the resources as they were at a resource version, sorted by namespace and name.
+func (b *backend) listAtRevision(ctx context.Context, req *resource.ListRequest, cb func(resource.ListIterator) error) (int64, error) {
+	ctx, span := b.tracer.Start(ctx, tracePrefix+"listAtRevision")
+	defer span.End()
+
+	iter := &listIter{ctx: ctx, listRV: req.ResourceVersion}
+	if req.NextPageToken != "" {
+		continueToken, err := resource.GetContinueToken(req.NextPageToken)
+		if err != nil {
+			return 0, fmt.Errorf("get continue token: %w", err)
+		}
+		iter.listRV = continueToken.ResourceVersion
+		iter.offset = continueToken.StartOffset
+
+		if req.ResourceVersion != 0 && req.ResourceVersion != iter.listRV {
+			return 0, apierrors.NewBadRequest("request resource version does not math token")
+		}
+	}
+	if iter.listRV < 1 {
+		return 0, apierrors.NewBadRequest("expecting an explicit resource version query")
+	}
+
+	skip := iter.offset
+	err := b.db.View(func(tx *bolt.Tx) error {
+		scan := prefixScanner(tx.Bucket(bucketHistory), prefixOf(req.Options.Key))
+		k, v := scan()
+		iter.next = func() (*listItem, error) {
+			// The versions of a resource are next to each other and sorted by resource
+			// version, so the version to return is the last one not newer than the list RV.
+			for k != nil {
+				if len(k) < 8 {
+					return nil, fmt.Errorf("invalid history key: %q", k)
+				}
+				resourceKey := k[:len(k)-8]
+				var foundKey, foundValue []byte
+				for ; k != nil && bytes.HasPrefix(k, resourceKey) && len(k) == len(resourceKey)+8; k, v = scan() {
+					_, rv, err := parseHistoryKey(k)
+					if err != nil {
+						return nil, err
+					}
+					if rv <= iter.listRV {
+						foundKey, foundValue = k, v
+					}
+				}
+				if foundKey == nil {
+					continue
+				}
+				item, err := decodeItem(foundKey, foundValue)
+				if err != nil {
+					return nil, err
+				}
+				if item.action == resource.WatchEvent_DELETED {
+					continue
+				}
+				if skip > 0 {
+					skip--
+					continue
+				}
+				return item, nil
+			}
+			return nil, nil
+		}
+		return cb(iter)
+	})
+	return iter.listRV, err
+}
+
+// getHistory iterates over the versions of the resources, or over the deleted resources for the trash.
+func (b *backend) getHistory(ctx context.Context, req *resource.ListRequest, cb func(resource.ListIterator) error) (int64, error) {
+	ctx, span := b.tracer.Start(ctx, tracePrefix+"getHistory")
+	defer span.End()
+
+	trash := req.Source == resource.ListRequest_TRASH
+
+	// Like the SQL backend, we are assuming that users want history in ascending order
+	// when they are using NotOlderThan matching, and descending order otherwise.
+	sortAsc := req.GetVersionMatchV2() == resource.ResourceVersionMatchV2_NotOlderThan
+	var startRV int64
+	if req.NextPageToken != "" {
+		continueToken, err := resource.GetContinueToken(req.NextPageToken)
+		if err != nil {
+			return 0, fmt.Errorf("get continue token: %w", err)
+		}
+		startRV = continueToken.ResourceVersion
+		sortAsc = continueToken.SortAscending
+	}
+
+	var exactRV, minRV int64
+	if req.VersionMatchV2 == resource.ResourceVersionMatchV2_Exact {
+		if req.ResourceVersion <= 0 {
+			return 0, fmt.Errorf("expecting an explicit resource version query when using Exact matching")
+		}
+		exactRV = req.ResourceVersion
+	}
+	if req.ResourceVersion > 0 && req.VersionMatchV2 == resource.ResourceVersionMatchV2_NotOlderThan {
+		minRV = req.ResourceVersion
+	}
+
+	// Ignore the versions before the last deletion, unless listing the trash or using exact matching
+	useLatestDeletionAsMinRV := minRV == 0 && !trash && exactRV == 0
+
+	iter := &listIter{ctx: ctx, sortAsc: sortAsc}
+	err := b.db.View(func(tx *bolt.Tx) error {
+		iter.listRV = latestResourceVersion(tx)
+
+		var versions []*listItem
+		scan := prefixScanner(tx.Bucket(bucketHistory), prefixOf(req.Options.Key))
+		for k, v := scan(); k != nil; k, v = scan() {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			item, err := decodeItem(k, v)
+			if err != nil {
+				return err
+			}
+			if useLatestDeletionAsMinRV && item.action == resource.WatchEvent_DELETED {
+				minRV = max(minRV, item.rv+1)
+			}
+			versions = append(versions, item)
+		}
+
+		filtered := versions[:0]
+		for _, v := range versions {
+			switch {
+			case trash && v.action != resource.WatchEvent_DELETED,
+				startRV > 0 && sortAsc && v.rv <= startRV,
+				startRV > 0 && !sortAsc && v.rv >= startRV,
+				minRV > 0 && v.rv < minRV,
+				exactRV > 0 && v.rv != exactRV:
+				continue
+			}
+			filtered = append(filtered, v)
+		}
+
+		// without a name, the versions of several resources are merged
+		sort.SliceStable(filtered, func(i, j int) bool {
+			if sortAsc {
+				return filtered[i].rv < filtered[j].rv
+			}
+			return filtered[i].rv > filtered[j].rv
+		})
+
+		iter.next = func() (*listItem, error) {
+			if len(filtered) == 0 {
+				return nil, nil
+			}
+			item := filtered[0]
+			filtered = filtered[1:]
+			return item, nil
+		}
+		return cb(iter)
+	})
+	return iter.listRV, err
+}
+
+// prefixScanner returns a function that returns the keys and values of a bucket starting with prefix,
+// in key order, and a nil key once they have all been returned.
+func prefixScanner(bucket *bolt.Bucket, prefix []byte) func() ([]byte, []byte) {
+	c := bucket.Cursor()
+	started := false
+	return func() ([]byte, []byte) {
+		var k, v []byte
+		if started {
+			k, v = c.Next()
+		} else {
+			k, v = c.Seek(prefix)
+			started = true
+		}
+		if k == nil || !bytes.HasPrefix(k, prefix) {
+			return nil, nil
+		}
+		return k, v
+	}
+}
+
+func decodeItem(k, v []byte) (*listItem, error) {
+	key, _, err := parseKey(k)
+	if err != nil {
+		return nil, err
+	}
+	rec, err := decodeRecord(v)
+	if err != nil {
+		return nil, err
+	}
+	return &listItem{key: key, record: rec}, nil
+}
@@ -0,0 +1,69 @@
+package kv
+
+import (
+	"context"
+	"sync"
+
+	"github.com/grafana/grafana-app-sdk/logging"
+	"github.com/grafana/grafana/pkg/storage/unified/resource"
+)
+
+// notifier forwards the written events to the watchers of the local process.
+type notifier struct {
+	log        logging.Logger
+	bufferSize int
+
+	mu          sync.RWMutex
+	subscribers map[chan *resource.WrittenEvent]bool
+}
+
+func newNotifier(bufferSize int, log logging.Logger) *notifier {
+	return &notifier{
+		subscribers: make(map[chan *resource.WrittenEvent]bool),
+		log:         log,
+		bufferSize:  bufferSize,
+	}
+}
+
+func (n *notifier) notify(ctx context.Context) <-chan *resource.WrittenEvent {
+	events := make(chan *resource.WrittenEvent, n.bufferSize)
+
+	n.mu.Lock()
+	n.subscribers[events] = true
+	n.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		n.mu.Lock()
+		if n.subscribers[events] {
+			delete(n.subscribers, events)
+			close(events)
+		}
+		n.mu.Unlock()
+	}()
+
+	return events
+}
+
+func (n *notifier) send(event *resource.WrittenEvent) {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+
+	for ch := range n.subscribers {
+		select {
+		case ch <- event:
+		default:
+			n.log.Warn("Dropped event notification for subscriber - channel full")
+		}
+	}
+}
+
+func (n *notifier) close() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	for ch := range n.subscribers {
+		close(ch)
+	}
+	n.subscribers = make(map[chan *resource.WrittenEvent]bool)
+}
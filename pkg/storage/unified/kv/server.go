@@ -0,0 +1,55 @@
+package kv
+
+import (
+	"os"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/grafana/authlib/types"
+
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/storage/unified/resource"
+)
+
+// Creates a new ResourceServer storing the resources in the database file at path
+func NewResourceServer(path string, cfg *setting.Cfg,
+	tracer trace.Tracer, reg prometheus.Registerer, ac types.AccessClient,
+	searchOptions resource.SearchOptions, indexMetrics *resource.BleveIndexMetrics) (resource.ResourceServer, error) {
+	apiserverCfg := cfg.SectionWithEnvOverrides("grafana-apiserver")
+	opts := resource.ResourceServerOptions{
+		Tracer: tracer,
+		Blob: resource.BlobConfig{
+			URL: apiserverCfg.Key("blob_url").MustString(""),
+		},
+		Reg: reg,
+	}
+	if ac != nil {
+		opts.AccessClient = resource.NewAuthzLimitedClient(ac, resource.AuthzOptions{Tracer: tracer, Registry: reg})
+	}
+	// Support local file blob
+	if strings.HasPrefix(opts.Blob.URL, "./data/") {
+		dir := strings.Replace(opts.Blob.URL, "./data", cfg.DataPath, 1)
+		err := os.MkdirAll(dir, 0700)
+		if err != nil {
+			return nil, err
+		}
+		opts.Blob.URL = "file:///" + dir
+	}
+
+	store, err := NewBackend(BackendOptions{
+		Path:   path,
+		Tracer: tracer,
+	})
+	if err != nil {
+		return nil, err
+	}
+	opts.Backend = store
+	opts.Diagnostics = store
+	opts.Lifecycle = store
+	opts.Search = searchOptions
+	opts.IndexMetrics = indexMetrics
+
+	return resource.NewResourceServer(opts)
+}
@@ -0,0 +1,168 @@
+package parquet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/grafana/grafana/pkg/storage/unified/resource"
+)
+
+// ExportOptions configures the export of a namespace
+type ExportOptions struct {
+	// The namespace to export
+	Namespace string
+
+	// The directory where the parquet files are written, one file per group and resource: {dir}/{group}/{resource}.parquet
+	Dir string
+
+	// Export every saved version of the resources, and the deleted resources, instead of only their latest version
+	History bool
+}
+
+// ExportNamespace writes every resource of a namespace into parquet files.
+// The files can be read with NewParquetReader to bulk import them, or with ImportNamespace.
+func ExportNamespace(ctx context.Context, backend resource.StorageBackend, opts ExportOptions) (*resource.BulkResponse, error) {
+	if opts.Namespace == "" {
+		return nil, fmt.Errorf("missing namespace")
+	}
+	if opts.Dir == "" {
+		return nil, fmt.Errorf("missing directory")
+	}
+
+	collections, err := listCollections(ctx, backend, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	rsp := &resource.BulkResponse{}
+	for _, c := range collections {
+		key := &resource.ResourceKey{
+			Namespace: opts.Namespace,
+			Group:     c.Group,
+			Resource:  c.Resource,
+		}
+		res, err := exportCollection(ctx, backend, key, opts)
+		if err != nil {
+			return rsp, fmt.Errorf("export %s: %w", key.NSGR(), err)
+		}
+		rsp.Processed += res.Processed
+		rsp.Summary = append(rsp.Summary, res.Summary...)
+	}
+	return rsp, nil
+}
+
+// listCollections returns the collections to export. The resource stats only include the collections
+// with live resources, so the history of the collections where everything was deleted needs a
+// backend able to list them.
+func listCollections(ctx context.Context, backend resource.StorageBackend, opts ExportOptions) ([]resource.NamespacedResource, error) {
+	if lister, ok := backend.(resource.CollectionLister); ok {
+		collections, err := lister.ListCollections(ctx, opts.Namespace)
+		if err != nil {
+			return nil, fmt.Errorf("list collections: %w", err)
+		}
+		return collections, nil
+	}
+	if opts.History {
+		return nil, fmt.Errorf("the storage backend can not list the collections to export with history")
+	}
+
+	stats, err := backend.GetResourceStats(ctx, opts.Namespace, 0)
+	if err != nil {
+		return nil, fmt.Errorf("get resource stats: %w", err)
+	}
+	collections := make([]resource.NamespacedResource, 0, len(stats))
+	for _, s := range stats {
+		collections = append(collections, s.NamespacedResource)
+	}
+	return collections, nil
+}
+
+func exportCollection(ctx context.Context, backend resource.StorageBackend, key *resource.ResourceKey, opts ExportOptions) (*resource.BulkResponse, error) {
+	dir := filepath.Join(opts.Dir, key.Group)
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return nil, err
+	}
+	file, err := os.Create(filepath.Join(dir, key.Resource+".parquet"))
+	if err != nil {
+		return nil, err
+	}
+	writer, err := NewParquetWriter(file)
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+
+	err = exportValues(ctx, backend, writer, key, opts.History)
+	rsp, closeErr := writer.CloseWithResults()
+	return rsp, errors.Join(err, closeErr)
+}
+
+func exportValues(ctx context.Context, backend resource.StorageBackend, writer *parquetWriter, key *resource.ResourceKey, history bool) error {
+	var names []string
+	_, err := backend.ListIterator(ctx, &resource.ListRequest{
+		Options: &resource.ListOptions{Key: key},
+	}, func(iter resource.ListIterator) error {
+		for iter.Next() {
+			if err := iter.Error(); err != nil {
+				return err
+			}
+			if history {
+				names = append(names, iter.Name())
+				continue
+			}
+			k := &resource.ResourceKey{Namespace: key.Namespace, Group: key.Group, Resource: key.Resource, Name: iter.Name()}
+			if err := writer.WriteVersion(ctx, k, iter.ResourceVersion(), iter.Folder(), iter.Value()); err != nil {
+				return err
+			}
+		}
+		return iter.Error()
+	})
+	if err != nil || !history {
+		return err
+	}
+
+	// The deleted resources come first, so that a resource that was deleted and created
+	// again is imported with its latest versions
+	if err := exportHistory(ctx, backend, writer, &resource.ListRequest{
+		Source:  resource.ListRequest_TRASH,
+		Options: &resource.ListOptions{Key: key},
+	}); err != nil {
+		return err
+	}
+	for _, name := range names {
+		err := exportHistory(ctx, backend, writer, &resource.ListRequest{
+			Source:         resource.ListRequest_HISTORY,
+			VersionMatchV2: resource.ResourceVersionMatchV2_NotOlderThan, // oldest first
+			Options: &resource.ListOptions{Key: &resource.ResourceKey{
+				Namespace: key.Namespace,
+				Group:     key.Group,
+				Resource:  key.Resource,
+				Name:      name,
+			}},
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func exportHistory(ctx context.Context, backend resource.StorageBackend, writer *parquetWriter, req *resource.ListRequest) error {
+	key := req.Options.Key
+	_, err := backend.ListIterator(ctx, req, func(iter resource.ListIterator) error {
+		for iter.Next() {
+			if err := iter.Error(); err != nil {
+				return err
+			}
+			k := &resource.ResourceKey{Namespace: key.Namespace, Group: key.Group, Resource: key.Resource, Name: iter.Name()}
+			if err := writer.WriteVersion(ctx, k, iter.ResourceVersion(), iter.Folder(), iter.Value()); err != nil {
+				return err
+			}
+		}
+		return iter.Error()
+	})
+	return err
+}
@@ -0,0 +1,167 @@
+package parquet
+
+import (
+	"context"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/grafana/grafana/pkg/apimachinery/utils"
+	"github.com/grafana/grafana/pkg/storage/unified/kv"
+	"github.com/grafana/grafana/pkg/storage/unified/resource"
+)
+
+func TestExportImportNamespace(t *testing.T) {
+	ctx := context.Background()
+	source := newKVBackend(t)
+
+	write := func(action resource.WatchEvent_Type, group, res, name, folder string, generation int64, spec string) {
+		obj := &unstructured.Unstructured{Object: map[string]any{
+			"apiVersion": group + "/v1",
+			"kind":       res,
+			"metadata": map[string]any{
+				"namespace":  "source",
+				"name":       name,
+				"generation": generation,
+			},
+			"spec": map[string]any{"value": spec},
+		}}
+		meta, err := utils.MetaAccessor(obj)
+		require.NoError(t, err)
+		meta.SetFolder(folder)
+		value, err := obj.MarshalJSON()
+		require.NoError(t, err)
+		_, err = source.WriteEvent(ctx, resource.WriteEvent{
+			Type:   action,
+			Key:    &resource.ResourceKey{Namespace: "source", Group: group, Resource: res, Name: name},
+			Value:  value,
+			Object: meta,
+		})
+		require.NoError(t, err)
+	}
+	write(resource.WatchEvent_ADDED, "dashboard.grafana.app", "dashboards", "a", "f1", 1, "a1")
+	write(resource.WatchEvent_MODIFIED, "dashboard.grafana.app", "dashboards", "a", "f2", 2, "a2")
+	write(resource.WatchEvent_ADDED, "dashboard.grafana.app", "dashboards", "b", "", 1, "b1")
+	write(resource.WatchEvent_DELETED, "dashboard.grafana.app", "dashboards", "b", "", utils.DeletedGeneration, "b1")
+	write(resource.WatchEvent_ADDED, "folder.grafana.app", "folders", "f1", "", 1, "f1")
+	write(resource.WatchEvent_ADDED, "playlist.grafana.app", "playlists", "p", "", 1, "p1")
+	write(resource.WatchEvent_DELETED, "playlist.grafana.app", "playlists", "p", "", utils.DeletedGeneration, "p1")
+
+	t.Run("latest versions", func(t *testing.T) {
+		dir := t.TempDir()
+		rsp, err := ExportNamespace(ctx, source, ExportOptions{Namespace: "source", Dir: dir})
+		require.NoError(t, err)
+		require.Equal(t, int64(2), rsp.Processed)
+		require.FileExists(t, filepath.Join(dir, "dashboard.grafana.app", "dashboards.parquet"))
+		require.FileExists(t, filepath.Join(dir, "folder.grafana.app", "folders.parquet"))
+		require.FileExists(t, filepath.Join(dir, "playlist.grafana.app", "playlists.parquet"))
+
+		target := newKVBackend(t)
+		rsp, err = ImportNamespace(ctx, target, ImportOptions{Namespace: "target", Dir: dir})
+		require.NoError(t, err)
+		require.Empty(t, rsp.Rejected)
+		require.Equal(t, int64(2), rsp.Processed)
+
+		read := target.ReadResource(ctx, &resource.ReadRequest{Key: &resource.ResourceKey{
+			Namespace: "target", Group: "dashboard.grafana.app", Resource: "dashboards", Name: "a",
+		}})
+		require.Nil(t, read.Error)
+		require.Equal(t, "f2", read.Folder)
+		obj := &unstructured.Unstructured{}
+		require.NoError(t, obj.UnmarshalJSON(read.Value))
+		require.Equal(t, "target", obj.GetNamespace())
+		require.Equal(t, map[string]any{"value": "a2"}, obj.Object["spec"])
+
+		// importing again replaces the existing resources
+		rsp, err = ImportNamespace(ctx, target, ImportOptions{Namespace: "target", Dir: dir})
+		require.NoError(t, err)
+		require.Len(t, rsp.Summary, 3)
+		require.Equal(t, int64(1), rsp.Summary[0].PreviousCount)
+		require.Equal(t, int64(1), rsp.Summary[0].Count)
+	})
+
+	t.Run("failed import", func(t *testing.T) {
+		dir := t.TempDir()
+		_, err := ExportNamespace(ctx, source, ExportOptions{Namespace: "source", Dir: dir})
+		require.NoError(t, err)
+
+		target := newKVBackend(t)
+		_, err = ImportNamespace(ctx, target, ImportOptions{Namespace: "target", Dir: dir})
+		require.NoError(t, err)
+
+		// the last file can't be read, the collections rebuilt before are rolled back
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, "zz.grafana.app"), 0750))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "zz.grafana.app", "broken.parquet"), []byte("broken"), 0600))
+		_, err = ImportNamespace(ctx, target, ImportOptions{Namespace: "target", Dir: dir})
+		require.Error(t, err)
+
+		read := target.ReadResource(ctx, &resource.ReadRequest{Key: &resource.ResourceKey{
+			Namespace: "target", Group: "dashboard.grafana.app", Resource: "dashboards", Name: "a",
+		}})
+		require.Nil(t, read.Error)
+	})
+
+	t.Run("with history", func(t *testing.T) {
+		dir := t.TempDir()
+		rsp, err := ExportNamespace(ctx, source, ExportOptions{Namespace: "source", Dir: dir, History: true})
+		require.NoError(t, err)
+		// the two versions of a, the deletion of b, f1 and the deletion of p
+		require.Equal(t, int64(5), rsp.Processed)
+
+		var actions []resource.BulkRequest_Action
+		var names []string
+		reader, err := NewParquetReader(filepath.Join(dir, "dashboard.grafana.app", "dashboards.parquet"), 10)
+		require.NoError(t, err)
+		for reader.Next() {
+			actions = append(actions, reader.Request().Action)
+			names = append(names, reader.Request().Key.Name)
+		}
+		require.Equal(t, []string{"b", "a", "a"}, names)
+		require.Equal(t, []resource.BulkRequest_Action{
+			resource.BulkRequest_DELETED,
+			resource.BulkRequest_ADDED,
+			resource.BulkRequest_MODIFIED,
+		}, actions)
+
+		target := newKVBackend(t)
+		_, err = ImportNamespace(ctx, target, ImportOptions{Namespace: "target", Dir: dir})
+		require.NoError(t, err)
+
+		var versions []string
+		_, err = target.ListIterator(ctx, &resource.ListRequest{
+			Source:         resource.ListRequest_HISTORY,
+			VersionMatchV2: resource.ResourceVersionMatchV2_NotOlderThan,
+			Options: &resource.ListOptions{Key: &resource.ResourceKey{
+				Namespace: "target", Group: "dashboard.grafana.app", Resource: "dashboards", Name: "a",
+			}},
+		}, func(iter resource.ListIterator) error {
+			for iter.Next() {
+				versions = append(versions, iter.Folder())
+			}
+			return iter.Error()
+		})
+		require.NoError(t, err)
+		require.Equal(t, []string{"f1", "f2"}, versions)
+
+		// the collections without any resource left are exported too
+		read := target.ReadResource(ctx, &resource.ReadRequest{Key: &resource.ResourceKey{
+			Namespace: "target", Group: "playlist.grafana.app", Resource: "playlists", Name: "p",
+		}, ResourceVersion: math.MaxInt64})
+		require.Nil(t, read.Error)
+	})
+}
+
+func newKVBackend(t *testing.T) kv.Backend {
+	t.Helper()
+
+	backend, err := kv.NewBackend(kv.BackendOptions{Path: filepath.Join(t.TempDir(), "resource.db")})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = backend.Stop(context.Background())
+	})
+	return backend
+}
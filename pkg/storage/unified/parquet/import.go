@@ -0,0 +1,181 @@
+package parquet
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/grafana/grafana/pkg/storage/unified/resource"
+)
+
+var (
+	_ resource.BulkRequestIterator = (*importIterator)(nil)
+)
+
+// ImportOptions configures the import of a namespace exported with ExportNamespace
+type ImportOptions struct {
+	// The namespace where the resources are imported, which can be different from the exported namespace
+	Namespace string
+
+	// The directory with the exported parquet files
+	Dir string
+
+	// The number of rows read at once from the parquet files
+	BatchSize int64
+}
+
+// ImportNamespace replaces the resources of a namespace with the ones exported in a directory.
+// The collections (group and resource) found in the export are rebuilt from the exported values
+// in a single bulk transaction, so a failed import leaves the existing resources untouched.
+func ImportNamespace(ctx context.Context, backend resource.StorageBackend, opts ImportOptions) (*resource.BulkResponse, error) {
+	if opts.Namespace == "" {
+		return nil, fmt.Errorf("missing namespace")
+	}
+	bulk, ok := backend.(resource.BulkProcessingBackend)
+	if !ok {
+		return nil, fmt.Errorf("the storage backend does not support bulk processing")
+	}
+	if opts.BatchSize < 1 {
+		opts.BatchSize = 50
+	}
+
+	collections, err := findCollections(opts.Dir, opts.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	iter := &importIterator{
+		ctx:         ctx,
+		collections: collections,
+		namespace:   opts.Namespace,
+		batchSize:   opts.BatchSize,
+	}
+	defer iter.close()
+
+	settings := resource.BulkSettings{RebuildCollection: true}
+	for _, c := range collections {
+		settings.Collection = append(settings.Collection, c.key)
+	}
+	rsp := bulk.ProcessBulk(ctx, settings, iter)
+
+	// a bulk process rolls back without an error when the iterator fails
+	if iter.err != nil {
+		return rsp, iter.err
+	}
+	return rsp, resource.GetError(rsp.Error)
+}
+
+type collectionFile struct {
+	key  *resource.ResourceKey
+	path string
+}
+
+// findCollections returns the files written by ExportNamespace, named {dir}/{group}/{resource}.parquet
+func findCollections(dir string, namespace string) ([]collectionFile, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*", "*.parquet"))
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no parquet files found in %s", dir)
+	}
+	sort.Strings(paths)
+
+	collections := make([]collectionFile, 0, len(paths))
+	for _, p := range paths {
+		collections = append(collections, collectionFile{
+			key: &resource.ResourceKey{
+				Namespace: namespace,
+				Group:     filepath.Base(filepath.Dir(p)),
+				Resource:  strings.TrimSuffix(filepath.Base(p), ".parquet"),
+			},
+			path: p,
+		})
+	}
+	return collections, nil
+}
+
+// importIterator reads the exported files one after the other, moving the values into the imported namespace
+type importIterator struct {
+	ctx         context.Context
+	collections []collectionFile
+	namespace   string
+	batchSize   int64
+
+	index   int
+	current *parquetReader
+	req     *resource.BulkRequest
+	err     error
+}
+
+// Next implements resource.BulkRequestIterator.
+func (i *importIterator) Next() bool {
+	i.req = nil
+	for i.err == nil && i.index < len(i.collections) {
+		if err := i.ctx.Err(); err != nil {
+			i.err = err
+			return false
+		}
+
+		collection := i.collections[i.index]
+		if i.current == nil {
+			i.current, i.err = newResourceReader(collection.path, i.batchSize)
+			if i.err != nil {
+				return false
+			}
+		}
+
+		if i.current.Next() {
+			req := i.current.Request()
+			if req.Key.Group != collection.key.Group || req.Key.Resource != collection.key.Resource {
+				i.err = fmt.Errorf("unexpected %s/%s value in %s", req.Key.Group, req.Key.Resource, collection.path)
+				return false
+			}
+			if req.Key.Namespace != i.namespace {
+				req.Key.Namespace = i.namespace
+				req.Value, i.err = setNamespace(req.Value, i.namespace)
+				if i.err != nil {
+					return false
+				}
+			}
+			i.req = req
+			return true
+		}
+		if i.current.err != nil {
+			i.err = fmt.Errorf("read %s: %w", collection.path, i.current.err)
+			return false
+		}
+		i.current = nil
+		i.index++
+	}
+	return false
+}
+
+// Request implements resource.BulkRequestIterator.
+func (i *importIterator) Request() *resource.BulkRequest {
+	return i.req
+}
+
+// RollbackRequested implements resource.BulkRequestIterator.
+func (i *importIterator) RollbackRequested() bool {
+	return i.err != nil
+}
+
+// close closes the current file when the import stopped before reading all the files
+func (i *importIterator) close() {
+	if i.current != nil && i.current.reader != nil {
+		_ = i.current.reader.Close()
+	}
+}
+
+func setNamespace(value []byte, namespace string) ([]byte, error) {
+	obj := &unstructured.Unstructured{}
+	if err := obj.UnmarshalJSON(value); err != nil {
+		return nil, err
+	}
+	obj.SetNamespace(namespace)
+	return obj.MarshalJSON()
+}
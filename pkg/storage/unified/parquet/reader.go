@@ -53,7 +53,6 @@ func (r *parquetReader) Next() bool {
 			if r.err != nil {
 				return false
 			}
-			r.bufferIndex = r.value.count
 		}
 
 		if r.bufferSize > r.bufferIndex {
@@ -147,6 +146,7 @@ func newResourceReader(inputPath string, batchSize int64) (*parquetReader, error
 		reader.name,
 		reader.action,
 		reader.value,
+		reader.folder,
 	}
 
 	// Empty file, close and return
@@ -71,19 +71,30 @@ func TestParquetWriteThenRead(t *testing.T) {
 		require.Equal(t, int64(3), res.Processed)
 
 		var keys []string
-		reader, err := newResourceReader(file.Name(), 20)
+		var actions []resource.BulkRequest_Action
+		var folders []string
+		reader, err := newResourceReader(file.Name(), 2) // smaller than the number of rows
 		require.NoError(t, err)
 		for reader.Next() {
 			req := reader.Request()
 			keys = append(keys, req.Key.SearchID())
+			actions = append(actions, req.Action)
+			folders = append(folders, req.Folder)
 		}
+		require.NoError(t, reader.err)
 
 		// Verify that we read all values
 		require.Equal(t, []string{
-			"rrr/ns/ggg/aaa",
-			"rrr/ns/ggg/bbb",
-			"rrr/ns/ggg/ccc",
+			"ns/ggg/rrr/aaa",
+			"ns/ggg/rrr/bbb",
+			"ns/ggg/rrr/ccc",
 		}, keys)
+		require.Equal(t, []resource.BulkRequest_Action{
+			resource.BulkRequest_ADDED,
+			resource.BulkRequest_DELETED,
+			resource.BulkRequest_MODIFIED,
+		}, actions)
+		require.Equal(t, []string{"xyz", "", ""}, folders)
 	})
 
 	t.Run("read-write-empty-db", func(t *testing.T) {
@@ -2,6 +2,7 @@ package parquet
 
 import (
 	"context"
+	"fmt"
 	"io"
 
 	"github.com/apache/arrow-go/v18/arrow"
@@ -47,16 +48,18 @@ func NewParquetWriter(f io.Writer) (*parquetWriter, error) {
 func (w *parquetWriter) ProcessBulk(ctx context.Context, setting resource.BulkSettings, iter resource.BulkRequestIterator) *resource.BulkResponse {
 	defer func() { _ = w.Close() }()
 
-	var err error
+	// a rolled back or failed bulk returns an error, so that the partial file is not used
+	var writeErr error
 	for iter.Next() {
 		if iter.RollbackRequested() {
+			writeErr = fmt.Errorf("rollback requested")
 			break
 		}
 
 		req := iter.Request()
 
-		err = w.Write(ctx, req.Key, req.Value)
-		if err != nil {
+		writeErr = w.Write(ctx, req.Key, req.Value)
+		if writeErr != nil {
 			break
 		}
 	}
@@ -68,6 +71,9 @@ func (w *parquetWriter) ProcessBulk(ctx context.Context, setting resource.BulkSe
 	if rsp == nil {
 		rsp = &resource.BulkResponse{}
 	}
+	if writeErr != nil {
+		err = writeErr
+	}
 	if err != nil {
 		rsp.Error = resource.AsErrorResult(err)
 	}
@@ -114,9 +120,9 @@ func (w *parquetWriter) flush() error {
 	w.logger.Info("flush", "count", w.rv.Len())
 	rec := array.NewRecord(w.schema, []arrow.Array{
 		w.rv.NewArray(),
-		w.namespace.NewArray(),
 		w.group.NewArray(),
 		w.resource.NewArray(),
+		w.namespace.NewArray(),
 		w.name.NewArray(),
 		w.folder.NewArray(),
 		w.action.NewArray(),
@@ -144,42 +150,36 @@ func (w *parquetWriter) init() error {
 }
 
 func (w *parquetWriter) Write(ctx context.Context, key *resource.ResourceKey, value []byte) error {
-	w.rsp.Processed++
-	obj := &unstructured.Unstructured{}
-	err := obj.UnmarshalJSON(value)
+	meta, err := metaAccessor(value)
 	if err != nil {
+		w.rsp.Processed++
 		return err
 	}
-	meta, err := utils.MetaAccessor(obj)
+	rv, _ := meta.GetResourceVersionInt64() // it can be empty
+	return w.write(key, rv, meta.GetFolder(), actionOf(meta), value)
+}
+
+// WriteVersion writes a value as returned by a storage backend, where the resource version and
+// folder are not read from the value as the backends don't save the resource version in it.
+func (w *parquetWriter) WriteVersion(ctx context.Context, key *resource.ResourceKey, rv int64, folder string, value []byte) error {
+	meta, err := metaAccessor(value)
 	if err != nil {
+		w.rsp.Processed++
 		return err
 	}
-	rv, _ := meta.GetResourceVersionInt64() // it can be empty
+	return w.write(key, rv, folder, actionOf(meta), value)
+}
 
+func (w *parquetWriter) write(key *resource.ResourceKey, rv int64, folder string, action resource.WatchEvent_Type, value []byte) error {
+	w.rsp.Processed++
 	w.rv.Append(rv)
 	w.namespace.Append(key.Namespace)
 	w.group.Append(key.Group)
 	w.resource.Append(key.Resource)
 	w.name.Append(key.Name)
-	w.folder.Append(meta.GetFolder())
-	w.value.Append(string(value))
-
-	var action resource.WatchEvent_Type
-	switch meta.GetGeneration() {
-	case 0, 1:
-		action = resource.WatchEvent_ADDED
-	case utils.DeletedGeneration:
-		action = resource.WatchEvent_DELETED
-	default:
-		action = resource.WatchEvent_MODIFIED
-	}
+	w.folder.Append(folder)
 	w.action.Append(int8(action))
-
-	w.wrote = w.wrote + len(value)
-	if w.wrote > w.buffer {
-		w.logger.Info("buffer full", "buffer", w.wrote, "max", w.buffer)
-		return w.flush()
-	}
+	w.value.Append(string(value))
 
 	summary := w.summary[key.NSGR()]
 	if summary == nil {
@@ -192,9 +192,36 @@ func (w *parquetWriter) Write(ctx context.Context, key *resource.ResourceKey, va
 		w.rsp.Summary = append(w.rsp.Summary, summary)
 	}
 	summary.Count++
+
+	w.wrote = w.wrote + len(value)
+	if w.wrote > w.buffer {
+		w.logger.Info("buffer full", "buffer", w.wrote, "max", w.buffer)
+		return w.flush()
+	}
 	return nil
 }
 
+func metaAccessor(value []byte) (utils.GrafanaMetaAccessor, error) {
+	obj := &unstructured.Unstructured{}
+	err := obj.UnmarshalJSON(value)
+	if err != nil {
+		return nil, err
+	}
+	return utils.MetaAccessor(obj)
+}
+
+// actionOf returns the action that wrote a value, which is only known from its generation
+func actionOf(meta utils.GrafanaMetaAccessor) resource.WatchEvent_Type {
+	switch meta.GetGeneration() {
+	case 0, 1:
+		return resource.WatchEvent_ADDED
+	case utils.DeletedGeneration:
+		return resource.WatchEvent_DELETED
+	default:
+		return resource.WatchEvent_MODIFIED
+	}
+}
+
 func newSchema(metadata *arrow.Metadata) *arrow.Schema {
 	return arrow.NewSchema([]arrow.Field{
 		{Name: "resource_version", Type: &arrow.Int64Type{}, Nullable: false},
@@ -6,8 +6,12 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 
 	"google.golang.org/grpc/metadata"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 
 	authlib "github.com/grafana/authlib/types"
 	"github.com/grafana/grafana/pkg/apimachinery/utils"
@@ -50,6 +54,7 @@ type BulkSettings struct {
 
 	// The batch will include everything from the collection
 	// - all existing values will be removed/replaced if the batch completes successfully
+	// Otherwise the requests are added to the collection, and checked with CheckBulkAppend
 	RebuildCollection bool
 
 	// The byte[] payload and folder has already been validated - no need to decode and verify
@@ -94,6 +99,37 @@ func NewBulkSettings(md metadata.MD) (BulkSettings, error) {
 	return settings, nil
 }
 
+// CheckBulkAppend checks a request of a bulk that does not rebuild its collection against the current resource
+// version, zero when the resource does not exist: an added resource must not exist yet, and a modified or deleted
+// one must exist. When the value has a resource version it must be the current one, like the resource version of
+// an update. It returns the value to save, without resource version.
+func CheckBulkAppend(req *BulkRequest, currentRV int64) ([]byte, error) {
+	gr := schema.GroupResource{Group: req.Key.Group, Resource: req.Key.Resource}
+	switch {
+	case req.Action == BulkRequest_ADDED && currentRV > 0:
+		return nil, apierrors.NewAlreadyExists(gr, req.Key.Name)
+	case req.Action != BulkRequest_ADDED && currentRV == 0:
+		return nil, apierrors.NewNotFound(gr, req.Key.Name)
+	}
+
+	obj := &unstructured.Unstructured{}
+	if err := obj.UnmarshalJSON(req.Value); err != nil {
+		return nil, fmt.Errorf("unable to unmarshal json: %w", err)
+	}
+	if obj.GetResourceVersion() == "" {
+		return req.Value, nil
+	}
+	rv, err := strconv.ParseInt(obj.GetResourceVersion(), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid resource version: %w", err)
+	}
+	if rv != currentRV {
+		return nil, apierrors.NewConflict(gr, req.Key.Name, ErrOptimisticLockingFailed)
+	}
+	obj.SetResourceVersion("")
+	return obj.MarshalJSON()
+}
+
 // BulkWrite implements ResourceServer.
 // All requests must be to the same NAMESPACE/GROUP/RESOURCE
 func (s *server) BulkProcess(stream BulkStore_BulkProcessServer) error {
@@ -118,7 +154,7 @@ func (s *server) BulkProcess(stream BulkStore_BulkProcessServer) error {
 		})
 	}
 	runner := &batchRunner{
-		checker: make(map[string]authlib.ItemChecker), // Can create
+		checker: make(map[string]authlib.ItemChecker), // By collection and verb
 		stream:  stream,
 	}
 	settings, err := NewBulkSettings(md)
@@ -141,6 +177,10 @@ func (s *server) BulkProcess(stream BulkStore_BulkProcessServer) error {
 		})
 	}
 
+	// Rebuilding a collection creates every resource again, otherwise each request
+	// is checked with the verb of its action
+	verbs := []string{utils.VerbCreate}
+	runner.rebuild = settings.RebuildCollection
 	if settings.RebuildCollection {
 		for _, k := range settings.Collection {
 			// Can we delete the whole collection
@@ -158,30 +198,29 @@ func (s *server) BulkProcess(stream BulkStore_BulkProcessServer) error {
 					},
 				})
 			}
+		}
+	} else {
+		verbs = append(verbs, utils.VerbUpdate, utils.VerbDelete)
+	}
 
+	for _, k := range settings.Collection {
+		for _, verb := range verbs {
 			// This will be called for each request -- with the folder ID
-			runner.checker[k.NSGR()], err = s.access.Compile(ctx, user, authlib.ListRequest{
+			runner.checker[k.NSGR()+"/"+verb], err = s.access.Compile(ctx, user, authlib.ListRequest{
 				Namespace: k.Namespace,
 				Group:     k.Group,
 				Resource:  k.Resource,
-				Verb:      utils.VerbCreate,
+				Verb:      verb,
 			})
 			if err != nil {
 				return stream.SendAndClose(&BulkResponse{
 					Error: &ErrorResult{
-						Message: "Unable to check `create` permission",
+						Message: fmt.Sprintf("Unable to check `%s` permission", verb),
 						Code:    http.StatusForbidden,
 					},
 				})
 			}
 		}
-	} else {
-		return stream.SendAndClose(&BulkResponse{
-			Error: &ErrorResult{
-				Message: "Bulk currently only supports RebuildCollection",
-				Code:    http.StatusBadRequest,
-			},
-		})
 	}
 
 	backend, ok := s.backend.(BulkProcessingBackend)
@@ -239,6 +278,7 @@ type batchRunner struct {
 	request  *BulkRequest
 	err      error
 	checker  map[string]authlib.ItemChecker
+	rebuild  bool
 }
 
 // Next implements BulkRequestIterator.
@@ -263,12 +303,13 @@ func (b *batchRunner) Next() bool {
 	if b.request != nil {
 		key := b.request.Key
 		k := key.NSGR()
-		checker, ok := b.checker[k]
+		verb := b.verb(b.request.Action)
+		checker, ok := b.checker[k+"/"+verb]
 		if !ok {
 			b.err = fmt.Errorf("missing access control for: %s", k)
 			b.rollback = true
 		} else if !checker(key.Name, b.request.Folder) {
-			b.err = fmt.Errorf("not allowed to create resource")
+			b.err = fmt.Errorf("not allowed to %s resource", verb)
 			b.rollback = true
 		}
 		return true
@@ -276,6 +317,21 @@ func (b *batchRunner) Next() bool {
 	return false
 }
 
+// verb returns the permission needed by a request
+func (b *batchRunner) verb(action BulkRequest_Action) string {
+	if b.rebuild {
+		return utils.VerbCreate
+	}
+	switch action {
+	case BulkRequest_MODIFIED:
+		return utils.VerbUpdate
+	case BulkRequest_DELETED:
+		return utils.VerbDelete
+	default:
+		return utils.VerbCreate
+	}
+}
+
 // Request implements BulkRequestIterator.
 func (b *batchRunner) Request() *BulkRequest {
 	if b.rollback {
@@ -42,6 +42,17 @@ type resourceClient struct {
 	BulkStoreClient
 	BlobStoreClient
 	DiagnosticsClient
+
+	// Set when the server is in the same process
+	indexStatus IndexStatusProvider
+}
+
+// IndexStatus implements IndexStatusProvider.
+func (c *resourceClient) IndexStatus(ctx context.Context) ([]IndexStatus, error) {
+	if c.indexStatus == nil {
+		return nil, ErrIndexStatusNotSupported
+	}
+	return c.indexStatus.IndexStatus(ctx)
 }
 
 func NewLegacyResourceClient(channel grpc.ClientConnInterface) ResourceClient {
@@ -86,7 +97,7 @@ func NewLocalResourceClient(server ResourceServer) ResourceClient {
 	)
 
 	cc := grpchan.InterceptClientConn(channel, clientInt.UnaryClientInterceptor, clientInt.StreamClientInterceptor)
-	return &resourceClient{
+	client := &resourceClient{
 		ResourceStoreClient:      NewResourceStoreClient(cc),
 		ResourceIndexClient:      NewResourceIndexClient(cc),
 		ManagedObjectIndexClient: NewManagedObjectIndexClient(cc),
@@ -94,6 +105,10 @@ func NewLocalResourceClient(server ResourceServer) ResourceClient {
 		BlobStoreClient:          NewBlobStoreClient(cc),
 		DiagnosticsClient:        NewDiagnosticsClient(cc),
 	}
+	if provider, ok := server.(IndexStatusProvider); ok {
+		client.indexStatus = provider
+	}
+	return client
 }
 
 type RemoteResourceClientConfig struct {
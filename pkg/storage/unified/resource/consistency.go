@@ -0,0 +1,31 @@
+package resource
+
+import "context"
+
+type contextMinResourceVersionKey struct{}
+
+// WithMinResourceVersion allows the reads of the latest version of resources to return data
+// at least as recent as the resource version, for example from a read replica of the database.
+func WithMinResourceVersion(ctx context.Context, rv int64) context.Context {
+	return context.WithValue(ctx, contextMinResourceVersionKey{}, rv)
+}
+
+// MinResourceVersion returns the resource version set with WithMinResourceVersion
+func MinResourceVersion(ctx context.Context) (int64, bool) {
+	rv, ok := ctx.Value(contextMinResourceVersionKey{}).(int64)
+	return rv, ok && rv > 0
+}
+
+type contextStrongConsistencyKey struct{}
+
+// WithStrongConsistency requires the reads to return the latest committed data, for example from the primary
+// database instead of a read replica. The reads of the resources about to be written use it.
+func WithStrongConsistency(ctx context.Context) context.Context {
+	return context.WithValue(ctx, contextStrongConsistencyKey{}, true)
+}
+
+// StrongConsistency reports whether the context was created with WithStrongConsistency
+func StrongConsistency(ctx context.Context) bool {
+	strong, _ := ctx.Value(contextStrongConsistencyKey{}).(bool)
+	return strong
+}
@@ -0,0 +1,333 @@
+package resource
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const defaultQuotaRefreshInterval = time.Minute
+
+// QuotaLimits caps the objects stored in a namespace, zero or negative values are not limited
+type QuotaLimits struct {
+	// Maximum number of objects
+	MaxObjects int64
+	// Maximum total size of the objects in bytes
+	MaxBytes int64
+	// Maximum size of a single object in bytes
+	MaxObjectSize int64
+}
+
+func (l QuotaLimits) isZero() bool {
+	return l == QuotaLimits{}
+}
+
+// QuotaOptions configures the quotas enforced when writing resources
+type QuotaOptions struct {
+	// Limits of all the resources of a namespace together
+	Namespace QuotaLimits
+
+	// Limits of each resource of a namespace
+	Default QuotaLimits
+
+	// Limits of specific resources, replacing the defaults
+	Resources map[schema.GroupResource]QuotaLimits
+
+	// How long the usage of a namespace is trusted before being counted again.
+	// The usage is updated by the writes of this server, so the interval bounds
+	// how long the writes of the other servers are ignored.
+	RefreshInterval time.Duration
+}
+
+func (o QuotaOptions) enabled() bool {
+	if !o.Namespace.isZero() || !o.Default.isZero() {
+		return true
+	}
+	for _, l := range o.Resources {
+		if !l.isZero() {
+			return true
+		}
+	}
+	return false
+}
+
+// limitsBytes returns whether a quota limits the total size of the objects
+func (o QuotaOptions) limitsBytes() bool {
+	if o.Namespace.MaxBytes > 0 || o.Default.MaxBytes > 0 {
+		return true
+	}
+	for _, l := range o.Resources {
+		if l.MaxBytes > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (o QuotaOptions) limits(group, resource string) QuotaLimits {
+	if l, ok := o.Resources[schema.GroupResource{Group: group, Resource: resource}]; ok {
+		return l
+	}
+	return o.Default
+}
+
+type quotaCount struct {
+	count int64
+	bytes int64
+}
+
+type namespaceUsage struct {
+	mu        sync.Mutex
+	counted   time.Time
+	resources map[schema.GroupResource]*quotaCount
+	// The changes made while the usage is counted, added to the new counts
+	pending map[schema.GroupResource]*quotaCount
+}
+
+func (u *namespaceUsage) total() quotaCount {
+	total := quotaCount{}
+	for _, c := range u.resources {
+		total.count += c.count
+		total.bytes += c.bytes
+	}
+	return total
+}
+
+func (u *namespaceUsage) resource(group, resource string) *quotaCount {
+	gr := schema.GroupResource{Group: group, Resource: resource}
+	c, ok := u.resources[gr]
+	if !ok {
+		c = &quotaCount{}
+		u.resources[gr] = c
+	}
+	return c
+}
+
+// add updates the usage, and the pending changes when the usage is being counted.
+// It must be called with the lock held.
+func (u *namespaceUsage) add(group, resource string, objects, bytes int64) {
+	gr := schema.GroupResource{Group: group, Resource: resource}
+	if u.pending != nil {
+		p, ok := u.pending[gr]
+		if !ok {
+			p = &quotaCount{}
+			u.pending[gr] = p
+		}
+		p.count += objects
+		p.bytes += bytes
+	}
+	if u.resources != nil {
+		c := u.resource(group, resource)
+		c.count = max(c.count+objects, 0)
+		c.bytes = max(c.bytes+bytes, 0)
+	}
+}
+
+// quotas tracks the usage of the namespaces, and rejects the writes going over the limits
+type quotas struct {
+	opts    QuotaOptions
+	backend StorageBackend
+	now     func() time.Time
+
+	mu         sync.Mutex
+	namespaces map[string]*namespaceUsage
+
+	// Counts each namespace once, even when many writes need it at the same time
+	counting singleflight.Group
+}
+
+func newQuotas(opts QuotaOptions, backend StorageBackend) *quotas {
+	if !opts.enabled() {
+		return nil
+	}
+	if opts.RefreshInterval <= 0 {
+		opts.RefreshInterval = defaultQuotaRefreshInterval
+	}
+	return &quotas{
+		opts:       opts,
+		backend:    backend,
+		now:        time.Now,
+		namespaces: map[string]*namespaceUsage{},
+	}
+}
+
+// usage returns the usage of a namespace, which is counted by count
+func (q *quotas) usage(namespace string) *namespaceUsage {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	u, ok := q.namespaces[namespace]
+	if !ok {
+		u = &namespaceUsage{}
+		q.namespaces[namespace] = u
+	}
+	return u
+}
+
+// count returns the usage of a namespace, counted again from the resource stats when it is older
+// than the refresh interval. The lock of the usage is not held while reading the stats, the writes
+// made in the meantime are added to the new counts.
+func (q *quotas) count(ctx context.Context, namespace string) (*namespaceUsage, error) {
+	u := q.usage(namespace)
+	u.mu.Lock()
+	fresh := u.resources != nil && q.now().Sub(u.counted) < q.opts.RefreshInterval
+	u.mu.Unlock()
+	if fresh {
+		return u, nil
+	}
+
+	_, err, _ := q.counting.Do(namespace, func() (any, error) {
+		u.mu.Lock()
+		u.pending = map[schema.GroupResource]*quotaCount{}
+		u.mu.Unlock()
+
+		stats, err := q.stats(ctx, namespace)
+
+		u.mu.Lock()
+		defer u.mu.Unlock()
+		pending := u.pending
+		u.pending = nil
+		if err != nil {
+			return nil, err
+		}
+		resources := make(map[schema.GroupResource]*quotaCount, len(stats))
+		for _, stat := range stats {
+			resources[schema.GroupResource{Group: stat.Group, Resource: stat.Resource}] = &quotaCount{count: stat.Count, bytes: stat.Size}
+		}
+		for gr, p := range pending {
+			c, ok := resources[gr]
+			if !ok {
+				c = &quotaCount{}
+				resources[gr] = c
+			}
+			c.count = max(c.count+p.count, 0)
+			c.bytes = max(c.bytes+p.bytes, 0)
+		}
+		u.resources = resources
+		u.counted = q.now()
+		return nil, nil
+	})
+	return u, err
+}
+
+// stats returns the usage of the resources of a namespace. The sizes are only summed when a quota limits them,
+// and the backend can sum them, as it reads every value. Otherwise only the writes of the server are added up.
+func (q *quotas) stats(ctx context.Context, namespace string) ([]ResourceStats, error) {
+	if sizes, ok := q.backend.(ResourceSizeStatsGetter); ok && q.opts.limitsBytes() {
+		return sizes.GetResourceSizeStats(ctx, namespace)
+	}
+	return q.backend.GetResourceStats(ctx, namespace, 0)
+}
+
+// reserve checks that a write fits in the quotas, and adds it to the usage.
+// The reservation must be released with add when the write fails.
+func (q *quotas) reserve(ctx context.Context, key *ResourceKey, objects, bytes, size int64) *ErrorResult {
+	if q == nil {
+		return nil
+	}
+	limits := q.opts.limits(key.Group, key.Resource)
+	gr := schema.GroupResource{Group: key.Group, Resource: key.Resource}
+	for _, maxSize := range []int64{limits.MaxObjectSize, q.opts.Namespace.MaxObjectSize} {
+		if maxSize > 0 && size > maxSize {
+			return &ErrorResult{
+				Code:    http.StatusRequestEntityTooLarge,
+				Reason:  string(metav1.StatusReasonRequestEntityTooLarge),
+				Message: fmt.Sprintf("%s %q is too large: %d bytes, limited: %d bytes", gr.String(), key.Name, size, maxSize),
+				Details: &ErrorDetails{Group: key.Group, Kind: key.Resource, Name: key.Name},
+			}
+		}
+	}
+	if objects <= 0 && bytes <= 0 {
+		// Nothing grows, no limit can be exceeded
+		q.add(key, objects, bytes)
+		return nil
+	}
+
+	u, err := q.count(ctx, key.Namespace)
+	if err != nil {
+		// Failing open would ignore the quotas, let the client retry instead
+		return &ErrorResult{
+			Code:    http.StatusTooManyRequests,
+			Reason:  string(metav1.StatusReasonTooManyRequests),
+			Message: fmt.Sprintf("unable to count the usage of the quota: %v", err),
+			Details: &ErrorDetails{Group: key.Group, Kind: key.Resource, Name: key.Name, RetryAfterSeconds: 1},
+		}
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	c := u.resource(key.Group, key.Resource)
+	if e := exceeded(gr.String(), key, limits, *c, objects, bytes); e != nil {
+		return e
+	}
+	if e := exceeded("namespace "+key.Namespace, key, q.opts.Namespace, u.total(), objects, bytes); e != nil {
+		return e
+	}
+	u.add(key.Group, key.Resource, objects, bytes)
+	return nil
+}
+
+// exceeded returns a forbidden error, like the Kubernetes resource quotas, when the usage goes over a limit
+func exceeded(quota string, key *ResourceKey, limits QuotaLimits, used quotaCount, objects, bytes int64) *ErrorResult {
+	var msg string
+	switch {
+	case objects > 0 && limits.MaxObjects > 0 && used.count+objects > limits.MaxObjects:
+		msg = fmt.Sprintf("exceeded quota: %s, requested: count=%d, used: count=%d, limited: count=%d", quota, objects, used.count, limits.MaxObjects)
+	case bytes > 0 && limits.MaxBytes > 0 && used.bytes+bytes > limits.MaxBytes:
+		msg = fmt.Sprintf("exceeded quota: %s, requested: bytes=%d, used: bytes=%d, limited: bytes=%d", quota, bytes, used.bytes, limits.MaxBytes)
+	default:
+		return nil
+	}
+	return &ErrorResult{
+		Code:    http.StatusForbidden,
+		Reason:  string(metav1.StatusReasonForbidden),
+		Message: msg,
+		Details: &ErrorDetails{Group: key.Group, Kind: key.Resource, Name: key.Name},
+	}
+}
+
+// add updates the usage, releasing a reservation with the negative values
+func (q *quotas) add(key *ResourceKey, objects, bytes int64) {
+	if q == nil || (objects == 0 && bytes == 0) {
+		return
+	}
+	u := q.usage(key.Namespace)
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	// When not counted yet, the next count includes the write
+	u.add(key.Group, key.Resource, objects, bytes)
+}
+
+// report adds the usage of the quotas to the stats of a namespace
+func (q *quotas) report(ctx context.Context, namespace string, rsp *ResourceStatsResponse) error {
+	if q == nil || namespace == "" {
+		return nil
+	}
+	u, err := q.count(ctx, namespace)
+	if err != nil {
+		return err
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	for _, stat := range rsp.Stats {
+		limits := q.opts.limits(stat.Group, stat.Resource)
+		stat.Quota = quotaUsage(limits, *u.resource(stat.Group, stat.Resource))
+	}
+	rsp.NamespaceQuota = quotaUsage(q.opts.Namespace, u.total())
+	return nil
+}
+
+func quotaUsage(limits QuotaLimits, used quotaCount) *QuotaUsage {
+	return &QuotaUsage{
+		Count:         used.count,
+		Bytes:         used.bytes,
+		MaxCount:      limits.MaxObjects,
+		MaxBytes:      limits.MaxBytes,
+		MaxObjectSize: limits.MaxObjectSize,
+	}
+}
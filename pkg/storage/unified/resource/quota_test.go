@@ -0,0 +1,237 @@
+package resource
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gocloud.dev/blob/memblob"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	claims "github.com/grafana/authlib/types"
+	"github.com/grafana/grafana/pkg/apimachinery/identity"
+)
+
+// The CDK backend does not count the resources, the stats are counted from the listed values
+type quotaTestBackend struct {
+	StorageBackend
+	UnimplementedResourceIndexServer
+	stats []ResourceStats
+}
+
+func (b *quotaTestBackend) GetResourceStats(ctx context.Context, namespace string, minCount int) ([]ResourceStats, error) {
+	return b.count(ctx, false)
+}
+
+func (b *quotaTestBackend) GetResourceSizeStats(ctx context.Context, namespace string) ([]ResourceStats, error) {
+	return b.count(ctx, true)
+}
+
+func (b *quotaTestBackend) count(ctx context.Context, sizes bool) ([]ResourceStats, error) {
+	stats := make([]ResourceStats, 0, len(b.stats))
+	for _, s := range b.stats {
+		_, err := b.ListIterator(ctx, &ListRequest{
+			Options: &ListOptions{
+				Key: &ResourceKey{Namespace: s.Namespace, Group: s.Group, Resource: s.Resource},
+			},
+		}, func(iter ListIterator) error {
+			for iter.Next() {
+				s.Count++
+				if sizes {
+					s.Size += int64(len(iter.Value()))
+				}
+			}
+			return iter.Error()
+		})
+		if err != nil {
+			return nil, err
+		}
+		stats = append(stats, s)
+	}
+	return stats, nil
+}
+
+func (b *quotaTestBackend) GetStats(ctx context.Context, req *ResourceStatsRequest) (*ResourceStatsResponse, error) {
+	rsp := &ResourceStatsResponse{}
+	for _, s := range b.stats {
+		rsp.Stats = append(rsp.Stats, &ResourceStatsResponse_Stats{Group: s.Group, Resource: s.Resource, Count: s.Count})
+	}
+	return rsp, nil
+}
+
+func TestQuotas(t *testing.T) {
+	ctx := claims.WithAuthInfo(context.Background(), &identity.StaticRequester{
+		Type:           claims.TypeUser,
+		UserID:         1,
+		UserUID:        "u1",
+		OrgRole:        identity.RoleAdmin,
+		IsGrafanaAdmin: true,
+	})
+	store, err := NewCDKBackend(ctx, CDKBackendOptions{Bucket: memblob.OpenBucket(nil)})
+	require.NoError(t, err)
+
+	playlists := NamespacedResource{Namespace: "default", Group: "playlist.grafana.app", Resource: "playlists"}
+	folders := NamespacedResource{Namespace: "default", Group: "folder.grafana.app", Resource: "folders"}
+	backend := &quotaTestBackend{StorageBackend: store, stats: []ResourceStats{{NamespacedResource: playlists}, {NamespacedResource: folders}}}
+
+	server, err := NewResourceServer(ResourceServerOptions{
+		Backend: backend,
+		Quotas: QuotaOptions{
+			Namespace: QuotaLimits{MaxObjects: 3},
+			Default:   QuotaLimits{MaxObjectSize: 1000},
+			Resources: map[schema.GroupResource]QuotaLimits{
+				{Group: "playlist.grafana.app", Resource: "playlists"}: {MaxObjects: 2, MaxBytes: 600},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	key := func(nr NamespacedResource, name string) *ResourceKey {
+		return &ResourceKey{Namespace: nr.Namespace, Group: nr.Group, Resource: nr.Resource, Name: name}
+	}
+	value := func(nr NamespacedResource, name, title string) []byte {
+		return fmt.Appendf(nil, `{"apiVersion":"%s/v0alpha1","kind":"Thing","metadata":{"name":"%s","uid":"%s","namespace":"default"},"spec":{"title":"%s"}}`,
+			nr.Group, name, name, title)
+	}
+
+	created, err := server.Create(ctx, &CreateRequest{Key: key(playlists, "a"), Value: value(playlists, "a", "A")})
+	require.NoError(t, err)
+	require.Nil(t, created.Error)
+	rsp, err := server.Create(ctx, &CreateRequest{Key: key(playlists, "b"), Value: value(playlists, "b", "B")})
+	require.NoError(t, err)
+	require.Nil(t, rsp.Error)
+
+	t.Run("the number of objects of a resource is limited", func(t *testing.T) {
+		rsp, err := server.Create(ctx, &CreateRequest{Key: key(playlists, "c"), Value: value(playlists, "c", "C")})
+		require.NoError(t, err)
+		require.NotNil(t, rsp.Error)
+		require.Equal(t, int32(http.StatusForbidden), rsp.Error.Code)
+		require.Contains(t, rsp.Error.Message, "exceeded quota: playlists.playlist.grafana.app")
+	})
+
+	t.Run("the total size of a resource is limited", func(t *testing.T) {
+		big := value(playlists, "a", strings.Repeat("x", 400))
+		rsp, err := server.Update(ctx, &UpdateRequest{Key: key(playlists, "a"), Value: big, ResourceVersion: created.ResourceVersion})
+		require.NoError(t, err)
+		require.NotNil(t, rsp.Error)
+		require.Equal(t, int32(http.StatusForbidden), rsp.Error.Code)
+		require.Contains(t, rsp.Error.Message, "bytes=")
+	})
+
+	t.Run("the size of an object is limited", func(t *testing.T) {
+		rsp, err := server.Create(ctx, &CreateRequest{Key: key(folders, "big"), Value: value(folders, "big", strings.Repeat("x", 1000))})
+		require.NoError(t, err)
+		require.NotNil(t, rsp.Error)
+		require.Equal(t, int32(http.StatusRequestEntityTooLarge), rsp.Error.Code)
+	})
+
+	t.Run("the number of objects of a namespace is limited", func(t *testing.T) {
+		rsp, err := server.Create(ctx, &CreateRequest{Key: key(folders, "f1"), Value: value(folders, "f1", "F1")})
+		require.NoError(t, err)
+		require.Nil(t, rsp.Error)
+
+		rsp, err = server.Create(ctx, &CreateRequest{Key: key(folders, "f2"), Value: value(folders, "f2", "F2")})
+		require.NoError(t, err)
+		require.NotNil(t, rsp.Error)
+		require.Equal(t, int32(http.StatusForbidden), rsp.Error.Code)
+		require.Contains(t, rsp.Error.Message, "exceeded quota: namespace default")
+	})
+
+	t.Run("deleted objects are released", func(t *testing.T) {
+		deleted, err := server.Delete(ctx, &DeleteRequest{Key: key(folders, "f1")})
+		require.NoError(t, err)
+		require.Nil(t, deleted.Error)
+
+		rsp, err := server.Create(ctx, &CreateRequest{Key: key(folders, "f2"), Value: value(folders, "f2", "F2")})
+		require.NoError(t, err)
+		require.Nil(t, rsp.Error)
+	})
+
+	t.Run("the usage is reported in the stats", func(t *testing.T) {
+		stats, err := server.GetStats(ctx, &ResourceStatsRequest{Namespace: "default"})
+		require.NoError(t, err)
+		require.Nil(t, stats.Error)
+		require.Len(t, stats.Stats, 2)
+		require.Equal(t, int64(2), stats.Stats[0].Quota.Count)
+		require.Equal(t, int64(2), stats.Stats[0].Quota.MaxCount)
+		require.Equal(t, int64(600), stats.Stats[0].Quota.MaxBytes)
+		require.Positive(t, stats.Stats[0].Quota.Bytes)
+		require.Equal(t, int64(1), stats.Stats[1].Quota.Count)
+		require.Equal(t, int64(1000), stats.Stats[1].Quota.MaxObjectSize)
+		require.Equal(t, int64(3), stats.NamespaceQuota.Count)
+		require.Equal(t, int64(3), stats.NamespaceQuota.MaxCount)
+	})
+}
+
+// Blocks the stats until released
+type blockingStatsBackend struct {
+	StorageBackend
+	called  chan struct{}
+	release chan []ResourceStats
+}
+
+func (b *blockingStatsBackend) GetResourceStats(ctx context.Context, namespace string, minCount int) ([]ResourceStats, error) {
+	b.called <- struct{}{}
+	return <-b.release, nil
+}
+
+func TestQuotasCount(t *testing.T) {
+	playlists := NamespacedResource{Namespace: "default", Group: "playlist.grafana.app", Resource: "playlists"}
+	key := &ResourceKey{Namespace: playlists.Namespace, Group: playlists.Group, Resource: playlists.Resource, Name: "a"}
+	backend := &blockingStatsBackend{called: make(chan struct{}), release: make(chan []ResourceStats)}
+	q := newQuotas(QuotaOptions{Default: QuotaLimits{MaxObjects: 10}}, backend)
+
+	reserved := make(chan *ErrorResult)
+	go func() {
+		reserved <- q.reserve(context.Background(), key, 1, 10, 10)
+	}()
+	<-backend.called
+
+	// The writes are not blocked while the usage is counted, and are added to the new counts
+	q.add(key, 1, 5)
+	backend.release <- []ResourceStats{{NamespacedResource: playlists, Count: 2, Size: 20}}
+	require.Nil(t, <-reserved)
+
+	u := q.usage("default")
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	require.Nil(t, u.pending)
+	require.Equal(t, quotaCount{count: 4, bytes: 35}, *u.resource(playlists.Group, playlists.Resource))
+}
+
+// Records the stats read by the quotas
+type sizeStatsBackend struct {
+	StorageBackend
+	calls []string
+}
+
+func (b *sizeStatsBackend) GetResourceStats(ctx context.Context, namespace string, minCount int) ([]ResourceStats, error) {
+	b.calls = append(b.calls, "GetResourceStats")
+	return nil, nil
+}
+
+func (b *sizeStatsBackend) GetResourceSizeStats(ctx context.Context, namespace string) ([]ResourceStats, error) {
+	b.calls = append(b.calls, "GetResourceSizeStats")
+	return nil, nil
+}
+
+func TestQuotasStats(t *testing.T) {
+	key := &ResourceKey{Namespace: "default", Group: "playlist.grafana.app", Resource: "playlists", Name: "a"}
+
+	t.Run("only count the objects without size quota", func(t *testing.T) {
+		backend := &sizeStatsBackend{}
+		q := newQuotas(QuotaOptions{Default: QuotaLimits{MaxObjects: 10, MaxObjectSize: 100}}, backend)
+		require.Nil(t, q.reserve(context.Background(), key, 1, 10, 10))
+		require.Equal(t, []string{"GetResourceStats"}, backend.calls)
+	})
+
+	t.Run("sum the sizes with a size quota", func(t *testing.T) {
+		backend := &sizeStatsBackend{}
+		q := newQuotas(QuotaOptions{Namespace: QuotaLimits{MaxBytes: 1000}}, backend)
+		require.Nil(t, q.reserve(context.Background(), key, 1, 10, 10))
+		require.Equal(t, []string{"GetResourceSizeStats"}, backend.calls)
+	})
+}
@@ -54,6 +54,17 @@ type ResourceIndex interface {
 
 	// Get the number of documents in the index
 	DocCount(ctx context.Context, folder string) (int64, error)
+
+	// The resource version of every indexed document, by name.
+	// Used to only index the changed documents when an index is loaded from disk
+	DocumentVersions(ctx context.Context) (map[string]int64, error)
+
+	// Record the resource version of the last indexed change.
+	// File based indexes persist it, so they are updated instead of rebuilt on startup
+	UpdateResourceVersion(rv int64) error
+
+	// The resource version of the last indexed change, zero for a new index
+	ResourceVersion() int64
 }
 
 // SearchBackend contains the technology specific logic to support search
@@ -81,6 +92,12 @@ type SearchBackend interface {
 
 	// Gets the total number of documents across all indexes
 	TotalDocs() int64
+
+	// The status of the indexes, including the ones being built
+	IndexStatus(ctx context.Context) []IndexStatus
+
+	// Stop the background tasks, and save the state of the indexes
+	Stop()
 }
 
 const tracingPrexfixSearch = "unified_search."
@@ -379,22 +396,28 @@ func (s *searchSupport) init(ctx context.Context) error {
 	}
 	span.AddEvent("namespaces indexed", trace.WithAttributes(attribute.Int("namespaced_indexed", totalBatchesIndexed)))
 
-	// Now start listening for new events
-	watchctx := context.Background() // new context?
-	events, err := s.storage.WatchWriteEvents(watchctx)
+	// Now start listening for new events, until the server stops
+	events, err := s.storage.WatchWriteEvents(ctx)
 	if err != nil {
 		return err
 	}
 	go func() {
 		for {
-			v := <-events
+			select {
+			case <-ctx.Done():
+				return
+			case v, ok := <-events:
+				if !ok {
+					return
+				}
 
-			// Skip events during batch updates
-			if v.PreviousRV < 0 {
-				continue
-			}
+				// Skip events during batch updates
+				if v.PreviousRV < 0 {
+					continue
+				}
 
-			s.handleEvent(watchctx, v)
+				s.handleEvent(ctx, v)
+			}
 		}
 	}()
 
@@ -477,6 +500,10 @@ func (s *searchSupport) handleEvent(ctx context.Context, evt *WrittenEvent) {
 		s.log.Warn("unknown watch event", "type", evt.Type)
 	}
 
+	if err = index.UpdateResourceVersion(evt.ResourceVersion); err != nil {
+		s.log.Warn("error updating index resource version", "error", err)
+	}
+
 	// record latency from when event was created to when it was indexed
 	latencySeconds := float64(time.Now().UnixMicro()-evt.ResourceVersion) / 1e6
 	span.AddEvent("index latency", trace.WithAttributes(attribute.Float64("latency_seconds", latencySeconds)))
@@ -535,7 +562,25 @@ func (s *searchSupport) build(ctx context.Context, nsr NamespacedResource, size
 		Namespace: nsr.Namespace,
 	}
 	index, err := s.search.BuildIndex(ctx, nsr, size, rv, fields, func(index ResourceIndex) (int64, error) {
-		rv, err = s.storage.ListIterator(ctx, &ListRequest{
+		// An index loaded from disk is updated with the changes made since it was saved
+		if since := index.ResourceVersion(); since > 0 {
+			if lister, ok := s.storage.(ModifiedSinceLister); ok {
+				return s.indexModifiedSince(ctx, lister, index, builder, nsr, since)
+			}
+		}
+
+		// Otherwise all the resources are compared with the indexed documents, only the changed ones are indexed again
+		indexed, err := index.DocumentVersions(ctx)
+		if err != nil {
+			return 0, err
+		}
+
+		// Data at least as recent as rv is enough, later changes are indexed like the ones made while building
+		listCtx := ctx
+		if rv > 0 {
+			listCtx = WithMinResourceVersion(ctx, rv)
+		}
+		rv, err = s.storage.ListIterator(listCtx, &ListRequest{
 			Limit: 1000000000000, // big number
 			Options: &ListOptions{
 				Key: key,
@@ -550,6 +595,13 @@ func (s *searchSupport) build(ctx context.Context, nsr NamespacedResource, size
 				// Or should we read it from the body?
 				key.Name = iter.Name()
 
+				if v, ok := indexed[key.Name]; ok {
+					delete(indexed, key.Name)
+					if v == iter.ResourceVersion() {
+						continue // unchanged
+					}
+				}
+
 				// Convert it to an indexable document
 				doc, err := builder.BuildDocument(ctx, key, iter.ResourceVersion(), iter.Value())
 				if err != nil {
@@ -564,7 +616,17 @@ func (s *searchSupport) build(ctx context.Context, nsr NamespacedResource, size
 			}
 			return iter.Error()
 		})
-		return rv, err
+		if err != nil {
+			return rv, err
+		}
+
+		// The documents left were deleted since the index was saved
+		for name := range indexed {
+			if err = index.Delete(&ResourceKey{Namespace: nsr.Namespace, Group: nsr.Group, Resource: nsr.Resource, Name: name}); err != nil {
+				return rv, err
+			}
+		}
+		return rv, nil
 	})
 
 	if err != nil {
@@ -588,6 +650,22 @@ func (s *searchSupport) build(ctx context.Context, nsr NamespacedResource, size
 	return index, rv, err
 }
 
+// indexModifiedSince updates an index with the resources changed after its resource version
+func (s *searchSupport) indexModifiedSince(ctx context.Context, lister ModifiedSinceLister, index ResourceIndex, builder DocumentBuilder, nsr NamespacedResource, since int64) (int64, error) {
+	rv, err := lister.ListModifiedSince(ctx, nsr, since, func(m *ModifiedResource) error {
+		if m.Action == WatchEvent_DELETED {
+			return index.Delete(m.Key)
+		}
+		doc, err := builder.BuildDocument(ctx, m.Key, m.ResourceVersion, m.Value)
+		if err != nil {
+			s.log.Error("error building search document", "key", m.Key.SearchID(), "err", err)
+			return nil
+		}
+		return index.Write(doc)
+	})
+	return max(rv, since), err
+}
+
 type builderCache struct {
 	// The default builder
 	defaultBuilder DocumentBuilder
@@ -0,0 +1,104 @@
+package resource
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrIndexStatusNotSupported is returned when the search indexes are not in this process
+var ErrIndexStatusNotSupported = errors.New("index status is only available with an in-process search server")
+
+type IndexStorage string
+
+const (
+	IndexStorageFile   IndexStorage = "file"
+	IndexStorageMemory IndexStorage = "memory"
+)
+
+type IndexBuildState string
+
+const (
+	IndexBuildStateBuilding IndexBuildState = "building"
+	IndexBuildStateReady    IndexBuildState = "ready"
+	IndexBuildStateFailed   IndexBuildState = "failed"
+)
+
+type IndexBuildMode string
+
+const (
+	// All the documents were written to a new index
+	IndexBuildModeFull IndexBuildMode = "full"
+	// An index was loaded from disk, and only the changed documents were written
+	IndexBuildModeIncremental IndexBuildMode = "incremental"
+	// An index was loaded from disk, and nothing changed since it was saved
+	IndexBuildModeReused IndexBuildMode = "reused"
+)
+
+// IndexStatus describes a search index
+type IndexStatus struct {
+	Namespace string       `json:"namespace"`
+	Group     string       `json:"group"`
+	Resource  string       `json:"resource"`
+	Storage   IndexStorage `json:"storage"`
+
+	Documents int64 `json:"documents"`
+	SizeBytes int64 `json:"sizeBytes"`
+
+	// The resource version of the last indexed change
+	ResourceVersion int64 `json:"resourceVersion"`
+	// The latest resource version in the storage
+	LatestResourceVersion int64 `json:"latestResourceVersion,omitempty"`
+	// How far the index is behind the storage
+	LagSeconds float64 `json:"lagSeconds"`
+
+	Build          IndexBuildStatus `json:"build"`
+	LastCompaction *time.Time       `json:"lastCompaction,omitempty"`
+}
+
+// IndexBuildStatus describes the last (or current) build of an index
+type IndexBuildStatus struct {
+	State    IndexBuildState `json:"state"`
+	Mode     IndexBuildMode  `json:"mode,omitempty"`
+	Started  time.Time       `json:"started"`
+	Duration string          `json:"duration,omitempty"`
+
+	// The number of documents written and deleted by the build
+	Updated int64 `json:"updated"`
+	Deleted int64 `json:"deleted"`
+
+	Error string `json:"error,omitempty"`
+}
+
+// IndexStatusProvider returns the status of the search indexes
+type IndexStatusProvider interface {
+	IndexStatus(ctx context.Context) ([]IndexStatus, error)
+}
+
+// IndexStatus adds the lag behind the storage to the status reported by the search backend
+func (s *searchSupport) IndexStatus(ctx context.Context) ([]IndexStatus, error) {
+	status := s.search.IndexStatus(ctx)
+	if len(status) == 0 {
+		return status, nil
+	}
+
+	stats, err := s.storage.GetResourceStats(ctx, "", 0)
+	if err != nil {
+		return nil, err
+	}
+	latest := make(map[NamespacedResource]int64, len(stats))
+	for _, s := range stats {
+		latest[s.NamespacedResource] = s.ResourceVersion
+	}
+	for i := range status {
+		rv, ok := latest[NamespacedResource{Namespace: status[i].Namespace, Group: status[i].Group, Resource: status[i].Resource}]
+		if !ok {
+			continue
+		}
+		status[i].LatestResourceVersion = rv
+		if rv > status[i].ResourceVersion && status[i].ResourceVersion > 0 {
+			status[i].LagSeconds = float64(rv-status[i].ResourceVersion) / 1e6
+		}
+	}
+	return status, nil
+}
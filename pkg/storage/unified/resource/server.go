@@ -111,6 +111,46 @@ type ResourceStats struct {
 
 	Count           int64
 	ResourceVersion int64
+
+	// The total size of the values in bytes, only set by ResourceSizeStatsGetter
+	Size int64
+}
+
+// ResourceSizeStatsGetter is implemented by the backends that can sum the size of the stored values.
+// Summing the sizes reads every value, so it is kept apart from GetResourceStats, which only counts them.
+type ResourceSizeStatsGetter interface {
+	// GetResourceSizeStats returns the count and the size of the resources of a namespace
+	GetResourceSizeStats(ctx context.Context, namespace string) ([]ResourceStats, error)
+}
+
+// ModifiedResource is the latest version of a resource changed after a resource version
+type ModifiedResource struct {
+	Key             *ResourceKey
+	Action          WatchEvent_Type
+	ResourceVersion int64
+	Value           []byte
+}
+
+// ModifiedSinceLister is implemented by the backends that can list the resources changed after a resource version,
+// so that the search indexes saved on disk are updated without listing every resource
+type ModifiedSinceLister interface {
+	// ListModifiedSince calls fn with the latest version of each resource of the collection changed after sinceRV,
+	// deleted resources included, and returns the highest resource version it found
+	ListModifiedSince(ctx context.Context, key NamespacedResource, sinceRV int64, fn func(*ModifiedResource) error) (int64, error)
+}
+
+// WrittenEventLister is implemented by the backends that can list the events written after a resource version,
+// so that a watch started from a past resource version also gets the events written before it started
+type WrittenEventLister interface {
+	// ListWrittenSince calls fn with the events of a group and resource written after sinceRV, in all the
+	// namespaces, in the order of their resource versions
+	ListWrittenSince(ctx context.Context, group, resource string, sinceRV int64, fn func(*WrittenEvent) error) error
+}
+
+// CollectionLister is implemented by the backends that can list every collection (group and resource)
+// with saved values in a namespace, including the collections where all the resources were deleted
+type CollectionLister interface {
+	ListCollections(ctx context.Context, namespace string) ([]NamespacedResource, error)
 }
 
 // This interface is not exposed to end users directly
@@ -188,6 +228,9 @@ type ResourceServerOptions struct {
 	storageMetrics *StorageMetrics
 
 	IndexMetrics *BleveIndexMetrics
+
+	// Quotas enforced when writing resources
+	Quotas QuotaOptions
 }
 
 func NewResourceServer(opts ResourceServerOptions) (ResourceServer, error) {
@@ -253,6 +296,7 @@ func NewResourceServer(opts ResourceServerOptions) (ResourceServer, error) {
 		cancel:         cancel,
 		storageMetrics: opts.storageMetrics,
 		indexMetrics:   opts.IndexMetrics,
+		quotas:         newQuotas(opts.Quotas, opts.Backend),
 	}
 
 	if opts.Search.Resources != nil {
@@ -288,6 +332,7 @@ type server struct {
 	mostRecentRV   atomic.Int64 // The most recent resource version seen by the server
 	storageMetrics *StorageMetrics
 	indexMetrics   *BleveIndexMetrics
+	quotas         *quotas
 
 	// Background watch task -- this has permissions for everything
 	ctx         context.Context
@@ -342,6 +387,10 @@ func (s *server) Stop(ctx context.Context) error {
 	// Stops the streaming
 	s.cancel()
 
+	if s.search != nil {
+		s.search.search.Stop()
+	}
+
 	// mark the value as done
 	if stopFailed {
 		return s.initErr
@@ -551,12 +600,19 @@ func (s *server) Create(ctx context.Context, req *CreateRequest) (*CreateRespons
 		return rsp, nil
 	}
 
+	size := int64(len(req.Value))
+	if e := s.quotas.reserve(ctx, req.Key, 1, size, size); e != nil {
+		rsp.Error = e
+		return rsp, nil
+	}
+
 	// If the resource already exists, the create will return an already exists error that is remapped appropriately by AsErrorResult.
 	// This also benefits from ACID behaviours on our databases, so we avoid race conditions.
 	var err error
 	rsp.ResourceVersion, err = s.backend.WriteEvent(ctx, *event)
 	if err != nil {
 		rsp.Error = AsErrorResult(err)
+		s.quotas.add(req.Key, -1, -size)
 	}
 	s.log.Debug("server.WriteEvent", "type", event.Type, "rv", rsp.ResourceVersion, "previousRV", event.PreviousRV, "group", event.Key.Group, "namespace", event.Key.Namespace, "name", event.Key.Name, "resource", event.Key.Resource)
 	return rsp, nil
@@ -580,7 +636,8 @@ func (s *server) Update(ctx context.Context, req *UpdateRequest) (*UpdateRespons
 		return rsp, nil
 	}
 
-	latest := s.backend.ReadResource(ctx, &ReadRequest{
+	// The previous version must be the latest committed one, not a lagging copy
+	latest := s.backend.ReadResource(WithStrongConsistency(ctx), &ReadRequest{
 		Key: req.Key,
 	})
 	if latest.Error != nil {
@@ -604,10 +661,17 @@ func (s *server) Update(ctx context.Context, req *UpdateRequest) (*UpdateRespons
 	event.Type = WatchEvent_MODIFIED
 	event.PreviousRV = latest.ResourceVersion
 
+	growth := int64(len(req.Value) - len(latest.Value))
+	if e := s.quotas.reserve(ctx, req.Key, 0, growth, int64(len(req.Value))); e != nil {
+		rsp.Error = e
+		return rsp, nil
+	}
+
 	var err error
 	rsp.ResourceVersion, err = s.backend.WriteEvent(ctx, *event)
 	if err != nil {
 		rsp.Error = AsErrorResult(err)
+		s.quotas.add(req.Key, 0, -growth)
 	}
 	return rsp, nil
 }
@@ -629,7 +693,8 @@ func (s *server) Delete(ctx context.Context, req *DeleteRequest) (*DeleteRespons
 		return rsp, nil
 	}
 
-	latest := s.backend.ReadResource(ctx, &ReadRequest{
+	// The previous version must be the latest committed one, not a lagging copy
+	latest := s.backend.ReadResource(WithStrongConsistency(ctx), &ReadRequest{
 		Key: req.Key,
 	})
 	if latest.Error != nil {
@@ -696,6 +761,8 @@ func (s *server) Delete(ctx context.Context, req *DeleteRequest) (*DeleteRespons
 	rsp.ResourceVersion, err = s.backend.WriteEvent(ctx, event)
 	if err != nil {
 		rsp.Error = AsErrorResult(err)
+	} else {
+		s.quotas.add(req.Key, -1, -int64(len(latest.Value)))
 	}
 	return rsp, nil
 }
@@ -961,6 +1028,73 @@ func (s *server) Watch(req *WatchRequest, srv ResourceStore_WatchServer) error {
 	default:
 		since = req.Since
 	}
+	sendEvent := func(event *WrittenEvent) error {
+		if event.ResourceVersion <= since || !matchesQueryKey(req.Options.Key, event.Key) {
+			return nil
+		}
+		if !checker(event.Key.Name, event.Folder) {
+			return nil
+		}
+
+		value := event.Value
+		// remove the delete marker stored in the value for deleted objects
+		if event.Type == WatchEvent_DELETED {
+			value = []byte{}
+		}
+		resp := &WatchEvent{
+			Timestamp: event.Timestamp,
+			Type:      event.Type,
+			Resource: &WatchEvent_Resource{
+				Value:   value,
+				Version: event.ResourceVersion,
+			},
+		}
+		if event.PreviousRV > 0 {
+			prevObj, err := s.Read(ctx, &ReadRequest{Key: event.Key, ResourceVersion: event.PreviousRV})
+			if err != nil {
+				// This scenario should never happen, but if it does, we should log it and continue
+				// sending the event without the previous object. The client will decide what to do.
+				s.log.Error("error reading previous object", "key", event.Key, "resource_version", event.PreviousRV, "error", prevObj.Error)
+			} else {
+				if prevObj.ResourceVersion != event.PreviousRV {
+					s.log.Error("resource version mismatch", "key", event.Key, "resource_version", event.PreviousRV, "actual", prevObj.ResourceVersion)
+					return fmt.Errorf("resource version mismatch")
+				}
+				resp.Previous = &WatchEvent_Resource{
+					Value:   prevObj.Value,
+					Version: prevObj.ResourceVersion,
+				}
+			}
+		}
+		if err := srv.Send(resp); err != nil {
+			return err
+		}
+
+		if s.storageMetrics != nil {
+			// record latency - resource version is a unix timestamp in microseconds so we convert to seconds
+			latencySeconds := float64(time.Now().UnixMicro()-event.ResourceVersion) / 1e6
+			if latencySeconds > 0 {
+				s.storageMetrics.WatchEventLatency.WithLabelValues(event.Key.Resource).Observe(latencySeconds)
+			}
+		}
+		return nil
+	}
+
+	// Replay the events written since the requested resource version, the same events
+	// received meanwhile from the broadcaster are skipped as they are not newer
+	if lister, ok := s.backend.(WrittenEventLister); ok && req.Since > 0 && !req.SendInitialEvents && key.Group != "" && key.Resource != "" {
+		err := lister.ListWrittenSince(ctx, key.Group, key.Resource, req.Since, func(event *WrittenEvent) error {
+			if err := sendEvent(event); err != nil {
+				return err
+			}
+			since = max(since, event.ResourceVersion)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
 	for {
 		select {
 		case <-ctx.Done():
@@ -972,52 +1106,8 @@ func (s *server) Watch(req *WatchRequest, srv ResourceStore_WatchServer) error {
 				return nil
 			}
 			s.log.Debug("Server Broadcasting", "type", event.Type, "rv", event.ResourceVersion, "previousRV", event.PreviousRV, "group", event.Key.Group, "namespace", event.Key.Namespace, "resource", event.Key.Resource, "name", event.Key.Name)
-			if event.ResourceVersion > since && matchesQueryKey(req.Options.Key, event.Key) {
-				if !checker(event.Key.Name, event.Folder) {
-					continue
-				}
-
-				value := event.Value
-				// remove the delete marker stored in the value for deleted objects
-				if event.Type == WatchEvent_DELETED {
-					value = []byte{}
-				}
-				resp := &WatchEvent{
-					Timestamp: event.Timestamp,
-					Type:      event.Type,
-					Resource: &WatchEvent_Resource{
-						Value:   value,
-						Version: event.ResourceVersion,
-					},
-				}
-				if event.PreviousRV > 0 {
-					prevObj, err := s.Read(ctx, &ReadRequest{Key: event.Key, ResourceVersion: event.PreviousRV})
-					if err != nil {
-						// This scenario should never happen, but if it does, we should log it and continue
-						// sending the event without the previous object. The client will decide what to do.
-						s.log.Error("error reading previous object", "key", event.Key, "resource_version", event.PreviousRV, "error", prevObj.Error)
-					} else {
-						if prevObj.ResourceVersion != event.PreviousRV {
-							s.log.Error("resource version mismatch", "key", event.Key, "resource_version", event.PreviousRV, "actual", prevObj.ResourceVersion)
-							return fmt.Errorf("resource version mismatch")
-						}
-						resp.Previous = &WatchEvent_Resource{
-							Value:   prevObj.Value,
-							Version: prevObj.ResourceVersion,
-						}
-					}
-				}
-				if err := srv.Send(resp); err != nil {
-					return err
-				}
-
-				if s.storageMetrics != nil {
-					// record latency - resource version is a unix timestamp in microseconds so we convert to seconds
-					latencySeconds := float64(time.Now().UnixMicro()-event.ResourceVersion) / 1e6
-					if latencySeconds > 0 {
-						s.storageMetrics.WatchEventLatency.WithLabelValues(event.Key.Resource).Observe(latencySeconds)
-					}
-				}
+			if err := sendEvent(event); err != nil {
+				return err
 			}
 		}
 	}
@@ -1035,15 +1125,36 @@ func (s *server) GetStats(ctx context.Context, req *ResourceStatsRequest) (*Reso
 	if err := s.Init(ctx); err != nil {
 		return nil, err
 	}
+	var rsp *ResourceStatsResponse
+	var err error
 	if s.search == nil {
 		// If the backend implements "GetStats", we can use it
 		srv, ok := s.backend.(ResourceIndexServer)
-		if ok {
-			return srv.GetStats(ctx, req)
+		if !ok {
+			return nil, fmt.Errorf("search index not configured")
 		}
+		rsp, err = srv.GetStats(ctx, req)
+	} else {
+		rsp, err = s.search.GetStats(ctx, req)
+	}
+	if err != nil || rsp.Error != nil {
+		return rsp, err
+	}
+	if err := s.quotas.report(ctx, req.Namespace, rsp); err != nil {
+		rsp.Error = AsErrorResult(err)
+	}
+	return rsp, nil
+}
+
+// IndexStatus implements IndexStatusProvider.
+func (s *server) IndexStatus(ctx context.Context) ([]IndexStatus, error) {
+	if err := s.Init(ctx); err != nil {
+		return nil, err
+	}
+	if s.search == nil {
 		return nil, fmt.Errorf("search index not configured")
 	}
-	return s.search.GetStats(ctx, req)
+	return s.search.IndexStatus(ctx)
 }
 
 func (s *server) ListManagedObjects(ctx context.Context, req *ListManagedObjectsRequest) (*ListManagedObjectsResponse, error) {
@@ -0,0 +1,70 @@
+package resourcediff
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/middleware"
+	"github.com/grafana/grafana/pkg/services/apiserver/endpoints/request"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/storage/unified/resource"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+// API exposes the difference between two versions of any resource saved in unified storage
+type API struct {
+	client     resource.ResourceClient
+	namespacer request.NamespaceMapper
+}
+
+func ProvideAPI(routeRegister routing.RouteRegister, cfg *setting.Cfg, client resource.ResourceClient) *API {
+	api := &API{
+		client:     client,
+		namespacer: request.GetNamespaceMapper(cfg),
+	}
+	routeRegister.Get("/api/unified-storage/diff/:group/:resource/:name", middleware.ReqSignedIn, routing.Wrap(api.diff))
+	return api
+}
+
+// GET /api/unified-storage/diff/:group/:resource/:name?from=<rv>&to=<rv>
+//
+// The access to both versions is checked by the storage with the permissions of the signed in user.
+func (a *API) diff(c *contextmodel.ReqContext) response.Response {
+	params := web.Params(c.Req)
+	key := &resource.ResourceKey{
+		Namespace: a.namespacer(c.SignedInUser.GetOrgID()),
+		Group:     params[":group"],
+		Resource:  params[":resource"],
+		Name:      params[":name"],
+	}
+
+	var from, to int64
+	var err error
+	if from, err = strconv.ParseInt(c.Query("from"), 10, 64); err != nil {
+		return response.Error(http.StatusBadRequest, "invalid from resource version", err)
+	}
+	if v := c.Query("to"); v != "" {
+		if to, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return response.Error(http.StatusBadRequest, "invalid to resource version", err)
+		}
+	}
+
+	result, err := CompareVersions(c.Req.Context(), a.client, key, from, to)
+	if err != nil {
+		var status apierrors.APIStatus
+		switch {
+		case errors.Is(err, ErrBadRequest):
+			return response.Error(http.StatusBadRequest, err.Error(), err)
+		case errors.As(err, &status):
+			return response.Error(int(status.Status().Code), status.Status().Message, err)
+		}
+		return response.Error(http.StatusInternalServerError, "Failed to compare the versions", err)
+	}
+	return response.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,334 @@
+package resourcediff
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+const (
+	dashboardGroup    = "dashboard.grafana.app"
+	dashboardResource = "dashboards"
+)
+
+// The dashboard spec fields compared by compareDashboards. The classic version is incremented on every save
+var dashboardFields = []string{
+	"panels", "templating", "version", // classic dashboards
+	"elements", "layout", "variables", // v2 dashboards
+}
+
+// DashboardDiff is the semantic difference between two dashboard versions.
+// Panels are matched by their ID in classic dashboards, and by their element key in v2 dashboards.
+type DashboardDiff struct {
+	Panels    []PanelChange    `json:"panels"`
+	Variables []VariableChange `json:"variables"`
+}
+
+// Empty is true when no panel and variable changed
+func (d *DashboardDiff) Empty() bool {
+	return len(d.Panels) == 0 && len(d.Variables) == 0
+}
+
+// GridPos is the position of a panel
+type GridPos struct {
+	X int64 `json:"x"`
+	Y int64 `json:"y"`
+	W int64 `json:"w"`
+	H int64 `json:"h"`
+}
+
+// PanelChange describes an added, removed or changed panel
+type PanelChange struct {
+	// The panel ID, or the element key in v2 dashboards
+	Key       string    `json:"key"`
+	Title     string    `json:"title,omitempty"`
+	Operation Operation `json:"op"`
+
+	// Set when the panel moved or was resized
+	Moved bool     `json:"moved,omitempty"`
+	From  *GridPos `json:"from,omitempty"`
+	To    *GridPos `json:"to,omitempty"`
+
+	// The changed queries, matched by their refId
+	Queries []QueryChange `json:"queries,omitempty"`
+
+	// The other changed fields of the panel
+	Changes []FieldChange `json:"changes,omitempty"`
+}
+
+// QueryChange describes an added, removed or changed panel query
+type QueryChange struct {
+	RefID     string        `json:"refId"`
+	Operation Operation     `json:"op"`
+	Changes   []FieldChange `json:"changes,omitempty"`
+}
+
+// VariableChange describes an added, removed or changed template variable
+type VariableChange struct {
+	Name      string        `json:"name"`
+	Operation Operation     `json:"op"`
+	Changes   []FieldChange `json:"changes,omitempty"`
+}
+
+type panel struct {
+	key     string
+	title   string
+	pos     *GridPos
+	queries keyedList
+	fields  map[string]any
+}
+
+// keyedList holds the values of a list by their key, which is made unique when it is repeated
+type keyedList map[string]map[string]any
+
+func (l keyedList) add(key string, value map[string]any) {
+	if _, ok := l[key]; ok {
+		key = fmt.Sprintf("%s#%d", key, len(l))
+	}
+	l[key] = value
+}
+
+func compareDashboards(a, b map[string]any) *DashboardDiff {
+	diff := &DashboardDiff{Panels: []PanelChange{}, Variables: []VariableChange{}}
+
+	oldPanels, newPanels := panelsOf(a), panelsOf(b)
+	for _, key := range unionKeys(oldPanels, newPanels) {
+		o, inOld := oldPanels[key]
+		n, inNew := newPanels[key]
+		switch {
+		case !inOld:
+			diff.Panels = append(diff.Panels, PanelChange{Key: key, Title: n.title, Operation: OperationAdded, To: n.pos})
+		case !inNew:
+			diff.Panels = append(diff.Panels, PanelChange{Key: key, Title: o.title, Operation: OperationRemoved, From: o.pos})
+		default:
+			if change, ok := comparePanels(o, n); ok {
+				diff.Panels = append(diff.Panels, change)
+			}
+		}
+	}
+
+	oldVars, newVars := variablesOf(a), variablesOf(b)
+	for _, name := range unionKeys(oldVars, newVars) {
+		o, inOld := oldVars[name]
+		n, inNew := newVars[name]
+		switch {
+		case !inOld:
+			diff.Variables = append(diff.Variables, VariableChange{Name: name, Operation: OperationAdded})
+		case !inNew:
+			diff.Variables = append(diff.Variables, VariableChange{Name: name, Operation: OperationRemoved})
+		default:
+			if changes := diffFields(o, n); len(changes) > 0 {
+				diff.Variables = append(diff.Variables, VariableChange{Name: name, Operation: OperationChanged, Changes: changes})
+			}
+		}
+	}
+	return diff
+}
+
+func comparePanels(o, n *panel) (PanelChange, bool) {
+	change := PanelChange{Key: n.key, Title: n.title, Operation: OperationChanged}
+	if o.pos != nil && n.pos != nil && *o.pos != *n.pos {
+		change.Moved = true
+		change.From = o.pos
+		change.To = n.pos
+	}
+	for _, refID := range unionKeys(o.queries, n.queries) {
+		oq, inOld := o.queries[refID]
+		nq, inNew := n.queries[refID]
+		switch {
+		case !inOld:
+			change.Queries = append(change.Queries, QueryChange{RefID: refID, Operation: OperationAdded})
+		case !inNew:
+			change.Queries = append(change.Queries, QueryChange{RefID: refID, Operation: OperationRemoved})
+		default:
+			if changes := diffFields(oq, nq); len(changes) > 0 {
+				change.Queries = append(change.Queries, QueryChange{RefID: refID, Operation: OperationChanged, Changes: changes})
+			}
+		}
+	}
+	if changes := diffFields(o.fields, n.fields); len(changes) > 0 {
+		change.Changes = changes
+	}
+	return change, change.Moved || len(change.Queries) > 0 || len(change.Changes) > 0
+}
+
+// panelsOf returns the panels of a classic or v2 dashboard by key
+func panelsOf(spec map[string]any) map[string]*panel {
+	panels := map[string]*panel{}
+	if elements, ok := spec["elements"].(map[string]any); ok {
+		positions := map[string]*GridPos{}
+		findLayoutPositions(spec["layout"], positions)
+		for key, e := range elements {
+			element, _ := e.(map[string]any)
+			elementSpec, _ := element["spec"].(map[string]any)
+			p := &panel{key: key, pos: positions[key], queries: keyedList{}, fields: element}
+			p.title, _ = elementSpec["title"].(string)
+			if data, ok := elementSpec["data"].(map[string]any); ok {
+				dataSpec, _ := data["spec"].(map[string]any)
+				queries, _ := dataSpec["queries"].([]any)
+				for i, q := range queries {
+					query, _ := q.(map[string]any)
+					querySpec, _ := query["spec"].(map[string]any)
+					p.queries.add(refIDOf(querySpec, i), query)
+				}
+				p.fields = withPath(element, withoutFields(dataSpec, "queries"), "spec", "data", "spec")
+			}
+			panels[key] = p
+		}
+		return panels
+	}
+
+	var add func(list []any)
+	add = func(list []any) {
+		for i, v := range list {
+			obj, ok := v.(map[string]any)
+			if !ok {
+				continue
+			}
+			p := &panel{
+				pos:     gridPosOf(obj["gridPos"]),
+				queries: keyedList{},
+				fields:  withoutFields(obj, "gridPos", "targets", "panels"),
+			}
+			p.title, _ = obj["title"].(string)
+			switch id := obj["id"].(type) {
+			case nil:
+				p.key = fmt.Sprintf("#%d", i)
+			case float64:
+				p.key = fmt.Sprintf("%d", int64(id))
+			default:
+				p.key = fmt.Sprint(id)
+			}
+			targets, _ := obj["targets"].([]any)
+			for j, t := range targets {
+				target, _ := t.(map[string]any)
+				p.queries.add(refIDOf(target, j), target)
+			}
+			panels[p.key] = p
+
+			// The panels of a collapsed row
+			if nested, ok := obj["panels"].([]any); ok {
+				add(nested)
+			}
+		}
+	}
+	list, _ := spec["panels"].([]any)
+	add(list)
+	return panels
+}
+
+// variablesOf returns the template variables of a classic or v2 dashboard by name
+func variablesOf(spec map[string]any) keyedList {
+	vars := keyedList{}
+	if list, ok := spec["variables"].([]any); ok {
+		for i, v := range list {
+			variable, _ := v.(map[string]any)
+			variableSpec, _ := variable["spec"].(map[string]any)
+			vars.add(nameOf(variableSpec, i), variable)
+		}
+		return vars
+	}
+	templating, _ := spec["templating"].(map[string]any)
+	list, _ := templating["list"].([]any)
+	for i, v := range list {
+		variable, _ := v.(map[string]any)
+		vars.add(nameOf(variable, i), variable)
+	}
+	return vars
+}
+
+// findLayoutPositions walks a v2 layout, and finds the grid items referencing an element
+func findLayoutPositions(layout any, positions map[string]*GridPos) {
+	switch v := layout.(type) {
+	case map[string]any:
+		if ref, ok := v["element"].(map[string]any); ok {
+			if name, ok := ref["name"].(string); ok {
+				positions[name] = &GridPos{
+					X: toInt(v["x"]),
+					Y: toInt(v["y"]),
+					W: toInt(v["width"]),
+					H: toInt(v["height"]),
+				}
+			}
+		}
+		for _, child := range v {
+			findLayoutPositions(child, positions)
+		}
+	case []any:
+		for _, child := range v {
+			findLayoutPositions(child, positions)
+		}
+	}
+}
+
+func gridPosOf(v any) *GridPos {
+	pos, ok := v.(map[string]any)
+	if !ok {
+		return nil
+	}
+	return &GridPos{X: toInt(pos["x"]), Y: toInt(pos["y"]), W: toInt(pos["w"]), H: toInt(pos["h"])}
+}
+
+func toInt(v any) int64 {
+	switch n := v.(type) {
+	case int64:
+		return n
+	case float64:
+		return int64(n)
+	}
+	return 0
+}
+
+func refIDOf(query map[string]any, index int) string {
+	if refID, ok := query["refId"].(string); ok && refID != "" {
+		return refID
+	}
+	return fmt.Sprintf("#%d", index)
+}
+
+func nameOf(variable map[string]any, index int) string {
+	if name, ok := variable["name"].(string); ok && name != "" {
+		return name
+	}
+	return fmt.Sprintf("#%d", index)
+}
+
+// withPath returns a copy of obj where the value at path is replaced
+func withPath(obj map[string]any, value map[string]any, path ...string) map[string]any {
+	copied := withoutFields(obj)
+	if copied == nil {
+		copied = map[string]any{}
+	}
+	if len(path) == 1 {
+		copied[path[0]] = value
+		return copied
+	}
+	child, _ := obj[path[0]].(map[string]any)
+	copied[path[0]] = withPath(child, value, path[1:]...)
+	return copied
+}
+
+// unionKeys returns the keys of both maps, sorted with the numeric keys (panel IDs) first and in numeric order
+func unionKeys[T any](a, b map[string]T) []string {
+	keys := make([]string, 0, len(a)+len(b))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, errA := strconv.ParseInt(keys[i], 10, 64)
+		b, errB := strconv.ParseInt(keys[j], 10, 64)
+		switch {
+		case errA == nil && errB == nil:
+			return a < b
+		case errA == nil || errB == nil:
+			return errA == nil
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
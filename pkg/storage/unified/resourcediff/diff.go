@@ -0,0 +1,255 @@
+package resourcediff
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/grafana/grafana/pkg/apimachinery/utils"
+	"github.com/grafana/grafana/pkg/storage/unified/resource"
+)
+
+// ErrBadRequest is returned when the compared versions are not valid
+var ErrBadRequest = errors.New("invalid diff request")
+
+// Operation is the kind of difference between two versions
+type Operation string
+
+const (
+	OperationAdded   Operation = "added"
+	OperationRemoved Operation = "removed"
+	OperationChanged Operation = "changed"
+)
+
+// The annotations that change with every write
+var volatileAnnotations = map[string]bool{
+	utils.AnnoKeyUpdatedBy:                true,
+	utils.AnnoKeyUpdatedTimestamp:         true,
+	utils.AnnoKeyKubectlLastAppliedConfig: true,
+}
+
+// The metadata that is compared, everything else is set by the storage
+var comparedMetadata = []string{"labels", "annotations"}
+
+// FieldChange is a difference in a single field
+type FieldChange struct {
+	// The path to the field, like spec.options.legend[0].show
+	Path      string    `json:"path"`
+	Operation Operation `json:"op"`
+	Old       any       `json:"old,omitempty"`
+	New       any       `json:"new,omitempty"`
+}
+
+// Result is the difference between two versions of a resource
+type Result struct {
+	Group    string `json:"group"`
+	Resource string `json:"resource"`
+	Name     string `json:"name"`
+
+	// The compared resource versions, when the values come from the storage
+	From int64 `json:"from,omitempty"`
+	To   int64 `json:"to,omitempty"`
+
+	// The changed fields. For dashboards, the panels and the variables are in the dashboard diff instead
+	Changes []FieldChange `json:"changes"`
+
+	// The panels and variables that changed, set for dashboards only
+	Dashboard *DashboardDiff `json:"dashboard,omitempty"`
+}
+
+// Empty is true when the two versions are the same
+func (r *Result) Empty() bool {
+	return len(r.Changes) == 0 && (r.Dashboard == nil || r.Dashboard.Empty())
+}
+
+// Compare returns the difference between two JSON values of a resource
+func Compare(group, res string, from, to []byte) (*Result, error) {
+	a, err := parseObject(from)
+	if err != nil {
+		return nil, fmt.Errorf("read old value: %w", err)
+	}
+	b, err := parseObject(to)
+	if err != nil {
+		return nil, fmt.Errorf("read new value: %w", err)
+	}
+
+	result := &Result{
+		Group:    group,
+		Resource: res,
+		Name:     b.GetName(),
+		Changes:  []FieldChange{},
+	}
+	oldContent, newContent := contentOf(a), contentOf(b)
+	if group == dashboardGroup && res == dashboardResource {
+		oldSpec, _ := oldContent["spec"].(map[string]any)
+		newSpec, _ := newContent["spec"].(map[string]any)
+		result.Dashboard = compareDashboards(oldSpec, newSpec)
+		oldContent["spec"] = withoutFields(oldSpec, dashboardFields...)
+		newContent["spec"] = withoutFields(newSpec, dashboardFields...)
+	}
+	result.Changes = diffFields(oldContent, newContent)
+	return result, nil
+}
+
+func parseObject(value []byte) (*unstructured.Unstructured, error) {
+	obj := &unstructured.Unstructured{}
+	if err := obj.UnmarshalJSON(value); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// contentOf returns the compared part of an object: every field except the status and
+// the metadata set by the storage
+func contentOf(obj *unstructured.Unstructured) map[string]any {
+	content := make(map[string]any, len(obj.Object))
+	for k, v := range obj.Object {
+		if k != "metadata" && k != "status" {
+			content[k] = v
+		}
+	}
+	metadata, _ := obj.Object["metadata"].(map[string]any)
+	compared := map[string]any{}
+	for _, k := range comparedMetadata {
+		if v, ok := metadata[k]; ok {
+			compared[k] = v
+		}
+	}
+	if annotations, ok := compared["annotations"].(map[string]any); ok {
+		filtered := make(map[string]any, len(annotations))
+		for k, v := range annotations {
+			if !volatileAnnotations[k] {
+				filtered[k] = v
+			}
+		}
+		compared["annotations"] = filtered
+	}
+	content["metadata"] = compared
+	return content
+}
+
+func withoutFields(obj map[string]any, fields ...string) map[string]any {
+	if obj == nil {
+		return nil
+	}
+	copied := make(map[string]any, len(obj))
+	for k, v := range obj {
+		copied[k] = v
+	}
+	for _, f := range fields {
+		delete(copied, f)
+	}
+	return copied
+}
+
+// diffFields returns the changed fields between two JSON values, sorted by path
+func diffFields(a, b any) []FieldChange {
+	changes := []FieldChange{}
+	diffValue("", a, b, &changes)
+	return changes
+}
+
+func diffValue(path string, a, b any, changes *[]FieldChange) {
+	switch av := a.(type) {
+	case map[string]any:
+		if bv, ok := b.(map[string]any); ok {
+			diffMaps(path, av, bv, changes)
+			return
+		}
+	case []any:
+		if bv, ok := b.([]any); ok {
+			diffSlices(path, av, bv, changes)
+			return
+		}
+	}
+	if !reflect.DeepEqual(a, b) {
+		*changes = append(*changes, FieldChange{Path: path, Operation: OperationChanged, Old: a, New: b})
+	}
+}
+
+func diffMaps(path string, a, b map[string]any, changes *[]FieldChange) {
+	keys := make([]string, 0, len(a)+len(b))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		av, inA := a[k]
+		bv, inB := b[k]
+		p := joinPath(path, k)
+		switch {
+		case !inA:
+			*changes = append(*changes, FieldChange{Path: p, Operation: OperationAdded, New: bv})
+		case !inB:
+			*changes = append(*changes, FieldChange{Path: p, Operation: OperationRemoved, Old: av})
+		default:
+			diffValue(p, av, bv, changes)
+		}
+	}
+}
+
+func diffSlices(path string, a, b []any, changes *[]FieldChange) {
+	for i := 0; i < len(a) || i < len(b); i++ {
+		p := path + "[" + strconv.Itoa(i) + "]"
+		switch {
+		case i >= len(a):
+			*changes = append(*changes, FieldChange{Path: p, Operation: OperationAdded, New: b[i]})
+		case i >= len(b):
+			*changes = append(*changes, FieldChange{Path: p, Operation: OperationRemoved, Old: a[i]})
+		default:
+			diffValue(p, a[i], b[i], changes)
+		}
+	}
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// CompareVersions reads two versions of a resource from the storage, and returns their difference.
+// The latest version is used when to is zero.
+func CompareVersions(ctx context.Context, client resource.ResourceClient, key *resource.ResourceKey, from, to int64) (*Result, error) {
+	if from < 1 {
+		return nil, fmt.Errorf("%w: missing the resource version to compare from", ErrBadRequest)
+	}
+	read := func(rv int64) (*resource.ReadResponse, error) {
+		rsp, err := client.Read(ctx, &resource.ReadRequest{Key: key, ResourceVersion: rv})
+		if err != nil {
+			return nil, err
+		}
+		if rsp.Error != nil {
+			return nil, resource.GetError(rsp.Error)
+		}
+		return rsp, nil
+	}
+	a, err := read(from)
+	if err != nil {
+		return nil, err
+	}
+	b, err := read(to)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := Compare(key.Group, key.Resource, a.Value, b.Value)
+	if err != nil {
+		return nil, err
+	}
+	result.From = a.ResourceVersion
+	result.To = b.ResourceVersion
+	return result, nil
+}
@@ -0,0 +1,215 @@
+package resourcediff
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/grafana/authlib/types"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/apimachinery/identity"
+	"github.com/grafana/grafana/pkg/storage/unified/kv"
+	"github.com/grafana/grafana/pkg/storage/unified/resource"
+)
+
+func TestCompare(t *testing.T) {
+	t.Run("any resource", func(t *testing.T) {
+		from := `{"apiVersion":"playlist.grafana.app/v0alpha1","kind":"Playlist","metadata":{"name":"a","resourceVersion":"1",
+			"annotations":{"grafana.app/updatedBy":"user:1"}},"spec":{"title":"A","interval":"5m","items":[{"type":"dashboard_by_uid","value":"x"}]}}`
+		to := `{"apiVersion":"playlist.grafana.app/v0alpha1","kind":"Playlist","metadata":{"name":"a","resourceVersion":"2",
+			"annotations":{"grafana.app/updatedBy":"user:2"},"labels":{"team":"a"}},"spec":{"title":"B","items":[{"type":"dashboard_by_uid","value":"x"},{"type":"dashboard_by_tag","value":"y"}]}}`
+
+		result, err := Compare("playlist.grafana.app", "playlists", []byte(from), []byte(to))
+		require.NoError(t, err)
+		require.Nil(t, result.Dashboard)
+		require.Equal(t, []FieldChange{
+			{Path: "metadata.labels", Operation: OperationAdded, New: map[string]any{"team": "a"}},
+			{Path: "spec.interval", Operation: OperationRemoved, Old: "5m"},
+			{Path: "spec.items[1]", Operation: OperationAdded, New: map[string]any{"type": "dashboard_by_tag", "value": "y"}},
+			{Path: "spec.title", Operation: OperationChanged, Old: "A", New: "B"},
+		}, result.Changes)
+
+		result, err = Compare("playlist.grafana.app", "playlists", []byte(from), []byte(from))
+		require.NoError(t, err)
+		require.True(t, result.Empty())
+	})
+
+	t.Run("classic dashboard", func(t *testing.T) {
+		from := dashboard(t, map[string]any{
+			"title":   "Dashboard",
+			"version": 1,
+			"panels": []any{
+				map[string]any{"id": 1, "title": "CPU", "type": "timeseries", "gridPos": pos(0, 0),
+					"targets": []any{map[string]any{"refId": "A", "expr": "cpu"}}},
+				map[string]any{"id": 2, "title": "Memory", "type": "timeseries", "gridPos": pos(12, 0)},
+				map[string]any{"id": 10, "title": "Row", "type": "row", "collapsed": true, "gridPos": pos(0, 8),
+					"panels": []any{map[string]any{"id": 11, "title": "Disk", "gridPos": pos(0, 9)}}},
+			},
+			"templating": map[string]any{"list": []any{
+				map[string]any{"name": "env", "type": "custom", "query": "dev,prod"},
+				map[string]any{"name": "host", "type": "query"},
+			}},
+		})
+		to := dashboard(t, map[string]any{
+			"title":   "Renamed",
+			"version": 2,
+			"panels": []any{
+				map[string]any{"id": 1, "title": "CPU", "type": "timeseries", "gridPos": pos(12, 0),
+					"targets": []any{map[string]any{"refId": "A", "expr": "cpu_total"}, map[string]any{"refId": "B", "expr": "load"}}},
+				map[string]any{"id": 3, "title": "Network", "type": "timeseries", "gridPos": pos(0, 0)},
+				map[string]any{"id": 10, "title": "Row", "type": "row", "collapsed": true, "gridPos": pos(0, 8),
+					"panels": []any{map[string]any{"id": 11, "title": "Disk usage", "gridPos": pos(0, 9)}}},
+			},
+			"templating": map[string]any{"list": []any{
+				map[string]any{"name": "env", "type": "custom", "query": "dev,staging,prod"},
+				map[string]any{"name": "region", "type": "custom"},
+			}},
+		})
+
+		result, err := Compare(dashboardGroup, dashboardResource, from, to)
+		require.NoError(t, err)
+		require.Equal(t, []FieldChange{
+			{Path: "spec.title", Operation: OperationChanged, Old: "Dashboard", New: "Renamed"},
+		}, result.Changes)
+
+		require.Equal(t, []PanelChange{
+			{
+				Key: "1", Title: "CPU", Operation: OperationChanged, Moved: true,
+				From: &GridPos{X: 0, Y: 0, W: 12, H: 8}, To: &GridPos{X: 12, Y: 0, W: 12, H: 8},
+				Queries: []QueryChange{
+					{RefID: "A", Operation: OperationChanged, Changes: []FieldChange{
+						{Path: "expr", Operation: OperationChanged, Old: "cpu", New: "cpu_total"},
+					}},
+					{RefID: "B", Operation: OperationAdded},
+				},
+			},
+			{Key: "2", Title: "Memory", Operation: OperationRemoved, From: &GridPos{X: 12, Y: 0, W: 12, H: 8}},
+			{Key: "3", Title: "Network", Operation: OperationAdded, To: &GridPos{X: 0, Y: 0, W: 12, H: 8}},
+			{Key: "11", Title: "Disk usage", Operation: OperationChanged, Changes: []FieldChange{
+				{Path: "title", Operation: OperationChanged, Old: "Disk", New: "Disk usage"},
+			}},
+		}, result.Dashboard.Panels)
+
+		require.Equal(t, []VariableChange{
+			{Name: "env", Operation: OperationChanged, Changes: []FieldChange{
+				{Path: "query", Operation: OperationChanged, Old: "dev,prod", New: "dev,staging,prod"},
+			}},
+			{Name: "host", Operation: OperationRemoved},
+			{Name: "region", Operation: OperationAdded},
+		}, result.Dashboard.Variables)
+	})
+
+	t.Run("v2 dashboard", func(t *testing.T) {
+		element := func(title, expr string) map[string]any {
+			return map[string]any{"kind": "Panel", "spec": map[string]any{
+				"title": title,
+				"data": map[string]any{"kind": "QueryGroup", "spec": map[string]any{
+					"queries": []any{map[string]any{"kind": "PanelQuery", "spec": map[string]any{
+						"refId": "A", "query": map[string]any{"expr": expr},
+					}}},
+					"transformations": []any{},
+				}},
+			}}
+		}
+		item := func(name string, x int) map[string]any {
+			return map[string]any{"kind": "GridLayoutItem", "spec": map[string]any{
+				"x": x, "y": 0, "width": 12, "height": 8,
+				"element": map[string]any{"kind": "ElementReference", "name": name},
+			}}
+		}
+		from := dashboard(t, map[string]any{
+			"title":    "Dashboard",
+			"elements": map[string]any{"panel-1": element("CPU", "cpu"), "panel-2": element("Memory", "mem")},
+			"layout": map[string]any{"kind": "GridLayout", "spec": map[string]any{
+				"items": []any{item("panel-1", 0), item("panel-2", 12)},
+			}},
+			"variables": []any{map[string]any{"kind": "CustomVariable", "spec": map[string]any{"name": "env", "query": "a"}}},
+		})
+		to := dashboard(t, map[string]any{
+			"title":    "Dashboard",
+			"elements": map[string]any{"panel-1": element("CPU", "cpu_total"), "panel-2": element("RAM", "mem")},
+			"layout": map[string]any{"kind": "GridLayout", "spec": map[string]any{
+				"items": []any{item("panel-2", 0), item("panel-1", 12)},
+			}},
+			"variables": []any{map[string]any{"kind": "CustomVariable", "spec": map[string]any{"name": "env", "query": "b"}}},
+		})
+
+		result, err := Compare(dashboardGroup, dashboardResource, from, to)
+		require.NoError(t, err)
+		require.Empty(t, result.Changes)
+		require.Equal(t, []PanelChange{
+			{
+				Key: "panel-1", Title: "CPU", Operation: OperationChanged, Moved: true,
+				From: &GridPos{X: 0, W: 12, H: 8}, To: &GridPos{X: 12, W: 12, H: 8},
+				Queries: []QueryChange{{RefID: "A", Operation: OperationChanged, Changes: []FieldChange{
+					{Path: "spec.query.expr", Operation: OperationChanged, Old: "cpu", New: "cpu_total"},
+				}}},
+			},
+			{
+				Key: "panel-2", Title: "RAM", Operation: OperationChanged, Moved: true,
+				From: &GridPos{X: 12, W: 12, H: 8}, To: &GridPos{X: 0, W: 12, H: 8},
+				Changes: []FieldChange{{Path: "spec.title", Operation: OperationChanged, Old: "Memory", New: "RAM"}},
+			},
+		}, result.Dashboard.Panels)
+		require.Equal(t, []VariableChange{
+			{Name: "env", Operation: OperationChanged, Changes: []FieldChange{
+				{Path: "spec.query", Operation: OperationChanged, Old: "a", New: "b"},
+			}},
+		}, result.Dashboard.Variables)
+	})
+}
+
+func TestCompareVersions(t *testing.T) {
+	ctx := identity.WithRequester(context.Background(), &identity.StaticRequester{
+		Type:    types.TypeUser,
+		UserID:  1,
+		UserUID: "u1",
+		OrgRole: identity.RoleAdmin,
+	})
+	backend, err := kv.NewBackend(kv.BackendOptions{Path: filepath.Join(t.TempDir(), "resource.db")})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = backend.Stop(context.Background())
+	})
+	server, err := resource.NewResourceServer(resource.ResourceServerOptions{Backend: backend})
+	require.NoError(t, err)
+	client := resource.NewLocalResourceClient(server)
+
+	key := &resource.ResourceKey{Namespace: "default", Group: dashboardGroup, Resource: dashboardResource, Name: "a"}
+	v1 := dashboard(t, map[string]any{"title": "A"})
+	created, err := client.Create(ctx, &resource.CreateRequest{Key: key, Value: v1})
+	require.NoError(t, err)
+	require.Nil(t, created.Error)
+	updated, err := client.Update(ctx, &resource.UpdateRequest{Key: key, Value: dashboard(t, map[string]any{"title": "B"}), ResourceVersion: created.ResourceVersion})
+	require.NoError(t, err)
+	require.Nil(t, updated.Error)
+
+	result, err := CompareVersions(ctx, client, key, created.ResourceVersion, 0)
+	require.NoError(t, err)
+	require.Equal(t, created.ResourceVersion, result.From)
+	require.Equal(t, updated.ResourceVersion, result.To)
+	require.Equal(t, []FieldChange{
+		{Path: "spec.title", Operation: OperationChanged, Old: "A", New: "B"},
+	}, result.Changes)
+
+	_, err = CompareVersions(ctx, client, key, 0, 0)
+	require.ErrorIs(t, err, ErrBadRequest)
+}
+
+func dashboard(t *testing.T, spec map[string]any) []byte {
+	t.Helper()
+	value, err := json.Marshal(map[string]any{
+		"apiVersion": "dashboard.grafana.app/v1alpha1",
+		"kind":       "Dashboard",
+		"metadata":   map[string]any{"name": "a", "namespace": "default", "uid": "a-uid"},
+		"spec":       spec,
+	})
+	require.NoError(t, err)
+	return value
+}
+
+func pos(x, y int) map[string]any {
+	return map[string]any{"x": x, "y": y, "w": 12, "h": 8}
+}
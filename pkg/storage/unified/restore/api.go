@@ -0,0 +1,144 @@
+package restore
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/middleware"
+	"github.com/grafana/grafana/pkg/services/apiserver/endpoints/request"
+	"github.com/grafana/grafana/pkg/services/auditlog"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/storage/unified/resource"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+// API exposes the restore of a namespace to the server admins
+type API struct {
+	restorer   *Restorer
+	namespacer request.NamespaceMapper
+	auditLog   auditlog.Service
+}
+
+func ProvideAPI(routeRegister routing.RouteRegister, cfg *setting.Cfg, client resource.ResourceClient, auditLog auditlog.Service) *API {
+	api := &API{
+		restorer:   NewRestorer(client),
+		namespacer: request.GetNamespaceMapper(cfg),
+		auditLog:   auditLog,
+	}
+	routeRegister.Group("/api/admin/unified-storage/restore", func(r routing.RouteRegister) {
+		r.Post("/preview", routing.Wrap(api.preview))
+		r.Post("/apply", routing.Wrap(api.apply))
+	}, middleware.ReqGrafanaAdmin)
+	return api
+}
+
+// RestoreCommand is the body of the restore requests
+type RestoreCommand struct {
+	// The organization to restore, defaults to the organization of the signed in user
+	OrgID int64 `json:"orgId"`
+
+	// The resource version or the time to restore
+	ResourceVersion int64      `json:"resourceVersion"`
+	Time            *time.Time `json:"time"`
+
+	// Only restore this folder and its subfolders
+	Folder string `json:"folder"`
+
+	// The resources to restore, as resource.group (for example dashboards.dashboard.grafana.app)
+	Resources []string `json:"resources"`
+
+	// The hash of the previewed plan, required to apply it
+	PlanHash string `json:"planHash"`
+}
+
+func (a *API) request(c *contextmodel.ReqContext) (Request, string, error) {
+	cmd := RestoreCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return Request{}, "", err
+	}
+	orgID := cmd.OrgID
+	if orgID == 0 {
+		orgID = c.SignedInUser.GetOrgID()
+	}
+	req := Request{
+		Namespace:       a.namespacer(orgID),
+		ResourceVersion: cmd.ResourceVersion,
+		Time:            cmd.Time,
+		Folder:          cmd.Folder,
+	}
+	for _, r := range cmd.Resources {
+		req.Resources = append(req.Resources, schema.ParseGroupResource(r))
+	}
+	return req, cmd.PlanHash, nil
+}
+
+// POST /api/admin/unified-storage/restore/preview
+func (a *API) preview(c *contextmodel.ReqContext) response.Response {
+	req, _, err := a.request(c)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	plan, err := a.restorer.Preview(c.Req.Context(), req)
+	if errors.Is(err, ErrBadRequest) {
+		return response.Error(http.StatusBadRequest, err.Error(), err)
+	}
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to preview the restore", err)
+	}
+	return response.JSON(http.StatusOK, plan)
+}
+
+// POST /api/admin/unified-storage/restore/apply
+func (a *API) apply(c *contextmodel.ReqContext) response.Response {
+	req, hash, err := a.request(c)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	result, err := a.restorer.Apply(c.Req.Context(), req, hash)
+	if errors.Is(err, ErrBadRequest) {
+		return response.Error(http.StatusBadRequest, err.Error(), err)
+	}
+	if errors.Is(err, ErrPlanChanged) {
+		return response.Error(http.StatusConflict, "The resources changed since the preview, preview the restore again", err)
+	}
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to restore", err)
+	}
+	a.audit(c, req, result)
+	if result.Error != "" {
+		return response.JSON(http.StatusConflict, result)
+	}
+	return response.JSON(http.StatusOK, result)
+}
+
+// audit records the outcome of a restore in the audit log, the restored resources
+// can be found in the history with the restore ID
+func (a *API) audit(c *contextmodel.ReqContext, req Request, result *Result) {
+	event := &auditlog.Event{
+		Action:       auditlog.ActionNamespaceRestore,
+		Result:       auditlog.ResultSuccess,
+		ResourceKind: auditlog.ResourceKindNamespace,
+		ResourceUID:  result.Namespace,
+		Details: map[string]string{
+			"id":              result.ID,
+			"resourceVersion": strconv.FormatInt(result.ResourceVersion, 10),
+			"changes":         strconv.Itoa(len(result.Changes)),
+			"applied":         strconv.Itoa(result.Applied),
+		},
+	}
+	if req.Folder != "" {
+		event.Details["folder"] = req.Folder
+	}
+	if result.Error != "" {
+		event.Result = auditlog.ResultFailure
+		event.Details["error"] = result.Error
+	}
+	a.auditLog.Log(c.Req.Context(), event)
+}
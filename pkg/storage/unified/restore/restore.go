@@ -0,0 +1,573 @@
+package restore
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc/metadata"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/grafana/grafana/pkg/apimachinery/identity"
+	"github.com/grafana/grafana/pkg/apimachinery/utils"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/storage/unified/resource"
+)
+
+var (
+	// ErrBadRequest is returned when the restore request is not valid
+	ErrBadRequest = errors.New("invalid restore request")
+
+	// ErrPlanChanged is returned when the changes to apply are not the previewed ones anymore
+	ErrPlanChanged = errors.New("the resources changed since the preview")
+
+	folders = schema.GroupResource{Group: "folder.grafana.app", Resource: "folders"}
+
+	// DefaultResources are the resources restored when a request does not list them
+	DefaultResources = []schema.GroupResource{
+		folders,
+		{Group: "dashboard.grafana.app", Resource: "dashboards"},
+		{Group: "dashboard.grafana.app", Resource: "librarypanels"},
+		{Group: "notifications.alerting.grafana.app", Resource: "receivers"},
+		{Group: "notifications.alerting.grafana.app", Resource: "routingtrees"},
+		{Group: "notifications.alerting.grafana.app", Resource: "templategroups"},
+		{Group: "notifications.alerting.grafana.app", Resource: "timeintervals"},
+		{Group: "rules.alerting.grafana.app", Resource: "alertrules"},
+	}
+)
+
+// The annotations that change with every write, and are ignored when comparing two versions
+var volatileAnnotations = []string{
+	utils.AnnoKeyUpdatedBy,
+	utils.AnnoKeyUpdatedTimestamp,
+	utils.AnnoKeyMessage,
+	utils.AnnoKeyKubectlLastAppliedConfig,
+}
+
+// Request describes the state to restore
+type Request struct {
+	// The namespace to restore
+	Namespace string
+
+	// Restore the resources as they were at this resource version
+	ResourceVersion int64
+
+	// Restore the resources as they were at this time. This is only used when the resource version is not set,
+	// and relies on the resource versions being timestamps in microseconds.
+	Time *time.Time
+
+	// Only restore this folder, its subfolders and the resources they contain
+	Folder string
+
+	// The resources to restore, defaults to DefaultResources
+	Resources []schema.GroupResource
+}
+
+// Action is the write needed to restore a resource
+type Action string
+
+const (
+	ActionCreate Action = "create"
+	ActionUpdate Action = "update"
+	ActionDelete Action = "delete"
+)
+
+// Change is the difference between the current and the restored version of a resource
+type Change struct {
+	Group    string `json:"group"`
+	Resource string `json:"resource"`
+	Name     string `json:"name"`
+	Action   Action `json:"action"`
+
+	// The folder of the resource once restored, or its current folder when it gets deleted
+	Folder string `json:"folder,omitempty"`
+
+	// The current resource version, zero when the resource does not exist anymore
+	CurrentResourceVersion int64 `json:"currentResourceVersion,omitempty"`
+
+	// The resource version of the restored value, zero when the resource did not exist yet
+	RestoredResourceVersion int64 `json:"restoredResourceVersion,omitempty"`
+
+	// sort order of the writes, parent folders are created first and deleted last
+	depth int
+	value []byte
+	// the current value, used for the generation of an update and as the value of a delete
+	previous []byte
+}
+
+// Plan lists the changes needed to restore a namespace
+type Plan struct {
+	Namespace       string   `json:"namespace"`
+	ResourceVersion int64    `json:"resourceVersion"`
+	Changes         []Change `json:"changes"`
+
+	// Identifies the changes and the current resource versions they apply to. Apply only writes the
+	// changes of the plan with this hash, so that what is written is what was previewed.
+	Hash string `json:"hash"`
+}
+
+// Result is the outcome of a restore
+type Result struct {
+	Plan
+
+	// Identifies the restore in the logs and in the message of the restored resources
+	ID string `json:"id"`
+
+	// The number of changes that were written. The changes are written in a single bulk transaction,
+	// so when the restore fails none of them is applied.
+	Applied int    `json:"applied"`
+	Error   string `json:"error,omitempty"`
+}
+
+// Restorer compares the resources of a namespace with their history, and writes them back to a previous state
+type Restorer struct {
+	client resource.ResourceClient
+	log    log.Logger
+}
+
+// NewRestorer reads the resources and their history with the client, and writes them back with its bulk process
+func NewRestorer(client resource.ResourceClient) *Restorer {
+	return &Restorer{
+		client: client,
+		log:    log.New("unified-storage.restore"),
+	}
+}
+
+// Preview returns the changes that Apply would write
+func (r *Restorer) Preview(ctx context.Context, req Request) (*Plan, error) {
+	if req.Namespace == "" {
+		return nil, fmt.Errorf("%w: missing namespace", ErrBadRequest)
+	}
+	rv := req.ResourceVersion
+	if rv == 0 && req.Time != nil {
+		rv = req.Time.UnixMicro()
+	}
+	if rv < 1 {
+		return nil, fmt.Errorf("%w: missing resource version or time", ErrBadRequest)
+	}
+	resources := req.Resources
+	if len(resources) == 0 {
+		resources = DefaultResources
+	}
+
+	plan := &Plan{Namespace: req.Namespace, ResourceVersion: rv, Changes: []Change{}}
+
+	// The folders are always needed to find the subtree and to sort the writes
+	folderCurrent, err := r.list(ctx, req.Namespace, folders, 0)
+	if err != nil {
+		return nil, err
+	}
+	folderRestored, err := r.list(ctx, req.Namespace, folders, rv)
+	if err != nil {
+		return nil, err
+	}
+	currentTree := newFolderTree(folderCurrent)
+	restoredTree := newFolderTree(folderRestored)
+	var subtree map[string]bool
+	if req.Folder != "" {
+		subtree = map[string]bool{req.Folder: true}
+		currentTree.addDescendants(req.Folder, subtree)
+		restoredTree.addDescendants(req.Folder, subtree)
+	}
+	inScope := func(isFolder bool, name string, folder string) bool {
+		if subtree == nil {
+			return true
+		}
+		if isFolder && subtree[name] {
+			return true
+		}
+		return folder != "" && subtree[folder]
+	}
+
+	for _, gr := range resources {
+		current, restored := folderCurrent, folderRestored
+		if gr != folders {
+			if current, err = r.list(ctx, req.Namespace, gr, 0); err != nil {
+				return nil, err
+			}
+			if restored, err = r.list(ctx, req.Namespace, gr, rv); err != nil {
+				return nil, err
+			}
+		}
+		isFolder := gr == folders
+
+		for name, now := range current {
+			then, ok := restored[name]
+			if !ok {
+				if !inScope(isFolder, name, now.folder) {
+					continue
+				}
+				change := now.change(gr, ActionDelete)
+				if isFolder {
+					change.depth = currentTree.depth(name)
+				}
+				plan.Changes = append(plan.Changes, change)
+				continue
+			}
+			if !inScope(isFolder, name, now.folder) && !inScope(isFolder, name, then.folder) {
+				continue
+			}
+			if now.rv == then.rv || sameContent(now.obj, then.obj) {
+				continue
+			}
+			change := then.change(gr, ActionUpdate)
+			change.CurrentResourceVersion = now.rv
+			change.previous = now.value
+			if isFolder {
+				change.depth = restoredTree.depth(name)
+			}
+			plan.Changes = append(plan.Changes, change)
+		}
+
+		for name, then := range restored {
+			if _, ok := current[name]; ok || !inScope(isFolder, name, then.folder) {
+				continue
+			}
+			change := then.change(gr, ActionCreate)
+			if isFolder {
+				change.depth = restoredTree.depth(name)
+			}
+			plan.Changes = append(plan.Changes, change)
+		}
+	}
+
+	sortChanges(plan.Changes)
+	plan.Hash = planHash(plan)
+	return plan, nil
+}
+
+// planHash identifies the changes of a plan, and the resource versions they are computed from
+func planHash(plan *Plan) string {
+	h := sha256.New()
+	_, _ = fmt.Fprintf(h, "%s/%d\n", plan.Namespace, plan.ResourceVersion)
+	for _, c := range plan.Changes {
+		_, _ = fmt.Fprintf(h, "%s/%s/%s/%s/%d/%d\n", c.Group, c.Resource, c.Name, c.Action, c.CurrentResourceVersion, c.RestoredResourceVersion)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Apply writes back the resources listed by the preview with the given plan hash, as the user in the context.
+// A resource changed since the preview fails the restore with ErrPlanChanged before anything is written. The
+// changes are written in a single bulk transaction, with the current resource versions as preconditions: either
+// all of them are restored, or none. Each restored value gets a message with the restore ID, and every change
+// is logged.
+func (r *Restorer) Apply(ctx context.Context, req Request, hash string) (*Result, error) {
+	user, err := identity.GetRequester(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if hash == "" {
+		return nil, fmt.Errorf("%w: missing plan hash, the restore must be previewed first", ErrBadRequest)
+	}
+	plan, err := r.Preview(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if plan.Hash != hash {
+		return nil, ErrPlanChanged
+	}
+
+	result := &Result{Plan: *plan, ID: uuid.NewString()}
+	message := fmt.Sprintf("Restored to resource version %d (restore %s)", plan.ResourceVersion, result.ID)
+	logger := r.log.FromContext(ctx).New("restore", result.ID, "namespace", plan.Namespace, "rv", plan.ResourceVersion, "user", user.GetUID())
+	logger.Info("Restore started", "changes", len(plan.Changes), "folder", req.Folder)
+	if len(plan.Changes) == 0 {
+		logger.Info("Restore completed", "applied", 0)
+		return result, nil
+	}
+
+	requests := make([]*resource.BulkRequest, 0, len(plan.Changes))
+	for _, change := range plan.Changes {
+		request, err := bulkRequest(plan.Namespace, change, user.GetUID(), message)
+		if err != nil {
+			return nil, err
+		}
+		requests = append(requests, request)
+	}
+	if err := r.bulk(ctx, requests); err != nil {
+		logger.Error("Restore failed", "error", err)
+		result.Error = err.Error()
+		return result, nil
+	}
+
+	for _, change := range plan.Changes {
+		logger.Info("Resource restored", "group", change.Group, "resource", change.Resource, "name", change.Name,
+			"action", change.Action, "from", change.CurrentResourceVersion, "to", change.RestoredResourceVersion)
+	}
+	result.Applied = len(plan.Changes)
+	logger.Info("Restore completed", "applied", result.Applied)
+	return result, nil
+}
+
+// bulk sends the requests to the storage in a single bulk, which adds them to the existing resources.
+// The storage checks the permission of the user for the action of each request.
+func (r *Restorer) bulk(ctx context.Context, requests []*resource.BulkRequest) error {
+	settings := resource.BulkSettings{}
+	collections := map[string]bool{}
+	for _, req := range requests {
+		key := &resource.ResourceKey{Namespace: req.Key.Namespace, Group: req.Key.Group, Resource: req.Key.Resource}
+		if !collections[key.NSGR()] {
+			collections[key.NSGR()] = true
+			settings.Collection = append(settings.Collection, key)
+		}
+	}
+
+	stream, err := r.client.BulkProcess(metadata.NewOutgoingContext(ctx, settings.ToMD()))
+	if err != nil {
+		return err
+	}
+	for _, req := range requests {
+		// the storage stops reading the requests when it fails, the error is in the response
+		if err := stream.Send(req); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return err
+		}
+	}
+	rsp, err := stream.CloseAndRecv()
+	if err != nil {
+		return err
+	}
+	if rsp.Error != nil {
+		return resource.GetError(rsp.Error)
+	}
+	if len(rsp.Rejected) > 0 {
+		rejected := rsp.Rejected[0]
+		return fmt.Errorf("%s/%s was rejected: %s", rejected.Key.Resource, rejected.Key.Name, rejected.Error)
+	}
+	return nil
+}
+
+// bulkRequest returns the write of a change. Like the writes of the resource server, the written value has the
+// user and time of the change, and the generation of the resource keeps increasing: the backends buffering the
+// bulks read the action back from it. The resource version of the value is the current one, which the storage
+// checks like the resource version of an update.
+func bulkRequest(namespace string, change Change, user string, message string) (*resource.BulkRequest, error) {
+	req := &resource.BulkRequest{
+		Key: &resource.ResourceKey{
+			Namespace: namespace,
+			Group:     change.Group,
+			Resource:  change.Resource,
+			Name:      change.Name,
+		},
+		Folder: change.Folder,
+	}
+
+	var written *version
+	var err error
+	switch change.Action {
+	case ActionCreate:
+		req.Action = resource.BulkRequest_ADDED
+		if written, err = parse(change.value); err != nil {
+			return nil, err
+		}
+		written.meta.SetGeneration(1)
+	case ActionUpdate:
+		req.Action = resource.BulkRequest_MODIFIED
+		var current *version
+		if current, err = parse(change.previous); err != nil {
+			return nil, err
+		}
+		if written, err = parse(change.value); err != nil {
+			return nil, err
+		}
+		// the history may have a previous incarnation of the resource, the UID is immutable
+		written.meta.SetUID(current.obj.GetUID())
+		written.meta.SetGeneration(max(current.meta.GetGeneration()+1, 2))
+	default:
+		req.Action = resource.BulkRequest_DELETED
+		if written, err = parse(change.previous); err != nil {
+			return nil, err
+		}
+		written.meta.SetGeneration(utils.DeletedGeneration)
+	}
+
+	now := time.Now()
+	written.meta.SetResourceVersion("")
+	if change.CurrentResourceVersion > 0 {
+		written.meta.SetResourceVersionInt64(change.CurrentResourceVersion)
+	}
+	written.meta.SetManagedFields(nil)
+	written.meta.SetUpdatedBy(user)
+	written.meta.SetUpdatedTimestamp(&now)
+	written.meta.SetMessage(message)
+	if req.Value, err = written.obj.MarshalJSON(); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+type version struct {
+	rv     int64
+	folder string
+	value  []byte
+	obj    *unstructured.Unstructured
+	meta   utils.GrafanaMetaAccessor
+}
+
+func (v *version) change(gr schema.GroupResource, action Action) Change {
+	c := Change{
+		Group:    gr.Group,
+		Resource: gr.Resource,
+		Name:     v.meta.GetName(),
+		Action:   action,
+		Folder:   v.folder,
+	}
+	if action == ActionDelete {
+		c.CurrentResourceVersion = v.rv
+		c.previous = v.value
+	} else {
+		c.RestoredResourceVersion = v.rv
+		c.value = v.value
+	}
+	return c
+}
+
+func parse(value []byte) (*version, error) {
+	obj := &unstructured.Unstructured{}
+	if err := obj.UnmarshalJSON(value); err != nil {
+		return nil, err
+	}
+	meta, err := utils.MetaAccessor(obj)
+	if err != nil {
+		return nil, err
+	}
+	return &version{folder: meta.GetFolder(), value: value, obj: obj, meta: meta}, nil
+}
+
+// list returns the resources by name, as they are now when rv is zero, or as they were at rv
+func (r *Restorer) list(ctx context.Context, namespace string, gr schema.GroupResource, rv int64) (map[string]*version, error) {
+	items := make(map[string]*version)
+	req := &resource.ListRequest{
+		ResourceVersion: rv,
+		Limit:           500,
+		Options: &resource.ListOptions{Key: &resource.ResourceKey{
+			Namespace: namespace,
+			Group:     gr.Group,
+			Resource:  gr.Resource,
+		}},
+	}
+	for {
+		rsp, err := r.client.List(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		if rsp.Error != nil {
+			// a resource that is not served by this storage has nothing to restore
+			if rsp.Error.Code == http.StatusNotFound {
+				return items, nil
+			}
+			return nil, fmt.Errorf("list %s: %w", gr, resource.GetError(rsp.Error))
+		}
+		for _, item := range rsp.Items {
+			v, err := parse(item.Value)
+			if err != nil {
+				return nil, fmt.Errorf("list %s: %w", gr, err)
+			}
+			v.rv = item.ResourceVersion
+			items[v.meta.GetName()] = v
+		}
+		if rsp.NextPageToken == "" {
+			return items, nil
+		}
+		req.NextPageToken = rsp.NextPageToken
+	}
+}
+
+// sameContent compares two versions of a resource, ignoring the metadata set on every write
+func sameContent(a, b *unstructured.Unstructured) bool {
+	return reflect.DeepEqual(contentOf(a), contentOf(b))
+}
+
+func contentOf(obj *unstructured.Unstructured) map[string]any {
+	c := obj.DeepCopy()
+	annotations := c.GetAnnotations()
+	for _, k := range volatileAnnotations {
+		delete(annotations, k)
+	}
+	labels := c.GetLabels()
+	delete(c.Object, "metadata")
+	if len(annotations) > 0 {
+		c.SetAnnotations(annotations)
+	}
+	if len(labels) > 0 {
+		c.SetLabels(labels)
+	}
+	delete(c.Object, "status")
+	return c.Object
+}
+
+// folderTree is the parent of each folder
+type folderTree map[string]string
+
+func newFolderTree(items map[string]*version) folderTree {
+	tree := make(folderTree, len(items))
+	for name, v := range items {
+		tree[name] = v.folder
+	}
+	return tree
+}
+
+func (t folderTree) addDescendants(folder string, into map[string]bool) {
+	for name, parent := range t {
+		if parent == folder && !into[name] {
+			into[name] = true
+			t.addDescendants(name, into)
+		}
+	}
+}
+
+func (t folderTree) depth(name string) int {
+	depth := 0
+	seen := map[string]bool{}
+	for parent := t[name]; parent != "" && !seen[parent]; parent = t[parent] {
+		seen[parent] = true
+		depth++
+	}
+	return depth
+}
+
+// sortChanges orders the writes so that a folder exists before the resources moved into it,
+// and is deleted after the resources it contained
+func sortChanges(changes []Change) {
+	rank := func(c Change) int {
+		isFolder := c.Group == folders.Group && c.Resource == folders.Resource
+		switch {
+		case isFolder && c.Action != ActionDelete:
+			return 0
+		case !isFolder:
+			return 1
+		default:
+			return 2
+		}
+	}
+	sort.SliceStable(changes, func(i, j int) bool {
+		a, b := changes[i], changes[j]
+		if ra, rb := rank(a), rank(b); ra != rb {
+			return ra < rb
+		}
+		if a.depth != b.depth {
+			if a.Action == ActionDelete {
+				return a.depth > b.depth // children first
+			}
+			return a.depth < b.depth // parents first
+		}
+		if a.Group != b.Group {
+			return a.Group < b.Group
+		}
+		if a.Resource != b.Resource {
+			return a.Resource < b.Resource
+		}
+		return a.Name < b.Name
+	})
+}
@@ -0,0 +1,265 @@
+package restore
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/grafana/authlib/types"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/grafana/grafana/pkg/apimachinery/identity"
+	"github.com/grafana/grafana/pkg/apimachinery/utils"
+	"github.com/grafana/grafana/pkg/services/auditlog"
+	"github.com/grafana/grafana/pkg/services/auditlog/auditlogtest"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/storage/unified/kv"
+	"github.com/grafana/grafana/pkg/storage/unified/resource"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+func TestRestore(t *testing.T) {
+	ctx := identity.WithRequester(context.Background(), &identity.StaticRequester{
+		Type:           types.TypeUser,
+		Login:          "admin",
+		UserID:         1,
+		UserUID:        "u1",
+		OrgRole:        identity.RoleAdmin,
+		IsGrafanaAdmin: true,
+	})
+	client := newTestClient(t)
+
+	write := func(res, name, folder, spec string) int64 {
+		t.Helper()
+		group := "dashboard.grafana.app"
+		kind := "Dashboard"
+		if res == "folders" {
+			group = "folder.grafana.app"
+			kind = "Folder"
+		}
+		key := &resource.ResourceKey{Namespace: "default", Group: group, Resource: res, Name: name}
+		obj := map[string]any{
+			"apiVersion": group + "/v1",
+			"kind":       kind,
+			"metadata": map[string]any{
+				"name":        name,
+				"namespace":   "default",
+				"uid":         name + "-uid",
+				"annotations": map[string]any{utils.AnnoKeyFolder: folder},
+			},
+			"spec": map[string]any{"title": spec},
+		}
+		value, err := json.Marshal(obj)
+		require.NoError(t, err)
+
+		current, err := client.Read(ctx, &resource.ReadRequest{Key: key})
+		require.NoError(t, err)
+		if current.Error != nil {
+			rsp, err := client.Create(ctx, &resource.CreateRequest{Key: key, Value: value})
+			require.NoError(t, err)
+			require.Nil(t, rsp.Error)
+			return rsp.ResourceVersion
+		}
+		rsp, err := client.Update(ctx, &resource.UpdateRequest{Key: key, Value: value, ResourceVersion: current.ResourceVersion})
+		require.NoError(t, err)
+		require.Nil(t, rsp.Error)
+		return rsp.ResourceVersion
+	}
+	remove := func(name string) {
+		t.Helper()
+		key := &resource.ResourceKey{Namespace: "default", Group: "dashboard.grafana.app", Resource: "dashboards", Name: name}
+		rsp, err := client.Delete(ctx, &resource.DeleteRequest{Key: key})
+		require.NoError(t, err)
+		require.Nil(t, rsp.Error)
+	}
+
+	write("folders", "f1", "", "F1")
+	write("folders", "f2", "f1", "F2")
+	write("dashboards", "a", "f1", "A1")
+	write("dashboards", "b", "", "B1")
+	rv := write("dashboards", "c", "f2", "C1")
+
+	write("dashboards", "a", "f1", "A2")
+	remove("b")
+	remove("c")
+	write("dashboards", "d", "f1", "D1")
+	write("folders", "f3", "f2", "F3")
+
+	restorer := NewRestorer(client)
+	summary := func(changes []Change) []string {
+		s := make([]string, 0, len(changes))
+		for _, c := range changes {
+			s = append(s, string(c.Action)+" "+c.Resource+"/"+c.Name)
+		}
+		return s
+	}
+	spec := func(name string) any {
+		t.Helper()
+		read, err := client.Read(ctx, &resource.ReadRequest{Key: &resource.ResourceKey{
+			Namespace: "default", Group: "dashboard.grafana.app", Resource: "dashboards", Name: name,
+		}})
+		require.NoError(t, err)
+		if read.Error != nil {
+			return nil
+		}
+		obj := &unstructured.Unstructured{}
+		require.NoError(t, obj.UnmarshalJSON(read.Value))
+		return obj.Object["spec"]
+	}
+	expected := []string{
+		"update dashboards/a",
+		"create dashboards/b",
+		"create dashboards/c",
+		"delete dashboards/d",
+		"delete folders/f3",
+	}
+
+	t.Run("preview", func(t *testing.T) {
+		plan, err := restorer.Preview(ctx, Request{Namespace: "default", ResourceVersion: rv})
+		require.NoError(t, err)
+		require.Equal(t, expected, summary(plan.Changes))
+		require.NotEmpty(t, plan.Hash)
+	})
+
+	t.Run("preview a folder", func(t *testing.T) {
+		plan, err := restorer.Preview(ctx, Request{Namespace: "default", ResourceVersion: rv, Folder: "f2"})
+		require.NoError(t, err)
+		require.Equal(t, []string{
+			"create dashboards/c",
+			"delete folders/f3",
+		}, summary(plan.Changes))
+	})
+
+	t.Run("apply requires the hash of the preview", func(t *testing.T) {
+		_, err := restorer.Apply(ctx, Request{Namespace: "default", ResourceVersion: rv}, "")
+		require.ErrorIs(t, err, ErrBadRequest)
+	})
+
+	t.Run("a resource changed since the preview fails the restore", func(t *testing.T) {
+		plan, err := restorer.Preview(ctx, Request{Namespace: "default", ResourceVersion: rv})
+		require.NoError(t, err)
+		write("dashboards", "d", "f1", "D2")
+
+		_, err = restorer.Apply(ctx, Request{Namespace: "default", ResourceVersion: rv}, plan.Hash)
+		require.ErrorIs(t, err, ErrPlanChanged)
+		require.Equal(t, map[string]any{"title": "A2"}, spec("a"))
+	})
+
+	t.Run("a resource changed during the restore fails it", func(t *testing.T) {
+		plan, err := restorer.Preview(ctx, Request{Namespace: "default", ResourceVersion: rv})
+		require.NoError(t, err)
+		changing := NewRestorer(&changingClient{ResourceClient: client, change: func() {
+			write("dashboards", "d", "f1", "D3")
+		}})
+
+		result, err := changing.Apply(ctx, Request{Namespace: "default", ResourceVersion: rv}, plan.Hash)
+		require.NoError(t, err)
+		require.Contains(t, result.Error, "optimistic locking failed")
+		require.Equal(t, 0, result.Applied)
+
+		// none of the changes is applied
+		require.Equal(t, map[string]any{"title": "A2"}, spec("a"))
+		require.Nil(t, spec("b"))
+		require.Nil(t, spec("c"))
+		plan, err = restorer.Preview(ctx, Request{Namespace: "default", ResourceVersion: rv})
+		require.NoError(t, err)
+		require.Equal(t, expected, summary(plan.Changes))
+	})
+
+	t.Run("apply", func(t *testing.T) {
+		plan, err := restorer.Preview(ctx, Request{Namespace: "default", ResourceVersion: rv})
+		require.NoError(t, err)
+		result, err := restorer.Apply(ctx, Request{Namespace: "default", ResourceVersion: rv}, plan.Hash)
+		require.NoError(t, err)
+		require.Empty(t, result.Error)
+		require.Equal(t, 5, result.Applied)
+
+		read, err := client.Read(ctx, &resource.ReadRequest{Key: &resource.ResourceKey{
+			Namespace: "default", Group: "dashboard.grafana.app", Resource: "dashboards", Name: "a",
+		}})
+		require.NoError(t, err)
+		require.Nil(t, read.Error)
+		obj := &unstructured.Unstructured{}
+		require.NoError(t, obj.UnmarshalJSON(read.Value))
+		require.Equal(t, map[string]any{"title": "A1"}, obj.Object["spec"])
+		meta, err := utils.MetaAccessor(obj)
+		require.NoError(t, err)
+		require.Contains(t, meta.GetMessage(), result.ID)
+
+		// nothing left to restore
+		plan, err = restorer.Preview(ctx, Request{Namespace: "default", ResourceVersion: rv})
+		require.NoError(t, err)
+		require.Empty(t, plan.Changes)
+	})
+}
+
+func TestSortChanges(t *testing.T) {
+	changes := []Change{
+		{Group: "folder.grafana.app", Resource: "folders", Name: "parent", Action: ActionDelete},
+		{Group: "folder.grafana.app", Resource: "folders", Name: "child", Action: ActionDelete, depth: 1},
+		{Group: "dashboard.grafana.app", Resource: "dashboards", Name: "a", Action: ActionCreate},
+		{Group: "folder.grafana.app", Resource: "folders", Name: "new-child", Action: ActionCreate, depth: 1},
+		{Group: "folder.grafana.app", Resource: "folders", Name: "new-parent", Action: ActionCreate},
+	}
+	sortChanges(changes)
+
+	names := make([]string, 0, len(changes))
+	for _, c := range changes {
+		names = append(names, c.Name)
+	}
+	require.Equal(t, []string{"new-parent", "new-child", "a", "child", "parent"}, names)
+}
+
+// changingClient changes a resource before sending a bulk, like a write made while a restore is applied
+type changingClient struct {
+	resource.ResourceClient
+	change func()
+}
+
+func (c *changingClient) BulkProcess(ctx context.Context, opts ...grpc.CallOption) (resource.BulkStore_BulkProcessClient, error) {
+	c.change()
+	return c.ResourceClient.BulkProcess(ctx, opts...)
+}
+
+func newTestClient(t *testing.T) resource.ResourceClient {
+	t.Helper()
+
+	backend, err := kv.NewBackend(kv.BackendOptions{Path: filepath.Join(t.TempDir(), "resource.db")})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = backend.Stop(context.Background())
+	})
+	server, err := resource.NewResourceServer(resource.ResourceServerOptions{Backend: backend})
+	require.NoError(t, err)
+	return resource.NewLocalResourceClient(server)
+}
+
+func TestAPIAudit(t *testing.T) {
+	auditLog := &auditlogtest.FakeService{}
+	api := &API{auditLog: auditLog}
+	c := &contextmodel.ReqContext{Context: &web.Context{Req: httptest.NewRequest(http.MethodPost, "/api/admin/unified-storage/restore/apply", nil)}}
+
+	api.audit(c, Request{Folder: "f1"}, &Result{
+		Plan:  Plan{Namespace: "default", ResourceVersion: 10, Changes: []Change{{}, {}}},
+		ID:    "restore-id",
+		Error: "dashboards/d changed",
+	})
+	require.Len(t, auditLog.Events, 1)
+	event := auditLog.Events[0]
+	require.Equal(t, auditlog.ActionNamespaceRestore, event.Action)
+	require.Equal(t, auditlog.ResultFailure, event.Result)
+	require.Equal(t, "default", event.ResourceUID)
+	require.Equal(t, map[string]string{
+		"id":              "restore-id",
+		"resourceVersion": "10",
+		"changes":         "2",
+		"applied":         "0",
+		"folder":          "f1",
+		"error":           "dashboards/d changed",
+	}, event.Details)
+}
@@ -1,6 +1,7 @@
 package search
 
 import (
+	"cmp"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -12,11 +13,13 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/blevesearch/bleve/v2"
 	"github.com/blevesearch/bleve/v2/analysis/analyzer/keyword"
 	"github.com/blevesearch/bleve/v2/analysis/analyzer/standard"
+	"github.com/blevesearch/bleve/v2/index/scorch"
 	"github.com/blevesearch/bleve/v2/mapping"
 	"github.com/blevesearch/bleve/v2/search"
 	"github.com/blevesearch/bleve/v2/search/query"
@@ -49,6 +52,12 @@ type BleveOptions struct {
 	// How big should a batch get before flushing
 	// ?? not totally sure the units
 	BatchSize int
+
+	// How often the file based indexes are checked for compaction, zero disables it
+	CompactionInterval time.Duration
+
+	// The share of reclaimable bytes (from deleted and updated documents) that triggers a compaction
+	CompactionThreshold float64
 }
 
 type bleveBackend struct {
@@ -61,10 +70,22 @@ type bleveBackend struct {
 	cache   map[resource.NamespacedResource]*bleveIndex
 	cacheMu sync.RWMutex
 
+	// The indexes being built, or that failed to build
+	builds   map[resource.NamespacedResource]resource.IndexBuildStatus
+	buildsMu sync.Mutex
+
 	features     featuremgmt.FeatureToggles
 	indexMetrics *resource.BleveIndexMetrics
+
+	// Stops the background tasks
+	cancel context.CancelFunc
+	tasks  sync.WaitGroup
 }
 
+// How often the resource versions of the file based indexes are saved. Saving an older
+// resource version is safe, the changes after it are indexed again on startup
+const resourceVersionSaveInterval = 10 * time.Second
+
 func NewBleveBackend(opts BleveOptions, tracer trace.Tracer, features featuremgmt.FeatureToggles, indexMetrics *resource.BleveIndexMetrics) (*bleveBackend, error) {
 	if opts.Root == "" {
 		return nil, fmt.Errorf("bleve backend missing root folder configuration")
@@ -81,17 +102,51 @@ func NewBleveBackend(opts BleveOptions, tracer trace.Tracer, features featuremgm
 		log:          slog.Default().With("logger", "bleve-backend"),
 		tracer:       tracer,
 		cache:        make(map[resource.NamespacedResource]*bleveIndex),
+		builds:       make(map[resource.NamespacedResource]resource.IndexBuildStatus),
 		opts:         opts,
 		start:        time.Now(),
 		features:     features,
 		indexMetrics: indexMetrics,
 	}
 
-	go bleveBackend.updateIndexSizeMetric(opts.Root)
+	ctx, cancel := context.WithCancel(context.Background())
+	bleveBackend.cancel = cancel
+	bleveBackend.runTask(func() { bleveBackend.updateIndexSizeMetric(ctx, opts.Root) })
+	bleveBackend.runTask(func() { bleveBackend.saveResourceVersions(ctx) })
+	if opts.CompactionInterval > 0 {
+		bleveBackend.runTask(func() { bleveBackend.compactIndexes(ctx) })
+	}
 
 	return bleveBackend, nil
 }
 
+func (b *bleveBackend) runTask(task func()) {
+	b.tasks.Add(1)
+	go func() {
+		defer b.tasks.Done()
+		task()
+	}()
+}
+
+// Stop implements resource.SearchBackend.
+// It stops the background tasks, saves the resource version of the file based indexes and closes them.
+func (b *bleveBackend) Stop() {
+	b.cancel()
+	b.tasks.Wait()
+
+	b.cacheMu.Lock()
+	defer b.cacheMu.Unlock()
+	for key, idx := range b.cache {
+		if err := idx.saveResourceVersion(); err != nil {
+			b.log.Warn("error saving index resource version", "namespace", key.Namespace, "group", key.Group, "resource", key.Resource, "error", err)
+		}
+		if err := idx.index.Close(); err != nil {
+			b.log.Warn("error closing index", "namespace", key.Namespace, "group", key.Group, "resource", key.Resource, "error", err)
+		}
+		delete(b.cache, key)
+	}
+}
+
 // This will return nil if the key does not exist
 func (b *bleveBackend) GetIndex(ctx context.Context, key resource.NamespacedResource) (resource.ResourceIndex, error) {
 	b.cacheMu.RLock()
@@ -105,11 +160,13 @@ func (b *bleveBackend) GetIndex(ctx context.Context, key resource.NamespacedReso
 }
 
 // updateIndexSizeMetric sets the total size of all file-based indices metric.
-func (b *bleveBackend) updateIndexSizeMetric(indexPath string) {
+func (b *bleveBackend) updateIndexSizeMetric(ctx context.Context, indexPath string) {
 	if b.indexMetrics == nil {
 		return
 	}
 
+	ticker := time.NewTicker(60 * time.Second)
+	defer ticker.Stop()
 	for {
 		var totalSize int64
 
@@ -133,7 +190,11 @@ func (b *bleveBackend) updateIndexSizeMetric(indexPath string) {
 			b.log.Error("got error while trying to calculate bleve file index size", "error", err)
 		}
 
-		time.Sleep(60 * time.Second)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
 	}
 }
 
@@ -157,8 +218,47 @@ func (b *bleveBackend) BuildIndex(ctx context.Context,
 	_, span := b.tracer.Start(ctx, tracingPrexfixBleve+"BuildIndex")
 	defer span.End()
 
+	status := resource.IndexBuildStatus{
+		State:   resource.IndexBuildStateBuilding,
+		Mode:    resource.IndexBuildModeFull,
+		Started: time.Now(),
+	}
+	b.setBuildStatus(key, &status)
+
+	idx, err := b.buildIndex(key, size, resourceVersion, fields, builder, &status)
+	if err != nil {
+		status.State = resource.IndexBuildStateFailed
+		status.Error = err.Error()
+		b.setBuildStatus(key, &status)
+		return nil, err
+	}
+	status.State = resource.IndexBuildStateReady
+	status.Duration = time.Since(status.Started).String()
+
+	idx.statusMu.Lock()
+	status.Updated, status.Deleted = idx.status.Updated, idx.status.Deleted
+	idx.status = status
+	idx.statusMu.Unlock()
+
+	b.cacheMu.Lock()
+	b.cache[key] = idx
+	b.cacheMu.Unlock()
+	b.setBuildStatus(key, nil)
+	return idx, nil
+}
+
+func (b *bleveBackend) buildIndex(
+	key resource.NamespacedResource,
+	size int64,
+	resourceVersion int64,
+	fields resource.SearchableDocumentFields,
+	builder func(index resource.ResourceIndex) (int64, error),
+	status *resource.IndexBuildStatus,
+) (*bleveIndex, error) {
 	var err error
 	var index bleve.Index
+	var dir string
+	var rv int64
 
 	build := true
 	mapper, err := GetBleveMappings(fields)
@@ -170,36 +270,31 @@ func (b *bleveBackend) BuildIndex(ctx context.Context,
 		resourceDir := filepath.Join(b.opts.Root, key.Namespace,
 			fmt.Sprintf("%s.%s", key.Resource, key.Group),
 		)
-		fname := fmt.Sprintf("rv%d", resourceVersion)
-		if resourceVersion == 0 {
-			fname = b.start.Format("tmp-20060102-150405")
-		}
-		dir := filepath.Join(resourceDir, fname)
-		if !isValidPath(dir, b.opts.Root) {
-			b.log.Error("Directory is not valid", "directory", dir)
-		}
-		if resourceVersion > 0 {
-			info, _ := os.Stat(dir)
-			if info != nil && info.IsDir() {
-				index, err = bleve.Open(dir) // NOTE, will use the same mappings!!!
-				if err == nil {
-					found, err := index.DocCount()
-					if err != nil || int64(found) != size {
-						b.log.Info("this size changed since the last time the index opened")
-						_ = index.Close()
-
-						// Pick a new file name
-						fname = b.start.Format("tmp-20060102-150405-changed")
-						dir = filepath.Join(resourceDir, fname)
-						index = nil
-					} else {
-						build = false // no need to build the index
-					}
-				}
+
+		// Continue from the index saved by the last run, when it has the same mappings
+		index, dir, rv = b.openPersistedIndex(resourceDir, mapper)
+		if index != nil {
+			status.Mode = resource.IndexBuildModeIncremental
+			found, err := index.DocCount()
+			if err == nil && resourceVersion > 0 && rv == resourceVersion && int64(found) == size {
+				status.Mode = resource.IndexBuildModeReused
+				build = false // no need to build the index
 			}
 		}
 
 		if index == nil {
+			fname := fmt.Sprintf("rv%d", resourceVersion)
+			if resourceVersion == 0 {
+				fname = b.start.Format("tmp-20060102-150405")
+			}
+			dir = filepath.Join(resourceDir, fname)
+			if !isValidPath(dir, b.opts.Root) {
+				b.log.Error("Directory is not valid", "directory", dir)
+			}
+			// A saved index that could not be used
+			if err = os.RemoveAll(dir); err != nil {
+				return nil, err
+			}
 			index, err = bleve.New(dir, mapper)
 			if err != nil {
 				err = fmt.Errorf("error creating new bleve index: %s %w", dir, err)
@@ -208,7 +303,7 @@ func (b *bleveBackend) BuildIndex(ctx context.Context,
 
 		// Start a background task to cleanup the old index directories
 		if index != nil && err == nil {
-			go b.cleanOldIndexes(resourceDir, fname)
+			go b.cleanOldIndexes(resourceDir, filepath.Base(dir))
 		}
 		if b.indexMetrics != nil {
 			b.indexMetrics.IndexTenants.WithLabelValues("file").Inc()
@@ -227,6 +322,7 @@ func (b *bleveBackend) BuildIndex(ctx context.Context,
 	idx := &bleveIndex{
 		key:       key,
 		index:     index,
+		path:      dir,
 		batch:     index.NewBatch(),
 		batchSize: b.opts.BatchSize,
 		fields:    fields,
@@ -235,30 +331,215 @@ func (b *bleveBackend) BuildIndex(ctx context.Context,
 		tracing:   b.tracer,
 	}
 
+	idx.rv.Store(rv)
+	idx.savedRV.Store(rv)
+
 	idx.allFields, err = getAllFields(idx.standard, fields)
 	if err != nil {
+		_ = index.Close()
 		return nil, err
 	}
 
 	if build {
-		_, err = builder(idx)
+		rv, err = builder(idx)
 		if err != nil {
+			_ = index.Close()
 			return nil, err
 		}
 
 		// Flush the batch
 		err = idx.Flush()
 		if err != nil {
+			_ = index.Close()
 			return nil, err
 		}
-	}
 
-	b.cacheMu.Lock()
-	b.cache[key] = idx
-	b.cacheMu.Unlock()
+		if err = idx.UpdateResourceVersion(rv); err != nil {
+			_ = index.Close()
+			return nil, err
+		}
+		if err = idx.saveResourceVersion(); err != nil {
+			_ = index.Close()
+			return nil, err
+		}
+	} else {
+		idx.batch = nil
+	}
 	return idx, nil
 }
 
+// openPersistedIndex opens the newest index saved in the resource directory.
+// It returns nil when there is none, or when it was created with different mappings.
+func (b *bleveBackend) openPersistedIndex(resourceDir string, mapper mapping.IndexMapping) (bleve.Index, string, int64) {
+	files, err := os.ReadDir(resourceDir)
+	if err != nil {
+		return nil, "", 0
+	}
+	var newest string
+	var newestTime time.Time
+	for _, file := range files {
+		if !file.IsDir() {
+			continue
+		}
+		info, err := file.Info()
+		if err != nil {
+			continue
+		}
+		if newest == "" || info.ModTime().After(newestTime) {
+			newest = file.Name()
+			newestTime = info.ModTime()
+		}
+	}
+	if newest == "" {
+		return nil, "", 0
+	}
+
+	dir := filepath.Join(resourceDir, newest)
+	if !isValidPath(dir, b.opts.Root) {
+		b.log.Error("Directory is not valid", "directory", dir)
+		return nil, "", 0
+	}
+	index, err := bleve.Open(dir)
+	if err != nil {
+		b.log.Info("unable to open saved index", "directory", dir, "error", err)
+		return nil, "", 0
+	}
+	if !sameMappings(index.Mapping(), mapper) {
+		b.log.Info("the index mappings changed since the index was saved", "directory", dir)
+		_ = index.Close()
+		return nil, "", 0
+	}
+
+	var rv int64
+	v, err := index.GetInternal(internalResourceVersionKey)
+	if err == nil && len(v) > 0 {
+		rv, _ = strconv.ParseInt(string(v), 10, 64)
+	}
+	return index, dir, rv
+}
+
+// sameMappings compares the mappings of a saved index with the current ones, after the same JSON round trip
+func sameMappings(saved mapping.IndexMapping, current mapping.IndexMapping) bool {
+	a, err := json.Marshal(saved)
+	if err != nil {
+		return false
+	}
+	b, err := json.Marshal(current)
+	if err != nil {
+		return false
+	}
+	tmp := mapping.NewIndexMapping()
+	if err = json.Unmarshal(b, tmp); err != nil {
+		return false
+	}
+	b, err = json.Marshal(tmp)
+	if err != nil {
+		return false
+	}
+	return string(a) == string(b)
+}
+
+func (b *bleveBackend) setBuildStatus(key resource.NamespacedResource, status *resource.IndexBuildStatus) {
+	b.buildsMu.Lock()
+	defer b.buildsMu.Unlock()
+	if status == nil {
+		delete(b.builds, key)
+	} else {
+		b.builds[key] = *status
+	}
+}
+
+// IndexStatus implements resource.SearchBackend.
+func (b *bleveBackend) IndexStatus(ctx context.Context) []resource.IndexStatus {
+	b.cacheMu.RLock()
+	indexes := make([]*bleveIndex, 0, len(b.cache))
+	for _, idx := range b.cache {
+		indexes = append(indexes, idx)
+	}
+	b.cacheMu.RUnlock()
+
+	status := make([]resource.IndexStatus, 0, len(indexes))
+	for _, idx := range indexes {
+		status = append(status, idx.indexStatus())
+	}
+
+	b.buildsMu.Lock()
+	for key, build := range b.builds {
+		status = append(status, resource.IndexStatus{
+			Namespace: key.Namespace,
+			Group:     key.Group,
+			Resource:  key.Resource,
+			Build:     build,
+		})
+	}
+	b.buildsMu.Unlock()
+
+	slices.SortFunc(status, func(a, b resource.IndexStatus) int {
+		return cmp.Or(
+			cmp.Compare(a.Namespace, b.Namespace),
+			cmp.Compare(a.Group, b.Group),
+			cmp.Compare(a.Resource, b.Resource),
+		)
+	})
+	return status
+}
+
+// fileIndexes returns the file based indexes
+func (b *bleveBackend) fileIndexes() []*bleveIndex {
+	b.cacheMu.RLock()
+	defer b.cacheMu.RUnlock()
+	indexes := make([]*bleveIndex, 0, len(b.cache))
+	for _, idx := range b.cache {
+		if idx.path != "" {
+			indexes = append(indexes, idx)
+		}
+	}
+	return indexes
+}
+
+// saveResourceVersions periodically saves the resource version of the file based indexes,
+// instead of writing it with every indexed change
+func (b *bleveBackend) saveResourceVersions(ctx context.Context) {
+	ticker := time.NewTicker(resourceVersionSaveInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		for _, idx := range b.fileIndexes() {
+			if err := idx.saveResourceVersion(); err != nil {
+				b.log.Warn("error saving index resource version", "namespace", idx.key.Namespace, "group", idx.key.Group, "resource", idx.key.Resource, "error", err)
+			}
+		}
+	}
+}
+
+// compactIndexes periodically merges the segments of the file based indexes
+// with a large share of reclaimable space
+func (b *bleveBackend) compactIndexes(ctx context.Context) {
+	ticker := time.NewTicker(b.opts.CompactionInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		for _, idx := range b.fileIndexes() {
+			compacted, err := idx.compact(ctx, b.opts.CompactionThreshold)
+			if err != nil {
+				b.log.Warn("error compacting index", "namespace", idx.key.Namespace, "group", idx.key.Group, "resource", idx.key.Resource, "error", err)
+			} else if compacted {
+				b.log.Info("compacted index", "namespace", idx.key.Namespace, "group", idx.key.Group, "resource", idx.key.Resource)
+			}
+		}
+	}
+}
+
 func (b *bleveBackend) cleanOldIndexes(dir string, skip string) {
 	files, err := os.ReadDir(dir)
 	if err != nil {
@@ -309,10 +590,24 @@ func (b *bleveBackend) TotalDocs() int64 {
 	return totalDocs
 }
 
+// The internal key where the resource version of the last indexed change is saved
+var internalResourceVersionKey = []byte("rv")
+
 type bleveIndex struct {
 	key   resource.NamespacedResource
 	index bleve.Index
 
+	// The directory of file based indexes, empty for memory indexes
+	path string
+
+	// The resource version of the last indexed change, and the one saved in the index
+	rv      atomic.Int64
+	savedRV atomic.Int64
+
+	status         resource.IndexBuildStatus
+	lastCompaction *time.Time
+	statusMu       sync.Mutex
+
 	standard resource.SearchableDocumentFields
 	fields   resource.SearchableDocumentFields
 
@@ -338,6 +633,7 @@ func (b *bleveIndex) Write(v *resource.IndexableDocument) error {
 		if err != nil {
 			return err
 		}
+		b.countBuildChange(1, 0)
 		if b.batch.Size() > b.batchSize {
 			err = b.index.Batch(b.batch)
 			b.batch.Reset() // clear the batch
@@ -350,11 +646,144 @@ func (b *bleveIndex) Write(v *resource.IndexableDocument) error {
 // Delete implements resource.DocumentIndex.
 func (b *bleveIndex) Delete(key *resource.ResourceKey) error {
 	if b.batch != nil {
-		return fmt.Errorf("unexpected delete while building batch")
+		b.batch.Delete(key.SearchID())
+		b.countBuildChange(0, 1)
+		return nil
 	}
 	return b.index.Delete(key.SearchID())
 }
 
+func (b *bleveIndex) countBuildChange(updated, deleted int64) {
+	b.statusMu.Lock()
+	b.status.Updated += updated
+	b.status.Deleted += deleted
+	b.statusMu.Unlock()
+}
+
+// DocumentVersions implements resource.ResourceIndex.
+func (b *bleveIndex) DocumentVersions(ctx context.Context) (map[string]int64, error) {
+	count, err := b.index.DocCount()
+	if err != nil {
+		return nil, err
+	}
+	versions := make(map[string]int64, count)
+	if count == 0 {
+		return versions, nil
+	}
+
+	req := bleve.NewSearchRequestOptions(bleve.NewMatchAllQuery(), int(count), 0, false)
+	req.Fields = []string{resource.SEARCH_FIELD_RV}
+	found, err := b.index.SearchInContext(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	key := &resource.ResourceKey{}
+	for _, hit := range found.Hits {
+		if err = key.ReadSearchID(hit.ID); err != nil {
+			return nil, err
+		}
+		rv, _ := hit.Fields[resource.SEARCH_FIELD_RV].(float64)
+		versions[key.Name] = int64(rv)
+	}
+	return versions, nil
+}
+
+// UpdateResourceVersion implements resource.ResourceIndex.
+// The resource version is saved by the background task of the backend, not with every change.
+func (b *bleveIndex) UpdateResourceVersion(rv int64) error {
+	if rv > b.rv.Load() {
+		b.rv.Store(rv)
+	}
+	return nil
+}
+
+// ResourceVersion implements resource.ResourceIndex.
+func (b *bleveIndex) ResourceVersion() int64 {
+	return b.rv.Load()
+}
+
+// saveResourceVersion saves the resource version of a file based index when it changed since it was last saved
+func (b *bleveIndex) saveResourceVersion() error {
+	rv := b.rv.Load()
+	if b.path == "" || rv == b.savedRV.Load() {
+		return nil
+	}
+	if err := b.index.SetInternal(internalResourceVersionKey, []byte(strconv.FormatInt(rv, 10))); err != nil {
+		return err
+	}
+	b.savedRV.Store(rv)
+	return nil
+}
+
+func (b *bleveIndex) indexStatus() resource.IndexStatus {
+	status := resource.IndexStatus{
+		Namespace:       b.key.Namespace,
+		Group:           b.key.Group,
+		Resource:        b.key.Resource,
+		Storage:         resource.IndexStorageMemory,
+		ResourceVersion: b.rv.Load(),
+	}
+	if count, err := b.index.DocCount(); err == nil {
+		status.Documents = int64(count)
+	}
+	if b.path != "" {
+		status.Storage = resource.IndexStorageFile
+		status.SizeBytes, _ = dirSize(b.path)
+	}
+
+	b.statusMu.Lock()
+	status.Build = b.status
+	status.LastCompaction = b.lastCompaction
+	b.statusMu.Unlock()
+	return status
+}
+
+// compact merges the index segments when the reclaimable share of the disk usage reaches the threshold
+func (b *bleveIndex) compact(ctx context.Context, threshold float64) (bool, error) {
+	idx, err := b.index.Advanced()
+	if err != nil {
+		return false, err
+	}
+	s, ok := idx.(*scorch.Scorch)
+	if !ok {
+		return false, nil
+	}
+	stats := s.StatsMap()
+	used, _ := stats["num_bytes_used_disk_by_root"].(uint64)
+	reclaimable, _ := stats["num_bytes_used_disk_by_root_reclaimable"].(uint64)
+	if used == 0 || float64(reclaimable)/float64(used) < threshold {
+		return false, nil
+	}
+
+	// nil merges everything into a single segment
+	if err = s.ForceMerge(ctx, nil); err != nil {
+		return false, err
+	}
+	now := time.Now()
+	b.statusMu.Lock()
+	b.lastCompaction = &now
+	b.statusMu.Unlock()
+	return true, nil
+}
+
+func dirSize(dir string) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(dir, func(path string, info os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			fileInfo, err := info.Info()
+			if err != nil {
+				return err
+			}
+			size += fileInfo.Size()
+		}
+		return nil
+	})
+	return size, err
+}
+
 // Flush implements resource.DocumentIndex.
 func (b *bleveIndex) Flush() (err error) {
 	if b.batch != nil {
@@ -125,6 +125,9 @@ func getBleveDocMappings(_ resource.SearchableDocumentFields) *mapping.DocumentM
 	})
 	source.AddFieldMappingsAt("timestampMillis", mapping.NewNumericFieldMapping())
 
+	// The resource version is stored, to find the changed documents when an index is loaded from disk
+	mapper.AddFieldMappingsAt(resource.SEARCH_FIELD_RV, mapping.NewNumericFieldMapping())
+
 	mapper.AddSubDocumentMapping("source", source)
 	mapper.AddSubDocumentMapping("manager", manager)
 	mapper.AddFieldMappingsAt(resource.SEARCH_FIELD_MANAGED_BY, &mapping.FieldMapping{
@@ -141,6 +144,18 @@ func getBleveDocMappings(_ resource.SearchableDocumentFields) *mapping.DocumentM
 	mapper.AddSubDocumentMapping(resource.SEARCH_FIELD_LABELS, labelMapper)
 
 	fieldMapper := bleve.NewDocumentMapping()
+	// The query fields are matched and faceted as exact values
+	for _, f := range []string{DASHBOARD_DS_UIDS, DASHBOARD_QUERY_METRICS, DASHBOARD_QUERY_LABELS} {
+		fieldMapper.AddFieldMappingsAt(f, &mapping.FieldMapping{
+			Name:               f,
+			Type:               "text",
+			Analyzer:           keyword.Name,
+			Store:              true,
+			Index:              true,
+			IncludeTermVectors: false,
+			IncludeInAll:       false,
+		})
+	}
 	mapper.AddSubDocumentMapping("fields", fieldMapper)
 
 	return mapper
@@ -8,6 +8,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -20,6 +21,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/featuremgmt"
 	"github.com/grafana/grafana/pkg/services/store/kind/dashboard"
 	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/storage/unified/kv"
 	"github.com/grafana/grafana/pkg/storage/unified/resource"
 )
 
@@ -80,6 +82,9 @@ func TestBleveBackend(t *testing.T) {
 					DASHBOARD_PANEL_TYPES:       []string{"timeseries", "table"},
 					DASHBOARD_ERRORS_TODAY:      25,
 					DASHBOARD_VIEWS_LAST_1_DAYS: 50,
+					DASHBOARD_DS_UIDS:           []string{"prom"},
+					DASHBOARD_QUERY_METRICS:     []string{"http_requests_total", "job:up:sum"},
+					DASHBOARD_QUERY_LABELS:      []string{"cluster", "job"},
 				},
 				Labels: map[string]string{
 					utils.LabelKeyDeprecatedInternalID: "10", // nolint:staticcheck
@@ -110,6 +115,9 @@ func TestBleveBackend(t *testing.T) {
 					DASHBOARD_PANEL_TYPES:       []string{"timeseries"},
 					DASHBOARD_ERRORS_TODAY:      40,
 					DASHBOARD_VIEWS_LAST_1_DAYS: 100,
+					DASHBOARD_DS_UIDS:           []string{"logs", "prom"},
+					DASHBOARD_QUERY_METRICS:     []string{"node_cpu_seconds_total"},
+					DASHBOARD_QUERY_LABELS:      []string{"cluster"},
 				},
 				Tags: []string{"aa"},
 				Labels: map[string]string{
@@ -243,6 +251,46 @@ func TestBleveBackend(t *testing.T) {
 		require.NoError(t, err)
 		require.Equal(t, int64(100), val)
 
+		// can filter and facet by the query fields
+		rsp, err = index.Search(ctx, NewStubAccessClient(map[string]bool{"dashboards": true}), &resource.ResourceSearchRequest{
+			Options: &resource.ListOptions{
+				Key: key,
+				Fields: []*resource.Requirement{{
+					Key:      "fields." + DASHBOARD_QUERY_LABELS,
+					Operator: "=",
+					Values:   []string{"cluster"},
+				}},
+			},
+			Limit: 100000,
+			Facet: map[string]*resource.ResourceSearchRequest_Facet{
+				DASHBOARD_DS_UIDS: {
+					Field: "fields." + DASHBOARD_DS_UIDS,
+					Limit: 100,
+				},
+			},
+		}, nil)
+		require.NoError(t, err)
+		require.Equal(t, int64(2), rsp.TotalHits)
+		require.Equal(t, []*resource.ResourceSearchResponse_TermFacet{
+			{Term: "prom", Count: 2},
+			{Term: "logs", Count: 1},
+		}, rsp.Facet[DASHBOARD_DS_UIDS].Terms)
+
+		rsp, err = index.Search(ctx, NewStubAccessClient(map[string]bool{"dashboards": true}), &resource.ResourceSearchRequest{
+			Options: &resource.ListOptions{
+				Key: key,
+				Fields: []*resource.Requirement{{
+					Key:      "fields." + DASHBOARD_QUERY_METRICS,
+					Operator: "=",
+					Values:   []string{"job:up:sum"},
+				}},
+			},
+			Limit: 100000,
+		}, nil)
+		require.NoError(t, err)
+		require.Equal(t, int64(1), rsp.TotalHits)
+		require.Equal(t, "aaa", rsp.Results.Rows[0].Key.Name)
+
 		// check auth will exclude results we don't have access to
 		rsp, err = index.Search(ctx, NewStubAccessClient(map[string]bool{"dashboards": false}), &resource.ResourceSearchRequest{
 			Options: &resource.ListOptions{
@@ -540,6 +588,206 @@ func TestBleveBackend(t *testing.T) {
 	})
 }
 
+func TestBleveIndexPersistence(t *testing.T) {
+	root := t.TempDir()
+	key := resource.NamespacedResource{
+		Namespace: "default",
+		Group:     "dashboard.grafana.app",
+		Resource:  "dashboards",
+	}
+	doc := func(name string, rv int64) *resource.IndexableDocument {
+		return &resource.IndexableDocument{
+			RV:    rv,
+			Name:  name,
+			Title: name,
+			Key: &resource.ResourceKey{
+				Name:      name,
+				Namespace: key.Namespace,
+				Group:     key.Group,
+				Resource:  key.Resource,
+			},
+		}
+	}
+	build := func(t *testing.T, size int64, rv int64, builder func(index resource.ResourceIndex) (int64, error)) (*bleveBackend, resource.IndexStatus) {
+		backend, err := NewBleveBackend(BleveOptions{
+			Root:          root,
+			FileThreshold: 1,
+			BatchSize:     10,
+		}, tracing.NewNoopTracerService(), featuremgmt.WithFeatures(), nil)
+		require.NoError(t, err)
+
+		_, err = backend.BuildIndex(context.Background(), key, size, rv, nil, builder)
+		require.NoError(t, err)
+
+		status := backend.IndexStatus(context.Background())
+		require.Len(t, status, 1)
+		return backend, status[0]
+	}
+	closeIndex := func(backend *bleveBackend) {
+		backend.Stop()
+	}
+
+	backend, status := build(t, 3, 30, func(index resource.ResourceIndex) (int64, error) {
+		versions, err := index.DocumentVersions(context.Background())
+		require.NoError(t, err)
+		require.Empty(t, versions)
+		for i, name := range []string{"a", "b", "c"} {
+			require.NoError(t, index.Write(doc(name, int64(i+1)*10)))
+		}
+		return 30, nil
+	})
+	require.Equal(t, resource.IndexStorageFile, status.Storage)
+	require.Equal(t, resource.IndexBuildModeFull, status.Build.Mode)
+	require.Equal(t, resource.IndexBuildStateReady, status.Build.State)
+	require.Equal(t, int64(3), status.Build.Updated)
+	require.Equal(t, int64(30), status.ResourceVersion)
+	closeIndex(backend)
+
+	t.Run("reuse an unchanged index", func(t *testing.T) {
+		backend, status := build(t, 3, 30, func(index resource.ResourceIndex) (int64, error) {
+			return 0, fmt.Errorf("the index should not be built")
+		})
+		require.Equal(t, resource.IndexBuildModeReused, status.Build.Mode)
+		require.Equal(t, int64(3), status.Documents)
+		require.Equal(t, int64(30), status.ResourceVersion)
+		closeIndex(backend)
+	})
+
+	t.Run("update a changed index", func(t *testing.T) {
+		backend, status := build(t, 2, 40, func(index resource.ResourceIndex) (int64, error) {
+			versions, err := index.DocumentVersions(context.Background())
+			require.NoError(t, err)
+			require.Equal(t, map[string]int64{"a": 10, "b": 20, "c": 30}, versions)
+
+			require.NoError(t, index.Write(doc("a", 40)))
+			require.NoError(t, index.Delete(doc("c", 30).Key))
+			return 40, nil
+		})
+		require.Equal(t, resource.IndexBuildModeIncremental, status.Build.Mode)
+		require.Equal(t, int64(1), status.Build.Updated)
+		require.Equal(t, int64(1), status.Build.Deleted)
+		require.Equal(t, int64(2), status.Documents)
+		require.Equal(t, int64(40), status.ResourceVersion)
+		require.Greater(t, status.SizeBytes, int64(0))
+
+		idx := backend.cache[key]
+		require.NoError(t, idx.UpdateResourceVersion(50))
+		compacted, err := idx.compact(context.Background(), 0)
+		require.NoError(t, err)
+		require.True(t, compacted)
+		require.NotNil(t, idx.indexStatus().LastCompaction)
+		closeIndex(backend)
+	})
+
+	t.Run("read the last indexed resource version", func(t *testing.T) {
+		backend, status := build(t, 2, 50, func(index resource.ResourceIndex) (int64, error) {
+			return 0, fmt.Errorf("the index should not be built")
+		})
+		require.Equal(t, resource.IndexBuildModeReused, status.Build.Mode)
+		closeIndex(backend)
+	})
+}
+
+func TestBleveCatchUpFromSavedResourceVersion(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	key := resource.NamespacedResource{Namespace: "default", Group: "dashboard.grafana.app", Resource: "dashboards"}
+
+	start := func(t *testing.T) (*bleveBackend, *listCountingBackend, resource.ResourceServer) {
+		store, err := kv.NewBackend(kv.BackendOptions{Path: filepath.Join(dir, "resource.db")})
+		require.NoError(t, err)
+		counting := &listCountingBackend{Backend: store}
+		search, err := NewBleveBackend(BleveOptions{
+			Root:          dir,
+			FileThreshold: 1,
+			BatchSize:     10,
+		}, tracing.NewNoopTracerService(), featuremgmt.WithFeatures(), nil)
+		require.NoError(t, err)
+		server, err := resource.NewResourceServer(resource.ResourceServerOptions{
+			Backend:   counting,
+			Lifecycle: store,
+			Search: resource.SearchOptions{
+				Backend:   search,
+				Resources: &standardBuilders{},
+			},
+		})
+		require.NoError(t, err)
+		return search, counting, server
+	}
+	write := func(t *testing.T, store resource.StorageBackend, typ resource.WatchEvent_Type, name string, title string) {
+		value, err := json.Marshal(map[string]any{
+			"apiVersion": "dashboard.grafana.app/v1",
+			"kind":       "Dashboard",
+			"metadata":   map[string]any{"name": name, "namespace": key.Namespace},
+			"spec":       map[string]any{"title": title},
+		})
+		require.NoError(t, err)
+		_, err = store.WriteEvent(ctx, resource.WriteEvent{
+			Type:  typ,
+			Key:   &resource.ResourceKey{Namespace: key.Namespace, Group: key.Group, Resource: key.Resource, Name: name},
+			Value: value,
+		})
+		require.NoError(t, err)
+	}
+
+	// the first server indexes the resources written before and while it runs
+	search, store, server := start(t)
+	write(t, store, resource.WatchEvent_ADDED, "a", "A")
+	write(t, store, resource.WatchEvent_ADDED, "b", "B")
+	write(t, store, resource.WatchEvent_ADDED, "c", "C")
+	require.Eventually(t, func() bool {
+		idx, _ := search.GetIndex(ctx, key)
+		if idx == nil {
+			return false
+		}
+		count, err := idx.DocCount(ctx, "")
+		return err == nil && count == 3
+	}, 5*time.Second, 10*time.Millisecond)
+	require.NoError(t, server.(interface{ Stop(context.Context) error }).Stop(ctx))
+
+	// changes made while no server runs
+	offline, err := kv.NewBackend(kv.BackendOptions{Path: filepath.Join(dir, "resource.db")})
+	require.NoError(t, err)
+	write(t, offline, resource.WatchEvent_MODIFIED, "b", "B2")
+	write(t, offline, resource.WatchEvent_DELETED, "c", "C")
+	write(t, offline, resource.WatchEvent_ADDED, "d", "D")
+	require.NoError(t, offline.Stop(ctx))
+
+	// the saved index is updated with the changes since its resource version, without listing every resource
+	search, store, server = start(t)
+	defer func() {
+		_ = server.(interface{ Stop(context.Context) error }).Stop(ctx)
+	}()
+	require.Zero(t, store.lists)
+	status := search.IndexStatus(ctx)
+	require.Len(t, status, 1)
+	require.Equal(t, resource.IndexBuildModeIncremental, status[0].Build.Mode)
+	require.Equal(t, int64(2), status[0].Build.Updated)
+	require.Equal(t, int64(1), status[0].Build.Deleted)
+	require.Equal(t, int64(3), status[0].Documents)
+}
+
+// listCountingBackend counts the list requests
+type listCountingBackend struct {
+	kv.Backend
+	lists int
+}
+
+func (b *listCountingBackend) ListIterator(ctx context.Context, req *resource.ListRequest, fn func(resource.ListIterator) error) (int64, error) {
+	b.lists++
+	return b.Backend.ListIterator(ctx, req, fn)
+}
+
+func (b *listCountingBackend) ListModifiedSince(ctx context.Context, key resource.NamespacedResource, sinceRV int64, fn func(*resource.ModifiedResource) error) (int64, error) {
+	return b.Backend.(resource.ModifiedSinceLister).ListModifiedSince(ctx, key, sinceRV, fn)
+}
+
+type standardBuilders struct{}
+
+func (b *standardBuilders) GetDocumentBuilders() ([]resource.DocumentBuilderInfo, error) {
+	return []resource.DocumentBuilderInfo{{Builder: resource.StandardDocumentBuilder()}}, nil
+}
+
 func TestGetSortFields(t *testing.T) {
 	t.Run("will prepend 'fields.' to sort fields when they are dashboard fields", func(t *testing.T) {
 		searchReq := &resource.ResourceSearchRequest{
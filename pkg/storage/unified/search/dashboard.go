@@ -5,6 +5,7 @@ import (
 	"context"
 	"fmt"
 	"sort"
+	"strings"
 
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 
@@ -23,6 +24,10 @@ const DASHBOARD_LINK_COUNT = "link_count"
 const DASHBOARD_PANEL_TYPES = "panel_types"
 const DASHBOARD_DS_TYPES = "ds_types"
 const DASHBOARD_TRANSFORMATIONS = "transformation"
+const DASHBOARD_DS_UIDS = "ds_uids"
+const DASHBOARD_QUERY_METRICS = "query_metrics"
+const DASHBOARD_QUERY_LABELS = "query_labels"
+const DASHBOARD_QUERY_EXPRESSIONS = "query_expressions"
 
 //------------------------------------------------------------
 // The following fields are added in enterprise
@@ -68,6 +73,39 @@ func DashboardBuilder(namespaced resource.NamespacedDocumentSupplier) (resource.
 				Filterable: true,
 			},
 		},
+		{
+			Name:        DASHBOARD_DS_UIDS,
+			Type:        resource.ResourceTableColumnDefinition_STRING,
+			IsArray:     true,
+			Description: "The datasources used by the panels",
+			Properties: &resource.ResourceTableColumnDefinition_Properties{
+				Filterable: true,
+			},
+		},
+		{
+			Name:        DASHBOARD_QUERY_METRICS,
+			Type:        resource.ResourceTableColumnDefinition_STRING,
+			IsArray:     true,
+			Description: "The metrics selected by the panel queries",
+			Properties: &resource.ResourceTableColumnDefinition_Properties{
+				Filterable: true,
+			},
+		},
+		{
+			Name:        DASHBOARD_QUERY_LABELS,
+			Type:        resource.ResourceTableColumnDefinition_STRING,
+			IsArray:     true,
+			Description: "The labels matched or grouped by the panel queries",
+			Properties: &resource.ResourceTableColumnDefinition_Properties{
+				Filterable: true,
+			},
+		},
+		{
+			Name:        DASHBOARD_QUERY_EXPRESSIONS,
+			Type:        resource.ResourceTableColumnDefinition_STRING,
+			IsArray:     true,
+			Description: "The text of the panel queries",
+		},
 		{
 			Name:        DASHBOARD_ERRORS_TODAY,
 			Type:        resource.ResourceTableColumnDefinition_INT64,
@@ -294,8 +332,13 @@ func (s *DashboardDocumentBuilder) BuildDocument(ctx context.Context, key *resou
 		}
 	}
 
+	queries := readQueries(summary.Panels)
+
 	for _, ds := range summary.Datasource {
 		dsTypes = append(dsTypes, ds.Type)
+		if ds.UID != "" {
+			queries.datasources[ds.UID] = true
+		}
 		doc.References = append(doc.References, resource.ResourceReference{
 			Group:    ds.Type,
 			Kind:     "DataSource",
@@ -325,6 +368,16 @@ func (s *DashboardDocumentBuilder) BuildDocument(ctx context.Context, key *resou
 		sort.Strings(transformations)
 		doc.Fields[DASHBOARD_TRANSFORMATIONS] = transformations
 	}
+	for k, v := range map[string]map[string]bool{
+		DASHBOARD_DS_UIDS:           queries.datasources,
+		DASHBOARD_QUERY_METRICS:     queries.metrics,
+		DASHBOARD_QUERY_LABELS:      queries.labels,
+		DASHBOARD_QUERY_EXPRESSIONS: queries.expressions,
+	} {
+		if len(v) > 0 {
+			doc.Fields[k] = sortedKeys(v)
+		}
+	}
 
 	// Add the stats fields
 	for k, v := range s.Stats[summary.UID] {
@@ -334,6 +387,53 @@ func (s *DashboardDocumentBuilder) BuildDocument(ctx context.Context, key *resou
 	return doc, nil
 }
 
+// The datasources, metrics, labels and expressions of the panel queries
+type dashboardQueries struct {
+	datasources map[string]bool
+	metrics     map[string]bool
+	labels      map[string]bool
+	expressions map[string]bool
+}
+
+// readQueries collects the queries of all the panels, including the panels in collapsed rows
+func readQueries(panels []dashboard.PanelSummaryInfo) dashboardQueries {
+	q := dashboardQueries{
+		datasources: map[string]bool{},
+		metrics:     map[string]bool{},
+		labels:      map[string]bool{},
+		expressions: map[string]bool{},
+	}
+	var add func(panels []dashboard.PanelSummaryInfo)
+	add = func(panels []dashboard.PanelSummaryInfo) {
+		for _, p := range panels {
+			for _, query := range p.Queries {
+				if query.Datasource.UID != "" && !strings.HasPrefix(query.Datasource.UID, "$") && !strings.HasPrefix(query.Datasource.UID, "-- ") {
+					q.datasources[query.Datasource.UID] = true
+				}
+				q.expressions[query.Expression] = true
+				for _, m := range query.Metrics {
+					q.metrics[m] = true
+				}
+				for _, l := range query.Labels {
+					q.labels[l] = true
+				}
+			}
+			add(p.Collapsed)
+		}
+	}
+	add(panels)
+	return q
+}
+
+func sortedKeys(values map[string]bool) []string {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 func DashboardFields() []string {
 	baseFields := []string{
 		DASHBOARD_SCHEMA_VERSION,
@@ -341,6 +441,10 @@ func DashboardFields() []string {
 		DASHBOARD_PANEL_TYPES,
 		DASHBOARD_DS_TYPES,
 		DASHBOARD_TRANSFORMATIONS,
+		DASHBOARD_DS_UIDS,
+		DASHBOARD_QUERY_METRICS,
+		DASHBOARD_QUERY_LABELS,
+		DASHBOARD_QUERY_EXPRESSIONS,
 	}
 
 	return append(baseFields, UsageInsightsFields()...)
@@ -26,6 +26,9 @@ func NewSearchOptions(features featuremgmt.FeatureToggles, cfg *setting.Cfg, tra
 			Root:          root,
 			FileThreshold: int64(cfg.IndexFileThreshold), // fewer than X items will use a memory index
 			BatchSize:     cfg.IndexMaxBatchSize,         // This is the batch size for how many objects to add to the index at once
+
+			CompactionInterval:  cfg.IndexCompactionInterval,
+			CompactionThreshold: cfg.IndexCompactionThreshold,
 		}, tracer, features, indexMetrics)
 
 		if err != nil {
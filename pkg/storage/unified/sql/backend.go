@@ -48,6 +48,16 @@ type BackendOptions struct {
 	IsHA            bool
 	storageMetrics  *resource.StorageMetrics
 
+	// Read replicas of the database. Reads go to a replica when it replicated the requested
+	// resource version, writes always go to the primary database.
+	Replicas []db.Replica
+	// Lists of the latest version go to a replica lagging at most this much behind the primary, as sampled
+	// every ReplicaCheckInterval. When zero, they only go to a replica when the caller accepts a minimum
+	// resource version.
+	ReplicaMaxLag time.Duration
+	// How often the resource versions of the replicas are compared with the primary
+	ReplicaCheckInterval time.Duration
+
 	// If true, the backend will prune history on write events.
 	// Will be removed once fully rolled out.
 	withPruner bool
@@ -71,6 +81,9 @@ func NewBackend(opts BackendOptions) (Backend, error) {
 	if opts.WatchBufferSize == 0 {
 		opts.WatchBufferSize = defaultWatchBufferSize
 	}
+	if opts.ReplicaCheckInterval == 0 {
+		opts.ReplicaCheckInterval = defaultReplicaCheckInterval
+	}
 	return &backend{
 		isHA:                    opts.IsHA,
 		done:                    ctx.Done(),
@@ -85,6 +98,8 @@ func NewBackend(opts BackendOptions) (Backend, error) {
 		bulkLock:                &bulkLock{running: make(map[string]bool)},
 		simulatedNetworkLatency: opts.SimulatedNetworkLatency,
 		withPruner:              opts.withPruner,
+		replicaOptions:          opts.Replicas,
+		replicas:                &replicaSet{maxLag: opts.ReplicaMaxLag, checkInterval: opts.ReplicaCheckInterval},
 	}, nil
 }
 
@@ -111,6 +126,12 @@ func (p *noopPruner) Add(key pruningKey) error {
 
 func (p *noopPruner) Start(ctx context.Context) {}
 
+var (
+	_ resource.CollectionLister    = (*backend)(nil)
+	_ resource.ModifiedSinceLister = (*backend)(nil)
+	_ resource.WrittenEventLister  = (*backend)(nil)
+)
+
 type backend struct {
 	//general
 	isHA bool
@@ -133,6 +154,10 @@ type backend struct {
 	dialect    sqltemplate.Dialect
 	bulkLock   *bulkLock
 
+	// read replicas
+	replicaOptions []db.Replica
+	replicas       *replicaSet
+
 	// watch streaming
 	//stream chan *resource.WatchEvent
 	pollingInterval time.Duration
@@ -196,6 +221,10 @@ func (b *backend) initLocked(ctx context.Context) error {
 		return fmt.Errorf("failed to create pruner: %w", err)
 	}
 
+	if err := b.initReplicas(ctx, b.replicaOptions); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -307,6 +336,163 @@ func (b *backend) GetResourceStats(ctx context.Context, namespace string, minCou
 	return res, err
 }
 
+// GetResourceSizeStats implements resource.ResourceSizeStatsGetter.
+func (b *backend) GetResourceSizeStats(ctx context.Context, namespace string) ([]resource.ResourceStats, error) {
+	ctx, span := b.tracer.Start(ctx, tracePrefix+".GetResourceSizeStats")
+	defer span.End()
+
+	req := &sqlSizeStatsRequest{
+		SQLTemplate: sqltemplate.New(b.dialect),
+		Namespace:   namespace,
+	}
+
+	res := make([]resource.ResourceStats, 0, 100)
+	err := b.db.WithTx(ctx, ReadCommittedRO, func(ctx context.Context, tx db.Tx) error {
+		rows, err := dbutil.QueryRows(ctx, tx, sqlResourceSizeStats, req)
+		if err != nil {
+			return err
+		}
+		for rows.Next() {
+			row := resource.ResourceStats{NamespacedResource: resource.NamespacedResource{Namespace: namespace}}
+			if err = rows.Scan(&row.Group, &row.Resource, &row.Count, &row.Size); err != nil {
+				return err
+			}
+			res = append(res, row)
+		}
+		return rows.Err()
+	})
+
+	return res, err
+}
+
+// ListModifiedSince implements resource.ModifiedSinceLister.
+func (b *backend) ListModifiedSince(ctx context.Context, key resource.NamespacedResource, sinceRV int64, fn func(*resource.ModifiedResource) error) (int64, error) {
+	ctx, span := b.tracer.Start(ctx, tracePrefix+".ListModifiedSince")
+	defer span.End()
+
+	req := &sqlResourceHistoryModifiedRequest{
+		SQLTemplate: sqltemplate.New(b.dialect),
+		Namespace:   key.Namespace,
+		Group:       key.Group,
+		Resource:    key.Resource,
+		SinceRV:     sinceRV,
+	}
+
+	var rv int64
+	err := b.db.WithTx(ctx, ReadCommittedRO, func(ctx context.Context, tx db.Tx) error {
+		rows, err := dbutil.QueryRows(ctx, tx, sqlResourceHistoryModified, req)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			_ = rows.Close()
+		}()
+
+		// the versions are sorted by name, the latest first
+		var last string
+		for rows.Next() {
+			m := &resource.ModifiedResource{Key: &resource.ResourceKey{
+				Namespace: key.Namespace,
+				Group:     key.Group,
+				Resource:  key.Resource,
+			}}
+			if err := rows.Scan(&m.Key.Name, &m.Action, &m.ResourceVersion, &m.Value); err != nil {
+				return err
+			}
+			rv = max(rv, m.ResourceVersion)
+			if m.Key.Name == last {
+				continue
+			}
+			last = m.Key.Name
+			if err := fn(m); err != nil {
+				return err
+			}
+		}
+		return rows.Err()
+	})
+	return rv, err
+}
+
+// ListWrittenSince implements resource.WrittenEventLister.
+func (b *backend) ListWrittenSince(ctx context.Context, group, res string, sinceRV int64, fn func(*resource.WrittenEvent) error) error {
+	ctx, span := b.tracer.Start(ctx, tracePrefix+"ListWrittenSince")
+	defer span.End()
+
+	records, err := b.historyPoll(ctx, group, res, sinceRV)
+	if err != nil {
+		return err
+	}
+	for _, rec := range records {
+		event := &resource.WrittenEvent{
+			Type: resource.WatchEvent_Type(rec.Action),
+			Key: &resource.ResourceKey{
+				Namespace: rec.Key.Namespace,
+				Group:     rec.Key.Group,
+				Resource:  rec.Key.Resource,
+				Name:      rec.Key.Name,
+			},
+			Value:           rec.Value,
+			Folder:          rec.Folder,
+			ResourceVersion: rec.ResourceVersion,
+		}
+		if rec.PreviousRV != nil {
+			event.PreviousRV = *rec.PreviousRV
+		}
+		if err := fn(event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// historyPoll returns the versions of a group and resource written after a resource version
+func (b *backend) historyPoll(ctx context.Context, grp string, res string, since int64) ([]*historyPollResponse, error) {
+	var records []*historyPollResponse
+	err := b.db.WithTx(ctx, ReadCommittedRO, func(ctx context.Context, tx db.Tx) error {
+		var err error
+		records, err = dbutil.Query(ctx, tx, sqlResourceHistoryPoll, &sqlResourceHistoryPollRequest{
+			SQLTemplate:          sqltemplate.New(b.dialect),
+			Resource:             res,
+			Group:                grp,
+			SinceResourceVersion: since,
+			Response:             &historyPollResponse{},
+		})
+		return err
+	})
+	return records, err
+}
+
+// ListCollections implements resource.CollectionLister.
+func (b *backend) ListCollections(ctx context.Context, namespace string) ([]resource.NamespacedResource, error) {
+	ctx, span := b.tracer.Start(ctx, tracePrefix+".ListCollections")
+	defer span.End()
+
+	req := &sqlResourceHistoryCollectionsRequest{
+		SQLTemplate: sqltemplate.New(b.dialect),
+		Namespace:   namespace,
+	}
+
+	var res []resource.NamespacedResource
+	err := b.db.WithTx(ctx, ReadCommittedRO, func(ctx context.Context, tx db.Tx) error {
+		rows, err := dbutil.QueryRows(ctx, tx, sqlResourceHistoryCollections, req)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			_ = rows.Close()
+		}()
+		for rows.Next() {
+			row := resource.NamespacedResource{Namespace: namespace}
+			if err := rows.Scan(&row.Group, &row.Resource); err != nil {
+				return err
+			}
+			res = append(res, row)
+		}
+		return rows.Err()
+	})
+	return res, err
+}
+
 func (b *backend) WriteEvent(ctx context.Context, event resource.WriteEvent) (int64, error) {
 	_, span := b.tracer.Start(ctx, tracePrefix+"WriteEvent")
 	defer span.End()
@@ -540,7 +726,8 @@ func (b *backend) ReadResource(ctx context.Context, req *resource.ReadRequest) *
 		Response:    NewReadResponse(),
 	}
 	var res *resource.BackendReadResponse
-	err := b.db.WithTx(ctx, ReadCommittedRO, func(ctx context.Context, tx db.Tx) error {
+	// The reads of a single resource usually precede a write of it, they do not accept the replica lag
+	err := b.readDB(ctx, req.Key.Group, req.Key.Resource, 0, false).WithTx(ctx, ReadCommittedRO, func(ctx context.Context, tx db.Tx) error {
 		var err error
 		res, err = dbutil.QueryRow(ctx, tx, sqlResourceRead, readReq)
 		return err
@@ -660,7 +847,7 @@ func (b *backend) listLatest(ctx context.Context, req *resource.ListRequest, cb
 	}
 
 	iter := &listIter{sortAsc: false}
-	err := b.db.WithTx(ctx, ReadCommittedRO, func(ctx context.Context, tx db.Tx) error {
+	err := b.readDB(ctx, req.Options.Key.Group, req.Options.Key.Resource, 0, true).WithTx(ctx, ReadCommittedRO, func(ctx context.Context, tx db.Tx) error {
 		var err error
 		iter.listRV, err = b.fetchLatestRV(ctx, tx, b.dialect, req.Options.Key.Group, req.Options.Key.Resource)
 		if err != nil {
@@ -718,7 +905,7 @@ func (b *backend) listAtRevision(ctx context.Context, req *resource.ListRequest,
 	// which stack is calling this.
 	b.log.Debug("listAtRevision", "ns", req.Options.Key.Namespace, "group", req.Options.Key.Group, "resource", req.Options.Key.Resource, "rv", iter.listRV)
 
-	err := b.db.WithTx(ctx, ReadCommittedRO, func(ctx context.Context, tx db.Tx) error {
+	err := b.readDB(ctx, req.Options.Key.Group, req.Options.Key.Resource, iter.listRV, false).WithTx(ctx, ReadCommittedRO, func(ctx context.Context, tx db.Tx) error {
 		limit := int64(0) // ignore limit
 		if iter.offset > 0 {
 			limit = math.MaxInt64 // a limit is required for offset
@@ -766,7 +953,7 @@ func (b *backend) readHistory(ctx context.Context, key *resource.ResourceKey, rv
 	}
 
 	var res *resource.BackendReadResponse
-	err := b.db.WithTx(ctx, ReadCommittedRO, func(ctx context.Context, tx db.Tx) error {
+	err := b.readDB(ctx, key.Group, key.Resource, rv, false).WithTx(ctx, ReadCommittedRO, func(ctx context.Context, tx db.Tx) error {
 		var err error
 		res, err = dbutil.QueryRow(ctx, tx, sqlResourceHistoryRead, readReq)
 		return err
@@ -824,7 +1011,7 @@ func (b *backend) getHistory(ctx context.Context, req *resource.ListRequest, cb
 	// Ignore last deleted history record when listing the trash, using exact matching or not older than matching with a specific RV
 	useLatestDeletionAsMinRV := listReq.MinRV == 0 && !listReq.Trash && req.VersionMatchV2 != resource.ResourceVersionMatchV2_Exact
 
-	err := b.db.WithTx(ctx, ReadCommittedRO, func(ctx context.Context, tx db.Tx) error {
+	err := b.readDB(ctx, req.Options.Key.Group, req.Options.Key.Resource, 0, true).WithTx(ctx, ReadCommittedRO, func(ctx context.Context, tx db.Tx) error {
 		var err error
 		iter.listRV, err = b.fetchLatestRV(ctx, tx, b.dialect, req.Options.Key.Group, req.Options.Key.Resource)
 		if err != nil {
@@ -863,10 +1050,15 @@ func (b *backend) WatchWriteEvents(ctx context.Context) (<-chan *resource.Writte
 
 // listLatestRVs returns the latest resource version for each (Group, Resource) pair.
 func (b *backend) listLatestRVs(ctx context.Context) (groupResourceRV, error) {
+	return b.readLatestRVs(ctx, b.db)
+}
+
+// readLatestRVs returns the latest resource version for each (Group, Resource) pair of a database.
+func (b *backend) readLatestRVs(ctx context.Context, x db.DB) (groupResourceRV, error) {
 	ctx, span := b.tracer.Start(ctx, tracePrefix+"listLatestRVs")
 	defer span.End()
 	var grvs []*groupResourceVersion
-	err := b.db.WithTx(ctx, ReadCommittedRO, func(ctx context.Context, tx db.Tx) error {
+	err := x.WithTx(ctx, ReadCommittedRO, func(ctx context.Context, tx db.Tx) error {
 		var err error
 		grvs, err = dbutil.Query(ctx, tx, sqlResourceVersionList, &sqlResourceVersionListRequest{
 			SQLTemplate:          sqltemplate.New(b.dialect),
@@ -2,6 +2,8 @@ package sql
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
@@ -12,6 +14,7 @@ import (
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 
 	"github.com/grafana/grafana-app-sdk/logging"
 	"github.com/grafana/grafana/pkg/apimachinery/utils"
@@ -148,6 +151,7 @@ func (b *backend) ProcessBulk(ctx context.Context, setting resource.BulkSettings
 // internal bulk process
 func (b *backend) processBulk(ctx context.Context, setting resource.BulkSettings, iter resource.BulkRequestIterator) *resource.BulkResponse {
 	rsp := &resource.BulkResponse{}
+	var events []*resource.WrittenEvent
 	err := b.db.WithTx(ctx, ReadCommitted, func(ctx context.Context, tx db.Tx) error {
 		rollbackWithError := func(err error) error {
 			txerr := tx.Rollback()
@@ -180,8 +184,22 @@ func (b *backend) processBulk(ctx context.Context, setting resource.BulkSettings
 				summaries[key.NSGR()] = summary
 				rsp.Summary = append(rsp.Summary, summary)
 			}
+		} else {
+			for _, key := range setting.Collection {
+				summary := &resource.BulkResponse_Summary{
+					Namespace: key.Namespace,
+					Group:     key.Group,
+					Resource:  key.Resource,
+				}
+				summaries[key.NSGR()] = summary
+				rsp.Summary = append(rsp.Summary, summary)
+			}
 		}
 
+		// When appending, the resource versions are allocated like for the other writes,
+		// the next one of each group and resource is kept until the end of the transaction
+		nextRV := make(map[schema.GroupResource]int64)
+
 		obj := &unstructured.Unstructured{}
 
 		// Write each event into the history
@@ -214,6 +232,26 @@ func (b *backend) processBulk(ctx context.Context, setting resource.BulkSettings
 				continue
 			}
 
+			if !setting.RebuildCollection {
+				summary := summaries[req.Key.NSGR()]
+				if summary == nil {
+					rsp.Rejected = append(rsp.Rejected, &resource.BulkResponse_Rejected{
+						Key:    req.Key,
+						Action: req.Action,
+						Error:  "key not in the bulk collections",
+					})
+					continue
+				}
+				event, err := b.appendBulk(ctx, tx, req, obj.GetGeneration(), nextRV)
+				if err != nil {
+					return rollbackWithError(err)
+				}
+				summary.History++
+				summary.ResourceVersion = event.ResourceVersion
+				events = append(events, event)
+				continue
+			}
+
 			// Write the event to history
 			if _, err := dbutil.Exec(ctx, tx, sqlResourceHistoryInsert, sqlResourceRequest{
 				SQLTemplate: sqltemplate.New(b.dialect),
@@ -231,6 +269,20 @@ func (b *backend) processBulk(ctx context.Context, setting resource.BulkSettings
 			}
 		}
 
+		if !setting.RebuildCollection {
+			for gr, rv := range nextRV {
+				if err := b.rvManager.saveRV(ctx, tx, gr.Group, gr.Resource, rv); err != nil {
+					return rollbackWithError(err)
+				}
+			}
+			for _, key := range setting.Collection {
+				if err := bulk.collectionStats(key, summaries[key.NSGR()]); err != nil {
+					return rollbackWithError(err)
+				}
+			}
+			return nil
+		}
+
 		// Now update the resource table from history
 		for _, key := range setting.Collection {
 			k := fmt.Sprintf("%s/%s/%s", key.Namespace, key.Group, key.Resource)
@@ -256,10 +308,96 @@ func (b *backend) processBulk(ctx context.Context, setting resource.BulkSettings
 	})
 	if err != nil {
 		rsp.Error = resource.AsErrorResult(err)
+		return rsp
+	}
+	for _, event := range events {
+		b.notifier.send(ctx, event)
 	}
 	return rsp
 }
 
+// appendBulk writes a request of a bulk that does not rebuild its collection, like a single write
+// but in the bulk transaction, and returns the event to send once the transaction is committed
+func (b *backend) appendBulk(ctx context.Context, tx db.Tx, req *resource.BulkRequest, generation int64, nextRV map[schema.GroupResource]int64) (*resource.WrittenEvent, error) {
+	gr := schema.GroupResource{Group: req.Key.Group, Resource: req.Key.Resource}
+	rv, ok := nextRV[gr]
+	if !ok {
+		var err error
+		if rv, err = b.rvManager.lock(ctx, tx, gr.Group, gr.Resource); err != nil {
+			return nil, err
+		}
+	}
+	nextRV[gr] = rv + 1
+
+	var previousRV int64
+	current, err := dbutil.QueryRow(ctx, tx, sqlResourceRead, &sqlResourceReadRequest{
+		SQLTemplate: sqltemplate.New(b.dialect),
+		Request:     &resource.ReadRequest{Key: req.Key},
+		Response:    NewReadResponse(),
+	})
+	switch {
+	case err == nil:
+		previousRV = current.ResourceVersion
+	case !errors.Is(err, sql.ErrNoRows):
+		return nil, fmt.Errorf("read resource: %w", err)
+	}
+	value, err := resource.CheckBulkAppend(req, previousRV)
+	if err != nil {
+		return nil, err
+	}
+
+	event := resource.WriteEvent{
+		Key:        req.Key,
+		Type:       resource.WatchEvent_Type(req.Action),
+		Value:      value,
+		PreviousRV: previousRV,
+	}
+	if req.Action == resource.BulkRequest_DELETED {
+		generation = 0 // object does not exist
+	}
+	if _, err := dbutil.Exec(ctx, tx, sqlResourceHistoryInsert, sqlResourceRequest{
+		SQLTemplate:     sqltemplate.New(b.dialect),
+		WriteEvent:      event,
+		Folder:          req.Folder,
+		GUID:            uuid.NewString(),
+		ResourceVersion: rv,
+		Generation:      generation,
+	}); err != nil {
+		return nil, fmt.Errorf("insert into resource history: %w", err)
+	}
+
+	// Replace the resource with the value just added to its history
+	if _, err := dbutil.Exec(ctx, tx, sqlResourceDelete, sqlResourceRequest{
+		SQLTemplate: sqltemplate.New(b.dialect),
+		WriteEvent:  event,
+	}); err != nil {
+		return nil, fmt.Errorf("delete resource: %w", err)
+	}
+	if req.Action != resource.BulkRequest_DELETED {
+		if _, err := dbutil.Exec(ctx, tx, sqlResourceInsertFromHistory, &sqlResourceInsertFromHistoryRequest{
+			SQLTemplate: sqltemplate.New(b.dialect),
+			Key:         req.Key,
+		}); err != nil {
+			return nil, fmt.Errorf("insert into resource: %w", err)
+		}
+	}
+	_ = b.historyPruner.Add(pruningKey{
+		namespace: req.Key.Namespace,
+		group:     req.Key.Group,
+		resource:  req.Key.Resource,
+		name:      req.Key.Name,
+	})
+
+	return &resource.WrittenEvent{
+		Type:            event.Type,
+		Key:             req.Key,
+		PreviousRV:      previousRV,
+		Value:           value,
+		ResourceVersion: rv,
+		Folder:          req.Folder,
+	}, nil
+}
+
 type bulkWroker struct {
 	ctx     context.Context
 	tx      db.ContextExecer
@@ -315,7 +453,11 @@ func (w *bulkWroker) syncCollection(key *resource.ResourceKey, summary *resource
 	if err != nil {
 		return err
 	}
+	return w.collectionStats(key, summary)
+}
 
+// Read the count and latest resource version of the collection into the summary
+func (w *bulkWroker) collectionStats(key *resource.ResourceKey, summary *resource.BulkResponse_Summary) error {
 	w.logger.Info("get stats (still in transaction)", "key", key.NSGR())
 	rows, err := dbutil.QueryRows(w.ctx, w.tx, sqlResourceStats, &sqlStatsRequest{
 		SQLTemplate: sqltemplate.New(w.dialect),
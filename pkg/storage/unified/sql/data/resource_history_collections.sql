@@ -0,0 +1,9 @@
+SELECT DISTINCT
+  {{ .Ident "group"    }},
+  {{ .Ident "resource" }}
+FROM {{ .Ident "resource_history" }}
+WHERE {{ .Ident "namespace" }} = {{ .Arg .Namespace }}
+ORDER BY
+  {{ .Ident "group"    }},
+  {{ .Ident "resource" }}
+;
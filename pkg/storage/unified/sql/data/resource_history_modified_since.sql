@@ -0,0 +1,15 @@
+SELECT
+  {{ .Ident "name" }},
+  {{ .Ident "action" }},
+  {{ .Ident "resource_version" }},
+  {{ .Ident "value" }}
+FROM {{ .Ident "resource_history" }}
+WHERE 1 = 1
+  AND {{ .Ident "namespace" }}        = {{ .Arg .Namespace }}
+  AND {{ .Ident "group" }}            = {{ .Arg .Group }}
+  AND {{ .Ident "resource" }}         = {{ .Arg .Resource }}
+  AND {{ .Ident "resource_version" }} > {{ .Arg .SinceRV }}
+ORDER BY
+  {{ .Ident "name" }} ASC,
+  {{ .Ident "resource_version" }} DESC
+;
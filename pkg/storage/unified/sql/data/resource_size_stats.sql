@@ -0,0 +1,11 @@
+SELECT
+  {{ .Ident "group"    }},
+  {{ .Ident "resource" }},
+  COUNT(*),
+  COALESCE(SUM({{ if eq .DialectName "sqlite" }}LENGTH(CAST({{ .Ident "value" }} AS BLOB)){{ else }}OCTET_LENGTH({{ .Ident "value" }}){{ end }}), 0)
+FROM {{ .Ident "resource" }}
+WHERE {{ .Ident "namespace" }} = {{ .Arg .Namespace }}
+GROUP BY
+  {{ .Ident "group"    }},
+  {{ .Ident "resource" }}
+;
@@ -0,0 +1,93 @@
+package dbimpl
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+	"xorm.io/xorm"
+
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/storage/unified/sql/db"
+	"github.com/grafana/grafana/pkg/storage/unified/sql/db/otel"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+// ProvideResourceReplicaDBs returns the read replicas listed in the
+// `db_replica_hosts` key of the [resource_api] section. The replicas are
+// reached with the same configuration as the primary database, except for the
+// host.
+func ProvideResourceReplicaDBs(cfg *setting.Cfg, tracer trace.Tracer) ([]db.Replica, error) {
+	if tracer == nil {
+		tracer = noop.NewTracerProvider().Tracer("test-tracer")
+	}
+	getter := newConfGetter(cfg.SectionWithEnvOverrides("resource_api"), "db_")
+	hosts := util.SplitString(getter.String("replica_hosts"))
+	if len(hosts) == 0 {
+		return nil, nil
+	}
+
+	replicas := make([]db.Replica, 0, len(hosts))
+	for _, host := range hosts {
+		engine, err := getReplicaEngine(cfg, getter, host)
+		if err != nil {
+			return nil, fmt.Errorf("provide Resource DB replica %q: %w", host, err)
+		}
+		replicas = append(replicas, db.Replica{
+			Name:       host,
+			DBProvider: newReplicaDBProvider(engine, tracer),
+		})
+	}
+	return replicas, nil
+}
+
+func getReplicaEngine(cfg *setting.Cfg, getter confGetter, host string) (*xorm.Engine, error) {
+	replicaGetter := &hostGetter{confGetter: getter, host: host}
+	switch dbType := getter.String("type"); dbType {
+	case dbTypePostgres:
+		return getEnginePostgres(replicaGetter)
+	case dbTypeMySQL:
+		return getEngineMySQL(replicaGetter)
+	case "":
+	default:
+		return nil, fmt.Errorf("invalid db type specified: %s", dbType)
+	}
+
+	config, err := sqlstore.NewReplicaDatabaseConfig(cfg, host)
+	if err != nil {
+		return nil, err
+	}
+	engine, err := xorm.NewEngine(config.Type, config.ConnectionString)
+	if err != nil {
+		return nil, fmt.Errorf("open database: %w", err)
+	}
+	return engine, nil
+}
+
+func newReplicaDBProvider(engine *xorm.Engine, tracer trace.Tracer) db.DBProvider {
+	var once sync.Once
+	var replicaDB db.DB
+	return dbProviderFunc(func(ctx context.Context) (db.DB, error) {
+		once.Do(func() {
+			d := NewDB(engine.DB().DB, engine.Dialect().DriverName())
+			replicaDB = otel.NewInstrumentedDB(d, tracer)
+		})
+		return replicaDB, nil
+	})
+}
+
+// hostGetter reads the configuration of the primary database, with the host of a replica
+type hostGetter struct {
+	confGetter
+	host string
+}
+
+func (g *hostGetter) String(key string) string {
+	if key == "host" {
+		return g.host
+	}
+	return g.confGetter.String(key)
+}
@@ -26,6 +26,14 @@ type DBProvider interface {
 	Init(context.Context) (DB, error)
 }
 
+// Replica is a read-only copy of the database. Its migrations are run on the
+// primary database, and replicated.
+type Replica struct {
+	// Name identifies the replica in logs and metrics
+	Name string
+	DBProvider
+}
+
 // DB is a thin abstraction on *sql.DB to allow mocking to provide better unit
 // testing. We purposefully hide database operation methods that would use
 // context.Background().
@@ -6,9 +6,6 @@ import (
 
 	"github.com/grafana/grafana-app-sdk/logging"
 	"github.com/grafana/grafana/pkg/storage/unified/resource"
-	"github.com/grafana/grafana/pkg/storage/unified/sql/db"
-	"github.com/grafana/grafana/pkg/storage/unified/sql/dbutil"
-	"github.com/grafana/grafana/pkg/storage/unified/sql/sqltemplate"
 )
 
 type eventNotifier interface {
@@ -32,23 +29,9 @@ func newNotifier(b *backend) (eventNotifier, error) {
 			bulkLock:        b.bulkLock,
 			listLatestRVs:   b.listLatestRVs,
 			storageMetrics:  b.storageMetrics,
-			historyPoll: func(ctx context.Context, grp string, res string, since int64) ([]*historyPollResponse, error) {
-				var records []*historyPollResponse
-				err := b.db.WithTx(ctx, ReadCommittedRO, func(ctx context.Context, tx db.Tx) error {
-					var err error
-					records, err = dbutil.Query(ctx, tx, sqlResourceHistoryPoll, &sqlResourceHistoryPollRequest{
-						SQLTemplate:          sqltemplate.New(b.dialect),
-						Resource:             res,
-						Group:                grp,
-						SinceResourceVersion: since,
-						Response:             &historyPollResponse{},
-					})
-					return err
-				})
-				return records, err
-			},
-			done:    b.done,
-			dialect: b.dialect,
+			historyPoll:     b.historyPoll,
+			done:            b.done,
+			dialect:         b.dialect,
 		})
 		if err != nil {
 			return nil, err
@@ -34,6 +34,7 @@ var (
 	sqlResourceUpdate              = mustTemplate("resource_update.sql")
 	sqlResourceRead                = mustTemplate("resource_read.sql")
 	sqlResourceStats               = mustTemplate("resource_stats.sql")
+	sqlResourceSizeStats           = mustTemplate("resource_size_stats.sql")
 	sqlResourceList                = mustTemplate("resource_list.sql")
 	sqlResourceHistoryList         = mustTemplate("resource_history_list.sql")
 	sqlResourceUpdateRV            = mustTemplate("resource_update_rv.sql")
@@ -46,6 +47,8 @@ var (
 	sqlResourceHistoryDelete       = mustTemplate("resource_history_delete.sql")
 	sqlResourceHistoryPrune        = mustTemplate("resource_history_prune.sql")
 	sqlResourceInsertFromHistory   = mustTemplate("resource_insert_from_history.sql")
+	sqlResourceHistoryCollections  = mustTemplate("resource_history_collections.sql")
+	sqlResourceHistoryModified     = mustTemplate("resource_history_modified_since.sql")
 
 	// sqlResourceLabelsInsert = mustTemplate("resource_labels_insert.sql")
 	sqlResourceVersionGet    = mustTemplate("resource_version_get.sql")
@@ -114,6 +117,19 @@ func (r sqlStatsRequest) Validate() error {
 	return nil
 }
 
+// sqlSizeStatsRequest sums the size of the values of a namespace, which is much slower than counting them
+type sqlSizeStatsRequest struct {
+	sqltemplate.SQLTemplate
+	Namespace string
+}
+
+func (r sqlSizeStatsRequest) Validate() error {
+	if r.Namespace == "" {
+		return fmt.Errorf("missing namespace")
+	}
+	return nil
+}
+
 type historyPollResponse struct {
 	Key             resource.ResourceKey
 	ResourceVersion int64
@@ -289,6 +305,33 @@ func (r *sqlResourceHistoryDeleteRequest) Validate() error {
 	return nil
 }
 
+type sqlResourceHistoryModifiedRequest struct {
+	sqltemplate.SQLTemplate
+	Namespace string
+	Group     string
+	Resource  string
+	SinceRV   int64
+}
+
+func (r *sqlResourceHistoryModifiedRequest) Validate() error {
+	if r.Namespace == "" || r.Group == "" || r.Resource == "" {
+		return fmt.Errorf("missing namespace, group or resource")
+	}
+	return nil
+}
+
+type sqlResourceHistoryCollectionsRequest struct {
+	sqltemplate.SQLTemplate
+	Namespace string
+}
+
+func (r *sqlResourceHistoryCollectionsRequest) Validate() error {
+	if r.Namespace == "" {
+		return fmt.Errorf("missing namespace")
+	}
+	return nil
+}
+
 type sqlGetHistoryRequest struct {
 	sqltemplate.SQLTemplate
 	Key           *resource.ResourceKey
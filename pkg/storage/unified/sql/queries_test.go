@@ -335,6 +335,16 @@ func TestUnifiedStorageQueries(t *testing.T) {
 				},
 			},
 
+			sqlResourceSizeStats: {
+				{
+					Name: "namespace",
+					Data: &sqlSizeStatsRequest{
+						SQLTemplate: mocks.NewTestingSQLTemplate(),
+						Namespace:   "default",
+					},
+				},
+			},
+
 			sqlResourceStats: {
 				{
 					Name: "global",
@@ -419,6 +429,29 @@ func TestUnifiedStorageQueries(t *testing.T) {
 					},
 				},
 			},
+			sqlResourceHistoryCollections: {
+				{
+					Name: "namespace",
+					Data: &sqlResourceHistoryCollectionsRequest{
+						SQLTemplate: mocks.NewTestingSQLTemplate(),
+						Namespace:   "ns",
+					},
+				},
+			},
+
+			sqlResourceHistoryModified: {
+				{
+					Name: "since",
+					Data: &sqlResourceHistoryModifiedRequest{
+						SQLTemplate: mocks.NewTestingSQLTemplate(),
+						Namespace:   "ns",
+						Group:       "gg",
+						Resource:    "rr",
+						SinceRV:     1234,
+					},
+				},
+			},
+
 			sqlResourceHistoryDelete: {
 				{
 					Name: "guid",
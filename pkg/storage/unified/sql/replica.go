@@ -0,0 +1,177 @@
+package sql
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/grafana/grafana/pkg/storage/unified/resource"
+	"github.com/grafana/grafana/pkg/storage/unified/sql/db"
+)
+
+const defaultReplicaCheckInterval = 5 * time.Second
+
+var (
+	replicaLag = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name:      "unified_storage_replica_lag_seconds",
+		Help:      "How far the resource versions of a read replica are behind the primary database",
+		Namespace: "grafana",
+	}, []string{"replica"})
+
+	replicaReads = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name:      "unified_storage_replica_reads_total",
+		Help:      "Number of reads served by the primary database or by a read replica",
+		Namespace: "grafana",
+	}, []string{"db"})
+)
+
+const primaryDB = "primary"
+
+type replica struct {
+	name string
+	db   db.DB
+
+	// The latest resource versions seen in the last check
+	rvs     atomic.Pointer[groupResourceRV]
+	healthy atomic.Bool
+	// In microseconds, like the resource versions
+	lag atomic.Int64
+}
+
+// rv returns the latest resource version of a resource replicated by the replica
+func (r *replica) rv(group, resource string) int64 {
+	rvs := r.rvs.Load()
+	if rvs == nil {
+		return 0
+	}
+	return (*rvs)[group][resource]
+}
+
+// replicaSet routes the reads to the read replicas that replicated the requested resource version
+type replicaSet struct {
+	replicas      []*replica
+	maxLag        time.Duration
+	checkInterval time.Duration
+	next          atomic.Uint64
+}
+
+// pick returns a replica that can serve a read at a resource version, or nil when the read
+// needs the primary database. A zero rv reads the latest version, which is only read from
+// a replica when the caller accepts a minimum resource version, or when acceptLag is set and
+// the lag is low enough. The reads with strong consistency always need the primary database.
+//
+// The resource versions and the lag of the replicas are sampled every checkInterval. A replica
+// which replicated a resource version keeps it, but the lag can grow between two checks, so the
+// reads accepting the lag may be up to maxLag plus checkInterval behind the primary.
+func (s *replicaSet) pick(ctx context.Context, group, res string, rv int64, acceptLag bool) *replica {
+	if s == nil || len(s.replicas) == 0 || resource.StrongConsistency(ctx) {
+		return nil
+	}
+	minRV := rv
+	if minRV <= 0 {
+		minRV, _ = resource.MinResourceVersion(ctx)
+	}
+
+	start := s.next.Add(1)
+	for i := range s.replicas {
+		r := s.replicas[(start+uint64(i))%uint64(len(s.replicas))]
+		if !r.healthy.Load() {
+			continue
+		}
+		if minRV > 0 {
+			if r.rv(group, res) >= minRV {
+				return r
+			}
+			continue
+		}
+		if acceptLag && s.maxLag > 0 && time.Duration(r.lag.Load())*time.Microsecond <= s.maxLag {
+			return r
+		}
+	}
+	return nil
+}
+
+// readDB returns the database to read a resource from at a resource version, zero being the latest.
+// Only the lists of the latest version accept the lag, see replicaSet.pick.
+func (b *backend) readDB(ctx context.Context, group, res string, rv int64, acceptLag bool) db.DB {
+	if r := b.replicas.pick(ctx, group, res, rv, acceptLag); r != nil {
+		replicaReads.WithLabelValues(r.name).Inc()
+		return r.db
+	}
+	if b.replicas != nil {
+		replicaReads.WithLabelValues(primaryDB).Inc()
+	}
+	return b.db
+}
+
+func (b *backend) initReplicas(ctx context.Context, opts []db.Replica) error {
+	if len(opts) == 0 {
+		return nil
+	}
+	b.replicas.replicas = make([]*replica, 0, len(opts))
+	for _, o := range opts {
+		replicaDB, err := o.Init(ctx)
+		if err != nil {
+			return fmt.Errorf("initialize resource DB replica %q: %w", o.Name, err)
+		}
+		if driver := replicaDB.DriverName(); driver != b.db.DriverName() {
+			return fmt.Errorf("resource DB replica %q uses driver %q, expected %q", o.Name, driver, b.db.DriverName())
+		}
+		b.replicas.replicas = append(b.replicas.replicas, &replica{name: o.Name, db: replicaDB})
+	}
+
+	b.checkReplicas(ctx)
+	go func() {
+		ticker := time.NewTicker(b.replicas.checkInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-b.done:
+				return
+			case <-ticker.C:
+				b.checkReplicas(context.Background())
+			}
+		}
+	}()
+	return nil
+}
+
+// checkReplicas compares the resource versions of the replicas with the ones of the primary database
+func (b *backend) checkReplicas(ctx context.Context) {
+	primary, err := b.readLatestRVs(ctx, b.db)
+	if err != nil {
+		b.log.Warn("failed to read the primary resource versions, not using the replicas", "error", err)
+		for _, r := range b.replicas.replicas {
+			r.healthy.Store(false)
+		}
+		return
+	}
+	for _, r := range b.replicas.replicas {
+		rvs, err := b.readLatestRVs(ctx, r.db)
+		if err != nil {
+			b.log.Warn("failed to read the replica resource versions, not using the replica", "replica", r.name, "error", err)
+			r.healthy.Store(false)
+			continue
+		}
+		lag := max(primary.max()-rvs.max(), 0)
+		r.rvs.Store(&rvs)
+		r.lag.Store(lag)
+		r.healthy.Store(true)
+		replicaLag.WithLabelValues(r.name).Set(float64(lag) / 1e6)
+	}
+}
+
+// max returns the latest resource version of all the resources
+func (g groupResourceRV) max() int64 {
+	var latest int64
+	for _, resources := range g {
+		for _, rv := range resources {
+			latest = max(latest, rv)
+		}
+	}
+	return latest
+}
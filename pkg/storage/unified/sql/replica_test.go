@@ -0,0 +1,75 @@
+package sql
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/storage/unified/resource"
+)
+
+func TestReplicaSetPick(t *testing.T) {
+	newReplica := func(name string, rv, lag int64, healthy bool) *replica {
+		r := &replica{name: name}
+		r.rvs.Store(&groupResourceRV{"gr": {"rs": rv}})
+		r.lag.Store(lag)
+		r.healthy.Store(healthy)
+		return r
+	}
+	ctx := context.Background()
+
+	t.Run("without replicas", func(t *testing.T) {
+		var s *replicaSet
+		require.Nil(t, s.pick(ctx, "gr", "rs", 10, false))
+		require.Nil(t, (&replicaSet{}).pick(ctx, "gr", "rs", 10, false))
+	})
+
+	t.Run("reads at a resource version", func(t *testing.T) {
+		behind := newReplica("behind", 5, 0, true)
+		s := &replicaSet{replicas: []*replica{behind}}
+		require.Nil(t, s.pick(ctx, "gr", "rs", 10, false))
+		require.Nil(t, s.pick(ctx, "gr", "other", 1, false))
+		require.Equal(t, behind, s.pick(ctx, "gr", "rs", 5, false))
+
+		upToDate := newReplica("up-to-date", 10, 0, true)
+		s.replicas = append(s.replicas, upToDate)
+		for range 3 {
+			require.Equal(t, upToDate, s.pick(ctx, "gr", "rs", 10, false))
+		}
+
+		upToDate.healthy.Store(false)
+		require.Nil(t, s.pick(ctx, "gr", "rs", 10, false))
+	})
+
+	t.Run("reads the latest version", func(t *testing.T) {
+		r := newReplica("r", 10, (2 * time.Second).Microseconds(), true)
+		s := &replicaSet{replicas: []*replica{r}}
+		require.Nil(t, s.pick(ctx, "gr", "rs", 0, false), "replicas are not used without max lag")
+
+		s.maxLag = time.Second
+		require.Nil(t, s.pick(ctx, "gr", "rs", 0, true))
+		s.maxLag = 5 * time.Second
+		require.Equal(t, r, s.pick(ctx, "gr", "rs", 0, true))
+		require.Nil(t, s.pick(ctx, "gr", "rs", 0, false), "the lag is only accepted by the lists")
+
+		s.maxLag = 0
+		require.Equal(t, r, s.pick(resource.WithMinResourceVersion(ctx, 10), "gr", "rs", 0, false))
+		require.Nil(t, s.pick(resource.WithMinResourceVersion(ctx, 11), "gr", "rs", 0, false))
+	})
+
+	t.Run("reads with strong consistency", func(t *testing.T) {
+		r := newReplica("r", 10, 0, true)
+		s := &replicaSet{replicas: []*replica{r}, maxLag: 5 * time.Second}
+		strong := resource.WithStrongConsistency(ctx)
+		require.Nil(t, s.pick(strong, "gr", "rs", 0, true))
+		require.Nil(t, s.pick(strong, "gr", "rs", 10, false))
+		require.Nil(t, s.pick(resource.WithMinResourceVersion(strong, 10), "gr", "rs", 0, false))
+	})
+}
+
+func TestGroupResourceRVMax(t *testing.T) {
+	require.Equal(t, int64(0), groupResourceRV{}.max())
+	require.Equal(t, int64(7), groupResourceRV{"a": {"x": 3, "y": 7}, "b": {"z": 5}}.max())
+}
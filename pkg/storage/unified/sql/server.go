@@ -6,6 +6,7 @@ import (
 
 	"github.com/prometheus/client_golang/prometheus"
 	"go.opentelemetry.io/otel/trace"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 
 	"github.com/grafana/authlib/types"
 
@@ -48,22 +49,31 @@ func NewResourceServer(db infraDB.DB, cfg *setting.Cfg,
 		return nil, err
 	}
 
-	isHA := isHighAvailabilityEnabled(cfg.SectionWithEnvOverrides("database"),
-		cfg.SectionWithEnvOverrides("resource_api"))
+	replicas, err := dbimpl.ProvideResourceReplicaDBs(cfg, tracer)
+	if err != nil {
+		return nil, err
+	}
+	apiCfg := cfg.SectionWithEnvOverrides("resource_api")
+
+	isHA := isHighAvailabilityEnabled(cfg.SectionWithEnvOverrides("database"), apiCfg)
 	withPruner := features.IsEnabledGlobally(featuremgmt.FlagUnifiedStorageHistoryPruner)
 
 	store, err := NewBackend(BackendOptions{
-		DBProvider:     eDB,
-		Tracer:         tracer,
-		Reg:            reg,
-		IsHA:           isHA,
-		withPruner:     withPruner,
-		storageMetrics: storageMetrics,
+		DBProvider:           eDB,
+		Tracer:               tracer,
+		Reg:                  reg,
+		IsHA:                 isHA,
+		withPruner:           withPruner,
+		storageMetrics:       storageMetrics,
+		Replicas:             replicas,
+		ReplicaMaxLag:        apiCfg.Key("db_replica_max_lag").MustDuration(0),
+		ReplicaCheckInterval: apiCfg.Key("db_replica_check_interval").MustDuration(defaultReplicaCheckInterval),
 	})
 	if err != nil {
 		return nil, err
 	}
 	opts.Backend = store
+	opts.Quotas = quotaOptions(cfg)
 	opts.Diagnostics = store
 	opts.Lifecycle = store
 	opts.Search = searchOptions
@@ -98,3 +108,40 @@ func isHighAvailabilityEnabled(dbCfg, resourceAPICfg *setting.DynamicSection) bo
 
 	return isHA
 }
+
+// quotaOptions reads the quotas of the [unified_storage] section, and of the resource sections
+func quotaOptions(cfg *setting.Cfg) resource.QuotaOptions {
+	q := cfg.UnifiedStorageQuotas
+	opts := resource.QuotaOptions{
+		Namespace: resource.QuotaLimits{
+			MaxObjects: q.NamespaceMaxObjects,
+			MaxBytes:   q.NamespaceMaxBytes,
+		},
+		Default: resource.QuotaLimits{
+			MaxObjects:    q.MaxObjects,
+			MaxBytes:      q.MaxBytes,
+			MaxObjectSize: q.MaxObjectSize,
+		},
+		RefreshInterval: q.RefreshInterval,
+	}
+	for name, c := range cfg.UnifiedStorage {
+		if c.QuotaMaxObjects == 0 && c.QuotaMaxBytes == 0 && c.MaxObjectSize == 0 {
+			continue
+		}
+		limits := opts.Default
+		if c.QuotaMaxObjects != 0 {
+			limits.MaxObjects = c.QuotaMaxObjects
+		}
+		if c.QuotaMaxBytes != 0 {
+			limits.MaxBytes = c.QuotaMaxBytes
+		}
+		if c.MaxObjectSize != 0 {
+			limits.MaxObjectSize = c.MaxObjectSize
+		}
+		if opts.Resources == nil {
+			opts.Resources = map[schema.GroupResource]resource.QuotaLimits{}
+		}
+		opts.Resources[schema.ParseGroupResource(name)] = limits
+	}
+	return opts
+}
@@ -0,0 +1,11 @@
+SELECT
+  `group`,
+  `resource`,
+  COUNT(*),
+  COALESCE(SUM(OCTET_LENGTH(`value`)), 0)
+FROM `resource`
+WHERE `namespace` = 'default'
+GROUP BY
+  `group`,
+  `resource`
+;
@@ -0,0 +1,9 @@
+SELECT DISTINCT
+  "group",
+  "resource"
+FROM "resource_history"
+WHERE "namespace" = 'ns'
+ORDER BY
+  "group",
+  "resource"
+;
@@ -0,0 +1,15 @@
+SELECT
+  "name",
+  "action",
+  "resource_version",
+  "value"
+FROM "resource_history"
+WHERE 1 = 1
+  AND "namespace"        = 'ns'
+  AND "group"            = 'gg'
+  AND "resource"         = 'rr'
+  AND "resource_version" > 1234
+ORDER BY
+  "name" ASC,
+  "resource_version" DESC
+;
@@ -35,6 +35,8 @@ const (
 	TestListHistory               = "list history"
 	TestListHistoryErrorReporting = "list history error reporting"
 	TestCreateNewResource         = "create new resource"
+	TestBulkAppend                = "bulk append"
+	TestListWrittenSince          = "list written since"
 )
 
 type NewBackendFunc func(ctx context.Context) resource.StorageBackend
@@ -79,6 +81,8 @@ func RunStorageBackendTest(t *testing.T, newBackend NewBackendFunc, opts *TestOp
 		{TestListHistory, runTestIntegrationBackendListHistory},
 		{TestListHistoryErrorReporting, runTestIntegrationBackendListHistoryErrorReporting},
 		{TestCreateNewResource, runTestIntegrationBackendCreateNewResource},
+		{TestBulkAppend, runTestIntegrationBackendBulkAppend},
+		{TestListWrittenSince, runTestIntegrationBackendListWrittenSince},
 	}
 
 	for _, tc := range cases {
@@ -1005,6 +1009,147 @@ func runTestIntegrationBackendCreateNewResource(t *testing.T, backend resource.S
 	})
 }
 
+func runTestIntegrationBackendBulkAppend(t *testing.T, backend resource.StorageBackend, nsPrefix string) {
+	bulk, ok := backend.(resource.BulkProcessingBackend)
+	if !ok {
+		t.Skip("the backend does not support bulk processing")
+	}
+
+	ctx := testutil.NewDefaultTestContext(t)
+	ns := nsPrefix + "-bulk-append"
+	collection := &resource.ResourceKey{Namespace: ns, Group: "group", Resource: "resource"}
+	settings := resource.BulkSettings{Collection: []*resource.ResourceKey{collection}}
+	keyOf := func(name string) *resource.ResourceKey {
+		return &resource.ResourceKey{Namespace: ns, Group: "group", Resource: "resource", Name: name}
+	}
+	valueOf := func(name string, generation int64, rv int64) []byte {
+		obj := &unstructured.Unstructured{Object: map[string]any{}}
+		obj.SetAPIVersion("group/v1")
+		obj.SetKind("Resource")
+		obj.SetName(name)
+		obj.SetNamespace(ns)
+		obj.SetGeneration(generation)
+		if rv > 0 {
+			obj.SetResourceVersion(fmt.Sprintf("%d", rv))
+		}
+		value, err := obj.MarshalJSON()
+		require.NoError(t, err)
+		return value
+	}
+
+	rv1, err := writeEvent(ctx, backend, "item1", resource.WatchEvent_ADDED, WithNamespace(ns), WithValue(valueOf("item1", 1, 0)))
+	require.NoError(t, err)
+	_, err = writeEvent(ctx, backend, "item2", resource.WatchEvent_ADDED, WithNamespace(ns), WithValue(valueOf("item2", 1, 0)))
+	require.NoError(t, err)
+
+	t.Run("a conflict rolls back the whole bulk", func(t *testing.T) {
+		rsp := bulk.ProcessBulk(ctx, settings, &bulkRequests{requests: []*resource.BulkRequest{
+			{Key: keyOf("item3"), Action: resource.BulkRequest_ADDED, Value: valueOf("item3", 1, 0)},
+			{Key: keyOf("item1"), Action: resource.BulkRequest_MODIFIED, Value: valueOf("item1", 2, rv1-1)},
+		}})
+		require.NotNil(t, rsp.Error)
+		require.Equal(t, int32(http.StatusConflict), rsp.Error.Code)
+
+		read := backend.ReadResource(ctx, &resource.ReadRequest{Key: keyOf("item3")})
+		require.NotNil(t, read.Error)
+		require.Equal(t, int32(http.StatusNotFound), read.Error.Code)
+	})
+
+	t.Run("append to the collection", func(t *testing.T) {
+		stream, err := backend.WatchWriteEvents(ctx)
+		require.NoError(t, err)
+
+		rsp := bulk.ProcessBulk(ctx, settings, &bulkRequests{requests: []*resource.BulkRequest{
+			{Key: keyOf("item3"), Action: resource.BulkRequest_ADDED, Value: valueOf("item3", 1, 0)},
+			{Key: keyOf("item1"), Action: resource.BulkRequest_MODIFIED, Value: valueOf("item1", 2, rv1)},
+			{Key: keyOf("item2"), Action: resource.BulkRequest_DELETED, Value: valueOf("item2", utils.DeletedGeneration, 0)},
+		}})
+		require.Nil(t, rsp.Error)
+		require.Empty(t, rsp.Rejected)
+		require.Len(t, rsp.Summary, 1)
+		require.Equal(t, int64(2), rsp.Summary[0].Count)
+
+		read := backend.ReadResource(ctx, &resource.ReadRequest{Key: keyOf("item1")})
+		require.Nil(t, read.Error)
+		require.Greater(t, read.ResourceVersion, rv1)
+		obj := &unstructured.Unstructured{}
+		require.NoError(t, obj.UnmarshalJSON(read.Value))
+		require.Equal(t, int64(2), obj.GetGeneration())
+		require.Empty(t, obj.GetResourceVersion(), "the saved value must not include a resource version")
+
+		read = backend.ReadResource(ctx, &resource.ReadRequest{Key: keyOf("item2")})
+		require.NotNil(t, read.Error)
+		require.Equal(t, int32(http.StatusNotFound), read.Error.Code)
+
+		names := []string{}
+		for len(names) < 3 {
+			select {
+			case event := <-stream:
+				if event.Key.Namespace == ns {
+					names = append(names, event.Key.Name)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatalf("missing watch events, got %v", names)
+			}
+		}
+		require.Equal(t, []string{"item3", "item1", "item2"}, names)
+	})
+}
+
+func runTestIntegrationBackendListWrittenSince(t *testing.T, backend resource.StorageBackend, nsPrefix string) {
+	lister, ok := backend.(resource.WrittenEventLister)
+	if !ok {
+		t.Skip("the backend does not list the written events")
+	}
+
+	ctx := testutil.NewDefaultTestContext(t)
+	group := nsPrefix + "-written.grafana.app"
+	rv1, err := writeEvent(ctx, backend, "item1", resource.WatchEvent_ADDED, WithNamespace("ns1"), WithGroup(group))
+	require.NoError(t, err)
+	rv2, err := writeEvent(ctx, backend, "item2", resource.WatchEvent_ADDED, WithNamespace("ns2"), WithGroup(group))
+	require.NoError(t, err)
+	rv3, err := writeEvent(ctx, backend, "item1", resource.WatchEvent_MODIFIED, WithNamespace("ns1"), WithGroup(group))
+	require.NoError(t, err)
+	rv4, err := writeEvent(ctx, backend, "item1", resource.WatchEvent_DELETED, WithNamespace("ns1"), WithGroup(group))
+	require.NoError(t, err)
+	_, err = writeEvent(ctx, backend, "item3", resource.WatchEvent_ADDED, WithNamespace("ns1"), WithGroup(group), WithResource("other"))
+	require.NoError(t, err)
+
+	var events []*resource.WrittenEvent
+	err = lister.ListWrittenSince(ctx, group, "resource", rv1, func(event *resource.WrittenEvent) error {
+		events = append(events, event)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+	require.Equal(t, []int64{rv2, rv3, rv4}, []int64{events[0].ResourceVersion, events[1].ResourceVersion, events[2].ResourceVersion})
+	require.Equal(t, "ns2", events[0].Key.Namespace)
+	require.Equal(t, resource.WatchEvent_ADDED, events[0].Type)
+	require.Equal(t, "item1", events[1].Key.Name)
+	require.Equal(t, resource.WatchEvent_MODIFIED, events[1].Type)
+	require.Equal(t, "folderuid", events[1].Folder)
+	require.Equal(t, resource.WatchEvent_DELETED, events[2].Type)
+}
+
+// bulkRequests iterates over a list of bulk requests
+type bulkRequests struct {
+	requests []*resource.BulkRequest
+	next     int
+}
+
+func (b *bulkRequests) Next() bool {
+	b.next++
+	return b.next <= len(b.requests)
+}
+
+func (b *bulkRequests) Request() *resource.BulkRequest {
+	return b.requests[b.next-1]
+}
+
+func (b *bulkRequests) RollbackRequested() bool {
+	return false
+}
+
 // WriteEventOption is a function that modifies WriteEventOptions
 type WriteEventOption func(*WriteEventOptions)
 
@@ -0,0 +1,106 @@
+package transfer
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/middleware"
+	"github.com/grafana/grafana/pkg/services/apiserver"
+	"github.com/grafana/grafana/pkg/services/apiserver/endpoints/request"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/storage/unified/resource"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+// API exposes the copy and the move of folders between organizations to the server admins
+type API struct {
+	transferer *Transferer
+	namespacer request.NamespaceMapper
+}
+
+func ProvideAPI(routeRegister routing.RouteRegister, cfg *setting.Cfg, client resource.ResourceClient,
+	restConfig apiserver.RestConfigProvider, rules AlertRuleStore) *API {
+	writer := &apiWriter{client: func(ctx context.Context) (dynamic.Interface, error) {
+		cfg, err := restConfig.GetRestConfig(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return dynamic.NewForConfig(cfg)
+	}}
+	api := &API{
+		transferer: NewTransferer(client, writer, rules),
+		namespacer: request.GetNamespaceMapper(cfg),
+	}
+	routeRegister.Group("/api/admin/unified-storage/transfer", func(r routing.RouteRegister) {
+		r.Post("/", routing.Wrap(api.transfer))
+	}, middleware.ReqGrafanaAdmin)
+	return api
+}
+
+// TransferCommand is the body of the transfer request
+type TransferCommand struct {
+	// The organization of the folder, defaults to the organization of the signed in user
+	SourceOrgID int64 `json:"sourceOrgId"`
+	TargetOrgID int64 `json:"targetOrgId"`
+
+	// The folder to copy with its subfolders
+	Folder string `json:"folder"`
+	// The parent folder of the copy in the target organization
+	TargetFolder string `json:"targetFolder"`
+
+	Move          bool `json:"move"`
+	GenerateNames bool `json:"generateNames"`
+	DryRun        bool `json:"dryRun"`
+
+	// The datasource UIDs of the target organization, by datasource UID of the source organization
+	Datasources map[string]string `json:"datasources"`
+
+	// The resources to copy with the folders, as resource.group (for example dashboards.dashboard.grafana.app)
+	Resources []string `json:"resources"`
+}
+
+// POST /api/admin/unified-storage/transfer
+func (a *API) transfer(c *contextmodel.ReqContext) response.Response {
+	cmd := TransferCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	if cmd.TargetOrgID == 0 {
+		return response.Error(http.StatusBadRequest, "targetOrgId is required", nil)
+	}
+	sourceOrgID := cmd.SourceOrgID
+	if sourceOrgID == 0 {
+		sourceOrgID = c.SignedInUser.GetOrgID()
+	}
+	req := Request{
+		SourceNamespace: a.namespacer(sourceOrgID),
+		TargetNamespace: a.namespacer(cmd.TargetOrgID),
+		Folder:          cmd.Folder,
+		TargetFolder:    cmd.TargetFolder,
+		Move:            cmd.Move,
+		GenerateNames:   cmd.GenerateNames,
+		Datasources:     cmd.Datasources,
+	}
+	for _, r := range cmd.Resources {
+		req.Resources = append(req.Resources, schema.ParseGroupResource(r))
+	}
+
+	result, err := a.transferer.Apply(c.Req.Context(), req, cmd.DryRun)
+	if errors.Is(err, ErrBadRequest) {
+		return response.Error(http.StatusBadRequest, err.Error(), err)
+	}
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to transfer", err)
+	}
+	if result.Error != "" {
+		return response.JSON(http.StatusConflict, result)
+	}
+	return response.JSON(http.StatusOK, result)
+}
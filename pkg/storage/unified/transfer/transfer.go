@@ -0,0 +1,800 @@
+package transfer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	claims "github.com/grafana/authlib/types"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/grafana/grafana/pkg/apimachinery/identity"
+	"github.com/grafana/grafana/pkg/apimachinery/utils"
+	"github.com/grafana/grafana/pkg/expr"
+	"github.com/grafana/grafana/pkg/infra/log"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/storage/unified/resource"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+var (
+	// ErrBadRequest is returned when the transfer request is not valid
+	ErrBadRequest = errors.New("invalid transfer request")
+
+	folders       = schema.GroupResource{Group: "folder.grafana.app", Resource: "folders"}
+	dashboards    = schema.GroupResource{Group: "dashboard.grafana.app", Resource: "dashboards"}
+	libraryPanels = schema.GroupResource{Group: "dashboard.grafana.app", Resource: "librarypanels"}
+
+	// The alert rules are not stored in unified storage, they are copied with the AlertRuleStore
+	alertRules = schema.GroupResource{Group: "rules.alerting.grafana.app", Resource: "alertrules"}
+
+	// DefaultResources are the resources copied with the folders when a request does not list them
+	DefaultResources = []schema.GroupResource{libraryPanels, dashboards, alertRules}
+)
+
+// Writer writes the copies and deletes the sources through the API server, so that the writes are
+// authorized, validated and admitted like any other write
+type Writer interface {
+	Create(ctx context.Context, gr schema.GroupResource, obj *unstructured.Unstructured) error
+	// Delete deletes the object, when its resource version is set it must be the latest
+	Delete(ctx context.Context, gr schema.GroupResource, obj *unstructured.Unstructured) error
+}
+
+// AlertRuleStore reads and writes the alert rules of the organizations
+type AlertRuleStore interface {
+	ListAlertRules(ctx context.Context, query *ngmodels.ListAlertRulesQuery) (ngmodels.RulesGroup, error)
+	InsertAlertRules(ctx context.Context, user *ngmodels.UserUID, rules []ngmodels.AlertRule) ([]ngmodels.AlertRuleKeyWithId, error)
+	DeleteAlertRulesByUID(ctx context.Context, orgID int64, user *ngmodels.UserUID, permanently bool, ruleUID ...string) error
+}
+
+// The datasource references that do not point to a datasource of the namespace
+var builtinDatasources = map[string]bool{
+	"grafana":         true,
+	"-- Grafana --":   true,
+	"-- Mixed --":     true,
+	"-- Dashboard --": true,
+}
+
+// Request describes the folder subtree to copy or move to another namespace
+type Request struct {
+	SourceNamespace string
+	TargetNamespace string
+
+	// The root of the subtree, it is copied with its subfolders and the resources they contain
+	Folder string
+
+	// The parent of the copied root folder in the target namespace, at the root when empty
+	TargetFolder string
+
+	// Delete the source resources once they are all copied
+	Move bool
+
+	// Give new names to the copies instead of keeping the names of the source resources,
+	// which is needed to copy a subtree within a namespace
+	GenerateNames bool
+
+	// Replaces the datasource UIDs referenced by the copied resources
+	Datasources map[string]string
+
+	// The resources copied with the folders, defaults to DefaultResources
+	Resources []schema.GroupResource
+}
+
+// Item is a resource written to the target namespace
+type Item struct {
+	Group    string `json:"group"`
+	Resource string `json:"resource"`
+	Name     string `json:"name"`
+
+	// The name of the copy, different from the name when the request generates names
+	TargetName string `json:"targetName"`
+	// The folder of the copy in the target namespace
+	TargetFolder string `json:"targetFolder,omitempty"`
+
+	SourceResourceVersion int64 `json:"sourceResourceVersion"`
+
+	// sort order of the writes, parent folders are created first and deleted last
+	depth int
+	value []byte
+	// the source object, recreated when a failed move is rolled back
+	source *unstructured.Unstructured
+	// the copy of an alert rule and its source
+	rule       *ngmodels.AlertRule
+	sourceRule *ngmodels.AlertRule
+}
+
+// Conflict is a resource that can not be copied
+type Conflict struct {
+	Group    string `json:"group"`
+	Resource string `json:"resource"`
+	Name     string `json:"name"`
+	Reason   string `json:"reason"`
+}
+
+// Plan lists the resources to copy, and what prevents the copy
+type Plan struct {
+	SourceNamespace string `json:"sourceNamespace"`
+	TargetNamespace string `json:"targetNamespace"`
+	Move            bool   `json:"move"`
+
+	Items     []Item     `json:"items"`
+	Conflicts []Conflict `json:"conflicts"`
+
+	// The datasource UIDs referenced by the copied resources without a mapping, kept as they are
+	UnmappedDatasources []string `json:"unmappedDatasources"`
+
+	// The library panels used by the copied dashboards which are neither copied nor in the target namespace
+	MissingLibraryPanels []string `json:"missingLibraryPanels"`
+}
+
+// Result is the outcome of a transfer
+type Result struct {
+	Plan
+
+	// Identifies the transfer in the logs and in the message of the copied resources
+	ID     string `json:"id"`
+	DryRun bool   `json:"dryRun"`
+
+	// The number of resources written to the target namespace and deleted from the source namespace.
+	// When a write fails the transfer stops, and the writes before it are rolled back.
+	Copied  int    `json:"copied"`
+	Deleted int    `json:"deleted"`
+	Error   string `json:"error,omitempty"`
+
+	// The number of writes undone after a failed write: the copies are deleted and the deleted
+	// sources are created again. When the rollback fails too, the other writes are still undone.
+	RolledBack    int    `json:"rolledBack,omitempty"`
+	RollbackError string `json:"rollbackError,omitempty"`
+}
+
+// Transferer copies and moves folder subtrees between namespaces
+type Transferer struct {
+	client resource.ResourceClient
+	writer Writer
+	rules  AlertRuleStore
+	log    log.Logger
+}
+
+// NewTransferer reads the resources with the client, and writes them with the writer. The alert rules
+// are not copied when the rule store is nil.
+func NewTransferer(client resource.ResourceClient, writer Writer, rules AlertRuleStore) *Transferer {
+	return &Transferer{
+		client: client,
+		writer: writer,
+		rules:  rules,
+		log:    log.New("unified-storage.transfer"),
+	}
+}
+
+// Preview returns the resources that Apply would copy
+func (t *Transferer) Preview(ctx context.Context, req Request) (*Plan, error) {
+	if req.SourceNamespace == "" || req.TargetNamespace == "" {
+		return nil, fmt.Errorf("%w: missing namespace", ErrBadRequest)
+	}
+	if req.Folder == "" {
+		return nil, fmt.Errorf("%w: missing folder", ErrBadRequest)
+	}
+	if req.SourceNamespace == req.TargetNamespace && !req.GenerateNames {
+		return nil, fmt.Errorf("%w: copies within a namespace need generated names", ErrBadRequest)
+	}
+	if req.SourceNamespace == req.TargetNamespace && req.Move {
+		return nil, fmt.Errorf("%w: resources can only be moved to another namespace", ErrBadRequest)
+	}
+	resources := req.Resources
+	if len(resources) == 0 {
+		resources = DefaultResources
+	}
+
+	sourceFolders, err := t.list(ctx, req.SourceNamespace, folders)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := sourceFolders[req.Folder]; !ok {
+		return nil, fmt.Errorf("%w: folder %q not found", ErrBadRequest, req.Folder)
+	}
+	targetFolders, err := t.list(ctx, req.TargetNamespace, folders)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := targetFolders[req.TargetFolder]; req.TargetFolder != "" && !ok {
+		return nil, fmt.Errorf("%w: target folder %q not found", ErrBadRequest, req.TargetFolder)
+	}
+
+	tree := folderTree{}
+	for name, v := range sourceFolders {
+		tree[name] = v.folder
+	}
+	subtree := map[string]bool{req.Folder: true}
+	tree.addDescendants(req.Folder, subtree)
+
+	plan := &Plan{
+		SourceNamespace:      req.SourceNamespace,
+		TargetNamespace:      req.TargetNamespace,
+		Move:                 req.Move,
+		Items:                []Item{},
+		Conflicts:            []Conflict{},
+		UnmappedDatasources:  []string{},
+		MissingLibraryPanels: []string{},
+	}
+
+	// The sources and the names of their copies, by resource
+	sources := map[schema.GroupResource]map[string]*version{folders: {}}
+	names := map[schema.GroupResource]map[string]string{folders: {}}
+	targets := map[schema.GroupResource]map[string]*version{folders: targetFolders}
+	for name := range subtree {
+		sources[folders][name] = sourceFolders[name]
+	}
+	for _, gr := range resources {
+		if gr == folders || gr == alertRules {
+			continue
+		}
+		items, err := t.list(ctx, req.SourceNamespace, gr)
+		if err != nil {
+			return nil, err
+		}
+		sources[gr] = map[string]*version{}
+		for name, v := range items {
+			if subtree[v.folder] {
+				sources[gr][name] = v
+			}
+		}
+		if targets[gr], err = t.list(ctx, req.TargetNamespace, gr); err != nil {
+			return nil, err
+		}
+	}
+	for gr, items := range sources {
+		names[gr] = make(map[string]string, len(items))
+		for name := range items {
+			names[gr][name] = name
+			if req.GenerateNames {
+				names[gr][name] = util.GenerateShortUID()
+			}
+		}
+	}
+
+	unmapped := map[string]bool{}
+	missing := map[string]bool{}
+	for gr, items := range sources {
+		for name, v := range items {
+			targetName := names[gr][name]
+			if _, ok := targets[gr][targetName]; ok {
+				plan.Conflicts = append(plan.Conflicts, Conflict{
+					Group:    gr.Group,
+					Resource: gr.Resource,
+					Name:     name,
+					Reason:   fmt.Sprintf("%s %q already exists in the target namespace", gr.Resource, targetName),
+				})
+				continue
+			}
+
+			item := Item{
+				Group:                 gr.Group,
+				Resource:              gr.Resource,
+				Name:                  name,
+				TargetName:            targetName,
+				TargetFolder:          names[folders][v.folder],
+				SourceResourceVersion: v.rv,
+			}
+			if gr == folders {
+				item.depth = tree.depth(name)
+				if name == req.Folder {
+					item.TargetFolder = req.TargetFolder
+				}
+			}
+
+			// the source is kept as it is, to be created again when a failed move is rolled back
+			item.source = v.obj.DeepCopy()
+			item.source.SetResourceVersion(strconv.FormatInt(v.rv, 10))
+
+			rewriter := &rewriter{
+				datasources:   req.Datasources,
+				libraryPanels: names[libraryPanels],
+				unmapped:      unmapped,
+				missing:       map[string]bool{},
+			}
+			if spec, ok := v.obj.Object["spec"]; ok {
+				v.obj.Object["spec"] = rewriter.rewrite(spec)
+			}
+			for uid := range rewriter.missing {
+				if _, ok := targets[libraryPanels][uid]; !ok {
+					missing[uid] = true
+				}
+			}
+			if item.value, err = copyValue(v, req.TargetNamespace, item.TargetName, item.TargetFolder); err != nil {
+				return nil, fmt.Errorf("copy %s %q: %w", gr.Resource, name, err)
+			}
+			plan.Items = append(plan.Items, item)
+		}
+	}
+
+	if t.rules != nil && slices.Contains(resources, alertRules) {
+		if err := t.planAlertRules(ctx, req, subtree, names, plan, unmapped); err != nil {
+			return nil, err
+		}
+	}
+
+	plan.UnmappedDatasources = sortedKeys(unmapped)
+	plan.MissingLibraryPanels = sortedKeys(missing)
+	sortItems(plan.Items)
+	sort.Slice(plan.Conflicts, func(i, j int) bool {
+		a, b := plan.Conflicts[i], plan.Conflicts[j]
+		if a.Resource != b.Resource {
+			return a.Resource < b.Resource
+		}
+		return a.Name < b.Name
+	})
+	return plan, nil
+}
+
+// Apply copies the resources listed by Preview, as the user in the context, and deletes the sources
+// when moving. Nothing is written when the plan has conflicts, or when it is a dry run.
+func (t *Transferer) Apply(ctx context.Context, req Request, dryRun bool) (*Result, error) {
+	user, err := identity.GetRequester(ctx)
+	if err != nil {
+		return nil, err
+	}
+	plan, err := t.Preview(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{Plan: *plan, ID: uuid.NewString(), DryRun: dryRun}
+	if len(plan.Conflicts) > 0 {
+		result.Error = fmt.Sprintf("%d resources conflict with the target namespace", len(plan.Conflicts))
+		return result, nil
+	}
+	if dryRun {
+		return result, nil
+	}
+
+	logger := t.log.FromContext(ctx).New("transfer", result.ID, "source", plan.SourceNamespace, "target", plan.TargetNamespace, "user", user.GetUID())
+	logger.Info("Transfer started", "folder", req.Folder, "items", len(plan.Items), "move", plan.Move)
+
+	message := fmt.Sprintf("Copied from %s (transfer %s)", plan.SourceNamespace, result.ID)
+	var copied, deleted []Item
+	fail := func(item Item, err error) (*Result, error) {
+		logger.Error("Transfer failed", "group", item.Group, "resource", item.Resource, "name", item.Name,
+			"copied", result.Copied, "deleted", result.Deleted, "error", err)
+		result.Error = err.Error()
+		t.rollback(ctx, logger, result, user, copied, deleted)
+		return result, nil
+	}
+	for _, item := range plan.Items {
+		if err := t.create(ctx, plan.TargetNamespace, item, user, message); err != nil {
+			return fail(item, err)
+		}
+		logger.Info("Resource copied", "group", item.Group, "resource", item.Resource, "name", item.Name, "targetName", item.TargetName)
+		copied = append(copied, item)
+		result.Copied++
+	}
+
+	if plan.Move {
+		// The resources are deleted before the folders containing them, children first
+		for i := len(plan.Items) - 1; i >= 0; i-- {
+			item := plan.Items[i]
+			if err := t.delete(ctx, item, user); err != nil {
+				return fail(item, err)
+			}
+			deleted = append(deleted, item)
+			result.Deleted++
+		}
+	}
+	logger.Info("Transfer completed", "copied", result.Copied, "deleted", result.Deleted)
+	return result, nil
+}
+
+// rollback undoes the writes of a failed transfer: the deleted sources are created again, parents first,
+// then the copies are deleted, children first. The rollback goes on when a write fails, to undo as much as possible.
+func (t *Transferer) rollback(ctx context.Context, logger log.Logger, result *Result, user identity.Requester, copied, deleted []Item) {
+	var errs []error
+	for i := len(deleted) - 1; i >= 0; i-- {
+		item := deleted[i]
+		if err := t.recreate(ctx, result.SourceNamespace, item, user); err != nil {
+			logger.Error("Transfer rollback failed", "group", item.Group, "resource", item.Resource, "name", item.Name, "error", err)
+			errs = append(errs, fmt.Errorf("recreate %s %q: %w", item.Resource, item.Name, err))
+			continue
+		}
+		result.RolledBack++
+	}
+	for i := len(copied) - 1; i >= 0; i-- {
+		item := copied[i]
+		if err := t.deleteCopy(ctx, result.TargetNamespace, item, user); err != nil {
+			logger.Error("Transfer rollback failed", "group", item.Group, "resource", item.Resource, "name", item.TargetName, "error", err)
+			errs = append(errs, fmt.Errorf("delete copy %s %q: %w", item.Resource, item.TargetName, err))
+			continue
+		}
+		result.RolledBack++
+	}
+	if err := errors.Join(errs...); err != nil {
+		result.RollbackError = err.Error()
+	}
+	logger.Info("Transfer rolled back", "rolledBack", result.RolledBack)
+}
+
+func (t *Transferer) create(ctx context.Context, namespace string, item Item, user identity.Requester, message string) error {
+	if item.rule != nil {
+		_, err := t.rules.InsertAlertRules(ctx, ngmodels.NewUserUID(user), []ngmodels.AlertRule{*item.rule})
+		return err
+	}
+	obj := &unstructured.Unstructured{}
+	if err := obj.UnmarshalJSON(item.value); err != nil {
+		return err
+	}
+	meta, err := utils.MetaAccessor(obj)
+	if err != nil {
+		return err
+	}
+	meta.SetMessage(message)
+	obj.SetNamespace(namespace)
+	return t.writer.Create(ctx, schema.GroupResource{Group: item.Group, Resource: item.Resource}, obj)
+}
+
+// delete deletes the source of a moved resource, unless it changed since it was listed
+func (t *Transferer) delete(ctx context.Context, item Item, user identity.Requester) error {
+	if item.sourceRule != nil {
+		return t.rules.DeleteAlertRulesByUID(ctx, item.sourceRule.OrgID, ngmodels.NewUserUID(user), false, item.Name)
+	}
+	return t.writer.Delete(ctx, schema.GroupResource{Group: item.Group, Resource: item.Resource}, item.source)
+}
+
+// recreate creates a deleted source again
+func (t *Transferer) recreate(ctx context.Context, namespace string, item Item, user identity.Requester) error {
+	if item.sourceRule != nil {
+		rule := item.sourceRule.Copy()
+		rule.ID = 0
+		_, err := t.rules.InsertAlertRules(ctx, ngmodels.NewUserUID(user), []ngmodels.AlertRule{*rule})
+		return err
+	}
+	obj := item.source.DeepCopy()
+	obj.SetResourceVersion("")
+	obj.SetNamespace(namespace)
+	return t.writer.Create(ctx, schema.GroupResource{Group: item.Group, Resource: item.Resource}, obj)
+}
+
+func (t *Transferer) deleteCopy(ctx context.Context, namespace string, item Item, user identity.Requester) error {
+	if item.rule != nil {
+		return t.rules.DeleteAlertRulesByUID(ctx, item.rule.OrgID, ngmodels.NewUserUID(user), true, item.TargetName)
+	}
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion(item.source.GetAPIVersion())
+	obj.SetNamespace(namespace)
+	obj.SetName(item.TargetName)
+	return t.writer.Delete(ctx, schema.GroupResource{Group: item.Group, Resource: item.Resource}, obj)
+}
+
+// planAlertRules adds the alert rules of the copied folders to the plan
+func (t *Transferer) planAlertRules(ctx context.Context, req Request, subtree map[string]bool, names map[schema.GroupResource]map[string]string, plan *Plan, unmapped map[string]bool) error {
+	source, err := claims.ParseNamespace(req.SourceNamespace)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrBadRequest, err)
+	}
+	target, err := claims.ParseNamespace(req.TargetNamespace)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrBadRequest, err)
+	}
+	rules, err := t.rules.ListAlertRules(ctx, &ngmodels.ListAlertRulesQuery{OrgID: source.OrgID, NamespaceUIDs: sortedKeys(subtree)})
+	if err != nil {
+		return fmt.Errorf("list alert rules: %w", err)
+	}
+	if len(rules) == 0 {
+		return nil
+	}
+
+	targetNames := make(map[string]string, len(rules))
+	uids := make([]string, 0, len(rules))
+	for _, rule := range rules {
+		targetNames[rule.UID] = rule.UID
+		if req.GenerateNames {
+			targetNames[rule.UID] = util.GenerateShortUID()
+		}
+		uids = append(uids, targetNames[rule.UID])
+	}
+	existing, err := t.rules.ListAlertRules(ctx, &ngmodels.ListAlertRulesQuery{OrgID: target.OrgID, RuleUIDs: uids})
+	if err != nil {
+		return fmt.Errorf("list alert rules: %w", err)
+	}
+	exists := make(map[string]bool, len(existing))
+	for _, rule := range existing {
+		exists[rule.UID] = true
+	}
+
+	rewriter := &rewriter{datasources: req.Datasources, unmapped: unmapped, missing: map[string]bool{}}
+	for _, rule := range rules {
+		targetName := targetNames[rule.UID]
+		if exists[targetName] {
+			plan.Conflicts = append(plan.Conflicts, Conflict{
+				Group:    alertRules.Group,
+				Resource: alertRules.Resource,
+				Name:     rule.UID,
+				Reason:   fmt.Sprintf("%s %q already exists in the target namespace", alertRules.Resource, targetName),
+			})
+			continue
+		}
+		copied, err := copyRule(rule, target.OrgID, targetName, names[folders][rule.NamespaceUID], names[dashboards], rewriter)
+		if err != nil {
+			return fmt.Errorf("copy %s %q: %w", alertRules.Resource, rule.UID, err)
+		}
+		plan.Items = append(plan.Items, Item{
+			Group:                 alertRules.Group,
+			Resource:              alertRules.Resource,
+			Name:                  rule.UID,
+			TargetName:            targetName,
+			TargetFolder:          copied.NamespaceUID,
+			SourceResourceVersion: rule.Version,
+			rule:                  copied,
+			sourceRule:            rule,
+		})
+	}
+	return nil
+}
+
+// copyRule returns the copy of an alert rule in another organization, with its datasource, folder and
+// dashboard references rewritten. The link to a dashboard which is not copied is removed.
+func copyRule(rule *ngmodels.AlertRule, orgID int64, uid, folder string, dashboardNames map[string]string, r *rewriter) (*ngmodels.AlertRule, error) {
+	c := rule.Copy()
+	c.ID = 0
+	c.GUID = ""
+	c.OrgID = orgID
+	c.UID = uid
+	c.NamespaceUID = folder
+	for i := range c.Data {
+		q := &c.Data[i]
+		if !expr.IsDataSource(q.DatasourceUID) {
+			q.DatasourceUID = r.datasourceUID(q.DatasourceUID)
+		}
+		if len(q.Model) == 0 {
+			continue
+		}
+		var model any
+		if err := json.Unmarshal(q.Model, &model); err != nil {
+			return nil, err
+		}
+		raw, err := json.Marshal(r.rewrite(model))
+		if err != nil {
+			return nil, err
+		}
+		q.Model = raw
+	}
+	if c.Record != nil && c.Record.TargetDatasourceUID != "" {
+		c.Record.TargetDatasourceUID = r.datasourceUID(c.Record.TargetDatasourceUID)
+	}
+	if c.DashboardUID != nil {
+		if name, ok := dashboardNames[*c.DashboardUID]; ok {
+			c.DashboardUID = &name
+		} else {
+			c.DashboardUID = nil
+			c.PanelID = nil
+		}
+	}
+	if c.Annotations != nil {
+		// the annotations keep the dashboard link of the rule
+		if c.DashboardUID != nil {
+			c.Annotations[ngmodels.DashboardUIDAnnotation] = *c.DashboardUID
+		} else {
+			delete(c.Annotations, ngmodels.DashboardUIDAnnotation)
+			delete(c.Annotations, ngmodels.PanelIDAnnotation)
+		}
+	}
+	return c, nil
+}
+
+// copyValue returns the value of the copy of a resource, without the metadata of the source
+func copyValue(v *version, namespace, name, folder string) ([]byte, error) {
+	obj := v.obj
+	meta := v.meta
+	obj.SetNamespace(namespace)
+	obj.SetName(name)
+	obj.SetUID(types.UID(uuid.NewString()))
+	obj.SetResourceVersion("")
+	obj.SetGeneration(1)
+	obj.SetManagedFields(nil)
+	obj.SetCreationTimestamp(metav1.NewTime(time.Now()))
+	meta.SetFolder(folder)
+	meta.SetUpdatedBy("")
+	meta.SetUpdatedTimestamp(nil)
+	// The legacy ID and the repository belong to the source namespace
+	// nolint:staticcheck
+	meta.SetDeprecatedInternalID(0)
+	meta.SetManagerProperties(utils.ManagerProperties{})
+	meta.SetSourceProperties(utils.SourceProperties{})
+	annotations := obj.GetAnnotations()
+	delete(annotations, utils.AnnoKeyKubectlLastAppliedConfig)
+	delete(annotations, utils.AnnoKeyFullpath)
+	delete(annotations, utils.AnnoKeyFullpathUIDs)
+	obj.SetAnnotations(annotations)
+	return obj.MarshalJSON()
+}
+
+// rewriter replaces the datasource and library panel references of a spec
+type rewriter struct {
+	datasources   map[string]string
+	libraryPanels map[string]string
+
+	// the datasource UIDs without a mapping
+	unmapped map[string]bool
+	// the library panels which are not copied
+	missing map[string]bool
+}
+
+func (r *rewriter) rewrite(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, child := range v {
+			switch k {
+			case "datasource":
+				v[k] = r.datasource(child)
+			case "libraryPanel":
+				r.libraryPanel(child)
+			}
+			v[k] = r.rewrite(v[k])
+		}
+	case []any:
+		for i, child := range v {
+			v[i] = r.rewrite(child)
+		}
+	}
+	return v
+}
+
+// datasource rewrites a datasource reference, which is either a name or UID, or an object with an UID
+func (r *rewriter) datasource(ref any) any {
+	switch ref := ref.(type) {
+	case string:
+		return r.datasourceUID(ref)
+	case map[string]any:
+		if uid, ok := ref["uid"].(string); ok {
+			ref["uid"] = r.datasourceUID(uid)
+		}
+	}
+	return ref
+}
+
+func (r *rewriter) datasourceUID(uid string) string {
+	if uid == "" || builtinDatasources[uid] || strings.HasPrefix(uid, "$") {
+		return uid
+	}
+	if mapped, ok := r.datasources[uid]; ok {
+		return mapped
+	}
+	r.unmapped[uid] = true
+	return uid
+}
+
+func (r *rewriter) libraryPanel(ref any) {
+	m, ok := ref.(map[string]any)
+	if !ok {
+		return
+	}
+	uid, ok := m["uid"].(string)
+	if !ok || uid == "" {
+		return
+	}
+	if name, ok := r.libraryPanels[uid]; ok {
+		m["uid"] = name
+		return
+	}
+	r.missing[uid] = true
+}
+
+type version struct {
+	rv     int64
+	folder string
+	obj    *unstructured.Unstructured
+	meta   utils.GrafanaMetaAccessor
+}
+
+// list returns the current resources of a namespace by name
+func (t *Transferer) list(ctx context.Context, namespace string, gr schema.GroupResource) (map[string]*version, error) {
+	items := make(map[string]*version)
+	req := &resource.ListRequest{
+		Limit: 500,
+		Options: &resource.ListOptions{Key: &resource.ResourceKey{
+			Namespace: namespace,
+			Group:     gr.Group,
+			Resource:  gr.Resource,
+		}},
+	}
+	for {
+		rsp, err := t.client.List(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		if rsp.Error != nil {
+			// a resource that is not served by this storage has nothing to copy
+			if rsp.Error.Code == http.StatusNotFound {
+				return items, nil
+			}
+			return nil, fmt.Errorf("list %s: %w", gr, resource.GetError(rsp.Error))
+		}
+		for _, item := range rsp.Items {
+			obj := &unstructured.Unstructured{}
+			if err := obj.UnmarshalJSON(item.Value); err != nil {
+				return nil, fmt.Errorf("list %s: %w", gr, err)
+			}
+			meta, err := utils.MetaAccessor(obj)
+			if err != nil {
+				return nil, fmt.Errorf("list %s: %w", gr, err)
+			}
+			items[meta.GetName()] = &version{rv: item.ResourceVersion, folder: meta.GetFolder(), obj: obj, meta: meta}
+		}
+		if rsp.NextPageToken == "" {
+			return items, nil
+		}
+		req.NextPageToken = rsp.NextPageToken
+	}
+}
+
+// folderTree is the parent of each folder
+type folderTree map[string]string
+
+func (t folderTree) addDescendants(folder string, into map[string]bool) {
+	for name, parent := range t {
+		if parent == folder && !into[name] {
+			into[name] = true
+			t.addDescendants(name, into)
+		}
+	}
+}
+
+func (t folderTree) depth(name string) int {
+	depth := 0
+	seen := map[string]bool{}
+	for parent := t[name]; parent != "" && !seen[parent]; parent = t[parent] {
+		seen[parent] = true
+		depth++
+	}
+	return depth
+}
+
+// sortItems orders the writes so that the folders exist before their content,
+// the library panels before the dashboards using them, and the dashboards before the alert rules linked to them
+func sortItems(items []Item) {
+	rank := func(i Item) int {
+		switch (schema.GroupResource{Group: i.Group, Resource: i.Resource}) {
+		case folders:
+			return 0
+		case libraryPanels:
+			return 1
+		case alertRules:
+			return 3
+		default:
+			return 2
+		}
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		a, b := items[i], items[j]
+		if ra, rb := rank(a), rank(b); ra != rb {
+			return ra < rb
+		}
+		if a.depth != b.depth {
+			return a.depth < b.depth
+		}
+		if a.Group != b.Group {
+			return a.Group < b.Group
+		}
+		if a.Resource != b.Resource {
+			return a.Resource < b.Resource
+		}
+		return a.Name < b.Name
+	})
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,406 @@
+package transfer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/grafana/authlib/types"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+
+	"github.com/grafana/grafana/pkg/apimachinery/identity"
+	"github.com/grafana/grafana/pkg/apimachinery/utils"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/storage/unified/kv"
+	"github.com/grafana/grafana/pkg/storage/unified/resource"
+)
+
+func TestTransfer(t *testing.T) {
+	ctx := identity.WithRequester(context.Background(), &identity.StaticRequester{
+		Type:           types.TypeUser,
+		Login:          "admin",
+		UserID:         1,
+		UserUID:        "u1",
+		OrgRole:        identity.RoleAdmin,
+		IsGrafanaAdmin: true,
+	})
+	client := newTestClient(t)
+
+	key := func(namespace, res, name string) *resource.ResourceKey {
+		group := "dashboard.grafana.app"
+		if res == "folders" {
+			group = "folder.grafana.app"
+		}
+		return &resource.ResourceKey{Namespace: namespace, Group: group, Resource: res, Name: name}
+	}
+	write := func(namespace, res, name, folder string, spec map[string]any) {
+		t.Helper()
+		k := key(namespace, res, name)
+		kinds := map[string]string{"folders": "Folder", "dashboards": "Dashboard", "librarypanels": "LibraryPanel"}
+		value, err := json.Marshal(map[string]any{
+			"apiVersion": k.Group + "/v1",
+			"kind":       kinds[res],
+			"metadata": map[string]any{
+				"name":        name,
+				"namespace":   namespace,
+				"uid":         namespace + "-" + name,
+				"annotations": map[string]any{utils.AnnoKeyFolder: folder},
+			},
+			"spec": spec,
+		})
+		require.NoError(t, err)
+		rsp, err := client.Create(ctx, &resource.CreateRequest{Key: k, Value: value})
+		require.NoError(t, err)
+		require.Nil(t, rsp.Error)
+	}
+	read := func(namespace, res, name string) (*unstructured.Unstructured, utils.GrafanaMetaAccessor) {
+		t.Helper()
+		rsp, err := client.Read(ctx, &resource.ReadRequest{Key: key(namespace, res, name)})
+		require.NoError(t, err)
+		require.Nil(t, rsp.Error, "%s/%s/%s", namespace, res, name)
+		obj := &unstructured.Unstructured{}
+		require.NoError(t, obj.UnmarshalJSON(rsp.Value))
+		meta, err := utils.MetaAccessor(obj)
+		require.NoError(t, err)
+		return obj, meta
+	}
+
+	write("default", "folders", "f1", "", map[string]any{"title": "F1"})
+	write("default", "folders", "f2", "f1", map[string]any{"title": "F2"})
+	write("default", "folders", "other", "", map[string]any{"title": "Other"})
+	write("default", "librarypanels", "lp1", "f1", map[string]any{"title": "LP1", "model": map[string]any{"datasource": "ds-a"}})
+	write("default", "dashboards", "d1", "f2", map[string]any{
+		"title": "D1",
+		"panels": []any{
+			map[string]any{"datasource": map[string]any{"type": "prometheus", "uid": "ds-a"}},
+			map[string]any{"datasource": map[string]any{"uid": "${ds}"}, "targets": []any{map[string]any{"datasource": map[string]any{"uid": "ds-c"}}}},
+			map[string]any{"libraryPanel": map[string]any{"uid": "lp1", "name": "LP1"}},
+			map[string]any{"libraryPanel": map[string]any{"uid": "lp-elsewhere", "name": "Elsewhere"}},
+		},
+	})
+	write("default", "dashboards", "d2", "other", map[string]any{"title": "D2"})
+	write("org-2", "folders", "target", "", map[string]any{"title": "Target"})
+	write("org-2", "dashboards", "d1", "", map[string]any{"title": "Existing"})
+
+	rules := newFakeRuleStore()
+	dashboard := "d1"
+	rules.add(&ngmodels.AlertRule{
+		OrgID:        1,
+		UID:          "r1",
+		Title:        "R1",
+		NamespaceUID: "f2",
+		DashboardUID: &dashboard,
+		Data: []ngmodels.AlertQuery{
+			{RefID: "A", DatasourceUID: "ds-a", Model: json.RawMessage(`{"datasource":{"uid":"ds-a"}}`)},
+			{RefID: "B", DatasourceUID: "__expr__", Model: json.RawMessage(`{"expression":"A"}`)},
+		},
+	})
+	rules.add(&ngmodels.AlertRule{OrgID: 1, UID: "r2", Title: "R2", NamespaceUID: "other"})
+
+	writer := &storageWriter{client: client}
+	transferer := NewTransferer(client, writer, rules)
+	req := Request{
+		SourceNamespace: "default",
+		TargetNamespace: "org-2",
+		Folder:          "f1",
+		TargetFolder:    "target",
+		Move:            true,
+		Datasources:     map[string]string{"ds-a": "ds-b"},
+	}
+	summary := func(items []Item) []string {
+		s := make([]string, 0, len(items))
+		for _, i := range items {
+			s = append(s, i.Resource+"/"+i.Name+" -> "+i.TargetFolder)
+		}
+		return s
+	}
+
+	t.Run("conflicts are reported and nothing is written", func(t *testing.T) {
+		result, err := transferer.Apply(ctx, req, false)
+		require.NoError(t, err)
+		require.NotEmpty(t, result.Error)
+		require.Equal(t, []Conflict{{
+			Group:    "dashboard.grafana.app",
+			Resource: "dashboards",
+			Name:     "d1",
+			Reason:   `dashboards "d1" already exists in the target namespace`,
+		}}, result.Conflicts)
+		require.Zero(t, result.Copied)
+		read("default", "folders", "f1")
+
+		rsp, err := client.Delete(ctx, &resource.DeleteRequest{Key: key("org-2", "dashboards", "d1")})
+		require.NoError(t, err)
+		require.Nil(t, rsp.Error)
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		result, err := transferer.Apply(ctx, req, true)
+		require.NoError(t, err)
+		require.Empty(t, result.Error)
+		require.Empty(t, result.Conflicts)
+		require.Equal(t, []string{
+			"folders/f1 -> target",
+			"folders/f2 -> f1",
+			"librarypanels/lp1 -> f1",
+			"dashboards/d1 -> f2",
+			"alertrules/r1 -> f2",
+		}, summary(result.Items))
+		require.Equal(t, []string{"ds-c"}, result.UnmappedDatasources)
+		require.Equal(t, []string{"lp-elsewhere"}, result.MissingLibraryPanels)
+		require.Zero(t, result.Copied)
+
+		rsp, err := client.Read(ctx, &resource.ReadRequest{Key: key("org-2", "folders", "f1")})
+		require.NoError(t, err)
+		require.NotNil(t, rsp.Error)
+	})
+
+	t.Run("move", func(t *testing.T) {
+		result, err := transferer.Apply(ctx, req, false)
+		require.NoError(t, err)
+		require.Empty(t, result.Error)
+		require.Equal(t, 5, result.Copied)
+		require.Equal(t, 5, result.Deleted)
+
+		_, meta := read("org-2", "folders", "f1")
+		require.Equal(t, "target", meta.GetFolder())
+		require.Contains(t, meta.GetMessage(), result.ID)
+		obj, meta := read("org-2", "dashboards", "d1")
+		require.Equal(t, "f2", meta.GetFolder())
+		require.NotEqual(t, "default-d1", string(meta.GetUID()))
+		panels, _, _ := unstructured.NestedSlice(obj.Object, "spec", "panels")
+		require.Equal(t, "ds-b", panels[0].(map[string]any)["datasource"].(map[string]any)["uid"])
+		require.Equal(t, "${ds}", panels[1].(map[string]any)["datasource"].(map[string]any)["uid"])
+		obj, _ = read("org-2", "librarypanels", "lp1")
+		ds, _, _ := unstructured.NestedString(obj.Object, "spec", "model", "datasource")
+		require.Equal(t, "ds-b", ds)
+
+		for _, name := range []string{"f1", "f2"} {
+			rsp, err := client.Read(ctx, &resource.ReadRequest{Key: key("default", "folders", name)})
+			require.NoError(t, err)
+			require.NotNil(t, rsp.Error)
+		}
+		read("default", "dashboards", "d2")
+
+		rule := rules.get(2, "r1")
+		require.NotNil(t, rule)
+		require.Equal(t, "f2", rule.NamespaceUID)
+		require.Equal(t, "d1", *rule.DashboardUID)
+		require.Equal(t, "ds-b", rule.Data[0].DatasourceUID)
+		require.JSONEq(t, `{"datasource":{"uid":"ds-b"}}`, string(rule.Data[0].Model))
+		require.Equal(t, "__expr__", rule.Data[1].DatasourceUID)
+		require.Nil(t, rules.get(1, "r1"))
+		require.NotNil(t, rules.get(1, "r2"))
+	})
+
+	t.Run("a failed move is rolled back", func(t *testing.T) {
+		// the root folder is deleted last, once all the other writes are done
+		writer.failDelete = "org-2/f1"
+		defer func() { writer.failDelete = "" }()
+
+		result, err := transferer.Apply(ctx, Request{
+			SourceNamespace: "org-2",
+			TargetNamespace: "default",
+			Folder:          "f1",
+			Move:            true,
+		}, false)
+		require.NoError(t, err)
+		require.NotEmpty(t, result.Error)
+		require.Equal(t, 5, result.Copied)
+		require.Equal(t, 4, result.Deleted)
+		require.Equal(t, 9, result.RolledBack)
+		require.Empty(t, result.RollbackError)
+
+		for _, item := range result.Items {
+			if item.Resource == "alertrules" {
+				require.NotNil(t, rules.get(2, item.Name))
+				require.Nil(t, rules.get(1, item.Name))
+				continue
+			}
+			read("org-2", item.Resource, item.Name)
+			rsp, err := client.Read(ctx, &resource.ReadRequest{Key: key("default", item.Resource, item.Name)})
+			require.NoError(t, err)
+			require.NotNil(t, rsp.Error, "%s/%s", item.Resource, item.Name)
+		}
+		_, meta := read("org-2", "dashboards", "d1")
+		require.Equal(t, "f2", meta.GetFolder())
+	})
+
+	t.Run("copy within a namespace with new names", func(t *testing.T) {
+		result, err := transferer.Apply(ctx, Request{
+			SourceNamespace: "org-2",
+			TargetNamespace: "org-2",
+			Folder:          "f1",
+			GenerateNames:   true,
+		}, false)
+		require.NoError(t, err)
+		require.Empty(t, result.Error)
+		require.Equal(t, 5, result.Copied)
+		require.Zero(t, result.Deleted)
+
+		names := map[string]string{}
+		for _, item := range result.Items {
+			require.NotEqual(t, item.Name, item.TargetName)
+			names[item.Resource+"/"+item.Name] = item.TargetName
+		}
+		_, meta := read("org-2", "folders", names["folders/f2"])
+		require.Equal(t, names["folders/f1"], meta.GetFolder())
+		obj, _ := read("org-2", "dashboards", names["dashboards/d1"])
+		panels, _, _ := unstructured.NestedSlice(obj.Object, "spec", "panels")
+		require.Equal(t, names["librarypanels/lp1"], panels[2].(map[string]any)["libraryPanel"].(map[string]any)["uid"])
+		rule := rules.get(2, names["alertrules/r1"])
+		require.Equal(t, names["folders/f2"], rule.NamespaceUID)
+		require.Equal(t, names["dashboards/d1"], *rule.DashboardUID)
+	})
+
+	t.Run("invalid requests", func(t *testing.T) {
+		_, err := transferer.Preview(ctx, Request{SourceNamespace: "org-2", TargetNamespace: "org-2", Folder: "f1"})
+		require.ErrorIs(t, err, ErrBadRequest)
+		_, err = transferer.Preview(ctx, Request{SourceNamespace: "default", TargetNamespace: "org-2", Folder: "missing"})
+		require.ErrorIs(t, err, ErrBadRequest)
+		_, err = transferer.Preview(ctx, Request{SourceNamespace: "org-2", TargetNamespace: "default", Folder: "f1", TargetFolder: "missing"})
+		require.ErrorIs(t, err, ErrBadRequest)
+	})
+}
+
+func newTestClient(t *testing.T) resource.ResourceClient {
+	t.Helper()
+
+	backend, err := kv.NewBackend(kv.BackendOptions{Path: filepath.Join(t.TempDir(), "resource.db")})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = backend.Stop(context.Background())
+	})
+	server, err := resource.NewResourceServer(resource.ResourceServerOptions{Backend: backend})
+	require.NoError(t, err)
+	return resource.NewLocalResourceClient(server)
+}
+
+// storageWriter writes to the resource client, in place of the API server
+type storageWriter struct {
+	client resource.ResourceClient
+	// the namespace/name of a resource which fails to be deleted
+	failDelete string
+}
+
+func (w *storageWriter) key(gr schema.GroupResource, obj *unstructured.Unstructured) *resource.ResourceKey {
+	return &resource.ResourceKey{Namespace: obj.GetNamespace(), Group: gr.Group, Resource: gr.Resource, Name: obj.GetName()}
+}
+
+func (w *storageWriter) Create(ctx context.Context, gr schema.GroupResource, obj *unstructured.Unstructured) error {
+	value, err := obj.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	rsp, err := w.client.Create(ctx, &resource.CreateRequest{Key: w.key(gr, obj), Value: value})
+	if err != nil {
+		return err
+	}
+	return resource.GetError(rsp.Error)
+}
+
+func (w *storageWriter) Delete(ctx context.Context, gr schema.GroupResource, obj *unstructured.Unstructured) error {
+	if obj.GetNamespace()+"/"+obj.GetName() == w.failDelete {
+		return errors.New("delete failed")
+	}
+	req := &resource.DeleteRequest{Key: w.key(gr, obj)}
+	if rv := obj.GetResourceVersion(); rv != "" {
+		var err error
+		if req.ResourceVersion, err = strconv.ParseInt(rv, 10, 64); err != nil {
+			return err
+		}
+	}
+	rsp, err := w.client.Delete(ctx, req)
+	if err != nil {
+		return err
+	}
+	return resource.GetError(rsp.Error)
+}
+
+type fakeRuleStore struct {
+	rules map[int64]map[string]*ngmodels.AlertRule
+}
+
+func newFakeRuleStore() *fakeRuleStore {
+	return &fakeRuleStore{rules: map[int64]map[string]*ngmodels.AlertRule{}}
+}
+
+func (s *fakeRuleStore) add(rule *ngmodels.AlertRule) {
+	if s.rules[rule.OrgID] == nil {
+		s.rules[rule.OrgID] = map[string]*ngmodels.AlertRule{}
+	}
+	s.rules[rule.OrgID][rule.UID] = rule
+}
+
+func (s *fakeRuleStore) get(orgID int64, uid string) *ngmodels.AlertRule {
+	return s.rules[orgID][uid]
+}
+
+func (s *fakeRuleStore) ListAlertRules(ctx context.Context, query *ngmodels.ListAlertRulesQuery) (ngmodels.RulesGroup, error) {
+	var result ngmodels.RulesGroup
+	for _, rule := range s.rules[query.OrgID] {
+		if len(query.NamespaceUIDs) > 0 && !slices.Contains(query.NamespaceUIDs, rule.NamespaceUID) {
+			continue
+		}
+		if len(query.RuleUIDs) > 0 && !slices.Contains(query.RuleUIDs, rule.UID) {
+			continue
+		}
+		result = append(result, rule)
+	}
+	return result, nil
+}
+
+func (s *fakeRuleStore) InsertAlertRules(ctx context.Context, user *ngmodels.UserUID, rules []ngmodels.AlertRule) ([]ngmodels.AlertRuleKeyWithId, error) {
+	keys := make([]ngmodels.AlertRuleKeyWithId, 0, len(rules))
+	for _, rule := range rules {
+		if s.get(rule.OrgID, rule.UID) != nil {
+			return nil, fmt.Errorf("rule %q already exists", rule.UID)
+		}
+		rule.Version = 1
+		s.add(&rule)
+		keys = append(keys, ngmodels.AlertRuleKeyWithId{AlertRuleKey: rule.GetKey()})
+	}
+	return keys, nil
+}
+
+func (s *fakeRuleStore) DeleteAlertRulesByUID(ctx context.Context, orgID int64, user *ngmodels.UserUID, permanently bool, ruleUID ...string) error {
+	for _, uid := range ruleUID {
+		delete(s.rules[orgID], uid)
+	}
+	return nil
+}
+
+func TestAPIWriter(t *testing.T) {
+	ctx := context.Background()
+	client := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())
+	writer := &apiWriter{client: func(context.Context) (dynamic.Interface, error) {
+		return client, nil
+	}}
+	gvr := schema.GroupVersionResource{Group: "dashboard.grafana.app", Version: "v1", Resource: "dashboards"}
+
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion("dashboard.grafana.app/v1")
+	obj.SetKind("Dashboard")
+	obj.SetNamespace("org-2")
+	obj.SetName("d1")
+	require.NoError(t, writer.Create(ctx, gvr.GroupResource(), obj))
+
+	created, err := client.Resource(gvr).Namespace("org-2").Get(ctx, "d1", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, "d1", created.GetName())
+
+	require.NoError(t, writer.Delete(ctx, gvr.GroupResource(), obj))
+	_, err = client.Resource(gvr).Namespace("org-2").Get(ctx, "d1", metav1.GetOptions{})
+	require.True(t, apierrors.IsNotFound(err))
+}
@@ -0,0 +1,48 @@
+package transfer
+
+import (
+	"context"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// apiWriter writes the resources with a dynamic client of the API server, as the user in the context
+type apiWriter struct {
+	client func(ctx context.Context) (dynamic.Interface, error)
+}
+
+func (w *apiWriter) resource(ctx context.Context, gr schema.GroupResource, obj *unstructured.Unstructured) (dynamic.ResourceInterface, error) {
+	client, err := w.client(ctx)
+	if err != nil {
+		return nil, err
+	}
+	gv, err := schema.ParseGroupVersion(obj.GetAPIVersion())
+	if err != nil {
+		return nil, err
+	}
+	return client.Resource(gv.WithResource(gr.Resource)).Namespace(obj.GetNamespace()), nil
+}
+
+func (w *apiWriter) Create(ctx context.Context, gr schema.GroupResource, obj *unstructured.Unstructured) error {
+	client, err := w.resource(ctx, gr, obj)
+	if err != nil {
+		return err
+	}
+	_, err = client.Create(ctx, obj, metav1.CreateOptions{})
+	return err
+}
+
+func (w *apiWriter) Delete(ctx context.Context, gr schema.GroupResource, obj *unstructured.Unstructured) error {
+	client, err := w.resource(ctx, gr, obj)
+	if err != nil {
+		return err
+	}
+	opts := metav1.DeleteOptions{}
+	if rv := obj.GetResourceVersion(); rv != "" {
+		opts.Preconditions = &metav1.Preconditions{ResourceVersion: &rv}
+	}
+	return client.Delete(ctx, obj.GetName(), opts)
+}
@@ -0,0 +1,206 @@
+package files
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/datasource"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/instancemgmt"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/resource/httpadapter"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/filestorage"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// PluginID is the id of the files datasource, also used to look up its settings in the [plugin.grafana-files-datasource] section.
+const PluginID = "grafana-files-datasource"
+
+const (
+	sourceStorage   = "storage"
+	sourceDirectory = "directory"
+
+	// allowedDirectoriesSetting is a comma separated list of the local directories datasources can read
+	allowedDirectoriesSetting = "allowed_directories"
+)
+
+var logger = backend.NewLoggerWith("logger", "tsdb.files")
+
+type Service struct {
+	im              instancemgmt.InstanceManager
+	resourceHandler backend.CallResourceHandler
+}
+
+func ProvideService(cfg *setting.Cfg, sql db.DB) *Service {
+	allowedDirectories := []string{}
+	for _, dir := range strings.Split(cfg.PluginSettings[PluginID][allowedDirectoriesSetting], ",") {
+		if dir = strings.TrimSpace(dir); dir != "" {
+			allowedDirectories = append(allowedDirectories, filepath.Clean(dir))
+		}
+	}
+
+	var provider storageProvider
+	if sql != nil {
+		provider = func(orgID int64, uid string) filestorage.FileStorage {
+			return filestorage.NewDbStorage(storageLogger, sql, nil, storagePathPrefix(orgID, uid))
+		}
+	}
+	return newService(provider, allowedDirectories)
+}
+
+func newService(storageProvider storageProvider, allowedDirectories []string) *Service {
+	s := &Service{
+		im: datasource.NewInstanceManager(newInstanceSettings(storageProvider, allowedDirectories)),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /files", s.handleList)
+	mux.HandleFunc("PUT /files/{path...}", s.handleUpload)
+	mux.HandleFunc("DELETE /files/{path...}", s.handleDelete)
+	s.resourceHandler = httpadapter.New(mux)
+
+	return s
+}
+
+type datasourceInfo struct {
+	Source      fileSource
+	Permissions []permission
+}
+
+type datasourceJSONData struct {
+	// Source is storage (files uploaded to the datasource) or directory (files of a local directory)
+	Source string `json:"source"`
+	// Directory is read when the source is directory. It must be in the allowed directories of the server settings.
+	Directory   string       `json:"directory"`
+	Permissions []permission `json:"permissions"`
+}
+
+func newInstanceSettings(storageProvider storageProvider, allowedDirectories []string) datasource.InstanceFactoryFunc {
+	return func(ctx context.Context, settings backend.DataSourceInstanceSettings) (instancemgmt.Instance, error) {
+		var jsonData datasourceJSONData
+		if len(settings.JSONData) > 0 {
+			if err := json.Unmarshal(settings.JSONData, &jsonData); err != nil {
+				return nil, fmt.Errorf("error reading settings: %w", err)
+			}
+		}
+
+		for _, p := range jsonData.Permissions {
+			if err := p.validate(); err != nil {
+				return nil, backend.DownstreamError(fmt.Errorf("error reading settings: %w", err))
+			}
+		}
+
+		var source fileSource
+		switch jsonData.Source {
+		case "", sourceStorage:
+			if storageProvider == nil {
+				return nil, backend.DownstreamError(errors.New("error reading settings: the storage source requires the grafana database"))
+			}
+			source = newStorageSource(storageProvider, settings.UID)
+		case sourceDirectory:
+			var err error
+			source, err = newDirectorySource(jsonData.Directory, allowedDirectories)
+			if err != nil {
+				return nil, backend.DownstreamError(fmt.Errorf("error reading settings: %w", err))
+			}
+		default:
+			return nil, backend.DownstreamError(fmt.Errorf("error reading settings: unsupported source %q", jsonData.Source))
+		}
+
+		return &datasourceInfo{
+			Source:      source,
+			Permissions: jsonData.Permissions,
+		}, nil
+	}
+}
+
+func (s *Service) getDSInfo(ctx context.Context, pluginCtx backend.PluginContext) (*datasourceInfo, error) {
+	i, err := s.im.Get(ctx, pluginCtx)
+	if err != nil {
+		return nil, err
+	}
+
+	instance, ok := i.(*datasourceInfo)
+	if !ok {
+		return nil, errors.New("failed to cast datasource info")
+	}
+
+	return instance, nil
+}
+
+func (s *Service) QueryData(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+	dsInfo, err := s.getDSInfo(ctx, req.PluginContext)
+	if err != nil {
+		return nil, err
+	}
+
+	response := backend.NewQueryDataResponse()
+	for _, q := range req.Queries {
+		frame, err := s.query(ctx, dsInfo, req.PluginContext, q)
+		if err != nil {
+			response.Responses[q.RefID] = backend.ErrorResponseWithErrorSource(err)
+			continue
+		}
+		response.Responses[q.RefID] = backend.DataResponse{Frames: data.Frames{frame}}
+	}
+
+	return response, nil
+}
+
+func (s *Service) query(ctx context.Context, dsInfo *datasourceInfo, pluginCtx backend.PluginContext, q backend.DataQuery) (*data.Frame, error) {
+	query, err := parseQuery(q)
+	if err != nil {
+		return nil, err
+	}
+
+	if !canRead(dsInfo.Permissions, requestRole(ctx, pluginCtx.User), query.Path) {
+		return nil, backend.DownstreamError(fmt.Errorf("%w: %s", errAccessDenied, query.Path))
+	}
+
+	content, err := dsInfo.Source.Read(ctx, pluginCtx.OrgID, query.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	frame, err := readFrame(ctx, query.Path, content)
+	if err != nil {
+		return nil, err
+	}
+	frame.RefID = q.RefID
+
+	return applyQuery(frame, query, q.TimeRange)
+}
+
+func (s *Service) CheckHealth(ctx context.Context, req *backend.CheckHealthRequest) (*backend.CheckHealthResult, error) {
+	dsInfo, err := s.getDSInfo(ctx, req.PluginContext)
+	if err != nil {
+		return &backend.CheckHealthResult{
+			Status:  backend.HealthStatusError,
+			Message: err.Error(),
+		}, nil
+	}
+
+	files, err := dsInfo.Source.List(ctx, req.PluginContext.OrgID)
+	if err != nil {
+		return &backend.CheckHealthResult{
+			Status:  backend.HealthStatusError,
+			Message: err.Error(),
+		}, nil
+	}
+
+	return &backend.CheckHealthResult{
+		Status:  backend.HealthStatusOk,
+		Message: fmt.Sprintf("Data source is working, %d files found", len(files)),
+	}, nil
+}
+
+func (s *Service) CallResource(ctx context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
+	return s.resourceHandler.CallResource(ctx, req, sender)
+}
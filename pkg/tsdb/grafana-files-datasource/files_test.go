@@ -0,0 +1,245 @@
+package files
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gocloud.dev/blob/memblob"
+
+	"github.com/grafana/grafana/pkg/apimachinery/identity"
+	"github.com/grafana/grafana/pkg/infra/filestorage"
+	"github.com/grafana/grafana/pkg/infra/log"
+)
+
+const sloTargets = `time,service,target
+2024-01-01T00:00:00Z,api,99.9
+2024-01-01T02:00:00Z,api,99.5
+2024-01-01T01:00:00Z,web,99
+`
+
+// newTestService returns a service whose storage source is backed by an in memory bucket
+func newTestService(t *testing.T, allowedDirectories ...string) *Service {
+	t.Helper()
+
+	bucket := memblob.OpenBucket(nil)
+	t.Cleanup(func() { _ = bucket.Close() })
+	storage := filestorage.NewCdkBlobStorage(log.NewNopLogger(), bucket, "", nil)
+
+	return newService(func(orgID int64, uid string) filestorage.FileStorage {
+		return storage
+	}, allowedDirectories)
+}
+
+func pluginContext(jsonData string, role string) backend.PluginContext {
+	pluginCtx := backend.PluginContext{
+		OrgID: 1,
+		DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{
+			ID:       1,
+			UID:      "files",
+			JSONData: []byte(jsonData),
+		},
+	}
+	if role != "" {
+		pluginCtx.User = &backend.User{Login: "user", Role: role}
+	}
+	return pluginCtx
+}
+
+func callResource(t *testing.T, s *Service, pluginCtx backend.PluginContext, method, path string, body []byte) *backend.CallResourceResponse {
+	t.Helper()
+
+	var res *backend.CallResourceResponse
+	err := s.CallResource(context.Background(), &backend.CallResourceRequest{
+		PluginContext: pluginCtx,
+		Method:        method,
+		Path:          path,
+		URL:           path,
+		Body:          body,
+	}, backend.CallResourceResponseSenderFunc(func(r *backend.CallResourceResponse) error {
+		res = r
+		return nil
+	}))
+	require.NoError(t, err)
+	require.NotNil(t, res)
+	return res
+}
+
+func queryFile(t *testing.T, s *Service, pluginCtx backend.PluginContext, model string) backend.DataResponse {
+	t.Helper()
+
+	res, err := s.QueryData(context.Background(), &backend.QueryDataRequest{
+		PluginContext: pluginCtx,
+		Queries: []backend.DataQuery{{
+			RefID: "A",
+			JSON:  []byte(model),
+			TimeRange: backend.TimeRange{
+				From: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+				To:   time.Date(2024, 1, 1, 1, 30, 0, 0, time.UTC),
+			},
+		}},
+	})
+	require.NoError(t, err)
+	return res.Responses["A"]
+}
+
+func TestStorageSource(t *testing.T) {
+	s := newTestService(t)
+	admin := pluginContext(`{"permissions": [{"pattern": "private/*", "role": "Editor"}]}`, "Admin")
+	viewer := pluginContext(`{"permissions": [{"pattern": "private/*", "role": "Editor"}]}`, "Viewer")
+
+	t.Run("only admins can upload files", func(t *testing.T) {
+		res := callResource(t, s, viewer, http.MethodPut, "files/slo.csv", []byte(sloTargets))
+		assert.Equal(t, http.StatusForbidden, res.Status)
+
+		res = callResource(t, s, admin, http.MethodPut, "files/slo.csv", []byte(sloTargets))
+		require.Equal(t, http.StatusOK, res.Status, string(res.Body))
+		res = callResource(t, s, admin, http.MethodPut, "files/private/slo.csv", []byte(sloTargets))
+		require.Equal(t, http.StatusOK, res.Status, string(res.Body))
+	})
+
+	t.Run("should reject unsupported files", func(t *testing.T) {
+		res := callResource(t, s, admin, http.MethodPut, "files/slo.xlsx", []byte(sloTargets))
+		assert.Equal(t, http.StatusBadRequest, res.Status)
+		res = callResource(t, s, admin, http.MethodPut, "files/slo|1.csv", []byte(sloTargets))
+		assert.Equal(t, http.StatusBadRequest, res.Status)
+	})
+
+	t.Run("should list the files the user can read", func(t *testing.T) {
+		res := callResource(t, s, viewer, http.MethodGet, "files", nil)
+		require.Equal(t, http.StatusOK, res.Status)
+		var files []fileInfo
+		require.NoError(t, json.Unmarshal(res.Body, &files))
+		require.Len(t, files, 1)
+		assert.Equal(t, "slo.csv", files[0].Path)
+		assert.Equal(t, formatCSV, files[0].Format)
+
+		res = callResource(t, s, admin, http.MethodGet, "files", nil)
+		require.NoError(t, json.Unmarshal(res.Body, &files))
+		require.Len(t, files, 2)
+	})
+
+	t.Run("should query the rows of the time range", func(t *testing.T) {
+		res := queryFile(t, s, viewer, `{"path": "slo.csv", "timeField": "time"}`)
+		require.NoError(t, res.Error)
+		require.Len(t, res.Frames, 1)
+
+		frame := res.Frames[0]
+		assert.Equal(t, "A", frame.RefID)
+		require.Equal(t, 2, frame.Rows())
+		assert.Equal(t, "api", *frame.Fields[1].At(0).(*string))
+		assert.Equal(t, "web", *frame.Fields[1].At(1).(*string))
+	})
+
+	t.Run("should deny files restricted to other roles", func(t *testing.T) {
+		res := queryFile(t, s, viewer, `{"path": "private/slo.csv"}`)
+		require.ErrorIs(t, res.Error, errAccessDenied)
+
+		res = queryFile(t, s, admin, `{"path": "private/slo.csv"}`)
+		require.NoError(t, res.Error)
+	})
+
+	t.Run("should deny restricted files to requests without user nor identity", func(t *testing.T) {
+		noUser := pluginContext(`{"permissions": [{"pattern": "private/*", "role": "Editor"}]}`, "")
+		res := queryFile(t, s, noUser, `{"path": "private/slo.csv"}`)
+		require.ErrorIs(t, res.Error, errAccessDenied)
+
+		res = queryFile(t, s, noUser, `{"path": "slo.csv"}`)
+		require.NoError(t, res.Error)
+
+		list := callResource(t, s, noUser, http.MethodGet, "files", nil)
+		require.Equal(t, http.StatusOK, list.Status)
+		var files []fileInfo
+		require.NoError(t, json.Unmarshal(list.Body, &files))
+		require.Len(t, files, 1)
+	})
+
+	t.Run("should use the identity of requests without user", func(t *testing.T) {
+		noUser := pluginContext(`{"permissions": [{"pattern": "private/*", "role": "Editor"}]}`, "")
+		for name, ctx := range map[string]context.Context{
+			"service": identity.WithServiceIdentityContext(context.Background(), 1),
+			"rule":    identity.WithRequester(context.Background(), &identity.StaticRequester{OrgID: 1, OrgRole: identity.RoleEditor}),
+		} {
+			res, err := s.QueryData(ctx, &backend.QueryDataRequest{
+				PluginContext: noUser,
+				Queries:       []backend.DataQuery{{RefID: "A", JSON: []byte(`{"path": "private/slo.csv"}`)}},
+			})
+			require.NoError(t, err, name)
+			require.NoError(t, res.Responses["A"].Error, name)
+		}
+
+		viewerCtx := identity.WithRequester(context.Background(), &identity.StaticRequester{OrgID: 1, OrgRole: identity.RoleViewer})
+		res, err := s.QueryData(viewerCtx, &backend.QueryDataRequest{
+			PluginContext: noUser,
+			Queries:       []backend.DataQuery{{RefID: "A", JSON: []byte(`{"path": "private/slo.csv"}`)}},
+		})
+		require.NoError(t, err)
+		require.ErrorIs(t, res.Responses["A"].Error, errAccessDenied)
+	})
+
+	t.Run("should delete files", func(t *testing.T) {
+		res := callResource(t, s, admin, http.MethodDelete, "files/slo.csv", nil)
+		require.Equal(t, http.StatusOK, res.Status)
+		res = callResource(t, s, admin, http.MethodDelete, "files/slo.csv", nil)
+		assert.Equal(t, http.StatusNotFound, res.Status)
+
+		resp := queryFile(t, s, viewer, `{"path": "slo.csv"}`)
+		require.ErrorIs(t, resp.Error, errFileNotFound)
+	})
+}
+
+func TestDirectorySource(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "reference"), 0o750))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "reference", "inventory.jsonl"), []byte(`{"host": "a", "cores": 4}
+{"host": "b", "cores": 8}
+`), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("notes"), 0o600))
+
+	outside := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(outside, "secret.csv"), []byte("a\n1\n"), 0o600))
+	require.NoError(t, os.Symlink(filepath.Join(outside, "secret.csv"), filepath.Join(dir, "secret.csv")))
+
+	s := newTestService(t, filepath.Dir(dir))
+	pluginCtx := pluginContext(`{"source": "directory", "directory": "`+dir+`"}`, "Admin")
+
+	t.Run("should list the supported files", func(t *testing.T) {
+		res := callResource(t, s, pluginCtx, http.MethodGet, "files", nil)
+		require.Equal(t, http.StatusOK, res.Status)
+		var files []fileInfo
+		require.NoError(t, json.Unmarshal(res.Body, &files))
+		require.Len(t, files, 2)
+		assert.Equal(t, "reference/inventory.jsonl", files[0].Path)
+	})
+
+	t.Run("should query the files", func(t *testing.T) {
+		res := queryFile(t, s, pluginCtx, `{"path": "reference/inventory.jsonl", "filters": [{"field": "cores", "operator": ">", "value": "4"}]}`)
+		require.NoError(t, res.Error)
+		require.Equal(t, 1, res.Frames[0].Rows())
+	})
+
+	t.Run("should not follow links outside of the directory", func(t *testing.T) {
+		res := queryFile(t, s, pluginCtx, `{"path": "secret.csv"}`)
+		require.Error(t, res.Error)
+	})
+
+	t.Run("should be read only", func(t *testing.T) {
+		res := callResource(t, s, pluginCtx, http.MethodPut, "files/slo.csv", []byte(sloTargets))
+		assert.Equal(t, http.StatusMethodNotAllowed, res.Status)
+	})
+
+	t.Run("should only allow the configured directories", func(t *testing.T) {
+		s := newTestService(t, outside)
+		res, err := s.CheckHealth(context.Background(), &backend.CheckHealthRequest{PluginContext: pluginCtx})
+		require.NoError(t, err)
+		assert.Equal(t, backend.HealthStatusError, res.Status)
+		assert.Contains(t, res.Message, "not in the allowed directories")
+	})
+}
@@ -0,0 +1,247 @@
+package files
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/apache/arrow-go/v18/parquet"
+	"github.com/apache/arrow-go/v18/parquet/pqarrow"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	testdatasource "github.com/grafana/grafana/pkg/tsdb/grafana-testdata-datasource"
+)
+
+const (
+	formatCSV       = "csv"
+	formatJSONLines = "jsonl"
+	formatParquet   = "parquet"
+)
+
+// formatOf returns the format of the file from its extension.
+func formatOf(filePath string) (string, error) {
+	switch strings.ToLower(path.Ext(filePath)) {
+	case ".csv":
+		return formatCSV, nil
+	case ".jsonl", ".ndjson":
+		return formatJSONLines, nil
+	case ".parquet":
+		return formatParquet, nil
+	default:
+		return "", fmt.Errorf("unsupported file type %q, expected csv, jsonl, ndjson or parquet", path.Ext(filePath))
+	}
+}
+
+// readFrame converts the content of the file to a frame. Files are written by users, so parsing errors are downstream errors.
+func readFrame(ctx context.Context, filePath string, content []byte) (*data.Frame, error) {
+	format, err := formatOf(filePath)
+	if err != nil {
+		return nil, backend.DownstreamError(err)
+	}
+
+	name := path.Base(filePath)
+	var frame *data.Frame
+	switch format {
+	case formatCSV:
+		frame, err = testdatasource.LoadCsvContent(bytes.NewReader(content), name)
+	case formatJSONLines:
+		frame, err = readJSONLines(name, content)
+	case formatParquet:
+		frame, err = readParquet(ctx, name, content)
+	}
+	if err != nil {
+		return nil, backend.DownstreamError(fmt.Errorf("failed to read %s: %w", filePath, err))
+	}
+	return frame, nil
+}
+
+// readJSONLines reads a file with a JSON object per line. There is a field for each key of the objects,
+// sorted by name. Fields with values of different types, or with arrays and objects, are JSON fields.
+func readJSONLines(name string, content []byte) (*data.Frame, error) {
+	rows := []map[string]any{}
+	dec := json.NewDecoder(bytes.NewReader(content))
+	for {
+		var row map[string]any
+		if err := dec.Decode(&row); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("line %d: %w", len(rows)+1, err)
+		}
+		rows = append(rows, row)
+	}
+
+	keys := map[string]struct{}{}
+	for _, row := range rows {
+		for key := range row {
+			keys[key] = struct{}{}
+		}
+	}
+	names := make([]string, 0, len(keys))
+	for key := range keys {
+		names = append(names, key)
+	}
+	sort.Strings(names)
+
+	frame := data.NewFrame(name)
+	for _, key := range names {
+		values := make([]any, len(rows))
+		for i, row := range rows {
+			values[i] = row[key]
+		}
+		frame.Fields = append(frame.Fields, jsonValuesToField(key, values))
+	}
+	return frame, nil
+}
+
+func jsonValuesToField(name string, values []any) *data.Field {
+	var fieldType data.FieldType
+	for _, value := range values {
+		var t data.FieldType
+		switch value.(type) {
+		case nil:
+			continue
+		case float64:
+			t = data.FieldTypeNullableFloat64
+		case bool:
+			t = data.FieldTypeNullableBool
+		case string:
+			t = data.FieldTypeNullableString
+		default:
+			t = data.FieldTypeNullableJSON
+		}
+		if fieldType != data.FieldTypeUnknown && fieldType != t {
+			fieldType = data.FieldTypeNullableJSON
+			break
+		}
+		fieldType = t
+	}
+	if fieldType == data.FieldTypeUnknown {
+		fieldType = data.FieldTypeNullableString
+	}
+
+	field := data.NewFieldFromFieldType(fieldType, len(values))
+	field.Name = name
+	for i, value := range values {
+		if value == nil {
+			continue
+		}
+		if fieldType == data.FieldTypeNullableJSON {
+			b, _ := json.Marshal(value)
+			field.SetConcrete(i, json.RawMessage(b))
+			continue
+		}
+		field.SetConcrete(i, value)
+	}
+	return field
+}
+
+// readParquet reads all the row groups of a parquet file. Columns of unsupported types are skipped with a notice.
+func readParquet(ctx context.Context, name string, content []byte) (*data.Frame, error) {
+	mem := memory.DefaultAllocator
+	table, err := pqarrow.ReadTable(ctx, bytes.NewReader(content), parquet.NewReaderProperties(mem), pqarrow.ArrowReadProperties{}, mem)
+	if err != nil {
+		return nil, err
+	}
+	defer table.Release()
+
+	frame := data.NewFrame(name)
+	skipped := []string{}
+	for i := 0; i < int(table.NumCols()); i++ {
+		column := table.Column(i)
+		field := arrowColumnToField(column)
+		if field == nil {
+			skipped = append(skipped, fmt.Sprintf("%s (%s)", column.Name(), column.DataType()))
+			continue
+		}
+		frame.Fields = append(frame.Fields, field)
+	}
+
+	if len(skipped) > 0 {
+		frame.AppendNotices(data.Notice{
+			Severity: data.NoticeSeverityWarning,
+			Text:     "Columns of unsupported types were skipped: " + strings.Join(skipped, ", "),
+		})
+	}
+	return frame, nil
+}
+
+// arrowColumnToField converts the column to a nullable field, or returns nil if its type is not supported.
+func arrowColumnToField(column *arrow.Column) *data.Field {
+	name := column.Name()
+	chunks := column.Data().Chunks()
+
+	switch dt := column.DataType().(type) {
+	case *arrow.BooleanType:
+		return data.NewField(name, nil, chunkValues(chunks, func(a *array.Boolean, i int) bool { return a.Value(i) }))
+	case *arrow.Int8Type:
+		return data.NewField(name, nil, chunkValues(chunks, func(a *array.Int8, i int) int8 { return a.Value(i) }))
+	case *arrow.Int16Type:
+		return data.NewField(name, nil, chunkValues(chunks, func(a *array.Int16, i int) int16 { return a.Value(i) }))
+	case *arrow.Int32Type:
+		return data.NewField(name, nil, chunkValues(chunks, func(a *array.Int32, i int) int32 { return a.Value(i) }))
+	case *arrow.Int64Type:
+		return data.NewField(name, nil, chunkValues(chunks, func(a *array.Int64, i int) int64 { return a.Value(i) }))
+	case *arrow.Uint8Type:
+		return data.NewField(name, nil, chunkValues(chunks, func(a *array.Uint8, i int) uint8 { return a.Value(i) }))
+	case *arrow.Uint16Type:
+		return data.NewField(name, nil, chunkValues(chunks, func(a *array.Uint16, i int) uint16 { return a.Value(i) }))
+	case *arrow.Uint32Type:
+		return data.NewField(name, nil, chunkValues(chunks, func(a *array.Uint32, i int) uint32 { return a.Value(i) }))
+	case *arrow.Uint64Type:
+		return data.NewField(name, nil, chunkValues(chunks, func(a *array.Uint64, i int) uint64 { return a.Value(i) }))
+	case *arrow.Float32Type:
+		return data.NewField(name, nil, chunkValues(chunks, func(a *array.Float32, i int) float32 { return a.Value(i) }))
+	case *arrow.Float64Type:
+		return data.NewField(name, nil, chunkValues(chunks, func(a *array.Float64, i int) float64 { return a.Value(i) }))
+	case *arrow.StringType:
+		return data.NewField(name, nil, chunkValues(chunks, func(a *array.String, i int) string { return a.Value(i) }))
+	case *arrow.LargeStringType:
+		return data.NewField(name, nil, chunkValues(chunks, func(a *array.LargeString, i int) string { return a.Value(i) }))
+	case *arrow.TimestampType:
+		return data.NewField(name, nil, chunkValues(chunks, func(a *array.Timestamp, i int) time.Time {
+			return a.Value(i).ToTime(dt.Unit).UTC()
+		}))
+	case *arrow.Date32Type:
+		return data.NewField(name, nil, chunkValues(chunks, func(a *array.Date32, i int) time.Time { return a.Value(i).ToTime() }))
+	case *arrow.Date64Type:
+		return data.NewField(name, nil, chunkValues(chunks, func(a *array.Date64, i int) time.Time { return a.Value(i).ToTime() }))
+	case *arrow.DictionaryType:
+		if dt.ValueType.ID() != arrow.STRING {
+			return nil
+		}
+		return data.NewField(name, nil, chunkValues(chunks, func(a *array.Dictionary, i int) string {
+			return a.Dictionary().(*array.String).Value(a.GetValueIndex(i))
+		}))
+	default:
+		return nil
+	}
+}
+
+// chunkValues returns the values of all the chunks of a column, with nil for null values.
+func chunkValues[A arrow.Array, T any](chunks []arrow.Array, value func(a A, i int) T) []*T {
+	values := []*T{}
+	for _, chunk := range chunks {
+		a := chunk.(A)
+		for i := 0; i < a.Len(); i++ {
+			if a.IsNull(i) {
+				values = append(values, nil)
+				continue
+			}
+			v := value(a, i)
+			values = append(values, &v)
+		}
+	}
+	return values
+}
@@ -0,0 +1,95 @@
+package files
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/apache/arrow-go/v18/parquet/pqarrow"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadJSONLines(t *testing.T) {
+	frame, err := readFrame(context.Background(), "inventory.ndjson", []byte(`{"host": "a", "cores": 4, "tags": ["db"], "mixed": 1}
+{"host": "b", "active": true, "mixed": "one"}
+`))
+	require.NoError(t, err)
+	require.Equal(t, 2, frame.Rows())
+
+	names := []string{}
+	for _, f := range frame.Fields {
+		names = append(names, f.Name)
+	}
+	assert.Equal(t, []string{"active", "cores", "host", "mixed", "tags"}, names)
+	assert.Equal(t, data.FieldTypeNullableBool, frame.Fields[0].Type())
+	assert.Equal(t, data.FieldTypeNullableFloat64, frame.Fields[1].Type())
+	assert.Nil(t, frame.Fields[1].At(1))
+	assert.Equal(t, data.FieldTypeNullableString, frame.Fields[2].Type())
+	assert.Equal(t, data.FieldTypeNullableJSON, frame.Fields[3].Type())
+	assert.Equal(t, json.RawMessage(`"one"`), *frame.Fields[3].At(1).(*json.RawMessage))
+	assert.Equal(t, data.FieldTypeNullableJSON, frame.Fields[4].Type())
+
+	_, err = readFrame(context.Background(), "invalid.jsonl", []byte("{\"a\": 1}\nnot json"))
+	require.Error(t, err)
+	assert.True(t, backend.IsDownstreamError(err))
+}
+
+func TestReadParquet(t *testing.T) {
+	mem := memory.NewGoAllocator()
+	schema := arrow.NewSchema([]arrow.Field{
+		{Name: "time", Type: &arrow.TimestampType{Unit: arrow.Millisecond}},
+		{Name: "service", Type: arrow.BinaryTypes.String},
+		{Name: "target", Type: arrow.PrimitiveTypes.Float64, Nullable: true},
+		{Name: "payload", Type: arrow.BinaryTypes.Binary},
+	}, nil)
+
+	builder := array.NewRecordBuilder(mem, schema)
+	defer builder.Release()
+	builder.Field(0).(*array.TimestampBuilder).AppendValues([]arrow.Timestamp{1704067200000, 1704070800000}, nil)
+	builder.Field(1).(*array.StringBuilder).AppendValues([]string{"api", "web"}, nil)
+	builder.Field(2).(*array.Float64Builder).AppendValues([]float64{99.9, 0}, []bool{true, false})
+	builder.Field(3).(*array.BinaryBuilder).AppendValues([][]byte{{1}, {2}}, nil)
+	record := builder.NewRecord()
+	defer record.Release()
+
+	table := array.NewTableFromRecords(schema, []arrow.Record{record})
+	defer table.Release()
+	var buf bytes.Buffer
+	require.NoError(t, pqarrow.WriteTable(table, &buf, 1024, nil, pqarrow.DefaultWriterProps()))
+
+	frame, err := readFrame(context.Background(), "slo.parquet", buf.Bytes())
+	require.NoError(t, err)
+	require.Len(t, frame.Fields, 3)
+	require.Equal(t, 2, frame.Rows())
+	assert.Equal(t, time.Date(2024, 1, 1, 1, 0, 0, 0, time.UTC), *frame.Fields[0].At(1).(*time.Time))
+	assert.Equal(t, "web", *frame.Fields[1].At(1).(*string))
+	assert.Equal(t, 99.9, *frame.Fields[2].At(0).(*float64))
+	assert.Nil(t, frame.Fields[2].At(1))
+	require.Len(t, frame.Meta.Notices, 1)
+	assert.Contains(t, frame.Meta.Notices[0].Text, "payload")
+}
+
+func TestFormatOf(t *testing.T) {
+	for path, expected := range map[string]string{
+		"a.csv":         formatCSV,
+		"dir/a.CSV":     formatCSV,
+		"a.jsonl":       formatJSONLines,
+		"a.ndjson":      formatJSONLines,
+		"a/b/c.parquet": formatParquet,
+	} {
+		format, err := formatOf(path)
+		require.NoError(t, err)
+		assert.Equal(t, expected, format, path)
+	}
+
+	_, err := formatOf("a.json")
+	require.Error(t, err)
+}
@@ -0,0 +1,63 @@
+package files
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+
+	"github.com/grafana/grafana/pkg/apimachinery/identity"
+	"github.com/grafana/grafana/pkg/services/org"
+)
+
+var errAccessDenied = errors.New("access denied")
+
+// permission restricts the files matching the pattern to the users with the given org role or a higher one.
+// The first permission matching a file applies, files matching no permission can be read by all users.
+type permission struct {
+	// Pattern matches the file paths, with the syntax of path.Match. For example: slo/*.csv
+	Pattern string `json:"pattern"`
+	// Role is the minimum org role required to read the files: Viewer, Editor or Admin
+	Role org.RoleType `json:"role"`
+}
+
+func (p permission) validate() error {
+	if _, err := path.Match(p.Pattern, ""); err != nil {
+		return fmt.Errorf("invalid permission pattern %q: %w", p.Pattern, err)
+	}
+	if !p.Role.IsValid() {
+		return fmt.Errorf("invalid permission role %q", p.Role)
+	}
+	return nil
+}
+
+// requestRole returns the org role of the request. Requests made by the server without user, like
+// alert rule evaluations, have the role of the identity of the context, the rule or the service identity.
+// The role is empty if there is neither.
+func requestRole(ctx context.Context, user *backend.User) org.RoleType {
+	if user != nil {
+		return org.RoleType(user.Role)
+	}
+	if requester, err := identity.GetRequester(ctx); err == nil {
+		return requester.GetOrgRole()
+	}
+	return ""
+}
+
+// canRead checks if the role can read the file. Requests without role can only read the files
+// matching no permission.
+func canRead(permissions []permission, role org.RoleType, filePath string) bool {
+	for _, p := range permissions {
+		if ok, _ := path.Match(p.Pattern, filePath); ok {
+			return role.IsValid() && role.Includes(p.Role)
+		}
+	}
+	return true
+}
+
+// canWrite checks if the role can upload and delete files, which is restricted to org admins.
+func canWrite(role org.RoleType) bool {
+	return role.IsValid() && role.Includes(org.RoleAdmin)
+}
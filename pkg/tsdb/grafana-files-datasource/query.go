@@ -0,0 +1,254 @@
+package files
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+type filter struct {
+	Field string `json:"field"`
+	// Operator is one of =, !=, >, >=, <, <=, =~ or !~
+	Operator string `json:"operator"`
+	Value    string `json:"value"`
+}
+
+type fileQuery struct {
+	Path string `json:"path"`
+	// TimeField restricts the rows to the time range of the query, sorted by time. Rows aren't filtered by time when empty.
+	// Strings are parsed as RFC 3339 and numbers as epoch milliseconds when the field is not a time field.
+	TimeField string   `json:"timeField"`
+	Filters   []filter `json:"filters"`
+}
+
+func parseQuery(q backend.DataQuery) (*fileQuery, error) {
+	var query fileQuery
+	if err := json.Unmarshal(q.JSON, &query); err != nil {
+		return nil, backend.DownstreamError(fmt.Errorf("error while parsing the query json. %w", err))
+	}
+	if query.Path == "" {
+		return nil, backend.DownstreamError(fmt.Errorf("query path is empty"))
+	}
+	return &query, nil
+}
+
+// rowMatcher returns if a row of the frame is kept
+type rowMatcher func(row int) bool
+
+// applyQuery filters the rows of the frame by time range and by the filters of the query.
+func applyQuery(frame *data.Frame, query *fileQuery, timeRange backend.TimeRange) (*data.Frame, error) {
+	matchers := []rowMatcher{}
+
+	var timeField *data.Field
+	if query.TimeField != "" {
+		var err error
+		if timeField, err = toTimeField(frame, query.TimeField); err != nil {
+			return nil, err
+		}
+		matchers = append(matchers, func(row int) bool {
+			t, ok := timeField.ConcreteAt(row)
+			return ok && !t.(time.Time).Before(timeRange.From) && !t.(time.Time).After(timeRange.To)
+		})
+	}
+
+	for _, f := range query.Filters {
+		matcher, err := newFilterMatcher(frame, f)
+		if err != nil {
+			return nil, err
+		}
+		matchers = append(matchers, matcher)
+	}
+
+	rows := []int{}
+	for row := 0; row < frame.Rows(); row++ {
+		keep := true
+		for _, matcher := range matchers {
+			if !matcher(row) {
+				keep = false
+				break
+			}
+		}
+		if keep {
+			rows = append(rows, row)
+		}
+	}
+
+	if timeField != nil {
+		sort.SliceStable(rows, func(i, j int) bool {
+			a, _ := timeField.ConcreteAt(rows[i])
+			b, _ := timeField.ConcreteAt(rows[j])
+			return a.(time.Time).Before(b.(time.Time))
+		})
+	}
+
+	filtered := frame.EmptyCopy()
+	filtered.Meta = frame.Meta
+	for _, row := range rows {
+		filtered.AppendRow(frame.RowCopy(row)...)
+	}
+	return filtered, nil
+}
+
+// toTimeField converts the field to a nullable time field in place when needed and returns it.
+func toTimeField(frame *data.Frame, name string) (*data.Field, error) {
+	field, idx := frame.FieldByName(name)
+	if idx < 0 {
+		return nil, backend.DownstreamError(fmt.Errorf("time field %q not found", name))
+	}
+	if field.Type() == data.FieldTypeNullableTime {
+		return field, nil
+	}
+
+	converted := data.NewFieldFromFieldType(data.FieldTypeNullableTime, field.Len())
+	converted.Name = field.Name
+	converted.Labels = field.Labels
+	for i := 0; i < field.Len(); i++ {
+		value, ok := field.ConcreteAt(i)
+		if !ok {
+			continue
+		}
+		if t, ok := parseTime(value); ok {
+			converted.SetConcrete(i, t)
+		}
+	}
+	frame.Fields[idx] = converted
+	return converted, nil
+}
+
+func parseTime(value any) (time.Time, bool) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, true
+	case string:
+		if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
+			return t, true
+		}
+		if ms, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return time.UnixMilli(ms).UTC(), true
+		}
+		return time.Time{}, false
+	default:
+		f, err := strconv.ParseFloat(fmt.Sprint(v), 64)
+		if err != nil {
+			return time.Time{}, false
+		}
+		return time.UnixMilli(int64(f)).UTC(), true
+	}
+}
+
+func newFilterMatcher(frame *data.Frame, f filter) (rowMatcher, error) {
+	field, idx := frame.FieldByName(f.Field)
+	if idx < 0 {
+		return nil, backend.DownstreamError(fmt.Errorf("filter field %q not found", f.Field))
+	}
+
+	switch f.Operator {
+	case "=~", "!~":
+		re, err := regexp.Compile(f.Value)
+		if err != nil {
+			return nil, backend.DownstreamError(fmt.Errorf("invalid filter regular expression %q: %w", f.Value, err))
+		}
+		negate := f.Operator == "!~"
+		return func(row int) bool {
+			value, ok := field.ConcreteAt(row)
+			return ok && re.MatchString(valueString(value)) != negate
+		}, nil
+	case "=", "!=", ">", ">=", "<", "<=":
+	default:
+		return nil, backend.DownstreamError(fmt.Errorf("unsupported filter operator %q", f.Operator))
+	}
+
+	compare, err := newComparer(field, f.Value)
+	if err != nil {
+		return nil, err
+	}
+	return func(row int) bool {
+		value, ok := field.ConcreteAt(row)
+		if !ok {
+			// Null values only match "not equal"
+			return f.Operator == "!="
+		}
+		c := compare(value)
+		switch f.Operator {
+		case "=":
+			return c == 0
+		case "!=":
+			return c != 0
+		case ">":
+			return c > 0
+		case ">=":
+			return c >= 0
+		case "<":
+			return c < 0
+		default:
+			return c <= 0
+		}
+	}, nil
+}
+
+// newComparer returns a function comparing a value of the field to the filter value, according to the field type.
+func newComparer(field *data.Field, value string) (func(v any) int, error) {
+	fieldType := field.Type()
+	switch {
+	case fieldType.Time():
+		t, ok := parseTime(value)
+		if !ok {
+			return nil, backend.DownstreamError(fmt.Errorf("invalid time %q for filter field %q", value, field.Name))
+		}
+		return func(v any) int { return v.(time.Time).Compare(t) }, nil
+	case fieldType.Numeric():
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, backend.DownstreamError(fmt.Errorf("invalid number %q for filter field %q", value, field.Name))
+		}
+		return func(v any) int {
+			f, err := strconv.ParseFloat(fmt.Sprint(v), 64)
+			if err != nil {
+				return -1
+			}
+			switch {
+			case f < n:
+				return -1
+			case f > n:
+				return 1
+			default:
+				return 0
+			}
+		}, nil
+	case fieldType == data.FieldTypeBool || fieldType == data.FieldTypeNullableBool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, backend.DownstreamError(fmt.Errorf("invalid boolean %q for filter field %q", value, field.Name))
+		}
+		return func(v any) int {
+			switch {
+			case v.(bool) == b:
+				return 0
+			case b:
+				return -1
+			default:
+				return 1
+			}
+		}, nil
+	default:
+		return func(v any) int { return strings.Compare(valueString(v), value) }, nil
+	}
+}
+
+func valueString(v any) string {
+	switch value := v.(type) {
+	case string:
+		return value
+	case json.RawMessage:
+		return string(value)
+	default:
+		return fmt.Sprint(value)
+	}
+}
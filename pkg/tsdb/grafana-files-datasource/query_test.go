@@ -0,0 +1,77 @@
+package files
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testFrame() *data.Frame {
+	str := func(s string) *string { return &s }
+	num := func(f float64) *float64 { return &f }
+	return data.NewFrame("test",
+		data.NewField("time", nil, []*string{str("2024-01-01T00:30:00Z"), str("1704067200000"), nil, str("2024-01-02T00:00:00Z")}),
+		data.NewField("service", nil, []*string{str("api"), str("web"), str("api-gw"), str("db")}),
+		data.NewField("target", nil, []*float64{num(99.9), num(99), num(95), nil}),
+		data.NewField("critical", nil, []bool{true, false, true, true}),
+	)
+}
+
+func TestApplyQuery(t *testing.T) {
+	timeRange := backend.TimeRange{
+		From: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		To:   time.Date(2024, 1, 1, 1, 0, 0, 0, time.UTC),
+	}
+
+	t.Run("should keep the rows of the time range sorted by time", func(t *testing.T) {
+		frame, err := applyQuery(testFrame(), &fileQuery{TimeField: "time"}, timeRange)
+		require.NoError(t, err)
+		require.Equal(t, 2, frame.Rows())
+		assert.Equal(t, data.FieldTypeNullableTime, frame.Fields[0].Type())
+		assert.Equal(t, timeRange.From, *frame.Fields[0].At(0).(*time.Time))
+		assert.Equal(t, "web", *frame.Fields[1].At(0).(*string))
+	})
+
+	tests := []struct {
+		name     string
+		filters  []filter
+		expected []string
+	}{
+		{name: "equal string", filters: []filter{{Field: "service", Operator: "=", Value: "api"}}, expected: []string{"api"}},
+		{name: "regex", filters: []filter{{Field: "service", Operator: "=~", Value: "^api"}}, expected: []string{"api", "api-gw"}},
+		{name: "not regex", filters: []filter{{Field: "service", Operator: "!~", Value: "^api"}}, expected: []string{"web", "db"}},
+		{name: "number", filters: []filter{{Field: "target", Operator: ">=", Value: "99"}}, expected: []string{"api", "web"}},
+		{name: "null number", filters: []filter{{Field: "target", Operator: "!=", Value: "99"}}, expected: []string{"api", "api-gw", "db"}},
+		{name: "boolean", filters: []filter{{Field: "critical", Operator: "=", Value: "false"}}, expected: []string{"web"}},
+		{name: "all filters", filters: []filter{
+			{Field: "critical", Operator: "=", Value: "true"},
+			{Field: "target", Operator: "<", Value: "99"},
+		}, expected: []string{"api-gw"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			frame, err := applyQuery(testFrame(), &fileQuery{Filters: tt.filters}, backend.TimeRange{})
+			require.NoError(t, err)
+			actual := []string{}
+			for i := 0; i < frame.Rows(); i++ {
+				actual = append(actual, *frame.Fields[1].At(i).(*string))
+			}
+			assert.Equal(t, tt.expected, actual)
+		})
+	}
+
+	for _, f := range []filter{
+		{Field: "missing", Operator: "=", Value: "a"},
+		{Field: "service", Operator: "like", Value: "a"},
+		{Field: "target", Operator: ">", Value: "high"},
+		{Field: "service", Operator: "=~", Value: "("},
+	} {
+		_, err := applyQuery(testFrame(), &fileQuery{Filters: []filter{f}}, backend.TimeRange{})
+		require.Error(t, err)
+		assert.True(t, backend.IsDownstreamError(err))
+	}
+}
@@ -0,0 +1,109 @@
+package files
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/resource/httpadapter"
+)
+
+// handleList returns the files the user can read.
+func (s *Service) handleList(rw http.ResponseWriter, req *http.Request) {
+	pluginCtx := httpadapter.PluginConfigFromContext(req.Context())
+	dsInfo, err := s.getDSInfo(req.Context(), pluginCtx)
+	if err != nil {
+		writeError(rw, err)
+		return
+	}
+
+	files, err := dsInfo.Source.List(req.Context(), pluginCtx.OrgID)
+	if err != nil {
+		writeError(rw, err)
+		return
+	}
+
+	role := requestRole(req.Context(), pluginCtx.User)
+	readable := make([]fileInfo, 0, len(files))
+	for _, f := range files {
+		if canRead(dsInfo.Permissions, role, f.Path) {
+			readable = append(readable, f)
+		}
+	}
+	writeJSON(rw, http.StatusOK, readable)
+}
+
+// handleUpload creates or replaces a file with the request body.
+func (s *Service) handleUpload(rw http.ResponseWriter, req *http.Request) {
+	pluginCtx := httpadapter.PluginConfigFromContext(req.Context())
+	if !canWrite(requestRole(req.Context(), pluginCtx.User)) {
+		writeError(rw, errAccessDenied)
+		return
+	}
+	dsInfo, err := s.getDSInfo(req.Context(), pluginCtx)
+	if err != nil {
+		writeError(rw, err)
+		return
+	}
+
+	content, err := io.ReadAll(io.LimitReader(req.Body, maxFileSize+1))
+	if err != nil {
+		writeError(rw, err)
+		return
+	}
+
+	path := req.PathValue("path")
+	if err := dsInfo.Source.Write(req.Context(), pluginCtx.OrgID, path, content); err != nil {
+		writeError(rw, err)
+		return
+	}
+	writeJSON(rw, http.StatusOK, map[string]string{"message": "File uploaded", "path": path})
+}
+
+// handleDelete deletes a file.
+func (s *Service) handleDelete(rw http.ResponseWriter, req *http.Request) {
+	pluginCtx := httpadapter.PluginConfigFromContext(req.Context())
+	if !canWrite(requestRole(req.Context(), pluginCtx.User)) {
+		writeError(rw, errAccessDenied)
+		return
+	}
+	dsInfo, err := s.getDSInfo(req.Context(), pluginCtx)
+	if err != nil {
+		writeError(rw, err)
+		return
+	}
+
+	path := req.PathValue("path")
+	if err := dsInfo.Source.Delete(req.Context(), pluginCtx.OrgID, path); err != nil {
+		writeError(rw, err)
+		return
+	}
+	writeJSON(rw, http.StatusOK, map[string]string{"message": "File deleted", "path": path})
+}
+
+func writeError(rw http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, errAccessDenied):
+		status = http.StatusForbidden
+	case errors.Is(err, errFileNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, errReadOnly):
+		status = http.StatusMethodNotAllowed
+	case errors.Is(err, errFileTooLarge):
+		status = http.StatusRequestEntityTooLarge
+	case backend.IsDownstreamError(err):
+		status = http.StatusBadRequest
+	}
+	writeJSON(rw, status, map[string]string{"message": err.Error()})
+}
+
+func writeJSON(rw http.ResponseWriter, status int, body any) {
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(status)
+	if err := json.NewEncoder(rw).Encode(body); err != nil {
+		logger.Error("Failed to write resource response", "error", err)
+	}
+}
@@ -0,0 +1,244 @@
+package files
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+
+	"github.com/grafana/grafana/pkg/infra/filestorage"
+	"github.com/grafana/grafana/pkg/infra/log"
+)
+
+// maxFileSize is the maximum size of the files that can be uploaded or read. The uploaded files are
+// stored in a single row of the SQL database, which keeps them small.
+const maxFileSize = 10 << 20
+
+var (
+	errFileNotFound = errors.New("file not found")
+	errReadOnly     = errors.New("files can only be uploaded to datasources using the storage source")
+	errFileTooLarge = fmt.Errorf("file is larger than %d bytes", maxFileSize)
+)
+
+var storageLogger = log.New("tsdb.files.storage")
+
+type fileInfo struct {
+	Path     string    `json:"path"`
+	Format   string    `json:"format"`
+	Size     int64     `json:"size"`
+	Modified time.Time `json:"modified"`
+}
+
+// fileSource stores the files of a datasource. Paths are relative to the root of the datasource, without leading slash.
+type fileSource interface {
+	List(ctx context.Context, orgID int64) ([]fileInfo, error)
+	Read(ctx context.Context, orgID int64, path string) ([]byte, error)
+	Write(ctx context.Context, orgID int64, path string, content []byte) error
+	Delete(ctx context.Context, orgID int64, path string) error
+}
+
+// validatePath checks that the path is canonical and has a supported format, and returns it with a leading slash.
+func validatePath(path string) (string, error) {
+	if _, err := formatOf(path); err != nil {
+		return "", backend.DownstreamError(err)
+	}
+	rooted := filestorage.Delimiter + path
+	if err := filestorage.ValidatePath(rooted); err != nil {
+		return "", backend.DownstreamError(fmt.Errorf("invalid path %q: %w", path, err))
+	}
+	return rooted, nil
+}
+
+// storageProvider returns the file storage of a datasource
+type storageProvider func(orgID int64, uid string) filestorage.FileStorage
+
+// storagePathPrefix is the root folder of the files uploaded to a datasource:
+//
+//	/${orgId}/datasources/grafana-files-datasource/${uid}/
+func storagePathPrefix(orgID int64, uid string) string {
+	return filestorage.Join(fmt.Sprintf("%d", orgID), "datasources", PluginID, uid+filestorage.Delimiter)
+}
+
+// storageSource reads the files uploaded to the datasource in the grafana file storage
+type storageSource struct {
+	provider storageProvider
+	uid      string
+}
+
+func newStorageSource(provider storageProvider, uid string) *storageSource {
+	return &storageSource{provider: provider, uid: uid}
+}
+
+func (s *storageSource) List(ctx context.Context, orgID int64) ([]fileInfo, error) {
+	storage := s.provider(orgID, s.uid)
+
+	files := []fileInfo{}
+	paging := &filestorage.Paging{}
+	for {
+		res, err := storage.List(ctx, filestorage.Delimiter, paging, &filestorage.ListOptions{Recursive: true, WithFiles: true})
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range res.Files {
+			path := strings.TrimPrefix(f.FullPath, filestorage.Delimiter)
+			format, err := formatOf(path)
+			if err != nil {
+				continue
+			}
+			files = append(files, fileInfo{Path: path, Format: format, Size: f.Size, Modified: f.Modified})
+		}
+		if !res.HasMore {
+			return files, nil
+		}
+		paging.After = res.LastPath
+	}
+}
+
+func (s *storageSource) Read(ctx context.Context, orgID int64, path string) ([]byte, error) {
+	rooted, err := validatePath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	file, ok, err := s.provider(orgID, s.uid).Get(ctx, rooted, &filestorage.GetFileOptions{WithContents: true})
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, backend.DownstreamError(fmt.Errorf("%w: %s", errFileNotFound, path))
+	}
+	return file.Contents, nil
+}
+
+func (s *storageSource) Write(ctx context.Context, orgID int64, path string, content []byte) error {
+	rooted, err := validatePath(path)
+	if err != nil {
+		return err
+	}
+	if len(content) > maxFileSize {
+		return backend.DownstreamError(errFileTooLarge)
+	}
+
+	return s.provider(orgID, s.uid).Upsert(ctx, &filestorage.UpsertFileCommand{
+		Path:     rooted,
+		Contents: content,
+	})
+}
+
+func (s *storageSource) Delete(ctx context.Context, orgID int64, path string) error {
+	rooted, err := validatePath(path)
+	if err != nil {
+		return err
+	}
+
+	storage := s.provider(orgID, s.uid)
+	_, ok, err := storage.Get(ctx, rooted, &filestorage.GetFileOptions{WithContents: false})
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return backend.DownstreamError(fmt.Errorf("%w: %s", errFileNotFound, path))
+	}
+	return storage.Delete(ctx, rooted)
+}
+
+// directorySource reads the files of a local directory. It is read only, and the files can't be
+// read outside of the directory, even through symbolic links.
+type directorySource struct {
+	dir string
+}
+
+func newDirectorySource(dir string, allowedDirectories []string) (*directorySource, error) {
+	if !filepath.IsAbs(dir) {
+		return nil, fmt.Errorf("directory %q must be an absolute path", dir)
+	}
+	dir = filepath.Clean(dir)
+
+	for _, allowed := range allowedDirectories {
+		rel, err := filepath.Rel(allowed, dir)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return &directorySource{dir: dir}, nil
+		}
+	}
+	return nil, fmt.Errorf("directory %q is not in the allowed directories, see the %s setting of the [plugin.%s] section", dir, allowedDirectoriesSetting, PluginID)
+}
+
+func (s *directorySource) List(_ context.Context, _ int64) ([]fileInfo, error) {
+	root, err := os.OpenRoot(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = root.Close() }()
+
+	files := []fileInfo{}
+	err = fs.WalkDir(root.FS(), ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		format, err := formatOf(path)
+		if err != nil {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		files = append(files, fileInfo{Path: path, Format: format, Size: info.Size(), Modified: info.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files, nil
+}
+
+func (s *directorySource) Read(_ context.Context, _ int64, path string) ([]byte, error) {
+	if _, err := validatePath(path); err != nil {
+		return nil, err
+	}
+
+	root, err := os.OpenRoot(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = root.Close() }()
+
+	f, err := root.Open(filepath.FromSlash(path))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, backend.DownstreamError(fmt.Errorf("%w: %s", errFileNotFound, path))
+		}
+		return nil, backend.DownstreamError(err)
+	}
+	defer func() { _ = f.Close() }()
+
+	content, err := io.ReadAll(io.LimitReader(f, maxFileSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(content) > maxFileSize {
+		return nil, backend.DownstreamError(errFileTooLarge)
+	}
+	return content, nil
+}
+
+func (s *directorySource) Write(context.Context, int64, string, []byte) error {
+	return backend.DownstreamError(errReadOnly)
+}
+
+func (s *directorySource) Delete(context.Context, int64, string) error {
+	return backend.DownstreamError(errReadOnly)
+}
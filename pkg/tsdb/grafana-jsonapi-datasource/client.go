@@ -0,0 +1,325 @@
+package jsonapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+// maxResponseSize caps the size of a single decoded response page
+const maxResponseSize = 50 << 20
+
+type client struct {
+	httpClient *http.Client
+	baseURL    *url.URL
+}
+
+func newClient(dsInfo *datasourceInfo) *client {
+	return &client{
+		httpClient: dsInfo.HTTPClient,
+		baseURL:    dsInfo.URL,
+	}
+}
+
+// response is a decoded response page
+type response struct {
+	body   any
+	header http.Header
+}
+
+// fetchRows requests the query and its following pages, and returns the rows of all the pages.
+func (c *client) fetchRows(ctx context.Context, query *apiQuery, timeRange backend.TimeRange) ([]any, error) {
+	var rowsSelector selector
+	if query.RowsSelector != "" {
+		var err error
+		if rowsSelector, err = compileSelector(query.Language, query.RowsSelector); err != nil {
+			return nil, err
+		}
+	}
+	var cursorSelector selector
+	if query.Pagination.Mode == paginationCursor {
+		var err error
+		if cursorSelector, err = compileSelector(query.Language, query.Pagination.CursorSelector); err != nil {
+			return nil, err
+		}
+	}
+
+	u, err := c.buildURL(query, timeRange)
+	if err != nil {
+		return nil, err
+	}
+	headers, body, err := interpolateRequest(query, timeRange)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := []any{}
+	for page := 0; page < query.Pagination.MaxPages; page++ {
+		res, err := c.do(ctx, query.Method, u, headers, body)
+		if err != nil {
+			return nil, err
+		}
+
+		selected := res.body
+		if rowsSelector != nil {
+			selected = rowsSelector.Select(res.body)
+		}
+		switch v := selected.(type) {
+		case nil:
+		case []any:
+			rows = append(rows, v...)
+		default:
+			rows = append(rows, v)
+		}
+
+		switch query.Pagination.Mode {
+		case paginationLink:
+			u, err = c.nextLink(u, res.header)
+			if err != nil {
+				return nil, err
+			}
+		case paginationCursor:
+			u = nextCursor(u, query.Pagination.CursorParam, cursorSelector.Select(res.body))
+		default:
+			u = nil
+		}
+		if u == nil {
+			break
+		}
+	}
+
+	return rows, nil
+}
+
+// get requests the first page of the query and returns its decoded body.
+func (c *client) get(ctx context.Context, query *apiQuery, timeRange backend.TimeRange) (any, error) {
+	u, err := c.buildURL(query, timeRange)
+	if err != nil {
+		return nil, err
+	}
+	res, err := c.do(ctx, http.MethodGet, u, nil, "")
+	if err != nil {
+		return nil, err
+	}
+	return res.body, nil
+}
+
+// buildURL resolves the query path and parameters against the datasource URL. The path can't point
+// to another host or outside of the datasource URL path, so that the datasource credentials are only
+// sent to the configured API.
+func (c *client) buildURL(query *apiQuery, timeRange backend.TimeRange) (*url.URL, error) {
+	p, err := interpolate(query.Path, timeRange)
+	if err != nil {
+		return nil, err
+	}
+	ref, err := url.Parse(p)
+	if err != nil {
+		return nil, backend.DownstreamError(fmt.Errorf("invalid path %q: %w", p, err))
+	}
+	if ref.Scheme != "" || ref.Host != "" {
+		return nil, backend.DownstreamError(fmt.Errorf("path %q must be relative to the datasource url", p))
+	}
+
+	// JoinPath cleans the ../ elements, which must not lead out of the datasource URL path
+	u := c.baseURL.JoinPath(ref.Path)
+	base := strings.TrimSuffix("/"+strings.TrimPrefix(c.baseURL.Path, "/"), "/")
+	if joined := "/" + strings.TrimPrefix(u.Path, "/"); joined != base && !strings.HasPrefix(joined, base+"/") {
+		return nil, backend.DownstreamError(fmt.Errorf("path %q must be relative to the datasource url", p))
+	}
+	params := c.baseURL.Query()
+	for key, values := range ref.Query() {
+		for _, value := range values {
+			params.Add(key, value)
+		}
+	}
+	for _, param := range query.Params {
+		value, err := interpolate(param.Value, timeRange)
+		if err != nil {
+			return nil, err
+		}
+		params.Add(param.Key, value)
+	}
+	u.RawQuery = params.Encode()
+	return u, nil
+}
+
+func interpolateRequest(query *apiQuery, timeRange backend.TimeRange) (http.Header, string, error) {
+	headers := http.Header{}
+	for _, header := range query.Headers {
+		value, err := interpolate(header.Value, timeRange)
+		if err != nil {
+			return nil, "", err
+		}
+		headers.Add(header.Key, value)
+	}
+
+	body, err := interpolate(query.Body, timeRange)
+	if err != nil {
+		return nil, "", err
+	}
+	return headers, body, nil
+}
+
+func (c *client) do(ctx context.Context, method string, u *url.URL, headers http.Header, body string) (*response, error) {
+	logger := logger.FromContext(ctx)
+
+	var reqBody io.Reader
+	if body != "" {
+		reqBody = strings.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), reqBody)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range headers {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != "" && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		if backend.IsDownstreamHTTPError(err) {
+			return nil, backend.DownstreamError(err)
+		}
+		return nil, err
+	}
+	defer func() {
+		if err := res.Body.Close(); err != nil {
+			logger.Error("Failed to close response body", "error", err)
+		}
+	}()
+
+	if res.StatusCode/100 != 2 {
+		err := fmt.Errorf("request failed: %s", res.Status)
+		if backend.ErrorSourceFromHTTPStatus(res.StatusCode) == backend.ErrorSourceDownstream {
+			return nil, backend.DownstreamError(err)
+		}
+		return nil, err
+	}
+
+	var decoded any
+	decoder := json.NewDecoder(io.LimitReader(res.Body, maxResponseSize))
+	decoder.UseNumber()
+	if err := decoder.Decode(&decoded); err != nil {
+		if errors.Is(err, io.EOF) {
+			return &response{header: res.Header}, nil
+		}
+		return nil, backend.DownstreamError(fmt.Errorf("failed to decode response: %w", err))
+	}
+
+	return &response{body: normalizeNumbers(decoded), header: res.Header}, nil
+}
+
+// maxExactInteger is the largest integer that float64 represents exactly
+const maxExactInteger = 1 << 53
+
+// normalizeNumbers converts the decoded numbers to float64, which the selectors compare, unless
+// it would round them. The larger integers, like IDs, cursors or nanosecond timestamps, are kept
+// as json.Number so that they are returned as they are in the response.
+func normalizeNumbers(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, item := range v {
+			v[key] = normalizeNumbers(item)
+		}
+		return v
+	case []any:
+		for i, item := range v {
+			v[i] = normalizeNumbers(item)
+		}
+		return v
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			if i > maxExactInteger || i < -maxExactInteger {
+				return v
+			}
+			return float64(i)
+		}
+		if !strings.ContainsAny(v.String(), ".eE") {
+			// an integer out of the int64 range
+			return v
+		}
+		f, err := v.Float64()
+		if err != nil {
+			return v
+		}
+		return f
+	default:
+		return v
+	}
+}
+
+// nextLink returns the URL of the rel="next" Link header, or nil if there is none.
+func (c *client) nextLink(current *url.URL, header http.Header) (*url.URL, error) {
+	for _, value := range header.Values("Link") {
+		for _, link := range strings.Split(value, ",") {
+			parts := strings.Split(link, ";")
+			target := strings.TrimSpace(parts[0])
+			if !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+				continue
+			}
+			isNext := false
+			for _, param := range parts[1:] {
+				param = strings.ReplaceAll(strings.TrimSpace(param), " ", "")
+				if param == `rel="next"` || param == "rel=next" {
+					isNext = true
+				}
+			}
+			if !isNext {
+				continue
+			}
+
+			next, err := current.Parse(strings.Trim(target, "<>"))
+			if err != nil {
+				return nil, backend.DownstreamError(fmt.Errorf("invalid next link: %w", err))
+			}
+			if next.Scheme != c.baseURL.Scheme || next.Host != c.baseURL.Host {
+				return nil, backend.DownstreamError(fmt.Errorf("next link %q doesn't match the datasource url", next.Redacted()))
+			}
+			return next, nil
+		}
+	}
+	return nil, nil
+}
+
+// nextCursor returns the URL of the next page with the cursor parameter set, or nil if the cursor is empty.
+func nextCursor(current *url.URL, param string, cursor any) *url.URL {
+	var value string
+	switch v := cursor.(type) {
+	case nil:
+		return nil
+	case string:
+		value = v
+	case float64:
+		// 'f' keeps the large numeric cursors out of the exponent notation
+		value = strconv.FormatFloat(v, 'f', -1, 64)
+	case json.Number:
+		value = v.String()
+	case bool:
+		value = strconv.FormatBool(v)
+	default:
+		return nil
+	}
+	if value == "" {
+		return nil
+	}
+
+	next := *current
+	params := next.Query()
+	params.Set(param, value)
+	next.RawQuery = params.Encode()
+	return &next
+}
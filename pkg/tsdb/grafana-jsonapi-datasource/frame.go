@@ -0,0 +1,266 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+const (
+	fieldTypeString  = "string"
+	fieldTypeNumber  = "number"
+	fieldTypeBoolean = "boolean"
+	fieldTypeTime    = "time"
+	fieldTypeJSON    = "json"
+
+	timeFormatUnix   = "unix"
+	timeFormatUnixMs = "unixms"
+)
+
+// rowsToFrame extracts the query fields from each row. Values that can't be converted to the field type are null.
+func rowsToFrame(refID string, rows []any, query *apiQuery) (*data.Frame, error) {
+	definitions := query.Fields
+	if len(definitions) == 0 {
+		definitions = inferFields(query.Language, rows)
+	}
+
+	frame := data.NewFrame(refID)
+	for _, definition := range definitions {
+		name := definition.Name
+		if name == "" {
+			name = definition.Selector
+		}
+		sel, err := compileSelector(query.Language, definition.Selector)
+		if err != nil {
+			return nil, err
+		}
+
+		values := make([]any, len(rows))
+		for i, row := range rows {
+			values[i] = sel.Select(row)
+		}
+
+		fieldType := definition.Type
+		if fieldType == "" {
+			fieldType = inferType(values)
+		}
+		field, err := newField(name, fieldType, definition.TimeFormat, values)
+		if err != nil {
+			return nil, err
+		}
+		frame.Fields = append(frame.Fields, field)
+	}
+
+	return frame, nil
+}
+
+// inferFields returns a field for each top level key of the first row, sorted by name.
+// Rows that aren't objects are returned as a single value field.
+func inferFields(language string, rows []any) []fieldDefinition {
+	if len(rows) == 0 {
+		return nil
+	}
+	obj, ok := rows[0].(map[string]any)
+	if !ok {
+		root := "$"
+		if language == languageJMESPath {
+			root = "@"
+		}
+		return []fieldDefinition{{Name: "value", Selector: root}}
+	}
+
+	keys := make([]string, 0, len(obj))
+	for key := range obj {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	definitions := make([]fieldDefinition, 0, len(keys))
+	for _, key := range keys {
+		// Quoted identifiers are valid in both languages and allow keys with dots or spaces
+		definitions = append(definitions, fieldDefinition{Name: key, Selector: `"` + key + `"`})
+	}
+	return definitions
+}
+
+// inferType returns the type of the first non-null value.
+func inferType(values []any) string {
+	for _, value := range values {
+		switch value.(type) {
+		case nil:
+			continue
+		case string:
+			return fieldTypeString
+		case float64, json.Number:
+			return fieldTypeNumber
+		case bool:
+			return fieldTypeBoolean
+		default:
+			return fieldTypeJSON
+		}
+	}
+	return fieldTypeString
+}
+
+func newField(name, fieldType, timeFormat string, values []any) (*data.Field, error) {
+	switch fieldType {
+	case fieldTypeString:
+		converted := make([]*string, len(values))
+		for i, value := range values {
+			converted[i] = toString(value)
+		}
+		return data.NewField(name, nil, converted), nil
+	case fieldTypeNumber:
+		converted := make([]*float64, len(values))
+		for i, value := range values {
+			converted[i] = toNumber(value)
+		}
+		return data.NewField(name, nil, converted), nil
+	case fieldTypeBoolean:
+		converted := make([]*bool, len(values))
+		for i, value := range values {
+			converted[i] = toBool(value)
+		}
+		return data.NewField(name, nil, converted), nil
+	case fieldTypeTime:
+		converted := make([]*time.Time, len(values))
+		for i, value := range values {
+			converted[i] = toTime(value, timeFormat)
+		}
+		return data.NewField(name, nil, converted), nil
+	case fieldTypeJSON:
+		converted := make([]*json.RawMessage, len(values))
+		for i, value := range values {
+			converted[i] = toJSON(value)
+		}
+		return data.NewField(name, nil, converted), nil
+	default:
+		return nil, backend.DownstreamError(fmt.Errorf("unsupported type %q for field %q", fieldType, name))
+	}
+}
+
+func toString(value any) *string {
+	var s string
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case string:
+		s = v
+	case float64:
+		s = strconv.FormatFloat(v, 'f', -1, 64)
+	case json.Number:
+		s = v.String()
+	case bool:
+		s = strconv.FormatBool(v)
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil
+		}
+		s = string(b)
+	}
+	return &s
+}
+
+func toNumber(value any) *float64 {
+	switch v := value.(type) {
+	case float64:
+		return &v
+	case json.Number:
+		return toNumber(v.String())
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return nil
+		}
+		return &f
+	case bool:
+		f := 0.0
+		if v {
+			f = 1
+		}
+		return &f
+	default:
+		return nil
+	}
+}
+
+func toBool(value any) *bool {
+	switch v := value.(type) {
+	case bool:
+		return &v
+	case string:
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil
+		}
+		return &b
+	case float64:
+		b := v != 0
+		return &b
+	case json.Number:
+		b := v.String() != "0"
+		return &b
+	default:
+		return nil
+	}
+}
+
+func toTime(value any, format string) *time.Time {
+	var t time.Time
+	switch v := value.(type) {
+	case float64:
+		switch format {
+		case timeFormatUnix:
+			t = time.UnixMilli(int64(v * 1000))
+		case timeFormatUnixMs, "":
+			t = time.UnixMilli(int64(v))
+		default:
+			return nil
+		}
+	case json.Number:
+		// the integers too large for a float64
+		i, err := v.Int64()
+		if err != nil || format != timeFormatUnixMs && format != "" {
+			return toTime(v.String(), format)
+		}
+		t = time.UnixMilli(i)
+	case string:
+		switch format {
+		case timeFormatUnix, timeFormatUnixMs:
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return nil
+			}
+			return toTime(f, format)
+		case "":
+			format = time.RFC3339Nano
+		}
+		parsed, err := time.Parse(format, v)
+		if err != nil {
+			return nil
+		}
+		t = parsed
+	default:
+		return nil
+	}
+	t = t.UTC()
+	return &t
+}
+
+func toJSON(value any) *json.RawMessage {
+	if value == nil {
+		return nil
+	}
+	b, err := json.Marshal(value)
+	if err != nil {
+		return nil
+	}
+	raw := json.RawMessage(b)
+	return &raw
+}
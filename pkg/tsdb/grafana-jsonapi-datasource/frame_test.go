@@ -0,0 +1,76 @@
+package jsonapi
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRowsToFrame(t *testing.T) {
+	t.Run("should infer the fields from the keys of the first row", func(t *testing.T) {
+		rows := []any{
+			map[string]any{"b": "x", "a": 1.0, "a.b": true},
+			map[string]any{"b": "y", "c": 2.0},
+		}
+		for _, language := range []string{languageJSONPath, languageJMESPath} {
+			frame, err := rowsToFrame("A", rows, &apiQuery{Language: language})
+			require.NoError(t, err)
+			require.Len(t, frame.Fields, 3)
+			assert.Equal(t, "a", frame.Fields[0].Name)
+			assert.Equal(t, 1.0, *frame.Fields[0].At(0).(*float64))
+			assert.Nil(t, frame.Fields[0].At(1))
+			assert.Equal(t, "a.b", frame.Fields[1].Name)
+			assert.True(t, *frame.Fields[1].At(0).(*bool))
+			assert.Equal(t, "b", frame.Fields[2].Name)
+			assert.Equal(t, "y", *frame.Fields[2].At(1).(*string))
+		}
+	})
+
+	t.Run("should return rows that are not objects as values", func(t *testing.T) {
+		frame, err := rowsToFrame("A", []any{1.0, 2.0}, &apiQuery{Language: languageJMESPath})
+		require.NoError(t, err)
+		require.Len(t, frame.Fields, 1)
+		assert.Equal(t, "value", frame.Fields[0].Name)
+		assert.Equal(t, 2.0, *frame.Fields[0].At(1).(*float64))
+	})
+
+	t.Run("should fail for unknown types", func(t *testing.T) {
+		_, err := rowsToFrame("A", []any{}, &apiQuery{
+			Language: languageJSONPath,
+			Fields:   []fieldDefinition{{Selector: "a", Type: "date"}},
+		})
+		require.Error(t, err)
+		assert.True(t, backend.IsDownstreamError(err))
+	})
+}
+
+func TestToTime(t *testing.T) {
+	expected := time.Date(2024, 1, 1, 0, 0, 0, 500000000, time.UTC)
+
+	tests := []struct {
+		name   string
+		value  any
+		format string
+	}{
+		{name: "epoch milliseconds by default", value: 1704067200500.0},
+		{name: "epoch milliseconds string", value: "1704067200500", format: timeFormatUnixMs},
+		{name: "epoch seconds", value: 1704067200.5, format: timeFormatUnix},
+		{name: "epoch seconds string", value: "1704067200.5", format: timeFormatUnix},
+		{name: "RFC 3339 by default", value: "2024-01-01T01:00:00.5+01:00"},
+		{name: "Go layout", value: "2024-01-01 00:00:00.5", format: "2006-01-02 15:04:05"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := toTime(tt.value, tt.format)
+			require.NotNil(t, actual)
+			assert.Equal(t, expected, *actual)
+		})
+	}
+
+	assert.Nil(t, toTime("yesterday", ""))
+	assert.Nil(t, toTime(true, ""))
+	assert.Nil(t, toTime(1.0, "2006-01-02"))
+}
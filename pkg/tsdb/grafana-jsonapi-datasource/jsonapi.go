@@ -0,0 +1,211 @@
+package jsonapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/datasource"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/instancemgmt"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+
+	"github.com/grafana/grafana/pkg/infra/httpclient"
+)
+
+var logger = backend.NewLoggerWith("logger", "tsdb.jsonapi")
+
+const authTypeOAuth2ClientCredentials = "oauth2ClientCredentials"
+
+type Service struct {
+	im instancemgmt.InstanceManager
+}
+
+func ProvideService(httpClientProvider httpclient.Provider) *Service {
+	return &Service{
+		im: datasource.NewInstanceManager(newInstanceSettings(httpClientProvider)),
+	}
+}
+
+type datasourceInfo struct {
+	HTTPClient      *http.Client
+	URL             *url.URL
+	HealthCheckPath string
+}
+
+type datasourceJSONData struct {
+	// HealthCheckPath is requested by the health check, relative to the datasource URL
+	HealthCheckPath string `json:"healthCheckPath"`
+
+	// AuthType enables an additional token based authentication on top of the
+	// basic auth, custom headers and TLS options of the HTTP client
+	AuthType    string   `json:"authType"`
+	TokenURL    string   `json:"tokenUrl"`
+	ClientID    string   `json:"clientId"`
+	Scopes      []string `json:"scopes"`
+	TokenParams []struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	} `json:"tokenParams"`
+}
+
+func newInstanceSettings(httpClientProvider httpclient.Provider) datasource.InstanceFactoryFunc {
+	return func(ctx context.Context, settings backend.DataSourceInstanceSettings) (instancemgmt.Instance, error) {
+		if settings.URL == "" {
+			return nil, backend.DownstreamError(errors.New("error reading settings: url is empty"))
+		}
+		baseURL, err := url.Parse(settings.URL)
+		if err != nil {
+			return nil, backend.DownstreamError(fmt.Errorf("error reading settings: invalid url: %w", err))
+		}
+		if baseURL.Scheme != "http" && baseURL.Scheme != "https" {
+			return nil, backend.DownstreamError(fmt.Errorf("error reading settings: unsupported url scheme %q", baseURL.Scheme))
+		}
+
+		var jsonData datasourceJSONData
+		if len(settings.JSONData) > 0 {
+			if err := json.Unmarshal(settings.JSONData, &jsonData); err != nil {
+				return nil, fmt.Errorf("error reading settings: %w", err)
+			}
+		}
+
+		httpClientOptions, err := settings.HTTPClientOptions(ctx)
+		if err != nil {
+			return nil, backend.DownstreamError(fmt.Errorf("error reading settings: %w", err))
+		}
+
+		httpClient, err := httpClientProvider.New(httpClientOptions)
+		if err != nil {
+			return nil, fmt.Errorf("error creating http client: %w", err)
+		}
+
+		switch jsonData.AuthType {
+		case "":
+		case authTypeOAuth2ClientCredentials:
+			httpClient, err = withClientCredentials(httpClient, settings, jsonData)
+			if err != nil {
+				return nil, err
+			}
+		default:
+			return nil, backend.DownstreamError(fmt.Errorf("error reading settings: unsupported auth type %q", jsonData.AuthType))
+		}
+
+		return &datasourceInfo{
+			HTTPClient:      httpClient,
+			URL:             baseURL,
+			HealthCheckPath: jsonData.HealthCheckPath,
+		}, nil
+	}
+}
+
+// reservedTokenParams are set from the datasource settings and can't be overridden by the token params
+var reservedTokenParams = map[string]bool{
+	"grant_type":    true,
+	"client_id":     true,
+	"client_secret": true,
+	"scope":         true,
+}
+
+// withClientCredentials wraps the client so that every request carries an access token obtained with the
+// OAuth2 client credentials grant. The token is cached and only requested again once it has expired.
+func withClientCredentials(client *http.Client, settings backend.DataSourceInstanceSettings, jsonData datasourceJSONData) (*http.Client, error) {
+	if jsonData.TokenURL == "" || jsonData.ClientID == "" {
+		return nil, backend.DownstreamError(errors.New("error reading settings: token url and client id are required for client credentials authentication"))
+	}
+
+	params := url.Values{}
+	for _, p := range jsonData.TokenParams {
+		if reservedTokenParams[p.Name] {
+			return nil, backend.DownstreamError(fmt.Errorf("error reading settings: token param %q is reserved", p.Name))
+		}
+		params.Set(p.Name, p.Value)
+	}
+
+	config := clientcredentials.Config{
+		ClientID:       jsonData.ClientID,
+		ClientSecret:   settings.DecryptedSecureJSONData["clientSecret"],
+		TokenURL:       jsonData.TokenURL,
+		Scopes:         jsonData.Scopes,
+		EndpointParams: params,
+		AuthStyle:      oauth2.AuthStyleInParams,
+	}
+
+	// The token endpoint is requested without the credentials and headers of the datasource
+	tokenCtx := context.WithValue(context.Background(), oauth2.HTTPClient, &http.Client{Timeout: client.Timeout})
+
+	return &http.Client{
+		Transport: &tokenAuthTransport{
+			base:   client.Transport,
+			source: config.TokenSource(tokenCtx),
+		},
+		Timeout: client.Timeout,
+	}, nil
+}
+
+// tokenAuthTransport sets the access token of the token source on the requests
+type tokenAuthTransport struct {
+	base   http.RoundTripper
+	source oauth2.TokenSource
+}
+
+func (t *tokenAuthTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := t.source.Token()
+	if err != nil {
+		logger.Warn("Failed to get an access token", "error", err)
+		return nil, backend.DownstreamError(fmt.Errorf("failed to get an access token with the client credentials: %w", err))
+	}
+
+	req = req.Clone(req.Context())
+	token.SetAuthHeader(req)
+	return t.base.RoundTrip(req)
+}
+
+func (s *Service) getDSInfo(ctx context.Context, pluginCtx backend.PluginContext) (*datasourceInfo, error) {
+	i, err := s.im.Get(ctx, pluginCtx)
+	if err != nil {
+		return nil, err
+	}
+
+	instance, ok := i.(*datasourceInfo)
+	if !ok {
+		return nil, errors.New("failed to cast datasource info")
+	}
+
+	return instance, nil
+}
+
+func (s *Service) QueryData(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+	dsInfo, err := s.getDSInfo(ctx, req.PluginContext)
+	if err != nil {
+		return nil, err
+	}
+
+	return queryData(ctx, dsInfo, req)
+}
+
+func (s *Service) CheckHealth(ctx context.Context, req *backend.CheckHealthRequest) (*backend.CheckHealthResult, error) {
+	dsInfo, err := s.getDSInfo(ctx, req.PluginContext)
+	if err != nil {
+		return &backend.CheckHealthResult{
+			Status:  backend.HealthStatusError,
+			Message: err.Error(),
+		}, nil
+	}
+
+	client := newClient(dsInfo)
+	if _, err := client.get(ctx, &apiQuery{Path: dsInfo.HealthCheckPath}, backend.TimeRange{}); err != nil {
+		return &backend.CheckHealthResult{
+			Status:  backend.HealthStatusError,
+			Message: err.Error(),
+		}, nil
+	}
+
+	return &backend.CheckHealthResult{
+		Status:  backend.HealthStatusOk,
+		Message: "Data source is working",
+	}, nil
+}
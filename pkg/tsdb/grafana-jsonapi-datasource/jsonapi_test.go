@@ -0,0 +1,338 @@
+package jsonapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestService(t *testing.T, url string, jsonData string, secureJSONData map[string]string) (*Service, backend.PluginContext) {
+	t.Helper()
+
+	pluginCtx := backend.PluginContext{
+		DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{
+			ID:                      1,
+			URL:                     url,
+			JSONData:                []byte(jsonData),
+			DecryptedSecureJSONData: secureJSONData,
+		},
+	}
+	return ProvideService(httpclient.NewProvider()), pluginCtx
+}
+
+func query(t *testing.T, s *Service, pluginCtx backend.PluginContext, model string, timeRange backend.TimeRange) backend.DataResponse {
+	t.Helper()
+
+	res, err := s.QueryData(context.Background(), &backend.QueryDataRequest{
+		PluginContext: pluginCtx,
+		Queries: []backend.DataQuery{{
+			RefID:     "A",
+			JSON:      []byte(model),
+			TimeRange: timeRange,
+		}},
+	})
+	require.NoError(t, err)
+	return res.Responses["A"]
+}
+
+func TestQueryData(t *testing.T) {
+	timeRange := backend.TimeRange{
+		From: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		To:   time.Date(2024, 1, 1, 1, 0, 0, 0, time.UTC),
+	}
+
+	t.Run("should extract typed fields from the selected rows", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/api/v1/metrics", r.URL.Path)
+			assert.Equal(t, "1704067200", r.URL.Query().Get("from"))
+			assert.Equal(t, "2024-01-01T01:00:00Z", r.URL.Query().Get("to"))
+			assert.Equal(t, "team-a", r.Header.Get("X-Team"))
+			_, _ = w.Write([]byte(`{"data": {"items": [
+				{"ts": 1704067200000, "name": "cpu", "value": 0.5, "ok": true, "meta": {"host": "a"}},
+				{"ts": 1704067260000, "name": "mem", "value": "12", "ok": false},
+				{"ts": 1704067320000, "name": "disk", "value": null}
+			]}}`))
+		}))
+		defer srv.Close()
+
+		s, pluginCtx := newTestService(t, srv.URL+"/api", "{}", nil)
+		res := query(t, s, pluginCtx, `{
+			"path": "v1/metrics",
+			"params": [{"key": "from", "value": "$__unixEpochFrom()"}, {"key": "to", "value": "$__isoTo()"}],
+			"headers": [{"key": "X-Team", "value": "team-a"}],
+			"rowsSelector": "data.items",
+			"fields": [
+				{"name": "time", "selector": "ts", "type": "time"},
+				{"selector": "name"},
+				{"name": "value", "selector": "value", "type": "number"},
+				{"selector": "ok", "type": "boolean"},
+				{"selector": "meta", "type": "json"}
+			]
+		}`, timeRange)
+		require.NoError(t, res.Error)
+		require.Len(t, res.Frames, 1)
+
+		frame := res.Frames[0]
+		require.Len(t, frame.Fields, 5)
+		require.Equal(t, 3, frame.Rows())
+		assert.Equal(t, "time", frame.Fields[0].Name)
+		assert.Equal(t, time.Date(2024, 1, 1, 0, 1, 0, 0, time.UTC), *frame.Fields[0].At(1).(*time.Time))
+		assert.Equal(t, "name", frame.Fields[1].Name)
+		assert.Equal(t, "mem", *frame.Fields[1].At(1).(*string))
+		assert.Equal(t, 0.5, *frame.Fields[2].At(0).(*float64))
+		assert.Equal(t, 12.0, *frame.Fields[2].At(1).(*float64))
+		assert.Nil(t, frame.Fields[2].At(2))
+		assert.False(t, *frame.Fields[3].At(1).(*bool))
+		assert.JSONEq(t, `{"host": "a"}`, string(*frame.Fields[4].At(0).(*json.RawMessage)))
+		assert.Nil(t, frame.Fields[4].At(1))
+	})
+
+	t.Run("should follow the next link header", func(t *testing.T) {
+		var srv *httptest.Server
+		srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Query().Get("page") {
+			case "":
+				w.Header().Set("Link", fmt.Sprintf(`<%s/items?page=2>; rel="next", <%s/items?page=3>; rel="last"`, srv.URL, srv.URL))
+				_, _ = w.Write([]byte(`[{"id": 1}, {"id": 2}]`))
+			case "2":
+				w.Header().Set("Link", `</items?page=3>; rel="next"`)
+				_, _ = w.Write([]byte(`[{"id": 3}]`))
+			default:
+				_, _ = w.Write([]byte(`[{"id": 4}]`))
+			}
+		}))
+		defer srv.Close()
+
+		s, pluginCtx := newTestService(t, srv.URL, "{}", nil)
+		res := query(t, s, pluginCtx, `{"path": "items", "pagination": {"mode": "link"}}`, timeRange)
+		require.NoError(t, res.Error)
+
+		frame := res.Frames[0]
+		require.Len(t, frame.Fields, 1)
+		require.Equal(t, 4, frame.Rows())
+		assert.Equal(t, 4.0, *frame.Fields[0].At(3).(*float64))
+	})
+
+	t.Run("should not follow links to another host", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Link", `<http://example.com/items?page=2>; rel="next"`)
+			_, _ = w.Write([]byte(`[{"id": 1}]`))
+		}))
+		defer srv.Close()
+
+		s, pluginCtx := newTestService(t, srv.URL, "{}", nil)
+		res := query(t, s, pluginCtx, `{"path": "items", "pagination": {"mode": "link"}}`, timeRange)
+		require.Error(t, res.Error)
+		assert.Equal(t, backend.ErrorSourceDownstream, res.ErrorSource)
+	})
+
+	t.Run("should send the cursor of the previous page up to the max pages", func(t *testing.T) {
+		requests := 0
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			assert.Equal(t, http.MethodPost, r.Method)
+			assert.Equal(t, "10", r.URL.Query().Get("limit"))
+			cursor := r.URL.Query().Get("after")
+			_, _ = fmt.Fprintf(w, `{"results": [{"cursor": %q}], "next": "%s-next"}`, cursor, cursor)
+		}))
+		defer srv.Close()
+
+		s, pluginCtx := newTestService(t, srv.URL, "{}", nil)
+		res := query(t, s, pluginCtx, `{
+			"method": "post",
+			"path": "search?limit=10",
+			"body": "{\"from\": $__unixEpochMsFrom()}",
+			"language": "jmespath",
+			"rowsSelector": "results",
+			"pagination": {"mode": "cursor", "cursorSelector": "next", "cursorParam": "after", "maxPages": 3}
+		}`, timeRange)
+		require.NoError(t, res.Error)
+
+		frame := res.Frames[0]
+		require.Equal(t, 3, frame.Rows())
+		assert.Equal(t, 3, requests)
+		assert.Equal(t, "", *frame.Fields[0].At(0).(*string))
+		assert.Equal(t, "-next-next", *frame.Fields[0].At(2).(*string))
+	})
+
+	t.Run("should send the large numeric cursors without exponent", func(t *testing.T) {
+		var cursors []string
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			cursor := r.URL.Query().Get("after")
+			cursors = append(cursors, cursor)
+			if cursor == "" {
+				_, _ = fmt.Fprint(w, `{"results": [{"id": 1}], "next": 1234567890123}`)
+				return
+			}
+			_, _ = fmt.Fprint(w, `{"results": [{"id": 2}]}`)
+		}))
+		defer srv.Close()
+
+		s, pluginCtx := newTestService(t, srv.URL, "{}", nil)
+		res := query(t, s, pluginCtx, `{
+			"path": "search",
+			"language": "jmespath",
+			"rowsSelector": "results",
+			"pagination": {"mode": "cursor", "cursorSelector": "next", "cursorParam": "after", "maxPages": 3}
+		}`, timeRange)
+		require.NoError(t, res.Error)
+		assert.Equal(t, []string{"", "1234567890123"}, cursors)
+	})
+
+	t.Run("should reject paths to another host", func(t *testing.T) {
+		s, pluginCtx := newTestService(t, "http://localhost:3000", "{}", nil)
+		res := query(t, s, pluginCtx, `{"path": "http://example.com/items"}`, timeRange)
+		require.Error(t, res.Error)
+		assert.Equal(t, backend.ErrorSourceDownstream, res.ErrorSource)
+	})
+
+	t.Run("should reject paths out of the datasource url path", func(t *testing.T) {
+		s, pluginCtx := newTestService(t, "http://localhost:3000/api/v1", "{}", nil)
+		for _, path := range []string{"../admin/users", "items/../../../admin", "../v1-private"} {
+			res := query(t, s, pluginCtx, fmt.Sprintf(`{"path": %q}`, path), timeRange)
+			require.ErrorContains(t, res.Error, "must be relative to the datasource url")
+			assert.Equal(t, backend.ErrorSourceDownstream, res.ErrorSource)
+		}
+	})
+
+	t.Run("should keep the precision of the large integers", func(t *testing.T) {
+		var cursors []string
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			cursors = append(cursors, r.URL.Query().Get("after"))
+			if len(cursors) == 1 {
+				_, _ = fmt.Fprint(w, `{"results": [{"id": 9007199254740993, "value": 1.5}], "next": 9007199254740995}`)
+				return
+			}
+			_, _ = fmt.Fprint(w, `{"results": [{"id": 12345678901234567890, "value": 2}]}`)
+		}))
+		defer srv.Close()
+
+		s, pluginCtx := newTestService(t, srv.URL, "{}", nil)
+		res := query(t, s, pluginCtx, `{
+			"path": "search",
+			"rowsSelector": "results",
+			"fields": [{"selector": "id", "type": "string"}, {"selector": "value", "type": "number"}],
+			"pagination": {"mode": "cursor", "cursorSelector": "next", "cursorParam": "after", "maxPages": 3}
+		}`, timeRange)
+		require.NoError(t, res.Error)
+		assert.Equal(t, []string{"", "9007199254740995"}, cursors)
+		require.Len(t, res.Frames, 1)
+		assert.Equal(t, "9007199254740993", *res.Frames[0].Fields[0].At(0).(*string))
+		assert.Equal(t, "12345678901234567890", *res.Frames[0].Fields[0].At(1).(*string))
+		assert.Equal(t, 1.5, *res.Frames[0].Fields[1].At(0).(*float64))
+	})
+
+	t.Run("should return a downstream error for error responses", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		}))
+		defer srv.Close()
+
+		s, pluginCtx := newTestService(t, srv.URL, "{}", nil)
+		res := query(t, s, pluginCtx, `{"path": "missing"}`, timeRange)
+		require.ErrorContains(t, res.Error, "404 Not Found")
+		assert.Equal(t, backend.ErrorSourceDownstream, res.ErrorSource)
+	})
+
+	t.Run("should authenticate with client credentials", func(t *testing.T) {
+		tokenRequests := 0
+		mux := http.NewServeMux()
+		mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+			tokenRequests++
+			require.NoError(t, r.ParseForm())
+			assert.Equal(t, "client_credentials", r.PostForm.Get("grant_type"))
+			assert.Equal(t, "api", r.PostForm.Get("audience"))
+			assert.Equal(t, "read write", r.PostForm.Get("scope"))
+			assert.Equal(t, "grafana", r.PostForm.Get("client_id"))
+			assert.Equal(t, "secret", r.PostForm.Get("client_secret"))
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"access_token": "token", "token_type": "bearer", "expires_in": 3600}`))
+		})
+		mux.HandleFunc("/items", func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+			_, _ = w.Write([]byte(`[{"id": 1}]`))
+		})
+		srv := httptest.NewServer(mux)
+		defer srv.Close()
+
+		s, pluginCtx := newTestService(t, srv.URL, fmt.Sprintf(`{
+			"authType": "oauth2ClientCredentials",
+			"tokenUrl": "%s/token",
+			"clientId": "grafana",
+			"scopes": ["read", "write"],
+			"tokenParams": [{"name": "audience", "value": "api"}]
+		}`, srv.URL), map[string]string{"clientSecret": "secret"})
+
+		for i := 0; i < 2; i++ {
+			res := query(t, s, pluginCtx, `{"path": "items"}`, timeRange)
+			require.NoError(t, res.Error)
+		}
+		assert.Equal(t, 1, tokenRequests)
+	})
+
+	t.Run("should not allow the token params to override the client credentials", func(t *testing.T) {
+		s, pluginCtx := newTestService(t, "http://localhost", `{
+			"authType": "oauth2ClientCredentials",
+			"tokenUrl": "http://localhost/token",
+			"clientId": "grafana",
+			"tokenParams": [{"name": "grant_type", "value": "password"}]
+		}`, nil)
+		pluginCtx.DataSourceInstanceSettings.ID = 3
+
+		_, err := s.QueryData(context.Background(), &backend.QueryDataRequest{PluginContext: pluginCtx})
+		require.ErrorContains(t, err, `token param "grant_type" is reserved`)
+		assert.True(t, backend.IsDownstreamError(err))
+	})
+
+	t.Run("should fail without an access token", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+		}))
+		defer srv.Close()
+
+		s, pluginCtx := newTestService(t, srv.URL, fmt.Sprintf(`{
+			"authType": "oauth2ClientCredentials",
+			"tokenUrl": "%s/token",
+			"clientId": "grafana"
+		}`, srv.URL), nil)
+		pluginCtx.DataSourceInstanceSettings.ID = 2
+
+		res := query(t, s, pluginCtx, `{"path": "items"}`, timeRange)
+		require.ErrorContains(t, res.Error, "failed to get an access token")
+		assert.Equal(t, backend.ErrorSourceDownstream, res.ErrorSource)
+	})
+}
+
+func TestCheckHealth(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/health" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`{"status": "ok"}`))
+	}))
+	defer srv.Close()
+
+	t.Run("should request the health check path", func(t *testing.T) {
+		s, pluginCtx := newTestService(t, srv.URL, `{"healthCheckPath": "health"}`, nil)
+		res, err := s.CheckHealth(context.Background(), &backend.CheckHealthRequest{PluginContext: pluginCtx})
+		require.NoError(t, err)
+		assert.Equal(t, backend.HealthStatusOk, res.Status)
+	})
+
+	t.Run("should fail when the health check path is not found", func(t *testing.T) {
+		s, pluginCtx := newTestService(t, srv.URL, `{"healthCheckPath": "missing"}`, nil)
+		res, err := s.CheckHealth(context.Background(), &backend.CheckHealthRequest{PluginContext: pluginCtx})
+		require.NoError(t, err)
+		assert.Equal(t, backend.HealthStatusError, res.Status)
+	})
+}
@@ -0,0 +1,176 @@
+package jsonapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+const (
+	languageJSONPath = "jsonpath"
+	languageJMESPath = "jmespath"
+
+	paginationNone   = "none"
+	paginationLink   = "link"
+	paginationCursor = "cursor"
+
+	defaultMaxPages = 10
+	maxPagesLimit   = 100
+)
+
+type keyValue struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+type fieldDefinition struct {
+	Name     string `json:"name"`
+	Selector string `json:"selector"`
+	// Type is one of string, number, boolean, time or json. It is inferred from the values when empty.
+	Type string `json:"type"`
+	// TimeFormat is used to parse time fields: unix, unixms or a Go time layout.
+	// Numbers are parsed as epoch milliseconds and strings as RFC 3339 when empty.
+	TimeFormat string `json:"timeFormat"`
+}
+
+type pagination struct {
+	// Mode is one of none, link (follows the rel="next" Link header) or cursor
+	Mode string `json:"mode"`
+	// CursorSelector selects the cursor of the next page in the response
+	CursorSelector string `json:"cursorSelector"`
+	// CursorParam is the query parameter the cursor is sent with
+	CursorParam string `json:"cursorParam"`
+	MaxPages    int    `json:"maxPages"`
+}
+
+type apiQuery struct {
+	Method  string     `json:"method"`
+	Path    string     `json:"path"`
+	Params  []keyValue `json:"params"`
+	Headers []keyValue `json:"headers"`
+	Body    string     `json:"body"`
+
+	// Language of the selectors, jsonpath or jmespath
+	Language string `json:"language"`
+	// RowsSelector selects the array of rows in the response. The whole response is used when empty.
+	RowsSelector string `json:"rowsSelector"`
+	// Fields are extracted from each row. All the top level keys of the rows are used when empty.
+	Fields     []fieldDefinition `json:"fields"`
+	Pagination pagination        `json:"pagination"`
+}
+
+func parseQuery(q backend.DataQuery) (*apiQuery, error) {
+	var query apiQuery
+	if err := json.Unmarshal(q.JSON, &query); err != nil {
+		return nil, backend.DownstreamError(fmt.Errorf("error while parsing the query json. %w", err))
+	}
+
+	if query.Method == "" {
+		query.Method = http.MethodGet
+	}
+	query.Method = strings.ToUpper(query.Method)
+	if query.Method != http.MethodGet && query.Method != http.MethodPost {
+		return nil, backend.DownstreamError(fmt.Errorf("unsupported method %q", query.Method))
+	}
+
+	if query.Language == "" {
+		query.Language = languageJSONPath
+	}
+	if query.Language != languageJSONPath && query.Language != languageJMESPath {
+		return nil, backend.DownstreamError(fmt.Errorf("unsupported selector language %q", query.Language))
+	}
+
+	if query.Pagination.Mode == "" {
+		query.Pagination.Mode = paginationNone
+	}
+	switch query.Pagination.Mode {
+	case paginationNone, paginationLink:
+	case paginationCursor:
+		if query.Pagination.CursorSelector == "" || query.Pagination.CursorParam == "" {
+			return nil, backend.DownstreamError(fmt.Errorf("cursor pagination requires a cursor selector and a cursor parameter"))
+		}
+	default:
+		return nil, backend.DownstreamError(fmt.Errorf("unsupported pagination mode %q", query.Pagination.Mode))
+	}
+	if query.Pagination.MaxPages <= 0 {
+		query.Pagination.MaxPages = defaultMaxPages
+	}
+	if query.Pagination.MaxPages > maxPagesLimit {
+		query.Pagination.MaxPages = maxPagesLimit
+	}
+
+	return &query, nil
+}
+
+func queryData(ctx context.Context, dsInfo *datasourceInfo, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+	response := backend.NewQueryDataResponse()
+	client := newClient(dsInfo)
+
+	for _, q := range req.Queries {
+		query, err := parseQuery(q)
+		if err != nil {
+			response.Responses[q.RefID] = backend.ErrorResponseWithErrorSource(err)
+			continue
+		}
+
+		rows, err := client.fetchRows(ctx, query, q.TimeRange)
+		if err != nil {
+			response.Responses[q.RefID] = backend.ErrorResponseWithErrorSource(err)
+			continue
+		}
+
+		frame, err := rowsToFrame(q.RefID, rows, query)
+		if err != nil {
+			response.Responses[q.RefID] = backend.ErrorResponseWithErrorSource(err)
+			continue
+		}
+
+		response.Responses[q.RefID] = backend.DataResponse{
+			Frames: data.Frames{frame},
+		}
+	}
+
+	return response, nil
+}
+
+var macroRegexp = regexp.MustCompile(`\$__(\w+)\(\)`)
+
+// interpolate replaces the time range macros in the value:
+//
+//	$__unixEpochFrom() and $__unixEpochTo(): epoch seconds
+//	$__unixEpochMsFrom() and $__unixEpochMsTo(): epoch milliseconds
+//	$__isoFrom() and $__isoTo(): RFC 3339
+func interpolate(value string, timeRange backend.TimeRange) (string, error) {
+	var err error
+	result := macroRegexp.ReplaceAllStringFunc(value, func(match string) string {
+		name := macroRegexp.FindStringSubmatch(match)[1]
+		switch name {
+		case "unixEpochFrom":
+			return strconv.FormatInt(timeRange.From.Unix(), 10)
+		case "unixEpochTo":
+			return strconv.FormatInt(timeRange.To.Unix(), 10)
+		case "unixEpochMsFrom":
+			return strconv.FormatInt(timeRange.From.UnixMilli(), 10)
+		case "unixEpochMsTo":
+			return strconv.FormatInt(timeRange.To.UnixMilli(), 10)
+		case "isoFrom":
+			return timeRange.From.UTC().Format(time.RFC3339)
+		case "isoTo":
+			return timeRange.To.UTC().Format(time.RFC3339)
+		default:
+			if err == nil {
+				err = backend.DownstreamError(fmt.Errorf("unknown macro %q", match))
+			}
+			return match
+		}
+	})
+	return result, err
+}
@@ -0,0 +1,64 @@
+package jsonapi
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInterpolate(t *testing.T) {
+	timeRange := backend.TimeRange{
+		From: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		To:   time.Date(2024, 1, 1, 1, 0, 0, 0, time.UTC),
+	}
+
+	tests := []struct {
+		value    string
+		expected string
+	}{
+		{value: "$__unixEpochFrom()-$__unixEpochTo()", expected: "1704067200-1704070800"},
+		{value: "$__unixEpochMsFrom()-$__unixEpochMsTo()", expected: "1704067200000-1704070800000"},
+		{value: "$__isoFrom()/$__isoTo()", expected: "2024-01-01T00:00:00Z/2024-01-01T01:00:00Z"},
+		{value: "$__interval", expected: "$__interval"},
+	}
+	for _, tt := range tests {
+		actual, err := interpolate(tt.value, timeRange)
+		require.NoError(t, err)
+		assert.Equal(t, tt.expected, actual)
+	}
+
+	_, err := interpolate("$__timeFilter()", timeRange)
+	require.Error(t, err)
+	assert.True(t, backend.IsDownstreamError(err))
+}
+
+func TestParseQuery(t *testing.T) {
+	t.Run("should fill the defaults", func(t *testing.T) {
+		query, err := parseQuery(backend.DataQuery{JSON: []byte(`{"path": "items"}`)})
+		require.NoError(t, err)
+		assert.Equal(t, "GET", query.Method)
+		assert.Equal(t, languageJSONPath, query.Language)
+		assert.Equal(t, paginationNone, query.Pagination.Mode)
+		assert.Equal(t, defaultMaxPages, query.Pagination.MaxPages)
+	})
+
+	t.Run("should limit the max pages", func(t *testing.T) {
+		query, err := parseQuery(backend.DataQuery{JSON: []byte(`{"pagination": {"mode": "link", "maxPages": 1000}}`)})
+		require.NoError(t, err)
+		assert.Equal(t, maxPagesLimit, query.Pagination.MaxPages)
+	})
+
+	for _, model := range []string{
+		`{"method": "DELETE"}`,
+		`{"language": "xpath"}`,
+		`{"pagination": {"mode": "offset"}}`,
+		`{"pagination": {"mode": "cursor", "cursorSelector": "next"}}`,
+	} {
+		_, err := parseQuery(backend.DataQuery{JSON: []byte(model)})
+		require.Error(t, err, model)
+		assert.True(t, backend.IsDownstreamError(err))
+	}
+}
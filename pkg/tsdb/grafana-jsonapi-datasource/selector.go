@@ -0,0 +1,67 @@
+package jsonapi
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dolthub/jsonpath"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/jmespath-community/go-jmespath"
+)
+
+// selector extracts a value from a decoded JSON document.
+type selector interface {
+	// Select returns the selected value, or nil when it doesn't exist in the document
+	Select(obj any) any
+}
+
+type jsonPathSelector struct {
+	compiled *jsonpath.Compiled
+}
+
+func (s *jsonPathSelector) Select(obj any) any {
+	// Lookups fail when a key doesn't exist, which is handled as a missing value
+	value, err := s.compiled.Lookup(obj)
+	if err != nil {
+		return nil
+	}
+	return value
+}
+
+type jmesPathSelector struct {
+	compiled jmespath.JMESPath
+}
+
+func (s *jmesPathSelector) Select(obj any) any {
+	value, err := s.compiled.Search(obj)
+	if err != nil {
+		return nil
+	}
+	return value
+}
+
+// compileSelector compiles the expression in the given language. For convenience, JSONPath
+// expressions don't need to start with the root element, "items" is the same as "$.items".
+func compileSelector(language, expression string) (selector, error) {
+	switch language {
+	case languageJMESPath:
+		compiled, err := jmespath.Compile(expression)
+		if err != nil {
+			return nil, backend.DownstreamError(fmt.Errorf("invalid JMESPath expression %q: %w", expression, err))
+		}
+		return &jmesPathSelector{compiled: compiled}, nil
+	default:
+		if !strings.HasPrefix(expression, "$") && !strings.HasPrefix(expression, "@") {
+			if strings.HasPrefix(expression, "[") {
+				expression = "$" + expression
+			} else {
+				expression = "$." + expression
+			}
+		}
+		compiled, err := jsonpath.Compile(expression)
+		if err != nil {
+			return nil, backend.DownstreamError(fmt.Errorf("invalid JSONPath expression %q: %w", expression, err))
+		}
+		return &jsonPathSelector{compiled: compiled}, nil
+	}
+}
@@ -0,0 +1,27 @@
+package influxdb
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+
+	"github.com/grafana/grafana/pkg/tsdb/influxdb/fsql"
+)
+
+func (s *Service) CallResource(ctx context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
+	dsInfo, err := s.getDSInfo(ctx, req.PluginContext)
+	if err != nil {
+		return err
+	}
+
+	// Schema introspection resources are only available in SQL mode,
+	// InfluxQL and Flux use metadata queries instead
+	if dsInfo.Version != influxVersionSQL {
+		return sender.Send(&backend.CallResourceResponse{
+			Status: http.StatusNotFound,
+		})
+	}
+
+	return fsql.CallResource(ctx, dsInfo, req, sender)
+}
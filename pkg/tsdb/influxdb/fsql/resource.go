@@ -0,0 +1,403 @@
+package fsql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/flight"
+	"github.com/apache/arrow-go/v18/arrow/flight/flightsql"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/resource/httpadapter"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/grafana/grafana/pkg/tsdb/influxdb/models"
+)
+
+const (
+	columnKindTime  = "time"
+	columnKindTag   = "tag"
+	columnKindField = "field"
+
+	// ioxColumnTypeKey is the schema metadata key InfluxDB 3 uses to describe the role of a column
+	ioxColumnTypeKey = "iox::column::type"
+
+	// tagValuesLimit caps the number of distinct values returned for a tag
+	tagValuesLimit = 1000
+)
+
+var errTableNotFound = errors.New("table not found")
+
+// systemSchemas are the schemas of InfluxDB 3 that don't contain user data
+var systemSchemas = map[string]bool{
+	"information_schema": true,
+	"system":             true,
+}
+
+// Column describes a column of a table (measurement).
+type Column struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+	// Kind is one of "time", "tag" or "field"
+	Kind string `json:"kind"`
+}
+
+// CallResource serves the schema introspection resources of the SQL mode,
+// used by the query builder for autocompletion:
+//
+//	GET /databases
+//	GET /tables
+//	GET /tables/{table}/columns
+//	GET /tables/{table}/tags/{tag}/values?from={ms}&to={ms}
+func CallResource(ctx context.Context, dsInfo *models.DatasourceInfo, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
+	handler := httpadapter.New(newResourceRouter(dsInfo))
+	return handler.CallResource(ctx, req, sender)
+}
+
+func newResourceRouter(dsInfo *models.DatasourceInfo) *http.ServeMux {
+	router := http.NewServeMux()
+	router.HandleFunc("GET /databases", func(rw http.ResponseWriter, r *http.Request) {
+		databases, err := Databases(r.Context(), dsInfo)
+		writeResourceResponse(r.Context(), rw, databases, err)
+	})
+	router.HandleFunc("GET /tables", func(rw http.ResponseWriter, r *http.Request) {
+		tables, err := Tables(r.Context(), dsInfo)
+		writeResourceResponse(r.Context(), rw, tables, err)
+	})
+	router.HandleFunc("GET /tables/{table}/columns", func(rw http.ResponseWriter, r *http.Request) {
+		columns, err := Columns(r.Context(), dsInfo, r.PathValue("table"))
+		writeResourceResponse(r.Context(), rw, columns, err)
+	})
+	router.HandleFunc("GET /tables/{table}/tags/{tag}/values", func(rw http.ResponseWriter, r *http.Request) {
+		timeRange, err := parseTimeRange(r.URL.Query())
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+			return
+		}
+		values, err := TagValues(r.Context(), dsInfo, r.PathValue("table"), r.PathValue("tag"), timeRange)
+		writeResourceResponse(r.Context(), rw, values, err)
+	})
+	return router
+}
+
+// Databases returns the databases of the InfluxDB 3 instance. FlightSQL has no notion of
+// databases, so they are listed through the InfluxDB 3 HTTP API.
+func Databases(ctx context.Context, dsInfo *models.DatasourceInfo) ([]string, error) {
+	u, err := url.JoinPath(dsInfo.URL, "/api/v3/configure/database")
+	if err != nil {
+		return nil, backend.DownstreamError(fmt.Errorf("failed to join url: %w", err))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u+"?format=json", nil)
+	if err != nil {
+		return nil, err
+	}
+	if dsInfo.Token != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", dsInfo.Token))
+	}
+
+	res, err := dsInfo.HTTPClient.Do(req)
+	if err != nil {
+		return nil, backend.DownstreamError(err)
+	}
+	defer func() {
+		if err := res.Body.Close(); err != nil {
+			glog.FromContext(ctx).Warn("Failed to close response body", "err", err)
+		}
+	}()
+
+	if res.StatusCode/100 != 2 {
+		return nil, backend.DownstreamError(fmt.Errorf("request failed: %s", res.Status))
+	}
+
+	var rows []map[string]string
+	if err := json.NewDecoder(res.Body).Decode(&rows); err != nil {
+		return nil, backend.DownstreamError(fmt.Errorf("failed to decode databases: %w", err))
+	}
+
+	databases := make([]string, 0, len(rows))
+	for _, row := range rows {
+		if name, ok := row["iox::database"]; ok && name != "_internal" {
+			databases = append(databases, name)
+		}
+	}
+	sort.Strings(databases)
+	return databases, nil
+}
+
+// Tables returns the tables (measurements) of the configured database.
+func Tables(ctx context.Context, dsInfo *models.DatasourceInfo) ([]string, error) {
+	tables := []string{}
+	err := withRunner(ctx, dsInfo, func(ctx context.Context, r *runner) error {
+		return r.getTables(ctx, &flightsql.GetTablesOpts{}, func(schemaName, tableName string, _ []byte) error {
+			if !systemSchemas[schemaName] {
+				tables = append(tables, tableName)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(tables)
+	return tables, nil
+}
+
+// Columns returns the columns of a table (measurement), with their kind.
+func Columns(ctx context.Context, dsInfo *models.DatasourceInfo, table string) ([]Column, error) {
+	if table == "" {
+		return nil, backend.DownstreamError(errors.New("table is required"))
+	}
+
+	columns := []Column{}
+	found := false
+	err := withRunner(ctx, dsInfo, func(ctx context.Context, r *runner) error {
+		opts := &flightsql.GetTablesOpts{
+			TableNameFilterPattern: &table,
+			IncludeSchema:          true,
+		}
+		return r.getTables(ctx, opts, func(schemaName, tableName string, serializedSchema []byte) error {
+			// The filter is a pattern where "_" matches any character, so the name is compared exactly
+			if found || tableName != table || systemSchemas[schemaName] {
+				return nil
+			}
+			found = true
+
+			schema, err := flight.DeserializeSchema(serializedSchema, r.client.Alloc)
+			if err != nil {
+				return fmt.Errorf("failed to deserialize table schema: %w", err)
+			}
+			for _, field := range schema.Fields() {
+				columns = append(columns, Column{
+					Name: field.Name,
+					Type: field.Type.String(),
+					Kind: columnKind(field),
+				})
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, backend.DownstreamError(fmt.Errorf("%w: %q", errTableNotFound, table))
+	}
+	return columns, nil
+}
+
+// TagValues returns the distinct values of a tag in a table (measurement). When the time range
+// is not empty, only the values of the rows within that range are returned.
+func TagValues(ctx context.Context, dsInfo *models.DatasourceInfo, table, tag string, timeRange backend.TimeRange) ([]string, error) {
+	if table == "" || tag == "" {
+		return nil, backend.DownstreamError(errors.New("table and tag are required"))
+	}
+
+	sql := fmt.Sprintf("SELECT DISTINCT %s FROM %s", quoteIdentifier(tag), quoteIdentifier(table))
+	if !timeRange.From.IsZero() && !timeRange.To.IsZero() {
+		sql += fmt.Sprintf(" WHERE %s >= '%s' AND %s <= '%s'",
+			quoteIdentifier("time"), timeRange.From.UTC().Format(time.RFC3339Nano),
+			quoteIdentifier("time"), timeRange.To.UTC().Format(time.RFC3339Nano))
+	}
+	sql += fmt.Sprintf(" ORDER BY %s LIMIT %d", quoteIdentifier(tag), tagValuesLimit)
+
+	values := []string{}
+	err := withRunner(ctx, dsInfo, func(ctx context.Context, r *runner) error {
+		reader, err := r.execute(ctx, sql)
+		if err != nil {
+			return err
+		}
+		defer reader.Release()
+
+		for reader.Next() {
+			col := reader.Record().Column(0)
+			for i := 0; i < col.Len(); i++ {
+				if !col.IsNull(i) {
+					values = append(values, col.ValueStr(i))
+				}
+			}
+		}
+		if err := reader.Err(); err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// withRunner creates a runner for the datasource and closes it once fn returns.
+func withRunner(ctx context.Context, dsInfo *models.DatasourceInfo, fn func(ctx context.Context, r *runner) error) error {
+	r, err := runnerFromDataSource(dsInfo)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := r.client.Close(); err != nil {
+			glog.FromContext(ctx).Warn("Failed to close fsql client", "err", err)
+		}
+	}()
+
+	if r.client.md.Len() != 0 {
+		ctx = metadata.NewOutgoingContext(ctx, r.client.md)
+	}
+	return fn(ctx, r)
+}
+
+// execute runs the SQL query and returns a reader for the resulting records.
+func (r *runner) execute(ctx context.Context, sql string) (*flightReader, error) {
+	info, err := r.client.Execute(ctx, sql)
+	if err != nil {
+		return nil, err
+	}
+	if len(info.Endpoint) != 1 {
+		return nil, fmt.Errorf("unsupported endpoint count in response: %d", len(info.Endpoint))
+	}
+	return r.client.DoGetWithHeaderExtraction(ctx, info.Endpoint[0].Ticket)
+}
+
+// getTables calls fn for each table returned by the FlightSQL GetTables command.
+// serializedSchema is only set when opts.IncludeSchema is true.
+func (r *runner) getTables(ctx context.Context, opts *flightsql.GetTablesOpts, fn func(schemaName, tableName string, serializedSchema []byte) error) error {
+	info, err := r.client.GetTables(ctx, opts)
+	if err != nil {
+		return err
+	}
+	if len(info.Endpoint) != 1 {
+		return fmt.Errorf("unsupported endpoint count in response: %d", len(info.Endpoint))
+	}
+
+	reader, err := r.client.DoGet(ctx, info.Endpoint[0].Ticket)
+	if err != nil {
+		return err
+	}
+	defer reader.Release()
+
+	for reader.Next() {
+		record := reader.Record()
+		schemaNames, ok := record.Column(1).(*array.String)
+		if !ok {
+			return fmt.Errorf("unexpected type for db_schema_name: %s", record.Column(1).DataType())
+		}
+		tableNames, ok := record.Column(2).(*array.String)
+		if !ok {
+			return fmt.Errorf("unexpected type for table_name: %s", record.Column(2).DataType())
+		}
+		var schemas *array.Binary
+		if opts.IncludeSchema {
+			if schemas, ok = record.Column(4).(*array.Binary); !ok {
+				return fmt.Errorf("unexpected type for table_schema: %s", record.Column(4).DataType())
+			}
+		}
+
+		for i := 0; i < int(record.NumRows()); i++ {
+			var serializedSchema []byte
+			if schemas != nil {
+				serializedSchema = schemas.Value(i)
+			}
+			if err := fn(schemaNames.Value(i), tableNames.Value(i), serializedSchema); err != nil {
+				return err
+			}
+		}
+	}
+	if err := reader.Err(); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	return nil
+}
+
+// columnKind returns the kind of the column. InfluxDB 3 describes it in the field metadata;
+// for other FlightSQL servers it is derived from the column name and type.
+func columnKind(field arrow.Field) string {
+	if columnType, ok := field.Metadata.GetValue(ioxColumnTypeKey); ok {
+		switch {
+		case strings.HasSuffix(columnType, "::timestamp"):
+			return columnKindTime
+		case strings.HasSuffix(columnType, "::tag"):
+			return columnKindTag
+		default:
+			return columnKindField
+		}
+	}
+
+	if field.Name == "time" || field.Type.ID() == arrow.TIMESTAMP {
+		return columnKindTime
+	}
+	// Tags are stored as dictionary encoded strings
+	if dict, ok := field.Type.(*arrow.DictionaryType); ok && dict.ValueType.ID() == arrow.STRING {
+		return columnKindTag
+	}
+	return columnKindField
+}
+
+// quoteIdentifier quotes a SQL identifier, escaping the double quotes it contains.
+func quoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func parseTimeRange(values url.Values) (backend.TimeRange, error) {
+	var timeRange backend.TimeRange
+	if from := values.Get("from"); from != "" {
+		ms, err := strconv.ParseInt(from, 10, 64)
+		if err != nil {
+			return timeRange, fmt.Errorf("invalid from: %q", from)
+		}
+		timeRange.From = time.UnixMilli(ms)
+	}
+	if to := values.Get("to"); to != "" {
+		ms, err := strconv.ParseInt(to, 10, 64)
+		if err != nil {
+			return timeRange, fmt.Errorf("invalid to: %q", to)
+		}
+		timeRange.To = time.UnixMilli(ms)
+	}
+	return timeRange, nil
+}
+
+func writeResourceResponse(ctx context.Context, rw http.ResponseWriter, res any, err error) {
+	if err != nil {
+		glog.FromContext(ctx).Warn("An error occurred while doing a resource call", "err", err)
+		statusCode := http.StatusInternalServerError
+		if grpcStatusErr, ok := status.FromError(err); ok {
+			switch grpcStatusErr.Code() {
+			case codes.InvalidArgument:
+				statusCode = http.StatusBadRequest
+			case codes.PermissionDenied, codes.Unauthenticated:
+				statusCode = http.StatusForbidden
+			case codes.NotFound:
+				statusCode = http.StatusNotFound
+			case codes.Unavailable:
+				statusCode = http.StatusServiceUnavailable
+			}
+		} else if errors.Is(err, errTableNotFound) {
+			statusCode = http.StatusNotFound
+		} else if backend.IsDownstreamError(err) {
+			statusCode = http.StatusBadRequest
+		}
+		http.Error(rw, err.Error(), statusCode)
+		return
+	}
+
+	b, err := json.Marshal(res)
+	if err != nil {
+		glog.FromContext(ctx).Warn("An error occurred while processing response from resource call", "err", err)
+		http.Error(rw, "An error occurred within the plugin", http.StatusInternalServerError)
+		return
+	}
+	rw.Header().Set("Content-Type", "application/json")
+	_, _ = rw.Write(b)
+}
@@ -0,0 +1,123 @@
+package fsql
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/proxy"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/tsdb/influxdb/models"
+)
+
+func (suite *FSQLTestSuite) dsInfo() *models.DatasourceInfo {
+	return &models.DatasourceInfo{
+		Token:        "secret",
+		URL:          "http://" + suite.addr,
+		DbName:       "influxdb",
+		InsecureGrpc: true,
+		ProxyClient:  proxy.New(nil),
+	}
+}
+
+func (suite *FSQLTestSuite) TestIntegration_Tables() {
+	tables, err := Tables(context.Background(), suite.dsInfo())
+	require.NoError(suite.T(), err)
+	require.Contains(suite.T(), tables, "intTable")
+	require.Contains(suite.T(), tables, "foreignTable")
+}
+
+func (suite *FSQLTestSuite) TestIntegration_Columns() {
+	suite.Run("should return the columns of the table", func() {
+		columns, err := Columns(context.Background(), suite.dsInfo(), "intTable")
+		require.NoError(suite.T(), err)
+		names := make([]string, 0, len(columns))
+		for _, c := range columns {
+			names = append(names, c.Name)
+		}
+		require.Equal(suite.T(), []string{"id", "keyName", "value", "foreignId"}, names)
+	})
+
+	suite.Run("should return an error for an unknown table", func() {
+		_, err := Columns(context.Background(), suite.dsInfo(), "unknown")
+		require.ErrorIs(suite.T(), err, errTableNotFound)
+	})
+}
+
+func (suite *FSQLTestSuite) TestIntegration_TagValues() {
+	values, err := TagValues(context.Background(), suite.dsInfo(), "intTable", "keyName", backend.TimeRange{})
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), []string{"negative one", "one", "zero"}, values)
+}
+
+func TestDatabases(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/api/v3/configure/database", r.URL.Path)
+		require.Equal(t, "json", r.URL.Query().Get("format"))
+		require.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		_, _ = w.Write([]byte(`[{"iox::database":"metrics"},{"iox::database":"_internal"},{"iox::database":"logs"}]`))
+	}))
+	defer server.Close()
+
+	databases, err := Databases(context.Background(), &models.DatasourceInfo{
+		HTTPClient: server.Client(),
+		URL:        server.URL,
+		Token:      "secret",
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"logs", "metrics"}, databases)
+}
+
+func TestColumnKind(t *testing.T) {
+	tests := []struct {
+		name     string
+		field    arrow.Field
+		expected string
+	}{
+		{
+			name:     "influxdb 3 tag",
+			field:    arrow.Field{Name: "host", Type: arrow.BinaryTypes.String, Metadata: arrow.NewMetadata([]string{ioxColumnTypeKey}, []string{"iox::column_type::tag"})},
+			expected: columnKindTag,
+		},
+		{
+			name:     "influxdb 3 field",
+			field:    arrow.Field{Name: "usage", Type: arrow.PrimitiveTypes.Float64, Metadata: arrow.NewMetadata([]string{ioxColumnTypeKey}, []string{"iox::column_type::field::float"})},
+			expected: columnKindField,
+		},
+		{
+			name:     "influxdb 3 time",
+			field:    arrow.Field{Name: "time", Type: arrow.FixedWidthTypes.Timestamp_ns, Metadata: arrow.NewMetadata([]string{ioxColumnTypeKey}, []string{"iox::column_type::timestamp"})},
+			expected: columnKindTime,
+		},
+		{
+			name:     "dictionary encoded string",
+			field:    arrow.Field{Name: "host", Type: &arrow.DictionaryType{IndexType: arrow.PrimitiveTypes.Int32, ValueType: arrow.BinaryTypes.String}},
+			expected: columnKindTag,
+		},
+		{
+			name:     "timestamp",
+			field:    arrow.Field{Name: "ts", Type: arrow.FixedWidthTypes.Timestamp_ms},
+			expected: columnKindTime,
+		},
+		{
+			name:     "number",
+			field:    arrow.Field{Name: "value", Type: arrow.PrimitiveTypes.Int64},
+			expected: columnKindField,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, columnKind(tt.field))
+		})
+	}
+}
+
+func TestQuoteIdentifier(t *testing.T) {
+	require.Equal(t, `"cpu"`, quoteIdentifier("cpu"))
+	require.Equal(t, `"my ""table"""`, quoteIdentifier(`my "table"`))
+}
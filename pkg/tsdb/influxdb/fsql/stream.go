@@ -0,0 +1,152 @@
+package fsql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana/pkg/tsdb/influxdb/models"
+)
+
+const (
+	// streamPathPrefix is the prefix of the channel path of live queries: live/${key}
+	streamPathPrefix = "live/"
+
+	defaultStreamInterval = 10 * time.Second
+	minStreamInterval     = time.Second
+
+	// streamInitialLookback is the time range covered by the first execution of a live query
+	streamInitialLookback = 5 * time.Minute
+)
+
+// streamRequest is a live query, sent as the data of the stream subscription.
+type streamRequest struct {
+	queryRequest
+	// StreamIntervalMs is the interval at which the query is executed
+	StreamIntervalMs int64 `json:"streamIntervalMs"`
+}
+
+func parseStreamRequest(raw json.RawMessage) (*streamRequest, error) {
+	var req streamRequest
+	if err := json.Unmarshal(raw, &req); err != nil {
+		return nil, fmt.Errorf("unmarshal json: %w", err)
+	}
+	if req.RawQuery == "" {
+		return nil, fmt.Errorf("missing rawSql in channel")
+	}
+	return &req, nil
+}
+
+func (req *streamRequest) interval() time.Duration {
+	interval := time.Duration(req.StreamIntervalMs) * time.Millisecond
+	if interval == 0 {
+		return defaultStreamInterval
+	}
+	if interval < minStreamInterval {
+		return minStreamInterval
+	}
+	return interval
+}
+
+// SubscribeStream validates the subscription to a live query.
+func SubscribeStream(_ context.Context, req *backend.SubscribeStreamRequest) (*backend.SubscribeStreamResponse, error) {
+	if !strings.HasPrefix(req.Path, streamPathPrefix) {
+		return &backend.SubscribeStreamResponse{
+			Status: backend.SubscribeStreamStatusNotFound,
+		}, fmt.Errorf("expected live in channel path")
+	}
+
+	if _, err := parseStreamRequest(req.Data); err != nil {
+		return &backend.SubscribeStreamResponse{
+			Status: backend.SubscribeStreamStatusNotFound,
+		}, err
+	}
+
+	return &backend.SubscribeStreamResponse{
+		Status: backend.SubscribeStreamStatusOK,
+	}, nil
+}
+
+// RunStream executes a live query periodically. Each execution covers the time range since the previous
+// one, so queries using the time macros only return the rows added in between.
+// A single instance runs for each channel, the results are shared with all listeners.
+func RunStream(ctx context.Context, dsInfo *models.DatasourceInfo, req *backend.RunStreamRequest, sender *backend.StreamSender) error {
+	logger := glog.FromContext(ctx)
+	streamReq, err := parseStreamRequest(req.Data)
+	if err != nil {
+		return err
+	}
+
+	return withRunner(ctx, dsInfo, func(ctx context.Context, r *runner) error {
+		ticker := time.NewTicker(streamReq.interval())
+		defer ticker.Stop()
+
+		prev := data.FrameJSONCache{}
+		from := time.Now().Add(-streamInitialLookback)
+		for {
+			to := time.Now()
+			frames, err := r.runStreamQuery(ctx, req.Data, backend.TimeRange{From: from, To: to})
+			if err != nil {
+				logger.Warn("Failed to execute live query", "path", req.Path, "err", err)
+			} else {
+				from = to
+			}
+
+			for _, frame := range frames {
+				next, err := data.FrameToJSONCache(frame)
+				if err != nil {
+					return err
+				}
+				if next.SameSchema(&prev) {
+					err = sender.SendBytes(next.Bytes(data.IncludeDataOnly))
+				} else {
+					err = sender.SendFrame(frame, data.IncludeAll)
+				}
+				if err != nil {
+					return err
+				}
+				prev = next
+			}
+
+			select {
+			case <-ctx.Done():
+				logger.Debug("Stop streaming (context canceled)", "path", req.Path)
+				return nil
+			case <-ticker.C:
+			}
+		}
+	})
+}
+
+// runStreamQuery executes the live query over the given time range.
+func (r *runner) runStreamQuery(ctx context.Context, raw json.RawMessage, timeRange backend.TimeRange) (data.Frames, error) {
+	qm, err := getQueryModel(backend.DataQuery{
+		JSON:      raw,
+		TimeRange: timeRange,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	reader, err := r.execute(ctx, qm.RawSQL)
+	if err != nil {
+		return nil, fmt.Errorf("flightsql: %w", err)
+	}
+	defer reader.Release()
+
+	headers, err := reader.Header()
+	if err != nil {
+		glog.FromContext(ctx).Error(fmt.Sprintf("Failed to extract headers: %s", err))
+	}
+
+	resp := newQueryDataResponse(reader, *qm.Query, headers)
+	if resp.Error != nil {
+		return nil, resp.Error
+	}
+	return resp.Frames, nil
+}
@@ -0,0 +1,62 @@
+package fsql
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubscribeStream(t *testing.T) {
+	tests := []struct {
+		name           string
+		path           string
+		data           string
+		expectedStatus backend.SubscribeStreamStatus
+		expectError    bool
+	}{
+		{
+			name:           "valid live query",
+			path:           "live/abc",
+			data:           `{"rawSql":"SELECT * FROM cpu WHERE $__timeFilter(time)"}`,
+			expectedStatus: backend.SubscribeStreamStatusOK,
+		},
+		{
+			name:           "unknown path",
+			path:           "tail/abc",
+			data:           `{"rawSql":"SELECT * FROM cpu"}`,
+			expectedStatus: backend.SubscribeStreamStatusNotFound,
+			expectError:    true,
+		},
+		{
+			name:           "missing query",
+			path:           "live/abc",
+			data:           `{}`,
+			expectedStatus: backend.SubscribeStreamStatusNotFound,
+			expectError:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := SubscribeStream(context.Background(), &backend.SubscribeStreamRequest{
+				Path: tt.path,
+				Data: []byte(tt.data),
+			})
+			if tt.expectError {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, tt.expectedStatus, res.Status)
+		})
+	}
+}
+
+func TestStreamRequestInterval(t *testing.T) {
+	require.Equal(t, defaultStreamInterval, (&streamRequest{}).interval())
+	require.Equal(t, minStreamInterval, (&streamRequest{StreamIntervalMs: 10}).interval())
+	require.Equal(t, 30*time.Second, (&streamRequest{StreamIntervalMs: 30000}).interval())
+}
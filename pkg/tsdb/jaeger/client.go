@@ -6,7 +6,9 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"strings"
 
+	"github.com/go-logfmt/logfmt"
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
 )
@@ -228,3 +230,111 @@ func (j *JaegerClient) Dependencies(ctx context.Context, start, end int64) (Depe
 
 	return dependencies, nil
 }
+
+// Search returns the traces matching the given search query. start and end are in milliseconds.
+func (j *JaegerClient) Search(ctx context.Context, query *jaegerQuery, start, end int64) ([]TraceResponse, error) {
+	logger := j.logger.FromContext(ctx)
+	var response TracesResponse
+	traces := []TraceResponse{}
+
+	if query.Service == "" {
+		return traces, backend.DownstreamError(fmt.Errorf("service is required for searching traces"))
+	}
+
+	u, err := url.JoinPath(j.url, "/api/traces")
+	if err != nil {
+		return traces, backend.DownstreamError(fmt.Errorf("failed to join url: %w", err))
+	}
+
+	parsedURL, err := url.Parse(u)
+	if err != nil {
+		return traces, backend.DownstreamError(fmt.Errorf("failed to parse url: %w", err))
+	}
+
+	params := parsedURL.Query()
+	params.Set("service", query.Service)
+	if query.Operation != "" {
+		params.Set("operation", query.Operation)
+	}
+	if query.Tags != "" {
+		tags, err := convertTagsLogfmt(query.Tags)
+		if err != nil {
+			return traces, backend.DownstreamError(fmt.Errorf("failed to parse tags: %w", err))
+		}
+		params.Set("tags", tags)
+	}
+	if query.MinDuration != "" {
+		params.Set("minDuration", query.MinDuration)
+	}
+	if query.MaxDuration != "" {
+		params.Set("maxDuration", query.MaxDuration)
+	}
+	if query.Limit > 0 {
+		params.Set("limit", fmt.Sprintf("%d", query.Limit))
+	}
+	// The search API expects the time range in microseconds
+	if start > 0 {
+		params.Set("start", fmt.Sprintf("%d", start*1000))
+	}
+	if end > 0 {
+		params.Set("end", fmt.Sprintf("%d", end*1000))
+	}
+	parsedURL.RawQuery = params.Encode()
+
+	res, err := j.httpClient.Get(parsedURL.String())
+	if err != nil {
+		if backend.IsDownstreamHTTPError(err) {
+			return traces, backend.DownstreamError(err)
+		}
+		return traces, err
+	}
+
+	defer func() {
+		if err = res.Body.Close(); err != nil {
+			logger.Error("Failed to close response body", "error", err)
+		}
+	}()
+
+	if res != nil && res.StatusCode/100 != 2 {
+		err := backend.DownstreamError(fmt.Errorf("request failed: %s", res.Status))
+		if backend.ErrorSourceFromHTTPStatus(res.StatusCode) == backend.ErrorSourceDownstream {
+			return traces, backend.DownstreamError(err)
+		}
+		return traces, err
+	}
+
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		return traces, err
+	}
+
+	if response.Data != nil {
+		traces = response.Data
+	}
+	return traces, nil
+}
+
+// convertTagsLogfmt converts the logfmt formatted tags used in the query editor
+// into the JSON object expected by the Jaeger search API.
+func convertTagsLogfmt(tags string) (string, error) {
+	result := map[string]string{}
+	decoder := logfmt.NewDecoder(strings.NewReader(tags))
+	for decoder.ScanRecord() {
+		for decoder.ScanKeyval() {
+			// Keys without a value are treated as boolean flags, the same way the frontend does
+			value := "true"
+			if decoder.Value() != nil {
+				value = string(decoder.Value())
+			}
+			result[string(decoder.Key())] = value
+		}
+	}
+	if err := decoder.Err(); err != nil {
+		return "", err
+	}
+
+	b, err := json.Marshal(result)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
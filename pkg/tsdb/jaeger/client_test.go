@@ -385,3 +385,76 @@ func TestJaegerClient_Dependencies(t *testing.T) {
 		})
 	}
 }
+
+func TestJaegerClient_Search(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          jaegerQuery
+		mockResponse   string
+		mockStatusCode int
+		expectedURL    string
+		expectedTraces int
+		expectError    bool
+	}{
+		{
+			name: "Successful response with all parameters",
+			query: jaegerQuery{
+				Service:     "frontend",
+				Operation:   "GET /api",
+				Tags:        `http.status_code=500 error`,
+				MinDuration: "100ms",
+				MaxDuration: "1s",
+				Limit:       50,
+			},
+			mockResponse:   `{"data":[{"traceID":"abc123"},{"traceID":"def456"}]}`,
+			mockStatusCode: http.StatusOK,
+			expectedURL:    "/api/traces?end=2000000&limit=50&maxDuration=1s&minDuration=100ms&operation=GET+%2Fapi&service=frontend&start=1000000&tags=%7B%22error%22%3A%22true%22%2C%22http.status_code%22%3A%22500%22%7D",
+			expectedTraces: 2,
+		},
+		{
+			name:           "Successful response with only service",
+			query:          jaegerQuery{Service: "frontend"},
+			mockResponse:   `{"data":null}`,
+			mockStatusCode: http.StatusOK,
+			expectedURL:    "/api/traces?end=2000000&service=frontend&start=1000000",
+			expectedTraces: 0,
+		},
+		{
+			name:           "Non-200 response",
+			query:          jaegerQuery{Service: "frontend"},
+			mockStatusCode: http.StatusInternalServerError,
+			expectedURL:    "/api/traces?end=2000000&service=frontend&start=1000000",
+			expectError:    true,
+		},
+		{
+			name:        "Missing service",
+			query:       jaegerQuery{},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var actualURL string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				actualURL = r.URL.String()
+				w.WriteHeader(tt.mockStatusCode)
+				_, _ = w.Write([]byte(tt.mockResponse))
+			}))
+			defer server.Close()
+
+			client, err := New(server.URL, server.Client(), log.NewNullLogger(), false)
+			assert.NoError(t, err)
+
+			traces, err := client.Search(context.Background(), &tt.query, 1000, 2000)
+
+			if tt.expectError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Len(t, traces, tt.expectedTraces)
+			}
+			assert.Equal(t, tt.expectedURL, actualURL)
+		})
+	}
+}
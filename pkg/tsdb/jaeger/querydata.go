@@ -8,6 +8,8 @@ import (
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana/pkg/tsdb/spanmetrics"
 )
 
 type jaegerQuery struct {
@@ -19,6 +21,7 @@ type jaegerQuery struct {
 	MinDuration string `json:"minDuration"`
 	MaxDuration string `json:"maxDuration"`
 	Limit       int    `json:"limit"`
+	SpanMetric  string `json:"spanMetric"`
 }
 
 func queryData(ctx context.Context, dsInfo *datasourceInfo, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
@@ -63,6 +66,29 @@ func queryData(ctx context.Context, dsInfo *datasourceInfo, req *backend.QueryDa
 				Frames: frames,
 			}
 		}
+
+		if query.QueryType == queryTypeSpanMetrics {
+			if err := spanmetrics.ValidateMetric(query.SpanMetric); err != nil {
+				response.Responses[q.RefID] = backend.ErrorResponseWithErrorSource(err)
+				continue
+			}
+			if query.Limit == 0 {
+				query.Limit = spanmetrics.DefaultLimit
+			}
+			traces, err := dsInfo.JaegerClient.Search(ctx, &query, q.TimeRange.From.UnixMilli(), q.TimeRange.To.UnixMilli())
+			if err != nil {
+				response.Responses[q.RefID] = backend.ErrorResponseWithErrorSource(err)
+				continue
+			}
+			frames, err := transformSpanMetricsResponse(traces, q.RefID, q.TimeRange, query.Limit, query.SpanMetric)
+			if err != nil {
+				response.Responses[q.RefID] = backend.ErrorResponseWithErrorSource(err)
+				continue
+			}
+			response.Responses[q.RefID] = backend.DataResponse{
+				Frames: frames,
+			}
+		}
 	}
 
 	return response, nil
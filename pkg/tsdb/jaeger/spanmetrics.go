@@ -0,0 +1,81 @@
+package jaeger
+
+import (
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana/pkg/tsdb/spanmetrics"
+)
+
+const queryTypeSpanMetrics = "spanMetrics"
+
+// transformSpanMetricsResponse aggregates the spans of the searched traces into RED metrics
+// per service and operation, see spanmetrics.Frames.
+func transformSpanMetricsResponse(traces []TraceResponse, refID string, timeRange backend.TimeRange, limit int, spanMetric string) ([]*data.Frame, error) {
+	spans := make([][]spanmetrics.Span, 0, len(traces))
+	for _, trace := range traces {
+		// Span IDs are only unique within a trace
+		serviceBySpanID := make(map[string]string, len(trace.Spans))
+		for _, span := range trace.Spans {
+			serviceBySpanID[span.SpanID] = trace.Processes[span.ProcessID].ServiceName
+		}
+
+		traceSpans := make([]spanmetrics.Span, 0, len(trace.Spans))
+		for _, span := range trace.Spans {
+			traceSpans = append(traceSpans, spanmetrics.Span{
+				ServiceName:   serviceBySpanID[span.SpanID],
+				OperationName: span.OperationName,
+				// Times are in microseconds
+				StartTime:         time.UnixMicro(span.StartTime),
+				Duration:          time.Duration(span.Duration) * time.Microsecond,
+				Error:             isErrorSpan(span),
+				ParentServiceName: parentServiceName(span, serviceBySpanID),
+			})
+		}
+		spans = append(spans, traceSpans)
+	}
+
+	return spanmetrics.Frames(spans, spanmetrics.Query{
+		RefID:     refID,
+		TimeRange: timeRange,
+		Limit:     limit,
+		Metric:    spanMetric,
+	})
+}
+
+// parentServiceName returns the service of the CHILD_OF parent of the span
+func parentServiceName(span Span, serviceBySpanID map[string]string) string {
+	for _, ref := range span.References {
+		if ref.RefType == "CHILD_OF" {
+			return serviceBySpanID[ref.SpanID]
+		}
+	}
+	return ""
+}
+
+// isErrorSpan reports whether the span is marked as failed, either with the OpenTracing
+// `error` tag or with the OpenTelemetry status code.
+func isErrorSpan(span Span) bool {
+	for _, tag := range span.Tags {
+		switch tag.Key {
+		case "error":
+			switch v := tag.Value.(type) {
+			case bool:
+				if v {
+					return true
+				}
+			case string:
+				if v == "true" {
+					return true
+				}
+			}
+		case "otel.status_code":
+			if v, ok := tag.Value.(string); ok && v == "ERROR" {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,89 @@
+package jaeger
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/experimental"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransformSpanMetricsResponse(t *testing.T) {
+	timeRange := backend.TimeRange{
+		From: time.UnixMilli(1605873894000),
+		To:   time.UnixMilli(1605873894000).Add(10 * time.Second),
+	}
+	traces := []TraceResponse{
+		{
+			TraceID: "trace1",
+			Spans: []Span{
+				{TraceID: "trace1", SpanID: "a", ProcessID: "p1", OperationName: "GET /api", Duration: 100000},
+				{
+					TraceID: "trace1", SpanID: "b", ProcessID: "p2", OperationName: "query", Duration: 40000,
+					References: []TraceSpanReference{{RefType: "CHILD_OF", TraceID: "trace1", SpanID: "a"}},
+				},
+			},
+			Processes: map[string]TraceProcess{
+				"p1": {ServiceName: "frontend"},
+				"p2": {ServiceName: "database"},
+			},
+		},
+		{
+			TraceID: "trace2",
+			Spans: []Span{
+				{
+					TraceID: "trace2", SpanID: "a", ProcessID: "p1", OperationName: "GET /api", Duration: 300000,
+					Tags: []TraceKeyValuePair{{Key: "error", Type: "bool", Value: true}},
+				},
+				{
+					TraceID: "trace2", SpanID: "b", ProcessID: "p2", OperationName: "query", Duration: 20000,
+					References: []TraceSpanReference{{RefType: "CHILD_OF", TraceID: "trace2", SpanID: "a"}},
+					Tags:       []TraceKeyValuePair{{Key: "otel.status_code", Type: "string", Value: "ERROR"}},
+				},
+				{
+					TraceID: "trace2", SpanID: "c", ProcessID: "p1", OperationName: "render", Duration: 5000,
+					References: []TraceSpanReference{{RefType: "CHILD_OF", TraceID: "trace2", SpanID: "a"}},
+				},
+			},
+			Processes: map[string]TraceProcess{
+				"p1": {ServiceName: "frontend"},
+				"p2": {ServiceName: "database"},
+			},
+		},
+	}
+
+	t.Run("all_metrics", func(t *testing.T) {
+		frames, err := transformSpanMetricsResponse(traces, "test", timeRange, 1000, "")
+		require.NoError(t, err)
+		require.Len(t, frames, 3)
+		experimental.CheckGoldenJSONFrame(t, "./testdata", "span_metrics.golden", frames[0], false)
+		experimental.CheckGoldenJSONFrame(t, "./testdata", "span_metrics_nodes.golden", frames[1], false)
+		experimental.CheckGoldenJSONFrame(t, "./testdata", "span_metrics_edges.golden", frames[2], false)
+	})
+
+	t.Run("single_metric", func(t *testing.T) {
+		frames, err := transformSpanMetricsResponse(traces, "test", timeRange, 1000, "p99")
+		require.NoError(t, err)
+		require.Len(t, frames, 1)
+		require.Len(t, frames[0].Fields, 3)
+		assert.Equal(t, "p99", frames[0].Fields[2].Name)
+		assert.Equal(t, []any{"database", "query", 40.0}, []any{frames[0].At(0, 0), frames[0].At(1, 0), frames[0].At(2, 0)})
+	})
+
+	t.Run("invalid_metric", func(t *testing.T) {
+		_, err := transformSpanMetricsResponse(traces, "test", timeRange, 1000, "unknown")
+		require.Error(t, err)
+		assert.True(t, backend.IsDownstreamError(err))
+	})
+
+	t.Run("empty_response", func(t *testing.T) {
+		frames, err := transformSpanMetricsResponse([]TraceResponse{}, "test", timeRange, 1000, "")
+		require.NoError(t, err)
+		require.Len(t, frames, 3)
+		for _, frame := range frames {
+			assert.Equal(t, 0, frame.Rows())
+		}
+	})
+}
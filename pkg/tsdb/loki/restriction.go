@@ -0,0 +1,165 @@
+package loki
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/grafana/loki/v3/pkg/logql/syntax"
+	"github.com/prometheus/prometheus/model/labels"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/queryrestriction"
+	"github.com/grafana/grafana/pkg/tsdb/loki/kinds/dataquery"
+)
+
+// queryRestrictor restricts the queries to the stream selectors of the restrictions
+type queryRestrictor struct{}
+
+func (queryRestrictor) RestrictQuery(query *simplejson.Json, restrictions []queryrestriction.Restriction) error {
+	if err := queryrestriction.UnsupportedKind(restrictions, datasources.DS_LOKI, queryrestriction.KindStreamSelector); err != nil {
+		return err
+	}
+	expr, err := applyStreamSelectors(query.Get("expr").MustString(), queryrestriction.Patterns(restrictions, queryrestriction.KindStreamSelector))
+	if err != nil {
+		return err
+	}
+	query.Set("expr", expr)
+	return nil
+}
+
+// applyStreamSelectors restricts the raw expression to the log streams matching the stream selectors.
+// With a single stream selector its matchers are added to every stream selector of the expression. With several
+// stream selectors, which can't be combined in a stream selector, every stream selector of the expression must
+// already contain the matchers of one of them.
+func applyStreamSelectors(rawExpr string, streamSelectors []string) (string, error) {
+	allowed := make([][]*labels.Matcher, 0, len(streamSelectors))
+	for _, selector := range streamSelectors {
+		matchers, err := syntax.ParseMatchers(selector, false)
+		if err != nil {
+			return "", fmt.Errorf("invalid stream selector %s: %w", selector, err)
+		}
+		allowed = append(allowed, matchers)
+	}
+	if len(allowed) == 0 {
+		return rawExpr, nil
+	}
+
+	spans, err := streamSelectorSpans(rawExpr)
+	if err != nil {
+		return "", err
+	}
+
+	// the expression is rewritten from the end, to keep the positions of the previous selectors
+	expr := rawExpr
+	for i := len(spans) - 1; i >= 0; i-- {
+		start, end := spans[i][0], spans[i][1]
+		selector := rawExpr[start : end+1]
+		matchers, err := syntax.ParseMatchers(selector, false)
+		if err != nil {
+			return "", fmt.Errorf("invalid stream selector %s: %w", selector, err)
+		}
+		if containsOneOf(matchers, allowed) {
+			continue
+		}
+		if len(allowed) > 1 {
+			return "", fmt.Errorf("the stream selector %s must match one of %s", selector, strings.Join(streamSelectors, ", "))
+		}
+
+		inner := strings.TrimSuffix(strings.TrimSpace(selector[1:len(selector)-1]), ",")
+		added := make([]string, 0, len(allowed[0]))
+		for _, m := range allowed[0] {
+			added = append(added, m.String())
+		}
+		if inner != "" {
+			inner += ", "
+		}
+		expr = expr[:start] + "{" + inner + strings.Join(added, ", ") + "}" + expr[end+1:]
+	}
+
+	// the rewritten expression is parsed to make sure that every stream selector is restricted
+	parsed, err := syntax.ParseExpr(interpolateVariables(expr, time.Minute, time.Hour, dataquery.LokiQueryTypeRange, time.Minute))
+	if err != nil {
+		return "", err
+	}
+	var restrictionErr error
+	selectors := 0
+	parsed.Walk(func(e syntax.Expr) {
+		if m, ok := e.(*syntax.MatchersExpr); ok {
+			selectors++
+			if !containsOneOf(m.Mts, allowed) {
+				restrictionErr = fmt.Errorf("the stream selector %s must match one of %s", m.String(), strings.Join(streamSelectors, ", "))
+			}
+		}
+	})
+	if restrictionErr != nil {
+		return "", restrictionErr
+	}
+	if selectors != len(spans) {
+		return "", errors.New("unexpected stream selectors in the query")
+	}
+	return expr, nil
+}
+
+// streamSelectorSpans returns the position of the opening and the closing brace of the stream selectors
+func streamSelectorSpans(expr string) ([][2]int, error) {
+	spans := [][2]int{}
+	start := -1
+	for i := 0; i < len(expr); i++ {
+		switch c := expr[i]; c {
+		case '"', '`':
+			// skip the strings, they may contain braces
+			for i++; i < len(expr) && expr[i] != c; i++ {
+				if c == '"' && expr[i] == '\\' {
+					i++
+				}
+			}
+			if i >= len(expr) {
+				return nil, errors.New("unterminated string in the query")
+			}
+		case '#':
+			// skip the comments
+			for i < len(expr) && expr[i] != '\n' {
+				i++
+			}
+		case '{':
+			if start >= 0 {
+				return nil, errors.New("unexpected { in a stream selector")
+			}
+			start = i
+		case '}':
+			if start < 0 {
+				return nil, errors.New("unexpected } outside of a stream selector")
+			}
+			spans = append(spans, [2]int{start, i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		return nil, errors.New("unterminated stream selector in the query")
+	}
+	return spans, nil
+}
+
+// containsOneOf returns whether the matchers contain all the matchers of one of the allowed matchers
+func containsOneOf(matchers []*labels.Matcher, allowed [][]*labels.Matcher) bool {
+	existing := make(map[string]bool, len(matchers))
+	for _, m := range matchers {
+		existing[m.String()] = true
+	}
+	for _, a := range allowed {
+		found := true
+		for _, m := range a {
+			if !existing[m.String()] {
+				found = false
+				break
+			}
+		}
+		if found {
+			return true
+		}
+	}
+	return false
+}
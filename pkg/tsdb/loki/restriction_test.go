@@ -0,0 +1,100 @@
+package loki
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/queryrestriction"
+)
+
+func TestApplyStreamSelectors(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		selectors []string
+		expected  string
+		expectErr bool
+	}{
+		{
+			name:      "add the matchers to the stream selector",
+			query:     `{app="api"} |= "error"`,
+			selectors: []string{`{namespace="team-a"}`},
+			expected:  `{app="api", namespace="team-a"} |= "error"`,
+		},
+		{
+			name:      "add the matchers to every stream selector of a metric query",
+			query:     `sum by (app) (rate({app="api"} | json | line_format "{{.msg}}" [$__auto])) / sum(count_over_time({app=~"web|api"}[$__interval]))`,
+			selectors: []string{`{namespace="team-a", cluster="prod"}`},
+			expected:  `sum by (app) (rate({app="api", namespace="team-a", cluster="prod"} | json | line_format "{{.msg}}" [$__auto])) / sum(count_over_time({app=~"web|api", namespace="team-a", cluster="prod"}[$__interval]))`,
+		},
+		{
+			name:      "keep the stream selectors already restricted",
+			query:     `{namespace="team-a", app="api"}`,
+			selectors: []string{`{namespace="team-a"}`},
+			expected:  `{namespace="team-a", app="api"}`,
+		},
+		{
+			name:      "restrict an empty stream selector",
+			query:     `{}`,
+			selectors: []string{`{namespace="team-a"}`},
+			expected:  `{namespace="team-a"}`,
+		},
+		{
+			name:      "ignore the braces of the strings and the comments",
+			query:     "{app=\"api\"} |~ `{.*}` # {namespace=\"team-b\"}",
+			selectors: []string{`{namespace="team-a"}`},
+			expected:  "{app=\"api\", namespace=\"team-a\"} |~ `{.*}` # {namespace=\"team-b\"}",
+		},
+		{
+			name:      "accept the stream selectors matching one of several stream selectors",
+			query:     `count_over_time({namespace="team-b", app="api"}[5m]) or count_over_time({namespace="team-a"}[5m])`,
+			selectors: []string{`{namespace="team-a"}`, `{namespace="team-b"}`},
+			expected:  `count_over_time({namespace="team-b", app="api"}[5m]) or count_over_time({namespace="team-a"}[5m])`,
+		},
+		{
+			name:      "reject the stream selectors not matching one of several stream selectors",
+			query:     `{app="api"}`,
+			selectors: []string{`{namespace="team-a"}`, `{namespace="team-b"}`},
+			expectErr: true,
+		},
+		{
+			name:      "reject an invalid query",
+			query:     `{app="api"`,
+			selectors: []string{`{namespace="team-a"}`},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := applyStreamSelectors(tt.query, tt.selectors)
+			if tt.expectErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, expr)
+		})
+	}
+}
+
+func TestQueryRestrictor(t *testing.T) {
+	t.Run("rewrite the expression of the query", func(t *testing.T) {
+		query := simplejson.NewFromAny(map[string]any{"refId": "A", "expr": `{app="api"} |= "error"`})
+		err := queryRestrictor{}.RestrictQuery(query, []queryrestriction.Restriction{
+			{TeamID: 1, Kind: queryrestriction.KindStreamSelector, Pattern: `{namespace="team-a"}`},
+		})
+		require.NoError(t, err)
+		require.Equal(t, `{app="api", namespace="team-a"} |= "error"`, query.Get("expr").MustString())
+	})
+
+	t.Run("reject the restrictions of other kinds", func(t *testing.T) {
+		query := simplejson.NewFromAny(map[string]any{"refId": "A", "expr": `{app="api"}`})
+		err := queryRestrictor{}.RestrictQuery(query, []queryrestriction.Restriction{
+			{TeamID: 1, Kind: queryrestriction.KindMetricPrefix, Pattern: "team_a_"},
+		})
+		require.Error(t, err)
+	})
+}
@@ -10,6 +10,8 @@ import (
 	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
 
 	"github.com/grafana/grafana/pkg/promlib"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/queryrestriction"
 	"github.com/grafana/grafana/pkg/tsdb/prometheus/azureauth"
 )
 
@@ -17,7 +19,8 @@ type Service struct {
 	lib *promlib.Service
 }
 
-func ProvideService(httpClientProvider *sdkhttpclient.Provider) *Service {
+func ProvideService(httpClientProvider *sdkhttpclient.Provider, queryRestrictionService queryrestriction.Service) *Service {
+	queryRestrictionService.RegisterQueryRestrictor(datasources.DS_PROMETHEUS, queryRestrictor{})
 	plog := backend.NewLoggerWith("logger", "tsdb.prometheus")
 	plog.Debug("Initializing")
 	return &Service{
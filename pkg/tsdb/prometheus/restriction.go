@@ -0,0 +1,18 @@
+package prometheus
+
+import (
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/promlib/models"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/queryrestriction"
+)
+
+// queryRestrictor restricts the queries to the metric prefixes of the restrictions
+type queryRestrictor struct{}
+
+func (queryRestrictor) RestrictQuery(query *simplejson.Json, restrictions []queryrestriction.Restriction) error {
+	if err := queryrestriction.UnsupportedKind(restrictions, datasources.DS_PROMETHEUS, queryrestriction.KindMetricPrefix); err != nil {
+		return err
+	}
+	return models.CheckMetricPrefixes(query.Get("expr").MustString(), queryrestriction.Patterns(restrictions, queryrestriction.KindMetricPrefix))
+}
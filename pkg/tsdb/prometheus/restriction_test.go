@@ -0,0 +1,32 @@
+package prometheus
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/queryrestriction"
+)
+
+func TestQueryRestrictor(t *testing.T) {
+	metricPrefix := []queryrestriction.Restriction{{TeamID: 1, Kind: queryrestriction.KindMetricPrefix, Pattern: "team_a_"}}
+
+	t.Run("allow the queries of the metric prefixes", func(t *testing.T) {
+		query := simplejson.NewFromAny(map[string]any{"refId": "A", "expr": `rate(team_a_requests_total[$__rate_interval])`})
+		require.NoError(t, queryRestrictor{}.RestrictQuery(query, metricPrefix))
+	})
+
+	t.Run("reject the queries of other metrics", func(t *testing.T) {
+		query := simplejson.NewFromAny(map[string]any{"refId": "A", "expr": `node_cpu_seconds_total`})
+		require.Error(t, queryRestrictor{}.RestrictQuery(query, metricPrefix))
+	})
+
+	t.Run("reject the restrictions of other kinds", func(t *testing.T) {
+		query := simplejson.NewFromAny(map[string]any{"refId": "A", "expr": `team_a_up`})
+		err := queryRestrictor{}.RestrictQuery(query, []queryrestriction.Restriction{
+			{TeamID: 1, Kind: queryrestriction.KindStreamSelector, Pattern: `{namespace="team-a"}`},
+		})
+		require.Error(t, err)
+	})
+}
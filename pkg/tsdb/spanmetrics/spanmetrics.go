@@ -0,0 +1,300 @@
+// Package spanmetrics aggregates the spans of the traces returned by the searches of the tracing
+// datasources into RED metrics (request rate, error rate and duration percentiles).
+package spanmetrics
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// DefaultLimit is the number of traces searched when the query does not define a limit.
+// The tracing backends default to a few traces per search, which is too few to compute meaningful metrics.
+const DefaultLimit = 1000
+
+// Fields are the metric fields of the span metrics frame, in the order they are added.
+// A query can select a single one of them with `spanMetric`, which results in a frame
+// with exactly one numeric field that can be used in alert rules.
+var Fields = []string{"requests", "rate", "errors", "errorRate", "p50", "p90", "p99"}
+
+// Span is a span of a searched trace
+type Span struct {
+	ServiceName   string
+	OperationName string
+	StartTime     time.Time
+	Duration      time.Duration
+	Error         bool
+	// ParentServiceName is the service of the parent span, empty for the root spans
+	// and when the parent span is not in the trace
+	ParentServiceName string
+}
+
+// Query is a span metrics query of the traces of a search
+type Query struct {
+	RefID     string
+	TimeRange backend.TimeRange
+	// Limit is the number of traces of the search. A search which returned the limit only
+	// returned a sample of the traces of the time range.
+	Limit int
+	// Metric selects a single metric field, all the fields and the node graph are returned when it is empty
+	Metric string
+}
+
+type key struct {
+	serviceName   string
+	operationName string
+}
+
+type aggregate struct {
+	durations []float64
+	errors    int64
+}
+
+type edgeKey struct {
+	source string
+	target string
+}
+
+type edge struct {
+	calls  int64
+	errors int64
+}
+
+// ValidateMetric checks the metric selected by a query, before searching the traces
+func ValidateMetric(metric string) error {
+	if metric != "" && !slices.Contains(Fields, metric) {
+		return backend.DownstreamError(fmt.Errorf("invalid span metric %q, expected one of %s", metric, strings.Join(Fields, ", ")))
+	}
+	return nil
+}
+
+// Frames aggregates the spans of the traces per service and operation. If the query does not select
+// a metric, the node graph frames of the calls between services are returned as well.
+func Frames(traces [][]Span, query Query) ([]*data.Frame, error) {
+	if err := ValidateMetric(query.Metric); err != nil {
+		return nil, err
+	}
+
+	aggregates := map[key]*aggregate{}
+	edges := map[edgeKey]*edge{}
+	services := map[string]bool{}
+	var start, end time.Time
+
+	for _, trace := range traces {
+		for _, span := range trace {
+			services[span.ServiceName] = true
+			if start.IsZero() || span.StartTime.Before(start) {
+				start = span.StartTime
+			}
+			if spanEnd := span.StartTime.Add(span.Duration); spanEnd.After(end) {
+				end = spanEnd
+			}
+
+			k := key{serviceName: span.ServiceName, operationName: span.OperationName}
+			a, ok := aggregates[k]
+			if !ok {
+				a = &aggregate{}
+				aggregates[k] = a
+			}
+			a.durations = append(a.durations, float64(span.Duration.Microseconds())/1000)
+			if span.Error {
+				a.errors++
+			}
+
+			if span.ParentServiceName == "" || span.ParentServiceName == span.ServiceName {
+				continue
+			}
+			ek := edgeKey{source: span.ParentServiceName, target: span.ServiceName}
+			e, ok := edges[ek]
+			if !ok {
+				e = &edge{}
+				edges[ek] = e
+			}
+			e.calls++
+			if span.Error {
+				e.errors++
+			}
+		}
+	}
+
+	// A search which returned its limit only has a sample of the traces of the time range, the searches returning
+	// the most recent traces first. The rates are computed over the window of the sampled traces instead.
+	window := query.TimeRange
+	sampled := query.Limit > 0 && len(traces) >= query.Limit
+	if sampled && !start.IsZero() {
+		window = backend.TimeRange{From: maxTime(start, window.From), To: minTime(end, window.To)}
+	}
+
+	metricsFrame := newMetricsFrame(query, aggregates, window.Duration())
+	if sampled {
+		metricsFrame.AppendNotices(data.Notice{
+			Severity: data.NoticeSeverityWarning,
+			Text: fmt.Sprintf("The search returned its limit of %d traces, the metrics are computed from a sample of the traces from %s to %s",
+				query.Limit, window.From.UTC().Format(time.RFC3339), window.To.UTC().Format(time.RFC3339)),
+		})
+	}
+	if query.Metric != "" {
+		return []*data.Frame{metricsFrame}, nil
+	}
+
+	nodesFrame, edgesFrame := newNodeGraphFrames(query.RefID, services, edges)
+	return []*data.Frame{metricsFrame, nodesFrame, edgesFrame}, nil
+}
+
+func newMetricsFrame(query Query, aggregates map[key]*aggregate, window time.Duration) *data.Frame {
+	keys := make([]key, 0, len(aggregates))
+	for k := range aggregates {
+		keys = append(keys, k)
+	}
+	// Sort the keys to ensure the returned rows are in a consistent order
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].serviceName != keys[j].serviceName {
+			return keys[i].serviceName < keys[j].serviceName
+		}
+		return keys[i].operationName < keys[j].operationName
+	})
+
+	serviceNames := make([]string, 0, len(keys))
+	operationNames := make([]string, 0, len(keys))
+	requests := make([]int64, 0, len(keys))
+	errors := make([]int64, 0, len(keys))
+	rates := make([]float64, 0, len(keys))
+	errorRates := make([]float64, 0, len(keys))
+	p50s := make([]float64, 0, len(keys))
+	p90s := make([]float64, 0, len(keys))
+	p99s := make([]float64, 0, len(keys))
+
+	windowSeconds := window.Seconds()
+	for _, k := range keys {
+		a := aggregates[k]
+		count := int64(len(a.durations))
+		sort.Float64s(a.durations)
+
+		serviceNames = append(serviceNames, k.serviceName)
+		operationNames = append(operationNames, k.operationName)
+		requests = append(requests, count)
+		errors = append(errors, a.errors)
+
+		rate := 0.0
+		if windowSeconds > 0 {
+			rate = float64(count) / windowSeconds
+		}
+		rates = append(rates, rate)
+		errorRates = append(errorRates, float64(a.errors)/float64(count))
+		p50s = append(p50s, percentile(a.durations, 0.5))
+		p90s = append(p90s, percentile(a.durations, 0.9))
+		p99s = append(p99s, percentile(a.durations, 0.99))
+	}
+
+	fields := map[string]*data.Field{
+		"requests":  data.NewField("requests", nil, requests).SetConfig(&data.FieldConfig{DisplayName: "Requests"}),
+		"rate":      data.NewField("rate", nil, rates).SetConfig(&data.FieldConfig{DisplayName: "Rate", Unit: "reqps"}),
+		"errors":    data.NewField("errors", nil, errors).SetConfig(&data.FieldConfig{DisplayName: "Errors"}),
+		"errorRate": data.NewField("errorRate", nil, errorRates).SetConfig(&data.FieldConfig{DisplayName: "Error rate", Unit: "percentunit"}),
+		"p50":       data.NewField("p50", nil, p50s).SetConfig(&data.FieldConfig{DisplayName: "Duration p50", Unit: "ms"}),
+		"p90":       data.NewField("p90", nil, p90s).SetConfig(&data.FieldConfig{DisplayName: "Duration p90", Unit: "ms"}),
+		"p99":       data.NewField("p99", nil, p99s).SetConfig(&data.FieldConfig{DisplayName: "Duration p99", Unit: "ms"}),
+	}
+
+	frame := data.NewFrame(query.RefID,
+		data.NewField("serviceName", nil, serviceNames),
+		data.NewField("operationName", nil, operationNames),
+	)
+	for _, name := range Fields {
+		if query.Metric == "" || query.Metric == name {
+			frame.Fields = append(frame.Fields, fields[name])
+		}
+	}
+
+	frame.Meta = &data.FrameMeta{
+		PreferredVisualization: "table",
+	}
+	return frame
+}
+
+func newNodeGraphFrames(refID string, services map[string]bool, edges map[edgeKey]*edge) (*data.Frame, *data.Frame) {
+	nodesFrame := data.NewFrame(refID+"_nodes",
+		data.NewField("id", nil, []string{}),
+		data.NewField("title", nil, []string{}),
+	)
+	nodesFrame.Meta = &data.FrameMeta{
+		PreferredVisualization: "nodeGraph",
+	}
+
+	mainStatField := data.NewField("mainstat", nil, []int64{})
+	mainStatField.Config = &data.FieldConfig{
+		DisplayName: "Call count",
+	}
+	secondaryStatField := data.NewField("secondarystat", nil, []int64{})
+	secondaryStatField.Config = &data.FieldConfig{
+		DisplayName: "Error count",
+	}
+	edgesFrame := data.NewFrame(refID+"_edges",
+		data.NewField("id", nil, []string{}),
+		data.NewField("source", nil, []string{}),
+		data.NewField("target", nil, []string{}),
+		mainStatField,
+		secondaryStatField,
+	)
+	edgesFrame.Meta = &data.FrameMeta{
+		PreferredVisualization: "nodeGraph",
+	}
+
+	serviceNames := make([]string, 0, len(services))
+	for service := range services {
+		serviceNames = append(serviceNames, service)
+	}
+	sort.Strings(serviceNames)
+	for _, service := range serviceNames {
+		nodesFrame.AppendRow(service, service)
+	}
+
+	edgeKeys := make([]edgeKey, 0, len(edges))
+	for k := range edges {
+		edgeKeys = append(edgeKeys, k)
+	}
+	sort.Slice(edgeKeys, func(i, j int) bool {
+		if edgeKeys[i].source != edgeKeys[j].source {
+			return edgeKeys[i].source < edgeKeys[j].source
+		}
+		return edgeKeys[i].target < edgeKeys[j].target
+	})
+	for _, k := range edgeKeys {
+		e := edges[k]
+		edgesFrame.AppendRow(k.source+"--"+k.target, k.source, k.target, e.calls, e.errors)
+	}
+
+	return nodesFrame, edgesFrame
+}
+
+// percentile returns the nearest-rank percentile of the sorted values.
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	return sorted[rank]
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,64 @@
+package spanmetrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFrames(t *testing.T) {
+	from := time.UnixMilli(1605873894000)
+	timeRange := backend.TimeRange{From: from, To: from.Add(time.Hour)}
+	traces := [][]Span{
+		{
+			{ServiceName: "frontend", OperationName: "get /api", StartTime: from.Add(50 * time.Minute), Duration: 100 * time.Millisecond},
+			{ServiceName: "database", OperationName: "query", StartTime: from.Add(50 * time.Minute), Duration: 40 * time.Millisecond, Error: true, ParentServiceName: "frontend"},
+		},
+		{
+			{ServiceName: "frontend", OperationName: "get /api", StartTime: from.Add(55 * time.Minute), Duration: 300 * time.Millisecond},
+		},
+	}
+
+	t.Run("time_range_rate", func(t *testing.T) {
+		frames, err := Frames(traces, Query{RefID: "test", TimeRange: timeRange, Limit: DefaultLimit, Metric: "rate"})
+		require.NoError(t, err)
+		require.Len(t, frames, 1)
+		assert.Equal(t, []any{"frontend", "get /api", 2.0 / 3600}, frames[0].RowCopy(1))
+		assert.Nil(t, frames[0].Meta.Notices)
+	})
+
+	t.Run("sampled_rate", func(t *testing.T) {
+		frames, err := Frames(traces, Query{RefID: "test", TimeRange: timeRange, Limit: 2})
+		require.NoError(t, err)
+		require.Len(t, frames, 3)
+
+		// The sampled traces span from 50m to 55m and 300ms
+		window := (5*time.Minute + 300*time.Millisecond).Seconds()
+		rate, idx := frames[0].FieldByName("rate")
+		require.GreaterOrEqual(t, idx, 0)
+		assert.Equal(t, 1/window, rate.At(0))
+		assert.Equal(t, 2/window, rate.At(1))
+
+		require.Len(t, frames[0].Meta.Notices, 1)
+		assert.Equal(t, data.NoticeSeverityWarning, frames[0].Meta.Notices[0].Severity)
+		assert.Contains(t, frames[0].Meta.Notices[0].Text, "limit of 2 traces")
+	})
+
+	t.Run("invalid_metric", func(t *testing.T) {
+		_, err := Frames(traces, Query{RefID: "test", TimeRange: timeRange, Metric: "unknown"})
+		require.Error(t, err)
+		assert.True(t, backend.IsDownstreamError(err))
+	})
+}
+
+func TestPercentile(t *testing.T) {
+	values := []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	assert.Equal(t, 5.0, percentile(values, 0.5))
+	assert.Equal(t, 9.0, percentile(values, 0.9))
+	assert.Equal(t, 10.0, percentile(values, 0.99))
+	assert.Equal(t, 0.0, percentile([]float64{}, 0.5))
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"strconv"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
@@ -156,6 +157,72 @@ func (z *ZipkinClient) Trace(traceId string) ([]model.SpanModel, error) {
 	return trace, err
 }
 
+// SearchTraces returns list of traces matching the given search query. start and end are in milliseconds.
+// https://zipkin.io/zipkin-api/#/default/get_traces
+func (z *ZipkinClient) SearchTraces(query zipkinQuery, start, end int64) ([][]model.SpanModel, error) {
+	traces := [][]model.SpanModel{}
+	if query.ServiceName == "" {
+		return traces, backend.DownstreamError(errors.New("invalid/empty serviceName"))
+	}
+
+	params := map[string]string{"serviceName": query.ServiceName}
+	if query.SpanName != "" {
+		params["spanName"] = query.SpanName
+	}
+	if query.AnnotationQuery != "" {
+		params["annotationQuery"] = query.AnnotationQuery
+	}
+	if query.MinDuration > 0 {
+		params["minDuration"] = strconv.FormatInt(query.MinDuration, 10)
+	}
+	if query.MaxDuration > 0 {
+		params["maxDuration"] = strconv.FormatInt(query.MaxDuration, 10)
+	}
+	if query.Limit > 0 {
+		params["limit"] = strconv.Itoa(query.Limit)
+	}
+	if end > 0 {
+		params["endTs"] = strconv.FormatInt(end, 10)
+		if start > 0 {
+			params["lookback"] = strconv.FormatInt(end-start, 10)
+		}
+	}
+
+	tracesUrl, err := createZipkinURL(z.url, "/api/v2/traces", params)
+	if err != nil {
+		return traces, backend.DownstreamError(fmt.Errorf("failed to compose url: %w", err))
+	}
+
+	res, err := z.httpClient.Get(tracesUrl)
+	if err != nil {
+		if backend.IsDownstreamHTTPError(err) {
+			return traces, backend.DownstreamError(err)
+		}
+		return traces, err
+	}
+
+	defer func() {
+		if res != nil {
+			if err = res.Body.Close(); err != nil {
+				z.logger.Error("Failed to close response body", "error", err)
+			}
+		}
+	}()
+
+	if res != nil && res.StatusCode/100 != 2 {
+		err := backend.DownstreamError(fmt.Errorf("request failed: %s", res.Status))
+		if backend.ErrorSourceFromHTTPStatus(res.StatusCode) == backend.ErrorSourceDownstream {
+			return traces, backend.DownstreamError(err)
+		}
+		return traces, err
+	}
+
+	if err := json.NewDecoder(res.Body).Decode(&traces); err != nil {
+		return traces, err
+	}
+	return traces, nil
+}
+
 func createZipkinURL(baseURL string, path string, params map[string]string) (string, error) {
 	// Parse the base URL
 	finalUrl, err := url.Parse(baseURL)
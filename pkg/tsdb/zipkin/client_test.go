@@ -386,3 +386,78 @@ func TestCreateZipkinURL(t *testing.T) {
 		})
 	}
 }
+
+func TestZipkinClient_SearchTraces(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          zipkinQuery
+		mockStatusCode int
+		mockResponse   string
+		expectedQuery  url.Values
+		expectError    bool
+	}{
+		{
+			name: "Successful response with all parameters",
+			query: zipkinQuery{
+				ServiceName:     "frontend",
+				SpanName:        "get /api",
+				AnnotationQuery: "error",
+				MinDuration:     1000,
+				MaxDuration:     2000,
+				Limit:           50,
+			},
+			mockStatusCode: http.StatusOK,
+			mockResponse:   `[[{"traceId":"0000000000000001","id":"0000000000000001","name":"get /api"}]]`,
+			expectedQuery: url.Values{
+				"serviceName":     []string{"frontend"},
+				"spanName":        []string{"get /api"},
+				"annotationQuery": []string{"error"},
+				"minDuration":     []string{"1000"},
+				"maxDuration":     []string{"2000"},
+				"limit":           []string{"50"},
+				"endTs":           []string{"2000"},
+				"lookback":        []string{"1000"},
+			},
+		},
+		{
+			name:           "Non-200 response",
+			query:          zipkinQuery{ServiceName: "frontend"},
+			mockStatusCode: http.StatusInternalServerError,
+			expectedQuery: url.Values{
+				"serviceName": []string{"frontend"},
+				"endTs":       []string{"2000"},
+				"lookback":    []string{"1000"},
+			},
+			expectError: true,
+		},
+		{
+			name:        "Empty serviceName",
+			query:       zipkinQuery{},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var actualQuery url.Values
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/api/v2/traces", r.URL.Path)
+				actualQuery = r.URL.Query()
+				w.WriteHeader(tt.mockStatusCode)
+				_, _ = w.Write([]byte(tt.mockResponse))
+			}))
+			defer server.Close()
+
+			client, _ := New(server.URL, server.Client(), log.New())
+			traces, err := client.SearchTraces(tt.query, 1000, 2000)
+
+			if tt.expectError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Len(t, traces, 1)
+			}
+			assert.Equal(t, tt.expectedQuery, actualQuery)
+		})
+	}
+}
@@ -8,6 +8,8 @@ import (
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/openzipkin/zipkin-go/model"
+
+	"github.com/grafana/grafana/pkg/tsdb/spanmetrics"
 )
 
 func queryData(ctx context.Context, dsInfo *datasourceInfo, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
@@ -35,6 +37,27 @@ func queryData(ctx context.Context, dsInfo *datasourceInfo, req *backend.QueryDa
 				Error:       fmt.Errorf("unsupported query type %s. only available in frontend mode", query.QueryType),
 				ErrorSource: backend.ErrorSourcePlugin,
 			}
+		case zipkinQueryTypeSpanMetrics:
+			if err := spanmetrics.ValidateMetric(query.SpanMetric); err != nil {
+				response.Responses[q.RefID] = backend.ErrorResponseWithErrorSource(err)
+				continue
+			}
+			if query.Limit == 0 {
+				query.Limit = spanmetrics.DefaultLimit
+			}
+			traces, err := dsInfo.ZipkinClient.SearchTraces(query, q.TimeRange.From.UnixMilli(), q.TimeRange.To.UnixMilli())
+			if err != nil {
+				response.Responses[q.RefID] = backend.ErrorResponseWithErrorSource(err)
+				continue
+			}
+			frames, err := transformSpanMetricsResponse(traces, q.RefID, q.TimeRange, query.Limit, query.SpanMetric)
+			if err != nil {
+				response.Responses[q.RefID] = backend.ErrorResponseWithErrorSource(err)
+				continue
+			}
+			response.Responses[q.RefID] = backend.DataResponse{
+				Frames: frames,
+			}
 		default:
 			traces, err := dsInfo.ZipkinClient.Trace(query.Query)
 			if err != nil {
@@ -63,11 +86,23 @@ type zipkinQueryType string
 const (
 	zipkinQueryTypeTraceId zipkinQueryType = "traceID"
 	zipkinQueryTypeUpload  zipkinQueryType = "upload"
+	// zipkinQueryTypeSpanMetrics aggregates the spans of the searched traces into RED metrics
+	zipkinQueryTypeSpanMetrics zipkinQueryType = "spanMetrics"
 )
 
 type zipkinQuery struct {
 	Query     string          `json:"query,omitempty"`
 	QueryType zipkinQueryType `json:"queryType,omitempty"`
+
+	// Search parameters, used by the spanMetrics query type
+	ServiceName     string `json:"serviceName,omitempty"`
+	SpanName        string `json:"spanName,omitempty"`
+	AnnotationQuery string `json:"annotationQuery,omitempty"`
+	// Durations are in microseconds
+	MinDuration int64  `json:"minDuration,omitempty"`
+	MaxDuration int64  `json:"maxDuration,omitempty"`
+	Limit       int    `json:"limit,omitempty"`
+	SpanMetric  string `json:"spanMetric,omitempty"`
 }
 
 func loadQuery(backendQuery backend.DataQuery) (zipkinQuery, error) {
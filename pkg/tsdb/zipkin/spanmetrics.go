@@ -0,0 +1,79 @@
+package zipkin
+
+import (
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/openzipkin/zipkin-go/model"
+
+	"github.com/grafana/grafana/pkg/tsdb/spanmetrics"
+)
+
+// spanKey identifies a span of a trace. The B3 instrumentations share the span ID between the client
+// span of a call and the server span handling it, so the ID alone does not identify a span.
+type spanKey struct {
+	id   model.ID
+	kind model.Kind
+}
+
+// parentKinds are the kinds of the parent span candidates, in order of preference. The children
+// of a shared span are created by the server handling the call.
+var parentKinds = []model.Kind{model.Server, model.Undetermined, model.Client, model.Consumer, model.Producer}
+
+// transformSpanMetricsResponse aggregates the spans of the searched traces into RED metrics
+// per service and operation, see spanmetrics.Frames.
+func transformSpanMetricsResponse(traces [][]model.SpanModel, refID string, timeRange backend.TimeRange, limit int, spanMetric string) ([]*data.Frame, error) {
+	spans := make([][]spanmetrics.Span, 0, len(traces))
+	for _, trace := range traces {
+		// Span IDs are only unique within a trace
+		serviceBySpan := make(map[spanKey]string, len(trace))
+		for _, span := range trace {
+			serviceBySpan[spanKey{id: span.ID, kind: span.Kind}] = getServiceName(span)
+		}
+
+		traceSpans := make([]spanmetrics.Span, 0, len(trace))
+		for _, span := range trace {
+			traceSpans = append(traceSpans, spanmetrics.Span{
+				ServiceName:       getServiceName(span),
+				OperationName:     span.Name,
+				StartTime:         span.Timestamp,
+				Duration:          span.Duration,
+				Error:             isErrorSpan(span),
+				ParentServiceName: parentServiceName(span, serviceBySpan),
+			})
+		}
+		spans = append(spans, traceSpans)
+	}
+
+	return spanmetrics.Frames(spans, spanmetrics.Query{
+		RefID:     refID,
+		TimeRange: timeRange,
+		Limit:     limit,
+		Metric:    spanMetric,
+	})
+}
+
+// parentServiceName returns the service of the caller of the span
+func parentServiceName(span model.SpanModel, serviceBySpan map[spanKey]string) string {
+	// The server half of a shared span is called by the service of the client half
+	if span.Kind == model.Server {
+		if service, ok := serviceBySpan[spanKey{id: span.ID, kind: model.Client}]; ok {
+			return service
+		}
+	}
+	if span.ParentID == nil {
+		return ""
+	}
+	for _, kind := range parentKinds {
+		if service, ok := serviceBySpan[spanKey{id: *span.ParentID, kind: kind}]; ok {
+			return service
+		}
+	}
+	return ""
+}
+
+// isErrorSpan reports whether the span is marked as failed. Zipkin sets the `error` tag
+// on failed spans, its value being the error message or code.
+func isErrorSpan(span model.SpanModel) bool {
+	_, ok := span.Tags["error"]
+	return ok
+}
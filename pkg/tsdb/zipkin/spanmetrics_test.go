@@ -0,0 +1,128 @@
+package zipkin
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/openzipkin/zipkin-go/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransformSpanMetricsResponse(t *testing.T) {
+	timeRange := backend.TimeRange{
+		From: time.UnixMilli(1605873894000),
+		To:   time.UnixMilli(1605873894000).Add(10 * time.Second),
+	}
+	rootID := model.ID(1)
+	traces := [][]model.SpanModel{
+		{
+			{
+				SpanContext:   model.SpanContext{TraceID: model.TraceID{Low: 1}, ID: rootID},
+				Name:          "get /api",
+				Duration:      100 * time.Millisecond,
+				LocalEndpoint: &model.Endpoint{ServiceName: "frontend"},
+			},
+			{
+				SpanContext:   model.SpanContext{TraceID: model.TraceID{Low: 1}, ID: 2, ParentID: &rootID},
+				Name:          "query",
+				Duration:      40 * time.Millisecond,
+				LocalEndpoint: &model.Endpoint{ServiceName: "database"},
+				Tags:          map[string]string{"error": "timeout"},
+			},
+		},
+		{
+			{
+				SpanContext:   model.SpanContext{TraceID: model.TraceID{Low: 2}, ID: rootID},
+				Name:          "get /api",
+				Duration:      300 * time.Millisecond,
+				LocalEndpoint: &model.Endpoint{ServiceName: "frontend"},
+			},
+		},
+	}
+
+	t.Run("all_metrics", func(t *testing.T) {
+		frames, err := transformSpanMetricsResponse(traces, "test", timeRange, 1000, "")
+		require.NoError(t, err)
+		require.Len(t, frames, 3)
+
+		metrics := frames[0]
+		require.Equal(t, 2, metrics.Rows())
+		assert.Equal(t, []string{"serviceName", "operationName", "requests", "rate", "errors", "errorRate", "p50", "p90", "p99"}, fieldNames(metrics))
+		// database / query
+		assert.Equal(t, []any{"database", "query", int64(1), 0.1, int64(1), 1.0, 40.0}, metrics.RowCopy(0)[:7])
+		// frontend / get /api
+		assert.Equal(t, []any{"frontend", "get /api", int64(2), 0.2, int64(0), 0.0, 100.0, 300.0, 300.0}, metrics.RowCopy(1))
+
+		nodes, edges := frames[1], frames[2]
+		assert.Equal(t, 2, nodes.Rows())
+		require.Equal(t, 1, edges.Rows())
+		assert.Equal(t, []any{"frontend--database", "frontend", "database", int64(1), int64(1)}, edges.RowCopy(0))
+	})
+
+	t.Run("single_metric", func(t *testing.T) {
+		frames, err := transformSpanMetricsResponse(traces, "test", timeRange, 1000, "errorRate")
+		require.NoError(t, err)
+		require.Len(t, frames, 1)
+		assert.Equal(t, []string{"serviceName", "operationName", "errorRate"}, fieldNames(frames[0]))
+	})
+
+	t.Run("invalid_metric", func(t *testing.T) {
+		_, err := transformSpanMetricsResponse(traces, "test", timeRange, 1000, "unknown")
+		require.Error(t, err)
+		assert.True(t, backend.IsDownstreamError(err))
+	})
+
+	t.Run("shared_span_ids", func(t *testing.T) {
+		// The server span of a B3 call shares the ID of the client span of its caller
+		sharedID := model.ID(2)
+		frames, err := transformSpanMetricsResponse([][]model.SpanModel{
+			{
+				{
+					SpanContext:   model.SpanContext{TraceID: model.TraceID{Low: 3}, ID: rootID},
+					Name:          "get /api",
+					Kind:          model.Server,
+					Duration:      100 * time.Millisecond,
+					LocalEndpoint: &model.Endpoint{ServiceName: "frontend"},
+				},
+				{
+					SpanContext:   model.SpanContext{TraceID: model.TraceID{Low: 3}, ID: sharedID, ParentID: &rootID},
+					Name:          "get /users",
+					Kind:          model.Client,
+					Duration:      60 * time.Millisecond,
+					LocalEndpoint: &model.Endpoint{ServiceName: "frontend"},
+				},
+				{
+					SpanContext:   model.SpanContext{TraceID: model.TraceID{Low: 3}, ID: sharedID, ParentID: &rootID},
+					Name:          "get /users",
+					Kind:          model.Server,
+					Duration:      50 * time.Millisecond,
+					LocalEndpoint: &model.Endpoint{ServiceName: "users"},
+				},
+				{
+					SpanContext:   model.SpanContext{TraceID: model.TraceID{Low: 3}, ID: 3, ParentID: &sharedID},
+					Name:          "query",
+					Duration:      40 * time.Millisecond,
+					LocalEndpoint: &model.Endpoint{ServiceName: "database"},
+				},
+			},
+		}, "test", timeRange, 1000, "")
+		require.NoError(t, err)
+		require.Len(t, frames, 3)
+
+		edges := frames[2]
+		require.Equal(t, 2, edges.Rows())
+		assert.Equal(t, []any{"frontend--users", "frontend", "users", int64(1), int64(0)}, edges.RowCopy(0))
+		assert.Equal(t, []any{"users--database", "users", "database", int64(1), int64(0)}, edges.RowCopy(1))
+	})
+}
+
+func fieldNames(frame *data.Frame) []string {
+	names := make([]string, 0, len(frame.Fields))
+	for _, field := range frame.Fields {
+		names = append(names, field.Name)
+	}
+	return names
+}
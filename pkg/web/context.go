@@ -95,6 +95,55 @@ func RemoteAddr(req *http.Request) string {
 	return addr
 }
 
+// ClientIP returns the IP address of the client. Unlike RemoteAddr, the X-Forwarded-For and X-Real-IP headers,
+// which any client can set, are only used when the connection comes from one of the trusted proxies.
+// The proxies append the address of their client to X-Forwarded-For, so the client is the last address
+// which is not a trusted proxy.
+func ClientIP(req *http.Request, trustedProxies []*net.IPNet) string {
+	addr := req.RemoteAddr
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		addr = host
+	}
+	if !containsIP(trustedProxies, addr) {
+		return addr
+	}
+
+	var forwarded []string
+	for _, value := range req.Header.Values("X-Forwarded-For") {
+		forwarded = append(forwarded, strings.Split(value, ",")...)
+	}
+	if len(forwarded) == 0 {
+		if realIP := strings.TrimSpace(req.Header.Get("X-Real-IP")); net.ParseIP(realIP) != nil {
+			return realIP
+		}
+	}
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		ip := strings.TrimSpace(forwarded[i])
+		if net.ParseIP(ip) == nil {
+			// a proxy would not forward an invalid address, it was set by the client
+			return addr
+		}
+		addr = ip
+		if !containsIP(trustedProxies, ip) {
+			return addr
+		}
+	}
+	return addr
+}
+
+func containsIP(networks []*net.IPNet, addr string) bool {
+	ip := net.ParseIP(strings.Trim(addr, "[]"))
+	if ip == nil {
+		return false
+	}
+	for _, network := range networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
 const (
 	headerContentType = "Content-Type"
 	contentTypeJSON   = "application/json; charset=UTF-8"
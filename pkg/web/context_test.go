@@ -1,11 +1,13 @@
 package web
 
 import (
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/grafana/grafana/pkg/infra/log"
 )
@@ -98,6 +100,56 @@ func TestContext_RemoteAddr(t *testing.T) {
 	}
 }
 
+func TestClientIP(t *testing.T) {
+	_, proxies, err := net.ParseCIDR("10.0.0.0/8")
+	require.NoError(t, err)
+	trusted := []*net.IPNet{proxies}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		header     http.Header
+		want       string
+	}{
+		{
+			name:       "the headers of an untrusted client are ignored",
+			remoteAddr: "192.168.1.1:51299",
+			header:     http.Header{"X-Forwarded-For": []string{"1.2.3.4"}, "X-Real-Ip": []string{"1.2.3.4"}},
+			want:       "192.168.1.1",
+		},
+		{
+			name:       "the last untrusted address forwarded by a trusted proxy is the client",
+			remoteAddr: "10.0.0.1:51299",
+			header:     http.Header{"X-Forwarded-For": []string{"1.2.3.4, 192.168.1.1", "10.0.0.2"}},
+			want:       "192.168.1.1",
+		},
+		{
+			name:       "X-Real-IP is used without X-Forwarded-For",
+			remoteAddr: "10.0.0.1:51299",
+			header:     http.Header{"X-Real-Ip": []string{"192.168.1.1"}},
+			want:       "192.168.1.1",
+		},
+		{
+			name:       "an invalid forwarded address stops at the last trusted address",
+			remoteAddr: "10.0.0.1:51299",
+			header:     http.Header{"X-Forwarded-For": []string{"not an ip, 10.0.0.2"}},
+			want:       "10.0.0.2",
+		},
+		{
+			name:       "IPv6 connection",
+			remoteAddr: "[::1]:51299",
+			header:     http.Header{},
+			want:       "::1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &http.Request{RemoteAddr: tt.remoteAddr, Header: tt.header}
+			assert.Equal(t, tt.want, ClientIP(req, trusted))
+		})
+	}
+}
+
 func TestContext_noHandler(t *testing.T) {
 	recorder := httptest.NewRecorder()
 
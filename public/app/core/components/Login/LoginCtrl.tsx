@@ -4,7 +4,7 @@ import { FetchError, getBackendSrv, isFetchError, locationService } from '@grafa
 import config from 'app/core/config';
 import { t } from 'app/core/internationalization';
 
-import { LoginDTO, AuthNRedirectDTO } from './types';
+import { LoginDTO, AuthNRedirectDTO, TOTPEnrollmentDTO } from './types';
 
 const isOauthEnabled = () => {
   return !!config.oauth && Object.keys(config.oauth).length > 0;
@@ -21,6 +21,17 @@ export interface FormModel {
   email: string;
 }
 
+export interface TOTPFormModel {
+  code: string;
+}
+
+// TOTPChallenge is a login waiting for a two-factor authentication code, after the password was checked
+export interface TOTPChallenge {
+  token: string;
+  // Set when the second factor is enforced and the user sets it up while logging in
+  enrollment?: TOTPEnrollmentDTO;
+}
+
 export interface PasswordlessFormModel {
   email: string;
 }
@@ -41,6 +52,9 @@ interface Props {
     isChangingPassword: boolean;
     skipPasswordChange: Function;
     login: (data: FormModel) => void;
+    loginTOTP: (data: TOTPFormModel) => void;
+    cancelTOTP: () => void;
+    totpChallenge: TOTPChallenge | undefined;
     passwordlessStart: (data: PasswordlessFormModel) => void;
     passwordlessConfirm: (data: PasswordlessConfirmationFormModel) => void;
     showPasswordlessConfirmation: boolean;
@@ -59,10 +73,13 @@ interface State {
   isChangingPassword: boolean;
   showDefaultPasswordWarning: boolean;
   loginErrorMessage?: string;
+  totpChallenge?: TOTPChallenge;
 }
 
 export class LoginCtrl extends PureComponent<Props, State> {
   result: LoginDTO | undefined;
+  // The default admin password is changed once the login completes, also after a second factor
+  isDefaultPassword = false;
 
   constructor(props: Props) {
     super(props);
@@ -109,27 +126,85 @@ export class LoginCtrl extends PureComponent<Props, State> {
       loginErrorMessage: undefined,
       isLoggingIn: true,
     });
+    this.isDefaultPassword = formModel.password === 'admin' && !config.ldapEnabled && !config.authProxyEnabled;
 
     getBackendSrv()
       .post<LoginDTO>('/login', formModel, { showErrorAlert: false })
       .then((result) => {
-        this.result = result;
-        if (formModel.password !== 'admin' || config.ldapEnabled || config.authProxyEnabled) {
-          this.toGrafana();
+        this.loggedIn(result);
+      })
+      .catch((err) => {
+        if (isFetchError(err) && isTOTPRequired(err)) {
+          this.startTOTP(err.data.extra);
           return;
-        } else {
-          this.changeView(formModel.password === 'admin');
         }
+        const fetchErrorMessage = isFetchError(err) ? getErrorMessage(err) : undefined;
+        this.setState({
+          isLoggingIn: false,
+          loginErrorMessage: fetchErrorMessage || t('login.error.unknown', 'Unknown error occurred'),
+        });
+      });
+  };
+
+  loggedIn = (result: LoginDTO) => {
+    this.result = result;
+    if (!this.isDefaultPassword) {
+      this.toGrafana();
+      return;
+    }
+    this.changeView(true);
+  };
+
+  startTOTP = async ({ token, enroll }: { token: string; enroll: boolean }) => {
+    try {
+      const enrollment = enroll
+        ? await getBackendSrv().post<TOTPEnrollmentDTO>('/api/login/totp/enroll', { token }, { showErrorAlert: false })
+        : undefined;
+      this.setState({ isLoggingIn: false, totpChallenge: { token, enrollment } });
+    } catch (err) {
+      const fetchErrorMessage = isFetchError(err) ? getErrorMessage(err) : undefined;
+      this.setState({
+        isLoggingIn: false,
+        loginErrorMessage: fetchErrorMessage || t('login.error.unknown', 'Unknown error occurred'),
+      });
+    }
+  };
+
+  loginTOTP = (formModel: TOTPFormModel) => {
+    const { totpChallenge } = this.state;
+    if (!totpChallenge) {
+      return;
+    }
+    this.setState({
+      loginErrorMessage: undefined,
+      isLoggingIn: true,
+    });
+
+    getBackendSrv()
+      .post<LoginDTO>(
+        '/api/login/totp',
+        { token: totpChallenge.token, code: formModel.code },
+        { showErrorAlert: false }
+      )
+      .then((result) => {
+        this.loggedIn(result);
       })
       .catch((err) => {
         const fetchErrorMessage = isFetchError(err) ? getErrorMessage(err) : undefined;
         this.setState({
           isLoggingIn: false,
           loginErrorMessage: fetchErrorMessage || t('login.error.unknown', 'Unknown error occurred'),
+          // the login starts again with the password once the challenge expired
+          totpChallenge:
+            isFetchError(err) && err.data?.messageId === 'totp.invalid-challenge' ? undefined : totpChallenge,
         });
       });
   };
 
+  cancelTOTP = () => {
+    this.setState({ loginErrorMessage: undefined, totpChallenge: undefined });
+  };
+
   passwordlessStart = (formModel: PasswordlessFormModel) => {
     this.setState({
       loginErrorMessage: undefined,
@@ -199,8 +274,9 @@ export class LoginCtrl extends PureComponent<Props, State> {
 
   render() {
     const { children } = this.props;
-    const { isLoggingIn, isChangingPassword, showDefaultPasswordWarning, loginErrorMessage } = this.state;
-    const { login, toGrafana, changePassword, passwordlessStart, passwordlessConfirm } = this;
+    const { isLoggingIn, isChangingPassword, showDefaultPasswordWarning, loginErrorMessage, totpChallenge } =
+      this.state;
+    const { login, loginTOTP, cancelTOTP, toGrafana, changePassword, passwordlessStart, passwordlessConfirm } = this;
     const { loginHint, passwordHint, disableLoginForm, disableUserSignUp } = config;
 
     return (
@@ -212,6 +288,9 @@ export class LoginCtrl extends PureComponent<Props, State> {
           disableLoginForm,
           disableUserSignUp,
           login,
+          loginTOTP,
+          cancelTOTP,
+          totpChallenge,
           passwordlessStart,
           passwordlessConfirm,
           showPasswordlessConfirmation: showPasswordlessConfirmation(),
@@ -229,12 +308,21 @@ export class LoginCtrl extends PureComponent<Props, State> {
 
 export default LoginCtrl;
 
+function isTOTPRequired(
+  err: FetchError<undefined | { messageId?: string; extra?: { token?: string; enroll?: boolean } }>
+): err is FetchError<{ messageId: string; extra: { token: string; enroll: boolean } }> {
+  return err.data?.messageId === 'totp.required' && !!err.data.extra?.token;
+}
+
 function getErrorMessage(err: FetchError<undefined | { messageId?: string; message?: string }>): string | undefined {
   switch (err.data?.messageId) {
     case 'password-auth.empty':
     case 'password-auth.failed':
     case 'password-auth.invalid':
       return t('login.error.invalid-user-or-password', 'Invalid username or password');
+    case 'totp.invalid-code':
+      return t('login.error.invalid-totp-code', 'Invalid authentication code');
+    case 'totp.invalid.login-attempt':
     case 'login-attempt.blocked':
       return t(
         'login.error.blocked',
@@ -163,4 +163,69 @@ describe('Login Page', () => {
       'You have exceeded the number of login attempts for this user. Please try again later.'
     );
   });
+
+  it('asks for a two-factor authentication code after the password', async () => {
+    Object.defineProperty(window, 'location', {
+      value: {
+        assign: jest.fn(),
+      },
+    });
+    postMock.mockRejectedValueOnce({
+      data: {
+        message: 'Two-factor authentication code required',
+        messageId: 'totp.required',
+        statusCode: 401,
+        extra: { token: 'challenge', enroll: false },
+      },
+      status: 401,
+      statusText: 'Unauthorized',
+    });
+    postMock.mockResolvedValueOnce({ message: 'Logged in' });
+
+    render(<LoginPage />);
+
+    await userEvent.type(screen.getByLabelText('Email or username'), 'admin');
+    await userEvent.type(screen.getByLabelText('Password'), 'test');
+    await userEvent.click(screen.getByRole('button', { name: 'Log in' }));
+
+    await userEvent.type(await screen.findByLabelText(/Authentication code/), '123456');
+    await userEvent.click(screen.getByRole('button', { name: 'Verify' }));
+
+    await waitFor(() =>
+      expect(postMock).toHaveBeenCalledWith(
+        '/api/login/totp',
+        { token: 'challenge', code: '123456' },
+        { showErrorAlert: false }
+      )
+    );
+    expect(window.location.assign).toHaveBeenCalledWith('/');
+  });
+
+  it('sets up the enforced two-factor authentication while logging in', async () => {
+    postMock.mockRejectedValueOnce({
+      data: {
+        message: 'Two-factor authentication code required',
+        messageId: 'totp.required',
+        statusCode: 401,
+        extra: { token: 'challenge', enroll: true },
+      },
+      status: 401,
+      statusText: 'Unauthorized',
+    });
+    postMock.mockResolvedValueOnce({
+      secret: 'JBSWY3DPEHPK3PXP',
+      url: 'otpauth://totp/Grafana:admin?secret=JBSWY3DPEHPK3PXP',
+      recoveryCodes: ['abcde-fghjk'],
+    });
+
+    render(<LoginPage />);
+
+    await userEvent.type(screen.getByLabelText('Email or username'), 'admin');
+    await userEvent.type(screen.getByLabelText('Password'), 'test');
+    await userEvent.click(screen.getByRole('button', { name: 'Log in' }));
+
+    expect(await screen.findByText('JBSWY3DPEHPK3PXP')).toBeInTheDocument();
+    expect(screen.getByText('abcde-fghjk')).toBeInTheDocument();
+    expect(postMock).toHaveBeenCalledWith('/api/login/totp/enroll', { token: 'challenge' }, { showErrorAlert: false });
+  });
 });
@@ -16,6 +16,7 @@ import { LoginLayout, InnerBox } from './LoginLayout';
 import { LoginServiceButtons } from './LoginServiceButtons';
 import { PasswordlessConfirmation } from './PasswordlessConfirmationForm';
 import { PasswordlessLoginForm } from './PasswordlessLoginForm';
+import { TOTPForm } from './TOTPForm';
 import { UserSignup } from './UserSignup';
 
 const LoginPage = () => {
@@ -30,6 +31,9 @@ const LoginPage = () => {
         disableLoginForm,
         disableUserSignUp,
         login,
+        loginTOTP,
+        cancelTOTP,
+        totpChallenge,
         passwordlessStart,
         passwordlessConfirm,
         showPasswordlessConfirmation,
@@ -41,7 +45,23 @@ const LoginPage = () => {
         loginErrorMessage,
       }) => (
         <LoginLayout isChangingPassword={isChangingPassword}>
-          {!isChangingPassword && !showPasswordlessConfirmation && (
+          {!isChangingPassword && totpChallenge && (
+            <InnerBox>
+              {loginErrorMessage && (
+                <Alert className={styles.alert} severity="error" title={t('login.error.title', 'Login failed')}>
+                  {loginErrorMessage}
+                </Alert>
+              )}
+              <TOTPForm
+                onSubmit={loginTOTP}
+                onCancel={cancelTOTP}
+                isLoggingIn={isLoggingIn}
+                enrollment={totpChallenge.enrollment}
+              />
+            </InnerBox>
+          )}
+
+          {!isChangingPassword && !showPasswordlessConfirmation && !totpChallenge && (
             <InnerBox>
               {loginErrorMessage && (
                 <Alert className={styles.alert} severity="error" title={t('login.error.title', 'Login failed')}>
@@ -0,0 +1,121 @@
+import { css } from '@emotion/css';
+import { useId } from 'react';
+import { useForm } from 'react-hook-form';
+
+import { GrafanaTheme2 } from '@grafana/data';
+import { selectors } from '@grafana/e2e-selectors';
+import { Button, Field, Input, Stack, Text, useStyles2 } from '@grafana/ui';
+import { t, Trans } from 'app/core/internationalization';
+
+import { TOTPFormModel } from './LoginCtrl';
+import { TOTPEnrollmentDTO } from './types';
+
+interface Props {
+  onSubmit: (data: TOTPFormModel) => void;
+  onCancel: () => void;
+  isLoggingIn: boolean;
+  // Set when the second factor is enforced and the user sets it up while logging in
+  enrollment?: TOTPEnrollmentDTO;
+}
+
+export const TOTPForm = ({ onSubmit, onCancel, isLoggingIn, enrollment }: Props) => {
+  const styles = useStyles2(getStyles);
+  const codeId = useId();
+  const {
+    handleSubmit,
+    register,
+    formState: { errors },
+  } = useForm<TOTPFormModel>({ mode: 'onChange' });
+
+  return (
+    <div className={styles.wrapper}>
+      <form onSubmit={handleSubmit(onSubmit)}>
+        {enrollment && (
+          <Stack direction="column" gap={1}>
+            <Text>
+              <Trans i18nKey="login.totp.enroll-description">
+                Two-factor authentication is required. Add this secret to your authenticator app, then enter the code
+                it shows.
+              </Trans>
+            </Text>
+            <Text variant="code">{enrollment.secret}</Text>
+            {/* the otpauth links open the authenticator apps of the mobile devices */}
+            <a href={enrollment.url} className={styles.link}>
+              {t('login.totp.enroll-link', 'Open in an authenticator app')}
+            </a>
+            <Text>
+              <Trans i18nKey="login.totp.recovery-codes-description">
+                Save these recovery codes. Each code can be used once to log in if you lose your device.
+              </Trans>
+            </Text>
+            <ul className={styles.recoveryCodes}>
+              {enrollment.recoveryCodes.map((code) => (
+                <li key={code}>
+                  <Text variant="code">{code}</Text>
+                </li>
+              ))}
+            </ul>
+          </Stack>
+        )}
+        <Field
+          label={t('login.totp.code-label', 'Authentication code')}
+          description={
+            enrollment
+              ? undefined
+              : t('login.totp.code-description', 'Enter a code of your authenticator app or a recovery code')
+          }
+          invalid={!!errors.code}
+          error={errors.code?.message}
+        >
+          <Input
+            {...register('code', { required: t('login.totp.code-required', 'Authentication code is required') })}
+            id={codeId}
+            autoFocus
+            autoCapitalize="none"
+            autoComplete="one-time-code"
+            placeholder={t('login.totp.code-placeholder', '123456')}
+          />
+        </Field>
+        <Stack direction="column" gap={1}>
+          <Button
+            type="submit"
+            data-testid={selectors.pages.Login.submit}
+            className={styles.submitButton}
+            disabled={isLoggingIn}
+          >
+            {isLoggingIn
+              ? t('login.form.submit-loading-label', 'Logging in...')
+              : t('login.totp.submit-label', 'Verify')}
+          </Button>
+          <Button type="button" fill="text" className={styles.submitButton} onClick={onCancel}>
+            {t('login.totp.cancel-label', 'Back to login')}
+          </Button>
+        </Stack>
+      </form>
+    </div>
+  );
+};
+
+export const getStyles = (theme: GrafanaTheme2) => {
+  return {
+    wrapper: css({
+      width: '100%',
+      paddingBottom: theme.spacing(2),
+    }),
+
+    link: css({
+      color: theme.colors.text.link,
+    }),
+
+    recoveryCodes: css({
+      columns: 2,
+      listStyle: 'none',
+      marginBottom: theme.spacing(2),
+    }),
+
+    submitButton: css({
+      justifyContent: 'center',
+      width: '100%',
+    }),
+  };
+};
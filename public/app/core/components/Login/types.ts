@@ -6,3 +6,9 @@ export interface LoginDTO {
 export interface AuthNRedirectDTO {
   URL: string;
 }
+
+export interface TOTPEnrollmentDTO {
+  secret: string;
+  url: string;
+  recoveryCodes: string[];
+}
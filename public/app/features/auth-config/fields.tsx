@@ -43,7 +43,7 @@ export const sectionFields: Section = {
     {
       name: 'User mapping',
       id: 'user',
-      fields: ['roleAttributeStrict', 'orgMapping', 'allowAssignGrafanaAdmin', 'skipOrgRoleSync'],
+      fields: ['roleAttributeStrict', 'orgMapping', 'teamMapping', 'allowAssignGrafanaAdmin', 'skipOrgRoleSync'],
     },
     {
       name: 'Extra security measures',
@@ -94,6 +94,7 @@ export const sectionFields: Section = {
         'roleAttributeStrict',
         'orgMapping',
         'orgAttributePath',
+        'teamMapping',
         'allowAssignGrafanaAdmin',
         'skipOrgRoleSync',
       ],
@@ -129,7 +130,14 @@ export const sectionFields: Section = {
     {
       name: 'User mapping',
       id: 'user',
-      fields: ['roleAttributePath', 'roleAttributeStrict', 'orgMapping', 'allowAssignGrafanaAdmin', 'skipOrgRoleSync'],
+      fields: [
+        'roleAttributePath',
+        'roleAttributeStrict',
+        'orgMapping',
+        'teamMapping',
+        'allowAssignGrafanaAdmin',
+        'skipOrgRoleSync',
+      ],
     },
     {
       name: 'Extra security measures',
@@ -157,7 +165,14 @@ export const sectionFields: Section = {
     {
       name: 'User mapping',
       id: 'user',
-      fields: ['roleAttributePath', 'roleAttributeStrict', 'orgMapping', 'allowAssignGrafanaAdmin', 'skipOrgRoleSync'],
+      fields: [
+        'roleAttributePath',
+        'roleAttributeStrict',
+        'orgMapping',
+        'teamMapping',
+        'allowAssignGrafanaAdmin',
+        'skipOrgRoleSync',
+      ],
     },
     {
       name: 'Extra security measures',
@@ -184,7 +199,14 @@ export const sectionFields: Section = {
     {
       name: 'User mapping',
       id: 'user',
-      fields: ['roleAttributePath', 'roleAttributeStrict', 'orgMapping', 'allowAssignGrafanaAdmin', 'skipOrgRoleSync'],
+      fields: [
+        'roleAttributePath',
+        'roleAttributeStrict',
+        'orgMapping',
+        'teamMapping',
+        'allowAssignGrafanaAdmin',
+        'skipOrgRoleSync',
+      ],
     },
     {
       name: 'Extra security measures',
@@ -226,6 +248,7 @@ export const sectionFields: Section = {
         'roleAttributeStrict',
         'orgMapping',
         'orgAttributePath',
+        'teamMapping',
         'allowAssignGrafanaAdmin',
         'skipOrgRoleSync',
       ],
@@ -495,6 +518,17 @@ export function fieldMap(provider: string): Record<string, FieldData> {
       options: [],
       placeholder: 'Enter mappings (my-team:1:Viewer...) and press Enter to add',
     },
+    teamMapping: {
+      label: 'Team mapping',
+      description:
+        'Map groups of the identity provider to Grafana teams. The format is <group>:<org id or name>:<team name>.',
+      type: 'select',
+      hidden: !contextSrv.isGrafanaAdmin,
+      multi: true,
+      allowCustomValue: true,
+      options: [],
+      placeholder: 'Enter mappings (my-group:1:my-team...) and press Enter to add',
+    },
     orgAttributePath: {
       label: 'Organization attribute path',
       description: 'JMESPath expression to use for organization lookup.',
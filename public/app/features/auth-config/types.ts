@@ -75,6 +75,7 @@ export type SSOProvider = {
     allowedGroups?: string;
     scopes?: string;
     orgMapping?: string;
+    teamMapping?: string;
     serverDiscoveryUrl?: string;
   };
 };
@@ -87,6 +88,7 @@ export type SSOProviderDTO = Partial<SSOProviderSettingsBase> & {
   allowedGroups?: Array<SelectableValue<string>>;
   scopes?: Array<SelectableValue<string>>;
   orgMapping?: Array<SelectableValue<string>>;
+  teamMapping?: Array<SelectableValue<string>>;
   serverDiscoveryUrl?: string;
 };
 
@@ -21,6 +21,10 @@ const prometheusPlugin = async () =>
   await import(/* webpackChunkName: "prometheusPlugin" */ 'app/plugins/datasource/prometheus/module');
 const alertmanagerPlugin = async () =>
   await import(/* webpackChunkName: "alertmanagerPlugin" */ 'app/plugins/datasource/alertmanager/module');
+const filesPlugin = async () =>
+  await import(/* webpackChunkName: "filesPlugin" */ 'app/plugins/datasource/grafana-files-datasource/module');
+const jsonAPIPlugin = async () =>
+  await import(/* webpackChunkName: "jsonAPIPlugin" */ 'app/plugins/datasource/grafana-jsonapi-datasource/module');
 
 // Async loaded panels
 const alertListPanel = async () =>
@@ -94,6 +98,8 @@ const builtInPlugins: Record<string, System.Module | (() => Promise<System.Modul
   'core:plugin/mixed': mixedPlugin,
   'core:plugin/prometheus': prometheusPlugin,
   'core:plugin/alertmanager': alertmanagerPlugin,
+  'core:plugin/grafana-files-datasource': filesPlugin,
+  'core:plugin/grafana-jsonapi-datasource': jsonAPIPlugin,
   // panels
   'core:plugin/text': textPanel,
   'core:plugin/timeseries': timeseriesPanel,
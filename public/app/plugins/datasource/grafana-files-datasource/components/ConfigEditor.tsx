@@ -0,0 +1,161 @@
+import { useState } from 'react';
+import { useAsyncFn, useEffectOnce } from 'react-use';
+
+import {
+  DataSourcePluginOptionsEditorProps,
+  onUpdateDatasourceJsonDataOption,
+  onUpdateDatasourceJsonDataOptionSelect,
+} from '@grafana/data';
+import { getBackendSrv } from '@grafana/runtime';
+import { Alert, Button, FileUpload, InlineField, InlineFieldRow, Input, Select } from '@grafana/ui';
+
+import { FileInfo, FilesOptions, Permission } from '../types';
+
+const sources = [
+  { label: 'Uploaded files', value: 'storage' },
+  { label: 'Local directory', value: 'directory' },
+];
+
+const roles: Array<{ label: string; value: Permission['role'] }> = [
+  { label: 'Viewer', value: 'Viewer' },
+  { label: 'Editor', value: 'Editor' },
+  { label: 'Admin', value: 'Admin' },
+];
+
+const labelWidth = 20;
+
+export const ConfigEditor = (props: DataSourcePluginOptionsEditorProps<FilesOptions>) => {
+  const { options, onOptionsChange } = props;
+  const { jsonData } = options;
+  const permissions = jsonData.permissions ?? [];
+
+  const updatePermissions = (permissions: Permission[]) =>
+    onOptionsChange({ ...options, jsonData: { ...jsonData, permissions } });
+
+  return (
+    <>
+      <InlineField label="Source" labelWidth={labelWidth}>
+        <Select
+          width={40}
+          options={sources}
+          value={jsonData.source ?? 'storage'}
+          onChange={onUpdateDatasourceJsonDataOptionSelect(props, 'source')}
+        />
+      </InlineField>
+      {jsonData.source === 'directory' && (
+        <InlineField
+          label="Directory"
+          labelWidth={labelWidth}
+          tooltip="Absolute path of the directory, which must be allowed by the allowed_directories server setting"
+        >
+          <Input
+            width={40}
+            value={jsonData.directory ?? ''}
+            onChange={onUpdateDatasourceJsonDataOption(props, 'directory')}
+          />
+        </InlineField>
+      )}
+
+      <h3 className="page-heading">Permissions</h3>
+      <p>
+        Restricts the files matching a pattern to a minimum role. The first matching pattern applies, all users can read
+        the other files.
+      </p>
+      {permissions.map((permission, index) => (
+        <InlineFieldRow key={index}>
+          <InlineField label="Pattern" labelWidth={labelWidth}>
+            <Input
+              width={30}
+              value={permission.pattern}
+              placeholder="private/*.csv"
+              onChange={(e) =>
+                updatePermissions(
+                  permissions.map((p, i) => (i === index ? { ...p, pattern: e.currentTarget.value } : p))
+                )
+              }
+            />
+          </InlineField>
+          <InlineField>
+            <Select
+              width={14}
+              options={roles}
+              value={permission.role}
+              onChange={(v) =>
+                updatePermissions(permissions.map((p, i) => (i === index ? { ...p, role: v.value ?? 'Viewer' } : p)))
+              }
+            />
+          </InlineField>
+          <Button
+            variant="secondary"
+            icon="trash-alt"
+            aria-label="Remove permission"
+            onClick={() => updatePermissions(permissions.filter((_, i) => i !== index))}
+          />
+        </InlineFieldRow>
+      ))}
+      <Button
+        variant="secondary"
+        icon="plus"
+        onClick={() => updatePermissions([...permissions, { pattern: '', role: 'Editor' }])}
+      >
+        Permission
+      </Button>
+
+      {(jsonData.source ?? 'storage') === 'storage' && options.uid && <FileManager uid={options.uid} />}
+    </>
+  );
+};
+
+const FileManager = ({ uid }: { uid: string }) => {
+  const url = `/api/datasources/uid/${uid}/resources/files`;
+  const [error, setError] = useState<string>();
+  const [files, loadFiles] = useAsyncFn(() => getBackendSrv().get<FileInfo[]>(url), [url]);
+  useEffectOnce(() => {
+    loadFiles();
+  });
+
+  const run = async (action: () => Promise<unknown>) => {
+    setError(undefined);
+    try {
+      await action();
+    } catch (err) {
+      setError(err instanceof Error ? err.message : String(err));
+    }
+    loadFiles();
+  };
+
+  return (
+    <>
+      <h3 className="page-heading">Files</h3>
+      <p>Files are saved when uploaded. Save the datasource first when it is new.</p>
+      {error && <Alert title={error} severity="error" />}
+      {(files.value ?? []).map((file) => (
+        <InlineFieldRow key={file.path}>
+          <InlineField label={file.path} labelWidth={40}>
+            <Button
+              variant="destructive"
+              icon="trash-alt"
+              aria-label={`Delete ${file.path}`}
+              onClick={() => run(() => getBackendSrv().delete(`${url}/${file.path}`))}
+            />
+          </InlineField>
+        </InlineFieldRow>
+      ))}
+      <FileUpload
+        accept=".csv,.jsonl,.ndjson,.parquet"
+        onFileUpload={({ currentTarget }) => {
+          const file = currentTarget.files?.[0];
+          if (file) {
+            run(() =>
+              getBackendSrv().put(`${url}/${file.name}`, file, {
+                headers: { 'Content-Type': 'application/octet-stream' },
+              })
+            );
+          }
+        }}
+      >
+        Upload file
+      </FileUpload>
+    </>
+  );
+};
@@ -0,0 +1,98 @@
+import { useAsync } from 'react-use';
+
+import { QueryEditorProps, SelectableValue } from '@grafana/data';
+import { Button, InlineField, InlineFieldRow, Input, Select } from '@grafana/ui';
+
+import { FilesDatasource } from '../datasource';
+import { Filter, FilesOptions, FilesQuery, FilterOperator } from '../types';
+
+type Props = QueryEditorProps<FilesDatasource, FilesQuery, FilesOptions>;
+
+const operators: Array<SelectableValue<FilterOperator>> = ['=', '!=', '>', '>=', '<', '<=', '=~', '!~'].map((op) => ({
+  label: op,
+  value: op as FilterOperator,
+}));
+
+const labelWidth = 14;
+
+export const QueryEditor = ({ datasource, query, onChange, onRunQuery }: Props) => {
+  const files = useAsync(() => datasource.listFiles(), [datasource]);
+  const filters = query.filters ?? [];
+
+  const update = (changes: Partial<FilesQuery>) => onChange({ ...query, ...changes });
+  const run = (changes: Partial<FilesQuery>) => {
+    update(changes);
+    onRunQuery();
+  };
+  const updateFilter = (index: number, changes: Partial<Filter>) =>
+    update({ filters: filters.map((f, i) => (i === index ? { ...f, ...changes } : f)) });
+
+  return (
+    <>
+      <InlineFieldRow>
+        <InlineField label="File" labelWidth={labelWidth} invalid={!!files.error} error={files.error?.message}>
+          <Select
+            width={40}
+            isLoading={files.loading}
+            allowCustomValue
+            options={(files.value ?? []).map((f) => ({ label: f.path, value: f.path, description: f.format }))}
+            value={query.path}
+            onChange={(v) => run({ path: v.value })}
+          />
+        </InlineField>
+        <InlineField label="Time field" tooltip="Restricts the rows to the dashboard time range">
+          <Input
+            width={20}
+            value={query.timeField ?? ''}
+            placeholder="time"
+            onChange={(e) => update({ timeField: e.currentTarget.value })}
+            onBlur={onRunQuery}
+          />
+        </InlineField>
+      </InlineFieldRow>
+      {filters.map((filter, index) => (
+        <InlineFieldRow key={index}>
+          <InlineField label="Filter" labelWidth={labelWidth}>
+            <Input
+              width={20}
+              value={filter.field}
+              placeholder="Field"
+              onChange={(e) => updateFilter(index, { field: e.currentTarget.value })}
+              onBlur={onRunQuery}
+            />
+          </InlineField>
+          <InlineField>
+            <Select
+              width={8}
+              options={operators}
+              value={filter.operator}
+              onChange={(v) => updateFilter(index, { operator: v.value })}
+            />
+          </InlineField>
+          <InlineField>
+            <Input
+              width={20}
+              value={filter.value}
+              placeholder="Value"
+              onChange={(e) => updateFilter(index, { value: e.currentTarget.value })}
+              onBlur={onRunQuery}
+            />
+          </InlineField>
+          <Button
+            variant="secondary"
+            icon="trash-alt"
+            aria-label="Remove filter"
+            onClick={() => run({ filters: filters.filter((_, i) => i !== index) })}
+          />
+        </InlineFieldRow>
+      ))}
+      <Button
+        variant="secondary"
+        icon="plus"
+        onClick={() => update({ filters: [...filters, { field: '', operator: '=', value: '' }] })}
+      >
+        Filter
+      </Button>
+    </>
+  );
+};
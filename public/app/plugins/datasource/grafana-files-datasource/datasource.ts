@@ -0,0 +1,39 @@
+import { DataSourceInstanceSettings, ScopedVars } from '@grafana/data';
+import { DataSourceWithBackend, getBackendSrv, getTemplateSrv, TemplateSrv } from '@grafana/runtime';
+
+import { FileInfo, FilesOptions, FilesQuery } from './types';
+
+export class FilesDatasource extends DataSourceWithBackend<FilesQuery, FilesOptions> {
+  constructor(
+    instanceSettings: DataSourceInstanceSettings<FilesOptions>,
+    private readonly templateSrv: TemplateSrv = getTemplateSrv()
+  ) {
+    super(instanceSettings);
+  }
+
+  filterQuery(query: FilesQuery): boolean {
+    return !query.hide && !!query.path;
+  }
+
+  applyTemplateVariables(query: FilesQuery, scopedVars: ScopedVars): FilesQuery {
+    return {
+      ...query,
+      path: this.templateSrv.replace(query.path ?? '', scopedVars),
+      filters: query.filters?.map((f) => ({ ...f, value: this.templateSrv.replace(f.value, scopedVars) })),
+    };
+  }
+
+  listFiles(): Promise<FileInfo[]> {
+    return this.getResource('files');
+  }
+
+  uploadFile(path: string, file: Blob): Promise<unknown> {
+    return getBackendSrv().put(`/api/datasources/uid/${this.uid}/resources/files/${path}`, file, {
+      headers: { 'Content-Type': 'application/octet-stream' },
+    });
+  }
+
+  deleteFile(path: string): Promise<unknown> {
+    return getBackendSrv().delete(`/api/datasources/uid/${this.uid}/resources/files/${path}`);
+  }
+}
@@ -0,0 +1,7 @@
+import { DataSourcePlugin } from '@grafana/data';
+
+import { ConfigEditor } from './components/ConfigEditor';
+import { QueryEditor } from './components/QueryEditor';
+import { FilesDatasource } from './datasource';
+
+export const plugin = new DataSourcePlugin(FilesDatasource).setQueryEditor(QueryEditor).setConfigEditor(ConfigEditor);
@@ -0,0 +1,34 @@
+import { DataQuery, DataSourceJsonData } from '@grafana/data';
+
+export type FilterOperator = '=' | '!=' | '>' | '>=' | '<' | '<=' | '=~' | '!~';
+
+export interface Filter {
+  field: string;
+  operator: FilterOperator;
+  value: string;
+}
+
+export interface FilesQuery extends DataQuery {
+  path?: string;
+  timeField?: string;
+  filters?: Filter[];
+}
+
+export interface Permission {
+  // path.Match pattern, for example slo/*.csv
+  pattern: string;
+  role: 'Viewer' | 'Editor' | 'Admin';
+}
+
+export interface FilesOptions extends DataSourceJsonData {
+  source?: 'storage' | 'directory';
+  directory?: string;
+  permissions?: Permission[];
+}
+
+export interface FileInfo {
+  path: string;
+  format: 'csv' | 'jsonl' | 'parquet';
+  size: number;
+  modified: string;
+}
@@ -0,0 +1,89 @@
+import {
+  DataSourcePluginOptionsEditorProps,
+  onUpdateDatasourceJsonDataOption,
+  onUpdateDatasourceJsonDataOptionSelect,
+  onUpdateDatasourceSecureJsonDataOption,
+  updateDatasourcePluginResetOption,
+} from '@grafana/data';
+import { config } from '@grafana/runtime';
+import { DataSourceHttpSettings, InlineField, Input, SecretInput, Select } from '@grafana/ui';
+
+import { JSONAPIOptions, JSONAPISecureOptions } from '../types';
+
+const authTypes = [
+  { label: 'None', value: '' },
+  { label: 'OAuth2 client credentials', value: 'oauth2ClientCredentials' },
+];
+
+const labelWidth = 20;
+
+export const ConfigEditor = (props: DataSourcePluginOptionsEditorProps<JSONAPIOptions, JSONAPISecureOptions>) => {
+  const { options, onOptionsChange } = props;
+  const { jsonData, secureJsonFields, secureJsonData } = options;
+
+  return (
+    <>
+      <DataSourceHttpSettings
+        defaultUrl="http://localhost:8080"
+        dataSourceConfig={options}
+        onChange={onOptionsChange}
+        secureSocksDSProxyEnabled={config.secureSocksDSProxyEnabled}
+      />
+
+      <h3 className="page-heading">JSON API</h3>
+      <InlineField label="Health check path" labelWidth={labelWidth} tooltip="Path requested by save & test">
+        <Input
+          width={40}
+          value={jsonData.healthCheckPath ?? ''}
+          placeholder="health"
+          onChange={onUpdateDatasourceJsonDataOption(props, 'healthCheckPath')}
+        />
+      </InlineField>
+      <InlineField label="Token authentication" labelWidth={labelWidth}>
+        <Select
+          width={40}
+          options={authTypes}
+          value={jsonData.authType ?? ''}
+          onChange={onUpdateDatasourceJsonDataOptionSelect(props, 'authType')}
+        />
+      </InlineField>
+      {jsonData.authType === 'oauth2ClientCredentials' && (
+        <>
+          <InlineField label="Token URL" labelWidth={labelWidth}>
+            <Input width={40} value={jsonData.tokenUrl ?? ''} onChange={onUpdateDatasourceJsonDataOption(props, 'tokenUrl')} />
+          </InlineField>
+          <InlineField label="Client ID" labelWidth={labelWidth}>
+            <Input width={40} value={jsonData.clientId ?? ''} onChange={onUpdateDatasourceJsonDataOption(props, 'clientId')} />
+          </InlineField>
+          <InlineField label="Client secret" labelWidth={labelWidth}>
+            <SecretInput
+              width={40}
+              isConfigured={Boolean(secureJsonFields?.clientSecret)}
+              value={secureJsonData?.clientSecret ?? ''}
+              onChange={onUpdateDatasourceSecureJsonDataOption(props, 'clientSecret')}
+              onReset={() => updateDatasourcePluginResetOption(props, 'clientSecret')}
+            />
+          </InlineField>
+          <InlineField label="Scopes" labelWidth={labelWidth} tooltip="Comma separated list of scopes">
+            <Input
+              width={40}
+              defaultValue={(jsonData.scopes ?? []).join(', ')}
+              onBlur={(e) =>
+                onOptionsChange({
+                  ...options,
+                  jsonData: {
+                    ...jsonData,
+                    scopes: e.currentTarget.value
+                      .split(',')
+                      .map((s) => s.trim())
+                      .filter(Boolean),
+                  },
+                })
+              }
+            />
+          </InlineField>
+        </>
+      )}
+    </>
+  );
+};
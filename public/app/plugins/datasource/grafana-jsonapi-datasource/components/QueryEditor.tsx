@@ -0,0 +1,165 @@
+import { QueryEditorProps, SelectableValue } from '@grafana/data';
+import { Button, InlineField, InlineFieldRow, Input, Select, TextArea } from '@grafana/ui';
+
+import { JSONAPIDatasource } from '../datasource';
+import { FieldDefinition, FieldType, JSONAPIOptions, JSONAPIQuery, PaginationMode, SelectorLanguage } from '../types';
+
+type Props = QueryEditorProps<JSONAPIDatasource, JSONAPIQuery, JSONAPIOptions>;
+
+const methods: Array<SelectableValue<'GET' | 'POST'>> = [
+  { label: 'GET', value: 'GET' },
+  { label: 'POST', value: 'POST' },
+];
+
+const languages: Array<SelectableValue<SelectorLanguage>> = [
+  { label: 'JSONPath', value: 'jsonpath' },
+  { label: 'JMESPath', value: 'jmespath' },
+];
+
+const fieldTypes: Array<SelectableValue<FieldType>> = [
+  { label: 'Auto', value: undefined },
+  { label: 'String', value: 'string' },
+  { label: 'Number', value: 'number' },
+  { label: 'Boolean', value: 'boolean' },
+  { label: 'Time', value: 'time' },
+  { label: 'JSON', value: 'json' },
+];
+
+const paginationModes: Array<SelectableValue<PaginationMode>> = [
+  { label: 'None', value: 'none' },
+  { label: 'Link header', value: 'link' },
+  { label: 'Cursor', value: 'cursor' },
+];
+
+const labelWidth = 16;
+
+export const QueryEditor = ({ query, onChange, onRunQuery }: Props) => {
+  const update = (changes: Partial<JSONAPIQuery>) => onChange({ ...query, ...changes });
+  const fields = query.fields ?? [];
+  const pagination = query.pagination ?? { mode: 'none' };
+
+  const updateField = (index: number, changes: Partial<FieldDefinition>) =>
+    update({ fields: fields.map((f, i) => (i === index ? { ...f, ...changes } : f)) });
+
+  return (
+    <>
+      <InlineFieldRow>
+        <InlineField label="Method" labelWidth={labelWidth}>
+          <Select width={12} options={methods} value={query.method ?? 'GET'} onChange={(v) => update({ method: v.value })} />
+        </InlineField>
+        <InlineField label="Path" grow tooltip="Relative to the datasource URL. Supports $__unixEpochFrom() and other time macros">
+          <Input value={query.path ?? ''} onChange={(e) => update({ path: e.currentTarget.value })} onBlur={onRunQuery} />
+        </InlineField>
+      </InlineFieldRow>
+      {query.method === 'POST' && (
+        <InlineField label="Body" labelWidth={labelWidth} grow>
+          <TextArea value={query.body ?? ''} onChange={(e) => update({ body: e.currentTarget.value })} onBlur={onRunQuery} />
+        </InlineField>
+      )}
+      <InlineFieldRow>
+        <InlineField label="Language" labelWidth={labelWidth}>
+          <Select
+            width={16}
+            options={languages}
+            value={query.language ?? 'jsonpath'}
+            onChange={(v) => update({ language: v.value })}
+          />
+        </InlineField>
+        <InlineField label="Rows" grow tooltip="Selects the array of rows in the response">
+          <Input
+            value={query.rowsSelector ?? ''}
+            placeholder="data.items"
+            onChange={(e) => update({ rowsSelector: e.currentTarget.value })}
+            onBlur={onRunQuery}
+          />
+        </InlineField>
+      </InlineFieldRow>
+      {fields.map((field, index) => (
+        <InlineFieldRow key={index}>
+          <InlineField label="Field" labelWidth={labelWidth}>
+            <Input
+              width={24}
+              value={field.selector}
+              placeholder="Selector"
+              onChange={(e) => updateField(index, { selector: e.currentTarget.value })}
+              onBlur={onRunQuery}
+            />
+          </InlineField>
+          <InlineField>
+            <Input
+              width={20}
+              value={field.name ?? ''}
+              placeholder="Name"
+              onChange={(e) => updateField(index, { name: e.currentTarget.value })}
+              onBlur={onRunQuery}
+            />
+          </InlineField>
+          <InlineField>
+            <Select width={12} options={fieldTypes} value={field.type} onChange={(v) => updateField(index, { type: v.value })} />
+          </InlineField>
+          {field.type === 'time' && (
+            <InlineField>
+              <Input
+                width={20}
+                value={field.timeFormat ?? ''}
+                placeholder="unixms"
+                onChange={(e) => updateField(index, { timeFormat: e.currentTarget.value })}
+                onBlur={onRunQuery}
+              />
+            </InlineField>
+          )}
+          <Button
+            variant="secondary"
+            icon="trash-alt"
+            aria-label="Remove field"
+            onClick={() => update({ fields: fields.filter((_, i) => i !== index) })}
+          />
+        </InlineFieldRow>
+      ))}
+      <Button variant="secondary" icon="plus" onClick={() => update({ fields: [...fields, { selector: '' }] })}>
+        Field
+      </Button>
+      <InlineFieldRow>
+        <InlineField label="Pagination" labelWidth={labelWidth}>
+          <Select
+            width={16}
+            options={paginationModes}
+            value={pagination.mode}
+            onChange={(v) => update({ pagination: { ...pagination, mode: v.value ?? 'none' } })}
+          />
+        </InlineField>
+        {pagination.mode === 'cursor' && (
+          <>
+            <InlineField label="Cursor">
+              <Input
+                width={20}
+                value={pagination.cursorSelector ?? ''}
+                placeholder="next"
+                onChange={(e) => update({ pagination: { ...pagination, cursorSelector: e.currentTarget.value } })}
+              />
+            </InlineField>
+            <InlineField label="Parameter">
+              <Input
+                width={16}
+                value={pagination.cursorParam ?? ''}
+                placeholder="after"
+                onChange={(e) => update({ pagination: { ...pagination, cursorParam: e.currentTarget.value } })}
+              />
+            </InlineField>
+          </>
+        )}
+        {pagination.mode !== 'none' && (
+          <InlineField label="Max pages">
+            <Input
+              width={8}
+              type="number"
+              value={pagination.maxPages ?? 10}
+              onChange={(e) => update({ pagination: { ...pagination, maxPages: Number(e.currentTarget.value) } })}
+              onBlur={onRunQuery}
+            />
+          </InlineField>
+        )}
+      </InlineFieldRow>
+    </>
+  );
+};
@@ -0,0 +1,30 @@
+import { DataSourceInstanceSettings, ScopedVars } from '@grafana/data';
+import { DataSourceWithBackend, getTemplateSrv, TemplateSrv } from '@grafana/runtime';
+
+import { JSONAPIOptions, JSONAPIQuery, KeyValue } from './types';
+
+export class JSONAPIDatasource extends DataSourceWithBackend<JSONAPIQuery, JSONAPIOptions> {
+  constructor(
+    instanceSettings: DataSourceInstanceSettings<JSONAPIOptions>,
+    private readonly templateSrv: TemplateSrv = getTemplateSrv()
+  ) {
+    super(instanceSettings);
+  }
+
+  filterQuery(query: JSONAPIQuery): boolean {
+    return !query.hide && !!query.path;
+  }
+
+  applyTemplateVariables(query: JSONAPIQuery, scopedVars: ScopedVars): JSONAPIQuery {
+    const replace = (value?: string) => (value ? this.templateSrv.replace(value, scopedVars) : value);
+    const replaceAll = (values?: KeyValue[]) => values?.map((kv) => ({ key: kv.key, value: replace(kv.value) ?? '' }));
+
+    return {
+      ...query,
+      path: replace(query.path),
+      params: replaceAll(query.params),
+      headers: replaceAll(query.headers),
+      body: replace(query.body),
+    };
+  }
+}
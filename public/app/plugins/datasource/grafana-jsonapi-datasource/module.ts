@@ -0,0 +1,7 @@
+import { DataSourcePlugin } from '@grafana/data';
+
+import { ConfigEditor } from './components/ConfigEditor';
+import { QueryEditor } from './components/QueryEditor';
+import { JSONAPIDatasource } from './datasource';
+
+export const plugin = new DataSourcePlugin(JSONAPIDatasource).setQueryEditor(QueryEditor).setConfigEditor(ConfigEditor);
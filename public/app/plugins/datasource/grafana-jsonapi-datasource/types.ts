@@ -0,0 +1,51 @@
+import { DataQuery, DataSourceJsonData } from '@grafana/data';
+
+export type SelectorLanguage = 'jsonpath' | 'jmespath';
+
+export type FieldType = 'string' | 'number' | 'boolean' | 'time' | 'json';
+
+export type PaginationMode = 'none' | 'link' | 'cursor';
+
+export interface KeyValue {
+  key: string;
+  value: string;
+}
+
+export interface FieldDefinition {
+  name?: string;
+  selector: string;
+  type?: FieldType;
+  // unix, unixms or a Go time layout
+  timeFormat?: string;
+}
+
+export interface Pagination {
+  mode: PaginationMode;
+  cursorSelector?: string;
+  cursorParam?: string;
+  maxPages?: number;
+}
+
+export interface JSONAPIQuery extends DataQuery {
+  method?: 'GET' | 'POST';
+  path?: string;
+  params?: KeyValue[];
+  headers?: KeyValue[];
+  body?: string;
+  language?: SelectorLanguage;
+  rowsSelector?: string;
+  fields?: FieldDefinition[];
+  pagination?: Pagination;
+}
+
+export interface JSONAPIOptions extends DataSourceJsonData {
+  healthCheckPath?: string;
+  authType?: '' | 'oauth2ClientCredentials';
+  tokenUrl?: string;
+  clientId?: string;
+  scopes?: string[];
+}
+
+export interface JSONAPISecureOptions {
+  clientSecret?: string;
+}
@@ -73,6 +73,11 @@ export class JaegerDatasource extends DataSourceWithBackend<JaegerQuery, JaegerJ
     // If all targets are trace ID queries, we can use the backend querying
     const allTargetsTraceIdQuery = options.targets.every((target) => !target.queryType);
     const allTargetsDependencyGraph = options.targets.every((target) => target.queryType === 'dependencyGraph');
+    // Span metrics are only computed in the backend
+    const allTargetsSpanMetrics = options.targets.every((target) => target.queryType === 'spanMetrics');
+    if (allTargetsSpanMetrics) {
+      return super.query(options);
+    }
     // We have not migrated the node graph to the backend
     // If the node graph is disabled, we can use the backend migration
     const nodeGraphDisabled = !this.nodeGraph?.enabled;
@@ -50,9 +50,13 @@ export type JaegerQuery = {
   minDuration?: string;
   maxDuration?: string;
   limit?: number;
+  // metric returned by the spanMetrics query type, all metrics are returned when empty
+  spanMetric?: JaegerSpanMetric;
 } & DataQuery;
 
-export type JaegerQueryType = 'search' | 'upload' | 'dependencyGraph';
+export type JaegerQueryType = 'search' | 'upload' | 'dependencyGraph' | 'spanMetrics';
+
+export type JaegerSpanMetric = 'requests' | 'rate' | 'errors' | 'errorRate' | 'p50' | 'p90' | 'p99';
 
 export type JaegerResponse = {
   data: TraceResponse[];
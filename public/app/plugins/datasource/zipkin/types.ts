@@ -26,9 +26,21 @@ export type ZipkinAnnotation = {
   timestamp: number;
   value: string;
 };
-export type ZipkinQueryType = 'traceID' | 'upload';
+export type ZipkinQueryType = 'traceID' | 'upload' | 'spanMetrics';
+
+export type ZipkinSpanMetric = 'requests' | 'rate' | 'errors' | 'errorRate' | 'p50' | 'p90' | 'p99';
 
 export interface ZipkinQuery extends DataQuery {
   query: string;
   queryType?: ZipkinQueryType;
+  // search parameters used by the spanMetrics query type
+  serviceName?: string;
+  spanName?: string;
+  annotationQuery?: string;
+  // durations in microseconds
+  minDuration?: number;
+  maxDuration?: number;
+  limit?: number;
+  // metric returned by the spanMetrics query type, all metrics are returned when empty
+  spanMetric?: ZipkinSpanMetric;
 }